@@ -359,8 +359,9 @@ popd
 kind load docker-image ${OVN_IMAGE} --name ${KIND_CLUSTER_NAME}
 
 pushd ../dist/yaml
-run_kubectl apply -f k8s.ovn.org_egressfirewalls.yaml
-run_kubectl apply -f k8s.ovn.org_egressips.yaml
+for crd in k8s.ovn.org_*.yaml; do
+  run_kubectl apply -f ${crd}
+done
 run_kubectl apply -f ovn-setup.yaml
 MASTER_NODES=$(kind get nodes --name ${KIND_CLUSTER_NAME} | sort | head -n ${KIND_NUM_MASTER})
 # We want OVN HA not Kubernetes HA
@@ -39,6 +39,10 @@ OVN_HYBRID_OVERLAY_ENABLE=""
 OVN_DISABLE_SNAT_MULTIPLE_GWS=""
 OVN_MULTICAST_ENABLE=""
 OVN_EGRESSIP_ENABLE=
+OVN_EGRESSIP_HEALTHCHECK_PORT=
+OVN_EGRESSIP_INTERFACES=
+OVN_MASTER_FEATURES=
+OVN_BRIDGE_MAPPINGS=
 
 # Parse parameters given as arguments to this script.
 while [ "$1" != "" ]; do
@@ -147,6 +151,18 @@ while [ "$1" != "" ]; do
   --egress-ip-enable)
     OVN_EGRESSIP_ENABLE=$VALUE
     ;;
+  --egress-ip-healthcheck-port)
+    OVN_EGRESSIP_HEALTHCHECK_PORT=$VALUE
+    ;;
+  --egress-ip-interfaces)
+    OVN_EGRESSIP_INTERFACES=$VALUE
+    ;;
+  --master-features)
+    OVN_MASTER_FEATURES=$VALUE
+    ;;
+  --bridge-mappings)
+    OVN_BRIDGE_MAPPINGS=$VALUE
+    ;;
   *)
     echo "WARNING: unknown parameter \"$PARAM\""
     exit 1
@@ -200,6 +216,14 @@ ovn_hybrid_overlay_enable=${OVN_HYBRID_OVERLAY_ENABLE}
 echo "ovn_hybrid_overlay_enable: ${ovn_hybrid_overlay_enable}"
 ovn_egress_ip_enable=${OVN_EGRESSIP_ENABLE}
 echo "ovn_egress_ip_enable: ${ovn_egress_ip_enable}"
+ovn_egress_ip_healthcheck_port=${OVN_EGRESSIP_HEALTHCHECK_PORT:-9107}
+echo "ovn_egress_ip_healthcheck_port: ${ovn_egress_ip_healthcheck_port}"
+ovn_egress_ip_interfaces=${OVN_EGRESSIP_INTERFACES}
+echo "ovn_egress_ip_interfaces: ${ovn_egress_ip_interfaces}"
+ovn_master_features=${OVN_MASTER_FEATURES}
+echo "ovn_master_features: ${ovn_master_features}"
+ovn_bridge_mappings=${OVN_BRIDGE_MAPPINGS}
+echo "ovn_bridge_mappings: ${ovn_bridge_mappings}"
 ovn_hybrid_overlay_net_cidr=${OVN_HYBRID_OVERLAY_NET_CIDR}
 echo "ovn_hybrid_overlay_net_cidr: ${ovn_hybrid_overlay_net_cidr}"
 ovn_disable_snat_multiple_gws=${OVN_DISABLE_SNAT_MULTIPLE_GWS}
@@ -243,6 +267,9 @@ ovn_image=${image} \
   ovn_disable_snat_multiple_gws=${ovn_disable_snat_multiple_gws} \
   ovn_multicast_enable=${ovn_multicast_enable} \
   ovn_egress_ip_enable=${ovn_egress_ip_enable} \
+  ovn_egress_ip_healthcheck_port=${ovn_egress_ip_healthcheck_port} \
+  ovn_egress_ip_interfaces=${ovn_egress_ip_interfaces} \
+  ovn_bridge_mappings=${ovn_bridge_mappings} \
   ovn_ssl_en=${ovn_ssl_en} \
   ovn_remote_probe_interval=${ovn_remote_probe_interval} \
   j2 ../templates/ovnkube-node.yaml.j2 -o ../yaml/ovnkube-node.yaml
@@ -259,7 +286,9 @@ ovn_image=${image} \
   ovn_hybrid_overlay_enable=${ovn_hybrid_overlay_enable} \
   ovn_disable_snat_multiple_gws=${ovn_disable_snat_multiple_gws} \
   ovn_multicast_enable=${ovn_multicast_enable} \
+  ovn_master_features=${ovn_master_features} \
   ovn_egress_ip_enable=${ovn_egress_ip_enable} \
+  ovn_egress_ip_healthcheck_port=${ovn_egress_ip_healthcheck_port} \
   ovn_ssl_en=${ovn_ssl_en} \
   ovn_master_count=${ovn_master_count} \
   ovn_gateway_mode=${ovn_gateway_mode} \
@@ -313,7 +342,9 @@ net_cidr=${net_cidr} svc_cidr=${svc_cidr} \
   j2 ../templates/ovn-setup.yaml.j2 -o ../yaml/ovn-setup.yaml
 
 cp ../templates/ovnkube-monitor.yaml.j2 ../yaml/ovnkube-monitor.yaml
-cp ../templates/k8s.ovn.org_egressfirewalls.yaml.j2 ../yaml/k8s.ovn.org_egressfirewalls.yaml
-cp ../templates/k8s.ovn.org_egressips.yaml.j2 ../yaml/k8s.ovn.org_egressips.yaml
+for crd in ../templates/k8s.ovn.org_*.yaml.j2; do
+  crd_yaml=$(basename ${crd} .j2)
+  cp ${crd} ../yaml/${crd_yaml}
+done
 
 exit 0
@@ -67,6 +67,11 @@ fi
 # OVN_SSL_ENABLE - use SSL transport to NB/SB db and northd (default: no)
 # OVN_REMOTE_PROBE_INTERVAL - ovn remote probe interval in ms (default 100000)
 # OVN_EGRESSIP_ENABLE - enable egress IP for ovn-kubernetes
+# OVN_EGRESSIP_HEALTHCHECK_PORT - port of the egress node health check endpoint, 0 to use the discard port (default 9107)
+# OVN_EGRESSIP_INTERFACES - comma separated host interfaces, besides the primary one, which can host egress IPs
+# OVN_MASTER_FEATURES - comma separated features enabled on ovnkube-master, each one passed as its --enable-<feature> flag
+#   (admin-policy-based-external-routes, admin-network-policy, egress-qos, persistent-ips, multi-network)
+# OVN_BRIDGE_MAPPINGS - comma separated <physical network>:<bridge> mappings of the localnet secondary networks
 # OVN_UNPRIVILEGED_MODE - execute CNI ovs/netns commands from host (default no)
 
 # The argument to the command is the operation to be performed
@@ -183,6 +188,14 @@ ovn_remote_probe_interval=${OVN_REMOTE_PROBE_INTERVAL:-100000}
 ovn_multicast_enable=${OVN_MULTICAST_ENABLE:-}
 #OVN_EGRESSIP_ENABLE - enable egress IP for ovn-kubernetes
 ovn_egressip_enable=${OVN_EGRESSIP_ENABLE:-false}
+#OVN_EGRESSIP_HEALTHCHECK_PORT - port of the egress node health check endpoint, 0 to use the discard port
+ovn_egressip_healthcheck_port=${OVN_EGRESSIP_HEALTHCHECK_PORT:-9107}
+#OVN_EGRESSIP_INTERFACES - comma separated host interfaces, besides the primary one, which can host egress IPs
+ovn_egressip_interfaces=${OVN_EGRESSIP_INTERFACES:-}
+#OVN_MASTER_FEATURES - comma separated features enabled on ovnkube-master
+ovn_master_features=${OVN_MASTER_FEATURES:-}
+#OVN_BRIDGE_MAPPINGS - comma separated <physical network>:<bridge> mappings of the localnet secondary networks
+ovn_bridge_mappings=${OVN_BRIDGE_MAPPINGS:-}
 
 # Determine the ovn rundir.
 if [[ -f /usr/bin/ovn-appctl ]]; then
@@ -847,9 +860,14 @@ ovn-master() {
 
   egressip_enabled_flag=
   if [[ ${ovn_egressip_enable} == "true" ]]; then
-      egressip_enabled_flag="--enable-egress-ip"
+      egressip_enabled_flag="--enable-egress-ip --egressip-node-healthcheck-port=${ovn_egressip_healthcheck_port}"
   fi
 
+  master_features_flags=
+  for feature in ${ovn_master_features//,/ }; do
+      master_features_flags="${master_features_flags} --enable-${feature}"
+  done
+
   ovnkube_master_metrics_bind_address="${metrics_endpoint_ip}:9409"
 
   echo "=============== ovn-master ========== MASTER ONLY"
@@ -870,6 +888,7 @@ ovn-master() {
     ${ovn_master_ssl_opts} \
     ${multicast_enabled_flag} \
     ${egressip_enabled_flag} \
+    ${master_features_flags} \
     --metrics-bind-address ${ovnkube_master_metrics_bind_address} &
   echo "=============== ovn-master ========== running"
   wait_for_event attempts=3 process_ready ovnkube-master
@@ -957,7 +976,15 @@ ovn-node() {
 
   egressip_enabled_flag=
   if [[ ${ovn_egressip_enable} == "true" ]]; then
-      egressip_enabled_flag="--enable-egress-ip"
+      egressip_enabled_flag="--enable-egress-ip --egressip-node-healthcheck-port=${ovn_egressip_healthcheck_port}"
+      if [[ -n "${ovn_egressip_interfaces}" ]]; then
+          egressip_enabled_flag="${egressip_enabled_flag} --egressip-interfaces=${ovn_egressip_interfaces}"
+      fi
+  fi
+
+  bridge_mappings_flag=
+  if [[ -n "${ovn_bridge_mappings}" ]]; then
+      bridge_mappings_flag="--bridge-mappings=${ovn_bridge_mappings}"
   fi
 
   OVN_ENCAP_IP=""
@@ -1013,6 +1040,7 @@ ovn-node() {
     --inactivity-probe=${ovn_remote_probe_interval} \
     ${multicast_enabled_flag} \
     ${egressip_enabled_flag} \
+    ${bridge_mappings_flag} \
     --ovn-metrics-bind-address ${ovn_metrics_bind_address} \
     --metrics-bind-address ${ovnkube_node_metrics_bind_address} &
 
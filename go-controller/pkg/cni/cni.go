@@ -2,6 +2,7 @@ package cni
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -63,6 +64,49 @@ func (pr *PodRequest) String() string {
 	return fmt.Sprintf("[%s/%s %s]", pr.PodNamespace, pr.PodName, pr.SandboxID)
 }
 
+// isSecondaryNetwork returns true if the request is for an OVN-managed secondary
+// network of the pod, defined by a NetworkAttachmentDefinition
+func (pr *PodRequest) isSecondaryNetwork() bool {
+	return pr.CNIConf != nil && pr.CNIConf.Topology != ""
+}
+
+// nadName returns the key of the pod annotation entry of the network of the request
+func (pr *PodRequest) nadName() string {
+	if pr.isSecondaryNetwork() {
+		return pr.CNIConf.NADName
+	}
+	return util.OvnPodDefaultNetwork
+}
+
+// hostInterfaceName returns the name of the host side interface of the pod
+// interface of the request. The default network interface is named after the
+// sandbox; a secondary network interface is named after a hash of the sandbox
+// and the pod interface, as a pod has one per network.
+func (pr *PodRequest) hostInterfaceName() string {
+	if !pr.isSecondaryNetwork() {
+		return pr.SandboxID[:15]
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(pr.SandboxID+"_"+pr.IfName)))[:15]
+}
+
+// ifaceID returns the iface-id of the OVS interface of the pod interface of the
+// request, the name of its logical switch port
+func (pr *PodRequest) ifaceID(namespace, podName string) string {
+	if pr.isSecondaryNetwork() {
+		return util.GetSecondaryNetworkLogicalPortName(pr.CNIConf.Name, pr.CNIConf.NADName, namespace, podName)
+	}
+	return fmt.Sprintf("%s_%s", namespace, podName)
+}
+
+// clearPodBandwidth clears the bandwidth limits of the pod, only applied to its
+// default network interface
+func (pr *PodRequest) clearPodBandwidth() error {
+	if pr.isSecondaryNetwork() {
+		return nil
+	}
+	return clearPodBandwidth(pr.SandboxID)
+}
+
 func (pr *PodRequest) cmdAdd(podLister corev1listers.PodLister) ([]byte, error) {
 	namespace := pr.PodNamespace
 	podName := pr.PodName
@@ -71,19 +115,22 @@ func (pr *PodRequest) cmdAdd(podLister corev1listers.PodLister) ([]byte, error)
 	}
 
 	// Get the IP address and MAC address of the pod
-	annotations, err := getPodAnnotations(pr.ctx, podLister, pr.PodNamespace, pr.PodName)
+	annotations, err := getPodAnnotations(pr.ctx, podLister, pr.PodNamespace, pr.PodName, pr.nadName())
 	if err != nil {
 		return nil, err
 	}
 
-	podInfo, err := util.UnmarshalPodAnnotation(annotations)
+	podInfo, err := util.UnmarshalPodAnnotationForNetwork(annotations, pr.nadName())
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal ovn annotation: %v", err)
 	}
 
-	ingress, egress, err := extractPodBandwidthResources(annotations)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse bandwidth request: %v", err)
+	ingress, egress := int64(-1), int64(-1)
+	if !pr.isSecondaryNetwork() {
+		ingress, egress, err = extractPodBandwidthResources(annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bandwidth request: %v", err)
+		}
 	}
 	podInterfaceInfo := &PodInterfaceInfo{
 		PodAnnotation: *podInfo,
@@ -91,6 +138,9 @@ func (pr *PodRequest) cmdAdd(podLister corev1listers.PodLister) ([]byte, error)
 		Ingress:       ingress,
 		Egress:        egress,
 	}
+	if pr.isSecondaryNetwork() && pr.CNIConf.MTU != 0 {
+		podInterfaceInfo.MTU = pr.CNIConf.MTU
+	}
 	response := &Response{}
 	if !config.UnprivilegedMode {
 		response.Result, err = pr.getCNIResult(podInterfaceInfo)
@@ -180,8 +230,9 @@ func (pr *PodRequest) getCNIResult(podInterfaceInfo *PodInterfaceInfo) (*current
 	}, nil
 }
 
-// getPodAnnotations obtains the pod annotation from the cache
-func getPodAnnotations(ctx context.Context, podLister corev1listers.PodLister, namespace, name string) (map[string]string, error) {
+// getPodAnnotations obtains the pod annotation from the cache, once it has the
+// entry of the network nadName
+func getPodAnnotations(ctx context.Context, podLister corev1listers.PodLister, namespace, name, nadName string) (map[string]string, error) {
 	timeout := time.After(30 * time.Second)
 	for {
 		select {
@@ -195,7 +246,7 @@ func getPodAnnotations(ctx context.Context, podLister corev1listers.PodLister, n
 				return nil, fmt.Errorf("failed to get annotations: %v", err)
 			}
 			annotations := pod.ObjectMeta.Annotations
-			if _, ok := annotations[util.OvnPodAnnotationName]; ok {
+			if _, err := util.UnmarshalPodAnnotationForNetwork(annotations, nadName); !util.IsAnnotationNotSetError(err) {
 				return annotations, nil
 			}
 			// try again later
@@ -137,7 +137,7 @@ func setupNetwork(link netlink.Link, ifInfo *PodInterfaceInfo) error {
 	return nil
 }
 
-func setupInterface(netns ns.NetNS, containerID, ifName string, ifInfo *PodInterfaceInfo) (*current.Interface, *current.Interface, error) {
+func setupInterface(netns ns.NetNS, hostIfaceName, ifName string, ifInfo *PodInterfaceInfo) (*current.Interface, *current.Interface, error) {
 	hostIface := &current.Interface{}
 	contIface := &current.Interface{}
 
@@ -172,7 +172,7 @@ func setupInterface(netns ns.NetNS, containerID, ifName string, ifInfo *PodInter
 	}
 
 	// rename the host end of veth pair
-	hostIface.Name = containerID[:15]
+	hostIface.Name = hostIfaceName
 	if err := renameLink(oldHostVethName, hostIface.Name); err != nil {
 		return nil, nil, fmt.Errorf("failed to rename %s to %s: %v", oldHostVethName, hostIface.Name, err)
 	}
@@ -181,7 +181,7 @@ func setupInterface(netns ns.NetNS, containerID, ifName string, ifInfo *PodInter
 }
 
 // Setup sriov interface in the pod
-func setupSriovInterface(netns ns.NetNS, containerID, ifName string, ifInfo *PodInterfaceInfo, pciAddrs string) (*current.Interface, *current.Interface, error) {
+func setupSriovInterface(netns ns.NetNS, hostIfaceName, ifName string, ifInfo *PodInterfaceInfo, pciAddrs string) (*current.Interface, *current.Interface, error) {
 	hostIface := &current.Interface{}
 	contIface := &current.Interface{}
 
@@ -218,7 +218,7 @@ func setupSriovInterface(netns ns.NetNS, containerID, ifName string, ifInfo *Pod
 	oldHostRepName := rep
 
 	// 5. rename the host VF representor
-	hostIface.Name = containerID[:15]
+	hostIface.Name = hostIfaceName
 	if err = renameLink(oldHostRepName, hostIface.Name); err != nil {
 		return nil, nil, fmt.Errorf("failed to rename %s to %s: %v", oldHostRepName, hostIface.Name, err)
 	}
@@ -288,17 +288,17 @@ func (pr *PodRequest) ConfigureInterface(namespace string, podName string, ifInf
 	klog.V(5).Infof("CNI Conf %v", pr.CNIConf)
 	if pr.CNIConf.DeviceID != "" {
 		// SR-IOV Case
-		hostIface, contIface, err = setupSriovInterface(netns, pr.SandboxID, pr.IfName, ifInfo, pr.CNIConf.DeviceID)
+		hostIface, contIface, err = setupSriovInterface(netns, pr.hostInterfaceName(), pr.IfName, ifInfo, pr.CNIConf.DeviceID)
 
 	} else {
 		// General case
-		hostIface, contIface, err = setupInterface(netns, pr.SandboxID, pr.IfName, ifInfo)
+		hostIface, contIface, err = setupInterface(netns, pr.hostInterfaceName(), pr.IfName, ifInfo)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	ifaceID := fmt.Sprintf("%s_%s", namespace, podName)
+	ifaceID := pr.ifaceID(namespace, podName)
 
 	// Find and remove any existing OVS port with this iface-id. Pods can
 	// have multiple sandboxes if some are waiting for garbage collection,
@@ -329,7 +329,8 @@ func (pr *PodRequest) ConfigureInterface(namespace string, podName string, ifInf
 		return nil, fmt.Errorf("failure in plugging pod interface: %v\n  %q", err, out)
 	}
 
-	if err := clearPodBandwidth(pr.SandboxID); err != nil {
+	// the bandwidth of the pod is only applied to its default network interface
+	if err := pr.clearPodBandwidth(); err != nil {
 		return nil, err
 	}
 
@@ -398,7 +399,7 @@ func (pr *PodRequest) deletePodConntrack() {
 				continue
 			}
 		}
-		err = util.DeleteConntrack(ip.Address.IP.String(), 0, "")
+		_, err = util.DeleteConntrack(ip.Address.IP.String(), 0, "")
 		if err != nil {
 			klog.Errorf("Failed to delete Conntrack Entry for %s: %v", ip.Address.IP.String(), err)
 			continue
@@ -408,7 +409,7 @@ func (pr *PodRequest) deletePodConntrack() {
 
 // PlatformSpecificCleanup deletes the OVS port
 func (pr *PodRequest) PlatformSpecificCleanup() error {
-	ifaceName := pr.SandboxID[:15]
+	ifaceName := pr.hostInterfaceName()
 	ovsArgs := []string{
 		"del-port", "br-int", ifaceName,
 	}
@@ -418,7 +419,7 @@ func (pr *PodRequest) PlatformSpecificCleanup() error {
 		klog.Warningf("Failed to delete OVS port %s: %v\n  %q", ifaceName, err, string(out))
 	}
 
-	_ = clearPodBandwidth(pr.SandboxID)
+	_ = pr.clearPodBandwidth()
 	pr.deletePodConntrack()
 
 	return nil
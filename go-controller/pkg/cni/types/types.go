@@ -23,6 +23,28 @@ type NetConf struct {
 	// LogFileMaxAge represents the maximum number
 	// of days to retain old log files
 	LogFileMaxAge int `json:"logfile-maxage"`
+
+	// The fields below are only set in the NetworkAttachmentDefinitions of the
+	// secondary networks, the network name being the name of the NetConf.
+
+	// Topology of the secondary network, "layer3", "layer2" or "localnet"
+	Topology string `json:"topology,omitempty"`
+	// NADName is the <namespace>/<name> of the NetworkAttachmentDefinition
+	// the configuration is part of
+	NADName string `json:"netAttachDefName,omitempty"`
+	// Subnets of the secondary network, a comma separated list of CIDRs, with
+	// the length of the node subnets (<cidr>/<length>) for the layer3 topology;
+	// optional for the localnet topology, whose pods get no IPs without subnets
+	Subnets string `json:"subnets,omitempty"`
+	// MTU of the interfaces of the pods on the secondary network
+	MTU int `json:"mtu,omitempty"`
+	// PhysicalNetworkName is the name of the physical network of the localnet
+	// topology, mapped to an OVS bridge by the ovn-bridge-mappings of the nodes;
+	// the network name by default
+	PhysicalNetworkName string `json:"physicalNetworkName,omitempty"`
+	// VLANID is the VLAN tag of the traffic of the localnet topology on the
+	// physical network, 0 for untagged traffic
+	VLANID int `json:"vlanID,omitempty"`
 }
 
 // NetworkSelectionElement represents one element of the JSON format
@@ -34,9 +56,18 @@ type NetworkSelectionElement struct {
 	// Namespace contains the optional namespace that the network referenced
 	// by Name exists in
 	Namespace string `json:"namespace,omitempty"`
+	// IPRequest contains an optional requested IP addresses for this
+	// network attachment, with or without prefix length
+	IPRequest []string `json:"ips,omitempty"`
 	// MacRequest contains an optional requested MAC address for this
 	// network attachment
 	MacRequest string `json:"mac,omitempty"`
 	// GatewayRequest contains default route IP address for the pod
 	GatewayRequest []net.IP `json:"default-route,omitempty"`
+	// InterfaceRequest contains an optional requested name for the
+	// network interface
+	InterfaceRequest string `json:"interface,omitempty"`
+	// IPAMClaimReference contains the optional name of the IPAMClaim, in the
+	// namespace of the pod, persisting the IPs of this network attachment
+	IPAMClaimReference string `json:"ipam-claim-reference,omitempty"`
 }
@@ -95,7 +95,12 @@ var (
 	}
 
 	// OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
-	OVNKubernetesFeature OVNKubernetesFeatureConfig
+	OVNKubernetesFeature = OVNKubernetesFeatureConfig{
+		EgressFirewallDNSService:             "kube-system/kube-dns",
+		EgressIPNodeHealthCheckPort:          9107,
+		EgressIPReachabilityInterval:         2,
+		EgressIPReachabilityFailureThreshold: 3,
+	}
 
 	// OvnNorth holds northbound OVN database client and server authentication and location details
 	OvnNorth OvnAuthConfig
@@ -217,7 +222,41 @@ type KubernetesConfig struct {
 
 // OVNKubernetesFeatureConfig holds OVN-Kubernetes feature enhancement config file parameters and command-line overrides
 type OVNKubernetesFeatureConfig struct {
-	EnableEgressIP bool `gcfg:"enable-egress-ip"`
+	EnableEgressIP       bool `gcfg:"enable-egress-ip"`
+	EnableEndpointSlices bool `gcfg:"enable-endpoint-slices"`
+	// EnableEgressFirewallDNSSnooping enables wildcard DNS names in EgressFirewall rules, the
+	// nodes learn the IPs of the names from the responses of the cluster DNS servers
+	EnableEgressFirewallDNSSnooping bool `gcfg:"enable-egress-firewall-dns-snooping"`
+	// EgressFirewallDNSService is the namespace/name of the service of the cluster DNS servers
+	EgressFirewallDNSService string `gcfg:"egress-firewall-dns-service"`
+	// EgressIPNodeHealthCheckPort is the port of the health check endpoint served by the nodes
+	// and probed by the master to decide whether a node can host egress IPs. When 0 the master
+	// instead tries to connect to the discard port of the nodes.
+	EgressIPNodeHealthCheckPort int `gcfg:"egressip-node-healthcheck-port"`
+	// EgressIPReachabilityInterval is the interval in seconds between two probes of an egress node
+	EgressIPReachabilityInterval int `gcfg:"egressip-reachability-interval"`
+	// EgressIPReachabilityFailureThreshold is the number of consecutive failed probes after
+	// which an egress node is considered unreachable
+	EgressIPReachabilityFailureThreshold int `gcfg:"egressip-reachability-failure-threshold"`
+	// EgressIPInterfaces is a comma separated list of host interfaces, besides the primary
+	// one, whose networks can host egress IPs. The traffic of those egress IPs leaves the
+	// node through the interface instead of the gateway bridge.
+	EgressIPInterfaces string `gcfg:"egressip-interfaces"`
+	// EnableAdminPolicyBasedExternalRoutes enables the AdminPolicyBasedExternalRoute CRD
+	// routing the egress traffic of the selected namespaces through external gateways
+	EnableAdminPolicyBasedExternalRoutes bool `gcfg:"enable-admin-policy-based-external-routes"`
+	// EnableAdminNetworkPolicy enables the AdminNetworkPolicy CRD, a tier of cluster-scoped
+	// policies evaluated before the NetworkPolicies of the namespaces
+	EnableAdminNetworkPolicy bool `gcfg:"enable-admin-network-policy"`
+	// EnableEgressQoS enables the EgressQoS CRD, marking and rate limiting the egress
+	// traffic of the selected pods with QoS rules of the node logical switches
+	EnableEgressQoS bool `gcfg:"enable-egress-qos"`
+	// EnableMultiNetwork enables the OVN-managed secondary networks defined by
+	// NetworkAttachmentDefinitions
+	EnableMultiNetwork bool `gcfg:"enable-multi-network"`
+	// EnablePersistentIPs enables the IPAMClaim CRD, pinning the IPs of the pods
+	// referencing an IPAMClaim until the IPAMClaim is deleted
+	EnablePersistentIPs bool `gcfg:"enable-persistent-ips"`
 }
 
 // GatewayMode holds the node gateway mode
@@ -246,6 +285,13 @@ type GatewayConfig struct {
 	NodeportEnable bool `gcfg:"nodeport"`
 	// DisableSNATMultipleGws sets whether to disable SNAT of egress traffic in namespaces annotated with routing-external-gws
 	DisableSNATMultipleGWs bool `gcfg:"disable-snat-multiple-gws"`
+	// RawBridgeMappings holds the unparsed mappings of the physical networks of the
+	// localnet secondary networks to local OVS bridges, as physnet1:br1,physnet2:br2.
+	// Should only be used inside config module.
+	RawBridgeMappings string `gcfg:"bridge-mappings"`
+	// BridgeMappings holds the parsed bridge mappings, by physical network name,
+	// and may be used outside the config module.
+	BridgeMappings map[string]string
 }
 
 // OvnAuthConfig holds client authentication and location details for
@@ -611,6 +657,86 @@ var OVNK8sFeatureFlags = []cli.Flag{
 		Destination: &cliConfig.OVNKubernetesFeature.EnableEgressIP,
 		Value:       OVNKubernetesFeature.EnableEgressIP,
 	},
+	&cli.BoolFlag{
+		Name:        "enable-endpoint-slices",
+		Usage:       "Configure to program service load balancers from EndpointSlices instead of Endpoints.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableEndpointSlices,
+		Value:       OVNKubernetesFeature.EnableEndpointSlices,
+	},
+	&cli.BoolFlag{
+		Name: "enable-egress-firewall-dns-snooping",
+		Usage: "Configure to support wildcard DNS names in EgressFirewall rules by snooping " +
+			"the responses of the cluster DNS servers on the nodes.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping,
+		Value:       OVNKubernetesFeature.EnableEgressFirewallDNSSnooping,
+	},
+	&cli.StringFlag{
+		Name:        "egress-firewall-dns-service",
+		Usage:       "The namespace/name of the service of the cluster DNS servers whose responses are snooped (default: kube-system/kube-dns)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressFirewallDNSService,
+		Value:       OVNKubernetesFeature.EgressFirewallDNSService,
+	},
+	&cli.IntFlag{
+		Name: "egressip-node-healthcheck-port",
+		Usage: "Configure the port of the health check endpoint served by the nodes and probed by the master " +
+			"to decide whether a node can host egress IPs. When 0 the master connects to the discard port of the nodes instead (default: 9107)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPNodeHealthCheckPort,
+		Value:       OVNKubernetesFeature.EgressIPNodeHealthCheckPort,
+	},
+	&cli.IntFlag{
+		Name:        "egressip-reachability-interval",
+		Usage:       "The interval in seconds between two reachability probes of an egress node (default: 2)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPReachabilityInterval,
+		Value:       OVNKubernetesFeature.EgressIPReachabilityInterval,
+	},
+	&cli.IntFlag{
+		Name:        "egressip-reachability-failure-threshold",
+		Usage:       "The number of consecutive failed reachability probes after which an egress node is considered unreachable (default: 3)",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPReachabilityFailureThreshold,
+		Value:       OVNKubernetesFeature.EgressIPReachabilityFailureThreshold,
+	},
+	&cli.StringFlag{
+		Name: "egressip-interfaces",
+		Usage: "A comma separated list of host interfaces, besides the primary one, whose networks can host egress IPs. " +
+			"The traffic of those egress IPs leaves the node through the interface instead of the gateway bridge.",
+		Destination: &cliConfig.OVNKubernetesFeature.EgressIPInterfaces,
+		Value:       OVNKubernetesFeature.EgressIPInterfaces,
+	},
+	&cli.BoolFlag{
+		Name: "enable-admin-policy-based-external-routes",
+		Usage: "Configure to use the AdminPolicyBasedExternalRoute CRD feature with ovn-kubernetes, " +
+			"routing the egress traffic of the selected namespaces through external gateways.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableAdminPolicyBasedExternalRoutes,
+		Value:       OVNKubernetesFeature.EnableAdminPolicyBasedExternalRoutes,
+	},
+	&cli.BoolFlag{
+		Name: "enable-admin-network-policy",
+		Usage: "Configure to use the AdminNetworkPolicy CRD feature with ovn-kubernetes, " +
+			"enforcing cluster-scoped policies before the NetworkPolicies of the namespaces.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableAdminNetworkPolicy,
+		Value:       OVNKubernetesFeature.EnableAdminNetworkPolicy,
+	},
+	&cli.BoolFlag{
+		Name: "enable-egress-qos",
+		Usage: "Configure to use the EgressQoS CRD feature with ovn-kubernetes, " +
+			"setting the DSCP and limiting the bandwidth of the egress traffic of the selected pods.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableEgressQoS,
+		Value:       OVNKubernetesFeature.EnableEgressQoS,
+	},
+	&cli.BoolFlag{
+		Name: "enable-multi-network",
+		Usage: "Configure to create the OVN topologies of the secondary networks defined by " +
+			"NetworkAttachmentDefinitions of type ovn-k8s-cni-overlay, and attach the pods to them.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnableMultiNetwork,
+		Value:       OVNKubernetesFeature.EnableMultiNetwork,
+	},
+	&cli.BoolFlag{
+		Name: "enable-persistent-ips",
+		Usage: "Configure to use the IPAMClaim CRD feature with ovn-kubernetes, " +
+			"keeping the IPs of the pods referencing an IPAMClaim across their recreation.",
+		Destination: &cliConfig.OVNKubernetesFeature.EnablePersistentIPs,
+		Value:       OVNKubernetesFeature.EnablePersistentIPs,
+	},
 }
 
 // K8sFlags capture Kubernetes-related options
@@ -809,6 +935,13 @@ var OVNGatewayFlags = []cli.Flag{
 		Usage:       "Disable SNAT for egress traffic with multiple gateways.",
 		Destination: &cliConfig.Gateway.DisableSNATMultipleGWs,
 	},
+	&cli.StringFlag{
+		Name: "bridge-mappings",
+		Usage: "Comma separated mappings of the physical networks of the localnet " +
+			"secondary networks to local OVS bridges, e.g. physnet1:br1,physnet2:br2. " +
+			"Valid with or without a gateway mode.",
+		Destination: &cliConfig.Gateway.RawBridgeMappings,
+	},
 
 	// Deprecated CLI options
 	&cli.BoolFlag{
@@ -1108,9 +1241,35 @@ func buildGatewayConfig(ctx *cli.Context, cli, file *config) error {
 	if Gateway.Mode != GatewayModeShared && Gateway.VLANID != 0 {
 		return fmt.Errorf("gateway VLAN ID option: %d is supported only in shared gateway mode", Gateway.VLANID)
 	}
+
+	var err error
+	Gateway.BridgeMappings, err = parseBridgeMappings(Gateway.RawBridgeMappings)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// parseBridgeMappings parses comma separated physical network name to OVS bridge
+// mappings, as physnet1:br1,physnet2:br2
+func parseBridgeMappings(rawBridgeMappings string) (map[string]string, error) {
+	if rawBridgeMappings == "" {
+		return nil, nil
+	}
+	bridgeMappings := make(map[string]string)
+	for _, bridgeMapping := range strings.Split(rawBridgeMappings, ",") {
+		m := strings.Split(strings.TrimSpace(bridgeMapping), ":")
+		if len(m) != 2 || m[0] == "" || m[1] == "" {
+			return nil, fmt.Errorf("invalid bridge mapping %q: expect <physical network>:<bridge>", bridgeMapping)
+		}
+		if _, ok := bridgeMappings[m[0]]; ok {
+			return nil, fmt.Errorf("duplicate bridge mapping of physical network %q", m[0])
+		}
+		bridgeMappings[m[0]] = m[1]
+	}
+	return bridgeMappings, nil
+}
+
 func buildOVNKubernetesFeatureConfig(ctx *cli.Context, cli, file *config) error {
 	// Copy config file values over default values
 	if err := overrideFields(&OVNKubernetesFeature, &file.OVNKubernetesFeature, &savedOVNKubernetesFeature); err != nil {
@@ -1120,6 +1279,15 @@ func buildOVNKubernetesFeatureConfig(ctx *cli.Context, cli, file *config) error
 	if err := overrideFields(&OVNKubernetesFeature, &cli.OVNKubernetesFeature, &savedOVNKubernetesFeature); err != nil {
 		return err
 	}
+	if OVNKubernetesFeature.EgressIPNodeHealthCheckPort < 0 || OVNKubernetesFeature.EgressIPNodeHealthCheckPort > 65535 {
+		return fmt.Errorf("invalid egress IP node health check port: %d", OVNKubernetesFeature.EgressIPNodeHealthCheckPort)
+	}
+	if OVNKubernetesFeature.EgressIPReachabilityInterval <= 0 {
+		return fmt.Errorf("invalid egress IP reachability interval: %d", OVNKubernetesFeature.EgressIPReachabilityInterval)
+	}
+	if OVNKubernetesFeature.EgressIPReachabilityFailureThreshold <= 0 {
+		return fmt.Errorf("invalid egress IP reachability failure threshold: %d", OVNKubernetesFeature.EgressIPReachabilityFailureThreshold)
+	}
 	return nil
 }
 
@@ -797,6 +797,35 @@ mode=shared
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("parses the bridge mappings without a gateway mode", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Gateway.BridgeMappings).To(Equal(map[string]string{"physnet-dc": "br-dc", "physnet-lab": "br-lab"}))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-bridge-mappings=physnet-dc:br-dc, physnet-lab:br-lab",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when a bridge mapping is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("invalid bridge mapping \"br-lab\": expect <physical network>:<bridge>"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-bridge-mappings=physnet-dc:br-dc,br-lab",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 	It("overrides config file and defaults with CLI options (multi-master)", func() {
 		kubeconfigFile, err := createTempFile("kubeconfig")
 		Expect(err).NotTo(HaveOccurred())
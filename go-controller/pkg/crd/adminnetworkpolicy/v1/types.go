@@ -0,0 +1,156 @@
+package v1
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +resource:path=adminnetworkpolicy
+// +kubebuilder:resource:shortName=anp,scope=Cluster
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Priority",type="integer",JSONPath=`.spec.priority`
+// AdminNetworkPolicy is a CRD allowing the cluster administrators to allow or
+// deny the traffic of the selected pods. Its rules are evaluated before the
+// NetworkPolicies of the namespaces, which cannot override them.
+type AdminNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of AdminNetworkPolicy.
+	Spec AdminNetworkPolicySpec `json:"spec"`
+}
+
+// AdminNetworkPolicySpec is a desired state description of AdminNetworkPolicy.
+type AdminNetworkPolicySpec struct {
+	// Priority is a value from 0 to 99. The rules of the policies with lower
+	// priority values are evaluated first.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=99
+	Priority int32 `json:"priority"`
+	// Subject defines the pods the policy applies to.
+	Subject AdminNetworkPolicySubject `json:"subject"`
+	// Ingress is the list of rules applied to the traffic received by the
+	// subject pods, evaluated in order.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Ingress []AdminNetworkPolicyIngressRule `json:"ingress,omitempty"`
+	// Egress is the list of rules applied to the traffic sent by the subject
+	// pods, evaluated in order.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Egress []AdminNetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// AdminNetworkPolicySubject selects pods either by namespace or by namespace
+// and pod. Exactly one of its fields must be set.
+type AdminNetworkPolicySubject struct {
+	// Namespaces selects all the pods of the namespaces matching the selector.
+	// +optional
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	// Pods selects the pods matching the pod selector in the namespaces
+	// matching the namespace selector.
+	// +optional
+	Pods *NamespacedPodSelector `json:"pods,omitempty"`
+}
+
+// NamespacedPodSelector selects the pods matching PodSelector in the
+// namespaces matching NamespaceSelector.
+type NamespacedPodSelector struct {
+	// NamespaceSelector selects the namespaces of the pods, an empty
+	// selector selects all the namespaces.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	// PodSelector selects the pods, an empty selector selects all the pods.
+	PodSelector metav1.LabelSelector `json:"podSelector"`
+}
+
+// AdminNetworkPolicyRuleAction is the action applied to the traffic matching a rule.
+type AdminNetworkPolicyRuleAction string
+
+const (
+	// AdminNetworkPolicyRuleActionAllow allows the traffic, regardless of the
+	// NetworkPolicies of the namespaces.
+	AdminNetworkPolicyRuleActionAllow AdminNetworkPolicyRuleAction = "Allow"
+	// AdminNetworkPolicyRuleActionDeny drops the traffic, regardless of the
+	// NetworkPolicies of the namespaces.
+	AdminNetworkPolicyRuleActionDeny AdminNetworkPolicyRuleAction = "Deny"
+	// AdminNetworkPolicyRuleActionPass skips the remaining AdminNetworkPolicy
+	// rules, leaving the NetworkPolicies of the namespaces to decide.
+	AdminNetworkPolicyRuleActionPass AdminNetworkPolicyRuleAction = "Pass"
+)
+
+// AdminNetworkPolicyIngressRule matches the traffic received by the subject
+// pods from the peers.
+type AdminNetworkPolicyIngressRule struct {
+	// Name is an optional identifier of the rule.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Action is the action applied to the traffic matching the rule.
+	// +kubebuilder:validation:Enum=Allow;Deny;Pass
+	Action AdminNetworkPolicyRuleAction `json:"action"`
+	// From is the list of peers the traffic is received from.
+	// +kubebuilder:validation:MinItems=1
+	From []AdminNetworkPolicyPeer `json:"from"`
+	// Ports restricts the rule to the traffic destined to the given ports. The
+	// rule matches all the ports when it is empty.
+	// +optional
+	Ports []AdminNetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// AdminNetworkPolicyEgressRule matches the traffic sent by the subject pods
+// to the peers.
+type AdminNetworkPolicyEgressRule struct {
+	// Name is an optional identifier of the rule.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Action is the action applied to the traffic matching the rule.
+	// +kubebuilder:validation:Enum=Allow;Deny;Pass
+	Action AdminNetworkPolicyRuleAction `json:"action"`
+	// To is the list of peers the traffic is sent to.
+	// +kubebuilder:validation:MinItems=1
+	To []AdminNetworkPolicyPeer `json:"to"`
+	// Ports restricts the rule to the traffic destined to the given ports. The
+	// rule matches all the ports when it is empty.
+	// +optional
+	Ports []AdminNetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// AdminNetworkPolicyPeer selects pods either by namespace or by namespace and
+// pod. Exactly one of its fields must be set.
+type AdminNetworkPolicyPeer struct {
+	// Namespaces selects all the pods of the namespaces matching the selector.
+	// +optional
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	// Pods selects the pods matching the pod selector in the namespaces
+	// matching the namespace selector.
+	// +optional
+	Pods *NamespacedPodSelector `json:"pods,omitempty"`
+}
+
+// AdminNetworkPolicyPort is a port, or a range of ports, of a protocol.
+type AdminNetworkPolicyPort struct {
+	// Protocol is the protocol of the port, TCP when not set.
+	// +optional
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	Protocol kapi.Protocol `json:"protocol,omitempty"`
+	// Port is the port number. All the ports of the protocol are matched when
+	// it is not set.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// EndPort is the last port of the range starting at Port, if any.
+	// +optional
+	EndPort int32 `json:"endPort,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=adminnetworkpolicy
+// AdminNetworkPolicyList contains a list of AdminNetworkPolicies
+type AdminNetworkPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of AdminNetworkPolicy.
+	Items []AdminNetworkPolicy `json:"items"`
+}
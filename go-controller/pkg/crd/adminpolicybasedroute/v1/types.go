@@ -0,0 +1,120 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +resource:path=adminpolicybasedexternalroute
+// +kubebuilder:resource:shortName=apbexternalroute,scope=Cluster
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Last Update",type="date",JSONPath=`.status.lastTransitionTime`
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=`.status.status`
+// AdminPolicyBasedExternalRoute is a CRD allowing the cluster administrators to
+// route the egress traffic of the pods of the selected namespaces through
+// external gateways, given as static IPs or as the IPs of selected pods.
+type AdminPolicyBasedExternalRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of AdminPolicyBasedExternalRoute.
+	Spec AdminPolicyBasedExternalRouteSpec `json:"spec"`
+	// Observed status of AdminPolicyBasedExternalRoute. Read-only.
+	// +optional
+	Status AdminPolicyBasedRouteStatus `json:"status,omitempty"`
+}
+
+// AdminPolicyBasedExternalRouteSpec is a desired state description of AdminPolicyBasedExternalRoute.
+type AdminPolicyBasedExternalRouteSpec struct {
+	// From defines the selectors that will determine the target namespaces to this CR.
+	From ExternalNetworkSource `json:"from"`
+	// NextHops defines two types of hops: Static and Dynamic. Each hop defines at least one external gateway IP.
+	NextHops ExternalNextHops `json:"nextHops"`
+}
+
+// ExternalNetworkSource contains the selectors used to determine the namespaces
+// where the policy will be applied to
+type ExternalNetworkSource struct {
+	// NamespaceSelector defines a selector to be used to determine which namespaces will be targeted by this CR.
+	// This field is mandatory.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+}
+
+// ExternalNextHops contains slices of StaticHops and DynamicHops structures. At least one of them must be set.
+type ExternalNextHops struct {
+	// StaticHops defines a slice of StaticHop. This field is optional.
+	// +optional
+	StaticHops []*StaticHop `json:"static,omitempty"`
+	// DynamicHops defines a slice of DynamicHop. This field is optional.
+	// +optional
+	DynamicHops []*DynamicHop `json:"dynamic,omitempty"`
+}
+
+// StaticHop defines the configuration of a static IP that acts as an external gateway.
+type StaticHop struct {
+	// IP defines the static IP to be used for egress traffic. The IP can be either IPv4 or IPv6.
+	IP string `json:"ip"`
+}
+
+// DynamicHop defines the configuration for a dynamic external gateway, whose IPs
+// are those of the pods matching the selectors.
+type DynamicHop struct {
+	// PodSelector defines the selector to filter the pods that are external gateways.
+	// This field is mandatory.
+	PodSelector metav1.LabelSelector `json:"podSelector"`
+	// NamespaceSelector defines a selector to filter the namespaces where the pod gateways are located.
+	// This field is optional, and in case it is not set the pods are selected in all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// NetworkAttachmentName determines the multus network name to use when retrieving the pod IPs
+	// that will be used as the gateway IP. When this field is empty, the pods must be host
+	// networked and their IPs are used.
+	// +optional
+	NetworkAttachmentName string `json:"networkAttachmentName,omitempty"`
+}
+
+// StatusType defines the types of status used in the Status field.
+type StatusType string
+
+const (
+	// SuccessStatus is set when all the next hops of the policy are programmed
+	SuccessStatus StatusType = "Success"
+	// FailStatus is set when some of the next hops of the policy could not be programmed
+	FailStatus StatusType = "Fail"
+)
+
+// AdminPolicyBasedRouteStatus contains the observed status of the AdminPolicyBased route types.
+type AdminPolicyBasedRouteStatus struct {
+	// Captures the time when the last change was applied.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// An array of Human-readable messages indicating details about the status of the object.
+	// +optional
+	Messages []string `json:"messages,omitempty"`
+	// A concise indication of whether the AdminPolicyBasedRoute resource is applied with success
+	Status StatusType `json:"status"`
+	// Gateways lists, for each namespace targeted by the policy, the external gateway IPs
+	// programmed for its pods.
+	// +optional
+	Gateways []AdminPolicyBasedRouteGateways `json:"gateways,omitempty"`
+}
+
+// AdminPolicyBasedRouteGateways contains the external gateway IPs programmed for the pods of a namespace.
+type AdminPolicyBasedRouteGateways struct {
+	// Namespace is the name of the namespace targeted by the policy.
+	Namespace string `json:"namespace"`
+	// IPs are the external gateway IPs programmed for the pods of the namespace.
+	IPs []string `json:"ips"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=adminpolicybasedexternalroute
+// AdminPolicyBasedExternalRouteList contains a list of AdminPolicyBasedExternalRoutes
+type AdminPolicyBasedExternalRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of AdminPolicyBasedExternalRoute.
+	Items []AdminPolicyBasedExternalRoute `json:"items"`
+}
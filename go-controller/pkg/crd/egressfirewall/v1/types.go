@@ -17,11 +17,13 @@ const (
 // +resource:path=egressfirewall
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:printcolumn:name="EgressFirewall Status",type=string,JSONPath=".status.status"
+// +kubebuilder:printcolumn:name="Selected Pods",type=integer,JSONPath=".status.selectedPods"
 // EgressFirewall describes the current egress firewall for a Namespace.
 // Traffic from a pod to an IP address outside the cluster will be checked against
 // each EgressFirewallRule in the pod's namespace's EgressFirewall, in
 // order. If no rule matches (or no EgressFirewall is present) then the traffic
-// will be allowed by default.
+// will be allowed by default. When a podSelector is set, only the selected pods
+// of the namespace are subject to the rules.
 type EgressFirewall struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -33,14 +35,81 @@ type EgressFirewall struct {
 	Status EgressFirewallStatus `json:"status,omitempty"`
 }
 
+// Condition types of an EgressFirewall
+const (
+	// EgressFirewallConditionReady is true when the egress firewall is applied
+	EgressFirewallConditionReady = "Ready"
+	// EgressFirewallConditionDegraded is true when some of the rules of the egress
+	// firewall are not in effect
+	EgressFirewallConditionDegraded = "Degraded"
+)
+
+// EgressFirewallRuleStatusType indicates whether a rule is in effect
+type EgressFirewallRuleStatusType string
+
+const (
+	EgressFirewallRuleApplied EgressFirewallRuleStatusType = "Applied"
+	EgressFirewallRuleFailed  EgressFirewallRuleStatusType = "Failed"
+)
+
+// Reasons for which an EgressFirewall rule is not in effect
+const (
+	// EgressFirewallRuleReasonInvalidCIDR is used when the cidrSelector of the rule cannot be parsed
+	EgressFirewallRuleReasonInvalidCIDR = "InvalidCIDR"
+	// EgressFirewallRuleReasonDNSResolutionFailed is used when the dnsName of the rule cannot be resolved
+	EgressFirewallRuleReasonDNSResolutionFailed = "DNSResolutionFailed"
+	// EgressFirewallRuleReasonOVNTransactionFailed is used when the rule could not be written to OVN
+	EgressFirewallRuleReasonOVNTransactionFailed = "OVNTransactionFailed"
+	// EgressFirewallRuleReasonTooManyRules is used for the rules past the maximum number of rules
+	EgressFirewallRuleReasonTooManyRules = "TooManyRules"
+	// EgressFirewallRuleReasonNotApplied is used for the rules that were not applied because
+	// of the failure of another rule or of the egress firewall itself
+	EgressFirewallRuleReasonNotApplied = "NotApplied"
+)
+
 type EgressFirewallStatus struct {
 	Status string `json:"status,omitempty"`
+	// selectedPods is the number of pods the egress firewall rules currently apply to
+	// +optional
+	SelectedPods int32 `json:"selectedPods,omitempty"`
+	// conditions describe the state of the egress firewall, Ready and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// rules holds the status of each rule of the egress firewall, in order
+	// +optional
+	Rules []EgressFirewallRuleStatus `json:"rules,omitempty"`
+}
+
+// EgressFirewallRuleStatus is the status of a single egressfirewall rule object
+type EgressFirewallRuleStatus struct {
+	// index of the rule in the egress array of the spec
+	Index int32 `json:"index"`
+	// status is "Applied" when the rule is in effect and "Failed" otherwise
+	Status EgressFirewallRuleStatusType `json:"status"`
+	// reason is a CamelCase reason for which the rule is not in effect
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// message is a human readable description of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// resolvedIPs are the IPs the dnsName of the rule last resolved to
+	// +optional
+	ResolvedIPs []string `json:"resolvedIPs,omitempty"`
+	// lastResolved is the time the dnsName of the rule was last resolved
+	// +optional
+	LastResolved *metav1.Time `json:"lastResolved,omitempty"`
 }
 
 // EgressFirewallSpec is a desired state description of EgressFirewall.
 type EgressFirewallSpec struct {
 	// a collection of egress firewall rule objects
 	Egress []EgressFirewallRule `json:"egress"`
+	// podSelector selects the pods of the namespace the rules apply to. If unset,
+	// the rules apply to all the pods of the namespace.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
 }
 
 // EgressFirewallRule is a single egressfirewall rule object
@@ -52,6 +121,9 @@ type EgressFirewallRule struct {
 	Ports []EgressFirewallPort `json:"ports,omitempty"`
 	// to is the target that traffic is allowed/denied to
 	To EgressFirewallDestination `json:"to"`
+	// log enables logging of the traffic matching this rule
+	// +optional
+	Log bool `json:"log,omitempty"`
 }
 
 // EgressFirewallPort specifies the port to allow or deny traffic to
@@ -70,7 +142,9 @@ type EgressFirewallDestination struct {
 	// cidrSelector is the CIDR range to allow/deny traffic to. If this is set, dnsName must be unset.
 	CIDRSelector string `json:"cidrSelector,omitempty"`
 	// dnsName is the domain name to allow/deny traffic to. If this is set, cidrSelector must be unset.
-	// +kubebuilder:validation:Pattern=^([A-Za-z0-9-]+\.)*[A-Za-z0-9-]+\.?$
+	// A wildcard name such as *.example.com matches all the subdomains of the domain and requires
+	// DNS snooping to be enabled on the nodes.
+	// +kubebuilder:validation:Pattern=^(\*\.)?([A-Za-z0-9-]+\.)*[A-Za-z0-9-]+\.?$
 	DNSName string `json:"dnsName,omitempty"`
 }
 
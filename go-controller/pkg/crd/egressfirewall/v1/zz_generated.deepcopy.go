@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,7 +31,7 @@ func (in *EgressFirewall) DeepCopyInto(out *EgressFirewall) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -139,6 +140,31 @@ func (in *EgressFirewallRule) DeepCopy() *EgressFirewallRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressFirewallRuleStatus) DeepCopyInto(out *EgressFirewallRuleStatus) {
+	*out = *in
+	if in.ResolvedIPs != nil {
+		in, out := &in.ResolvedIPs, &out.ResolvedIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastResolved != nil {
+		in, out := &in.LastResolved, &out.LastResolved
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressFirewallRuleStatus.
+func (in *EgressFirewallRuleStatus) DeepCopy() *EgressFirewallRuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressFirewallRuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressFirewallSpec) DeepCopyInto(out *EgressFirewallSpec) {
 	*out = *in
@@ -149,6 +175,11 @@ func (in *EgressFirewallSpec) DeepCopyInto(out *EgressFirewallSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -165,6 +196,20 @@ func (in *EgressFirewallSpec) DeepCopy() *EgressFirewallSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EgressFirewallStatus) DeepCopyInto(out *EgressFirewallStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]EgressFirewallRuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
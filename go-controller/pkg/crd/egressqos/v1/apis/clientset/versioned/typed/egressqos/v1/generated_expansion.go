@@ -16,6 +16,6 @@ limitations under the License.
 
 // Code generated by client-gen. DO NOT EDIT.
 
-package v2alpha1
+package v1
 
-type CronJobExpansion interface{}
+type EgressQoSExpansion interface{}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +resource:path=egressqos
+// +kubebuilder:resource:shortName=eq
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="EgressQoS Status",type=string,JSONPath=".status.status"
+// EgressQoS marks with a DSCP value, and limits the rate of, the egress traffic
+// of the pods of a namespace. Only the EgressQoS named "default" is applied.
+// Each packet is handled by the first rule, in order, matching its source pod
+// and its destination.
+type EgressQoS struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of EgressQoS.
+	Spec EgressQoSSpec `json:"spec"`
+	// Observed status of EgressQoS
+	// +optional
+	Status EgressQoSStatus `json:"status,omitempty"`
+}
+
+// EgressQoSSpec is a desired state description of EgressQoS.
+type EgressQoSSpec struct {
+	// a collection of egress QoS rule objects
+	// +kubebuilder:validation:MaxItems=100
+	Egress []EgressQoSRule `json:"egress"`
+}
+
+// EgressQoSRule is a single egress QoS rule object. At least one of dscp
+// and bandwidth must be set.
+type EgressQoSRule struct {
+	// dscp is the DSCP value the traffic matching the rule is marked with
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=63
+	// +optional
+	DSCP *int32 `json:"dscp,omitempty"`
+	// bandwidth limits the rate of the traffic matching the rule
+	// +optional
+	Bandwidth *EgressQoSBandwidth `json:"bandwidth,omitempty"`
+	// dstCIDR restricts the rule to the traffic destined to the CIDR. If unset,
+	// the rule applies to all the destinations.
+	// +optional
+	DstCIDR *string `json:"dstCIDR,omitempty"`
+	// podSelector selects the pods of the namespace the rule applies to. If
+	// unset, the rule applies to all the pods of the namespace.
+	// +optional
+	PodSelector metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+// EgressQoSBandwidth is the rate limit of the traffic matching a rule, shared by
+// all the pods of the namespace the rule applies to on a node
+type EgressQoSBandwidth struct {
+	// rate is the maximum rate of the traffic, in kbps
+	// +kubebuilder:validation:Minimum:=1
+	Rate int32 `json:"rate"`
+	// burst is the maximum burst size of the traffic, in kilobits
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// EgressQoSStatus is the observed state of an EgressQoS
+type EgressQoSStatus struct {
+	// status reports whether the rules of the EgressQoS are applied
+	// +optional
+	Status string `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=egressqos
+// EgressQoSList is the list of EgressQoSes.
+type EgressQoSList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of EgressQoSes.
+	Items []EgressQoS `json:"items"`
+}
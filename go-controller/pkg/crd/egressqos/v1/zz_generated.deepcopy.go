@@ -18,35 +18,34 @@ limitations under the License.
 
 // Code generated by deepcopy-gen. DO NOT EDIT.
 
-package v2alpha1
+package v1
 
 import (
-	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CronJob) DeepCopyInto(out *CronJob) {
+func (in *EgressQoS) DeepCopyInto(out *EgressQoS) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.Status = in.Status
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJob.
-func (in *CronJob) DeepCopy() *CronJob {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoS.
+func (in *EgressQoS) DeepCopy() *EgressQoS {
 	if in == nil {
 		return nil
 	}
-	out := new(CronJob)
+	out := new(EgressQoS)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CronJob) DeepCopyObject() runtime.Object {
+func (in *EgressQoS) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -54,13 +53,29 @@ func (in *CronJob) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CronJobList) DeepCopyInto(out *CronJobList) {
+func (in *EgressQoSBandwidth) DeepCopyInto(out *EgressQoSBandwidth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoSBandwidth.
+func (in *EgressQoSBandwidth) DeepCopy() *EgressQoSBandwidth {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressQoSBandwidth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressQoSList) DeepCopyInto(out *EgressQoSList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CronJob, len(*in))
+		*out = make([]EgressQoS, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -68,18 +83,18 @@ func (in *CronJobList) DeepCopyInto(out *CronJobList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobList.
-func (in *CronJobList) DeepCopy() *CronJobList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoSList.
+func (in *EgressQoSList) DeepCopy() *EgressQoSList {
 	if in == nil {
 		return nil
 	}
-	out := new(CronJobList)
+	out := new(EgressQoSList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CronJobList) DeepCopyObject() runtime.Object {
+func (in *EgressQoSList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -87,108 +102,72 @@ func (in *CronJobList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CronJobSpec) DeepCopyInto(out *CronJobSpec) {
+func (in *EgressQoSRule) DeepCopyInto(out *EgressQoSRule) {
 	*out = *in
-	if in.StartingDeadlineSeconds != nil {
-		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
-		*out = new(int64)
-		**out = **in
-	}
-	if in.Suspend != nil {
-		in, out := &in.Suspend, &out.Suspend
-		*out = new(bool)
+	if in.DSCP != nil {
+		in, out := &in.DSCP, &out.DSCP
+		*out = new(int32)
 		**out = **in
 	}
-	in.JobTemplate.DeepCopyInto(&out.JobTemplate)
-	if in.SuccessfulJobsHistoryLimit != nil {
-		in, out := &in.SuccessfulJobsHistoryLimit, &out.SuccessfulJobsHistoryLimit
-		*out = new(int32)
+	if in.Bandwidth != nil {
+		in, out := &in.Bandwidth, &out.Bandwidth
+		*out = new(EgressQoSBandwidth)
 		**out = **in
 	}
-	if in.FailedJobsHistoryLimit != nil {
-		in, out := &in.FailedJobsHistoryLimit, &out.FailedJobsHistoryLimit
-		*out = new(int32)
+	if in.DstCIDR != nil {
+		in, out := &in.DstCIDR, &out.DstCIDR
+		*out = new(string)
 		**out = **in
 	}
+	in.PodSelector.DeepCopyInto(&out.PodSelector)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobSpec.
-func (in *CronJobSpec) DeepCopy() *CronJobSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoSRule.
+func (in *EgressQoSRule) DeepCopy() *EgressQoSRule {
 	if in == nil {
 		return nil
 	}
-	out := new(CronJobSpec)
+	out := new(EgressQoSRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CronJobStatus) DeepCopyInto(out *CronJobStatus) {
+func (in *EgressQoSSpec) DeepCopyInto(out *EgressQoSSpec) {
 	*out = *in
-	if in.Active != nil {
-		in, out := &in.Active, &out.Active
-		*out = make([]v1.ObjectReference, len(*in))
-		copy(*out, *in)
-	}
-	if in.LastScheduleTime != nil {
-		in, out := &in.LastScheduleTime, &out.LastScheduleTime
-		*out = (*in).DeepCopy()
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronJobStatus.
-func (in *CronJobStatus) DeepCopy() *CronJobStatus {
-	if in == nil {
-		return nil
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]EgressQoSRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out := new(CronJobStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobTemplate) DeepCopyInto(out *JobTemplate) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Template.DeepCopyInto(&out.Template)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTemplate.
-func (in *JobTemplate) DeepCopy() *JobTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoSSpec.
+func (in *EgressQoSSpec) DeepCopy() *EgressQoSSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(JobTemplate)
+	out := new(EgressQoSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *JobTemplate) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *JobTemplateSpec) DeepCopyInto(out *JobTemplateSpec) {
+func (in *EgressQoSStatus) DeepCopyInto(out *EgressQoSStatus) {
 	*out = *in
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTemplateSpec.
-func (in *JobTemplateSpec) DeepCopy() *JobTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressQoSStatus.
+func (in *EgressQoSStatus) DeepCopy() *EgressQoSStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(JobTemplateSpec)
+	out := new(EgressQoSStatus)
 	in.DeepCopyInto(out)
 	return out
 }
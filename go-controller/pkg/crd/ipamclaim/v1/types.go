@@ -0,0 +1,54 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +resource:path=ipamclaim
+// +kubebuilder:resource:shortName=ipc
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="Network",type=string,JSONPath=".spec.network"
+// +kubebuilder:printcolumn:name="IPs",type=string,JSONPath=".status.ips"
+// IPAMClaim pins the IPs of a pod interface to a stable identity, so that the
+// pods recreated for a workload, like the replicas of a StatefulSet or the pods
+// of a migrated VM, keep their IPs. The pods reference the IPAMClaim of their
+// namespace through the "ipam-claim-reference" of their network selection. The
+// IPs are allocated for the first pod and released when the IPAMClaim is
+// deleted; the IPAMClaim is usually owned by the workload.
+type IPAMClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the desired behavior of IPAMClaim.
+	Spec IPAMClaimSpec `json:"spec"`
+	// Observed status of IPAMClaim
+	// +optional
+	Status IPAMClaimStatus `json:"status,omitempty"`
+}
+
+// IPAMClaimSpec is a desired state description of IPAMClaim.
+type IPAMClaimSpec struct {
+	// network is the name of the layer2 or localnet secondary network the IPs
+	// are claimed on
+	Network string `json:"network"`
+}
+
+// IPAMClaimStatus is the observed state of an IPAMClaim
+type IPAMClaimStatus struct {
+	// ips are the IPs, with their prefix length, allocated for the IPAMClaim
+	// +optional
+	IPs []string `json:"ips,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=ipamclaim
+// IPAMClaimList is the list of IPAMClaims.
+type IPAMClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// List of IPAMClaims.
+	Items []IPAMClaim `json:"items"`
+}
@@ -9,20 +9,45 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	adminnetworkpolicyapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1"
+	adminnetworkpolicyscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/clientset/versioned/scheme"
+	adminnetworkpolicyinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/informers/externalversions"
+	adminnetworkpolicylister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/listers/adminnetworkpolicy/v1"
+	adminpolicybasedrouteapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1"
+	adminpolicybasedroutescheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/clientset/versioned/scheme"
+	adminpolicybasedrouteinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/informers/externalversions"
+	adminpolicybasedroutelister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/listers/adminpolicybasedroute/v1"
+
 	egressfirewallapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
 	egressfirewallclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned"
 	egressfirewallscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned/scheme"
 	egressfirewallinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/informers/externalversions"
+	egressfirewalllister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/listers/egressfirewall/v1"
 
 	egressipapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	egressipscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/scheme"
 	egressipinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/informers/externalversions"
+	egressqosapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1"
+	egressqosscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/clientset/versioned/scheme"
+	egressqosinformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/informers/externalversions"
+	egressqoslister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/listers/egressqos/v1"
+	ipamclaimapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	ipamclaimscheme "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/clientset/versioned/scheme"
+	ipamclaiminformerfactory "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/informers/externalversions"
+	ipamclaimlister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/listers/ipamclaim/v1"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	networkattachmentdefinitionscheme "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/scheme"
+	networkattachmentdefinitioninformerfactory "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/informers/externalversions"
+	networkattachmentdefinitionlister "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/listers/k8s.cni.cncf.io/v1"
+
 	apiextensionsapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsscheme "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/scheme"
 	apiextensionsinformerfactory "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 
 	kapi "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
 	knet "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -30,8 +55,10 @@ import (
 	"k8s.io/apimachinery/pkg/selection"
 	informerfactory "k8s.io/client-go/informers"
 	v1coreinformers "k8s.io/client-go/informers/core/v1"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1beta1"
 	"k8s.io/client-go/kubernetes"
 	listers "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
@@ -42,12 +69,17 @@ type WatchFactory struct {
 	// requirements with atomic accesses
 	handlerCounter uint64
 
-	iFactory    informerfactory.SharedInformerFactory
-	eipFactory  egressipinformerfactory.SharedInformerFactory
-	efFactory   egressfirewallinformerfactory.SharedInformerFactory
-	efClientset egressfirewallclientset.Interface
-	crdFactory  apiextensionsinformerfactory.SharedInformerFactory
-	informers   map[reflect.Type]*informer
+	iFactory         informerfactory.SharedInformerFactory
+	eipFactory       egressipinformerfactory.SharedInformerFactory
+	efFactory        egressfirewallinformerfactory.SharedInformerFactory
+	efClientset      egressfirewallclientset.Interface
+	crdFactory       apiextensionsinformerfactory.SharedInformerFactory
+	apbRouteFactory  adminpolicybasedrouteinformerfactory.SharedInformerFactory
+	anpFactory       adminnetworkpolicyinformerfactory.SharedInformerFactory
+	egressQoSFactory egressqosinformerfactory.SharedInformerFactory
+	nadFactory       networkattachmentdefinitioninformerfactory.SharedInformerFactory
+	ipamClaimFactory ipamclaiminformerfactory.SharedInformerFactory
+	informers        map[reflect.Type]*informer
 
 	stopChan               chan struct{}
 	egressFirewallStopChan chan struct{}
@@ -73,12 +105,18 @@ var (
 	podType            reflect.Type = reflect.TypeOf(&kapi.Pod{})
 	serviceType        reflect.Type = reflect.TypeOf(&kapi.Service{})
 	endpointsType      reflect.Type = reflect.TypeOf(&kapi.Endpoints{})
+	endpointSliceType  reflect.Type = reflect.TypeOf(&discovery.EndpointSlice{})
 	policyType         reflect.Type = reflect.TypeOf(&knet.NetworkPolicy{})
 	namespaceType      reflect.Type = reflect.TypeOf(&kapi.Namespace{})
 	nodeType           reflect.Type = reflect.TypeOf(&kapi.Node{})
 	egressFirewallType reflect.Type = reflect.TypeOf(&egressfirewallapi.EgressFirewall{})
 	crdType            reflect.Type = reflect.TypeOf(&apiextensionsapi.CustomResourceDefinition{})
 	egressIPType       reflect.Type = reflect.TypeOf(&egressipapi.EgressIP{})
+	apbRouteType       reflect.Type = reflect.TypeOf(&adminpolicybasedrouteapi.AdminPolicyBasedExternalRoute{})
+	anpType            reflect.Type = reflect.TypeOf(&adminnetworkpolicyapi.AdminNetworkPolicy{})
+	egressQoSType      reflect.Type = reflect.TypeOf(&egressqosapi.EgressQoS{})
+	nadType            reflect.Type = reflect.TypeOf(&nadapi.NetworkAttachmentDefinition{})
+	ipamClaimType      reflect.Type = reflect.TypeOf(&ipamclaimapi.IPAMClaim{})
 )
 
 // NewMasterWatchFactory initializes a new watch factory for the master or master+node processes.
@@ -107,6 +145,41 @@ func NewMasterWatchFactory(ovnClientset *util.OVNClientset) (*WatchFactory, erro
 	if err != nil {
 		return nil, err
 	}
+	if config.OVNKubernetesFeature.EnableAdminPolicyBasedExternalRoutes {
+		err = adminpolicybasedrouteapi.AddToScheme(adminpolicybasedroutescheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		wf.apbRouteFactory = adminpolicybasedrouteinformerfactory.NewSharedInformerFactory(ovnClientset.APBRouteClient, resyncInterval)
+	}
+	if config.OVNKubernetesFeature.EnableAdminNetworkPolicy {
+		err = adminnetworkpolicyapi.AddToScheme(adminnetworkpolicyscheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		wf.anpFactory = adminnetworkpolicyinformerfactory.NewSharedInformerFactory(ovnClientset.ANPClient, resyncInterval)
+	}
+	if config.OVNKubernetesFeature.EnableEgressQoS {
+		err = egressqosapi.AddToScheme(egressqosscheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		wf.egressQoSFactory = egressqosinformerfactory.NewSharedInformerFactory(ovnClientset.EgressQoSClient, resyncInterval)
+	}
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		err = nadapi.AddToScheme(networkattachmentdefinitionscheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		wf.nadFactory = networkattachmentdefinitioninformerfactory.NewSharedInformerFactory(ovnClientset.NetworkAttchDefClient, resyncInterval)
+	}
+	if config.OVNKubernetesFeature.EnablePersistentIPs {
+		err = ipamclaimapi.AddToScheme(ipamclaimscheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		wf.ipamClaimFactory = ipamclaiminformerfactory.NewSharedInformerFactory(ovnClientset.IPAMClaimClient, resyncInterval)
+	}
 
 	// For Services and Endpoints, pre-populate the shared Informer with one that
 	// has a label selector excluding headless services.
@@ -119,6 +192,19 @@ func NewMasterWatchFactory(ovnClientset *util.OVNClientset) (*WatchFactory, erro
 			noAlternateProxySelector())
 	})
 
+	// The master programs service backends from EndpointSlices when they
+	// are enabled, but Endpoints are always watched: in combined mode the
+	// node shares this factory and still handles Endpoints
+	if config.OVNKubernetesFeature.EnableEndpointSlices {
+		wf.iFactory.InformerFor(&discovery.EndpointSlice{}, func(c kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+			return discoveryinformers.NewFilteredEndpointSliceInformer(
+				c,
+				kapi.NamespaceAll,
+				resyncPeriod,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+				noHeadlessServiceSelector())
+		})
+	}
 	wf.iFactory.InformerFor(&kapi.Endpoints{}, func(c kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
 		return v1coreinformers.NewFilteredEndpointsInformer(
 			c,
@@ -137,6 +223,12 @@ func NewMasterWatchFactory(ovnClientset *util.OVNClientset) (*WatchFactory, erro
 	if err != nil {
 		return nil, err
 	}
+	if config.OVNKubernetesFeature.EnableEndpointSlices {
+		wf.informers[endpointSliceType], err = newInformer(endpointSliceType, wf.iFactory.Discovery().V1beta1().EndpointSlices().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
 	wf.informers[endpointsType], err = newInformer(endpointsType, wf.iFactory.Core().V1().Endpoints().Informer())
 	if err != nil {
 		return nil, err
@@ -182,6 +274,66 @@ func NewMasterWatchFactory(ovnClientset *util.OVNClientset) (*WatchFactory, erro
 			}
 		}
 	}
+	if config.OVNKubernetesFeature.EnableAdminPolicyBasedExternalRoutes {
+		wf.informers[apbRouteType], err = newInformer(apbRouteType, wf.apbRouteFactory.K8s().V1().AdminPolicyBasedExternalRoutes().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.apbRouteFactory.Start(wf.stopChan)
+		for oType, synced := range wf.apbRouteFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+	if config.OVNKubernetesFeature.EnableAdminNetworkPolicy {
+		wf.informers[anpType], err = newInformer(anpType, wf.anpFactory.K8s().V1().AdminNetworkPolicies().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.anpFactory.Start(wf.stopChan)
+		for oType, synced := range wf.anpFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+	if config.OVNKubernetesFeature.EnableEgressQoS {
+		wf.informers[egressQoSType], err = newInformer(egressQoSType, wf.egressQoSFactory.K8s().V1().EgressQoSes().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.egressQoSFactory.Start(wf.stopChan)
+		for oType, synced := range wf.egressQoSFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		wf.informers[nadType], err = newInformer(nadType, wf.nadFactory.K8sCniCncfIo().V1().NetworkAttachmentDefinitions().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.nadFactory.Start(wf.stopChan)
+		for oType, synced := range wf.nadFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+	if config.OVNKubernetesFeature.EnablePersistentIPs {
+		wf.informers[ipamClaimType], err = newInformer(ipamClaimType, wf.ipamClaimFactory.K8s().V1().IPAMClaims().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.ipamClaimFactory.Start(wf.stopChan)
+		for oType, synced := range wf.ipamClaimFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
 	return wf, nil
 }
 
@@ -216,17 +368,23 @@ func NewNodeWatchFactory(ovnClientset *util.OVNClientset, nodeName string) (*Wat
 			noHeadlessServiceSelector())
 	})
 
+	// The node SNATs the traffic of the pods of all the nodes to the egress IPs it hosts on its
+	// secondary egress interfaces, it needs to watch all the pods and namespaces then
+	hostsSecondaryEgressIPs := config.OVNKubernetesFeature.EnableEgressIP && config.OVNKubernetesFeature.EgressIPInterfaces != ""
+
 	// For Pods, only select pods scheduled to this node
-	wf.iFactory.InformerFor(&kapi.Pod{}, func(c kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
-		return v1coreinformers.NewFilteredPodInformer(
-			c,
-			kapi.NamespaceAll,
-			resyncPeriod,
-			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
-			func(opts *metav1.ListOptions) {
-				opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
-			})
-	})
+	if !hostsSecondaryEgressIPs {
+		wf.iFactory.InformerFor(&kapi.Pod{}, func(c kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+			return v1coreinformers.NewFilteredPodInformer(
+				c,
+				kapi.NamespaceAll,
+				resyncPeriod,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+				func(opts *metav1.ListOptions) {
+					opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+				})
+		})
+	}
 
 	var err error
 	wf.informers[podType], err = newQueuedInformer(podType, wf.iFactory.Core().V1().Pods().Informer(), wf.stopChan)
@@ -246,6 +404,12 @@ func NewNodeWatchFactory(ovnClientset *util.OVNClientset, nodeName string) (*Wat
 	if err != nil {
 		return nil, err
 	}
+	if hostsSecondaryEgressIPs {
+		wf.informers[namespaceType], err = newInformer(namespaceType, wf.iFactory.Core().V1().Namespaces().Informer())
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	wf.iFactory.Start(wf.stopChan)
 	for oType, synced := range wf.iFactory.WaitForCacheSync(wf.stopChan) {
@@ -254,6 +418,20 @@ func NewNodeWatchFactory(ovnClientset *util.OVNClientset, nodeName string) (*Wat
 		}
 	}
 
+	// The node only hosts egress IPs itself on its secondary egress interfaces
+	if hostsSecondaryEgressIPs {
+		wf.informers[egressIPType], err = newInformer(egressIPType, wf.eipFactory.K8s().V1().EgressIPs().Informer())
+		if err != nil {
+			return nil, err
+		}
+		wf.eipFactory.Start(wf.stopChan)
+		for oType, synced := range wf.eipFactory.WaitForCacheSync(wf.stopChan) {
+			if !synced {
+				return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			}
+		}
+	}
+
 	return wf, nil
 }
 
@@ -305,6 +483,10 @@ func getObjectMeta(objType reflect.Type, obj interface{}) (*metav1.ObjectMeta, e
 		if endpoints, ok := obj.(*kapi.Endpoints); ok {
 			return &endpoints.ObjectMeta, nil
 		}
+	case endpointSliceType:
+		if endpointSlice, ok := obj.(*discovery.EndpointSlice); ok {
+			return &endpointSlice.ObjectMeta, nil
+		}
 	case policyType:
 		if policy, ok := obj.(*knet.NetworkPolicy); ok {
 			return &policy.ObjectMeta, nil
@@ -325,6 +507,26 @@ func getObjectMeta(objType reflect.Type, obj interface{}) (*metav1.ObjectMeta, e
 		if egressIP, ok := obj.(*egressipapi.EgressIP); ok {
 			return &egressIP.ObjectMeta, nil
 		}
+	case apbRouteType:
+		if apbRoute, ok := obj.(*adminpolicybasedrouteapi.AdminPolicyBasedExternalRoute); ok {
+			return &apbRoute.ObjectMeta, nil
+		}
+	case anpType:
+		if anp, ok := obj.(*adminnetworkpolicyapi.AdminNetworkPolicy); ok {
+			return &anp.ObjectMeta, nil
+		}
+	case egressQoSType:
+		if egressQoS, ok := obj.(*egressqosapi.EgressQoS); ok {
+			return &egressQoS.ObjectMeta, nil
+		}
+	case nadType:
+		if nad, ok := obj.(*nadapi.NetworkAttachmentDefinition); ok {
+			return &nad.ObjectMeta, nil
+		}
+	case ipamClaimType:
+		if ipamClaim, ok := obj.(*ipamclaimapi.IPAMClaim); ok {
+			return &ipamClaim.ObjectMeta, nil
+		}
 	}
 	return nil, fmt.Errorf("cannot get ObjectMeta from type %v", objType)
 }
@@ -419,6 +621,16 @@ func (wf *WatchFactory) RemoveEndpointsHandler(handler *Handler) {
 	wf.removeHandler(endpointsType, handler)
 }
 
+// AddEndpointSliceHandler adds a handler function that will be executed on EndpointSlice object changes
+func (wf *WatchFactory) AddEndpointSliceHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(endpointSliceType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveEndpointSliceHandler removes an EndpointSlice object event handler function
+func (wf *WatchFactory) RemoveEndpointSliceHandler(handler *Handler) {
+	wf.removeHandler(endpointSliceType, handler)
+}
+
 // AddPolicyHandler adds a handler function that will be executed on NetworkPolicy object changes
 func (wf *WatchFactory) AddPolicyHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
 	return wf.addHandler(policyType, "", nil, handlerFuncs, processExisting)
@@ -459,6 +671,57 @@ func (wf *WatchFactory) RemoveEgressIPHandler(handler *Handler) {
 	wf.removeHandler(egressIPType, handler)
 }
 
+// AddAPBRouteHandler adds a handler function that will be executed on AdminPolicyBasedExternalRoute object changes
+func (wf *WatchFactory) AddAPBRouteHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(apbRouteType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveAPBRouteHandler removes an AdminPolicyBasedExternalRoute object event handler function
+func (wf *WatchFactory) RemoveAPBRouteHandler(handler *Handler) {
+	wf.removeHandler(apbRouteType, handler)
+}
+
+// AddAdminNetworkPolicyHandler adds a handler function that will be executed on AdminNetworkPolicy object changes
+func (wf *WatchFactory) AddAdminNetworkPolicyHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(anpType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveAdminNetworkPolicyHandler removes an AdminNetworkPolicy object event handler function
+func (wf *WatchFactory) RemoveAdminNetworkPolicyHandler(handler *Handler) {
+	wf.removeHandler(anpType, handler)
+}
+
+// AddEgressQoSHandler adds a handler function that will be executed on EgressQoS object changes
+func (wf *WatchFactory) AddEgressQoSHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(egressQoSType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveEgressQoSHandler removes an EgressQoS object event handler function
+func (wf *WatchFactory) RemoveEgressQoSHandler(handler *Handler) {
+	wf.removeHandler(egressQoSType, handler)
+}
+
+// AddNetworkAttachmentDefinitionHandler adds a handler function that will be executed on
+// NetworkAttachmentDefinition object changes
+func (wf *WatchFactory) AddNetworkAttachmentDefinitionHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(nadType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveNetworkAttachmentDefinitionHandler removes a NetworkAttachmentDefinition object event handler function
+func (wf *WatchFactory) RemoveNetworkAttachmentDefinitionHandler(handler *Handler) {
+	wf.removeHandler(nadType, handler)
+}
+
+// AddIPAMClaimHandler adds a handler function that will be executed on IPAMClaim object changes
+func (wf *WatchFactory) AddIPAMClaimHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
+	return wf.addHandler(ipamClaimType, "", nil, handlerFuncs, processExisting)
+}
+
+// RemoveIPAMClaimHandler removes an IPAMClaim object event handler function
+func (wf *WatchFactory) RemoveIPAMClaimHandler(handler *Handler) {
+	wf.removeHandler(ipamClaimType, handler)
+}
+
 // AddNamespaceHandler adds a handler function that will be executed on Namespace object changes
 func (wf *WatchFactory) AddNamespaceHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler {
 	return wf.addHandler(namespaceType, "", nil, handlerFuncs, processExisting)
@@ -501,6 +764,17 @@ func (wf *WatchFactory) GetPods(namespace string) ([]*kapi.Pod, error) {
 	return podLister.Pods(namespace).List(labels.Everything())
 }
 
+// GetPodsBySelector returns all the pods in a given namespace matching the label selector,
+// the pods of all the namespaces are matched when namespace is empty
+func (wf *WatchFactory) GetPodsBySelector(namespace string, labelSelector metav1.LabelSelector) ([]*kapi.Pod, error) {
+	podLister := wf.informers[podType].lister.(listers.PodLister)
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return podLister.Pods(namespace).List(selector)
+}
+
 // GetNodes returns the node specs of all the nodes
 func (wf *WatchFactory) GetNodes() ([]*kapi.Node, error) {
 	nodeLister := wf.informers[nodeType].lister.(listers.NodeLister)
@@ -519,6 +793,12 @@ func (wf *WatchFactory) GetService(namespace, name string) (*kapi.Service, error
 	return serviceLister.Services(namespace).Get(name)
 }
 
+// GetServices returns all the services in the cluster
+func (wf *WatchFactory) GetServices() ([]*kapi.Service, error) {
+	serviceLister := wf.informers[serviceType].lister.(listers.ServiceLister)
+	return serviceLister.List(labels.Everything())
+}
+
 // GetEndpoints returns the endpoints list in a given namespace
 func (wf *WatchFactory) GetEndpoints(namespace string) ([]*kapi.Endpoints, error) {
 	endpointsLister := wf.informers[endpointsType].lister.(listers.EndpointsLister)
@@ -531,6 +811,73 @@ func (wf *WatchFactory) GetEndpoint(namespace, name string) (*kapi.Endpoints, er
 	return endpointsLister.Endpoints(namespace).Get(name)
 }
 
+// GetEndpointSlices returns the endpoint slices in a given namespace
+func (wf *WatchFactory) GetEndpointSlices(namespace string) ([]*discovery.EndpointSlice, error) {
+	endpointSliceLister := wf.informers[endpointSliceType].lister.(discoverylisters.EndpointSliceLister)
+	return endpointSliceLister.EndpointSlices(namespace).List(labels.Everything())
+}
+
+// GetServiceEndpointSlices returns the endpoint slices of a service in a given namespace
+func (wf *WatchFactory) GetServiceEndpointSlices(namespace, name string) ([]*discovery.EndpointSlice, error) {
+	endpointSliceLister := wf.informers[endpointSliceType].lister.(discoverylisters.EndpointSliceLister)
+	serviceSelector := labels.SelectorFromSet(labels.Set{discovery.LabelServiceName: name})
+	return endpointSliceLister.EndpointSlices(namespace).List(serviceSelector)
+}
+
+// GetEgressFirewall returns the egress firewall of a given namespace by name
+func (wf *WatchFactory) GetEgressFirewall(namespace, name string) (*egressfirewallapi.EgressFirewall, error) {
+	egressFirewallLister := wf.informers[egressFirewallType].lister.(egressfirewalllister.EgressFirewallLister)
+	return egressFirewallLister.EgressFirewalls(namespace).Get(name)
+}
+
+// GetAdminPolicyBasedExternalRoute returns a specific AdminPolicyBasedExternalRoute
+func (wf *WatchFactory) GetAdminPolicyBasedExternalRoute(name string) (*adminpolicybasedrouteapi.AdminPolicyBasedExternalRoute, error) {
+	apbRouteLister := wf.informers[apbRouteType].lister.(adminpolicybasedroutelister.AdminPolicyBasedExternalRouteLister)
+	return apbRouteLister.Get(name)
+}
+
+// GetAdminPolicyBasedExternalRoutes returns all the AdminPolicyBasedExternalRoutes
+func (wf *WatchFactory) GetAdminPolicyBasedExternalRoutes() ([]*adminpolicybasedrouteapi.AdminPolicyBasedExternalRoute, error) {
+	apbRouteLister := wf.informers[apbRouteType].lister.(adminpolicybasedroutelister.AdminPolicyBasedExternalRouteLister)
+	return apbRouteLister.List(labels.Everything())
+}
+
+// GetAdminNetworkPolicy returns a specific AdminNetworkPolicy
+func (wf *WatchFactory) GetAdminNetworkPolicy(name string) (*adminnetworkpolicyapi.AdminNetworkPolicy, error) {
+	anpLister := wf.informers[anpType].lister.(adminnetworkpolicylister.AdminNetworkPolicyLister)
+	return anpLister.Get(name)
+}
+
+// GetAdminNetworkPolicies returns all the AdminNetworkPolicies
+func (wf *WatchFactory) GetAdminNetworkPolicies() ([]*adminnetworkpolicyapi.AdminNetworkPolicy, error) {
+	anpLister := wf.informers[anpType].lister.(adminnetworkpolicylister.AdminNetworkPolicyLister)
+	return anpLister.List(labels.Everything())
+}
+
+// GetEgressQoS returns a specific EgressQoS of a namespace
+func (wf *WatchFactory) GetEgressQoS(namespace, name string) (*egressqosapi.EgressQoS, error) {
+	egressQoSLister := wf.informers[egressQoSType].lister.(egressqoslister.EgressQoSLister)
+	return egressQoSLister.EgressQoSes(namespace).Get(name)
+}
+
+// GetNetworkAttachmentDefinition returns a specific NetworkAttachmentDefinition of a namespace
+func (wf *WatchFactory) GetNetworkAttachmentDefinition(namespace, name string) (*nadapi.NetworkAttachmentDefinition, error) {
+	nadLister := wf.informers[nadType].lister.(networkattachmentdefinitionlister.NetworkAttachmentDefinitionLister)
+	return nadLister.NetworkAttachmentDefinitions(namespace).Get(name)
+}
+
+// GetIPAMClaim returns a specific IPAMClaim of a namespace
+func (wf *WatchFactory) GetIPAMClaim(namespace, name string) (*ipamclaimapi.IPAMClaim, error) {
+	ipamClaimLister := wf.informers[ipamClaimType].lister.(ipamclaimlister.IPAMClaimLister)
+	return ipamClaimLister.IPAMClaims(namespace).Get(name)
+}
+
+// GetIPAMClaims returns all the IPAMClaims
+func (wf *WatchFactory) GetIPAMClaims() ([]*ipamclaimapi.IPAMClaim, error) {
+	ipamClaimLister := wf.informers[ipamClaimType].lister.(ipamclaimlister.IPAMClaimLister)
+	return ipamClaimLister.List(labels.Everything())
+}
+
 // GetNamespace returns a specific namespace
 func (wf *WatchFactory) GetNamespace(name string) (*kapi.Namespace, error) {
 	namespaceLister := wf.informers[namespaceType].lister.(listers.NamespaceLister)
@@ -543,6 +890,16 @@ func (wf *WatchFactory) GetNamespaces() ([]*kapi.Namespace, error) {
 	return namespaceLister.List(labels.Everything())
 }
 
+// GetNamespacesBySelector returns a list of namespaces in the cluster matching the label selector
+func (wf *WatchFactory) GetNamespacesBySelector(labelSelector metav1.LabelSelector) ([]*kapi.Namespace, error) {
+	namespaceLister := wf.informers[namespaceType].lister.(listers.NamespaceLister)
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return namespaceLister.List(selector)
+}
+
 func (wf *WatchFactory) NodeInformer() cache.SharedIndexInformer {
 	return wf.informers[nodeType].inf
 }
@@ -562,7 +919,7 @@ func (wf *WatchFactory) NamespaceInformer() cache.SharedIndexInformer {
 }
 
 // noHeadlessServiceSelector is a LabelSelector added to the watch for
-// Endpoints (and, eventually, EndpointSlices) that excludes endpoints
+// Endpoints and EndpointSlices that excludes endpoints
 // for headless services.
 // This matches the behavior of kube-proxy
 func noHeadlessServiceSelector() func(options *metav1.ListOptions) {
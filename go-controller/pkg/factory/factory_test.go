@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
 	knet "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -97,6 +98,13 @@ func newEndpoints(name, namespace string) *v1.Endpoints {
 	}
 }
 
+func newEndpointSlice(name, namespace string) *discovery.EndpointSlice {
+	return &discovery.EndpointSlice{
+		ObjectMeta:  newObjectMeta(name, namespace),
+		AddressType: discovery.AddressTypeIPv4,
+	}
+}
+
 func newService(name, namespace string) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -114,7 +122,7 @@ func newEgressFirewall(name, namespace string) *egressfirewall.EgressFirewall {
 	return &egressfirewall.EgressFirewall{
 		ObjectMeta: newObjectMeta(name, namespace),
 		Spec: egressfirewall.EgressFirewallSpec{
-			[]egressfirewall.EgressFirewallRule{
+			Egress: []egressfirewall.EgressFirewallRule{
 				{
 					Type: egressfirewall.EgressFirewallRuleAllow,
 					To: egressfirewall.EgressFirewallDestination{
@@ -206,12 +214,13 @@ var _ = Describe("Watch Factory Operations", func() {
 		podWatch, namespaceWatch, nodeWatch       *watch.FakeWatcher
 		policyWatch, endpointsWatch, serviceWatch *watch.FakeWatcher
 		egressFirewallWatch, crdWatch             *watch.FakeWatcher
-		egressIPWatch                             *watch.FakeWatcher
+		egressIPWatch, endpointSliceWatch         *watch.FakeWatcher
 		pods                                      []*v1.Pod
 		namespaces                                []*v1.Namespace
 		nodes                                     []*v1.Node
 		policies                                  []*knet.NetworkPolicy
 		endpoints                                 []*v1.Endpoints
+		endpointSlices                            []*discovery.EndpointSlice
 		services                                  []*v1.Service
 		egressIPs                                 []*egressip.EgressIP
 		wf                                        *WatchFactory
@@ -283,6 +292,15 @@ var _ = Describe("Watch Factory Operations", func() {
 			return true, obj, nil
 		})
 
+		endpointSlices = make([]*discovery.EndpointSlice, 0)
+		endpointSliceWatch = objSetup(fakeClient, "endpointslices", func(core.Action) (bool, runtime.Object, error) {
+			obj := &discovery.EndpointSliceList{}
+			for _, p := range endpointSlices {
+				obj.Items = append(obj.Items, *p)
+			}
+			return true, obj, nil
+		})
+
 		services = make([]*v1.Service, 0)
 		serviceWatch = objSetup(fakeClient, "services", func(core.Action) (bool, runtime.Object, error) {
 			obj := &v1.ServiceList{}
@@ -368,6 +386,12 @@ var _ = Describe("Watch Factory Operations", func() {
 			testExisting(endpointsType, "", nil)
 		})
 
+		It("is called for each existing endpoint slice", func() {
+			config.OVNKubernetesFeature.EnableEndpointSlices = true
+			endpointSlices = append(endpointSlices, newEndpointSlice("myendpointslice", "default"))
+			testExisting(endpointSliceType, "", nil)
+		})
+
 		It("is called for each existing service", func() {
 			services = append(services, newService("myservice", "default"))
 			testExisting(serviceType, "", nil)
@@ -1009,6 +1033,43 @@ var _ = Describe("Watch Factory Operations", func() {
 		wf.RemoveEndpointsHandler(h)
 	})
 
+	It("responds to endpoint slice add/update/delete events", func() {
+		config.OVNKubernetesFeature.EnableEndpointSlices = true
+		wf, err = NewMasterWatchFactory(ovnClientset)
+		Expect(err).NotTo(HaveOccurred())
+
+		added := newEndpointSlice("myendpointslice", "default")
+		h, c := addHandler(wf, endpointSliceType, cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				slice := obj.(*discovery.EndpointSlice)
+				Expect(reflect.DeepEqual(slice, added)).To(BeTrue())
+			},
+			UpdateFunc: func(old, new interface{}) {
+				newSlice := new.(*discovery.EndpointSlice)
+				Expect(reflect.DeepEqual(newSlice, added)).To(BeTrue())
+				Expect(len(newSlice.Endpoints)).To(Equal(1))
+			},
+			DeleteFunc: func(obj interface{}) {
+				slice := obj.(*discovery.EndpointSlice)
+				Expect(reflect.DeepEqual(slice, added)).To(BeTrue())
+			},
+		})
+
+		endpointSlices = append(endpointSlices, added)
+		endpointSliceWatch.Add(added)
+		Eventually(c.getAdded, 2).Should(Equal(1))
+		added.Endpoints = append(added.Endpoints, discovery.Endpoint{
+			Addresses: []string{"10.128.0.2"},
+		})
+		endpointSliceWatch.Modify(added)
+		Eventually(c.getUpdated, 2).Should(Equal(1))
+		endpointSlices = endpointSlices[:0]
+		endpointSliceWatch.Delete(added)
+		Eventually(c.getDeleted, 2).Should(Equal(1))
+
+		wf.RemoveEndpointSliceHandler(h)
+	})
+
 	It("responds to service add/update/delete events", func() {
 		wf, err = NewMasterWatchFactory(ovnClientset)
 		Expect(err).NotTo(HaveOccurred())
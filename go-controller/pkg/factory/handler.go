@@ -10,12 +10,20 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 
+	adminnetworkpolicylister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/listers/adminnetworkpolicy/v1"
+	adminpolicybasedroutelister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/listers/adminpolicybasedroute/v1"
 	egressfirewalllister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/listers/egressfirewall/v1"
 
 	egressiplister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/listers/egressip/v1"
+	egressqoslister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/listers/egressqos/v1"
+	ipamclaimlister "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/listers/ipamclaim/v1"
+
+	networkattachmentdefinitionlister "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/listers/k8s.cni.cncf.io/v1"
+
 	apiextensionslister "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1beta1"
 
 	listers "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
@@ -289,6 +297,8 @@ func newInformerLister(oType reflect.Type, sharedInformer cache.SharedIndexInfor
 		return listers.NewServiceLister(sharedInformer.GetIndexer()), nil
 	case endpointsType:
 		return listers.NewEndpointsLister(sharedInformer.GetIndexer()), nil
+	case endpointSliceType:
+		return discoverylisters.NewEndpointSliceLister(sharedInformer.GetIndexer()), nil
 	case namespaceType:
 		return listers.NewNamespaceLister(sharedInformer.GetIndexer()), nil
 	case nodeType:
@@ -301,6 +311,16 @@ func newInformerLister(oType reflect.Type, sharedInformer cache.SharedIndexInfor
 		return apiextensionslister.NewCustomResourceDefinitionLister(sharedInformer.GetIndexer()), nil
 	case egressIPType:
 		return egressiplister.NewEgressIPLister(sharedInformer.GetIndexer()), nil
+	case apbRouteType:
+		return adminpolicybasedroutelister.NewAdminPolicyBasedExternalRouteLister(sharedInformer.GetIndexer()), nil
+	case anpType:
+		return adminnetworkpolicylister.NewAdminNetworkPolicyLister(sharedInformer.GetIndexer()), nil
+	case egressQoSType:
+		return egressqoslister.NewEgressQoSLister(sharedInformer.GetIndexer()), nil
+	case nadType:
+		return networkattachmentdefinitionlister.NewNetworkAttachmentDefinitionLister(sharedInformer.GetIndexer()), nil
+	case ipamClaimType:
+		return ipamclaimlister.NewIPAMClaimLister(sharedInformer.GetIndexer()), nil
 	}
 
 	return nil, fmt.Errorf("cannot create lister from type %v", oType)
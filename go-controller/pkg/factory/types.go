@@ -2,6 +2,7 @@ package factory
 
 import (
 	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 )
@@ -30,13 +31,27 @@ type NodeWatchFactory interface {
 
 	AddServiceHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
 	RemoveServiceHandler(handler *Handler)
+	GetService(namespace, name string) (*kapi.Service, error)
 
 	AddEndpointsHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
 	AddFilteredEndpointsHandler(namespace string, sel labels.Selector, handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
 	RemoveEndpointsHandler(handler *Handler)
+	GetEndpoint(namespace, name string) (*kapi.Endpoints, error)
 
 	AddPodHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
 	RemovePodHandler(handler *Handler)
+	GetPodsBySelector(namespace string, labelSelector metav1.LabelSelector) ([]*kapi.Pod, error)
+
+	AddNamespaceHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
+	RemoveNamespaceHandler(handler *Handler)
+	GetNamespacesBySelector(labelSelector metav1.LabelSelector) ([]*kapi.Namespace, error)
+
+	InitializeEgressFirewallWatchFactory() error
+	AddEgressFirewallHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
+	RemoveEgressFirewallHandler(handler *Handler)
+
+	AddEgressIPHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) *Handler
+	RemoveEgressIPHandler(handler *Handler)
 
 	NodeInformer() cache.SharedIndexInformer
 	LocalPodInformer() cache.SharedIndexInformer
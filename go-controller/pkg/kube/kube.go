@@ -7,10 +7,16 @@ import (
 
 	"k8s.io/klog/v2"
 
+	adminpolicybasedroutev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1"
+	adminpolicybasedrouteclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/clientset/versioned"
 	egressfirewall "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
 	egressfirewallclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned"
 	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	egressipclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned"
+	egressqosv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1"
+	egressqosclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/clientset/versioned"
+	ipamclaimv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	ipamclaimclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/clientset/versioned"
 	kapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -27,6 +33,9 @@ type Interface interface {
 	SetAnnotationsOnNamespace(namespace *kapi.Namespace, annotations map[string]string) error
 	UpdateEgressFirewall(egressfirewall *egressfirewall.EgressFirewall) error
 	UpdateEgressIP(eIP *egressipv1.EgressIP) error
+	UpdateAdminPolicyBasedExternalRoute(apbRoute *adminpolicybasedroutev1.AdminPolicyBasedExternalRoute) error
+	UpdateEgressQoS(egressQoS *egressqosv1.EgressQoS) error
+	UpdateIPAMClaimStatus(ipamClaim *ipamclaimv1.IPAMClaim) error
 	UpdateNodeStatus(node *kapi.Node) error
 	GetAnnotationsOnPod(namespace, name string) (map[string]string, error)
 	GetNodes() (*kapi.NodeList, error)
@@ -45,6 +54,9 @@ type Kube struct {
 	KClient              kubernetes.Interface
 	EIPClient            egressipclientset.Interface
 	EgressFirewallClient egressfirewallclientset.Interface
+	APBRouteClient       adminpolicybasedrouteclientset.Interface
+	EgressQoSClient      egressqosclientset.Interface
+	IPAMClaimClient      ipamclaimclientset.Interface
 }
 
 // SetAnnotationsOnPod takes the pod object and map of key/value string pairs to set as annotations
@@ -144,6 +156,33 @@ func (k *Kube) UpdateEgressIP(eIP *egressipv1.EgressIP) error {
 	return nil
 }
 
+// UpdateAdminPolicyBasedExternalRoute updates the AdminPolicyBasedExternalRoute with the provided data
+func (k *Kube) UpdateAdminPolicyBasedExternalRoute(apbRoute *adminpolicybasedroutev1.AdminPolicyBasedExternalRoute) error {
+	klog.Infof("Updating status on AdminPolicyBasedExternalRoute %s", apbRoute.Name)
+	if _, err := k.APBRouteClient.K8sV1().AdminPolicyBasedExternalRoutes().Update(context.TODO(), apbRoute, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error in updating status on AdminPolicyBasedExternalRoute %s: %v", apbRoute.Name, err)
+	}
+	return nil
+}
+
+// UpdateEgressQoS updates the EgressQoS with the provided EgressQoS data
+func (k *Kube) UpdateEgressQoS(egressQoS *egressqosv1.EgressQoS) error {
+	klog.Infof("Updating status on EgressQoS %s in namespace %s", egressQoS.Name, egressQoS.Namespace)
+	if _, err := k.EgressQoSClient.K8sV1().EgressQoSes(egressQoS.Namespace).Update(context.TODO(), egressQoS, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error in updating status on EgressQoS %s/%s: %v", egressQoS.Namespace, egressQoS.Name, err)
+	}
+	return nil
+}
+
+// UpdateIPAMClaimStatus updates the status of the IPAMClaim with the provided IPAMClaim data
+func (k *Kube) UpdateIPAMClaimStatus(ipamClaim *ipamclaimv1.IPAMClaim) error {
+	klog.Infof("Updating status on IPAMClaim %s in namespace %s", ipamClaim.Name, ipamClaim.Namespace)
+	if _, err := k.IPAMClaimClient.K8sV1().IPAMClaims(ipamClaim.Namespace).UpdateStatus(context.TODO(), ipamClaim, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error in updating status on IPAMClaim %s/%s: %v", ipamClaim.Namespace, ipamClaim.Name, err)
+	}
+	return nil
+}
+
 // UpdateNodeStatus takes the node object and sets the provided update status
 func (k *Kube) UpdateNodeStatus(node *kapi.Node) error {
 	klog.Infof("Updating status on node %s", node.Name)
@@ -83,6 +83,26 @@ var MetricMasterLeader = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "Identifies whether the instance of ovnkube-master is a leader(1) or not(0).",
 })
 
+// metricExternalGatewayBFDUp is the state of the BFD sessions monitoring the external
+// gateways of the namespaces annotated with k8s.ovn.org/bfd-enabled
+var metricExternalGatewayBFDUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "external_gateway_bfd_up",
+	Help:      "Identifies whether the BFD session from a gateway router to an external gateway is up(1) or not(0).",
+},
+	[]string{"gateway_router", "next_hop"},
+)
+
+var metricMulticastGroupMember = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "multicast_group_member",
+	Help:      "A metric with a constant '1' value for each pod that joined a multicast group, labeled by the group and the node of the pod.",
+},
+	[]string{"group", "namespace", "pod", "node"},
+)
+
 var registerMasterMetricsOnce sync.Once
 var startE2ETimeStampUpdaterOnce sync.Once
 
@@ -130,6 +150,8 @@ func RegisterMasterMetrics(nbClient, sbClient goovn.Client) {
 		util.MetricOvnCliLatency = metricOvnCliLatency
 		prometheus.MustRegister(MetricResourceUpdateCount)
 		prometheus.MustRegister(MetricResourceUpdateLatency)
+		prometheus.MustRegister(metricExternalGatewayBFDUp)
+		prometheus.MustRegister(metricMulticastGroupMember)
 		prometheus.MustRegister(prometheus.NewGaugeFunc(
 			prometheus.GaugeOpts{
 				Namespace: MetricOvnkubeNamespace,
@@ -204,3 +226,29 @@ func RecordPodCreated(pod *kapi.Pod) {
 		return
 	}
 }
+
+// RecordExternalGatewayBFDStatus records the state of the BFD session from a gateway router
+// to an external gateway
+func RecordExternalGatewayBFDStatus(gatewayRouter, nextHop string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	metricExternalGatewayBFDUp.WithLabelValues(gatewayRouter, nextHop).Set(value)
+}
+
+// DeleteExternalGatewayBFDStatus stops reporting the state of the BFD session from a gateway
+// router to an external gateway
+func DeleteExternalGatewayBFDStatus(gatewayRouter, nextHop string) {
+	metricExternalGatewayBFDUp.DeleteLabelValues(gatewayRouter, nextHop)
+}
+
+// RecordMulticastGroupMember records that a pod joined a multicast group
+func RecordMulticastGroupMember(group, namespace, pod, node string) {
+	metricMulticastGroupMember.WithLabelValues(group, namespace, pod, node).Set(1)
+}
+
+// ResetMulticastGroupMembers forgets all the pods that joined multicast groups
+func ResetMulticastGroupMembers() {
+	metricMulticastGroupMember.Reset()
+}
@@ -34,6 +34,18 @@ var metricOvnNodePortEnabled = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "Specifies if the node port is enabled on this node(1) or not(0).",
 })
 
+// MetricConntrackEntriesFlushed is a prometheus metric that counts the conntrack
+// entries flushed for the removed UDP and SCTP endpoints of services
+var MetricConntrackEntriesFlushed = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "conntrack_entries_flushed_total",
+	Help:      "The number of conntrack entries flushed for the removed endpoints of services.",
+},
+	//labels
+	[]string{"protocol"},
+)
+
 var registerNodeMetricsOnce sync.Once
 
 func RegisterNodeMetrics() {
@@ -42,6 +54,7 @@ func RegisterNodeMetrics() {
 		prometheus.MustRegister(MetricCNIRequestDuration)
 		prometheus.MustRegister(MetricNodeReadyDuration)
 		prometheus.MustRegister(metricOvnNodePortEnabled)
+		prometheus.MustRegister(MetricConntrackEntriesFlushed)
 		prometheus.MustRegister(prometheus.NewGaugeFunc(
 			prometheus.GaugeOpts{
 				Namespace: MetricOvnkubeNamespace,
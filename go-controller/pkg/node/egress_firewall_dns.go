@@ -0,0 +1,486 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressfirewallapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// egressFirewallDNSMinTTL is the minimum time an IP learned for a wildcard DNS name
+	// is kept, so that names answered with very short TTLs do not churn the address sets
+	egressFirewallDNSMinTTL = 30 * time.Second
+	// egressFirewallDNSPruneInterval is the interval at which expired IPs are removed
+	egressFirewallDNSPruneInterval = 5 * time.Second
+)
+
+// dnsAnswer is an IP a DNS response resolved a name to, with its TTL in seconds
+type dnsAnswer struct {
+	ip  net.IP
+	ttl uint32
+}
+
+// egressFirewallDNSSnooper learns the IPs of the wildcard DNS names used in EgressFirewall
+// rules from the responses the cluster DNS server pods running on the node send to the
+// pods of any node, and sets them in the address sets the master created for the node,
+// which the rules of the names match along with the ones of the other nodes. The IPs are
+// forgotten when their TTL expires.
+type egressFirewallDNSSnooper struct {
+	sync.Mutex
+	nodeName     string
+	watchFactory factory.NodeWatchFactory
+
+	// the wildcard DNS names used by each EgressFirewall, by namespace/name
+	wildcards map[string]sets.String
+	// the IPs learned for each wildcard DNS name in use, with the time they expire
+	learned map[string]map[string]time.Time
+	// the stop channels of the captures of the local DNS server pods, by namespace/name
+	captures map[string]chan struct{}
+	// signals that new IPs were learned and must be set in the address sets
+	changed chan struct{}
+
+	// getPodInterface returns the name of the host interface of a pod
+	getPodInterface func(namespace, name string) (string, error)
+	// captureInterface passes the frames received on an interface to handleFrame until
+	// stop is closed
+	captureInterface func(ifName string, stop <-chan struct{}, handleFrame func([]byte)) error
+}
+
+func newEgressFirewallDNSSnooper(nodeName string, wf factory.NodeWatchFactory) *egressFirewallDNSSnooper {
+	return &egressFirewallDNSSnooper{
+		nodeName:         nodeName,
+		watchFactory:     wf,
+		wildcards:        make(map[string]sets.String),
+		learned:          make(map[string]map[string]time.Time),
+		captures:         make(map[string]chan struct{}),
+		changed:          make(chan struct{}, 1),
+		getPodInterface:  getPodInterfaceName,
+		captureInterface: captureFrames,
+	}
+}
+
+// Start watches the EgressFirewalls for their wildcard DNS names and the endpoints of
+// the cluster DNS service for the DNS server pods running on the node, and starts
+// setting the IPs learned from their responses in the address sets of the node
+func (s *egressFirewallDNSSnooper) Start(stopChan chan struct{}) error {
+	serviceNamespace, serviceName, err := cache.SplitMetaNamespaceKey(config.OVNKubernetesFeature.EgressFirewallDNSService)
+	if err != nil || serviceNamespace == "" || serviceName == "" {
+		return fmt.Errorf("invalid egress firewall DNS service %q, it must be namespace/name",
+			config.OVNKubernetesFeature.EgressFirewallDNSService)
+	}
+	if err := s.watchFactory.InitializeEgressFirewallWatchFactory(); err != nil {
+		return fmt.Errorf("failed to watch egress firewalls: %v", err)
+	}
+
+	s.watchFactory.AddEgressFirewallHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ef := obj.(*egressfirewallapi.EgressFirewall)
+			s.setEgressFirewallWildcards(ef.Namespace+"/"+ef.Name, getEgressFirewallWildcards(ef))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			ef := new.(*egressfirewallapi.EgressFirewall)
+			s.setEgressFirewallWildcards(ef.Namespace+"/"+ef.Name, getEgressFirewallWildcards(ef))
+		},
+		DeleteFunc: func(obj interface{}) {
+			ef := obj.(*egressfirewallapi.EgressFirewall)
+			s.setEgressFirewallWildcards(ef.Namespace+"/"+ef.Name, nil)
+		},
+	}, nil)
+
+	s.watchFactory.AddFilteredEndpointsHandler(serviceNamespace, labels.Everything(), cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ep := obj.(*kapi.Endpoints)
+			if ep.Name == serviceName {
+				s.syncDNSServers(ep.Subsets)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			ep := new.(*kapi.Endpoints)
+			if ep.Name == serviceName {
+				s.syncDNSServers(ep.Subsets)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			ep := obj.(*kapi.Endpoints)
+			if ep.Name == serviceName {
+				s.syncDNSServers(nil)
+			}
+		},
+	}, nil)
+
+	go s.run(stopChan)
+	return nil
+}
+
+// getEgressFirewallWildcards returns the normalized wildcard DNS names used in the rules
+// of an EgressFirewall
+func getEgressFirewallWildcards(ef *egressfirewallapi.EgressFirewall) sets.String {
+	wildcards := sets.NewString()
+	for _, rule := range ef.Spec.Egress {
+		if util.IsWildcardDNSName(rule.To.DNSName) {
+			wildcards.Insert(util.NormalizeDNSName(rule.To.DNSName))
+		}
+	}
+	return wildcards
+}
+
+// setEgressFirewallWildcards sets the wildcard DNS names used by an EgressFirewall and
+// forgets the IPs learned for the names no EgressFirewall uses anymore
+func (s *egressFirewallDNSSnooper) setEgressFirewallWildcards(key string, wildcards sets.String) {
+	s.Lock()
+	defer s.Unlock()
+	if wildcards.Len() == 0 {
+		delete(s.wildcards, key)
+	} else {
+		s.wildcards[key] = wildcards
+	}
+	inUse := sets.NewString()
+	for _, efWildcards := range s.wildcards {
+		inUse = inUse.Union(efWildcards)
+	}
+	for wildcard := range s.learned {
+		if !inUse.Has(wildcard) {
+			delete(s.learned, wildcard)
+		}
+	}
+	for _, wildcard := range inUse.UnsortedList() {
+		if _, ok := s.learned[wildcard]; !ok {
+			s.learned[wildcard] = make(map[string]time.Time)
+		}
+	}
+}
+
+// syncDNSServers captures the traffic of the DNS server pods of the endpoints that run
+// on the node, and stops capturing the traffic of the ones that went away
+func (s *egressFirewallDNSSnooper) syncDNSServers(subsets []kapi.EndpointSubset) {
+	servers := sets.NewString()
+	for _, subset := range subsets {
+		for _, address := range subset.Addresses {
+			if address.NodeName == nil || *address.NodeName != s.nodeName ||
+				address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+				continue
+			}
+			servers.Insert(address.TargetRef.Namespace + "/" + address.TargetRef.Name)
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	for server, stop := range s.captures {
+		if !servers.Has(server) {
+			klog.Infof("Stopping the capture of the DNS responses of pod %s", server)
+			close(stop)
+			delete(s.captures, server)
+		}
+	}
+	for _, server := range servers.List() {
+		if _, ok := s.captures[server]; ok {
+			continue
+		}
+		namespace, name, _ := cache.SplitMetaNamespaceKey(server)
+		ifName, err := s.getPodInterface(namespace, name)
+		if err != nil {
+			klog.Errorf("Failed to find the interface of DNS server pod %s: %v", server, err)
+			continue
+		}
+		klog.Infof("Capturing the DNS responses of pod %s on interface %s", server, ifName)
+		stop := make(chan struct{})
+		s.captures[server] = stop
+		go func(server string) {
+			if err := s.captureInterface(ifName, stop, s.handleFrame); err != nil {
+				klog.Errorf("Failed to capture the DNS responses of pod %s: %v", server, err)
+			}
+			// let the next update of the endpoints retry the capture
+			s.Lock()
+			if s.captures[server] == stop {
+				delete(s.captures, server)
+			}
+			s.Unlock()
+		}(server)
+	}
+}
+
+// stopCaptures stops all the captures of the DNS server pods
+func (s *egressFirewallDNSSnooper) stopCaptures() {
+	s.Lock()
+	defer s.Unlock()
+	for server, stop := range s.captures {
+		close(stop)
+		delete(s.captures, server)
+	}
+}
+
+func (s *egressFirewallDNSSnooper) handleFrame(frame []byte) {
+	dnsName, answers, ok := parseDNSResponse(frame)
+	if !ok {
+		return
+	}
+	s.learn(dnsName, answers, time.Now())
+}
+
+// learn records the IPs a DNS name resolved to for the wildcard DNS names it matches
+func (s *egressFirewallDNSSnooper) learn(dnsName string, answers []dnsAnswer, now time.Time) {
+	changed := false
+	s.Lock()
+	for wildcard, ips := range s.learned {
+		if !util.DNSNameMatchesWildcard(dnsName, wildcard) {
+			continue
+		}
+		for _, answer := range answers {
+			ttl := time.Duration(answer.ttl) * time.Second
+			if ttl < egressFirewallDNSMinTTL {
+				ttl = egressFirewallDNSMinTTL
+			}
+			expiry := now.Add(ttl)
+			ip := answer.ip.String()
+			current, ok := ips[ip]
+			if !ok {
+				klog.V(5).Infof("Learned IP %s of %s for wildcard DNS name %s", ip, dnsName, wildcard)
+				changed = true
+			}
+			if !ok || current.Before(expiry) {
+				ips[ip] = expiry
+			}
+		}
+	}
+	s.Unlock()
+	if changed {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// prune forgets the IPs whose TTL expired
+func (s *egressFirewallDNSSnooper) prune(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	for wildcard, ips := range s.learned {
+		for ip, expiry := range ips {
+			if !expiry.After(now) {
+				klog.V(5).Infof("IP %s of wildcard DNS name %s expired", ip, wildcard)
+				delete(ips, ip)
+			}
+		}
+	}
+}
+
+// getLearned returns the sorted IPs learned for each wildcard DNS name in use
+func (s *egressFirewallDNSSnooper) getLearned() map[string][]string {
+	s.Lock()
+	defer s.Unlock()
+	learned := make(map[string][]string)
+	for wildcard, ips := range s.learned {
+		learned[wildcard] = make([]string, 0, len(ips))
+		for ip := range ips {
+			learned[wildcard] = append(learned[wildcard], ip)
+		}
+		sort.Strings(learned[wildcard])
+	}
+	return learned
+}
+
+// run sets the learned IPs of the wildcard DNS names in the address sets of the node each
+// time they change. The address sets are always set once, to clear the IPs a previous run
+// learned, and the ones that failed to be set are retried at the next prune.
+func (s *egressFirewallDNSSnooper) run(stopChan chan struct{}) {
+	ticker := time.NewTicker(egressFirewallDNSPruneInterval)
+	defer ticker.Stop()
+	reported := make(map[string][]string)
+	for {
+		select {
+		case <-ticker.C:
+			s.prune(time.Now())
+		case <-s.changed:
+		case <-stopChan:
+			s.stopCaptures()
+			return
+		}
+		s.report(reported)
+	}
+}
+
+// report sets the learned IPs of the wildcard DNS names whose IPs changed since they were
+// last reported in the address sets of the node
+func (s *egressFirewallDNSSnooper) report(reported map[string][]string) {
+	learned := s.getLearned()
+	for wildcard := range reported {
+		if _, ok := learned[wildcard]; !ok {
+			// the master destroys the address sets of the names no longer in use
+			delete(reported, wildcard)
+		}
+	}
+	for wildcard, ips := range learned {
+		if reportedIPs, ok := reported[wildcard]; ok && reflect.DeepEqual(ips, reportedIPs) {
+			continue
+		}
+		if err := setEgressFirewallDNSAddressSets(wildcard, s.nodeName, ips); err != nil {
+			klog.Errorf("Failed to set the IPs learned for the egress firewall wildcard DNS name %s: %v", wildcard, err)
+			// the address sets may be missing until the master recreates them, empty:
+			// forget what was set so that the IPs are set again once they exist
+			delete(reported, wildcard)
+			continue
+		}
+		reported[wildcard] = ips
+	}
+}
+
+// setEgressFirewallDNSAddressSets sets the IPs learned for a wildcard DNS name in the
+// address sets the master created for the node, one per IP family
+func setEgressFirewallDNSAddressSets(wildcard, nodeName string, ips []string) error {
+	var v4IPs, v6IPs []string
+	for _, ip := range ips {
+		if utilnet.IsIPv6String(ip) {
+			v6IPs = append(v6IPs, `"`+ip+`"`)
+		} else {
+			v4IPs = append(v4IPs, `"`+ip+`"`)
+		}
+	}
+	name := util.GetEgressFirewallDNSAddressSetName(wildcard, nodeName)
+	for _, addressSet := range []struct {
+		enabled bool
+		name    string
+		ips     []string
+	}{
+		{config.IPv4Mode, name + "_v4", v4IPs},
+		{config.IPv6Mode, name + "_v6", v6IPs},
+	} {
+		if !addressSet.enabled {
+			continue
+		}
+		uuid, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find",
+			"address_set", "external-ids:name="+addressSet.name)
+		if err != nil {
+			return fmt.Errorf("failed to find address set %s, stderr: %q (%v)", addressSet.name, stderr, err)
+		}
+		if uuid == "" {
+			return fmt.Errorf("address set %s does not exist yet", addressSet.name)
+		}
+		if len(addressSet.ips) > 0 {
+			_, stderr, err = util.RunOVNNbctl("set", "address_set", uuid, "addresses="+strings.Join(addressSet.ips, " "))
+		} else {
+			_, stderr, err = util.RunOVNNbctl("clear", "address_set", uuid, "addresses")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set the addresses of address set %s, stderr: %q (%v)", addressSet.name, stderr, err)
+		}
+	}
+	return nil
+}
+
+// getPodInterfaceName returns the name of the OVS interface of a pod
+func getPodInterfaceName(namespace, name string) (string, error) {
+	stdout, stderr, err := util.RunOVSVsctl("--no-heading", "--data=bare", "--columns=name", "find",
+		"Interface", fmt.Sprintf("external_ids:iface-id=%s_%s", namespace, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to find the OVS interface of pod %s/%s: stderr: %q, error: %v",
+			namespace, name, stderr, err)
+	}
+	ifName := strings.TrimSpace(stdout)
+	if ifName == "" || strings.Contains(ifName, "\n") {
+		return "", fmt.Errorf("expected one OVS interface for pod %s/%s, found %q", namespace, name, ifName)
+	}
+	return ifName, nil
+}
+
+// parseDNSResponse returns the name asked by a successful DNS response carried in an
+// Ethernet frame over UDP, with the IPv4 and IPv6 addresses it answered. It returns
+// false if the frame does not carry such a response.
+func parseDNSResponse(frame []byte) (string, []dnsAnswer, bool) {
+	const (
+		etherTypeIPv4 = 0x0800
+		etherTypeIPv6 = 0x86dd
+		etherTypeVLAN = 0x8100
+		protocolUDP   = 17
+		dnsPort       = 53
+	)
+	if len(frame) < 14 {
+		return "", nil, false
+	}
+	etherType := binary.BigEndian.Uint16(frame[12:14])
+	packet := frame[14:]
+	if etherType == etherTypeVLAN {
+		if len(packet) < 4 {
+			return "", nil, false
+		}
+		etherType = binary.BigEndian.Uint16(packet[2:4])
+		packet = packet[4:]
+	}
+
+	var udp []byte
+	switch etherType {
+	case etherTypeIPv4:
+		if len(packet) < 20 {
+			return "", nil, false
+		}
+		headerLen := int(packet[0]&0x0f) * 4
+		totalLen := int(binary.BigEndian.Uint16(packet[2:4]))
+		// fragments other than the first one do not carry the UDP header, and
+		// fragmented responses cannot be parsed from a single frame anyway
+		fragmented := binary.BigEndian.Uint16(packet[6:8])&0x3fff != 0
+		if packet[9] != protocolUDP || fragmented || headerLen < 20 || totalLen < headerLen || totalLen > len(packet) {
+			return "", nil, false
+		}
+		udp = packet[headerLen:totalLen]
+	case etherTypeIPv6:
+		if len(packet) < 40 {
+			return "", nil, false
+		}
+		payloadLen := int(binary.BigEndian.Uint16(packet[4:6]))
+		if packet[6] != protocolUDP || 40+payloadLen > len(packet) {
+			return "", nil, false
+		}
+		udp = packet[40 : 40+payloadLen]
+	default:
+		return "", nil, false
+	}
+
+	if len(udp) < 8 || binary.BigEndian.Uint16(udp[0:2]) != dnsPort {
+		return "", nil, false
+	}
+	udpLen := int(binary.BigEndian.Uint16(udp[4:6]))
+	if udpLen < 8 || udpLen > len(udp) {
+		return "", nil, false
+	}
+
+	msg := new(dns.Msg)
+	if err := msg.Unpack(udp[8:udpLen]); err != nil {
+		return "", nil, false
+	}
+	if !msg.Response || msg.Rcode != dns.RcodeSuccess || len(msg.Question) != 1 {
+		return "", nil, false
+	}
+	var answers []dnsAnswer
+	for _, rr := range msg.Answer {
+		switch record := rr.(type) {
+		case *dns.A:
+			answers = append(answers, dnsAnswer{ip: record.A, ttl: record.Hdr.Ttl})
+		case *dns.AAAA:
+			answers = append(answers, dnsAnswer{ip: record.AAAA, ttl: record.Hdr.Ttl})
+		}
+	}
+	if len(answers) == 0 {
+		return "", nil, false
+	}
+	return msg.Question[0].Name, answers, true
+}
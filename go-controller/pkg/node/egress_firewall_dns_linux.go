@@ -0,0 +1,54 @@
+// +build linux
+
+package node
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// captureFrames passes the frames received and sent on an interface to handleFrame
+// until stop is closed
+func captureFrames(ifName string, stop <-chan struct{}, handleFrame func([]byte)) error {
+	intf, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return fmt.Errorf("failed to get interface %s: %v", ifName, err)
+	}
+	protocol := htons(unix.ETH_P_ALL)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(protocol))
+	if err != nil {
+		return fmt.Errorf("failed to open packet socket: %v", err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: protocol, Ifindex: intf.Index}); err != nil {
+		return fmt.Errorf("failed to bind packet socket to interface %s: %v", ifName, err)
+	}
+	// wake up regularly to check whether the capture must stop
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Sec: 1}); err != nil {
+		return fmt.Errorf("failed to set the receive timeout of packet socket: %v", err)
+	}
+
+	buf := make([]byte, 65536)
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			return fmt.Errorf("failed to read from interface %s: %v", ifName, err)
+		}
+		handleFrame(buf[:n])
+	}
+}
+
+// htons converts a short from host to network byte order
+func htons(i uint16) uint16 {
+	return i<<8 | i>>8
+}
@@ -0,0 +1,264 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newDNSResponseFrame returns an Ethernet frame carrying a DNS message over UDP from srcPort
+func newDNSResponseFrame(msg *dns.Msg, srcPort uint16, ipv6 bool) []byte {
+	payload, err := msg.Pack()
+	Expect(err).NotTo(HaveOccurred())
+	udp := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint16(udp[0:2], srcPort)
+	binary.BigEndian.PutUint16(udp[2:4], 40000)
+	binary.BigEndian.PutUint16(udp[4:6], uint16(8+len(payload)))
+	udp = append(udp, payload...)
+
+	frame := make([]byte, 14)
+	if ipv6 {
+		binary.BigEndian.PutUint16(frame[12:14], 0x86dd)
+		ip := make([]byte, 40)
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:6], uint16(len(udp)))
+		ip[6] = 17
+		ip[7] = 64
+		copy(ip[8:24], net.ParseIP("fd00:10:96::a"))
+		copy(ip[24:40], net.ParseIP("fd00:10:244::5"))
+		frame = append(frame, ip...)
+	} else {
+		binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+		ip := make([]byte, 20)
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:4], uint16(20+len(udp)))
+		ip[8] = 64
+		ip[9] = 17
+		copy(ip[12:16], net.ParseIP("10.96.0.10").To4())
+		copy(ip[16:20], net.ParseIP("10.244.0.5").To4())
+		frame = append(frame, ip...)
+	}
+	return append(frame, udp...)
+}
+
+func newDNSResponse(name string, records ...string) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, dns.TypeA)
+	msg.Response = true
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		Expect(err).NotTo(HaveOccurred())
+		msg.Answer = append(msg.Answer, rr)
+	}
+	return msg
+}
+
+var _ = Describe("Node EgressFirewall DNS snooping", func() {
+
+	It("parses the IPs answered by DNS responses", func() {
+		msg := newDNSResponse("www.example.com.",
+			"www.example.com. 300 IN CNAME cdn.example.net.",
+			"cdn.example.net. 60 IN A 93.184.216.34",
+			"cdn.example.net. 60 IN AAAA 2606:2800:220:1:248:1893:25c8:1946")
+		for _, ipv6 := range []bool{false, true} {
+			name, answers, ok := parseDNSResponse(newDNSResponseFrame(msg, 53, ipv6))
+			Expect(ok).To(BeTrue())
+			Expect(name).To(Equal("www.example.com."))
+			Expect(answers).To(HaveLen(2))
+			Expect(answers[0].ip.String()).To(Equal("93.184.216.34"))
+			Expect(answers[0].ttl).To(Equal(uint32(60)))
+			Expect(answers[1].ip.String()).To(Equal("2606:2800:220:1:248:1893:25c8:1946"))
+		}
+	})
+
+	It("ignores the frames that are not successful DNS responses", func() {
+		msg := newDNSResponse("www.example.com.", "www.example.com. 300 IN A 93.184.216.34")
+		_, _, ok := parseDNSResponse(newDNSResponseFrame(msg, 5353, false))
+		Expect(ok).To(BeFalse())
+
+		query := msg.Copy()
+		query.Response = false
+		_, _, ok = parseDNSResponse(newDNSResponseFrame(query, 53, false))
+		Expect(ok).To(BeFalse())
+
+		failed := newDNSResponse("www.example.com.")
+		failed.Rcode = dns.RcodeNameError
+		_, _, ok = parseDNSResponse(newDNSResponseFrame(failed, 53, false))
+		Expect(ok).To(BeFalse())
+
+		frame := newDNSResponseFrame(msg, 53, false)
+		_, _, ok = parseDNSResponse(frame[:len(frame)-10])
+		Expect(ok).To(BeFalse())
+	})
+
+	It("learns the IPs of the wildcard DNS names until they expire", func() {
+		s := newEgressFirewallDNSSnooper("node1", nil)
+		s.setEgressFirewallWildcards("namespace1/default", sets.NewString("*.example.com"))
+		now := time.Now()
+		answers := []dnsAnswer{
+			{ip: net.ParseIP("1.1.1.1"), ttl: 5},
+			{ip: net.ParseIP("2.2.2.2"), ttl: 300},
+		}
+
+		s.learn("www.other.com.", answers, now)
+		Expect(s.getLearned()).To(Equal(map[string][]string{
+			"*.example.com": {},
+		}))
+		Expect(s.changed).NotTo(Receive())
+
+		s.learn("www.Example.com.", answers, now)
+		Expect(s.changed).To(Receive())
+		Expect(s.getLearned()).To(Equal(map[string][]string{
+			"*.example.com": {"1.1.1.1", "2.2.2.2"},
+		}))
+
+		// learning the same IPs again only extends their expiry
+		s.learn("a.b.example.com.", answers[:1], now.Add(20*time.Second))
+		Expect(s.changed).NotTo(Receive())
+
+		// IPs are kept for at least the minimum TTL
+		s.prune(now.Add(egressFirewallDNSMinTTL))
+		Expect(s.getLearned()).To(Equal(map[string][]string{
+			"*.example.com": {"1.1.1.1", "2.2.2.2"},
+		}))
+		s.prune(now.Add(20*time.Second + egressFirewallDNSMinTTL))
+		Expect(s.getLearned()).To(Equal(map[string][]string{
+			"*.example.com": {"2.2.2.2"},
+		}))
+
+		// the IPs are forgotten when no egress firewall uses the name anymore
+		s.setEgressFirewallWildcards("namespace1/default", nil)
+		Expect(s.getLearned()).To(BeEmpty())
+	})
+
+	It("sets the learned IPs in the address sets of the node", func() {
+		config.PrepareTestConfig()
+		config.IPv4Mode = true
+		config.IPv6Mode = true
+		fexec := ovntest.NewFakeExec()
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node1_v4",
+			Output: "uuid-v4",
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 set address_set uuid-v4 addresses=\"1.1.1.1\" \"2.2.2.2\"",
+		})
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node1_v6",
+			Output: "uuid-v6",
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 clear address_set uuid-v6 addresses",
+		})
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node2_v4",
+		})
+		Expect(util.SetExec(fexec)).To(Succeed())
+
+		err := setEgressFirewallDNSAddressSets("*.example.com", "node1", []string{"1.1.1.1", "2.2.2.2"})
+		Expect(err).NotTo(HaveOccurred())
+		// the address sets are created by the master
+		err = setEgressFirewallDNSAddressSets("*.example.com", "node2", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("sets the learned IPs again once the address sets of the node are recreated", func() {
+		config.PrepareTestConfig()
+		config.IPv4Mode = true
+		config.IPv6Mode = false
+		fexec := ovntest.NewFakeExec()
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node1_v4",
+			Output: "uuid-v4",
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 set address_set uuid-v4 addresses=\"1.1.1.1\"",
+		})
+		Expect(util.SetExec(fexec)).To(Succeed())
+
+		s := newEgressFirewallDNSSnooper("node1", nil)
+		s.setEgressFirewallWildcards("namespace1/default", sets.NewString("*.example.com"))
+		now := time.Now()
+		reported := map[string][]string{}
+		s.learn("www.example.com.", []dnsAnswer{{ip: net.ParseIP("1.1.1.1"), ttl: 300}}, now)
+		s.report(reported)
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+
+		// the address set is missing while the IPs change
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node1_v4",
+		})
+		s.learn("www.example.com.", []dnsAnswer{{ip: net.ParseIP("2.2.2.2"), ttl: 5}}, now)
+		s.report(reported)
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+
+		// the IPs are set in the recreated address set even though they are the
+		// ones set in the previous one
+		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set external-ids:name=*.example.com_node1_v4",
+			Output: "uuid-v4-new",
+		})
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 set address_set uuid-v4-new addresses=\"1.1.1.1\"",
+		})
+		s.prune(now.Add(20*time.Second + egressFirewallDNSMinTTL))
+		s.report(reported)
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("captures the DNS responses of the local DNS server pods", func() {
+		s := newEgressFirewallDNSSnooper("node1", nil)
+		s.getPodInterface = func(namespace, name string) (string, error) {
+			if name == "coredns-missing" {
+				return "", fmt.Errorf("no interface")
+			}
+			return name + "_veth", nil
+		}
+		captured := make(chan string, 10)
+		stopped := make(chan string, 10)
+		s.captureInterface = func(ifName string, stop <-chan struct{}, handleFrame func([]byte)) error {
+			captured <- ifName
+			<-stop
+			stopped <- ifName
+			return nil
+		}
+		localNode := "node1"
+		remoteNode := "node2"
+		newAddress := func(name string, nodeName *string) kapi.EndpointAddress {
+			return kapi.EndpointAddress{
+				IP:        "10.244.0.10",
+				NodeName:  nodeName,
+				TargetRef: &kapi.ObjectReference{Kind: "Pod", Namespace: "kube-system", Name: name},
+			}
+		}
+
+		s.syncDNSServers([]kapi.EndpointSubset{
+			{
+				Addresses: []kapi.EndpointAddress{
+					newAddress("coredns-local", &localNode),
+					newAddress("coredns-remote", &remoteNode),
+					newAddress("coredns-missing", &localNode),
+				},
+			},
+		})
+		Eventually(captured).Should(Receive(Equal("coredns-local_veth")))
+		Consistently(captured).ShouldNot(Receive())
+
+		s.syncDNSServers(nil)
+		Eventually(stopped).Should(Receive(Equal("coredns-local_veth")))
+	})
+})
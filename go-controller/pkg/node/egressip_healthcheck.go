@@ -0,0 +1,35 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// startEgressIPHealthCheckServer serves the health check endpoint which the master probes
+// to decide whether the node can host egress IPs, until stopChan is closed
+func startEgressIPHealthCheckServer(port int, stopChan <-chan struct{}) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on egress IP health check port %d: %v", port, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(types.EgressIPHealthCheckPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Egress IP health check server stopped, err: %v", err)
+		}
+	}()
+	go func() {
+		<-stopChan
+		server.Close()
+	}()
+	return nil
+}
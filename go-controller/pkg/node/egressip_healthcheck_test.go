@@ -0,0 +1,40 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node EgressIP health check", func() {
+
+	It("serves the health check endpoint until stopped", func() {
+		// find a free port
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		port := listener.Addr().(*net.TCPAddr).Port
+		Expect(listener.Close()).To(Succeed())
+
+		stopChan := make(chan struct{})
+		Expect(startEgressIPHealthCheckServer(port, stopChan)).To(Succeed())
+		url := fmt.Sprintf("http://127.0.0.1:%d%s", port, types.EgressIPHealthCheckPath)
+		resp, err := http.Get(url)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		close(stopChan)
+		Eventually(func() error {
+			resp, err := http.Get(url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}).Should(HaveOccurred())
+	})
+})
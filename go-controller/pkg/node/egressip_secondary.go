@@ -0,0 +1,338 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// iptableEgressIPChain holds the SNAT rules of the egress IPs hosted on the secondary egress interfaces
+	iptableEgressIPChain = "OVN-KUBE-EGRESSIP"
+	// egressIPRoutingTableBase is added to the index of a secondary egress interface to get the
+	// routing table of the traffic leaving the node through it
+	egressIPRoutingTableBase = 7000
+	// egressIPRulePriority is the priority of the rules routing the traffic of the pods through
+	// the routing table of their secondary egress interface
+	egressIPRulePriority = 6000
+)
+
+// secondaryEgressNetwork is a host network on which the node can host egress IPs
+type secondaryEgressNetwork struct {
+	iface   string
+	address *net.IPNet
+}
+
+// secondaryEgressIP is an egress IP hosted by the node on a secondary egress network, and the IPs
+// of the pods whose traffic is SNATed to it
+type secondaryEgressIP struct {
+	network *secondaryEgressNetwork
+	podIPs  sets.String
+}
+
+// secondaryEgressIPController hosts the egress IPs assigned to the node on the networks of its
+// secondary egress interfaces. The master reroutes the traffic of the pods using those egress
+// IPs to the management port of the node, which SNATs it to the egress IP and sends it out
+// through the interface instead of the gateway bridge. The pods are selected from the pod and
+// namespace informers like the master does, so that the SNAT follows the reroute policies.
+type secondaryEgressIPController struct {
+	nodeName     string
+	watchFactory factory.NodeWatchFactory
+	networks     []*secondaryEgressNetwork
+
+	sync.Mutex
+	// the EgressIPs by name
+	egressIPs map[string]*egressipv1.EgressIP
+	// the egress IPs hosted by the node, by egress IP
+	hosted map[string]*secondaryEgressIP
+
+	// overridden by the tests
+	initNetwork    func(network *secondaryEgressNetwork) error
+	addEgressIP    func(network *secondaryEgressNetwork, egressIP net.IP) error
+	deleteEgressIP func(network *secondaryEgressNetwork, egressIP net.IP) error
+	addPodSNAT     func(network *secondaryEgressNetwork, egressIP net.IP, podIP net.IP) error
+	deletePodSNAT  func(network *secondaryEgressNetwork, egressIP net.IP, podIP net.IP) error
+	// deletes what a previous run left on the networks for the egress IPs and pods not hosted
+	cleanupNetworks func(networks []*secondaryEgressNetwork, hosted map[string]*secondaryEgressIP) error
+}
+
+func newSecondaryEgressIPController(nodeName string, watchFactory factory.NodeWatchFactory, networks []util.NodeEgressNetwork) (*secondaryEgressIPController, error) {
+	c := &secondaryEgressIPController{
+		nodeName:       nodeName,
+		watchFactory:   watchFactory,
+		egressIPs:      make(map[string]*egressipv1.EgressIP),
+		hosted:         make(map[string]*secondaryEgressIP),
+		initNetwork:    initSecondaryEgressNetwork,
+		addEgressIP:    addSecondaryEgressIP,
+		deleteEgressIP: deleteSecondaryEgressIP,
+		addPodSNAT:     addSecondaryEgressIPPodSNAT,
+		deletePodSNAT:  deleteSecondaryEgressIPPodSNAT,
+
+		cleanupNetworks: cleanupSecondaryEgressNetworks,
+	}
+	for _, network := range networks {
+		ip, ipNet, err := net.ParseCIDR(network.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s of egress interface %s: %v", network.Address, network.Interface, err)
+		}
+		c.networks = append(c.networks, &secondaryEgressNetwork{
+			iface:   network.Interface,
+			address: &net.IPNet{IP: ip, Mask: ipNet.Mask},
+		})
+	}
+	return c, nil
+}
+
+// getSecondaryEgressNetworks returns the networks of the given host interfaces on which the
+// node can host egress IPs
+func getSecondaryEgressNetworks(interfaces string) ([]util.NodeEgressNetwork, error) {
+	networks := []util.NodeEgressNetwork{}
+	for _, iface := range strings.Split(interfaces, ",") {
+		iface = strings.TrimSpace(iface)
+		if iface == "" {
+			continue
+		}
+		addresses, err := util.GetNetworkInterfaceIPs(iface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the addresses of egress interface %s: %v", iface, err)
+		}
+		for _, address := range addresses {
+			networks = append(networks, util.NodeEgressNetwork{Interface: iface, Address: address.String()})
+		}
+	}
+	return networks, nil
+}
+
+// Start sets up the secondary egress interfaces and hosts the egress IPs assigned to the node
+// on their networks until stopChan is closed
+func (c *secondaryEgressIPController) Start(stopChan <-chan struct{}) error {
+	for _, network := range c.networks {
+		if err := c.initNetwork(network); err != nil {
+			return fmt.Errorf("failed to set up egress interface %s: %v", network.iface, err)
+		}
+	}
+	c.watchFactory.AddEgressIPHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.setEgressIP(obj.(*egressipv1.EgressIP))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			c.setEgressIP(new.(*egressipv1.EgressIP))
+		},
+		DeleteFunc: func(obj interface{}) {
+			eIP, ok := obj.(*egressipv1.EgressIP)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					klog.Errorf("Couldn't get object from tombstone %#v", obj)
+					return
+				}
+				eIP, ok = tombstone.Obj.(*egressipv1.EgressIP)
+				if !ok {
+					klog.Errorf("Tombstone contained object that is not an EgressIP %#v", obj)
+					return
+				}
+			}
+			c.deleteEgressIPObject(eIP.Name)
+		},
+	}, nil)
+	c.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.sync()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldPod, newPod := old.(*kapi.Pod), new.(*kapi.Pod)
+			// only the labels and the IPs of a pod change the egress IPs it uses
+			if !reflect.DeepEqual(oldPod.Labels, newPod.Labels) || !reflect.DeepEqual(oldPod.Status.PodIPs, newPod.Status.PodIPs) {
+				c.sync()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.sync()
+		},
+	}, nil)
+	c.watchFactory.AddNamespaceHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.sync()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if !reflect.DeepEqual(old.(*kapi.Namespace).Labels, new.(*kapi.Namespace).Labels) {
+				c.sync()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.sync()
+		},
+	}, nil)
+	// the existing EgressIPs, pods and namespaces have been synced by now, anything else hosted on
+	// the networks was left by a previous run
+	c.Lock()
+	defer c.Unlock()
+	if err := c.cleanupNetworks(c.networks, c.hosted); err != nil {
+		klog.Errorf("Unable to clean up the stale egress IPs of the secondary egress interfaces, err: %v", err)
+	}
+	return nil
+}
+
+func (c *secondaryEgressIPController) setEgressIP(eIP *egressipv1.EgressIP) {
+	c.Lock()
+	c.egressIPs[eIP.Name] = eIP
+	c.Unlock()
+	c.sync()
+}
+
+func (c *secondaryEgressIPController) deleteEgressIPObject(name string) {
+	c.Lock()
+	delete(c.egressIPs, name)
+	c.Unlock()
+	c.sync()
+}
+
+// getNetwork returns the secondary egress network containing ip, if any
+func (c *secondaryEgressIPController) getNetwork(ip net.IP) *secondaryEgressNetwork {
+	for _, network := range c.networks {
+		if network.address.Contains(ip) {
+			return network
+		}
+	}
+	return nil
+}
+
+// sync hosts the egress IPs assigned to the node on its secondary egress networks, and SNATs
+// to them the traffic of the pods rerouted to the node
+func (c *secondaryEgressIPController) sync() {
+	c.Lock()
+	defer c.Unlock()
+
+	wanted := make(map[string]*secondaryEgressIP)
+	for name, eIP := range c.egressIPs {
+		// the egress IPs of the EgressIP hosted by the node
+		hosting := make(map[string]*secondaryEgressIP)
+		for _, status := range eIP.Status.Items {
+			if status.Node != c.nodeName {
+				continue
+			}
+			egressIP := net.ParseIP(status.EgressIP)
+			if egressIP == nil {
+				continue
+			}
+			network := c.getNetwork(egressIP)
+			if network == nil {
+				continue
+			}
+			hosting[egressIP.String()] = &secondaryEgressIP{network: network, podIPs: sets.NewString()}
+		}
+		if len(hosting) == 0 {
+			continue
+		}
+		for egressIPString, want := range hosting {
+			wanted[egressIPString] = want
+		}
+		pods, err := c.getEgressIPPods(eIP)
+		if err != nil {
+			klog.Errorf("Unable to get the pods using EgressIP: %s, err: %v", name, err)
+			// keep the current pods until the next sync
+			for egressIPString, want := range hosting {
+				if hosted, exists := c.hosted[egressIPString]; exists {
+					want.podIPs.Insert(hosted.podIPs.UnsortedList()...)
+				}
+			}
+			continue
+		}
+		for _, pod := range pods {
+			// the master reroutes the traffic of each pod to one egress node per IP family
+			for _, status := range util.SelectPodEgressIPStatuses(pod, eIP.Status.Items) {
+				egressIP := net.ParseIP(status.EgressIP)
+				if status.Node != c.nodeName || egressIP == nil {
+					continue
+				}
+				want, exists := hosting[egressIP.String()]
+				if !exists {
+					continue
+				}
+				for _, podIP := range pod.Status.PodIPs {
+					if ip := net.ParseIP(podIP.IP); ip != nil && utilnet.IsIPv6(ip) == utilnet.IsIPv6(egressIP) {
+						want.podIPs.Insert(ip.String())
+					}
+				}
+			}
+		}
+	}
+
+	for egressIPString, hosted := range c.hosted {
+		egressIP := net.ParseIP(egressIPString)
+		if want, exists := wanted[egressIPString]; exists && want.network == hosted.network {
+			continue
+		}
+		for _, podIP := range hosted.podIPs.List() {
+			if err := c.deletePodSNAT(hosted.network, egressIP, net.ParseIP(podIP)); err != nil {
+				klog.Errorf("Unable to stop SNATing pod IP: %s to egress IP: %s, err: %v", podIP, egressIPString, err)
+				continue
+			}
+			hosted.podIPs.Delete(podIP)
+		}
+		if err := c.deleteEgressIP(hosted.network, egressIP); err != nil {
+			klog.Errorf("Unable to delete egress IP: %s from interface: %s, err: %v", egressIPString, hosted.network.iface, err)
+			continue
+		}
+		delete(c.hosted, egressIPString)
+	}
+
+	for egressIPString, want := range wanted {
+		egressIP := net.ParseIP(egressIPString)
+		hosted, exists := c.hosted[egressIPString]
+		if !exists {
+			if err := c.addEgressIP(want.network, egressIP); err != nil {
+				klog.Errorf("Unable to add egress IP: %s to interface: %s, err: %v", egressIPString, want.network.iface, err)
+				continue
+			}
+			hosted = &secondaryEgressIP{network: want.network, podIPs: sets.NewString()}
+			c.hosted[egressIPString] = hosted
+		}
+		for _, podIP := range hosted.podIPs.Difference(want.podIPs).List() {
+			if err := c.deletePodSNAT(hosted.network, egressIP, net.ParseIP(podIP)); err != nil {
+				klog.Errorf("Unable to stop SNATing pod IP: %s to egress IP: %s, err: %v", podIP, egressIPString, err)
+				continue
+			}
+			hosted.podIPs.Delete(podIP)
+		}
+		for _, podIP := range want.podIPs.Difference(hosted.podIPs).List() {
+			if err := c.addPodSNAT(hosted.network, egressIP, net.ParseIP(podIP)); err != nil {
+				klog.Errorf("Unable to SNAT pod IP: %s to egress IP: %s, err: %v", podIP, egressIPString, err)
+				continue
+			}
+			hosted.podIPs.Insert(podIP)
+		}
+	}
+}
+
+// getEgressIPPods returns the pods matched by the namespace and pod selectors of an EgressIP
+func (c *secondaryEgressIPController) getEgressIPPods(eIP *egressipv1.EgressIP) ([]*kapi.Pod, error) {
+	namespaces, err := c.watchFactory.GetNamespacesBySelector(eIP.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	pods := []*kapi.Pod{}
+	for _, namespace := range namespaces {
+		namespacePods, err := c.watchFactory.GetPodsBySelector(namespace.Name, eIP.Spec.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range namespacePods {
+			if !pod.Spec.HostNetwork {
+				pods = append(pods, pod)
+			}
+		}
+	}
+	return pods, nil
+}
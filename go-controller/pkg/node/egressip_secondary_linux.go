@@ -0,0 +1,311 @@
+// +build linux
+
+package node
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+func getIPTablesProtocol(ip net.IP) iptables.Protocol {
+	if utilnet.IsIPv6(ip) {
+		return iptables.ProtocolIPv6
+	}
+	return iptables.ProtocolIPv4
+}
+
+// getHostIPNet returns ip as a single address network
+func getHostIPNet(ip net.IP) *net.IPNet {
+	if utilnet.IsIPv6(ip) {
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}
+}
+
+// initSecondaryEgressNetwork sets up the routing table of the traffic leaving the node through
+// the interface of the network, and the iptables chain SNATing it to the egress IPs
+func initSecondaryEgressNetwork(network *secondaryEgressNetwork) error {
+	link, err := util.GetNetLinkOps().LinkByName(network.iface)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", network.iface, err)
+	}
+	family := netlink.FAMILY_V4
+	defaultDst := &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}
+	if utilnet.IsIPv6(network.address.IP) {
+		family = netlink.FAMILY_V6
+		defaultDst = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	}
+	// use the gateway of the interface if the host has a default route through it, the
+	// destinations must be on the network of the interface otherwise
+	routes, err := util.GetNetLinkOps().RouteList(link, family)
+	if err != nil {
+		return fmt.Errorf("failed to list the routes of link %s: %v", network.iface, err)
+	}
+	var gateway net.IP
+	for _, route := range routes {
+		if route.Gw != nil && (route.Dst == nil || route.Dst.IP.IsUnspecified()) {
+			gateway = route.Gw
+			break
+		}
+	}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       defaultDst,
+		Gw:        gateway,
+		Table:     egressIPRoutingTableBase + link.Attrs().Index,
+	}
+	if gateway == nil {
+		route.Scope = netlink.SCOPE_LINK
+	}
+	if err := netlink.RouteReplace(route); err != nil {
+		return fmt.Errorf("failed to add the default route of table %d: %v", route.Table, err)
+	}
+	// the chain must exist before the jump to it is added
+	protocol := getIPTablesProtocol(network.address.IP)
+	ipt, err := util.GetIPTablesHelper(protocol)
+	if err != nil {
+		return err
+	}
+	if _, err = ipt.List("nat", iptableEgressIPChain); err != nil {
+		if err = ipt.NewChain("nat", iptableEgressIPChain); err != nil {
+			return fmt.Errorf("failed to create iptables nat chain %s: %v", iptableEgressIPChain, err)
+		}
+	}
+	return addIptRules([]iptRule{
+		{
+			table:    "nat",
+			chain:    "POSTROUTING",
+			args:     []string{"-j", iptableEgressIPChain},
+			protocol: protocol,
+		},
+	})
+}
+
+// cleanupSecondaryEgressNetworks deletes the egress IPs, the routing rules and the SNAT rules left
+// on the secondary egress networks by a previous run for egress IPs and pods no longer hosted
+func cleanupSecondaryEgressNetworks(networks []*secondaryEgressNetwork, hosted map[string]*secondaryEgressIP) error {
+	var errs []error
+	ifaces := sets.NewString()
+	for _, network := range networks {
+		ifaces.Insert(network.iface)
+	}
+	// the pod IPs routed through each routing table
+	podIPsByTable := make(map[int]sets.String)
+	for _, h := range hosted {
+		link, err := util.GetNetLinkOps().LinkByName(h.network.iface)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get link %s: %v", h.network.iface, err))
+			continue
+		}
+		table := egressIPRoutingTableBase + link.Attrs().Index
+		if _, exists := podIPsByTable[table]; !exists {
+			podIPsByTable[table] = sets.NewString()
+		}
+		podIPsByTable[table].Insert(h.podIPs.UnsortedList()...)
+	}
+
+	for _, iface := range ifaces.List() {
+		link, err := util.GetNetLinkOps().LinkByName(iface)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get link %s: %v", iface, err))
+			continue
+		}
+		addresses, err := util.GetNetLinkOps().AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list the addresses of link %s: %v", iface, err))
+			continue
+		}
+		for _, address := range addresses {
+			if !isStaleSecondaryEgressIP(networks, hosted, iface, address.IPNet) {
+				continue
+			}
+			klog.Infof("Deleting stale egress IP %s from interface %s", address.IPNet, iface)
+			if err := util.GetNetLinkOps().AddrDel(link, &netlink.Addr{IPNet: address.IPNet}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete address %s from link %s: %v", address.IPNet, iface, err))
+			}
+		}
+	}
+
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		rules, err := netlink.RuleList(family)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list the routing rules: %v", err))
+			continue
+		}
+		for i := range rules {
+			rule := &rules[i]
+			if rule.Priority != egressIPRulePriority || rule.Table < egressIPRoutingTableBase {
+				continue
+			}
+			if rule.Src != nil && podIPsByTable[rule.Table].Has(rule.Src.IP.String()) {
+				continue
+			}
+			klog.Infof("Deleting stale egress IP routing rule from %s to table %d", rule.Src, rule.Table)
+			if err := netlink.RuleDel(rule); err != nil && !os.IsNotExist(err) && err != unix.ESRCH {
+				errs = append(errs, fmt.Errorf("failed to delete the routing rule from %s to table %d: %v", rule.Src, rule.Table, err))
+			}
+		}
+	}
+
+	protocols := make(map[iptables.Protocol]bool)
+	for _, network := range networks {
+		protocols[getIPTablesProtocol(network.address.IP)] = true
+	}
+	for protocol := range protocols {
+		ipt, err := util.GetIPTablesHelper(protocol)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules, err := ipt.List("nat", iptableEgressIPChain)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list the rules of iptables nat chain %s: %v", iptableEgressIPChain, err))
+			continue
+		}
+		for _, rule := range rules {
+			args := strings.Fields(rule)
+			// skip the chain declaration, and the chain name of the rules
+			if len(args) < 2 || args[0] != "-A" {
+				continue
+			}
+			args = args[2:]
+			if !isStaleSecondaryEgressIPSNAT(hosted, args) {
+				continue
+			}
+			klog.Infof("Deleting stale egress IP SNAT rule %q", strings.Join(args, " "))
+			if err := ipt.Delete("nat", iptableEgressIPChain, args...); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete iptables nat/%s rule %q: %v", iptableEgressIPChain, strings.Join(args, " "), err))
+			}
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// isStaleSecondaryEgressIP returns whether address is an egress IP left on the interface, a host
+// address in one of the secondary egress networks of the interface other than their own addresses
+func isStaleSecondaryEgressIP(networks []*secondaryEgressNetwork, hosted map[string]*secondaryEgressIP, iface string, address *net.IPNet) bool {
+	if ones, bits := address.Mask.Size(); ones != bits {
+		return false
+	}
+	if h, exists := hosted[address.IP.String()]; exists && h.network.iface == iface {
+		return false
+	}
+	inNetwork := false
+	for _, network := range networks {
+		if network.address.IP.Equal(address.IP) {
+			return false
+		}
+		if network.iface == iface && network.address.Contains(address.IP) {
+			inNetwork = true
+		}
+	}
+	return inNetwork
+}
+
+// isStaleSecondaryEgressIPSNAT returns whether the rule of the egress IP chain, as listed by
+// iptables, SNATs a pod to an egress IP that is not hosted anymore or no longer used by the pod
+func isStaleSecondaryEgressIPSNAT(hosted map[string]*secondaryEgressIP, args []string) bool {
+	var podIP, iface, egressIP string
+	for i := 0; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-s":
+			podIP = args[i+1]
+		case "-o":
+			iface = args[i+1]
+		case "--to-source":
+			egressIP = args[i+1]
+		}
+	}
+	if ip, _, err := net.ParseCIDR(podIP); err == nil {
+		podIP = ip.String()
+	}
+	if h, exists := hosted[egressIP]; exists && h.network.iface == iface {
+		return !h.podIPs.Has(podIP)
+	}
+	return true
+}
+
+func addSecondaryEgressIP(network *secondaryEgressNetwork, egressIP net.IP) error {
+	link, err := util.GetNetLinkOps().LinkByName(network.iface)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", network.iface, err)
+	}
+	address := getHostIPNet(egressIP)
+	if exists, err := util.LinkAddrExist(link, address); err != nil || exists {
+		return err
+	}
+	return util.LinkAddrAdd(link, address)
+}
+
+func deleteSecondaryEgressIP(network *secondaryEgressNetwork, egressIP net.IP) error {
+	link, err := util.GetNetLinkOps().LinkByName(network.iface)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", network.iface, err)
+	}
+	address := getHostIPNet(egressIP)
+	if exists, err := util.LinkAddrExist(link, address); err != nil || !exists {
+		return err
+	}
+	if err := util.GetNetLinkOps().AddrDel(link, &netlink.Addr{IPNet: address}); err != nil {
+		return fmt.Errorf("failed to delete address %s from link %s: %v", address, network.iface, err)
+	}
+	return nil
+}
+
+func getSecondaryEgressIPPodRules(network *secondaryEgressNetwork, egressIP, podIP net.IP) ([]iptRule, *netlink.Rule, error) {
+	link, err := util.GetNetLinkOps().LinkByName(network.iface)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get link %s: %v", network.iface, err)
+	}
+	iptRules := []iptRule{
+		{
+			table:    "nat",
+			chain:    iptableEgressIPChain,
+			args:     []string{"-s", podIP.String(), "-o", network.iface, "-j", "SNAT", "--to-source", egressIP.String()},
+			protocol: getIPTablesProtocol(egressIP),
+		},
+	}
+	rule := netlink.NewRule()
+	rule.Src = getHostIPNet(podIP)
+	rule.Table = egressIPRoutingTableBase + link.Attrs().Index
+	rule.Priority = egressIPRulePriority
+	return iptRules, rule, nil
+}
+
+// addSecondaryEgressIPPodSNAT routes the traffic of the pod through the interface of the
+// network and SNATs it to the egress IP
+func addSecondaryEgressIPPodSNAT(network *secondaryEgressNetwork, egressIP, podIP net.IP) error {
+	iptRules, rule, err := getSecondaryEgressIPPodRules(network, egressIP, podIP)
+	if err != nil {
+		return err
+	}
+	if err := addIptRules(iptRules); err != nil {
+		return err
+	}
+	if err := netlink.RuleAdd(rule); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to add the routing rule of pod IP %s: %v", podIP, err)
+	}
+	return nil
+}
+
+func deleteSecondaryEgressIPPodSNAT(network *secondaryEgressNetwork, egressIP, podIP net.IP) error {
+	iptRules, rule, err := getSecondaryEgressIPPodRules(network, egressIP, podIP)
+	if err != nil {
+		return err
+	}
+	if err := netlink.RuleDel(rule); err != nil && !os.IsNotExist(err) && err != unix.ESRCH {
+		return fmt.Errorf("failed to delete the routing rule of pod IP %s: %v", podIP, err)
+	}
+	return delIptRules(iptRules)
+}
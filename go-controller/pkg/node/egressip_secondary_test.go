@@ -0,0 +1,303 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressipclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSecondaryEgressIPHost records the egress IPs and the pod SNATs programmed by a secondaryEgressIPController
+type fakeSecondaryEgressIPHost struct {
+	sync.Mutex
+	egressIPs map[string]string
+	podSNATs  sets.String
+}
+
+func (h *fakeSecondaryEgressIPHost) getEgressIPs() map[string]string {
+	h.Lock()
+	defer h.Unlock()
+	egressIPs := map[string]string{}
+	for egressIP, iface := range h.egressIPs {
+		egressIPs[egressIP] = iface
+	}
+	return egressIPs
+}
+
+func (h *fakeSecondaryEgressIPHost) getPodSNATs() []string {
+	h.Lock()
+	defer h.Unlock()
+	return h.podSNATs.List()
+}
+
+func newFakeSecondaryEgressIPController(nodeName string, wf factory.NodeWatchFactory) (*secondaryEgressIPController, *fakeSecondaryEgressIPHost) {
+	c, err := newSecondaryEgressIPController(nodeName, wf, []util.NodeEgressNetwork{
+		{Interface: "eth1", Address: "172.16.10.5/24"},
+		{Interface: "eth2", Address: "172.16.20.5/24"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	host := &fakeSecondaryEgressIPHost{
+		egressIPs: map[string]string{},
+		podSNATs:  sets.NewString(),
+	}
+	c.initNetwork = func(network *secondaryEgressNetwork) error {
+		return nil
+	}
+	c.addEgressIP = func(network *secondaryEgressNetwork, egressIP net.IP) error {
+		host.Lock()
+		defer host.Unlock()
+		host.egressIPs[egressIP.String()] = network.iface
+		return nil
+	}
+	c.deleteEgressIP = func(network *secondaryEgressNetwork, egressIP net.IP) error {
+		host.Lock()
+		defer host.Unlock()
+		delete(host.egressIPs, egressIP.String())
+		return nil
+	}
+	c.addPodSNAT = func(network *secondaryEgressNetwork, egressIP, podIP net.IP) error {
+		host.Lock()
+		defer host.Unlock()
+		host.podSNATs.Insert(fmt.Sprintf("%s %s -> %s", network.iface, podIP, egressIP))
+		return nil
+	}
+	c.deletePodSNAT = func(network *secondaryEgressNetwork, egressIP, podIP net.IP) error {
+		host.Lock()
+		defer host.Unlock()
+		host.podSNATs.Delete(fmt.Sprintf("%s %s -> %s", network.iface, podIP, egressIP))
+		return nil
+	}
+	c.cleanupNetworks = func(networks []*secondaryEgressNetwork, hosted map[string]*secondaryEgressIP) error {
+		host.Lock()
+		defer host.Unlock()
+		podSNATs := sets.NewString()
+		for egressIP, h := range hosted {
+			if host.egressIPs[egressIP] != h.network.iface {
+				return fmt.Errorf("egress IP %s is not on interface %s", egressIP, h.network.iface)
+			}
+			for _, podIP := range h.podIPs.UnsortedList() {
+				podSNATs.Insert(fmt.Sprintf("%s %s -> %s", h.network.iface, podIP, egressIP))
+			}
+		}
+		for egressIP := range host.egressIPs {
+			if _, exists := hosted[egressIP]; !exists {
+				delete(host.egressIPs, egressIP)
+			}
+		}
+		host.podSNATs = host.podSNATs.Intersection(podSNATs)
+		return nil
+	}
+	return c, host
+}
+
+func newSecondaryEgressIP(name string, statusItems ...egressipv1.EgressIPStatusItem) *egressipv1.EgressIP {
+	return &egressipv1.EgressIP{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: egressipv1.EgressIPSpec{
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"name": "egress"}},
+			PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"app": "egress"}},
+		},
+		Status: egressipv1.EgressIPStatus{Items: statusItems},
+	}
+}
+
+func newSecondaryEgressIPPod(namespace, name, nodeName, podIP string, labels map[string]string) *kapi.Pod {
+	return &kapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       kapi.PodSpec{NodeName: nodeName},
+		Status:     kapi.PodStatus{PodIP: podIP, PodIPs: []kapi.PodIP{{IP: podIP}}},
+	}
+}
+
+var _ = Describe("Node EgressIP on secondary egress interfaces", func() {
+	var (
+		stopChan       chan struct{}
+		wf             *factory.WatchFactory
+		kubeClient     kubernetes.Interface
+		egressIPClient egressipclientset.Interface
+	)
+
+	// startWithHost starts the controller on a host holding the given egress IPs and pod SNATs
+	startWithHost := func(egressIPs map[string]string, podSNATs []string, objects ...runtime.Object) (*secondaryEgressIPController, *fakeSecondaryEgressIPHost) {
+		fakeClient := util.GetOVNClientset(objects...)
+		kubeClient, egressIPClient = fakeClient.KubeClient, fakeClient.EgressIPClient
+		var err error
+		wf, err = factory.NewNodeWatchFactory(fakeClient, "node1")
+		Expect(err).NotTo(HaveOccurred())
+		c, host := newFakeSecondaryEgressIPController("node1", wf)
+		for egressIP, iface := range egressIPs {
+			host.egressIPs[egressIP] = iface
+		}
+		host.podSNATs.Insert(podSNATs...)
+		Expect(c.Start(stopChan)).To(Succeed())
+		return c, host
+	}
+
+	start := func(objects ...runtime.Object) (*secondaryEgressIPController, *fakeSecondaryEgressIPHost) {
+		return startWithHost(nil, nil, objects...)
+	}
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+		config.OVNKubernetesFeature.EnableEgressIP = true
+		config.OVNKubernetesFeature.EgressIPInterfaces = "eth1,eth2"
+		stopChan = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		close(stopChan)
+		wf.Shutdown()
+	})
+
+	It("hosts the egress IPs assigned to the node on its secondary egress networks", func() {
+		_, host := start(
+			&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"name": "egress"}}},
+			&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}},
+			newSecondaryEgressIPPod("ns1", "pod1", "node2", "10.244.1.3", map[string]string{"app": "egress"}),
+			newSecondaryEgressIPPod("ns1", "pod2", "node3", "10.244.2.4", map[string]string{"app": "egress"}),
+			newSecondaryEgressIPPod("ns1", "pod3", "node2", "10.244.1.5", nil),
+			newSecondaryEgressIPPod("ns2", "pod4", "node2", "10.244.1.6", map[string]string{"app": "egress"}),
+		)
+
+		_, err := egressIPClient.K8sV1().EgressIPs().Create(context.TODO(), newSecondaryEgressIP("egressip1",
+			egressipv1.EgressIPStatusItem{Node: "node1", EgressIP: "172.16.10.100"},
+		), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = egressIPClient.K8sV1().EgressIPs().Create(context.TODO(), newSecondaryEgressIP("egressip2",
+			egressipv1.EgressIPStatusItem{Node: "node1", EgressIP: "172.16.20.100"},
+		), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		// egress IPs on the network of the primary interface are handled by the gateway router
+		_, err = egressIPClient.K8sV1().EgressIPs().Create(context.TODO(), newSecondaryEgressIP("egressip3",
+			egressipv1.EgressIPStatusItem{Node: "node1", EgressIP: "192.168.126.100"},
+		), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(host.getEgressIPs).Should(Equal(map[string]string{
+			"172.16.10.100": "eth1",
+			"172.16.20.100": "eth2",
+		}))
+		Eventually(host.getPodSNATs).Should(Equal([]string{
+			"eth1 10.244.1.3 -> 172.16.10.100",
+			"eth1 10.244.2.4 -> 172.16.10.100",
+			"eth2 10.244.1.3 -> 172.16.20.100",
+			"eth2 10.244.2.4 -> 172.16.20.100",
+		}))
+
+		// the pods and namespaces starting or stopping to match the EgressIPs are SNATed right away
+		_, err = kubeClient.CoreV1().Pods("ns1").Create(context.TODO(),
+			newSecondaryEgressIPPod("ns1", "pod5", "node1", "10.244.0.7", map[string]string{"app": "egress"}), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		err = kubeClient.CoreV1().Pods("ns1").Delete(context.TODO(), "pod1", metav1.DeleteOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = kubeClient.CoreV1().Namespaces().Update(context.TODO(),
+			&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2", Labels: map[string]string{"name": "egress"}}}, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(host.getPodSNATs).Should(Equal([]string{
+			"eth1 10.244.0.7 -> 172.16.10.100",
+			"eth1 10.244.1.6 -> 172.16.10.100",
+			"eth1 10.244.2.4 -> 172.16.10.100",
+			"eth2 10.244.0.7 -> 172.16.20.100",
+			"eth2 10.244.1.6 -> 172.16.20.100",
+			"eth2 10.244.2.4 -> 172.16.20.100",
+		}))
+
+		// egress IPs moved to another node are released
+		_, err = egressIPClient.K8sV1().EgressIPs().Update(context.TODO(), newSecondaryEgressIP("egressip1",
+			egressipv1.EgressIPStatusItem{Node: "node2", EgressIP: "172.16.10.100"},
+		), metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(host.getEgressIPs).Should(Equal(map[string]string{
+			"172.16.20.100": "eth2",
+		}))
+		Eventually(host.getPodSNATs).Should(Equal([]string{
+			"eth2 10.244.0.7 -> 172.16.20.100",
+			"eth2 10.244.1.6 -> 172.16.20.100",
+			"eth2 10.244.2.4 -> 172.16.20.100",
+		}))
+
+		err = egressIPClient.K8sV1().EgressIPs().Delete(context.TODO(), "egressip2", metav1.DeleteOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(host.getEgressIPs).Should(BeEmpty())
+		Eventually(host.getPodSNATs).Should(BeEmpty())
+	})
+
+	It("only SNATs the pods the master reroutes to the node", func() {
+		objects := []runtime.Object{
+			&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"name": "egress"}}},
+		}
+		pods := []*kapi.Pod{}
+		for i := 0; i < 20; i++ {
+			pod := newSecondaryEgressIPPod("ns1", fmt.Sprintf("pod%d", i), "node2", fmt.Sprintf("10.244.1.%d", i+3), map[string]string{"app": "egress"})
+			pods = append(pods, pod)
+			objects = append(objects, pod)
+		}
+		_, host := start(objects...)
+
+		eIP := newSecondaryEgressIP("egressip1",
+			egressipv1.EgressIPStatusItem{Node: "node1", EgressIP: "172.16.10.100"},
+			egressipv1.EgressIPStatusItem{Node: "node2", EgressIP: "172.16.10.101"},
+		)
+		expected := []string{}
+		for _, pod := range pods {
+			selected := util.SelectPodEgressIPStatuses(pod, eIP.Status.Items)
+			Expect(selected).To(HaveLen(1))
+			if selected[0].Node == "node1" {
+				expected = append(expected, fmt.Sprintf("eth1 %s -> 172.16.10.100", pod.Status.PodIP))
+			}
+		}
+		Expect(expected).NotTo(BeEmpty())
+		Expect(len(expected)).To(BeNumerically("<", len(pods)))
+
+		_, err := egressIPClient.K8sV1().EgressIPs().Create(context.TODO(), eIP, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(host.getEgressIPs).Should(Equal(map[string]string{
+			"172.16.10.100": "eth1",
+		}))
+		Eventually(host.getPodSNATs).Should(ConsistOf(expected))
+	})
+
+	It("deletes the egress IPs and pod SNATs left by a previous run that are not used anymore", func() {
+		_, host := startWithHost(
+			map[string]string{
+				"172.16.10.100": "eth1",
+				"172.16.10.200": "eth1",
+			},
+			[]string{
+				"eth1 10.244.1.3 -> 172.16.10.100",
+				"eth1 10.244.1.5 -> 172.16.10.100",
+				"eth1 10.244.1.3 -> 172.16.10.200",
+			},
+			&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"name": "egress"}}},
+			newSecondaryEgressIPPod("ns1", "pod1", "node2", "10.244.1.3", map[string]string{"app": "egress"}),
+			&egressipv1.EgressIPList{
+				Items: []egressipv1.EgressIP{
+					*newSecondaryEgressIP("egressip1",
+						egressipv1.EgressIPStatusItem{Node: "node1", EgressIP: "172.16.10.100"},
+					),
+				},
+			},
+		)
+		Expect(host.getEgressIPs()).To(Equal(map[string]string{
+			"172.16.10.100": "eth1",
+		}))
+		Expect(host.getPodSNATs()).To(Equal([]string{
+			"eth1 10.244.1.3 -> 172.16.10.100",
+		}))
+	})
+})
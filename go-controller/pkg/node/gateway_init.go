@@ -3,6 +3,7 @@ package node
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 
 	kapi "k8s.io/api/core/v1"
@@ -38,6 +39,17 @@ func bridgedGatewayNodeSetup(nodeName, bridgeName, bridgeInterface, physicalNetw
 		}
 	}
 
+	if err = addBridgeMapping(physicalNetworkName, bridgeName); err != nil {
+		return "", nil, err
+	}
+
+	ifaceID := bridgeName + "_" + nodeName
+	return ifaceID, macAddress, nil
+}
+
+// addBridgeMapping maps a physical network name to a local OVS bridge in the
+// ovn-bridge-mappings, replacing the existing mapping of the physical network
+func addBridgeMapping(physicalNetworkName, bridgeName string) error {
 	// ovn-bridge-mappings maps a physical network name to a local ovs bridge
 	// that provides connectivity to that network. It is in the form of physnet1:br1,physnet2:br2.
 	// Note that there may be multiple ovs bridge mappings, be sure not to override
@@ -45,7 +57,7 @@ func bridgedGatewayNodeSetup(nodeName, bridgeName, bridgeInterface, physicalNetw
 	stdout, stderr, err := util.RunOVSVsctl("--if-exists", "get", "Open_vSwitch", ".",
 		"external_ids:ovn-bridge-mappings")
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get ovn-bridge-mappings stderr:%s (%v)", stderr, err)
+		return fmt.Errorf("failed to get ovn-bridge-mappings stderr:%s (%v)", stderr, err)
 	}
 	// skip the existing mapping setting for the specified physicalNetworkName
 	mapString := ""
@@ -67,12 +79,26 @@ func bridgedGatewayNodeSetup(nodeName, bridgeName, bridgeInterface, physicalNetw
 	_, stderr, err = util.RunOVSVsctl("set", "Open_vSwitch", ".",
 		fmt.Sprintf("external_ids:ovn-bridge-mappings=%s", mapString))
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to set ovn-bridge-mappings for ovs bridge %s"+
+		return fmt.Errorf("failed to set ovn-bridge-mappings for ovs bridge %s"+
 			", stderr:%s (%v)", bridgeName, stderr, err)
 	}
+	return nil
+}
 
-	ifaceID := bridgeName + "_" + nodeName
-	return ifaceID, macAddress, nil
+// setupLocalnetBridgeMappings maps the physical networks of the localnet
+// secondary networks to their local OVS bridges
+func setupLocalnetBridgeMappings() error {
+	physicalNetworkNames := make([]string, 0, len(config.Gateway.BridgeMappings))
+	for physicalNetworkName := range config.Gateway.BridgeMappings {
+		physicalNetworkNames = append(physicalNetworkNames, physicalNetworkName)
+	}
+	sort.Strings(physicalNetworkNames)
+	for _, physicalNetworkName := range physicalNetworkNames {
+		if err := addBridgeMapping(physicalNetworkName, config.Gateway.BridgeMappings[physicalNetworkName]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // getNetworkInterfaceIPAddresses returns the IP addresses for the network interface 'iface'.
@@ -168,7 +194,7 @@ func (n *OvnNode) initGateway(subnets []*net.IPNet, nodeAnnotator kube.Annotator
 	var portClaimWatcher *portClaimWatcher
 
 	if config.Gateway.NodeportEnable {
-		loadBalancerHealthChecker = newLoadBalancerHealthChecker(n.name)
+		loadBalancerHealthChecker = newLoadBalancerHealthChecker(n.name, n.watchFactory)
 		portClaimWatcher, err = newPortClaimWatcher(n.recorder)
 		if err != nil {
 			return err
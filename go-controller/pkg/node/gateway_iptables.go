@@ -173,7 +173,7 @@ func getNodePortIPTRules(svcPort kapi.ServicePort, nodeIP *net.IPNet, targetIP s
 	}
 }
 
-func getExternalIPTRules(svcPort kapi.ServicePort, externalIP, dstIP string) []iptRule {
+func getExternalIPTRules(svcPort kapi.ServicePort, externalIP, dstIP string, dstPort int32) []iptRule {
 	var protocol iptables.Protocol
 	if utilnet.IsIPv6String(externalIP) {
 		protocol = iptables.ProtocolIPv6
@@ -189,7 +189,7 @@ func getExternalIPTRules(svcPort kapi.ServicePort, externalIP, dstIP string) []i
 				"-d", externalIP,
 				"--dport", fmt.Sprintf("%v", svcPort.Port),
 				"-j", "DNAT",
-				"--to-destination", util.JoinHostPortInt32(dstIP, svcPort.Port),
+				"--to-destination", util.JoinHostPortInt32(dstIP, dstPort),
 			},
 			protocol: protocol,
 		},
@@ -207,6 +207,27 @@ func getExternalIPTRules(svcPort kapi.ServicePort, externalIP, dstIP string) []i
 	}
 }
 
+// getSkipMgmtPortSNATIPTRule returns the rule exempting the traffic DNAT'ed to the node
+// port on the node switch gateway IP from the management port SNAT, so that the
+// endpoints see the client source IP
+func getSkipMgmtPortSNATIPTRule(svcPort kapi.ServicePort, gatewayIP string) iptRule {
+	protocol := iptables.ProtocolIPv4
+	if utilnet.IsIPv6String(gatewayIP) {
+		protocol = iptables.ProtocolIPv6
+	}
+	return iptRule{
+		table: "nat",
+		chain: iptableMgmPortChain,
+		args: []string{
+			"-p", string(svcPort.Protocol),
+			"-d", gatewayIP,
+			"--dport", fmt.Sprintf("%d", svcPort.NodePort),
+			"-j", "RETURN",
+		},
+		protocol: protocol,
+	}
+}
+
 func getLocalGatewayNATRules(ifname string, cidr *net.IPNet) []iptRule {
 	// Allow packets to/from the gateway interface in case defaults deny
 	var protocol iptables.Protocol
@@ -357,7 +378,7 @@ func getGatewayIPTRules(service *kapi.Service, gatewayIP string, nodeIP *net.IPN
 				klog.Errorf("Skipping service: %s, invalid service port %v", svcPort.Name, err)
 				continue
 			}
-			rules = append(rules, getExternalIPTRules(svcPort, externalIP, service.Spec.ClusterIP)...)
+			rules = append(rules, getExternalIPTRules(svcPort, externalIP, service.Spec.ClusterIP, svcPort.Port)...)
 		}
 	}
 	return rules
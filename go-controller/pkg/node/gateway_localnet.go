@@ -4,6 +4,7 @@ package node
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
 	"reflect"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
@@ -69,7 +71,12 @@ func newLocalGateway(nodeName string, hostSubnets []*net.IPNet, gwNextHops []net
 		if err := initRoutingRules(); err != nil {
 			return nil, err
 		}
-		gw.localPortWatcher = newLocalPortWatcher(gatewayIfAddrs, recorder, localAddrSet)
+		if config.IPv6Mode {
+			if err := enableProxyNDP(bridgeName); err != nil {
+				return nil, err
+			}
+		}
+		gw.localPortWatcher = newLocalPortWatcher(gatewayIfAddrs, bridgeName, recorder, localAddrSet)
 	}
 
 	gw.initFunc = func() error {
@@ -98,20 +105,27 @@ type localPortWatcher struct {
 	recorder     record.EventRecorder
 	gatewayIPv4  string
 	gatewayIPv6  string
+	gatewayIntf  string
 	localAddrSet map[string]net.IPNet
+	// the services served by the node. It is only accessed from the service handlers,
+	// which are called serially.
+	services map[ktypes.NamespacedName]*kapi.Service
 }
 
-func newLocalPortWatcher(gatewayIfAddrs []*net.IPNet, recorder record.EventRecorder, localAddrSet map[string]net.IPNet) *localPortWatcher {
+func newLocalPortWatcher(gatewayIfAddrs []*net.IPNet, gatewayIntf string, recorder record.EventRecorder, localAddrSet map[string]net.IPNet) *localPortWatcher {
 	gatewayIPv4, gatewayIPv6 := getGatewayFamilyAddrs(gatewayIfAddrs)
 	return &localPortWatcher{
 		recorder:     recorder,
 		gatewayIPv4:  gatewayIPv4,
 		gatewayIPv6:  gatewayIPv6,
+		gatewayIntf:  gatewayIntf,
 		localAddrSet: localAddrSet,
+		services:     make(map[ktypes.NamespacedName]*kapi.Service),
 	}
 }
 
 func (l *localPortWatcher) AddService(svc *kapi.Service) {
+	l.services[ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}] = svc
 	err := l.addService(svc)
 	if err != nil {
 		klog.Errorf("Error in adding service: %v", err)
@@ -123,11 +137,16 @@ func (l *localPortWatcher) UpdateService(old, new *kapi.Service) {
 		reflect.DeepEqual(new.Spec.ExternalIPs, old.Spec.ExternalIPs) &&
 		reflect.DeepEqual(new.Spec.ClusterIP, old.Spec.ClusterIP) &&
 		reflect.DeepEqual(new.Spec.Type, old.Spec.Type) &&
+		reflect.DeepEqual(new.Spec.ExternalTrafficPolicy, old.Spec.ExternalTrafficPolicy) &&
 		reflect.DeepEqual(new.Status.LoadBalancer.Ingress, old.Status.LoadBalancer.Ingress) {
 		klog.V(5).Infof("Skipping service update for: %s as change does not apply to any of .Spec.Ports, "+
-			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type, .Status.LoadBalancer.Ingress", new.Name)
+			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type, .Spec.ExternalTrafficPolicy, "+
+			".Status.LoadBalancer.Ingress", new.Name)
 		return
 	}
+	// the new service is recorded first so that the routes, proxy neighbor entries and
+	// conntrack entries of the external IPs it keeps are not deleted with the old one
+	l.services[ktypes.NamespacedName{Namespace: new.Namespace, Name: new.Name}] = new
 	err := l.deleteService(old)
 	if err != nil {
 		klog.Errorf("Error in deleting service - %v", err)
@@ -136,13 +155,16 @@ func (l *localPortWatcher) UpdateService(old, new *kapi.Service) {
 	if err != nil {
 		klog.Errorf("Error in modifying service: %v", err)
 	}
+	l.deleteStaleExternalIPConntrack(old)
 }
 
 func (l *localPortWatcher) DeleteService(svc *kapi.Service) {
+	delete(l.services, ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name})
 	err := l.deleteService(svc)
 	if err != nil {
 		klog.Errorf("Error in deleting service - %v", err)
 	}
+	l.deleteStaleExternalIPConntrack(svc)
 }
 
 func getLocalAddrs() (map[string]net.IPNet, error) {
@@ -171,6 +193,94 @@ func (l *localPortWatcher) networkHasAddress(ip net.IP) bool {
 	return false
 }
 
+// getServiceExternalIPs returns the external IPs and the load balancer ingress IPs of a service
+func getServiceExternalIPs(svc *kapi.Service) []string {
+	externalIPs := append([]string{}, svc.Spec.ExternalIPs...)
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			externalIPs = append(externalIPs, ing.IP)
+		}
+	}
+	return externalIPs
+}
+
+// getExternalIPPorts returns each external and ingress IP of a service with each of its ports
+func getExternalIPPorts(svc *kapi.Service) map[epAddressItem]struct{} {
+	items := make(map[epAddressItem]struct{})
+	for _, port := range svc.Spec.Ports {
+		for _, externalIP := range getServiceExternalIPs(svc) {
+			items[epAddressItem{ip: externalIP, port: port.Port, protocol: port.Protocol}] = struct{}{}
+		}
+	}
+	return items
+}
+
+// deleteStaleExternalIPConntrack deletes the conntrack entries of the connections to the
+// external and ingress IPs and ports of a deleted or updated service that no service serves
+// anymore, otherwise they keep being DNAT'ed to the cluster IP
+func (l *localPortWatcher) deleteStaleExternalIPConntrack(old *kapi.Service) {
+	servedItems := make(map[epAddressItem]struct{})
+	for _, svc := range l.services {
+		for item := range getExternalIPPorts(svc) {
+			servedItems[item] = struct{}{}
+		}
+	}
+	for item := range getExternalIPPorts(old) {
+		if _, ok := servedItems[item]; ok {
+			continue
+		}
+		if err := util.DeleteConntrackDst(item.ip, item.port, item.protocol); err != nil {
+			klog.Errorf("Failed to delete conntrack entries for %s: %v",
+				util.JoinHostPortInt32(item.ip, item.port), err)
+		}
+	}
+}
+
+// externalIPInUse returns true if a service served by the node has the external or ingress IP
+func (l *localPortWatcher) externalIPInUse(externalIP string) bool {
+	for _, svc := range l.services {
+		for _, ip := range getServiceExternalIPs(svc) {
+			if ip == externalIP && util.IsClusterIPSet(svc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enableProxyNDP lets the host answer the neighbor solicitations for the IPv6 addresses
+// of the proxy neighbor entries of the given interface
+func enableProxyNDP(intf string) error {
+	sysctl := fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/proxy_ndp", intf)
+	if err := ioutil.WriteFile(sysctl, []byte("1"), 0640); err != nil {
+		return fmt.Errorf("failed to enable proxy NDP on %s: %v", intf, err)
+	}
+	return nil
+}
+
+// getLocalGatewayServiceTarget returns the destination the node port, external IPs and
+// ingress IPs of a service port are DNAT'ed to: the service port on the cluster IP, or
+// for services with the Local external traffic policy the node port on the node switch
+// gateway IP, which OVN load balances to the endpoints on the node only
+func getLocalGatewayServiceTarget(svc *kapi.Service, svcPort kapi.ServicePort, gatewayIP string) (string, int32) {
+	if util.ServiceExternalTrafficPolicyLocal(svc) && svcPort.NodePort != 0 {
+		return gatewayIP, svcPort.NodePort
+	}
+	return svc.Spec.ClusterIP, svcPort.Port
+}
+
+// getLocalGatewayNodePortIPTRules returns the iptables rules of a node port of the
+// service. The traffic of services with the Local external traffic policy is not SNAT'ed
+// by the management port, preserving the client source IP.
+func getLocalGatewayNodePortIPTRules(svc *kapi.Service, svcPort kapi.ServicePort, gatewayIP string) []iptRule {
+	targetIP, targetPort := getLocalGatewayServiceTarget(svc, svcPort, gatewayIP)
+	rules := getNodePortIPTRules(svcPort, nil, targetIP, targetPort)
+	if targetIP == gatewayIP {
+		rules = append(rules, getSkipMgmtPortSNATIPTRule(svcPort, gatewayIP))
+	}
+	return rules
+}
+
 func (l *localPortWatcher) addService(svc *kapi.Service) error {
 	iptRules := []iptRule{}
 	isIPv6Service := utilnet.IsIPv6String(svc.Spec.ClusterIP)
@@ -180,20 +290,16 @@ func (l *localPortWatcher) addService(svc *kapi.Service) error {
 	}
 	// holds map of external ips and if they are currently using routes
 	routeUsage := make(map[string]bool)
+	// holds map of external ips and if they are currently claimed with proxy ARP/NDP
+	proxyUsage := make(map[string]bool)
 	for _, port := range svc.Spec.Ports {
-		if util.ServiceTypeHasClusterIP(svc) {
-			// Fix Azure/GCP LoadBalancers. They will forward traffic directly to the node with the
-			// dest address as the load-balancer ingress IP and port
-			iptRules = append(iptRules, getLoadBalancerIPTRules(svc, port, svc.Spec.ClusterIP, port.Port)...)
-		}
-
 		if util.ServiceTypeHasNodePort(svc) {
 			if err := util.ValidatePort(port.Protocol, port.NodePort); err != nil {
 				klog.Warningf("Invalid service node port %s, err: %v", port.Name, err)
 				continue
 			}
 			if gatewayIP != "" {
-				iptRules = append(iptRules, getNodePortIPTRules(port, nil, svc.Spec.ClusterIP, port.Port)...)
+				iptRules = append(iptRules, getLocalGatewayNodePortIPTRules(svc, port, gatewayIP)...)
 				klog.V(5).Infof("Will add iptables rule for NodePort: %v and "+
 					"protocol: %v", port.NodePort, port.Protocol)
 			} else {
@@ -201,38 +307,45 @@ func (l *localPortWatcher) addService(svc *kapi.Service) error {
 					svc.Namespace, svc.Name, svc.Spec.ClusterIP)
 			}
 		}
-		for _, externalIP := range svc.Spec.ExternalIPs {
+		// The external IPs and the ingress IPs of cloud load balancers are DNAT'ed like
+		// the node port, whether they are configured on the host or not
+		for _, externalIP := range getServiceExternalIPs(svc) {
 			if err := util.ValidatePort(port.Protocol, port.Port); err != nil {
 				klog.Warningf("Invalid service port %s, err: %v", port.Name, err)
 				break
 			}
+			if !util.IsClusterIPSet(svc) {
+				serviceRef := kapi.ObjectReference{
+					Kind:      "Service",
+					Namespace: svc.Namespace,
+					Name:      svc.Name,
+				}
+				l.recorder.Eventf(&serviceRef, kapi.EventTypeWarning, "UnsupportedServiceDefinition", "Unsupported service definition, headless service: %s with an ExternalIP or an ingress IP is not supported by ovn-kubernetes in local gateway mode", svc.Name)
+				klog.Warningf("UnsupportedServiceDefinition event for service %s in namespace %s", svc.Name, svc.Namespace)
+				continue
+			}
 			if utilnet.IsIPv6String(externalIP) != isIPv6Service {
 				klog.Warningf("Invalid ExternalIP %s for Service %s/%s with ClusterIP %s",
 					externalIP, svc.Namespace, svc.Name, svc.Spec.ClusterIP)
 				continue
 			}
+			dstIP, dstPort := getLocalGatewayServiceTarget(svc, port, gatewayIP)
+			iptRules = append(iptRules, getExternalIPTRules(port, externalIP, dstIP, dstPort)...)
+			klog.V(5).Infof("Will add iptables rule for ExternalIP: %s", externalIP)
 			if _, exists := l.localAddrSet[externalIP]; exists {
-				if !util.IsClusterIPSet(svc) {
-					serviceRef := kapi.ObjectReference{
-						Kind:      "Service",
-						Namespace: svc.Namespace,
-						Name:      svc.Name,
-					}
-					l.recorder.Eventf(&serviceRef, kapi.EventTypeWarning, "UnsupportedServiceDefinition", "Unsupported service definition, headless service: %s with a local ExternalIP is not supported by ovn-kubernetes in local gateway mode", svc.Name)
-					klog.Warningf("UnsupportedServiceDefinition event for service %s in namespace %s", svc.Name, svc.Namespace)
-					continue
-				}
-				iptRules = append(iptRules, getExternalIPTRules(port, externalIP, svc.Spec.ClusterIP)...)
-				klog.V(5).Infof("Will add iptables rule for ExternalIP: %s", externalIP)
-			} else if l.networkHasAddress(net.ParseIP(externalIP)) {
-				klog.V(5).Infof("ExternalIP: %s is reachable through one of the interfaces on this node, will skip setup", externalIP)
-			} else {
-				if gatewayIP != "" {
-					routeUsage[externalIP] = true
-				} else {
-					klog.Warningf("No gateway of appropriate IP family for ExternalIP %s for Service %s/%s",
-						externalIP, svc.Namespace, svc.Name)
-				}
+				continue
+			}
+			// The host claims the external IPs it does not have: the route makes it
+			// forward their traffic, and the ones on the network of one of its interfaces
+			// are also answered with proxy ARP/NDP
+			if gatewayIP == "" {
+				klog.Warningf("No gateway of appropriate IP family for ExternalIP %s for Service %s/%s",
+					externalIP, svc.Namespace, svc.Name)
+				continue
+			}
+			routeUsage[externalIP] = true
+			if l.networkHasAddress(net.ParseIP(externalIP)) {
+				proxyUsage[externalIP] = true
 			}
 		}
 	}
@@ -243,6 +356,13 @@ func (l *localPortWatcher) addService(svc *kapi.Service) error {
 			klog.Infof("Successfully added route for ExternalIP: %s", externalIP)
 		}
 	}
+	for externalIP := range proxyUsage {
+		if stdout, stderr, err := util.RunIP("neigh", "replace", "proxy", externalIP, "dev", l.gatewayIntf); err != nil {
+			klog.Errorf("Error adding proxy neighbor entry for ExternalIP %s on %s: stdout: %s, stderr: %s, err: %v", externalIP, l.gatewayIntf, stdout, stderr, err)
+		} else {
+			klog.Infof("Successfully added proxy neighbor entry for ExternalIP: %s", externalIP)
+		}
+	}
 	klog.Infof("Adding iptables rules: %v for service: %v", iptRules, svc.Name)
 	return addIptRules(iptRules)
 }
@@ -256,32 +376,33 @@ func (l *localPortWatcher) deleteService(svc *kapi.Service) error {
 	}
 	// holds map of external ips and if they are currently using routes
 	routeUsage := make(map[string]bool)
+	// holds map of external ips and if they are currently claimed with proxy ARP/NDP
+	proxyUsage := make(map[string]bool)
 	// Note that unlike with addService we just silently ignore IPv4/IPv6 mismatches here
 	for _, port := range svc.Spec.Ports {
-		if util.ServiceTypeHasClusterIP(svc) {
-			// Fix Azure/GCP LoadBalancers. They will forward traffic directly to the node with the
-			// dest address as the load-balancer ingress IP and port
-			iptRules = append(iptRules, getLoadBalancerIPTRules(svc, port, svc.Spec.ClusterIP, port.Port)...)
-		}
 		if util.ServiceTypeHasNodePort(svc) {
 			if gatewayIP != "" {
-				iptRules = append(iptRules, getNodePortIPTRules(port, nil, svc.Spec.ClusterIP, port.Port)...)
+				iptRules = append(iptRules, getLocalGatewayNodePortIPTRules(svc, port, gatewayIP)...)
 				klog.V(5).Infof("Will delete iptables rule for NodePort: %v and "+
 					"protocol: %v", port.NodePort, port.Protocol)
 			}
 		}
-		for _, externalIP := range svc.Spec.ExternalIPs {
-			if utilnet.IsIPv6String(externalIP) != isIPv6Service {
+		for _, externalIP := range getServiceExternalIPs(svc) {
+			if !util.IsClusterIPSet(svc) || utilnet.IsIPv6String(externalIP) != isIPv6Service {
 				continue
 			}
+			dstIP, dstPort := getLocalGatewayServiceTarget(svc, port, gatewayIP)
+			iptRules = append(iptRules, getExternalIPTRules(port, externalIP, dstIP, dstPort)...)
+			klog.V(5).Infof("Will delete iptables rule for ExternalIP: %s", externalIP)
 			if _, exists := l.localAddrSet[externalIP]; exists {
-				iptRules = append(iptRules, getExternalIPTRules(port, externalIP, svc.Spec.ClusterIP)...)
-				klog.V(5).Infof("Will delete iptables rule for ExternalIP: %s", externalIP)
-			} else if l.networkHasAddress(net.ParseIP(externalIP)) {
-				klog.V(5).Infof("ExternalIP: %s is reachable through one of the interfaces on this node, will skip cleanup", externalIP)
-			} else {
-				if gatewayIP != "" {
-					routeUsage[externalIP] = true
+				continue
+			}
+			// the route and the proxy neighbor entry are kept while another service has
+			// the external IP
+			if gatewayIP != "" && !l.externalIPInUse(externalIP) {
+				routeUsage[externalIP] = true
+				if l.networkHasAddress(net.ParseIP(externalIP)) {
+					proxyUsage[externalIP] = true
 				}
 			}
 		}
@@ -294,6 +415,13 @@ func (l *localPortWatcher) deleteService(svc *kapi.Service) error {
 			klog.Infof("Successfully deleted route for ExternalIP: %s", externalIP)
 		}
 	}
+	for externalIP := range proxyUsage {
+		if stdout, stderr, err := util.RunIP("neigh", "del", "proxy", externalIP, "dev", l.gatewayIntf); err != nil {
+			klog.Errorf("Error deleting proxy neighbor entry for ExternalIP %s on %s: stdout: %s, stderr: %s, err: %v", externalIP, l.gatewayIntf, stdout, stderr, err)
+		} else {
+			klog.Infof("Successfully deleted proxy neighbor entry for ExternalIP: %s", externalIP)
+		}
+	}
 
 	klog.Infof("Deleting iptables rules: %v for service: %v", iptRules, svc.Name)
 	return delIptRules(iptRules)
@@ -320,6 +448,18 @@ func (l *localPortWatcher) SyncServices(serviceInterface []interface{}) {
 				if _, stderr, err := util.RunIP("route", "del", existingRoute, "table", localnetGatewayExternalIDTable); err != nil {
 					klog.Errorf("Error deleting stale routing rule: stderr: %s, err: %v", stderr, err)
 				}
+				// the external IPs on the network of an interface were also claimed
+				// with a proxy neighbor entry
+				fields := strings.Fields(existingRoute)
+				if len(fields) == 0 {
+					continue
+				}
+				if ip := net.ParseIP(fields[0]); ip != nil && l.networkHasAddress(ip) {
+					klog.Infof("Deleting stale proxy neighbor entry: %s", fields[0])
+					if _, stderr, err := util.RunIP("neigh", "del", "proxy", fields[0], "dev", l.gatewayIntf); err != nil {
+						klog.Errorf("Error deleting stale proxy neighbor entry: stderr: %s, err: %v", stderr, err)
+					}
+				}
 			}
 		}
 	}
@@ -331,14 +471,23 @@ func (l *localPortWatcher) SyncServices(serviceInterface []interface{}) {
 			klog.Errorf("Spurious object in syncServices: %v", serviceInterface)
 			continue
 		}
+		l.services[ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}] = svc
 		gatewayIP := l.gatewayIPv4
 		if utilnet.IsIPv6String(svc.Spec.ClusterIP) {
 			gatewayIP = l.gatewayIPv6
 		}
-		if gatewayIP != "" {
-			keepIPTRules = append(keepIPTRules, getGatewayIPTRules(svc, gatewayIP, nil)...)
+		if gatewayIP != "" && util.ServiceTypeHasClusterIP(svc) && util.IsClusterIPSet(svc) {
+			for _, svcPort := range svc.Spec.Ports {
+				if util.ServiceTypeHasNodePort(svc) && util.ValidatePort(svcPort.Protocol, svcPort.NodePort) == nil {
+					keepIPTRules = append(keepIPTRules, getLocalGatewayNodePortIPTRules(svc, svcPort, gatewayIP)...)
+				}
+				for _, externalIP := range getServiceExternalIPs(svc) {
+					dstIP, dstPort := getLocalGatewayServiceTarget(svc, svcPort, gatewayIP)
+					keepIPTRules = append(keepIPTRules, getExternalIPTRules(svcPort, externalIP, dstIP, dstPort)...)
+				}
+			}
 		}
-		keepRoutes = append(keepRoutes, svc.Spec.ExternalIPs...)
+		keepRoutes = append(keepRoutes, getServiceExternalIPs(svc)...)
 	}
 	for _, chain := range []string{iptableNodePortChain, iptableExternalIPChain} {
 		recreateIPTRules("nat", chain, keepIPTRules)
@@ -381,35 +530,6 @@ func cleanupLocalnetGateway(physnet string) error {
 	return err
 }
 
-func getLoadBalancerIPTRules(svc *kapi.Service, svcPort kapi.ServicePort, gatewayIP string, targetPort int32) []iptRule {
-	var rules []iptRule
-	ingPort := fmt.Sprintf("%d", svcPort.Port)
-	for _, ing := range svc.Status.LoadBalancer.Ingress {
-		if ing.IP == "" {
-			continue
-		}
-		rules = append(rules, iptRule{
-			table: "nat",
-			chain: iptableNodePortChain,
-			args: []string{
-				"-d", ing.IP,
-				"-p", string(svcPort.Protocol), "--dport", ingPort,
-				"-j", "DNAT", "--to-destination", util.JoinHostPortInt32(gatewayIP, targetPort),
-			},
-		})
-		rules = append(rules, iptRule{
-			table: "filter",
-			chain: iptableNodePortChain,
-			args: []string{
-				"-d", ing.IP,
-				"-p", string(svcPort.Protocol), "--dport", ingPort,
-				"-j", "ACCEPT",
-			},
-		})
-	}
-	return rules
-}
-
 // since we share the host's k8s node IP, add OpenFlow flows
 // -- to steer the NodePort traffic arriving on the host to the OVN logical topology and
 // -- to also connection track the outbound north-south traffic through l3 gateway so that
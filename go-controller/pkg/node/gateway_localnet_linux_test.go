@@ -20,7 +20,8 @@ import (
 )
 
 const (
-	v4localnetGatewayIP = "10.244.0.1"
+	v4localnetGatewayIP   = "10.244.0.1"
+	localnetGatewayBridge = "breth0"
 )
 
 func getFakeLocalAddrs() map[string]net.IPNet {
@@ -50,7 +51,9 @@ func initFakeNodePortWatcher(fakeOvnNode *FakeOVNNode, iptV4, iptV6 util.IPTable
 	fNPW := localPortWatcher{
 		recorder:     fakeOvnNode.recorder,
 		gatewayIPv4:  v4localnetGatewayIP,
+		gatewayIntf:  localnetGatewayBridge,
 		localAddrSet: getFakeLocalAddrs(),
+		services:     make(map[k8stypes.NamespacedName]*kapi.Service),
 	}
 	return &fNPW
 }
@@ -186,7 +189,7 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("removes stale physical routing rules while keeping remaining intact", func() {
+		It("removes stale physical routing rules and proxy neighbor entries while keeping remaining intact", func() {
 			app.Action = func(ctx *cli.Context) error {
 
 				externalIP := "1.1.1.1"
@@ -205,10 +208,12 @@ var _ = Describe("Node Operations", func() {
 				})
 				fakeOvnNode.fakeExec.AddFakeCmd(&ovntest.ExpectedCmd{
 					Cmd:    "ip route list table " + localnetGatewayExternalIDTable,
-					Output: fmt.Sprintf("%s via %s dev %s\n9.9.9.9 via %s dev %s\n", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, v4localnetGatewayIP, types.K8sMgmtIntfName),
+					Output: fmt.Sprintf("%s via %s dev %s\n9.9.9.9 via %s dev %s\n10.10.10.9 via %s dev %s\n", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, v4localnetGatewayIP, types.K8sMgmtIntfName, v4localnetGatewayIP, types.K8sMgmtIntfName),
 				})
 				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
 					fmt.Sprintf("ip route del 9.9.9.9 via %s dev %s table %s", v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip route del 10.10.10.9 via %s dev %s table %s", v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh del proxy 10.10.10.9 dev %s", localnetGatewayBridge),
 				})
 				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
 					fmt.Sprintf("ip route replace %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
@@ -225,7 +230,7 @@ var _ = Describe("Node Operations", func() {
 					[]string{externalIP},
 				)
 
-				fakeRules := getExternalIPTRules(service.Spec.Ports[0], externalIP, service.Spec.ClusterIP)
+				fakeRules := getExternalIPTRules(service.Spec.Ports[0], externalIP, service.Spec.ClusterIP, service.Spec.Ports[0].Port)
 				addIptRules(fakeRules)
 				fakeRules = getExternalIPTRules(
 					v1.ServicePort{
@@ -235,6 +240,7 @@ var _ = Describe("Node Operations", func() {
 					},
 					"10.10.10.10",
 					"172.32.0.12",
+					27000,
 				)
 				addIptRules(fakeRules)
 
@@ -332,16 +338,29 @@ var _ = Describe("Node Operations", func() {
 				})
 
 				fNPW.addService(&service)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
 				expectedTables := map[string]util.FakeTable{
-					"filter": {},
-					"nat":    {},
+					"filter": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j ACCEPT", service.Spec.Ports[0].Protocol, externalIP, service.Spec.Ports[0].Port),
+						},
+					},
+					"nat": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j DNAT --to-destination %s:%v", service.Spec.Ports[0].Protocol, externalIP, service.Spec.Ports[0].Port, service.Spec.ClusterIP, service.Spec.Ports[0].Port),
+						},
+					},
 				}
 
 				f4 := iptV4.(*util.FakeIPTables)
 				err := f4.MatchState(expectedTables)
 				Expect(err).NotTo(HaveOccurred())
 
+				expectedTables = map[string]util.FakeTable{
+					"filter": {},
+					"nat":    {},
+				}
 				f6 := iptV6.(*util.FakeIPTables)
 				err = f6.MatchState(expectedTables)
 				Expect(err).NotTo(HaveOccurred())
@@ -352,7 +371,7 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("does nothing when ExternalIP on shared network", func() {
+		It("claims ExternalIP on shared network with proxy ARP", func() {
 			app.Action = func(ctx *cli.Context) error {
 
 				externalIP := "10.10.10.2"
@@ -371,19 +390,82 @@ var _ = Describe("Node Operations", func() {
 					[]string{externalIP},
 				)
 
+				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ip route replace %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh replace proxy %s dev %s", externalIP, localnetGatewayBridge),
+				})
+
 				fNPW.addService(&service)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
 				expectedTables := map[string]util.FakeTable{
-					"filter": {},
-					"nat":    {},
+					"filter": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j ACCEPT", service.Spec.Ports[0].Protocol, externalIP, service.Spec.Ports[0].Port),
+						},
+					},
+					"nat": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j DNAT --to-destination %s:%v", service.Spec.Ports[0].Protocol, externalIP, service.Spec.Ports[0].Port, service.Spec.ClusterIP, service.Spec.Ports[0].Port),
+						},
+					},
 				}
 
 				f4 := iptV4.(*util.FakeIPTables)
 				err := f4.MatchState(expectedTables)
 				Expect(err).NotTo(HaveOccurred())
 
-				f6 := iptV6.(*util.FakeIPTables)
-				err = f6.MatchState(expectedTables)
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("inits physical routing rules and iptables rules with LoadBalancer ingress IP", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				ingressIP := "5.5.5.5"
+
+				iptV4, iptV6 := util.SetFakeIPTablesHelpers()
+				fNPW := initFakeNodePortWatcher(fakeOvnNode, iptV4, iptV6)
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolUDP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+					{IP: ingressIP},
+					{Hostname: "lb.example.com"},
+				}
+
+				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ip route replace %s via %s dev %s table %s", ingressIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+				})
+
+				fNPW.addService(&service)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				expectedTables := map[string]util.FakeTable{
+					"filter": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j ACCEPT", service.Spec.Ports[0].Protocol, ingressIP, service.Spec.Ports[0].Port),
+						},
+					},
+					"nat": {
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p %s -d %s --dport %v -j DNAT --to-destination %s:%v", service.Spec.Ports[0].Protocol, ingressIP, service.Spec.Ports[0].Port, service.Spec.ClusterIP, service.Spec.Ports[0].Port),
+						},
+					},
+				}
+
+				f4 := iptV4.(*util.FakeIPTables)
+				err := f4.MatchState(expectedTables)
 				Expect(err).NotTo(HaveOccurred())
 
 				return nil
@@ -480,6 +562,76 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("inits iptables rules with NodePort and the Local external traffic policy", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				nodePort := int32(31111)
+
+				iptV4, iptV6 := util.SetFakeIPTablesHelpers()
+				fNPW := initFakeNodePortWatcher(fakeOvnNode, iptV4, iptV6)
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8080,
+							NodePort: nodePort,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+					[]string{"10.10.10.1"},
+				)
+				service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+
+				fNPW.addService(&service)
+
+				expectedTables := map[string]util.FakeTable{
+					"filter": {
+						"OVN-KUBE-NODEPORT": []string{
+							fmt.Sprintf("-p TCP --dport %v -j ACCEPT", nodePort),
+						},
+						"OVN-KUBE-EXTERNALIP": []string{
+							"-p TCP -d 10.10.10.1 --dport 8080 -j ACCEPT",
+						},
+					},
+					"nat": {
+						"OVN-KUBE-NODEPORT": []string{
+							fmt.Sprintf("-p TCP --dport %v -j DNAT --to-destination %s:%v", nodePort, v4localnetGatewayIP, nodePort),
+						},
+						"OVN-KUBE-EXTERNALIP": []string{
+							fmt.Sprintf("-p TCP -d 10.10.10.1 --dport 8080 -j DNAT --to-destination %s:%v", v4localnetGatewayIP, nodePort),
+						},
+						iptableMgmPortChain: []string{
+							fmt.Sprintf("-p TCP -d %s --dport %v -j RETURN", v4localnetGatewayIP, nodePort),
+						},
+					},
+				}
+
+				f4 := iptV4.(*util.FakeIPTables)
+				err := f4.MatchState(expectedTables)
+				Expect(err).NotTo(HaveOccurred())
+
+				fNPW.deleteService(&service)
+				expectedTables = map[string]util.FakeTable{
+					"filter": {
+						"OVN-KUBE-NODEPORT":   []string{},
+						"OVN-KUBE-EXTERNALIP": []string{},
+					},
+					"nat": {
+						"OVN-KUBE-NODEPORT":   []string{},
+						"OVN-KUBE-EXTERNALIP": []string{},
+						iptableMgmPortChain:   []string{},
+					},
+				}
+				err = f4.MatchState(expectedTables)
+				Expect(err).NotTo(HaveOccurred())
+
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("emits event when ExternalIP attached to network interface with headless service", func() {
 			app.Action = func(ctx *cli.Context) error {
 
@@ -572,7 +724,7 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("does nothing when ExternalIP on shared network", func() {
+		It("deletes proxy neighbor entry with ExternalIP on shared network", func() {
 			app.Action = func(ctx *cli.Context) error {
 
 				externalIP := "10.10.10.2"
@@ -591,7 +743,13 @@ var _ = Describe("Node Operations", func() {
 					[]string{externalIP},
 				)
 
+				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ip route del %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh del proxy %s dev %s", externalIP, localnetGatewayBridge),
+				})
+
 				fNPW.deleteService(&service)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
 				expectedTables := map[string]util.FakeTable{
 					"filter": {},
@@ -612,6 +770,75 @@ var _ = Describe("Node Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("keeps the route and proxy neighbor entry of an ExternalIP shared with another service", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				externalIP := "10.10.10.2"
+
+				iptV4, iptV6 := util.SetFakeIPTablesHelpers()
+				fNPW := initFakeNodePortWatcher(fakeOvnNode, iptV4, iptV6)
+
+				service1 := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					[]string{externalIP},
+				)
+				service2 := *newService("service2", "namespace1", "10.129.0.3",
+					[]v1.ServicePort{
+						{
+							Port:     8033,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					[]string{externalIP},
+				)
+
+				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ip route replace %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh replace proxy %s dev %s", externalIP, localnetGatewayBridge),
+					fmt.Sprintf("ip route replace %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh replace proxy %s dev %s", externalIP, localnetGatewayBridge),
+				})
+				fNPW.AddService(&service1)
+				fNPW.AddService(&service2)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				// the route and the proxy neighbor entry are only deleted with the last service
+				fNPW.DeleteService(&service1)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				fakeOvnNode.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ip route del %s via %s dev %s table %s", externalIP, v4localnetGatewayIP, types.K8sMgmtIntfName, localnetGatewayExternalIDTable),
+					fmt.Sprintf("ip neigh del proxy %s dev %s", externalIP, localnetGatewayBridge),
+				})
+				fNPW.DeleteService(&service2)
+				Expect(fakeOvnNode.fakeExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				expectedTables := map[string]util.FakeTable{
+					"filter": {
+						"OVN-KUBE-EXTERNALIP": []string{},
+					},
+					"nat": {
+						"OVN-KUBE-EXTERNALIP": []string{},
+					},
+				}
+
+				f4 := iptV4.(*util.FakeIPTables)
+				err := f4.MatchState(expectedTables)
+				Expect(err).NotTo(HaveOccurred())
+
+				return nil
+			}
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("deletes iptables rules with ExternalIP attached to network interface", func() {
 			app.Action = func(ctx *cli.Context) error {
 
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube/healthcheck"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -18,34 +20,61 @@ import (
 // ServiceTypeLoadBalancer services
 
 type loadBalancerHealthChecker struct {
-	nodeName  string
-	server    healthcheck.Server
-	services  map[ktypes.NamespacedName]uint16
-	endpoints map[ktypes.NamespacedName]int
+	sync.Mutex
+	nodeName string
+	server   healthcheck.Server
+	// services and endpoints are updated from both the Service and the
+	// Endpoints informers, and are protected by the mutex
+	services     map[ktypes.NamespacedName]uint16
+	endpoints    map[ktypes.NamespacedName]int
+	watchFactory factory.NodeWatchFactory
 }
 
-func newLoadBalancerHealthChecker(nodeName string) *loadBalancerHealthChecker {
+func newLoadBalancerHealthChecker(nodeName string, watchFactory factory.NodeWatchFactory) *loadBalancerHealthChecker {
 	return &loadBalancerHealthChecker{
-		nodeName:  nodeName,
-		server:    healthcheck.NewServer(nodeName, nil, nil, nil),
-		services:  make(map[ktypes.NamespacedName]uint16),
-		endpoints: make(map[ktypes.NamespacedName]int),
+		nodeName:     nodeName,
+		server:       healthcheck.NewServer(nodeName, nil, nil, nil),
+		services:     make(map[ktypes.NamespacedName]uint16),
+		endpoints:    make(map[ktypes.NamespacedName]int),
+		watchFactory: watchFactory,
 	}
 }
 
 func (l *loadBalancerHealthChecker) AddService(svc *kapi.Service) {
+	l.Lock()
+	defer l.Unlock()
 	if svc.Spec.HealthCheckNodePort != 0 {
 		name := ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
 		l.services[name] = uint16(svc.Spec.HealthCheckNodePort)
+		l.countServiceEndpoints(name)
 		_ = l.server.SyncServices(l.services)
+		_ = l.server.SyncEndpoints(l.endpoints)
 	}
 }
 
 func (l *loadBalancerHealthChecker) UpdateService(old, new *kapi.Service) {
-	// HealthCheckNodePort can't be changed on update
+	l.Lock()
+	defer l.Unlock()
+	// HealthCheckNodePort is allocated or released when the external traffic policy
+	// changes between Cluster and Local
+	if old.Spec.HealthCheckNodePort == new.Spec.HealthCheckNodePort {
+		return
+	}
+	name := ktypes.NamespacedName{Namespace: new.Namespace, Name: new.Name}
+	if new.Spec.HealthCheckNodePort != 0 {
+		l.services[name] = uint16(new.Spec.HealthCheckNodePort)
+		l.countServiceEndpoints(name)
+	} else {
+		delete(l.services, name)
+		delete(l.endpoints, name)
+	}
+	_ = l.server.SyncServices(l.services)
+	_ = l.server.SyncEndpoints(l.endpoints)
 }
 
 func (l *loadBalancerHealthChecker) DeleteService(svc *kapi.Service) {
+	l.Lock()
+	defer l.Unlock()
 	if svc.Spec.HealthCheckNodePort != 0 {
 		name := ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
 		delete(l.services, name)
@@ -54,9 +83,30 @@ func (l *loadBalancerHealthChecker) DeleteService(svc *kapi.Service) {
 	}
 }
 
-func (l *loadBalancerHealthChecker) SyncServices(svcs []interface{}) {}
+func (l *loadBalancerHealthChecker) SyncServices(svcs []interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.services = make(map[ktypes.NamespacedName]uint16)
+	l.endpoints = make(map[ktypes.NamespacedName]int)
+	for _, svcInterface := range svcs {
+		svc, ok := svcInterface.(*kapi.Service)
+		if !ok {
+			klog.Errorf("Spurious object in syncServices: %v", svcInterface)
+			continue
+		}
+		if svc.Spec.HealthCheckNodePort != 0 {
+			name := ktypes.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
+			l.services[name] = uint16(svc.Spec.HealthCheckNodePort)
+			l.countServiceEndpoints(name)
+		}
+	}
+	_ = l.server.SyncServices(l.services)
+	_ = l.server.SyncEndpoints(l.endpoints)
+}
 
 func (l *loadBalancerHealthChecker) AddEndpoints(ep *kapi.Endpoints) {
+	l.Lock()
+	defer l.Unlock()
 	name := ktypes.NamespacedName{Namespace: ep.Namespace, Name: ep.Name}
 	if _, exists := l.services[name]; exists {
 		l.endpoints[name] = countLocalEndpoints(ep, l.nodeName)
@@ -65,6 +115,8 @@ func (l *loadBalancerHealthChecker) AddEndpoints(ep *kapi.Endpoints) {
 }
 
 func (l *loadBalancerHealthChecker) UpdateEndpoints(old, new *kapi.Endpoints) {
+	l.Lock()
+	defer l.Unlock()
 	name := ktypes.NamespacedName{Namespace: new.Namespace, Name: new.Name}
 	if _, exists := l.services[name]; exists {
 		l.endpoints[name] = countLocalEndpoints(new, l.nodeName)
@@ -74,11 +126,29 @@ func (l *loadBalancerHealthChecker) UpdateEndpoints(old, new *kapi.Endpoints) {
 }
 
 func (l *loadBalancerHealthChecker) DeleteEndpoints(ep *kapi.Endpoints) {
+	l.Lock()
+	defer l.Unlock()
 	name := ktypes.NamespacedName{Namespace: ep.Namespace, Name: ep.Name}
 	delete(l.endpoints, name)
 	_ = l.server.SyncEndpoints(l.endpoints)
 }
 
+// countServiceEndpoints counts the local endpoints of a service from the endpoints in
+// the informer cache, as the endpoints events of the service may have been handled
+// before it got a health check node port. Caller must hold the mutex.
+func (l *loadBalancerHealthChecker) countServiceEndpoints(name ktypes.NamespacedName) {
+	ep, err := l.watchFactory.GetEndpoint(name.Namespace, name.Name)
+	if err != nil {
+		klog.V(5).Infof("No endpoints found for service %s: %v", name, err)
+		delete(l.endpoints, name)
+		return
+	}
+	l.endpoints[name] = countLocalEndpoints(ep, l.nodeName)
+}
+
+// countLocalEndpoints returns the number of ready endpoints on the node, which are the
+// endpoints the node's gateway router load balances to for services with the Local
+// external traffic policy
 func countLocalEndpoints(ep *kapi.Endpoints, nodeName string) int {
 	num := 0
 	for i := range ep.Subsets {
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeHealthCheckServer records the services and local endpoint counts synced to it
+type fakeHealthCheckServer struct {
+	sync.Mutex
+	services  map[ktypes.NamespacedName]uint16
+	endpoints map[ktypes.NamespacedName]int
+}
+
+func (s *fakeHealthCheckServer) SyncServices(newServices map[ktypes.NamespacedName]uint16) error {
+	s.Lock()
+	defer s.Unlock()
+	s.services = map[ktypes.NamespacedName]uint16{}
+	for name, port := range newServices {
+		s.services[name] = port
+	}
+	return nil
+}
+
+func (s *fakeHealthCheckServer) SyncEndpoints(newEndpoints map[ktypes.NamespacedName]int) error {
+	s.Lock()
+	defer s.Unlock()
+	s.endpoints = map[ktypes.NamespacedName]int{}
+	for name, count := range newEndpoints {
+		s.endpoints[name] = count
+	}
+	return nil
+}
+
+func (s *fakeHealthCheckServer) getEndpoints(name ktypes.NamespacedName) int {
+	s.Lock()
+	defer s.Unlock()
+	return s.endpoints[name]
+}
+
+var _ = Describe("Node load balancer health checks", func() {
+	const nodeName = "node1"
+
+	var (
+		stopChan chan struct{}
+		wf       *factory.WatchFactory
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+		stopChan = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		close(stopChan)
+		wf.Shutdown()
+	})
+
+	It("counts the local endpoints on a master watch factory with EndpointSlices enabled", func() {
+		// In combined master and node mode the node shares the master's
+		// watch factory, which must still serve Endpoints to the node
+		config.OVNKubernetesFeature.EnableEndpointSlices = true
+
+		svcName := ktypes.NamespacedName{Namespace: "default", Name: "service1"}
+		nodeNameRef := nodeName
+		fakeClient := util.GetOVNClientset(
+			&kapi.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: svcName.Name, Namespace: svcName.Namespace},
+				Spec: kapi.ServiceSpec{
+					Type:                  kapi.ServiceTypeLoadBalancer,
+					ClusterIP:             "172.30.0.10",
+					ExternalTrafficPolicy: kapi.ServiceExternalTrafficPolicyTypeLocal,
+					HealthCheckNodePort:   32000,
+				},
+			},
+			&kapi.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: svcName.Name, Namespace: svcName.Namespace},
+				Subsets: []kapi.EndpointSubset{{
+					Addresses: []kapi.EndpointAddress{
+						{IP: "10.128.0.5", NodeName: &nodeNameRef},
+					},
+				}},
+			},
+		)
+		var err error
+		wf, err = factory.NewMasterWatchFactory(fakeClient)
+		Expect(err).NotTo(HaveOccurred())
+
+		n := NewNode(fakeClient.KubeClient, wf, nodeName, stopChan, record.NewFakeRecorder(10))
+		n.WatchEndpoints()
+
+		server := &fakeHealthCheckServer{}
+		l := newLoadBalancerHealthChecker(nodeName, wf)
+		l.server = server
+		wf.AddServiceHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				l.AddService(obj.(*kapi.Service))
+			},
+		}, l.SyncServices)
+		wf.AddEndpointsHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				l.AddEndpoints(obj.(*kapi.Endpoints))
+			},
+			UpdateFunc: func(old, new interface{}) {
+				l.UpdateEndpoints(old.(*kapi.Endpoints), new.(*kapi.Endpoints))
+			},
+		}, nil)
+		Expect(server.getEndpoints(svcName)).To(Equal(1))
+
+		ep, err := fakeClient.KubeClient.CoreV1().Endpoints(svcName.Namespace).Get(context.TODO(), svcName.Name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		ep.Subsets[0].Addresses = append(ep.Subsets[0].Addresses, kapi.EndpointAddress{IP: "10.128.0.6", NodeName: &nodeNameRef})
+		_, err = fakeClient.KubeClient.CoreV1().Endpoints(svcName.Namespace).Update(context.TODO(), ep, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() int { return server.getEndpoints(svcName) }).Should(Equal(2))
+	})
+})
@@ -100,6 +100,10 @@ func getIntfName(gatewayIntf string) (string, error) {
 	return intfName, nil
 }
 
-func deleteConntrack(ip string, port int32, protocol kapi.Protocol) error {
+func deleteConntrack(ip string, port int32, protocol kapi.Protocol) (uint, error) {
 	return util.DeleteConntrack(ip, port, protocol)
 }
+
+func deleteConntrackServiceVIP(vip string, port int32, protocol kapi.Protocol, backendIP string) (uint, error) {
+	return util.DeleteConntrackServiceVIP(vip, port, protocol, backendIP)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/informer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -220,6 +221,21 @@ func (n *OvnNode) Start(wg *sync.WaitGroup) error {
 		return err
 	}
 
+	// Map the physical networks of the localnet secondary networks to their bridges
+	if err := setupLocalnetBridgeMappings(); err != nil {
+		return err
+	}
+
+	var egressNetworks []util.NodeEgressNetwork
+	if config.OVNKubernetesFeature.EnableEgressIP && config.OVNKubernetesFeature.EgressIPInterfaces != "" {
+		if egressNetworks, err = getSecondaryEgressNetworks(config.OVNKubernetesFeature.EgressIPInterfaces); err != nil {
+			return err
+		}
+	}
+	if err := util.SetNodeEgressNetworks(nodeAnnotator, egressNetworks); err != nil {
+		return err
+	}
+
 	if err := nodeAnnotator.Run(); err != nil {
 		return fmt.Errorf("failed to set node %s annotations: %v", n.name, err)
 	}
@@ -271,6 +287,30 @@ func (n *OvnNode) Start(wg *sync.WaitGroup) error {
 	}
 
 	n.WatchEndpoints()
+	n.WatchServices()
+
+	if config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping {
+		snooper := newEgressFirewallDNSSnooper(n.name, n.watchFactory)
+		if err := snooper.Start(n.stopChan); err != nil {
+			return fmt.Errorf("failed to start snooping egress firewall DNS responses: %v", err)
+		}
+	}
+
+	if len(egressNetworks) > 0 {
+		egressIPController, err := newSecondaryEgressIPController(n.name, n.watchFactory, egressNetworks)
+		if err != nil {
+			return err
+		}
+		if err := egressIPController.Start(n.stopChan); err != nil {
+			return fmt.Errorf("failed to start hosting egress IPs on the secondary egress interfaces: %v", err)
+		}
+	}
+
+	if config.OVNKubernetesFeature.EnableEgressIP && config.OVNKubernetesFeature.EgressIPNodeHealthCheckPort != 0 {
+		if err := startEgressIPHealthCheckServer(config.OVNKubernetesFeature.EgressIPNodeHealthCheckPort, n.stopChan); err != nil {
+			return err
+		}
+	}
 
 	cniServer := cni.NewCNIServer("", n.watchFactory)
 	err = cniServer.Start(cni.HandleCNIRequest)
@@ -289,30 +329,135 @@ func (n *OvnNode) WatchEndpoints() {
 			newEpAddressMap := buildEndpointAddressMap(epNew.Subsets)
 			for item := range buildEndpointAddressMap(epOld.Subsets) {
 				if _, ok := newEpAddressMap[item]; !ok {
-					err := deleteConntrack(item.ip, item.port, item.protocol)
-					if err != nil {
-						klog.Errorf("Failed to delete conntrack entry for %s: %v", item.ip, err)
-					}
+					n.deleteEndpointConntrack(epNew, item)
 				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			ep := obj.(*kapi.Endpoints)
 			for item := range buildEndpointAddressMap(ep.Subsets) {
-				err := deleteConntrack(item.ip, item.port, item.protocol)
-				if err != nil {
-					klog.Errorf("Failed to delete conntrack entry for %s: %v", item.ip, err)
-				}
+				n.deleteEndpointConntrack(ep, item)
+			}
+		},
+	}, nil)
+}
 
+// WatchServices flushes the conntrack entries of the UDP and SCTP connections to the VIPs a
+// service stops serving. The Endpoints of a deleted service are usually deleted after it,
+// when its VIPs are not known anymore to flush the entries of its endpoints.
+func (n *OvnNode) WatchServices() {
+	n.watchFactory.AddServiceHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			svcOld := old.(*kapi.Service)
+			svcNew := new.(*kapi.Service)
+			newVIPs := make(map[epAddressItem]struct{})
+			for _, vip := range getServiceConntrackVIPs(svcNew) {
+				newVIPs[vip] = struct{}{}
+			}
+			for _, vip := range getServiceConntrackVIPs(svcOld) {
+				if _, ok := newVIPs[vip]; !ok {
+					deleteServiceVIPConntrack(svcOld, vip)
+				}
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			svc := obj.(*kapi.Service)
+			for _, vip := range getServiceConntrackVIPs(svc) {
+				deleteServiceVIPConntrack(svc, vip)
 			}
 		},
 	}, nil)
 }
 
+// getServiceConntrackVIPs returns the VIPs of the UDP and SCTP ports of a service
+func getServiceConntrackVIPs(svc *kapi.Service) []epAddressItem {
+	vips := []epAddressItem{}
+	for _, svcPort := range svc.Spec.Ports {
+		if svcPort.Protocol != kapi.ProtocolUDP && svcPort.Protocol != kapi.ProtocolSCTP {
+			continue
+		}
+		vips = append(vips, getServiceVIPs(svc, epAddressItem{protocol: svcPort.Protocol, name: svcPort.Name})...)
+	}
+	return vips
+}
+
+// deleteServiceVIPConntrack flushes the conntrack entries of the connections sent to a VIP
+// of a service, whatever endpoint they were DNAT'ed to
+func deleteServiceVIPConntrack(svc *kapi.Service, vip epAddressItem) {
+	flushed, err := deleteConntrackServiceVIP(vip.ip, vip.port, vip.protocol, "")
+	if err != nil {
+		klog.Errorf("Failed to delete conntrack entries of service %s/%s VIP %s: %v",
+			svc.Namespace, svc.Name, util.JoinHostPortInt32(vip.ip, vip.port), err)
+		return
+	}
+	klog.V(5).Infof("Deleted %d conntrack entries for service %s/%s VIP %s",
+		flushed, svc.Namespace, svc.Name, util.JoinHostPortInt32(vip.ip, vip.port))
+	metrics.MetricConntrackEntriesFlushed.WithLabelValues(string(vip.protocol)).Add(float64(flushed))
+}
+
+// deleteEndpointConntrack flushes the conntrack entries of a removed UDP or SCTP endpoint,
+// in the host conntrack zone as well as in the OVN ones: the entries of the connections
+// sent to the endpoint itself, and the ones of the connections DNAT'ed to it from each
+// VIP of its service. Otherwise, the traffic of the stale connections, like DNS queries
+// reusing the same source port, keeps being sent to the removed endpoint.
+func (n *OvnNode) deleteEndpointConntrack(ep *kapi.Endpoints, item epAddressItem) {
+	flushed, err := deleteConntrack(item.ip, item.port, item.protocol)
+	if err != nil {
+		klog.Errorf("Failed to delete conntrack entry for %s: %v", item.ip, err)
+	}
+	svc, err := n.watchFactory.GetService(ep.Namespace, ep.Name)
+	if err != nil {
+		klog.V(5).Infof("Not deleting the conntrack entries of the VIPs of service %s/%s for endpoint %s: %v",
+			ep.Namespace, ep.Name, item.ip, err)
+	} else {
+		for _, vip := range getServiceVIPs(svc, item) {
+			count, err := deleteConntrackServiceVIP(vip.ip, vip.port, vip.protocol, item.ip)
+			if err != nil {
+				klog.Errorf("Failed to delete conntrack entries of service %s/%s VIP %s for endpoint %s: %v",
+					svc.Namespace, svc.Name, util.JoinHostPortInt32(vip.ip, vip.port), item.ip, err)
+				continue
+			}
+			flushed += count
+		}
+	}
+	klog.V(5).Infof("Deleted %d conntrack entries for endpoint %s of service %s/%s",
+		flushed, util.JoinHostPortInt32(item.ip, item.port), ep.Namespace, ep.Name)
+	metrics.MetricConntrackEntriesFlushed.WithLabelValues(string(item.protocol)).Add(float64(flushed))
+}
+
+// getServiceVIPs returns the VIPs of a service that are load balanced to the endpoint port:
+// the cluster IP, external IPs and ingress IPs with the service port, and the node port with
+// an empty IP, as it is load balanced on all the node IPs
+func getServiceVIPs(svc *kapi.Service, item epAddressItem) []epAddressItem {
+	vips := []epAddressItem{}
+	for _, svcPort := range svc.Spec.Ports {
+		if svcPort.Name != item.name || svcPort.Protocol != item.protocol {
+			continue
+		}
+		if util.IsClusterIPSet(svc) {
+			vips = append(vips, epAddressItem{ip: svc.Spec.ClusterIP, port: svcPort.Port, protocol: svcPort.Protocol})
+		}
+		for _, externalIP := range svc.Spec.ExternalIPs {
+			vips = append(vips, epAddressItem{ip: externalIP, port: svcPort.Port, protocol: svcPort.Protocol})
+		}
+		for _, ing := range svc.Status.LoadBalancer.Ingress {
+			if ing.IP != "" {
+				vips = append(vips, epAddressItem{ip: ing.IP, port: svcPort.Port, protocol: svcPort.Protocol})
+			}
+		}
+		if util.ServiceTypeHasNodePort(svc) && svcPort.NodePort != 0 {
+			vips = append(vips, epAddressItem{port: svcPort.NodePort, protocol: svcPort.Protocol})
+		}
+	}
+	return vips
+}
+
 type epAddressItem struct {
 	ip       string
 	port     int32
 	protocol kapi.Protocol
+	// name is the name of the endpoint port, which is the one of its service port
+	name string
 }
 
 //buildEndpointAddressMap builds a map of all UDP and SCTP ports in the endpoint subset along with that port's IP address
@@ -326,6 +471,7 @@ func buildEndpointAddressMap(epSubsets []kapi.EndpointSubset) map[epAddressItem]
 						ip:       address.IP,
 						port:     port.Port,
 						protocol: port.Protocol,
+						name:     port.Name,
 					}] = struct{}{}
 				}
 			}
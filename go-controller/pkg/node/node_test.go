@@ -1,13 +1,18 @@
 package node
 
 import (
+	"context"
 	"fmt"
+	"sync/atomic"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/urfave/cli/v2"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util/mocks"
 	kapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -145,4 +150,89 @@ var _ = Describe("Node Operations", func() {
 		err := app.Run([]string{app.Name})
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("maps the physical networks of the localnet networks to their bridges", func() {
+		app.Action = func(ctx *cli.Context) error {
+			fexec := ovntest.NewFakeExec()
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge-mappings",
+				Output: "physnet:breth0,physnet-dc:br-old",
+			})
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				"ovs-vsctl --timeout=15 set Open_vSwitch . external_ids:ovn-bridge-mappings=physnet:breth0,physnet-dc:br-dc",
+			})
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:ovn-bridge-mappings",
+				Output: "physnet:breth0,physnet-dc:br-dc",
+			})
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				"ovs-vsctl --timeout=15 set Open_vSwitch . external_ids:ovn-bridge-mappings=physnet:breth0,physnet-dc:br-dc,physnet-lab:br-lab",
+			})
+
+			err := util.SetExec(fexec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = config.InitConfig(ctx, fexec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = setupLocalnetBridgeMappings()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--bridge-mappings=physnet-lab:br-lab,physnet-dc:br-dc"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("flushes the conntrack entries of the VIPs of a deleted UDP service", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, ovntest.NewFakeExec(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			mockNetLinkOps := new(mocks.NetLinkOps)
+			defer util.SetNetLinkOpMockInst(util.GetNetLinkOps())
+			util.SetNetLinkOpMockInst(mockNetLinkOps)
+			// the cluster IP and the external IP, and the node port in both IP families
+			var flushes int32
+			mockNetLinkOps.On("ConntrackDeleteFilter", mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil).Times(4).
+				Run(func(mock.Arguments) { atomic.AddInt32(&flushes, 1) })
+
+			fakeClient := util.GetOVNClientset(&kapi.ServiceList{
+				Items: []kapi.Service{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "kube-system"},
+						Spec: kapi.ServiceSpec{
+							Type:        kapi.ServiceTypeNodePort,
+							ClusterIP:   "10.96.0.10",
+							ExternalIPs: []string{"1.1.1.1"},
+							Ports: []kapi.ServicePort{
+								{Name: "dns", Port: 53, NodePort: 30053, Protocol: kapi.ProtocolUDP},
+								{Name: "dns-tcp", Port: 53, NodePort: 30054, Protocol: kapi.ProtocolTCP},
+							},
+						},
+					},
+				},
+			})
+			stopChan := make(chan struct{})
+			defer close(stopChan)
+			wf, err := factory.NewNodeWatchFactory(fakeClient, "node1")
+			Expect(err).NotTo(HaveOccurred())
+			defer wf.Shutdown()
+			n := NewNode(fakeClient.KubeClient, wf, "node1", stopChan, nil)
+			n.WatchServices()
+
+			err = fakeClient.KubeClient.CoreV1().Services("kube-system").Delete(context.TODO(), "dns", metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() int32 {
+				return atomic.LoadInt32(&flushes)
+			}).Should(Equal(int32(4)))
+			mockNetLinkOps.AssertExpectations(GinkgoT())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
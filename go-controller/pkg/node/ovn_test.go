@@ -10,11 +10,7 @@ import (
 	util "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/urfave/cli/v2"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/record"
-
-	egressipfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/fake"
-	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 )
 
 var fakeNodeName = "node"
@@ -41,18 +37,10 @@ func NewFakeOVNNode(fexec *ovntest.FakeExec) *FakeOVNNode {
 }
 
 func (o *FakeOVNNode) start(ctx *cli.Context, objects ...runtime.Object) {
-	v1Objects := []runtime.Object{}
-	for _, object := range objects {
-		v1Objects = append(v1Objects, object)
-	}
 	_, err := config.InitConfig(ctx, o.fakeExec, nil)
 	Expect(err).NotTo(HaveOccurred())
 
-	o.fakeClient = &util.OVNClientset{
-		KubeClient:          fake.NewSimpleClientset(v1Objects...),
-		EgressIPClient:      egressipfake.NewSimpleClientset(),
-		APIExtensionsClient: apiextensionsfake.NewSimpleClientset(),
-	}
+	o.fakeClient = util.GetOVNClientset(objects...)
 	o.init()
 }
 
@@ -31,6 +31,10 @@ type AddressSetFactory interface {
 	// and contains the given IPs, or an error. Internally it creates
 	// an address set for IPv4 and IPv6 each.
 	NewAddressSet(name string, ips []net.IP) (AddressSet, error)
+	// EnsureAddressSet returns an object for the named address sets, creating
+	// them empty if they do not exist. The IPs of existing address sets are
+	// left untouched, they are written by another component.
+	EnsureAddressSet(name string) (AddressSet, error)
 	// ForEachAddressSet calls the given function for each address set
 	// known to the factory
 	ForEachAddressSet(iteratorFn AddressSetIterFunc) error
@@ -72,6 +76,25 @@ func (asf *ovnAddressSetFactory) NewAddressSet(name string, ips []net.IP) (Addre
 	return newOvnAddressSets(name, ips)
 }
 
+// EnsureAddressSet returns an address set object, creating the address sets if needed
+func (asf *ovnAddressSetFactory) EnsureAddressSet(name string) (AddressSet, error) {
+	var v4set, v6set *ovnAddressSet
+	var err error
+	if config.IPv4Mode {
+		v4set, err = ensureOvnAddressSet(getIPv4ASName(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.IPv6Mode {
+		v6set, err = ensureOvnAddressSet(getIPv6ASName(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ovnAddressSets{name: name, ipv4: v4set, ipv6: v6set}, nil
+}
+
 // ForEachAddressSet will pass the unhashed address set name, namespace name
 // and the first suffix in the name to the 'iteratorFn' for every address_set in
 // OVN. (Unhashed address set names are of the form namespaceName[.suffix1.suffix2. .suffixN])
@@ -259,6 +282,34 @@ func newOvnAddressSet(name string, ips []net.IP) (*ovnAddressSet, error) {
 	return as, nil
 }
 
+// ensureOvnAddressSet creates the named address set if it does not exist, without
+// changing the addresses of an existing one
+func ensureOvnAddressSet(name string) (*ovnAddressSet, error) {
+	as := &ovnAddressSet{
+		name:     name,
+		hashName: hashedAddressSet(name),
+		ips:      make(map[string]net.IP),
+	}
+	uuid, stderr, err := util.RunOVNNbctl("--data=bare",
+		"--no-heading", "--columns=_uuid", "find", "address_set",
+		"name="+as.hashName)
+	if err != nil {
+		return nil, fmt.Errorf("find failed to get address set %q, stderr: %q (%v)",
+			as.name, stderr, err)
+	}
+	as.uuid = uuid
+	if uuid == "" {
+		as.uuid, stderr, err = util.RunOVNNbctl("create", "address_set",
+			"name="+as.hashName, "external-ids:name="+as.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create address set %q, stderr: %q (%v)",
+				asDetail(as), stderr, err)
+		}
+	}
+	klog.V(5).Infof("Ensure(%s)", asDetail(as))
+	return as, nil
+}
+
 func (as *ovnAddressSets) GetIPv4HashName() string {
 	if as.ipv4 != nil {
 		return as.ipv4.hashName
@@ -0,0 +1,857 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	adminnetworkpolicyv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// anpMaxACLPriority is the priority of the ACL of the first rule of the AdminNetworkPolicies
+	// with priority 0. The ACLs of the AdminNetworkPolicies are all above the NetworkPolicy ACLs.
+	anpMaxACLPriority = 30000
+	// anpMaxRules is the maximum number of ingress or egress rules of an AdminNetworkPolicy, each
+	// policy priority owns a range of anpMaxRules ACL priorities
+	anpMaxRules = 100
+	// anpMaxPriority is the maximum priority of an AdminNetworkPolicy
+	anpMaxPriority = 99
+	// anpMinACLPriority is the lowest priority of the ACLs of the AdminNetworkPolicies
+	anpMinACLPriority = anpMaxACLPriority - anpMaxPriority*anpMaxRules - anpMaxRules + 1
+
+	// anpPrefix prefixes the names of the port groups and address sets of the AdminNetworkPolicies.
+	// It is not a valid namespace name so they never collide with the ones of the namespaces.
+	anpPrefix = "AdminNetworkPolicy"
+	// anpExternalID is the external ID holding the name of the AdminNetworkPolicy of an ACL
+	anpExternalID = "adminNetworkPolicy"
+)
+
+// adminNetworkPolicy is the state of an AdminNetworkPolicy programmed in OVN
+type adminNetworkPolicy struct {
+	// the UUID of the port group of the subject pods
+	portGroupUUID string
+	// the logical ports of the subject pods in the port group
+	subjectPorts map[string]*lpInfo
+	// the address sets of the peer pods of each ingress rule
+	ingressAddressSets []AddressSet
+	// the address sets of the peer pods of each egress rule
+	egressAddressSets []AddressSet
+	// the ACLs currently programmed for the rules of the policy
+	acls []anpACL
+	// true once the ACLs of the policy were programmed, replacing the ones left by a previous run
+	aclsSynced bool
+	// the ports of the port group reused from a previous run not known to be of subject pods yet
+	stalePorts sets.String
+}
+
+// anpACL is an ACL implementing an AdminNetworkPolicy rule on the cluster port group
+type anpACL struct {
+	name      string
+	priority  int
+	direction string
+	match     string
+	action    string
+}
+
+// anpRule is the part of an ingress or egress AdminNetworkPolicy rule ACLs are built from
+type anpRule struct {
+	action adminnetworkpolicyv1.AdminNetworkPolicyRuleAction
+	peers  []adminnetworkpolicyv1.AdminNetworkPolicyPeer
+	ports  []adminnetworkpolicyv1.AdminNetworkPolicyPort
+}
+
+func anpPortGroupName(name string) string {
+	return anpPrefix + "_" + name
+}
+
+func anpAddressSetName(name, direction string, index int) string {
+	return fmt.Sprintf("%s.%s.%s.%d", anpPrefix, name, direction, index)
+}
+
+// WatchAdminNetworkPolicies starts the watching of the AdminNetworkPolicy resource, and of the
+// namespaces and pods they select, and calls back the appropriate handler logic.
+// It must be called before WatchPods and WatchNetworkPolicy so that the rules of the policies are
+// enforced on the pods as soon as they are added. The logical ports of the subject pods are only
+// known once the pods are added: syncAdminNetworkPolicyPortGroups must be called after WatchPods.
+func (oc *Controller) WatchAdminNetworkPolicies() {
+	oc.watchFactory.AddAdminNetworkPolicyHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			oc.syncAdminNetworkPolicies()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldPolicy := old.(*adminnetworkpolicyv1.AdminNetworkPolicy)
+			newPolicy := new.(*adminnetworkpolicyv1.AdminNetworkPolicy)
+			if !reflect.DeepEqual(oldPolicy.Spec, newPolicy.Spec) {
+				oc.syncAdminNetworkPolicies()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			oc.syncAdminNetworkPolicies()
+		},
+	}, oc.syncStaleAdminNetworkPolicies)
+	oc.watchFactory.AddNamespaceHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			namespace := obj.(*kapi.Namespace)
+			oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForNamespaces(namespace))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldNs, newNs := old.(*kapi.Namespace), new.(*kapi.Namespace)
+			if !reflect.DeepEqual(oldNs.Labels, newNs.Labels) {
+				oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForNamespaces(oldNs, newNs))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			namespace := obj.(*kapi.Namespace)
+			oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForNamespaces(namespace))
+		},
+	}, nil)
+	oc.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pod := obj.(*kapi.Pod)
+			oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForPods(pod))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldPod, newPod := old.(*kapi.Pod), new.(*kapi.Pod)
+			if !anpPodChanged(oldPod, newPod) {
+				return
+			}
+			oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForPods(oldPod, newPod))
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod := obj.(*kapi.Pod)
+			oc.syncAdminNetworkPolicyMembers(oc.getAdminNetworkPoliciesForPods(pod))
+		},
+	}, nil)
+}
+
+// anpPodChanged returns true if a pod update may change the ports or the IPs the
+// AdminNetworkPolicies selecting it program
+func anpPodChanged(old, new *kapi.Pod) bool {
+	return !reflect.DeepEqual(old.Labels, new.Labels) ||
+		old.Annotations[util.OvnPodAnnotationName] != new.Annotations[util.OvnPodAnnotationName] ||
+		old.Spec.NodeName != new.Spec.NodeName
+}
+
+// anpSelection is the part of an AdminNetworkPolicy selecting some pods: its subject and the
+// indexes of its ingress and egress rules with a peer selecting them
+type anpSelection struct {
+	subject      bool
+	ingressRules sets.Int
+	egressRules  sets.Int
+}
+
+// getAdminNetworkPolicySelections returns, by AdminNetworkPolicy name, the parts of the
+// AdminNetworkPolicies whose subject or peer selectors match
+func (oc *Controller) getAdminNetworkPolicySelections(
+	matches func(namespaces *metav1.LabelSelector, pods *adminnetworkpolicyv1.NamespacedPodSelector) bool) map[string]*anpSelection {
+	selections := make(map[string]*anpSelection)
+	policies, err := oc.watchFactory.GetAdminNetworkPolicies()
+	if err != nil {
+		klog.Errorf("Failed to list the AdminNetworkPolicies: %v", err)
+		return selections
+	}
+	for _, policy := range policies {
+		selection := &anpSelection{
+			subject:      matches(policy.Spec.Subject.Namespaces, policy.Spec.Subject.Pods),
+			ingressRules: sets.NewInt(),
+			egressRules:  sets.NewInt(),
+		}
+		for _, ingress := range []bool{true, false} {
+			rules := selection.egressRules
+			if ingress {
+				rules = selection.ingressRules
+			}
+			for i, rule := range getANPRules(policy, ingress) {
+				for _, peer := range rule.peers {
+					if matches(peer.Namespaces, peer.Pods) {
+						rules.Insert(i)
+						break
+					}
+				}
+			}
+		}
+		if selection.subject || selection.ingressRules.Len() > 0 || selection.egressRules.Len() > 0 {
+			selections[policy.Name] = selection
+		}
+	}
+	return selections
+}
+
+// getAdminNetworkPoliciesForPods returns the parts of the AdminNetworkPolicies with a subject or
+// a peer selecting any of the pods
+func (oc *Controller) getAdminNetworkPoliciesForPods(pods ...*kapi.Pod) map[string]*anpSelection {
+	namespaces := make(map[string]*kapi.Namespace)
+	for _, pod := range pods {
+		namespace, err := oc.watchFactory.GetNamespace(pod.Namespace)
+		if err != nil {
+			klog.Errorf("Failed to get the namespace of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		namespaces[pod.Namespace] = namespace
+	}
+	return oc.getAdminNetworkPolicySelections(func(nsSelector *metav1.LabelSelector,
+		podSelector *adminnetworkpolicyv1.NamespacedPodSelector) bool {
+		for _, pod := range pods {
+			if namespace, ok := namespaces[pod.Namespace]; ok && anpSelects(nsSelector, podSelector, namespace, pod) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// getAdminNetworkPoliciesForNamespaces returns the parts of the AdminNetworkPolicies with a
+// subject or a peer selecting any of the namespaces
+func (oc *Controller) getAdminNetworkPoliciesForNamespaces(namespaces ...*kapi.Namespace) map[string]*anpSelection {
+	return oc.getAdminNetworkPolicySelections(func(nsSelector *metav1.LabelSelector,
+		podSelector *adminnetworkpolicyv1.NamespacedPodSelector) bool {
+		for _, namespace := range namespaces {
+			if anpSelectsNamespace(nsSelector, podSelector, namespace) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// anpSelects returns true if the pod, in the given namespace, is selected by the namespace or
+// namespaced pod selector of an AdminNetworkPolicy subject or peer
+func anpSelects(namespaces *metav1.LabelSelector, pods *adminnetworkpolicyv1.NamespacedPodSelector,
+	namespace *kapi.Namespace, pod *kapi.Pod) bool {
+	if !anpSelectsNamespace(namespaces, pods, namespace) {
+		return false
+	}
+	podSelector := &metav1.LabelSelector{}
+	if pods != nil {
+		podSelector = &pods.PodSelector
+	}
+	podSel, err := metav1.LabelSelectorAsSelector(podSelector)
+	return err == nil && podSel.Matches(labels.Set(pod.Labels))
+}
+
+// anpSelectsNamespace returns true if the namespace is selected by the namespace or namespaced
+// pod selector of an AdminNetworkPolicy subject or peer
+func anpSelectsNamespace(namespaces *metav1.LabelSelector, pods *adminnetworkpolicyv1.NamespacedPodSelector,
+	namespace *kapi.Namespace) bool {
+	nsSelector := namespaces
+	if pods != nil {
+		nsSelector = &pods.NamespaceSelector
+	}
+	if nsSelector == nil {
+		return false
+	}
+	nsSel, err := metav1.LabelSelectorAsSelector(nsSelector)
+	return err == nil && nsSel.Matches(labels.Set(namespace.Labels))
+}
+
+// getANPRules returns the ingress or egress rules of an AdminNetworkPolicy
+func getANPRules(policy *adminnetworkpolicyv1.AdminNetworkPolicy, ingress bool) []anpRule {
+	var rules []anpRule
+	if ingress {
+		for _, rule := range policy.Spec.Ingress {
+			rules = append(rules, anpRule{action: rule.Action, peers: rule.From, ports: rule.Ports})
+		}
+	} else {
+		for _, rule := range policy.Spec.Egress {
+			rules = append(rules, anpRule{action: rule.Action, peers: rule.To, ports: rule.Ports})
+		}
+	}
+	return rules
+}
+
+// validateAdminNetworkPolicy checks the fields of an AdminNetworkPolicy the CRD schema cannot
+func validateAdminNetworkPolicy(policy *adminnetworkpolicyv1.AdminNetworkPolicy) error {
+	if policy.Spec.Priority < 0 || policy.Spec.Priority > anpMaxPriority {
+		return fmt.Errorf("priority %d is not between 0 and %d", policy.Spec.Priority, anpMaxPriority)
+	}
+	if (policy.Spec.Subject.Namespaces == nil) == (policy.Spec.Subject.Pods == nil) {
+		return fmt.Errorf("exactly one of namespaces and pods must be set in the subject")
+	}
+	if len(policy.Spec.Ingress) > anpMaxRules || len(policy.Spec.Egress) > anpMaxRules {
+		return fmt.Errorf("more than %d ingress or egress rules", anpMaxRules)
+	}
+	for _, ingress := range []bool{true, false} {
+		for i, rule := range getANPRules(policy, ingress) {
+			switch rule.action {
+			case adminnetworkpolicyv1.AdminNetworkPolicyRuleActionAllow,
+				adminnetworkpolicyv1.AdminNetworkPolicyRuleActionDeny,
+				adminnetworkpolicyv1.AdminNetworkPolicyRuleActionPass:
+			default:
+				return fmt.Errorf("invalid action %q in rule %d", rule.action, i)
+			}
+			if len(rule.peers) == 0 {
+				return fmt.Errorf("no peer in rule %d", i)
+			}
+			for _, peer := range rule.peers {
+				if (peer.Namespaces == nil) == (peer.Pods == nil) {
+					return fmt.Errorf("exactly one of namespaces and pods must be set in the peers of rule %d", i)
+				}
+			}
+			for _, port := range rule.ports {
+				if port.EndPort != 0 && port.EndPort < port.Port {
+					return fmt.Errorf("end port %d is lower than port %d in rule %d", port.EndPort, port.Port, i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getAdminNetworkPolicyPods returns the pods selected by the namespace or namespaced pod
+// selector of an AdminNetworkPolicy subject or peer
+func (oc *Controller) getAdminNetworkPolicyPods(namespaces *metav1.LabelSelector,
+	pods *adminnetworkpolicyv1.NamespacedPodSelector) ([]*kapi.Pod, error) {
+	nsSelector, podSelector := namespaces, metav1.LabelSelector{}
+	if pods != nil {
+		nsSelector, podSelector = &pods.NamespaceSelector, pods.PodSelector
+	}
+	if nsSelector == nil {
+		return nil, nil
+	}
+	nsList, err := oc.watchFactory.GetNamespacesBySelector(*nsSelector)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*kapi.Pod
+	for _, namespace := range nsList {
+		nsPods, err := oc.watchFactory.GetPodsBySelector(namespace.Name, podSelector)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, nsPods...)
+	}
+	return selected, nil
+}
+
+// syncAdminNetworkPolicies programs the port groups, address sets and ACLs of all the valid
+// AdminNetworkPolicies and removes the ones of the deleted or invalid policies. The ACLs of all
+// the policies are synced together since a Pass rule excludes its traffic from the rules of the
+// policies evaluated after it.
+func (oc *Controller) syncAdminNetworkPolicies() {
+	oc.anpLock.Lock()
+	defer oc.anpLock.Unlock()
+
+	policies, err := oc.watchFactory.GetAdminNetworkPolicies()
+	if err != nil {
+		klog.Errorf("Failed to list the AdminNetworkPolicies: %v", err)
+		return
+	}
+	valid := make([]*adminnetworkpolicyv1.AdminNetworkPolicy, 0, len(policies))
+	for _, policy := range policies {
+		if err := validateAdminNetworkPolicy(policy); err != nil {
+			klog.Errorf("Ignoring invalid AdminNetworkPolicy %s: %v", policy.Name, err)
+			continue
+		}
+		if err := oc.ensureAdminNetworkPolicy(policy); err != nil {
+			klog.Errorf("Failed to create AdminNetworkPolicy %s: %v", policy.Name, err)
+			continue
+		}
+		oc.syncAdminNetworkPolicyMembersLocked(policy, nil)
+		valid = append(valid, policy)
+	}
+
+	// the ACLs of the new policies are created before the ones of the deleted policies are removed
+	// so that moving a rule from a policy to another never lets its traffic through
+	sort.Slice(valid, func(i, j int) bool {
+		if valid[i].Spec.Priority != valid[j].Spec.Priority {
+			return valid[i].Spec.Priority < valid[j].Spec.Priority
+		}
+		return valid[i].Name < valid[j].Name
+	})
+	expected := sets.NewString()
+	var ingressPassMatches, egressPassMatches []string
+	for _, policy := range valid {
+		expected.Insert(policy.Name)
+		anp := oc.adminNetworkPolicies[policy.Name]
+		var ingressACLs, egressACLs []anpACL
+		ingressACLs, ingressPassMatches = anp.getACLs(policy, true, ingressPassMatches)
+		egressACLs, egressPassMatches = anp.getACLs(policy, false, egressPassMatches)
+		acls := append(ingressACLs, egressACLs...)
+		if anp.aclsSynced && reflect.DeepEqual(acls, anp.acls) {
+			continue
+		}
+		if err := oc.setAdminNetworkPolicyACLs(policy.Name, acls); err != nil {
+			klog.Errorf("Failed to program the ACLs of AdminNetworkPolicy %s: %v", policy.Name, err)
+			// retry on the next sync
+			anp.aclsSynced = false
+			continue
+		}
+		anp.acls = acls
+		anp.aclsSynced = true
+	}
+	for name := range oc.adminNetworkPolicies {
+		if !expected.Has(name) {
+			if err := oc.deleteAdminNetworkPolicy(name); err != nil {
+				klog.Errorf("Failed to delete AdminNetworkPolicy %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// getACLs returns the ACLs of the ingress or egress rules of an AdminNetworkPolicy. The traffic
+// matching the Pass rules of the policies evaluated before, given in passMatches, is excluded
+// from the ACLs. It returns passMatches along with the matches of the Pass rules of the policy.
+func (anp *adminNetworkPolicy) getACLs(policy *adminnetworkpolicyv1.AdminNetworkPolicy, ingress bool,
+	passMatches []string) ([]anpACL, []string) {
+	direction, lportMatch, addressSets, gress := fromLport, "inport == @", anp.egressAddressSets, "egress"
+	if ingress {
+		direction, lportMatch, addressSets, gress = toLport, "outport == @", anp.ingressAddressSets, "ingress"
+	}
+	lportMatch += hashedPortGroup(anpPortGroupName(policy.Name))
+
+	var acls []anpACL
+	for i, rule := range getANPRules(policy, ingress) {
+		match := lportMatch + " && " + getANPAddressSetMatch(addressSets[i], ingress)
+		if l4Match := getANPL4Match(rule.ports); l4Match != "" {
+			match += " && " + l4Match
+		}
+		if rule.action == adminnetworkpolicyv1.AdminNetworkPolicyRuleActionPass {
+			passMatches = append(passMatches, match)
+			continue
+		}
+		aclMatch := match
+		for _, passMatch := range passMatches {
+			aclMatch += " && !(" + passMatch + ")"
+		}
+		action := "allow-related"
+		if rule.action == adminnetworkpolicyv1.AdminNetworkPolicyRuleActionDeny {
+			action = "drop"
+		}
+		acls = append(acls, anpACL{
+			name:      fmt.Sprintf("%s_%s_%d", policy.Name, gress, i),
+			priority:  anpMaxACLPriority - int(policy.Spec.Priority)*anpMaxRules - i,
+			direction: direction,
+			match:     aclMatch,
+			action:    action,
+		})
+	}
+	return acls, passMatches
+}
+
+// getANPAddressSetMatch returns the match of the traffic from, or to, the IPs of an address set
+func getANPAddressSetMatch(addressSet AddressSet, ingress bool) string {
+	direction := "dst"
+	if ingress {
+		direction = "src"
+	}
+	var matches []string
+	if config.IPv4Mode {
+		matches = append(matches, fmt.Sprintf("ip4.%s == $%s", direction, addressSet.GetIPv4HashName()))
+	}
+	if config.IPv6Mode {
+		matches = append(matches, fmt.Sprintf("ip6.%s == $%s", direction, addressSet.GetIPv6HashName()))
+	}
+	if len(matches) > 1 {
+		return "(" + strings.Join(matches, " || ") + ")"
+	}
+	return strings.Join(matches, "")
+}
+
+// getANPL4Match returns the match of the traffic to the ports of a rule, or an empty string when
+// the rule matches all the ports
+func getANPL4Match(ports []adminnetworkpolicyv1.AdminNetworkPolicyPort) string {
+	var matches []string
+	for _, port := range ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = kapi.ProtocolTCP
+		}
+		pp := &portPolicy{protocol: string(protocol), port: port.Port, endPort: port.EndPort}
+		l4Match, err := pp.getL4Match()
+		if err != nil {
+			continue
+		}
+		matches = append(matches, l4Match)
+	}
+	if len(matches) == 1 {
+		return matches[0]
+	}
+	if len(matches) > 1 {
+		return "((" + strings.Join(matches, ") || (") + "))"
+	}
+	return ""
+}
+
+// ensureAdminNetworkPolicy creates the port group of the subject pods of an AdminNetworkPolicy,
+// and the address sets of the peer pods of each of its rules. The port group and the address
+// sets left by a previous run are reused so that the rules of the policy keep being enforced.
+// Caller must hold the anpLock.
+func (oc *Controller) ensureAdminNetworkPolicy(policy *adminnetworkpolicyv1.AdminNetworkPolicy) error {
+	anp := oc.adminNetworkPolicies[policy.Name]
+	if anp == nil {
+		portGroupName := hashedPortGroup(anpPortGroupName(policy.Name))
+		portGroupUUID, ports, err := getANPPortGroup(portGroupName)
+		if err != nil {
+			return err
+		}
+		if portGroupUUID == "" {
+			portGroupUUID, err = createPortGroup(anpPortGroupName(policy.Name), portGroupName)
+			if err != nil {
+				return fmt.Errorf("failed to create port_group for AdminNetworkPolicy %s (%v)", policy.Name, err)
+			}
+		}
+		anp = &adminNetworkPolicy{
+			portGroupUUID: portGroupUUID,
+			subjectPorts:  make(map[string]*lpInfo),
+			stalePorts:    sets.NewString(ports...),
+		}
+		oc.adminNetworkPolicies[policy.Name] = anp
+	}
+	var err error
+	anp.ingressAddressSets, err = oc.resizeANPAddressSets(policy.Name, "ingress",
+		anp.ingressAddressSets, len(policy.Spec.Ingress))
+	if err != nil {
+		return err
+	}
+	anp.egressAddressSets, err = oc.resizeANPAddressSets(policy.Name, "egress",
+		anp.egressAddressSets, len(policy.Spec.Egress))
+	return err
+}
+
+// getANPPortGroup returns the UUID and the ports of the port group of an AdminNetworkPolicy,
+// or an empty UUID if it does not exist
+func getANPPortGroup(portGroupName string) (string, []string, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=_uuid,ports", "find", "port_group", "name="+portGroupName)
+	if err != nil {
+		return "", nil, fmt.Errorf("find failed to get port_group %s, stderr: %q (%v)", portGroupName, stderr, err)
+	}
+	fields := strings.Split(stdout, ",")
+	if len(fields) != 2 || fields[0] == "" {
+		return "", nil, nil
+	}
+	return fields[0], strings.Fields(fields[1]), nil
+}
+
+// resizeANPAddressSets creates or destroys the address sets of the ingress or egress rules of an
+// AdminNetworkPolicy so that there is one per rule
+func (oc *Controller) resizeANPAddressSets(name, direction string, addressSets []AddressSet, rules int) ([]AddressSet, error) {
+	for len(addressSets) > rules {
+		if err := addressSets[len(addressSets)-1].Destroy(); err != nil {
+			return addressSets, err
+		}
+		addressSets = addressSets[:len(addressSets)-1]
+	}
+	for len(addressSets) < rules {
+		as, err := oc.addressSetFactory.EnsureAddressSet(anpAddressSetName(name, direction, len(addressSets)))
+		if err != nil {
+			return addressSets, fmt.Errorf("failed to create the address set of %s rule %d (%v)",
+				direction, len(addressSets), err)
+		}
+		addressSets = append(addressSets, as)
+	}
+	return addressSets, nil
+}
+
+// syncAdminNetworkPolicyMembers syncs the subject ports and the peer IPs of the given parts of
+// the AdminNetworkPolicies
+func (oc *Controller) syncAdminNetworkPolicyMembers(selections map[string]*anpSelection) {
+	oc.anpLock.Lock()
+	defer oc.anpLock.Unlock()
+
+	for name, selection := range selections {
+		if _, ok := oc.adminNetworkPolicies[name]; !ok {
+			continue
+		}
+		policy, err := oc.watchFactory.GetAdminNetworkPolicy(name)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				klog.Errorf("Failed to get AdminNetworkPolicy %s: %v", name, err)
+			}
+			continue
+		}
+		oc.syncAdminNetworkPolicyMembersLocked(policy, selection)
+	}
+}
+
+// syncAdminNetworkPolicyPortGroups syncs the subject ports of all the AdminNetworkPolicies and
+// removes from the port groups reused from a previous run the ports of the pods the policies do
+// not select anymore. It must be called once the existing pods have been added.
+func (oc *Controller) syncAdminNetworkPolicyPortGroups() {
+	oc.anpLock.Lock()
+	defer oc.anpLock.Unlock()
+
+	for name, anp := range oc.adminNetworkPolicies {
+		policy, err := oc.watchFactory.GetAdminNetworkPolicy(name)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				klog.Errorf("Failed to get AdminNetworkPolicy %s: %v", name, err)
+			}
+			continue
+		}
+		oc.syncAdminNetworkPolicySubjectLocked(policy)
+		if anp.stalePorts.Len() == 0 {
+			continue
+		}
+		args := []string{"--if-exists", "remove", "port_group", hashedPortGroup(anpPortGroupName(name)), "ports"}
+		_, stderr, err := util.RunOVNNbctl(append(args, anp.stalePorts.List()...)...)
+		if err != nil {
+			klog.Errorf("Failed to remove the stale ports of AdminNetworkPolicy %s, stderr: %q (%v)",
+				name, stderr, err)
+			continue
+		}
+		anp.stalePorts = sets.NewString()
+	}
+}
+
+// syncAdminNetworkPolicyMembersLocked syncs the subject ports of an AdminNetworkPolicy and the
+// peer IPs of its rules, limited to the given selection when it is not nil.
+// Caller must hold the anpLock.
+func (oc *Controller) syncAdminNetworkPolicyMembersLocked(policy *adminnetworkpolicyv1.AdminNetworkPolicy,
+	selection *anpSelection) {
+	anp := oc.adminNetworkPolicies[policy.Name]
+	if selection == nil || selection.subject {
+		oc.syncAdminNetworkPolicySubjectLocked(policy)
+	}
+	for _, ingress := range []bool{true, false} {
+		addressSets := anp.egressAddressSets
+		var selected sets.Int
+		if selection != nil {
+			selected = selection.egressRules
+		}
+		if ingress {
+			addressSets = anp.ingressAddressSets
+			if selection != nil {
+				selected = selection.ingressRules
+			}
+		}
+		for i, rule := range getANPRules(policy, ingress) {
+			if selection != nil && !selected.Has(i) {
+				continue
+			}
+			if err := addressSets[i].SetIPs(oc.getANPPeerIPs(rule.peers)); err != nil {
+				klog.Errorf("Failed to set the peer IPs of rule %d of AdminNetworkPolicy %s: %v",
+					i, policy.Name, err)
+			}
+		}
+	}
+}
+
+// syncAdminNetworkPolicySubjectLocked adds the logical ports of the subject pods of an
+// AdminNetworkPolicy to its port group, removing the ones of the pods it does not select anymore.
+// Caller must hold the anpLock.
+func (oc *Controller) syncAdminNetworkPolicySubjectLocked(policy *adminnetworkpolicyv1.AdminNetworkPolicy) {
+	anp := oc.adminNetworkPolicies[policy.Name]
+	portGroupName := hashedPortGroup(anpPortGroupName(policy.Name))
+
+	pods, err := oc.getAdminNetworkPolicyPods(policy.Spec.Subject.Namespaces, policy.Spec.Subject.Pods)
+	if err != nil {
+		klog.Errorf("Failed to get the subject pods of AdminNetworkPolicy %s: %v", policy.Name, err)
+		return
+	}
+	subjectPorts := make(map[string]*lpInfo)
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Spec.NodeName == "" {
+			continue
+		}
+		portName := podLogicalPortName(pod)
+		portInfo, err := oc.logicalPortCache.get(portName)
+		if err != nil {
+			// the port is added once the pod is annotated
+			klog.V(5).Infof("Subject pod %s/%s of AdminNetworkPolicy %s has no port yet: %v",
+				pod.Namespace, pod.Name, policy.Name, err)
+			continue
+		}
+		subjectPorts[portName] = portInfo
+		if old, ok := anp.subjectPorts[portName]; ok && old.uuid == portInfo.uuid {
+			continue
+		}
+		// the port is already in the port group reused from a previous run
+		if anp.stalePorts.Has(portInfo.uuid) {
+			anp.stalePorts.Delete(portInfo.uuid)
+			continue
+		}
+		if err := addToPortGroup(portGroupName, portInfo); err != nil {
+			klog.Warningf("Failed to add port %s to AdminNetworkPolicy %s: %v", portName, policy.Name, err)
+			delete(subjectPorts, portName)
+		}
+	}
+	for portName, portInfo := range anp.subjectPorts {
+		if _, ok := subjectPorts[portName]; ok {
+			continue
+		}
+		if err := deleteFromPortGroup(portGroupName, portInfo); err != nil {
+			klog.Warningf("Failed to delete port %s from AdminNetworkPolicy %s: %v", portName, policy.Name, err)
+			subjectPorts[portName] = portInfo
+		}
+	}
+	anp.subjectPorts = subjectPorts
+}
+
+// getANPPeerIPs returns the IPs of the pods selected by the peers of a rule
+func (oc *Controller) getANPPeerIPs(peers []adminnetworkpolicyv1.AdminNetworkPolicyPeer) []net.IP {
+	var ips []net.IP
+	found := sets.NewString()
+	for _, peer := range peers {
+		pods, err := oc.getAdminNetworkPolicyPods(peer.Namespaces, peer.Pods)
+		if err != nil {
+			klog.Errorf("Failed to get the peer pods of an AdminNetworkPolicy: %v", err)
+			continue
+		}
+		for _, pod := range pods {
+			if pod.Spec.HostNetwork {
+				continue
+			}
+			podIPs, err := util.GetAllPodIPs(pod)
+			if err != nil {
+				continue
+			}
+			for _, ip := range podIPs {
+				if !found.Has(ip.String()) {
+					found.Insert(ip.String())
+					ips = append(ips, ip)
+				}
+			}
+		}
+	}
+	return ips
+}
+
+// findAdminNetworkPolicyACLs returns the UUIDs of the ACLs of an AdminNetworkPolicy
+func findAdminNetworkPolicyACLs(name string) ([]string, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "ACL",
+		fmt.Sprintf("external-ids:%s=%s", anpExternalID, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the ACLs of AdminNetworkPolicy %s, stderr: %q (%v)",
+			name, stderr, err)
+	}
+	return strings.Fields(stdout), nil
+}
+
+// setAdminNetworkPolicyACLs replaces the ACLs of an AdminNetworkPolicy on the cluster port group,
+// creating the new ACLs before removing the old ones
+func (oc *Controller) setAdminNetworkPolicyACLs(name string, acls []anpACL) error {
+	oldUUIDs, err := findAdminNetworkPolicyACLs(name)
+	if err != nil {
+		return err
+	}
+	for _, acl := range acls {
+		_, stderr, err := util.RunOVNNbctl("--id=@acl", "create", "acl",
+			fmt.Sprintf("priority=%d", acl.priority),
+			fmt.Sprintf("direction=%s", acl.direction),
+			fmt.Sprintf("match=\"%s\"", acl.match),
+			"action="+acl.action,
+			getACLNameArg(acl.name),
+			fmt.Sprintf("external-ids:%s=%s", anpExternalID, name),
+			"--", "add", "port_group", oc.clusterPortGroupUUID, "acls", "@acl")
+		if err != nil {
+			return fmt.Errorf("failed to create ACL %s of AdminNetworkPolicy %s, stderr: %q (%v)",
+				acl.name, name, stderr, err)
+		}
+	}
+	return oc.removeAdminNetworkPolicyACLs(name, oldUUIDs)
+}
+
+// removeAdminNetworkPolicyACLs removes ACLs of an AdminNetworkPolicy from the cluster port group
+func (oc *Controller) removeAdminNetworkPolicyACLs(name string, uuids []string) error {
+	for _, uuid := range uuids {
+		_, stderr, err := util.RunOVNNbctl("--if-exists", "remove", "port_group", oc.clusterPortGroupUUID, "acls", uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete ACL %s of AdminNetworkPolicy %s, stderr: %q (%v)",
+				uuid, name, stderr, err)
+		}
+	}
+	return nil
+}
+
+// deleteAdminNetworkPolicy removes the ACLs, the port group and the address sets of an
+// AdminNetworkPolicy.
+// Caller must hold the anpLock.
+func (oc *Controller) deleteAdminNetworkPolicy(name string) error {
+	anp := oc.adminNetworkPolicies[name]
+	uuids, err := findAdminNetworkPolicyACLs(name)
+	if err != nil {
+		return err
+	}
+	if err := oc.removeAdminNetworkPolicyACLs(name, uuids); err != nil {
+		return err
+	}
+	deletePortGroup(hashedPortGroup(anpPortGroupName(name)))
+	for _, addressSets := range [][]AddressSet{anp.ingressAddressSets, anp.egressAddressSets} {
+		for _, as := range addressSets {
+			if err := as.Destroy(); err != nil {
+				klog.Errorf("Failed to destroy address set %s of AdminNetworkPolicy %s: %v",
+					as.GetName(), name, err)
+			}
+		}
+	}
+	delete(oc.adminNetworkPolicies, name)
+	return nil
+}
+
+// syncStaleAdminNetworkPolicies removes the ACLs, the port groups and the address sets of the
+// AdminNetworkPolicies deleted while ovnkube-master was not running, and the address sets of
+// the rules removed from the existing policies
+func (oc *Controller) syncStaleAdminNetworkPolicies(policies []interface{}) {
+	expected := sets.NewString()
+	expectedAddressSets := sets.NewString()
+	for _, obj := range policies {
+		policy, ok := obj.(*adminnetworkpolicyv1.AdminNetworkPolicy)
+		if !ok {
+			klog.Errorf("Spurious object in syncStaleAdminNetworkPolicies: %v", obj)
+			continue
+		}
+		if validateAdminNetworkPolicy(policy) != nil {
+			continue
+		}
+		expected.Insert(policy.Name)
+		for i := range policy.Spec.Ingress {
+			expectedAddressSets.Insert(anpAddressSetName(policy.Name, "ingress", i))
+		}
+		for i := range policy.Spec.Egress {
+			expectedAddressSets.Insert(anpAddressSetName(policy.Name, "egress", i))
+		}
+	}
+
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=_uuid,external_ids", "find", "ACL", fmt.Sprintf("priority>=%d", anpMinACLPriority))
+	if err != nil {
+		klog.Errorf("Failed to find the ACLs of the AdminNetworkPolicies, stderr: %q (%v)", stderr, err)
+		return
+	}
+	stale := make(map[string][]string)
+	for _, line := range strings.Split(stdout, "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) != 2 {
+			continue
+		}
+		for _, externalID := range strings.Fields(parts[1]) {
+			if name := strings.TrimPrefix(externalID, anpExternalID+"="); name != externalID && !expected.Has(name) {
+				stale[name] = append(stale[name], parts[0])
+			}
+		}
+	}
+	for name, uuids := range stale {
+		klog.Infof("Deleting stale AdminNetworkPolicy %s", name)
+		if err := oc.removeAdminNetworkPolicyACLs(name, uuids); err != nil {
+			klog.Error(err)
+			continue
+		}
+		deletePortGroup(hashedPortGroup(anpPortGroupName(name)))
+	}
+
+	err = oc.addressSetFactory.ForEachAddressSet(func(addrSetName, namespaceName, _ string) {
+		if namespaceName != anpPrefix || expectedAddressSets.Has(addrSetName) {
+			return
+		}
+		if err := oc.addressSetFactory.DestroyAddressSetInBackingStore(addrSetName); err != nil {
+			klog.Errorf(err.Error())
+		}
+	})
+	if err != nil {
+		klog.Errorf("Error in syncing the address sets of the AdminNetworkPolicies: %v", err)
+	}
+}
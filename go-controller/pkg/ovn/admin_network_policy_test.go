@@ -0,0 +1,305 @@
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	adminnetworkpolicyv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	"github.com/urfave/cli/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newAdminNetworkPolicy(name string, priority int32, subjectLabels map[string]string,
+	ingress []adminnetworkpolicyv1.AdminNetworkPolicyIngressRule) adminnetworkpolicyv1.AdminNetworkPolicy {
+	return adminnetworkpolicyv1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: adminnetworkpolicyv1.AdminNetworkPolicySpec{
+			Priority: priority,
+			Subject: adminnetworkpolicyv1.AdminNetworkPolicySubject{
+				Namespaces: &metav1.LabelSelector{MatchLabels: subjectLabels},
+			},
+			Ingress: ingress,
+		},
+	}
+}
+
+func anpNamespacePeer(peerLabels map[string]string) []adminnetworkpolicyv1.AdminNetworkPolicyPeer {
+	return []adminnetworkpolicyv1.AdminNetworkPolicyPeer{
+		{Namespaces: &metav1.LabelSelector{MatchLabels: peerLabels}},
+	}
+}
+
+var _ = Describe("OVN AdminNetworkPolicy Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewLooseCompareFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("programs the rules of the policies in priority order and excludes passed traffic from the later rules", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Labels = map[string]string{"team": "a"}
+			namespaceX := *newNamespace("namespace2")
+			namespaceX.Labels = map[string]string{"team": "b"}
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.3",
+				"0a:58:0a:80:01:03",
+				namespaceT.Name,
+			)
+			denyPolicy := newAdminNetworkPolicy("deny-b", 10, namespaceT.Labels,
+				[]adminnetworkpolicyv1.AdminNetworkPolicyIngressRule{
+					{
+						Action: adminnetworkpolicyv1.AdminNetworkPolicyRuleActionPass,
+						From:   anpNamespacePeer(namespaceX.Labels),
+						Ports:  []adminnetworkpolicyv1.AdminNetworkPolicyPort{{Protocol: v1.ProtocolTCP, Port: 8080}},
+					},
+					{
+						Action: adminnetworkpolicyv1.AdminNetworkPolicyRuleActionDeny,
+						From:   anpNamespacePeer(namespaceX.Labels),
+					},
+				})
+			allowPolicy := newAdminNetworkPolicy("allow-b", 20, namespaceT.Labels,
+				[]adminnetworkpolicyv1.AdminNetworkPolicyIngressRule{
+					{
+						Action: adminnetworkpolicyv1.AdminNetworkPolicyRuleActionAllow,
+						From:   anpNamespacePeer(namespaceX.Labels),
+					},
+				})
+			invalidPolicy := newAdminNetworkPolicy("invalid", 100, namespaceT.Labels, nil)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT, namespaceX,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+						*newPod(namespaceX.Name, "peerPod", "node2", "10.128.2.3"),
+					},
+				},
+				&adminnetworkpolicyv1.AdminNetworkPolicyList{
+					Items: []adminnetworkpolicyv1.AdminNetworkPolicy{denyPolicy, allowPolicy, invalidPolicy},
+				},
+			)
+			fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+			fakeOvn.controller.logicalPortCache.add(t.nodeName, t.portName, "myPod-UUID",
+				ovntest.MustParseMAC(t.podMAC), []*net.IPNet{ovntest.MustParseIPNet(t.podIP + "/24")})
+
+			denyPG := hashedPortGroup(anpPortGroupName(denyPolicy.Name))
+			allowPG := hashedPortGroup(anpPortGroupName(allowPolicy.Name))
+			passMatch := fmt.Sprintf("outport == @%s && ip4.src == $%s && tcp && tcp.dst==8080",
+				denyPG, getIPv4ASHashedName(anpAddressSetName(denyPolicy.Name, "ingress", 0)))
+			allowMatch := fmt.Sprintf("outport == @%s && ip4.src == $%s",
+				allowPG, getIPv4ASHashedName(anpAddressSetName(allowPolicy.Name, "ingress", 0)))
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				fmt.Sprintf("ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,external_ids find ACL priority>=%d", anpMinACLPriority),
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,ports find port_group name=" + denyPG,
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + denyPG,
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,ports find port_group name=" + allowPG,
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + allowPG,
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 create port_group name=" + denyPG + " external-ids:name=" + anpPortGroupName(denyPolicy.Name),
+				Output: denyPG,
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 create port_group name=" + allowPG + " external-ids:name=" + anpPortGroupName(allowPolicy.Name),
+				Output: allowPG,
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + denyPG + " ports myPod-UUID -- add port_group " + denyPG + " ports myPod-UUID",
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + allowPG + " ports myPod-UUID -- add port_group " + allowPG + " ports myPod-UUID",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:adminNetworkPolicy=deny-b",
+				"ovn-nbctl --timeout=15 --id=@acl create acl priority=28999 direction=to-lport " +
+					fmt.Sprintf("match=\"outport == @%s && ip4.src == $%s && !(%s)\" ", denyPG,
+						getIPv4ASHashedName(anpAddressSetName(denyPolicy.Name, "ingress", 1)), passMatch) +
+					"action=drop name=deny-b_ingress_1 external-ids:adminNetworkPolicy=deny-b -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:adminNetworkPolicy=allow-b",
+				"ovn-nbctl --timeout=15 --id=@acl create acl priority=28000 direction=to-lport " +
+					fmt.Sprintf("match=\"%s && !(%s)\" ", allowMatch, passMatch) +
+					"action=allow-related name=allow-b_ingress_0 external-ids:adminNetworkPolicy=allow-b -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+			})
+
+			fakeOvn.controller.WatchAdminNetworkPolicies()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 0)), []string{"10.128.2.3"})
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 1)), []string{"10.128.2.3"})
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(anpAddressSetName(allowPolicy.Name, "ingress", 0)), []string{"10.128.2.3"})
+			fakeOvn.asf.ExpectNoAddressSet(getIPv4ASName(anpAddressSetName(invalidPolicy.Name, "ingress", 0)))
+
+			// a pod event only syncs the parts of the policies selecting the pod
+			peerPod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceX.Name).Get(context.TODO(), "peerPod", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			selections := fakeOvn.controller.getAdminNetworkPoliciesForPods(peerPod)
+			Expect(selections).To(HaveLen(2))
+			Expect(selections[denyPolicy.Name].subject).To(BeFalse())
+			Expect(selections[denyPolicy.Name].ingressRules.List()).To(Equal([]int{0, 1}))
+			Expect(selections[allowPolicy.Name].subject).To(BeFalse())
+			Expect(selections[allowPolicy.Name].ingressRules.List()).To(Equal([]int{0}))
+			subjectPod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Get(context.TODO(), t.podName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			selections = fakeOvn.controller.getAdminNetworkPoliciesForPods(subjectPod)
+			Expect(selections[denyPolicy.Name].subject).To(BeTrue())
+			Expect(selections[denyPolicy.Name].ingressRules.Len()).To(Equal(0))
+
+			// deleting the policy with the Pass rule replaces the ACL of the other policy
+			// and removes its own ACL, port group and address sets
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:adminNetworkPolicy=allow-b",
+				Output: "allow-acl-UUID",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:adminNetworkPolicy=deny-b",
+				Output: "deny-acl-UUID",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + denyPG,
+				Output: "deny-pg-UUID",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --id=@acl create acl priority=28000 direction=to-lport " +
+					fmt.Sprintf("match=\"%s\" ", allowMatch) +
+					"action=allow-related name=allow-b_ingress_0 external-ids:adminNetworkPolicy=allow-b -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls allow-acl-UUID",
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls deny-acl-UUID",
+				"ovn-nbctl --timeout=15 --if-exists destroy port_group deny-pg-UUID",
+			})
+			err = fakeOvn.fakeClient.ANPClient.K8sV1().AdminNetworkPolicies().Delete(context.TODO(), denyPolicy.Name, metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			fakeOvn.asf.EventuallyExpectNoAddressSet(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 0)))
+			fakeOvn.asf.EventuallyExpectNoAddressSet(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 1)))
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-admin-network-policy"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reuses the port group and the address sets of a policy left by a previous run", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Labels = map[string]string{"team": "a"}
+			namespaceX := *newNamespace("namespace2")
+			namespaceX.Labels = map[string]string{"team": "b"}
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.3",
+				"0a:58:0a:80:01:03",
+				namespaceT.Name,
+			)
+			denyPolicy := newAdminNetworkPolicy("deny-b", 10, namespaceT.Labels,
+				[]adminnetworkpolicyv1.AdminNetworkPolicyIngressRule{
+					{
+						Action: adminnetworkpolicyv1.AdminNetworkPolicyRuleActionDeny,
+						From:   anpNamespacePeer(namespaceX.Labels),
+					},
+				})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT, namespaceX,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+						*newPod(namespaceX.Name, "peerPod", "node2", "10.128.2.3"),
+					},
+				},
+				&adminnetworkpolicyv1.AdminNetworkPolicyList{
+					Items: []adminnetworkpolicyv1.AdminNetworkPolicy{denyPolicy},
+				},
+			)
+			fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+			// the address sets of the rule, of a rule removed and of a policy deleted
+			// while ovnkube-master was not running
+			_, err := fakeOvn.asf.NewAddressSet(anpAddressSetName(denyPolicy.Name, "ingress", 0),
+				[]net.IP{net.ParseIP("10.128.2.3"), net.ParseIP("10.128.2.4")})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fakeOvn.asf.NewAddressSet(anpAddressSetName(denyPolicy.Name, "ingress", 1),
+				[]net.IP{net.ParseIP("10.128.2.3")})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fakeOvn.asf.NewAddressSet(anpAddressSetName("deleted", "ingress", 0),
+				[]net.IP{net.ParseIP("10.128.2.3")})
+			Expect(err).NotTo(HaveOccurred())
+
+			denyPG := hashedPortGroup(anpPortGroupName(denyPolicy.Name))
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				fmt.Sprintf("ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,external_ids find ACL priority>=%d", anpMinACLPriority),
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,ports find port_group name=" + denyPG,
+				Output: "deny-pg-UUID,myPod-UUID stalePod-UUID",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:adminNetworkPolicy=deny-b",
+				Output: "deny-acl-UUID",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --id=@acl create acl priority=29000 direction=to-lport " +
+					fmt.Sprintf("match=\"outport == @%s && ip4.src == $%s\" ", denyPG,
+						getIPv4ASHashedName(anpAddressSetName(denyPolicy.Name, "ingress", 0))) +
+					"action=drop name=deny-b_ingress_0 external-ids:adminNetworkPolicy=deny-b -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls deny-acl-UUID",
+			})
+
+			fakeOvn.controller.WatchAdminNetworkPolicies()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 0)), []string{"10.128.2.3"})
+			fakeOvn.asf.ExpectNoAddressSet(getIPv4ASName(anpAddressSetName(denyPolicy.Name, "ingress", 1)))
+			fakeOvn.asf.ExpectNoAddressSet(getIPv4ASName(anpAddressSetName("deleted", "ingress", 0)))
+
+			// once the pods are added, the port of the subject pod is kept and the other one removed
+			fakeOvn.controller.logicalPortCache.add(t.nodeName, t.portName, "myPod-UUID",
+				ovntest.MustParseMAC(t.podMAC), []*net.IPNet{ovntest.MustParseIPNet(t.podIP + "/24")})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists remove port_group " + denyPG + " ports stalePod-UUID",
+			})
+			fakeOvn.controller.syncAdminNetworkPolicyPortGroups()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-admin-network-policy"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
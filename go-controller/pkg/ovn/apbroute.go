@@ -0,0 +1,334 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+
+	adminpolicybasedroutev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1"
+
+	nettypes "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// WatchAdminPolicyBasedExternalRoutes starts the watching of the AdminPolicyBasedExternalRoute resource,
+// and of the namespaces and pods they select, and calls back the appropriate handler logic.
+func (oc *Controller) WatchAdminPolicyBasedExternalRoutes() {
+	oc.watchFactory.AddAPBRouteHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			policy := obj.(*adminpolicybasedroutev1.AdminPolicyBasedExternalRoute)
+			oc.syncAPBRoute(policy.Name)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldPolicy := old.(*adminpolicybasedroutev1.AdminPolicyBasedExternalRoute)
+			newPolicy := new.(*adminpolicybasedroutev1.AdminPolicyBasedExternalRoute)
+			if !reflect.DeepEqual(oldPolicy.Spec, newPolicy.Spec) {
+				oc.syncAPBRoute(newPolicy.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			policy := obj.(*adminpolicybasedroutev1.AdminPolicyBasedExternalRoute)
+			oc.syncAPBRoute(policy.Name)
+		},
+	}, nil)
+	oc.watchFactory.AddNamespaceHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			oc.syncAPBRoutes()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldNs, newNs := old.(*kapi.Namespace), new.(*kapi.Namespace)
+			if !reflect.DeepEqual(oldNs.Labels, newNs.Labels) {
+				oc.syncAPBRoutes()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			oc.syncAPBRoutes()
+		},
+	}, nil)
+	oc.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pod := obj.(*kapi.Pod)
+			for _, name := range oc.getAPBRoutesForPods(pod).List() {
+				oc.syncAPBRoute(name)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldPod, newPod := old.(*kapi.Pod), new.(*kapi.Pod)
+			if !apbRoutePodChanged(oldPod, newPod) {
+				return
+			}
+			for _, name := range oc.getAPBRoutesForPods(oldPod, newPod).List() {
+				oc.syncAPBRoute(name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod := obj.(*kapi.Pod)
+			for _, name := range oc.getAPBRoutesForPods(pod).List() {
+				oc.syncAPBRoute(name)
+			}
+		},
+	}, nil)
+}
+
+// apbRoutePodChanged returns true if a pod update may change the gateway IPs it provides as a dynamic hop
+func apbRoutePodChanged(old, new *kapi.Pod) bool {
+	return !reflect.DeepEqual(old.Labels, new.Labels) ||
+		!reflect.DeepEqual(old.Status.PodIPs, new.Status.PodIPs) ||
+		old.Annotations[nettypes.NetworkStatusAnnot] != new.Annotations[nettypes.NetworkStatusAnnot] ||
+		(old.DeletionTimestamp == nil) != (new.DeletionTimestamp == nil)
+}
+
+// getAPBRoutesForPods returns the names of the AdminPolicyBasedExternalRoutes with a dynamic hop
+// selecting any of the pods
+func (oc *Controller) getAPBRoutesForPods(pods ...*kapi.Pod) sets.String {
+	names := sets.NewString()
+	policies, err := oc.watchFactory.GetAdminPolicyBasedExternalRoutes()
+	if err != nil {
+		klog.Errorf("Failed to list the AdminPolicyBasedExternalRoutes: %v", err)
+		return names
+	}
+	for _, pod := range pods {
+		namespace, err := oc.watchFactory.GetNamespace(pod.Namespace)
+		if err != nil {
+			klog.Errorf("Failed to get the namespace of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		for _, policy := range policies {
+			for _, hop := range policy.Spec.NextHops.DynamicHops {
+				if dynamicHopSelects(hop, namespace, pod) {
+					names.Insert(policy.Name)
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// dynamicHopSelects returns true if the pod, in the given namespace, is a gateway of the dynamic hop
+func dynamicHopSelects(hop *adminpolicybasedroutev1.DynamicHop, namespace *kapi.Namespace, pod *kapi.Pod) bool {
+	podSelector, err := metav1.LabelSelectorAsSelector(&hop.PodSelector)
+	if err != nil || !podSelector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+	if hop.NamespaceSelector == nil {
+		return true
+	}
+	nsSelector, err := metav1.LabelSelectorAsSelector(hop.NamespaceSelector)
+	return err == nil && nsSelector.Matches(labels.Set(namespace.Labels))
+}
+
+// syncAPBRoutes syncs all the AdminPolicyBasedExternalRoutes
+func (oc *Controller) syncAPBRoutes() {
+	policies, err := oc.watchFactory.GetAdminPolicyBasedExternalRoutes()
+	if err != nil {
+		klog.Errorf("Failed to list the AdminPolicyBasedExternalRoutes: %v", err)
+		return
+	}
+	for _, policy := range policies {
+		oc.syncAPBRoute(policy.Name)
+	}
+}
+
+// syncAPBRoute programs the external gateways of an AdminPolicyBasedExternalRoute for the pods of
+// the namespaces it currently selects, removes them from the namespaces it does not select anymore
+// and reports the result in its status. All its gateways are removed once the policy is deleted.
+func (oc *Controller) syncAPBRoute(name string) {
+	oc.apbRouteLock.Lock()
+	defer oc.apbRouteLock.Unlock()
+
+	policy, err := oc.watchFactory.GetAdminPolicyBasedExternalRoute(name)
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to get AdminPolicyBasedExternalRoute %s: %v", name, err)
+		return
+	}
+	if errors.IsNotFound(err) {
+		policy = nil
+	}
+
+	var gws []net.IP
+	var messages []string
+	targets := sets.NewString()
+	if policy != nil {
+		gws, messages = oc.getAPBRouteGWs(policy)
+		namespaces, err := oc.watchFactory.GetNamespacesBySelector(policy.Spec.From.NamespaceSelector)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("invalid namespace selector: %v", err))
+		}
+		for _, namespace := range namespaces {
+			if namespace.DeletionTimestamp == nil {
+				targets.Insert(namespace.Name)
+			}
+		}
+	}
+
+	status := adminpolicybasedroutev1.AdminPolicyBasedRouteStatus{}
+	for _, namespace := range oc.apbRouteNamespaces[name].Union(targets).List() {
+		if !targets.Has(namespace) {
+			oc.deleteAPBRouteGWsForNamespace(name, namespace)
+			continue
+		}
+		if err := oc.setAPBRouteGWsForNamespace(name, namespace, gws); err != nil {
+			messages = append(messages, fmt.Sprintf("failed to program the gateways for namespace %s: %v", namespace, err))
+			continue
+		}
+		if len(gws) > 0 {
+			status.Gateways = append(status.Gateways, adminpolicybasedroutev1.AdminPolicyBasedRouteGateways{
+				Namespace: namespace,
+				IPs:       ipsToStrings(gws),
+			})
+		}
+	}
+	if policy == nil {
+		delete(oc.apbRouteNamespaces, name)
+		return
+	}
+	oc.apbRouteNamespaces[name] = targets
+
+	status.Messages = messages
+	status.Status = adminpolicybasedroutev1.SuccessStatus
+	if len(messages) > 0 {
+		status.Status = adminpolicybasedroutev1.FailStatus
+	}
+	status.LastTransitionTime = policy.Status.LastTransitionTime
+	if reflect.DeepEqual(status, policy.Status) {
+		return
+	}
+	status.LastTransitionTime = metav1.Now()
+	policy = policy.DeepCopy()
+	policy.Status = status
+	if err := oc.updateAPBRouteWithRetry(policy); err != nil {
+		klog.Error(err)
+	}
+}
+
+func (oc *Controller) updateAPBRouteWithRetry(policy *adminpolicybasedroutev1.AdminPolicyBasedExternalRoute) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return oc.kube.UpdateAdminPolicyBasedExternalRoute(policy)
+	})
+}
+
+// getAPBRouteGWs returns the gateway IPs of the static and dynamic hops of an AdminPolicyBasedExternalRoute,
+// along with messages describing the hops that could not be used
+func (oc *Controller) getAPBRouteGWs(policy *adminpolicybasedroutev1.AdminPolicyBasedExternalRoute) ([]net.IP, []string) {
+	var gws []net.IP
+	var messages []string
+	found := sets.NewString()
+	addGW := func(gw net.IP) {
+		if !found.Has(gw.String()) {
+			found.Insert(gw.String())
+			gws = append(gws, gw)
+		}
+	}
+	for _, hop := range policy.Spec.NextHops.StaticHops {
+		gw := net.ParseIP(hop.IP)
+		if gw == nil {
+			messages = append(messages, fmt.Sprintf("invalid static hop IP %q", hop.IP))
+			continue
+		}
+		addGW(gw)
+	}
+	for _, hop := range policy.Spec.NextHops.DynamicHops {
+		pods, err := oc.getDynamicHopPods(hop)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("failed to get the pods of dynamic hop: %v", err))
+			continue
+		}
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			podGWs, err := getExGWPodIPs(pod, hop.NetworkAttachmentName)
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("ignoring gateway pod %s/%s: %v", pod.Namespace, pod.Name, err))
+				continue
+			}
+			for _, gw := range podGWs {
+				addGW(gw)
+			}
+		}
+	}
+	sort.Slice(gws, func(i, j int) bool { return gws[i].String() < gws[j].String() })
+	return gws, messages
+}
+
+// getDynamicHopPods returns the pods selected by a dynamic hop
+func (oc *Controller) getDynamicHopPods(hop *adminpolicybasedroutev1.DynamicHop) ([]*kapi.Pod, error) {
+	if hop.NamespaceSelector == nil {
+		return oc.watchFactory.GetPodsBySelector("", hop.PodSelector)
+	}
+	namespaces, err := oc.watchFactory.GetNamespacesBySelector(*hop.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	var pods []*kapi.Pod
+	for _, namespace := range namespaces {
+		nsPods, err := oc.watchFactory.GetPodsBySelector(namespace.Name, hop.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, nsPods...)
+	}
+	return pods, nil
+}
+
+// setAPBRouteGWsForNamespace sets the gateways an AdminPolicyBasedExternalRoute provides to a namespace,
+// adding the routes to the new gateways for all the pods in the namespace and removing the routes to the
+// gateways no other source of the namespace provides anymore
+func (oc *Controller) setAPBRouteGWsForNamespace(policy, namespace string, gws []net.IP) error {
+	nsInfo, err := oc.waitForNamespaceLocked(namespace)
+	if err != nil {
+		return err
+	}
+	defer nsInfo.Unlock()
+
+	oldGWs := nsInfo.apbRouteGWs[policy]
+	if len(gws) == 0 {
+		delete(nsInfo.apbRouteGWs, policy)
+	} else {
+		nsInfo.apbRouteGWs[policy] = gws
+	}
+	oc.deleteGWRoutesForGWs(nsInfo, nsInfo.getUnusedExternalGWs(oldGWs))
+
+	existing := sets.NewString(ipsToStrings(oldGWs)...)
+	var newGWs []net.IP
+	for _, gw := range gws {
+		if !existing.Has(gw.String()) {
+			newGWs = append(newGWs, gw)
+		}
+	}
+	return oc.addGWRoutesForNamespace(namespace, newGWs, nsInfo)
+}
+
+// deleteAPBRouteGWsForNamespace removes the gateways an AdminPolicyBasedExternalRoute provides to a namespace
+func (oc *Controller) deleteAPBRouteGWsForNamespace(policy, namespace string) {
+	nsInfo := oc.getNamespaceLocked(namespace)
+	if nsInfo == nil {
+		return
+	}
+	defer nsInfo.Unlock()
+
+	gws, ok := nsInfo.apbRouteGWs[policy]
+	if !ok {
+		return
+	}
+	delete(nsInfo.apbRouteGWs, policy)
+	oc.deleteGWRoutesForGWs(nsInfo, nsInfo.getUnusedExternalGWs(gws))
+}
+
+func ipsToStrings(ips []net.IP) []string {
+	strs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		strs = append(strs, ip.String())
+	}
+	return strs
+}
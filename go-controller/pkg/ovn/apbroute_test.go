@@ -0,0 +1,201 @@
+package ovn
+
+import (
+	"context"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	adminpolicybasedroutev1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	"github.com/urfave/cli/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newAPBRoute(name string, targetLabels map[string]string, staticHops []*adminpolicybasedroutev1.StaticHop,
+	dynamicHops []*adminpolicybasedroutev1.DynamicHop) adminpolicybasedroutev1.AdminPolicyBasedExternalRoute {
+	return adminpolicybasedroutev1.AdminPolicyBasedExternalRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: adminpolicybasedroutev1.AdminPolicyBasedExternalRouteSpec{
+			From: adminpolicybasedroutev1.ExternalNetworkSource{
+				NamespaceSelector: metav1.LabelSelector{MatchLabels: targetLabels},
+			},
+			NextHops: adminpolicybasedroutev1.ExternalNextHops{
+				StaticHops:  staticHops,
+				DynamicHops: dynamicHops,
+			},
+		},
+	}
+}
+
+var _ = Describe("OVN AdminPolicyBasedExternalRoute Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewLooseCompareFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	getStatus := func(name string) adminpolicybasedroutev1.AdminPolicyBasedRouteStatus {
+		policy, err := fakeOvn.fakeClient.APBRouteClient.K8sV1().AdminPolicyBasedExternalRoutes().Get(context.TODO(), name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return policy.Status
+	}
+
+	It("reconciles the static hops of a policy for the pods of the selected namespaces", func() {
+		app.Action = func(ctx *cli.Context) error {
+
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Labels = map[string]string{"exgw": "true"}
+			namespaceX := *newNamespace("namespace2")
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.3",
+				"0a:58:0a:80:01:03",
+				namespaceT.Name,
+			)
+			policy := newAPBRoute("policy1", map[string]string{"exgw": "true"},
+				[]*adminpolicybasedroutev1.StaticHop{{IP: "9.0.0.1"}, {IP: "9.0.0.2"}, {IP: "invalid"}}, nil)
+
+			t.baseCmds(fExec)
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT, namespaceX,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+					},
+				},
+				&adminpolicybasedroutev1.AdminPolicyBasedExternalRouteList{
+					Items: []adminpolicybasedroutev1.AdminPolicyBasedExternalRoute{policy},
+				},
+			)
+			t.populateLogicalSwitchCache(fakeOvn)
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.3/32 9.0.0.1",
+				"ovn-nbctl --timeout=15 -- --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.3/32 9.0.0.2",
+			})
+			fakeOvn.controller.WatchNamespaces()
+			fakeOvn.controller.WatchPods()
+			fakeOvn.controller.WatchAdminPolicyBasedExternalRoutes()
+
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			Eventually(func() adminpolicybasedroutev1.StatusType { return getStatus(policy.Name).Status }).Should(Equal(adminpolicybasedroutev1.FailStatus))
+			status := getStatus(policy.Name)
+			Expect(status.Messages).To(Equal([]string{`invalid static hop IP "invalid"`}))
+			Expect(status.Gateways).To(Equal([]adminpolicybasedroutev1.AdminPolicyBasedRouteGateways{
+				{Namespace: namespaceT.Name, IPs: []string{"9.0.0.1", "9.0.0.2"}},
+			}))
+
+			// the routes of the namespace no longer selected are removed
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.3/32 9.0.0.1",
+				"ovn-nbctl --timeout=15 -- --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.3/32 9.0.0.2",
+			})
+			namespaceT.Labels = nil
+			_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), &namespaceT, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			Eventually(func() []adminpolicybasedroutev1.AdminPolicyBasedRouteGateways { return getStatus(policy.Name).Gateways }).Should(BeEmpty())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-admin-policy-based-external-routes"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reconciles the dynamic hops of a policy as the gateway pods come and go", func() {
+		app.Action = func(ctx *cli.Context) error {
+
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Labels = map[string]string{"exgw": "true"}
+			namespaceX := *newNamespace("namespace2")
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.3",
+				"0a:58:0a:80:01:03",
+				namespaceT.Name,
+			)
+			gwPod := *newPod(namespaceX.Name, "gwPod", "node2", "9.0.0.1")
+			gwPod.Labels = map[string]string{"gateway": "true"}
+			gwPod.Spec.HostNetwork = true
+			policy := newAPBRoute("policy1", map[string]string{"exgw": "true"}, nil,
+				[]*adminpolicybasedroutev1.DynamicHop{{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"gateway": "true"}}}})
+
+			t.baseCmds(fExec)
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT, namespaceX,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+					},
+				},
+				&adminpolicybasedroutev1.AdminPolicyBasedExternalRouteList{
+					Items: []adminpolicybasedroutev1.AdminPolicyBasedExternalRoute{policy},
+				},
+			)
+			t.populateLogicalSwitchCache(fakeOvn)
+			fakeOvn.controller.WatchNamespaces()
+			fakeOvn.controller.WatchPods()
+			fakeOvn.controller.WatchAdminPolicyBasedExternalRoutes()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			Eventually(func() adminpolicybasedroutev1.StatusType { return getStatus(policy.Name).Status }).Should(Equal(adminpolicybasedroutev1.SuccessStatus))
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.3/32 9.0.0.1",
+			})
+			_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceX.Name).Create(context.TODO(), &gwPod, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			Eventually(func() []adminpolicybasedroutev1.AdminPolicyBasedRouteGateways { return getStatus(policy.Name).Gateways }).Should(Equal(
+				[]adminpolicybasedroutev1.AdminPolicyBasedRouteGateways{{Namespace: namespaceT.Name, IPs: []string{"9.0.0.1"}}}))
+
+			// delete the GW
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.3/32 9.0.0.1",
+			})
+			err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceX.Name).Delete(context.TODO(), gwPod.Name, *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			Eventually(func() []adminpolicybasedroutev1.AdminPolicyBasedRouteGateways { return getStatus(policy.Name).Gateways }).Should(BeEmpty())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-admin-policy-based-external-routes"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
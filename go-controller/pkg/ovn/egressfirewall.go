@@ -5,16 +5,22 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	egressfirewallapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
-	"k8s.io/client-go/util/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
 )
@@ -23,12 +29,38 @@ const (
 	egressFirewallAppliedCorrectly = "EgressFirewall Rules applied"
 	egressFirewallAddError         = "EgressFirewall Rules not correctly added"
 	egressFirewallUpdateError      = "EgressFirewall Rules not correctly updated"
+	// egressFirewallAddressSetSuffix is appended to the namespace name to name the
+	// address set of the pods selected by the podSelector of its egress firewall
+	egressFirewallAddressSetSuffix = "_egressFirewall"
 )
 
 type egressFirewall struct {
+	sync.Mutex
 	name        string
 	namespace   string
 	egressRules []*egressFirewallRule
+	// podAddressSet holds the IPs of the pods selected by the podSelector, it is
+	// nil when the rules apply to all the pods of the namespace
+	podAddressSet AddressSet
+	// podHandler tracks the pods the rules apply to
+	podHandler *factory.Handler
+	// selectedPods holds the keys of the pods the rules apply to
+	selectedPods sets.String
+	// reportSelectedPods is set once the initial pods have been processed, from
+	// then on changes of the number of selected pods are reported in the status
+	reportSelectedPods bool
+	// hasACLs is set when the rules are mirrored by ACLs to log their traffic
+	hasACLs bool
+	// ruleStatus holds the status of each rule of the spec, in order
+	ruleStatus []egressfirewallapi.EgressFirewallRuleStatus
+}
+
+// hasPodAddressSet returns whether the rules apply to the pods of an address set
+// rather than to all the pods of the namespace
+func (ef *egressFirewall) hasPodAddressSet() bool {
+	ef.Lock()
+	defer ef.Unlock()
+	return ef.podAddressSet != nil
 }
 
 type egressFirewallRule struct {
@@ -36,6 +68,7 @@ type egressFirewallRule struct {
 	access egressfirewallapi.EgressFirewallRuleType
 	ports  []egressfirewallapi.EgressFirewallPort
 	to     destination
+	log    bool
 }
 
 type destination struct {
@@ -45,9 +78,19 @@ type destination struct {
 
 func newEgressFirewall(egressFirewallPolicy *egressfirewallapi.EgressFirewall) *egressFirewall {
 	ef := &egressFirewall{
-		name:        egressFirewallPolicy.Name,
-		namespace:   egressFirewallPolicy.Namespace,
-		egressRules: make([]*egressFirewallRule, 0),
+		name:         egressFirewallPolicy.Name,
+		namespace:    egressFirewallPolicy.Namespace,
+		egressRules:  make([]*egressFirewallRule, 0),
+		selectedPods: sets.NewString(),
+		ruleStatus:   make([]egressfirewallapi.EgressFirewallRuleStatus, len(egressFirewallPolicy.Spec.Egress)),
+	}
+	for i := range ef.ruleStatus {
+		ef.ruleStatus[i] = egressfirewallapi.EgressFirewallRuleStatus{
+			Index:   int32(i),
+			Status:  egressfirewallapi.EgressFirewallRuleFailed,
+			Reason:  egressfirewallapi.EgressFirewallRuleReasonNotApplied,
+			Message: "the rule was not applied, see the Ready condition of the egress firewall",
+		}
 	}
 	return ef
 }
@@ -56,10 +99,15 @@ func newEgressFirewallRule(rawEgressFirewallRule egressfirewallapi.EgressFirewal
 	efr := &egressFirewallRule{
 		id:     id,
 		access: rawEgressFirewallRule.Type,
+		log:    rawEgressFirewallRule.Log,
 	}
 
 	if rawEgressFirewallRule.To.DNSName != "" {
 		efr.to.dnsName = rawEgressFirewallRule.To.DNSName
+		if util.IsWildcardDNSName(efr.to.dnsName) {
+			// the nodes report the IPs of wildcard dnsNames in their normalized form
+			efr.to.dnsName = util.NormalizeDNSName(efr.to.dnsName)
+		}
 	} else {
 
 		_, _, err := net.ParseCIDR(rawEgressFirewallRule.To.CIDRSelector)
@@ -73,6 +121,46 @@ func newEgressFirewallRule(rawEgressFirewallRule egressfirewallapi.EgressFirewal
 	return efr, nil
 }
 
+// syncEgressFirewalls destroys the address sets the nodes set the IPs of wildcard DNS
+// names in, when no egress firewall uses the name anymore or the node was deleted
+func (oc *Controller) syncEgressFirewalls(egressFirewalls []interface{}) {
+	expectedAddressSets := sets.NewString()
+	if config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping {
+		nodes, err := oc.watchFactory.GetNodes()
+		if err != nil {
+			klog.Errorf("Error in syncing egress firewalls, cannot get the nodes: %v", err)
+			return
+		}
+		for _, efInterface := range egressFirewalls {
+			egressFirewall, ok := efInterface.(*egressfirewallapi.EgressFirewall)
+			if !ok {
+				klog.Errorf("Spurious object in syncEgressFirewalls: %v", efInterface)
+				continue
+			}
+			for _, rule := range egressFirewall.Spec.Egress {
+				if !util.IsWildcardDNSName(rule.To.DNSName) {
+					continue
+				}
+				for _, node := range nodes {
+					expectedAddressSets.Insert(util.GetEgressFirewallDNSAddressSetName(
+						util.NormalizeDNSName(rule.To.DNSName), node.Name))
+				}
+			}
+		}
+	}
+
+	err := oc.addressSetFactory.ForEachAddressSet(func(addrSetName, namespaceName, nameSuffix string) {
+		if util.IsWildcardDNSName(addrSetName) && !expectedAddressSets.Has(addrSetName) {
+			if err := oc.addressSetFactory.DestroyAddressSetInBackingStore(addrSetName); err != nil {
+				klog.Errorf(err.Error())
+			}
+		}
+	})
+	if err != nil {
+		klog.Errorf("Error in syncing egress firewalls: %v", err)
+	}
+}
+
 func (oc *Controller) addEgressFirewall(egressFirewall *egressfirewallapi.EgressFirewall) error {
 	klog.Infof("Adding egressFirewall %s in namespace %s", egressFirewall.Name, egressFirewall.Namespace)
 	nsInfo, err := oc.waitForNamespaceLocked(egressFirewall.Namespace)
@@ -105,12 +193,17 @@ func (oc *Controller) addEgressFirewall(egressFirewall *egressfirewallapi.Egress
 		if i > egressFirewallStartPriorityInt-minimumReservedEgressFirewallPriorityInt {
 			klog.Warningf("egressFirewall for namespace %s has too many rules, the rest will be ignored",
 				egressFirewall.Namespace)
+			for j := i; j < len(egressFirewall.Spec.Egress); j++ {
+				ef.setRuleFailed(j, egressfirewallapi.EgressFirewallRuleReasonTooManyRules,
+					fmt.Sprintf("only the first %d rules of an egress firewall are applied", i))
+			}
 			break
 		}
 		efr, err := newEgressFirewallRule(egressFirewallRule, i)
 		if err != nil {
 			addErrors = errors.Wrapf(addErrors, "error: cannot create EgressFirewall Rule to destination %s for namespace %s - %v",
 				egressFirewallRule.To.CIDRSelector, egressFirewall.Namespace, err)
+			ef.setRuleFailed(i, egressfirewallapi.EgressFirewallRuleReasonInvalidCIDR, err.Error())
 			continue
 
 		}
@@ -125,28 +218,163 @@ func (oc *Controller) addEgressFirewall(egressFirewall *egressfirewallapi.Egress
 		return fmt.Errorf("unable to add egress firewall policy, namespace: %s has no address set", egressFirewall.Namespace)
 	}
 
-	err = oc.addLogicalRouterPolicyToClusterRouter(nsInfo.addressSet.GetIPv4HashName(), nsInfo.addressSet.GetIPv6HashName(), egressFirewall.Namespace, egressFirewallStartPriorityInt)
+	// the rules apply to all the pods of the namespace unless a podSelector
+	// restricts them to the pods of a dedicated address set
+	hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 := nsInfo.addressSet.GetIPv4HashName(), nsInfo.addressSet.GetIPv6HashName()
+	podSelector := labels.Everything()
+	if egressFirewall.Spec.PodSelector != nil {
+		podSelector, err = metav1.LabelSelectorAsSelector(egressFirewall.Spec.PodSelector)
+		if err != nil {
+			return fmt.Errorf("invalid podSelector in egressFirewall for namespace %s: %v", egressFirewall.Namespace, err)
+		}
+		// an existing address set, left by a previous master or by the egress firewall
+		// being updated, is reused and set to the IPs of the selected pods so that the
+		// selected pods stay matched meanwhile
+		ef.podAddressSet, err = oc.addressSetFactory.EnsureAddressSet(getEgressFirewallAddressSetName(egressFirewall.Namespace))
+		if err != nil {
+			return fmt.Errorf("cannot create address set for egressFirewall in namespace %s: %v", egressFirewall.Namespace, err)
+		}
+		err = ef.podAddressSet.SetIPs(oc.getEgressFirewallPodIPs(egressFirewall.Namespace, *egressFirewall.Spec.PodSelector))
+		if err != nil {
+			return fmt.Errorf("cannot set the pods of the address set of egressFirewall in namespace %s: %v", egressFirewall.Namespace, err)
+		}
+		hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 = ef.podAddressSet.GetIPv4HashName(), ef.podAddressSet.GetIPv6HashName()
+	}
+
+	err = oc.addLogicalRouterPolicyToClusterRouter(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, egressFirewall.Namespace, egressFirewallStartPriorityInt)
 	if err != nil {
 		return err
 	}
 
+	// The pod handler does not lock the namespace, so it is safe to add it while
+	// holding the namespace lock
+	ef.podHandler = oc.watchFactory.AddFilteredPodHandler(egressFirewall.Namespace, podSelector,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				oc.handleEgressFirewallPodAddUpdate(ef, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oc.handleEgressFirewallPodAddUpdate(ef, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				oc.handleEgressFirewallPodDelete(ef, obj)
+			},
+		}, nil)
+	ef.Lock()
+	ef.reportSelectedPods = true
+	ef.Unlock()
+
 	return nil
 }
 
+// getEgressFirewallAddressSetName returns the name of the address set holding the
+// pods selected by the podSelector of the egress firewall of a namespace. Namespace
+// names cannot contain '_', so the name never matches a namespace; the namespace
+// sync attributes the set to its namespace and removes it with the namespace.
+func getEgressFirewallAddressSetName(namespace string) string {
+	return namespace + egressFirewallAddressSetSuffix
+}
+
+// getEgressFirewallPodIPs returns the IPs of the pods of a namespace selected by the
+// podSelector of its egress firewall
+func (oc *Controller) getEgressFirewallPodIPs(namespace string, podSelector metav1.LabelSelector) []net.IP {
+	pods, err := oc.watchFactory.GetPodsBySelector(namespace, podSelector)
+	if err != nil {
+		klog.Errorf("Failed to get the pods selected by egressFirewall in namespace %s: %v", namespace, err)
+		return nil
+	}
+	var podIPs []net.IP
+	for _, pod := range pods {
+		if !util.PodWantsNetwork(pod) {
+			continue
+		}
+		// pods without IPs yet are added by the pod handler once they get them
+		if ips, err := util.GetAllPodIPs(pod); err == nil {
+			podIPs = append(podIPs, ips...)
+		}
+	}
+	return podIPs
+}
+
+// handleEgressFirewallPodAddUpdate adds a pod to the pods the egress firewall
+// rules apply to once its IPs are known
+func (oc *Controller) handleEgressFirewallPodAddUpdate(ef *egressFirewall, obj interface{}) {
+	pod := obj.(*kapi.Pod)
+	if !util.PodWantsNetwork(pod) {
+		return
+	}
+	ips, err := util.GetAllPodIPs(pod)
+	if err != nil {
+		// the pod will be added once it gets its IPs
+		klog.V(5).Infof("Pod %s/%s selected by egressFirewall has no IPs yet: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	ef.Lock()
+	if ef.podAddressSet != nil {
+		if err := ef.podAddressSet.AddIPs(ips); err != nil {
+			klog.Errorf("Failed to add pod %s/%s to egressFirewall address set: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	key := pod.Namespace + "/" + pod.Name
+	changed := !ef.selectedPods.Has(key)
+	ef.selectedPods.Insert(key)
+	report := changed && ef.reportSelectedPods
+	ef.Unlock()
+	if report {
+		oc.refreshEgressFirewallStatus(ef)
+	}
+}
+
+// handleEgressFirewallPodDelete removes a pod that was deleted or no longer matches
+// the podSelector from the pods the egress firewall rules apply to
+func (oc *Controller) handleEgressFirewallPodDelete(ef *egressFirewall, obj interface{}) {
+	pod := obj.(*kapi.Pod)
+	key := pod.Namespace + "/" + pod.Name
+	ef.Lock()
+	if ef.podAddressSet != nil {
+		if ips, err := util.GetAllPodIPs(pod); err == nil {
+			if err := ef.podAddressSet.DeleteIPs(ips); err != nil {
+				klog.Errorf("Failed to delete pod %s/%s from egressFirewall address set: %v", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+	changed := ef.selectedPods.Has(key)
+	ef.selectedPods.Delete(key)
+	report := changed && ef.reportSelectedPods
+	ef.Unlock()
+	if report {
+		oc.refreshEgressFirewallStatus(ef)
+	}
+}
+
 func (oc *Controller) updateEgressFirewall(oldEgressFirewall, newEgressFirewall *egressfirewallapi.EgressFirewall) error {
 	// block all external traffic in this namespace
 	nsInfo, err := oc.waitForNamespaceLocked(newEgressFirewall.Namespace)
 	if err != nil {
 		return fmt.Errorf("cannot update egressfirewall in %s:%v", newEgressFirewall.Namespace, err)
 	}
-	addressSet := nsInfo.addressSet
+	// only the pods the current egress firewall applies to are blocked. The blockAll
+	// policy matches the address set of the current egress firewall, so it is taken
+	// from it to be kept until the policy is deleted, rather than destroyed along
+	// with the current egress firewall.
+	hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 := nsInfo.addressSet.GetIPv4HashName(), nsInfo.addressSet.GetIPv6HashName()
+	var oldPodAddressSet AddressSet
+	if ef := nsInfo.egressFirewallPolicy; ef != nil {
+		ef.Lock()
+		if ef.podAddressSet != nil {
+			hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 = ef.podAddressSet.GetIPv4HashName(), ef.podAddressSet.GetIPv6HashName()
+			oldPodAddressSet = ef.podAddressSet
+			ef.podAddressSet = nil
+		}
+		ef.Unlock()
+	}
 	nsInfo.Unlock()
 	priority, err := strconv.Atoi(types.EgressFirewallStartPriority)
 	if err != nil {
 		return fmt.Errorf("cannot update egressfirewall in %s:%v", newEgressFirewall.Namespace, err)
 	}
 
-	match := generateMatch(addressSet.GetIPv4HashName(), addressSet.GetIPv6HashName(), []matchTarget{
+	match := generateMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, []matchTarget{
 		{matchKindV4CIDR, "0.0.0.0/0"},
 		{matchKindV6CIDR, "::/0"},
 	},
@@ -176,6 +404,15 @@ func (oc *Controller) updateEgressFirewall(oldEgressFirewall, newEgressFirewall
 			"egressFirewall in namespace %s on logical switch %s during update, stderr: %q (%v)",
 			newEgressFirewall.Namespace, types.OVNClusterRouter, stderr, err)
 	}
+	// the new egress firewall reuses the address set when it has a podSelector too
+	if oldPodAddressSet != nil {
+		if ef := oc.getEgressFirewall(newEgressFirewall.Namespace); ef == nil || !ef.hasPodAddressSet() {
+			if err := oldPodAddressSet.Destroy(); err != nil {
+				updateErrors = errors.Wrapf(updateErrors, "failed to destroy the address set of "+
+					"egressFirewall in namespace %s during update: %v", newEgressFirewall.Namespace, err)
+			}
+		}
+	}
 	return updateErrors
 }
 
@@ -186,24 +423,30 @@ func (oc *Controller) deleteEgressFirewall(egressFirewall *egressfirewallapi.Egr
 	nsInfo := oc.getNamespaceLocked(egressFirewall.Namespace)
 	if nsInfo != nil {
 		// clear it so an error does not prevent future egressFirewalls
-		for _, rule := range nsInfo.egressFirewallPolicy.egressRules {
-			if len(rule.to.dnsName) > 0 {
-				deleteDNS = true
-				break
+		ef := nsInfo.egressFirewallPolicy
+		if ef != nil {
+			for _, rule := range ef.egressRules {
+				if len(rule.to.dnsName) > 0 {
+					deleteDNS = true
+					break
+				}
 			}
 		}
 		nsInfo.egressFirewallPolicy = nil
 		nsInfo.Unlock()
+		if ef != nil {
+			oc.releaseEgressFirewall(ef)
+		}
 	}
 	if deleteDNS {
 		oc.egressFirewallDNS.Delete(egressFirewall.Namespace)
 	}
+	var deletionErrors error
 	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "logical_router_policy", fmt.Sprintf("external-ids:egressFirewall=%s", egressFirewall.Namespace))
 	if err != nil {
 		return fmt.Errorf("error deleting egressFirewall for namespace %s, cannot get logical router policies from LR %s - %s:%s",
 			egressFirewall.Namespace, types.OVNClusterRouter, err, stderr)
 	}
-	var deletionErrors error
 
 	uuids := strings.Fields(stdout)
 	for _, uuid := range uuids {
@@ -216,45 +459,332 @@ func (oc *Controller) deleteEgressFirewall(egressFirewall *egressfirewallapi.Egr
 	return deletionErrors
 }
 
-func (oc *Controller) updateEgressFirewallWithRetry(egressfirewall *egressfirewallapi.EgressFirewall) error {
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		return oc.kube.UpdateEgressFirewall(egressfirewall)
-	})
+// releaseEgressFirewall stops tracking the pods an egress firewall applies to and
+// removes the address set and logging ACLs created for it
+func (oc *Controller) releaseEgressFirewall(ef *egressFirewall) {
+	if ef.podHandler != nil {
+		oc.watchFactory.RemovePodHandler(ef.podHandler)
+	}
+	ef.Lock()
+	defer ef.Unlock()
+	ef.podHandler = nil
+	ef.reportSelectedPods = false
+	if ef.podAddressSet != nil {
+		if err := ef.podAddressSet.Destroy(); err != nil {
+			klog.Errorf("Failed to destroy the address set of egressFirewall in namespace %s: %v", ef.namespace, err)
+		}
+		ef.podAddressSet = nil
+	}
+	if ef.hasACLs {
+		if err := oc.deleteEgressFirewallACLs(ef.namespace); err != nil {
+			klog.Errorf(err.Error())
+		}
+		ef.hasACLs = false
+	}
 }
 
 func (oc *Controller) addLogicalRouterPolicyToClusterRouter(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, namespace string, efStartPriority int) error {
-	ef := oc.namespaces[namespace].egressFirewallPolicy
+	nsInfo := oc.namespaces[namespace]
+	ef := nsInfo.egressFirewallPolicy
+	// logical router policies cannot log the traffic they match, so when a rule asks
+	// for logging all the rules are mirrored by ACLs that log the traffic instead. The
+	// ACLs only allow traffic, the verdict is left to the logical router policies.
+	for _, rule := range ef.egressRules {
+		if rule.log {
+			ef.hasACLs = true
+			break
+		}
+	}
+	aclStartPriority, err := strconv.Atoi(types.EgressFirewallACLStartPriority)
+	if err != nil {
+		return fmt.Errorf("failed to convert EgressFirewallACLStartPriority to Integer: %v", err)
+	}
 	for _, rule := range ef.egressRules {
+		if util.IsWildcardDNSName(rule.to.dnsName) && !config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping {
+			// wildcard dnsNames cannot be resolved, their IPs are only known when the nodes
+			// snoop the DNS responses of the pods
+			ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonDNSResolutionFailed,
+				"wildcard DNS names require DNS snooping to be enabled on the nodes")
+			continue
+		}
 		var action string
-		var matchTargets []matchTarget
 		if rule.access == egressfirewallapi.EgressFirewallRuleAllow {
 			action = "allow"
 		} else {
 			action = "drop"
 		}
-		if rule.to.cidrSelector != "" {
-			if utilnet.IsIPv6CIDRString(rule.to.cidrSelector) {
-				matchTargets = []matchTarget{{matchKindV6CIDR, rule.to.cidrSelector}}
-			} else {
-				matchTargets = []matchTarget{{matchKindV4CIDR, rule.to.cidrSelector}}
-			}
-		} else {
-			// rule based on DNS NAME
-			dnsNameAddressSets, err := oc.egressFirewallDNS.Add(ef.namespace, rule.to.dnsName)
-			if err != nil {
-				return fmt.Errorf("error with EgressFirewallDNS - %v", err)
+		matchTargets, err := oc.getEgressFirewallRuleTargets(ef.namespace, rule)
+		if err != nil {
+			ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonOVNTransactionFailed, err.Error())
+			return err
+		}
+		if len(matchTargets) == 0 {
+			// a wildcard dnsName has no address set until there is a node, the rule
+			// is added once a node is added
+			ef.setRuleApplied(rule.id)
+			continue
+		}
+		match := generateMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, matchTargets, rule.ports)
+		err = createLogicalRouterPolicy(efStartPriority-rule.id, match, action, ef.namespace)
+		if err != nil {
+			ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonOVNTransactionFailed, err.Error())
+			return err
+		}
+		if ef.hasACLs {
+			if aclStartPriority-rule.id < 0 {
+				klog.Warningf("egressFirewall for namespace %s has too many rules to log them all, "+
+					"rule %d and the following ones will not be logged", ef.namespace, rule.id)
+				ef.setRuleApplied(rule.id)
+				continue
 			}
-			if dnsNameAddressSets.GetIPv4HashName() != "" {
-				matchTargets = append(matchTargets, matchTarget{matchKindV4AddressSet, dnsNameAddressSets.GetIPv4HashName()})
+			var severity string
+			if rule.log {
+				severity = getEgressFirewallLoggingSeverity(rule.access, nsInfo.aclLogging)
 			}
-			if dnsNameAddressSets.GetIPv6HashName() != "" {
-				matchTargets = append(matchTargets, matchTarget{matchKindV6AddressSet, dnsNameAddressSets.GetIPv6HashName()})
+			aclMatch := generateACLMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, matchTargets, rule.ports)
+			err = oc.createEgressFirewallACL(aclStartPriority-rule.id, aclMatch, ef.namespace,
+				getEgressFirewallACLName(ef.namespace, rule), severity)
+			if err != nil {
+				ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonOVNTransactionFailed, err.Error())
+				return err
 			}
 		}
-		match := generateMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, matchTargets, rule.ports)
-		err := createLogicalRouterPolicy(efStartPriority-rule.id, match, action, ef.namespace)
+		ef.setRuleApplied(rule.id)
+	}
+	return nil
+}
+
+// getEgressFirewallRuleTargets returns the destinations of an egress firewall rule: its CIDR,
+// or the address sets holding the IPs of its dnsName
+func (oc *Controller) getEgressFirewallRuleTargets(namespace string, rule *egressFirewallRule) ([]matchTarget, error) {
+	if rule.to.cidrSelector != "" {
+		if utilnet.IsIPv6CIDRString(rule.to.cidrSelector) {
+			return []matchTarget{{matchKindV6CIDR, rule.to.cidrSelector}}, nil
+		}
+		return []matchTarget{{matchKindV4CIDR, rule.to.cidrSelector}}, nil
+	}
+	// rule based on DNS NAME
+	dnsNameAddressSets, err := oc.egressFirewallDNS.Add(namespace, rule.to.dnsName)
+	if err != nil {
+		return nil, fmt.Errorf("error with EgressFirewallDNS - %v", err)
+	}
+	var matchTargets []matchTarget
+	for _, addressSet := range dnsNameAddressSets {
+		if addressSet.GetIPv4HashName() != "" {
+			matchTargets = append(matchTargets, matchTarget{matchKindV4AddressSet, addressSet.GetIPv4HashName()})
+		}
+		if addressSet.GetIPv6HashName() != "" {
+			matchTargets = append(matchTargets, matchTarget{matchKindV6AddressSet, addressSet.GetIPv6HashName()})
+		}
+	}
+	return matchTargets, nil
+}
+
+// refreshEgressFirewallWildcardRules updates the rules using wildcard dnsNames of the egress
+// firewalls of the given namespaces, after the nodes holding the IPs of the names changed
+func (oc *Controller) refreshEgressFirewallWildcardRules(namespaces []string) {
+	for _, namespace := range namespaces {
+		ef, err := oc.refreshNamespaceEgressFirewallWildcardRules(namespace)
 		if err != nil {
-			return err
+			klog.Errorf("Failed to update the wildcard DNS name rules of egressFirewall in namespace %s: %v", namespace, err)
+		}
+		if ef != nil {
+			oc.refreshEgressFirewallStatus(ef)
+		}
+	}
+}
+
+// refreshNamespaceEgressFirewallWildcardRules updates the match of the logical router
+// policies and ACLs of the rules using wildcard dnsNames of the egress firewall of a
+// namespace to the current address sets of the names, creating them if the names had
+// no address set before. It returns the egress firewall, if any.
+func (oc *Controller) refreshNamespaceEgressFirewallWildcardRules(namespace string) (*egressFirewall, error) {
+	nsInfo := oc.getNamespaceLocked(namespace)
+	if nsInfo == nil {
+		return nil, nil
+	}
+	defer nsInfo.Unlock()
+	ef := nsInfo.egressFirewallPolicy
+	if ef == nil || nsInfo.addressSet == nil {
+		return nil, nil
+	}
+	hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 := nsInfo.addressSet.GetIPv4HashName(), nsInfo.addressSet.GetIPv6HashName()
+	ef.Lock()
+	if ef.podAddressSet != nil {
+		hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 = ef.podAddressSet.GetIPv4HashName(), ef.podAddressSet.GetIPv6HashName()
+	}
+	ef.Unlock()
+	efStartPriority, err := strconv.Atoi(types.EgressFirewallStartPriority)
+	if err != nil {
+		return ef, fmt.Errorf("failed to convert egressFirewallStartPriority to Integer: %v", err)
+	}
+	efStartPriority = efStartPriority - 1
+	aclStartPriority, err := strconv.Atoi(types.EgressFirewallACLStartPriority)
+	if err != nil {
+		return ef, fmt.Errorf("failed to convert EgressFirewallACLStartPriority to Integer: %v", err)
+	}
+
+	var errs []error
+	for _, rule := range ef.egressRules {
+		if !util.IsWildcardDNSName(rule.to.dnsName) {
+			continue
+		}
+		matchTargets, err := oc.getEgressFirewallRuleTargets(ef.namespace, rule)
+		if err != nil {
+			ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonOVNTransactionFailed, err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		err = refreshEgressFirewallPolicy(efStartPriority-rule.id, hashedAddressSetNameIPv4, hashedAddressSetNameIPv6,
+			matchTargets, rule, ef.namespace)
+		if err == nil && ef.hasACLs && aclStartPriority-rule.id >= 0 {
+			err = oc.refreshEgressFirewallACL(aclStartPriority-rule.id, hashedAddressSetNameIPv4, hashedAddressSetNameIPv6,
+				matchTargets, rule, ef.namespace, nsInfo.aclLogging)
+		}
+		if err != nil {
+			ef.setRuleFailed(rule.id, egressfirewallapi.EgressFirewallRuleReasonOVNTransactionFailed, err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		ef.setRuleApplied(rule.id)
+	}
+	return ef, kerrors.NewAggregate(errs)
+}
+
+// refreshEgressFirewallPolicy updates the match of the logical router policy of an egress
+// firewall rule, creating or deleting the policy when the rule gets or loses its destinations
+func refreshEgressFirewallPolicy(priority int, hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 string,
+	matchTargets []matchTarget, rule *egressFirewallRule, namespace string) error {
+	uuid, err := findEgressFirewallRow("logical_router_policy", priority, namespace)
+	if err != nil {
+		return err
+	}
+	match := generateMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, matchTargets, rule.ports)
+	switch {
+	case len(matchTargets) == 0 && uuid != "":
+		_, stderr, err := util.RunOVNNbctl("lr-policy-del", types.OVNClusterRouter, uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete the policy %s of egressFirewall in namespace %s, stderr: %q (%v)",
+				uuid, namespace, stderr, err)
+		}
+		return nil
+	case len(matchTargets) == 0:
+		return nil
+	case uuid != "":
+		return setEgressFirewallRowMatch("logical_router_policy", uuid, match, namespace)
+	}
+	action := "drop"
+	if rule.access == egressfirewallapi.EgressFirewallRuleAllow {
+		action = "allow"
+	}
+	return createLogicalRouterPolicy(priority, match, action, namespace)
+}
+
+// refreshEgressFirewallACL updates the match of the ACL mirroring an egress firewall rule,
+// creating or deleting the ACL when the rule gets or loses its destinations
+func (oc *Controller) refreshEgressFirewallACL(priority int, hashedAddressSetNameIPv4, hashedAddressSetNameIPv6 string,
+	matchTargets []matchTarget, rule *egressFirewallRule, namespace string, aclLogging ACLLoggingLevels) error {
+	uuid, err := findEgressFirewallRow("ACL", priority, namespace)
+	if err != nil {
+		return err
+	}
+	match := generateACLMatch(hashedAddressSetNameIPv4, hashedAddressSetNameIPv6, matchTargets, rule.ports)
+	switch {
+	case len(matchTargets) == 0 && uuid != "":
+		_, stderr, err := util.RunOVNNbctl("--if-exists", "remove", "port_group", oc.clusterPortGroupUUID, "acls", uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete the egressFirewall ACL %s for namespace %s, stderr: %q (%v)",
+				uuid, namespace, stderr, err)
+		}
+		return nil
+	case len(matchTargets) == 0:
+		return nil
+	case uuid != "":
+		return setEgressFirewallRowMatch("ACL", uuid, match, namespace)
+	}
+	var severity string
+	if rule.log {
+		severity = getEgressFirewallLoggingSeverity(rule.access, aclLogging)
+	}
+	return oc.createEgressFirewallACL(priority, match, namespace, getEgressFirewallACLName(namespace, rule), severity)
+}
+
+// findEgressFirewallRow returns the UUID of the logical router policy or ACL of the egress
+// firewall of a namespace at the given priority, or an empty string if there is none
+func findEgressFirewallRow(table string, priority int, namespace string) (string, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", table,
+		fmt.Sprintf("priority=%d", priority), fmt.Sprintf("external-ids:egressFirewall=%s", namespace))
+	if err != nil {
+		return "", fmt.Errorf("failed to find the %s of priority %d of egressFirewall in namespace %s, stderr: %q (%v)",
+			table, priority, namespace, stderr, err)
+	}
+	return stdout, nil
+}
+
+// setEgressFirewallRowMatch sets the match of a logical router policy or ACL of an egress firewall
+func setEgressFirewallRowMatch(table, uuid, match, namespace string) error {
+	_, stderr, err := util.RunOVNNbctl("set", table, uuid, match)
+	if err != nil {
+		return fmt.Errorf("failed to update the %s %s of egressFirewall in namespace %s, stderr: %q (%v)",
+			table, uuid, namespace, stderr, err)
+	}
+	return nil
+}
+
+// getEgressFirewallLoggingSeverity returns the severity at which the traffic matching a
+// logged rule is logged, following the namespace's ACL logging levels if it has them
+func getEgressFirewallLoggingSeverity(access egressfirewallapi.EgressFirewallRuleType, aclLogging ACLLoggingLevels) string {
+	severity := aclLogging.Allow
+	if access == egressfirewallapi.EgressFirewallRuleDeny {
+		severity = aclLogging.Deny
+	}
+	if severity == "" {
+		severity = "info"
+	}
+	return severity
+}
+
+// getEgressFirewallACLName returns the name of the ACL mirroring an egress firewall rule,
+// which tells the verdict of the rule in the logs as the ACL itself always allows
+func getEgressFirewallACLName(namespace string, rule *egressFirewallRule) string {
+	return fmt.Sprintf("%s_egressFirewall_%d_%s", namespace, rule.id, strings.ToLower(string(rule.access)))
+}
+
+// createEgressFirewallACL mirrors an egress firewall rule with an ACL on the cluster port group,
+// logging the traffic it matches at severity if it is not empty. The ACL only allows the traffic,
+// whatever the verdict of the rule, so that logging never changes how the traffic is handled:
+// the ACLs have a lower priority than the NetworkPolicy ACLs and allowing is what happens to the
+// traffic no ACL matches.
+func (oc *Controller) createEgressFirewallACL(priority int, match, namespace, aclName, severity string) error {
+	args := []string{"--id=@acl", "create", "acl",
+		fmt.Sprintf("priority=%d", priority),
+		fmt.Sprintf("direction=%s", fromLport), match, "action=allow",
+		getACLNameArg(aclName)}
+	args = append(args, getACLLoggingArgs(severity)...)
+	args = append(args,
+		fmt.Sprintf("external-ids:egressFirewall=%s", namespace),
+		"--", "add", "port_group", oc.clusterPortGroupUUID, "acls", "@acl")
+	_, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to create egressFirewall ACL '%s' for namespace %s, "+
+			"stderr: %s, error: %v", match, namespace, stderr, err)
+	}
+	return nil
+}
+
+// deleteEgressFirewallACLs deletes the ACLs mirroring the egress firewall rules of a namespace
+func (oc *Controller) deleteEgressFirewallACLs(namespace string) error {
+	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "ACL",
+		fmt.Sprintf("external-ids:egressFirewall=%s", namespace))
+	if err != nil {
+		return fmt.Errorf("failed to find the egressFirewall ACLs for namespace %s, stderr: %q (%v)",
+			namespace, stderr, err)
+	}
+	for _, uuid := range strings.Fields(stdout) {
+		_, stderr, err := util.RunOVNNbctl("--if-exists", "remove", "port_group", oc.clusterPortGroupUUID, "acls", uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete the egressFirewall ACL %s for namespace %s, stderr: %q (%v)",
+				uuid, namespace, stderr, err)
 		}
 	}
 	return nil
@@ -317,6 +847,21 @@ func (m *matchTarget) toExpr() (string, error) {
 // sample output:
 // match=\"(ip4.dst == 1.2.3.4/32) && ip4.src == $testv4 && ip4.dst != 10.128.0.0/14\
 func generateMatch(ipv4Source, ipv6Source string, destinations []matchTarget, dstPorts []egressfirewallapi.EgressFirewallPort) string {
+	return buildMatch(ipv4Source, ipv6Source, destinations, dstPorts, getClusterSubnetsExclusion())
+}
+
+// generateACLMatch generates the "match" section of the ACLs mirroring egressFirewallRules.
+// The ACLs see the traffic to the services before it is load balanced, so the service
+// CIDRs are excluded along with the cluster subnets.
+func generateACLMatch(ipv4Source, ipv6Source string, destinations []matchTarget, dstPorts []egressfirewallapi.EgressFirewallPort) string {
+	exclusion := getClusterSubnetsExclusion()
+	if serviceExclusion := getServiceCIDRsExclusion(); serviceExclusion != "" {
+		exclusion = strings.Join([]string{exclusion, serviceExclusion}, " && ")
+	}
+	return buildMatch(ipv4Source, ipv6Source, destinations, dstPorts, exclusion)
+}
+
+func buildMatch(ipv4Source, ipv6Source string, destinations []matchTarget, dstPorts []egressfirewallapi.EgressFirewallPort, exclusion string) string {
 	var src string
 	var dst string
 	switch {
@@ -349,7 +894,7 @@ func generateMatch(ipv4Source, ipv6Source string, destinations []matchTarget, ds
 		match = fmt.Sprintf("%s && %s", match, egressGetL4Match(dstPorts))
 	}
 
-	return fmt.Sprintf("%s && %s\"", match, getClusterSubnetsExclusion())
+	return fmt.Sprintf("%s && %s\"", match, exclusion)
 }
 
 // egressGetL4Match generates the rules for when ports are specified in an egressFirewall Rule
@@ -433,3 +978,18 @@ func getClusterSubnetsExclusion() string {
 	}
 	return exclusion
 }
+
+func getServiceCIDRsExclusion() string {
+	var exclusion string
+	for _, serviceCIDR := range config.Kubernetes.ServiceCIDRs {
+		if exclusion != "" {
+			exclusion += " && "
+		}
+		if utilnet.IsIPv6CIDR(serviceCIDR) {
+			exclusion += fmt.Sprintf("%s.dst != %s", "ip6", serviceCIDR)
+		} else {
+			exclusion += fmt.Sprintf("%s.dst != %s", "ip4", serviceCIDR)
+		}
+	}
+	return exclusion
+}
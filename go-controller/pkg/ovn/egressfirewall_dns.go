@@ -8,7 +8,9 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 )
 
@@ -21,6 +23,12 @@ type EgressDNS struct {
 	dnsEntries map[string]*dnsEntry
 	// allows for the creation of addresssets
 	addressSetFactory AddressSetFactory
+	// onResolve, if set, is called with the namespaces using a dnsName each time
+	// the dnsName is resolved
+	onResolve func(dnsName string, namespaces []string)
+	// the nodes that get an addressSet for each wildcard dnsName, in which they set
+	// the IPs they learn by snooping DNS responses
+	nodes sets.String
 
 	// Report change when Add operation is done
 	added          chan string
@@ -36,6 +44,13 @@ type dnsEntry struct {
 	dnsResolves []net.IP
 	// the addressSet that contains the current IPs
 	dnsAddressSet AddressSet
+	// the addressSets of a wildcard dnsName by node, which contain the IPs each node
+	// learned for it
+	nodeAddressSets map[string]AddressSet
+	// the last time the dnsName was successfully resolved
+	lastResolved time.Time
+	// the error of the last resolution of the dnsName, if it failed
+	resolveErr error
 }
 
 func NewEgressDNS(addressSetFactory AddressSetFactory, controllerStop <-chan struct{}) (*EgressDNS, error) {
@@ -48,6 +63,7 @@ func NewEgressDNS(addressSetFactory AddressSetFactory, controllerStop <-chan str
 		dns:               dnsInfo,
 		dnsEntries:        make(map[string]*dnsEntry),
 		addressSetFactory: addressSetFactory,
+		nodes:             sets.NewString(),
 
 		added:          make(chan string, 1),
 		stopChan:       make(chan struct{}),
@@ -57,7 +73,9 @@ func NewEgressDNS(addressSetFactory AddressSetFactory, controllerStop <-chan str
 	return egressDNS, nil
 }
 
-func (e *EgressDNS) Add(namespace, dnsName string) (AddressSet, error) {
+// Add adds a namespace using a dnsName and returns the addressSets holding the IPs of the
+// dnsName: one for a dnsName that is resolved, one per node for a wildcard dnsName
+func (e *EgressDNS) Add(namespace, dnsName string) ([]AddressSet, error) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
@@ -69,16 +87,103 @@ func (e *EgressDNS) Add(namespace, dnsName string) (AddressSet, error) {
 		if e.addressSetFactory == nil {
 			return nil, fmt.Errorf("error adding EgressFirewall DNS rule for host %s, in namespace %s: addressSetFactory is nil", dnsName, namespace)
 		}
-		dnsEntry.dnsAddressSet, err = e.addressSetFactory.NewAddressSet(dnsName, nil)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create addressSet for %s: %v", dnsName, err)
+		if util.IsWildcardDNSName(dnsName) {
+			// wildcard dnsNames cannot be resolved, their IPs are set by the nodes
+			dnsEntry.nodeAddressSets = make(map[string]AddressSet)
+			for _, nodeName := range e.nodes.UnsortedList() {
+				if err := e.ensureNodeAddressSet(&dnsEntry, dnsName, nodeName); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			dnsEntry.dnsAddressSet, err = e.addressSetFactory.NewAddressSet(dnsName, nil)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create addressSet for %s: %v", dnsName, err)
+			}
 		}
 		e.dnsEntries[dnsName] = &dnsEntry
-		e.signalAdded(dnsName)
+		if !util.IsWildcardDNSName(dnsName) {
+			e.signalAdded(dnsName)
+		}
 	}
 	e.dnsEntries[dnsName].namespaces[namespace] = struct{}{}
-	return e.dnsEntries[dnsName].dnsAddressSet, nil
+	return e.dnsEntries[dnsName].getAddressSets(), nil
+}
+
+// getAddressSets returns the addressSets of a dnsEntry, sorted by node for a wildcard dnsName
+func (entry *dnsEntry) getAddressSets() []AddressSet {
+	if entry.nodeAddressSets == nil {
+		return []AddressSet{entry.dnsAddressSet}
+	}
+	addressSets := make([]AddressSet, 0, len(entry.nodeAddressSets))
+	for _, nodeName := range sets.StringKeySet(entry.nodeAddressSets).List() {
+		addressSets = append(addressSets, entry.nodeAddressSets[nodeName])
+	}
+	return addressSets
+}
+
+// ensureNodeAddressSet creates the addressSet of a node for a wildcard dnsName. The IPs the
+// node already set in it are kept.
+func (e *EgressDNS) ensureNodeAddressSet(entry *dnsEntry, dnsName, nodeName string) error {
+	if _, ok := entry.nodeAddressSets[nodeName]; ok {
+		return nil
+	}
+	addressSet, err := e.addressSetFactory.EnsureAddressSet(util.GetEgressFirewallDNSAddressSetName(dnsName, nodeName))
+	if err != nil {
+		return fmt.Errorf("cannot create addressSet for %s on node %s: %v", dnsName, nodeName, err)
+	}
+	entry.nodeAddressSets[nodeName] = addressSet
+	return nil
+}
 
+// AddNode creates the addressSets of a node for the wildcard dnsNames in use and returns
+// the namespaces using the dnsNames that got a new addressSet
+func (e *EgressDNS) AddNode(nodeName string) ([]string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.nodes.Insert(nodeName)
+	namespaces := sets.NewString()
+	var errs []error
+	for dnsName, entry := range e.dnsEntries {
+		if entry.nodeAddressSets == nil {
+			continue
+		}
+		if _, ok := entry.nodeAddressSets[nodeName]; ok {
+			continue
+		}
+		if err := e.ensureNodeAddressSet(entry, dnsName, nodeName); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for namespace := range entry.namespaces {
+			namespaces.Insert(namespace)
+		}
+	}
+	return namespaces.List(), kerrors.NewAggregate(errs)
+}
+
+// DeleteNode destroys the addressSets of a node and returns the namespaces using the
+// wildcard dnsNames they belonged to
+func (e *EgressDNS) DeleteNode(nodeName string) ([]string, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.nodes.Delete(nodeName)
+	namespaces := sets.NewString()
+	var errs []error
+	for dnsName, entry := range e.dnsEntries {
+		addressSet, ok := entry.nodeAddressSets[nodeName]
+		if !ok {
+			continue
+		}
+		if err := addressSet.Destroy(); err != nil {
+			errs = append(errs, fmt.Errorf("cannot destroy addressSet for %s on node %s: %v", dnsName, nodeName, err))
+		}
+		delete(entry.nodeAddressSets, nodeName)
+		for namespace := range entry.namespaces {
+			namespaces.Insert(namespace)
+		}
+	}
+	return namespaces.List(), kerrors.NewAggregate(errs)
 }
 
 func (e *EgressDNS) Delete(namespace string) bool {
@@ -91,9 +196,11 @@ func (e *EgressDNS) Delete(namespace string) bool {
 		delete(dnsEntry.namespaces, namespace)
 		if len(dnsEntry.namespaces) == 0 {
 			// the dnsEntry appears in no other namespace so delete the address_set
-			err := dnsEntry.dnsAddressSet.Destroy()
-			if err != nil {
-				klog.Errorf("Error deleteing EgressFirewall AddressSet for dnsName: %s %v", dnsName, err)
+			for _, addressSet := range dnsEntry.getAddressSets() {
+				err := addressSet.Destroy()
+				if err != nil {
+					klog.Errorf("Error deleteing EgressFirewall AddressSet for dnsName: %s %v", dnsName, err)
+				}
 			}
 			// the dnsEntry is no longer needed because nothing references it delete it
 			delete(e.dnsEntries, dnsName)
@@ -111,18 +218,47 @@ func (e *EgressDNS) Update(dns string) (bool, error) {
 	return e.dns.Update(dns)
 }
 
-func (e *EgressDNS) updateEntryForName(dnsName string) error {
+func (e *EgressDNS) updateEntryForName(dnsName string, resolveErr error) error {
 	e.lock.Lock()
-	defer e.lock.Unlock()
+	entry, exists := e.dnsEntries[dnsName]
+	if !exists {
+		// the dnsName was deleted while it was being resolved
+		e.lock.Unlock()
+		return nil
+	}
 	ips := e.dns.GetIPs(dnsName)
-	e.dnsEntries[dnsName].dnsResolves = ips
+	entry.dnsResolves = ips
+	entry.resolveErr = resolveErr
+	if resolveErr == nil {
+		entry.lastResolved = time.Now()
+	}
+	namespaces := make([]string, 0, len(entry.namespaces))
+	for namespace := range entry.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
 
-	if err := e.dnsEntries[dnsName].dnsAddressSet.SetIPs(ips); err != nil {
+	err := entry.dnsAddressSet.SetIPs(ips)
+	e.lock.Unlock()
+	if e.onResolve != nil {
+		e.onResolve(dnsName, namespaces)
+	}
+	if err != nil {
 		return fmt.Errorf("cannot add IPs from EgressFirewall AddressSet %s: %v", dnsName, err)
 	}
 	return nil
 }
 
+// getResolution returns the IPs a dnsName last resolved to, the time it was last
+// successfully resolved and the error of its last resolution, if it failed
+func (e *EgressDNS) getResolution(dnsName string) ([]net.IP, time.Time, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if entry, exists := e.dnsEntries[dnsName]; exists {
+		return entry.dnsResolves, entry.lastResolved, entry.resolveErr
+	}
+	return nil, time.Time{}, nil
+}
+
 // Run spawns a goroutine that handles updates to the dns entries for dnsNames used in
 // EgressFirewalls. The loop runs after receiving one of two signals
 // 1. a new dnsName has been added and a signal is sent to add the new DNS name, if an
@@ -140,18 +276,20 @@ func (e *EgressDNS) Run(defaultInterval time.Duration) {
 			// Wait for the given duration or until something gets added
 			select {
 			case dnsName := <-e.added:
-				if err := e.dns.Add(dnsName); err != nil {
-					utilruntime.HandleError(err)
+				resolveErr := e.dns.Add(dnsName)
+				if resolveErr != nil {
+					utilruntime.HandleError(resolveErr)
 				}
-				if err := e.updateEntryForName(dnsName); err != nil {
+				if err := e.updateEntryForName(dnsName, resolveErr); err != nil {
 					utilruntime.HandleError(err)
 				}
 			case <-time.After(durationTillNextQuery):
 				if len(dnsName) > 0 {
-					if _, err := e.Update(dnsName); err != nil {
-						utilruntime.HandleError(err)
+					_, resolveErr := e.Update(dnsName)
+					if resolveErr != nil {
+						utilruntime.HandleError(resolveErr)
 					}
-					if err := e.updateEntryForName(dnsName); err != nil {
+					if err := e.updateEntryForName(dnsName, resolveErr); err != nil {
 						utilruntime.HandleError(err)
 					}
 				}
@@ -417,3 +417,56 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestAddDeleteNode(t *testing.T) {
+	mockAddressSetFactoryOps := new(mocks.AddressSetFactory)
+	node1AddressSet := new(mocks.AddressSet)
+	node2AddressSet := new(mocks.AddressSet)
+	mockDnsOps := new(util_mocks.DNSOps)
+	util.SetDNSLibOpsMockInst(mockDnsOps)
+	wildcard := "*.example.com"
+	mockDnsOps.On("ClientConfigFromFile", mock.AnythingOfType("string")).Return(&dns.ClientConfig{
+		Servers: []string{"1.1.1.1"},
+		Port:    "1234"}, nil).Once()
+
+	res, err := ovn.NewEgressDNS(mockAddressSetFactoryOps, make(chan struct{}))
+	assert.Nil(t, err)
+
+	// nodes get no addressSet until a wildcard dnsName is used
+	namespaces, err := res.AddNode("node1")
+	assert.Nil(t, err)
+	assert.Empty(t, namespaces)
+
+	// a wildcard dnsName gets an addressSet per node
+	mockAddressSetFactoryOps.On("EnsureAddressSet", wildcard+"_node1").Return(node1AddressSet, nil).Once()
+	addressSets, err := res.Add("addNamespace", wildcard)
+	assert.Nil(t, err)
+	assert.Equal(t, []ovn.AddressSet{node1AddressSet}, addressSets)
+
+	// a new node gets an addressSet for the wildcard dnsNames in use
+	mockAddressSetFactoryOps.On("EnsureAddressSet", wildcard+"_node2").Return(node2AddressSet, nil).Once()
+	namespaces, err = res.AddNode("node2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"addNamespace"}, namespaces)
+	namespaces, err = res.AddNode("node2")
+	assert.Nil(t, err)
+	assert.Empty(t, namespaces)
+	addressSets, err = res.Add("addNamespace", wildcard)
+	assert.Nil(t, err)
+	assert.Equal(t, []ovn.AddressSet{node1AddressSet, node2AddressSet}, addressSets)
+
+	// the addressSets of a node are destroyed when it goes away
+	node2AddressSet.On("Destroy").Return(nil).Once()
+	namespaces, err = res.DeleteNode("node2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"addNamespace"}, namespaces)
+
+	// the addressSets of all the nodes are destroyed with the wildcard dnsName
+	node1AddressSet.On("Destroy").Return(nil).Once()
+	res.Delete("addNamespace")
+
+	mockAddressSetFactoryOps.AssertExpectations(t)
+	node1AddressSet.AssertExpectations(t)
+	node2AddressSet.AssertExpectations(t)
+	mockDnsOps.AssertExpectations(t)
+}
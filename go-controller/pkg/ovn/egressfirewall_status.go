@@ -0,0 +1,157 @@
+package ovn
+
+import (
+	"fmt"
+	"reflect"
+
+	egressfirewallapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// setRuleApplied records that the rule with the given id is in effect
+func (ef *egressFirewall) setRuleApplied(id int) {
+	ef.Lock()
+	defer ef.Unlock()
+	if id < len(ef.ruleStatus) {
+		ef.ruleStatus[id] = egressfirewallapi.EgressFirewallRuleStatus{
+			Index:  int32(id),
+			Status: egressfirewallapi.EgressFirewallRuleApplied,
+		}
+	}
+}
+
+// setRuleFailed records why the rule with the given id is not in effect
+func (ef *egressFirewall) setRuleFailed(id int, reason, message string) {
+	ef.Lock()
+	defer ef.Unlock()
+	if id < len(ef.ruleStatus) {
+		ef.ruleStatus[id] = egressfirewallapi.EgressFirewallRuleStatus{
+			Index:   int32(id),
+			Status:  egressfirewallapi.EgressFirewallRuleFailed,
+			Reason:  reason,
+			Message: message,
+		}
+	}
+}
+
+// getEgressFirewall returns the egress firewall applied to a namespace, if any
+func (oc *Controller) getEgressFirewall(namespace string) *egressFirewall {
+	nsInfo := oc.getNamespaceLocked(namespace)
+	if nsInfo == nil {
+		return nil
+	}
+	defer nsInfo.Unlock()
+	return nsInfo.egressFirewallPolicy
+}
+
+// setEgressFirewallReadyCondition sets the Ready condition of an egress firewall from
+// the result of applying it
+func setEgressFirewallReadyCondition(egressFirewall *egressfirewallapi.EgressFirewall, applyErr error) {
+	condition := metav1.Condition{
+		Type:               egressfirewallapi.EgressFirewallConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: egressFirewall.Generation,
+		Reason:             "EgressFirewallApplied",
+		Message:            "the egress firewall is applied",
+	}
+	if applyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "EgressFirewallNotApplied"
+		condition.Message = applyErr.Error()
+	}
+	meta.SetStatusCondition(&egressFirewall.Status.Conditions, condition)
+}
+
+// setEgressFirewallRulesStatus reports the status of each rule of an egress firewall, the
+// last resolution of its DNS names and the number of pods it applies to, and sets the
+// Degraded condition when some of the rules are not in effect
+func (oc *Controller) setEgressFirewallRulesStatus(egressFirewall *egressfirewallapi.EgressFirewall, ef *egressFirewall) {
+	ef.Lock()
+	egressFirewall.Status.SelectedPods = int32(ef.selectedPods.Len())
+	rules := make([]egressfirewallapi.EgressFirewallRuleStatus, len(ef.ruleStatus))
+	for i := range ef.ruleStatus {
+		ef.ruleStatus[i].DeepCopyInto(&rules[i])
+	}
+	dnsNames := make(map[int]string)
+	for _, rule := range ef.egressRules {
+		if rule.to.dnsName != "" {
+			dnsNames[rule.id] = rule.to.dnsName
+		}
+	}
+	ef.Unlock()
+
+	failed := 0
+	for i := range rules {
+		dnsName, ok := dnsNames[i]
+		if ok && rules[i].Status == egressfirewallapi.EgressFirewallRuleApplied && oc.egressFirewallDNS != nil {
+			ips, lastResolved, err := oc.egressFirewallDNS.getResolution(dnsName)
+			for _, ip := range ips {
+				rules[i].ResolvedIPs = append(rules[i].ResolvedIPs, ip.String())
+			}
+			if !lastResolved.IsZero() {
+				// the API only stores the time up to the second
+				resolved := metav1.NewTime(lastResolved).Rfc3339Copy()
+				rules[i].LastResolved = &resolved
+			}
+			if err != nil {
+				rules[i].Status = egressfirewallapi.EgressFirewallRuleFailed
+				rules[i].Reason = egressfirewallapi.EgressFirewallRuleReasonDNSResolutionFailed
+				rules[i].Message = err.Error()
+			}
+		}
+		if rules[i].Status != egressfirewallapi.EgressFirewallRuleApplied {
+			failed++
+		}
+	}
+	egressFirewall.Status.Rules = rules
+
+	condition := metav1.Condition{
+		Type:               egressfirewallapi.EgressFirewallConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: egressFirewall.Generation,
+		Reason:             "AllRulesApplied",
+		Message:            "all the rules of the egress firewall are in effect",
+	}
+	if failed > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "RulesNotApplied"
+		condition.Message = fmt.Sprintf("%d of the %d rules of the egress firewall are not in effect, see the status of the rules",
+			failed, len(rules))
+	}
+	meta.SetStatusCondition(&egressFirewall.Status.Conditions, condition)
+}
+
+// updateEgressFirewallStatus updates the status of an egress firewall with setStatus, if set,
+// and the status of the rules of ef, if set. The status is only written when it changes.
+func (oc *Controller) updateEgressFirewallStatus(namespace, name string, ef *egressFirewall,
+	setStatus func(egressFirewall *egressfirewallapi.EgressFirewall)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		egressFirewall, err := oc.watchFactory.GetEgressFirewall(namespace, name)
+		if err != nil {
+			return err
+		}
+		updated := egressFirewall.DeepCopy()
+		if setStatus != nil {
+			setStatus(updated)
+		}
+		if ef != nil && ef.name == name {
+			oc.setEgressFirewallRulesStatus(updated, ef)
+		}
+		if reflect.DeepEqual(egressFirewall.Status, updated.Status) {
+			return nil
+		}
+		return oc.kube.UpdateEgressFirewall(updated)
+	})
+}
+
+// refreshEgressFirewallStatus reports changes of the rules of an egress firewall, the
+// pods it applies to or the resolution of its DNS names in its status
+func (oc *Controller) refreshEgressFirewallStatus(ef *egressFirewall) {
+	if err := oc.updateEgressFirewallStatus(ef.namespace, ef.name, ef, nil); err != nil {
+		klog.Errorf("Failed to update the status of egressFirewall %s/%s: %v", ef.namespace, ef.name, err)
+	}
+}
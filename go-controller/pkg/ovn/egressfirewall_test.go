@@ -3,6 +3,7 @@ package ovn
 import (
 	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,8 +15,10 @@ import (
 	"github.com/urfave/cli/v2"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 func newObjectMeta(name, namespace string) metav1.ObjectMeta {
@@ -300,6 +303,628 @@ var _ = Describe("OVN EgressFirewall Operations", func() {
 			err := app.Run([]string{app.Name})
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("applies an egressfirewall with a podSelector to the selected pods only", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace("namespace1")
+				selectedPod := newPod(namespace1.Name, "selected", "node1", "10.128.1.3")
+				selectedPod.Labels["app"] = "web"
+				otherPod := newPod(namespace1.Name, "other", "node1", "10.128.1.4")
+
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Deny",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+				})
+				egressFirewall.Spec.PodSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "web"},
+				}
+
+				podASName := getEgressFirewallAddressSetName(namespace1.Name)
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*selectedPod,
+							*otherPod,
+						},
+					})
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewall()
+
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3"})
+				Eventually(func() int32 {
+					ef, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Get(context.TODO(), egressFirewall.Name, metav1.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					return ef.Status.SelectedPods
+				}).Should(Equal(int32(1)))
+
+				// the other pod is selected once it gets the label
+				otherPod.Labels["app"] = "web"
+				_, err := fakeOVN.fakeClient.KubeClient.CoreV1().Pods(otherPod.Namespace).Update(context.TODO(), otherPod, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() int32 {
+					ef, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Get(context.TODO(), egressFirewall.Name, metav1.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					return ef.Status.SelectedPods
+				}).Should(Equal(int32(2)))
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3", "10.128.1.4"})
+
+				// updating the egressfirewall only blocks the selected pods meanwhile
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=10000 match=\"(ip4.dst == 0.0.0.0/0 || ip6.dst == ::/0) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1-blockAll -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.5/23) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1-blockAll",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+				})
+				egressFirewall.Spec.Egress[0].To.CIDRSelector = "1.2.3.5/23"
+				_, err = fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Update(context.TODO(), egressFirewall, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3", "10.128.1.4"})
+
+				// deleting the egressfirewall removes its address set
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+				})
+				err = fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Delete(context.TODO(), egressFirewall.Name, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.EventuallyExpectNoAddressSet(getIPv4ASName(podASName))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("reconciles the address set of an existing egressfirewall with a podSelector", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace("namespace1")
+				selectedPod := newPod(namespace1.Name, "selected", "node1", "10.128.1.3")
+				selectedPod.Labels["app"] = "web"
+
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Deny",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+				})
+				egressFirewall.Spec.PodSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "web"},
+				}
+
+				podASName := getEgressFirewallAddressSetName(namespace1.Name)
+				staleASName := getEgressFirewallAddressSetName("namespace2")
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*selectedPod,
+						},
+					})
+				// the address sets left by the previous master, one of them for a
+				// namespace that was deleted meanwhile
+				_, err := fakeOVN.asf.NewAddressSet(podASName, []net.IP{net.ParseIP("10.128.1.3"), net.ParseIP("10.128.1.9")})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = fakeOVN.asf.NewAddressSet(staleASName, []net.IP{net.ParseIP("10.128.2.3")})
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3", "10.128.1.9"})
+				fakeOVN.asf.ExpectNoAddressSet(getIPv4ASName(staleASName))
+
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3"})
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("destroys the address set of an egressfirewall once its podSelector is removed", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace("namespace1")
+				selectedPod := newPod(namespace1.Name, "selected", "node1", "10.128.1.3")
+				selectedPod.Labels["app"] = "web"
+
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Deny",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+				})
+				egressFirewall.Spec.PodSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "web"},
+				}
+
+				podASName := getEgressFirewallAddressSetName(namespace1.Name)
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*selectedPod,
+						},
+					})
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName(podASName), []string{"10.128.1.3"})
+
+				// the blockAll policy matches the address set until it is deleted
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=10000 match=\"(ip4.dst == 0.0.0.0/0 || ip6.dst == ::/0) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1-blockAll -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(podASName))),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $%s && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+						hashedAddressSet(getIPv4ASName(namespace1.Name))),
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1-blockAll",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+					Action: func() error {
+						// the address set still exists when the blockAll policy is deleted
+						if as := fakeOVN.asf.getAddressSet(getIPv4ASName(podASName)); as != nil {
+							as.Unlock()
+							return nil
+						}
+						return fmt.Errorf("address set %s destroyed before the blockAll policy", podASName)
+					},
+				})
+				egressFirewall.Spec.PodSelector = nil
+				_, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Update(context.TODO(), egressFirewall, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.EventuallyExpectNoAddressSet(getIPv4ASName(podASName))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("mirrors the rules of an egressfirewall with ACLs when a rule is logged", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace("namespace1")
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+					{
+						Type: "Deny",
+						Log:  true,
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "0.0.0.0/0",
+						},
+					},
+				})
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=allow external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+					"ovn-nbctl --timeout=15 --id=@acl create acl priority=999 direction=from-lport match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14 && ip4.dst != 172.16.1.0/24\" action=allow name=namespace1_egressFirewall_0_allow external-ids:egressFirewall=namespace1 -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9998 match=\"(ip4.dst == 0.0.0.0/0) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+					"ovn-nbctl --timeout=15 --id=@acl create acl priority=998 direction=from-lport match=\"(ip4.dst == 0.0.0.0/0) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14 && ip4.dst != 172.16.1.0/24\" action=allow name=namespace1_egressFirewall_1_deny log=true severity=info external-ids:egressFirewall=namespace1 -- add port_group " + ovnClusterPortGroupUUID + " acls @acl",
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					})
+				fakeOVN.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewall()
+
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// deleting the egressfirewall removes the ACLs
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:egressFirewall=namespace1",
+					Output: fakeUUIDv6,
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls " + fakeUUIDv6,
+					"ovn-nbctl --timeout=15 lr-policy-del ovn_cluster_router " + fakeUUID,
+				})
+				err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(egressFirewall.Namespace).Delete(context.TODO(), egressFirewall.Name, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("reports the status of the egressfirewall and of each rule", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=allow external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $a4615334824109672969 && ip4.dst != 10.128.0.0/14\" action=allow external-ids:egressFirewall=namespace2 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+				})
+
+				namespace1 := *newNamespace("namespace1")
+				namespace2 := *newNamespace("namespace2")
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+				})
+				// the invalid rule is skipped and the others are applied
+				invalidEgressFirewall := newEgressFirewallObject("default", namespace2.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+					{
+						Type: "Deny",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3./32",
+						},
+					},
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+							*invalidEgressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+							namespace2,
+						},
+					})
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				getStatus := func(namespace string) egressfirewallapi.EgressFirewallStatus {
+					ef, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(namespace).Get(context.TODO(), "default", metav1.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					return ef.Status
+				}
+				Eventually(func() []metav1.Condition { return getStatus(namespace1.Name).Conditions }).Should(HaveLen(2))
+				status := getStatus(namespace1.Name)
+				Expect(status.Status).To(Equal(egressFirewallAppliedCorrectly))
+				Expect(meta.IsStatusConditionTrue(status.Conditions, egressfirewallapi.EgressFirewallConditionReady)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(status.Conditions, egressfirewallapi.EgressFirewallConditionDegraded)).To(BeTrue())
+				Expect(status.Rules).To(Equal([]egressfirewallapi.EgressFirewallRuleStatus{
+					{Index: 0, Status: egressfirewallapi.EgressFirewallRuleApplied},
+				}))
+
+				Eventually(func() []metav1.Condition { return getStatus(namespace2.Name).Conditions }).Should(HaveLen(2))
+				status = getStatus(namespace2.Name)
+				Expect(meta.IsStatusConditionTrue(status.Conditions, egressfirewallapi.EgressFirewallConditionReady)).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(status.Conditions, egressfirewallapi.EgressFirewallConditionDegraded)).To(BeTrue())
+				Expect(status.Rules).To(HaveLen(2))
+				Expect(status.Rules[0].Status).To(Equal(egressfirewallapi.EgressFirewallRuleApplied))
+				Expect(status.Rules[1].Status).To(Equal(egressfirewallapi.EgressFirewallRuleFailed))
+				Expect(status.Rules[1].Reason).To(Equal(egressfirewallapi.EgressFirewallRuleReasonInvalidCIDR))
+				Expect(status.Rules[1].Message).To(Equal("invalid CIDR address: 1.2.3./32"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("fails the rules with wildcard DNS names when DNS snooping is disabled", func() {
+			app.Action = func(ctx *cli.Context) error {
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9998 match=\"(ip4.dst == 1.2.3.4/23) && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=drop external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+				})
+
+				namespace1 := *newNamespace("namespace1")
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							DNSName: "*.example.com",
+						},
+					},
+					{
+						Type: "Deny",
+						To: egressfirewallapi.EgressFirewallDestination{
+							CIDRSelector: "1.2.3.4/23",
+						},
+					},
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					})
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				getStatus := func() egressfirewallapi.EgressFirewallStatus {
+					ef, err := fakeOVN.fakeClient.EgressFirewallClient.K8sV1().EgressFirewalls(namespace1.Name).Get(context.TODO(), "default", metav1.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					return ef.Status
+				}
+				Eventually(func() []metav1.Condition { return getStatus().Conditions }).Should(HaveLen(2))
+				status := getStatus()
+				Expect(meta.IsStatusConditionTrue(status.Conditions, egressfirewallapi.EgressFirewallConditionDegraded)).To(BeTrue())
+				Expect(status.Rules).To(Equal([]egressfirewallapi.EgressFirewallRuleStatus{
+					{
+						Index:   0,
+						Status:  egressfirewallapi.EgressFirewallRuleFailed,
+						Reason:  egressfirewallapi.EgressFirewallRuleReasonDNSResolutionFailed,
+						Message: "wildcard DNS names require DNS snooping to be enabled on the nodes",
+					},
+					{Index: 1, Status: egressfirewallapi.EgressFirewallRuleApplied},
+				}))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("matches the address sets of all the nodes with the rules of wildcard DNS names", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping = true
+				node1AddressSet := hashedAddressSet(getIPv4ASName("*.example.com_node1"))
+				node2AddressSet := hashedAddressSet(getIPv4ASName("*.example.com_node2"))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == $" + node1AddressSet + ") && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=allow external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+				})
+
+				namespace1 := *newNamespace("namespace1")
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							DNSName: "*.Example.com",
+						},
+					},
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{
+							{
+								ObjectMeta: newObjectMeta("node1", ""),
+							},
+						},
+					})
+				fakeOVN.controller.egressFirewallDNS = &EgressDNS{
+					dnsEntries:        make(map[string]*dnsEntry),
+					addressSetFactory: fakeOVN.asf,
+					nodes:             sets.NewString(),
+				}
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchEgressFirewallNodes()
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectEmptyAddressSet(getIPv4ASName("*.example.com_node1"))
+
+				// the rule matches the address set of a new node as well
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy priority=9999 external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set logical_router_policy " + fakeUUID + " match=\"(ip4.dst == $" + node1AddressSet + " || ip4.dst == $" + node2AddressSet + ") && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\"",
+				})
+				_, err := fakeOVN.fakeClient.KubeClient.CoreV1().Nodes().Create(context.TODO(), &v1.Node{
+					ObjectMeta: newObjectMeta("node2", ""),
+				}, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectEmptyAddressSet(getIPv4ASName("*.example.com_node2"))
+
+				// and stops matching it when the node goes away
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_policy priority=9999 external-ids:egressFirewall=namespace1",
+					Output: fakeUUID,
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set logical_router_policy " + fakeUUID + " match=\"(ip4.dst == $" + node1AddressSet + ") && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\"",
+				})
+				err = fakeOVN.fakeClient.KubeClient.CoreV1().Nodes().Delete(context.TODO(), "node2", *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.EventuallyExpectNoAddressSet(getIPv4ASName("*.example.com_node2"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("keeps the wildcard DNS name address sets of the nodes in use across a restart", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping = true
+				node1AddressSet := hashedAddressSet(getIPv4ASName("*.example.com_node1"))
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					// the legacy default deny port groups removed by the network policy sync
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=ingressDefaultDeny",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=egressDefaultDeny",
+					"ovn-nbctl --timeout=15 --id=@logical_router_policy create logical_router_policy priority=9999 match=\"(ip4.dst == $" + node1AddressSet + ") && ip4.src == $a10481622940199974102 && ip4.dst != 10.128.0.0/14\" action=allow external-ids:egressFirewall=namespace1 -- add logical_router ovn_cluster_router policies @logical_router_policy",
+				})
+
+				namespace1 := *newNamespace("namespace1")
+				egressFirewall := newEgressFirewallObject("default", namespace1.Name, []egressfirewallapi.EgressFirewallRule{
+					{
+						Type: "Allow",
+						To: egressfirewallapi.EgressFirewallDestination{
+							DNSName: "*.example.com",
+						},
+					},
+				})
+
+				fakeOVN.start(ctx,
+					&egressfirewallapi.EgressFirewallList{
+						Items: []egressfirewallapi.EgressFirewall{
+							*egressFirewall,
+						},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{
+							{
+								ObjectMeta: newObjectMeta("node1", ""),
+							},
+						},
+					})
+				fakeOVN.controller.egressFirewallDNS = &EgressDNS{
+					dnsEntries:        make(map[string]*dnsEntry),
+					addressSetFactory: fakeOVN.asf,
+					nodes:             sets.NewString(),
+				}
+				// the address sets left by the previous master: the one in use holds the
+				// IPs set by node1, the others belong to a name no longer in use and to a
+				// node that was deleted
+				_, err := fakeOVN.asf.NewAddressSet("*.example.com_node1", []net.IP{net.ParseIP("1.1.1.1")})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = fakeOVN.asf.NewAddressSet("*.other.com_node1", []net.IP{net.ParseIP("2.2.2.2")})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = fakeOVN.asf.NewAddressSet("*.example.com_node2", []net.IP{net.ParseIP("3.3.3.3")})
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeOVN.controller.WatchNamespaces()
+				fakeOVN.controller.WatchNetworkPolicy()
+				fakeOVN.controller.WatchEgressFirewallNodes()
+				fakeOVN.controller.WatchEgressFirewall()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOVN.asf.ExpectAddressSetWithIPs(getIPv4ASName("*.example.com_node1"), []string{"1.1.1.1"})
+				fakeOVN.asf.ExpectNoAddressSet(getIPv4ASName("*.other.com_node1"))
+				fakeOVN.asf.ExpectNoAddressSet(getIPv4ASName("*.example.com_node2"))
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
 		It("correctly updates an egressfirewall", func() {
 			app.Action = func(ctx *cli.Context) error {
 				const (
@@ -563,6 +1188,57 @@ var _ = Describe("OVN test basic functions", func() {
 			}
 		}
 	})
+	It("reports the resolution of the DNS names of the rules", func() {
+		lastResolved := time.Now()
+		fakeOVN := &Controller{
+			egressFirewallDNS: &EgressDNS{
+				dnsEntries: map[string]*dnsEntry{
+					"www.example.com": {
+						dnsResolves:  []net.IP{net.ParseIP("1.2.3.4")},
+						lastResolved: lastResolved,
+					},
+					"www.unresolvable.com": {
+						resolveErr: fmt.Errorf("IPv4 or IPv6 addr not found for domain"),
+					},
+				},
+			},
+		}
+		egressFirewall := newEgressFirewallObject("default", "namespace1", []egressfirewallapi.EgressFirewallRule{
+			{
+				Type: egressfirewallapi.EgressFirewallRuleAllow,
+				To:   egressfirewallapi.EgressFirewallDestination{DNSName: "www.example.com"},
+			},
+			{
+				Type: egressfirewallapi.EgressFirewallRuleAllow,
+				To:   egressfirewallapi.EgressFirewallDestination{DNSName: "www.unresolvable.com"},
+			},
+		})
+		ef := newEgressFirewall(egressFirewall)
+		for i, rule := range egressFirewall.Spec.Egress {
+			efr, err := newEgressFirewallRule(rule, i)
+			Expect(err).NotTo(HaveOccurred())
+			ef.egressRules = append(ef.egressRules, efr)
+			ef.setRuleApplied(i)
+		}
+
+		fakeOVN.setEgressFirewallRulesStatus(egressFirewall, ef)
+		resolved := metav1.NewTime(lastResolved).Rfc3339Copy()
+		Expect(egressFirewall.Status.Rules).To(Equal([]egressfirewallapi.EgressFirewallRuleStatus{
+			{
+				Index:        0,
+				Status:       egressfirewallapi.EgressFirewallRuleApplied,
+				ResolvedIPs:  []string{"1.2.3.4"},
+				LastResolved: &resolved,
+			},
+			{
+				Index:   1,
+				Status:  egressfirewallapi.EgressFirewallRuleFailed,
+				Reason:  egressfirewallapi.EgressFirewallRuleReasonDNSResolutionFailed,
+				Message: "IPv4 or IPv6 addr not found for domain",
+			},
+		}))
+		Expect(meta.IsStatusConditionTrue(egressFirewall.Status.Conditions, egressfirewallapi.EgressFirewallConditionDegraded)).To(BeTrue())
+	})
 })
 
 //helper functions to help test egressfirewallDNS
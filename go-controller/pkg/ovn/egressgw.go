@@ -5,19 +5,31 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	utilnet "k8s.io/utils/net"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	nettypes "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 
 	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
+// exGWBFDStatusInterval is the interval at which the state of the BFD sessions to the external gateways is checked
+const exGWBFDStatusInterval = 5 * time.Second
+
+// exGWBFDSession identifies the BFD session from a gateway router to an external gateway
+type exGWBFDSession struct {
+	gr string
+	gw string
+}
+
 // addPodExternalGW handles detecting if a pod is serving as an external gateway for namespace(s) and adding routes
 // to all pods in that namespace
 func (oc *Controller) addPodExternalGW(pod *kapi.Pod) error {
@@ -26,16 +38,44 @@ func (oc *Controller) addPodExternalGW(pod *kapi.Pod) error {
 		return nil
 	}
 	klog.Infof("External gateway pod: %s, detected for namespace(s) %s", pod.Name, podRoutingNamespaceAnno)
-	var foundGws []net.IP
+	if pod.Annotations[routingNetworkAnnotation] == "" && !pod.Spec.HostNetwork {
+		klog.Errorf("Ignoring pod %s as an external gateway candidate. Invalid combination "+
+			"of host network: %t and routing-network annotation: %s", pod.Name, pod.Spec.HostNetwork,
+			pod.Annotations[routingNetworkAnnotation])
+		return nil
+	}
+	foundGws, err := getExGWPodIPs(pod, pod.Annotations[routingNetworkAnnotation])
+	if err != nil {
+		return err
+	}
+
+	// if we found any gateways then we need to update current pods routing in the relevant namespace
+	if len(foundGws) == 0 {
+		klog.Warningf("No valid gateway IPs found for requested external gateway pod: %s", pod.Name)
+		return nil
+	}
+
+	for _, namespace := range strings.Split(podRoutingNamespaceAnno, ",") {
+		err := oc.addPodExternalGWForNamespace(namespace, pod, foundGws)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	if pod.Annotations[routingNetworkAnnotation] != "" {
+// getExGWPodIPs returns the IPs of a pod serving as an external gateway, on the given multus network
+// or, when network is empty, on the host network
+func getExGWPodIPs(pod *kapi.Pod, network string) ([]net.IP, error) {
+	var foundGws []net.IP
+	if network != "" {
 		var multusNetworks []nettypes.NetworkStatus
 		err := json.Unmarshal([]byte(pod.ObjectMeta.Annotations[nettypes.NetworkStatusAnnot]), &multusNetworks)
 		if err != nil {
-			return fmt.Errorf("unable to unmarshall annotation k8s.v1.cni.cncf.io/network-status on pod %s: %v", pod.Name, err)
+			return nil, fmt.Errorf("unable to unmarshall annotation k8s.v1.cni.cncf.io/network-status on pod %s: %v", pod.Name, err)
 		}
 		for _, multusNetwork := range multusNetworks {
-			if multusNetwork.Name == pod.Annotations[routingNetworkAnnotation] {
+			if multusNetwork.Name == network {
 				for _, gwIP := range multusNetwork.IPs {
 					ip := net.ParseIP(gwIP)
 					if ip != nil {
@@ -52,25 +92,10 @@ func (oc *Controller) addPodExternalGW(pod *kapi.Pod) error {
 			}
 		}
 	} else {
-		klog.Errorf("Ignoring pod %s as an external gateway candidate. Invalid combination "+
-			"of host network: %t and routing-network annotation: %s", pod.Name, pod.Spec.HostNetwork,
-			pod.Annotations[routingNetworkAnnotation])
-		return nil
-	}
-
-	// if we found any gateways then we need to update current pods routing in the relevant namespace
-	if len(foundGws) == 0 {
-		klog.Warningf("No valid gateway IPs found for requested external gateway pod: %s", pod.Name)
-		return nil
+		return nil, fmt.Errorf("invalid combination of host network: %t and routing network: %s",
+			pod.Spec.HostNetwork, network)
 	}
-
-	for _, namespace := range strings.Split(podRoutingNamespaceAnno, ",") {
-		err := oc.addPodExternalGWForNamespace(namespace, pod, foundGws)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return foundGws, nil
 }
 
 // addPodExternalGWForNamespace handles adding routes to all pods in that namespace for a pod GW
@@ -107,8 +132,8 @@ func (oc *Controller) addGWRoutesForNamespace(namespace string, gws []net.IP, ns
 		for _, gw := range gws {
 			for _, podIP := range pod.Status.PodIPs {
 				mask := GetIPFullMask(podIP.IP)
-				_, stderr, err := util.RunOVNNbctl("--", "--may-exist", "--policy=src-ip", "--ecmp-symmetric-reply",
-					"lr-route-add", gr, podIP.IP+mask, gw.String())
+				_, stderr, err := util.RunOVNNbctl(append([]string{"--"},
+					getExGWRouteAddArgs(gr, podIP.IP+mask, gw.String(), nsInfo.bfdEnabled)...)...)
 				if err != nil {
 					return fmt.Errorf("unable to add src-ip route to GR router, stderr:%q, err:%v", stderr, err)
 				}
@@ -135,12 +160,12 @@ func (oc *Controller) deletePodExternalGW(pod *kapi.Pod) {
 	klog.Infof("Deleting routes for external gateway pod: %s, for namespace(s) %s", pod.Name,
 		podRoutingNamespaceAnno)
 	for _, namespace := range strings.Split(podRoutingNamespaceAnno, ",") {
-		oc.deletePodGWRoutesForNamespace(pod.Name, namespace, pod.Spec.NodeName)
+		oc.deletePodGWRoutesForNamespace(pod.Name, namespace)
 	}
 }
 
 // deletePodGwRoutesForNamespace handles deleting all routes in a namespace for a specific pod GW
-func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace, node string) {
+func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace string) {
 	nsInfo := oc.getNamespaceLocked(namespace)
 	if nsInfo == nil {
 		return
@@ -154,7 +179,42 @@ func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace, node string)
 		return
 	}
 
-	for _, gwIP := range foundGws {
+	delete(nsInfo.routingExternalPodGWs, pod)
+	// only remove the routes to the gateways no other source of the namespace provides
+	oc.deleteGWRoutesForGWs(nsInfo, nsInfo.getUnusedExternalGWs(foundGws))
+}
+
+// getUnusedExternalGWs returns the gateways in gws that neither the namespace annotation, a gateway pod
+// nor an AdminPolicyBasedExternalRoute provides to the namespace
+// This should only be called with a lock on nsInfo
+func (nsInfo *namespaceInfo) getUnusedExternalGWs(gws []net.IP) []net.IP {
+	used := sets.NewString()
+	for _, gw := range nsInfo.routingExternalGWs {
+		used.Insert(gw.String())
+	}
+	for _, podGWs := range nsInfo.routingExternalPodGWs {
+		for _, gw := range podGWs {
+			used.Insert(gw.String())
+		}
+	}
+	for _, policyGWs := range nsInfo.apbRouteGWs {
+		for _, gw := range policyGWs {
+			used.Insert(gw.String())
+		}
+	}
+	var unused []net.IP
+	for _, gw := range gws {
+		if !used.Has(gw.String()) {
+			unused = append(unused, gw)
+		}
+	}
+	return unused
+}
+
+// deleteGWRoutesForGWs handles deleting the routes of all the pods in a namespace to the given gateways
+// This should only be called with a lock on nsInfo
+func (oc *Controller) deleteGWRoutesForGWs(nsInfo *namespaceInfo, gws []net.IP) {
+	for _, gwIP := range gws {
 		// check for previously configured pod routes
 		for podIP, gwInfo := range nsInfo.podExternalRoutes {
 			if len(gwInfo) == 0 {
@@ -166,6 +226,7 @@ func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace, node string)
 			}
 			mask := GetIPFullMask(podIP)
 			// TODO (trozet): use the go bindings here and batch commands
+			node := strings.TrimPrefix(gr, types.GWRouterPrefix)
 			if err := oc.delHybridRoutePolicyForPod(net.ParseIP(podIP), node); err != nil {
 				klog.Error(err)
 			}
@@ -173,11 +234,14 @@ func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace, node string)
 			_, stderr, err := util.RunOVNNbctl("--", "--if-exists", "--policy=src-ip",
 				"lr-route-del", gr, podIP+mask, gwIP.String())
 			if err != nil {
-				klog.Errorf("Unable to delete pod %s route to GR %s, GW: %s, stderr:%q, err:%v",
-					pod, gr, gwIP.String(), stderr, err)
+				klog.Errorf("Unable to delete pod IP %s route to GR %s, GW: %s, stderr:%q, err:%v",
+					podIP, gr, gwIP.String(), stderr, err)
 			} else {
-				klog.V(5).Infof("ECMP route deleted for pod: %s, on gr: %s, to gw: %s", pod,
+				klog.V(5).Infof("ECMP route deleted for pod IP: %s, on gr: %s, to gw: %s", podIP,
 					gr, gwIP.String())
+				if nsInfo.bfdEnabled {
+					cleanUpBFDEntry(gr, gwIP.String())
+				}
 				delete(nsInfo.podExternalRoutes[podIP], gwIP.String())
 				// clean up if there are no more routes for this podIP
 				if entry := nsInfo.podExternalRoutes[podIP]; len(entry) == 0 {
@@ -186,7 +250,6 @@ func (oc *Controller) deletePodGWRoutesForNamespace(pod, namespace, node string)
 			}
 		}
 	}
-	delete(nsInfo.routingExternalPodGWs, pod)
 }
 
 // deleteGwRoutesForNamespace handles deleting all routes to gateways for a pod on a specific GR
@@ -209,6 +272,9 @@ func (oc *Controller) deleteGWRoutesForNamespace(nsInfo *namespaceInfo) {
 				klog.Errorf("Unable to delete src-ip route to GR router, stderr:%q, err:%v", stderr, err)
 			} else {
 				delete(nsInfo.podExternalRoutes, podIP)
+				if nsInfo.bfdEnabled {
+					cleanUpBFDEntry(gr, gw)
+				}
 			}
 		}
 	}
@@ -242,6 +308,9 @@ func (oc *Controller) deleteGWRoutesForPod(namespace string, podIPNets []*net.IP
 					klog.Errorf("Unable to delete external gw ecmp route to GR router, stderr:%q, err:%v", stderr, err)
 				} else {
 					delete(nsInfo.podExternalRoutes, pod)
+					if nsInfo.bfdEnabled {
+						cleanUpBFDEntry(gr, gw)
+					}
 				}
 			}
 		}
@@ -266,8 +335,7 @@ func (oc *Controller) addGWRoutesForPod(routingGWs []net.IP, podIfAddrs []*net.I
 			for _, gw := range gws {
 				gwStr := gw.String()
 				mask := GetIPFullMask(podIP)
-				_, stderr, err := util.RunOVNNbctl("--may-exist", "--policy=src-ip", "--ecmp-symmetric-reply",
-					"lr-route-add", gr, podIP+mask, gwStr)
+				_, stderr, err := util.RunOVNNbctl(getExGWRouteAddArgs(gr, podIP+mask, gwStr, nsInfo.bfdEnabled)...)
 				if err != nil {
 					return fmt.Errorf("unable to add external gwStr src-ip route to GR router, stderr:%q, err:%gw", stderr, err)
 				}
@@ -292,6 +360,136 @@ func (oc *Controller) addGWRoutesForPod(routingGWs []net.IP, podIfAddrs []*net.I
 	return nil
 }
 
+// getExGWRouteAddArgs returns the ovn-nbctl arguments adding the ECMP route of a pod IP to an external gateway
+// on the gateway router. When BFD is enabled the route is bound to the external port of the gateway router so that
+// OVN monitors the gateway through a BFD session on that port and withdraws the route while the gateway is down.
+func getExGWRouteAddArgs(gr, prefix, gw string, bfdEnabled bool) []string {
+	args := []string{"--may-exist", "--policy=src-ip", "--ecmp-symmetric-reply"}
+	if bfdEnabled {
+		args = append(args, "--bfd")
+	}
+	args = append(args, "lr-route-add", gr, prefix, gw)
+	if bfdEnabled {
+		args = append(args, types.GWRouterToExtSwitchPrefix+gr)
+	}
+	return args
+}
+
+// cleanUpBFDEntry destroys the BFD session towards an external gateway on the gateway router once no static
+// route uses it anymore
+func cleanUpBFDEntry(gr, gw string) {
+	port := types.GWRouterToExtSwitchPrefix + gr
+	bfdUUID, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "bfd",
+		"logical_port="+port, fmt.Sprintf("dst_ip=\"%s\"", gw))
+	if err != nil {
+		klog.Errorf("Unable to find BFD session to external gw %s on %s, stderr:%q, err:%v", gw, port, stderr, err)
+		return
+	}
+	if bfdUUID == "" {
+		return
+	}
+	routes, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find",
+		"logical_router_static_route", "bfd="+bfdUUID)
+	if err != nil {
+		klog.Errorf("Unable to find routes using BFD session %s, stderr:%q, err:%v", bfdUUID, stderr, err)
+		return
+	}
+	if routes != "" {
+		return
+	}
+	_, stderr, err = util.RunOVNNbctl("--if-exists", "destroy", "bfd", bfdUUID)
+	if err != nil {
+		klog.Errorf("Unable to delete BFD session to external gw %s on %s, stderr:%q, err:%v", gw, port, stderr, err)
+	}
+}
+
+// getExGWBFDSessions returns the BFD sessions monitoring the external gateways of the namespaces with BFD enabled,
+// and the namespaces routed through each of them
+func (oc *Controller) getExGWBFDSessions() map[exGWBFDSession]sets.String {
+	sessions := make(map[exGWBFDSession]sets.String)
+	for _, namespace := range oc.getNamespaceNames() {
+		nsInfo := oc.getNamespaceLocked(namespace)
+		if nsInfo == nil {
+			continue
+		}
+		if nsInfo.bfdEnabled {
+			for _, gwToGr := range nsInfo.podExternalRoutes {
+				for gw, gr := range gwToGr {
+					session := exGWBFDSession{gr: gr, gw: gw}
+					if sessions[session] == nil {
+						sessions[session] = sets.NewString()
+					}
+					sessions[session].Insert(namespace)
+				}
+			}
+		}
+		nsInfo.Unlock()
+	}
+	return sessions
+}
+
+// checkExternalGatewaysBFD reports the state of the BFD sessions monitoring the external gateways as metrics, and
+// its changes as events on the namespaces routed through the gateways
+func (oc *Controller) checkExternalGatewaysBFD() {
+	sessions := oc.getExGWBFDSessions()
+	for session := range oc.exGWBFDStatus {
+		if _, ok := sessions[session]; !ok {
+			delete(oc.exGWBFDStatus, session)
+			metrics.DeleteExternalGatewayBFDStatus(session.gr, session.gw)
+		}
+	}
+	if len(sessions) == 0 {
+		return
+	}
+
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=logical_port,dst_ip,status", "find", "bfd")
+	if err != nil {
+		klog.Errorf("Unable to get the state of the BFD sessions, stderr:%q, err:%v", stderr, err)
+		return
+	}
+	statuses := make(map[exGWBFDSession]string)
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], types.GWRouterToExtSwitchPrefix) {
+			continue
+		}
+		gw := net.ParseIP(fields[1])
+		if gw == nil {
+			continue
+		}
+		session := exGWBFDSession{gr: strings.TrimPrefix(fields[0], types.GWRouterToExtSwitchPrefix), gw: gw.String()}
+		statuses[session] = fields[2]
+	}
+
+	for session, namespaces := range sessions {
+		status := statuses[session]
+		oldStatus := oc.exGWBFDStatus[session]
+		// the status is empty until ovn-controller starts the session
+		if status == "" || status == oldStatus {
+			continue
+		}
+		oc.exGWBFDStatus[session] = status
+		metrics.RecordExternalGatewayBFDStatus(session.gr, session.gw, status == "up")
+		klog.Infof("BFD session from %s to external gw %s is %s", session.gr, session.gw, status)
+		for _, namespace := range namespaces.List() {
+			nsRef := kapi.ObjectReference{
+				Kind: "Namespace",
+				Name: namespace,
+			}
+			switch {
+			case status == "down":
+				oc.recorder.Eventf(&nsRef, kapi.EventTypeWarning, "ExternalGatewayBFDDown",
+					"BFD session from %s to external gateway %s is down, the gateway is not used until it recovers",
+					session.gr, session.gw)
+			case status == "up" && oldStatus != "":
+				oc.recorder.Eventf(&nsRef, kapi.EventTypeNormal, "ExternalGatewayBFDUp",
+					"BFD session from %s to external gateway %s is up", session.gr, session.gw)
+			}
+		}
+	}
+}
+
 // deletePerPodGRSNAT removes per pod SNAT rules that are applied to the GR where the pod resides if
 // there are no gateways
 func (oc *Controller) deletePerPodGRSNAT(node string, podIPNets []*net.IPNet) {
@@ -270,6 +270,133 @@ var _ = Describe("OVN Egress Gateway Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+	Context("on setting namespace BFD annotation", func() {
+
+		It("reconciles a pod with BFD monitored exgws", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				namespaceT := *newNamespace("namespace1")
+				namespaceT.Annotations = map[string]string{
+					"k8s.ovn.org/routing-external-gws": "9.0.0.1",
+					"k8s.ovn.org/bfd-enabled":          "true",
+				}
+				t := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.3",
+					"0a:58:0a:80:01:03",
+					namespaceT.Name,
+				)
+
+				t.baseCmds(fExec)
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespaceT,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+						},
+					},
+				)
+				t.populateLogicalSwitchCache(fakeOvn)
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply --bfd lr-route-add GR_node1 10.128.1.3/32 9.0.0.1 rtoe-GR_node1",
+					Output: "\n",
+				})
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchPods()
+
+				Eventually(func() string { return getPodAnnotations(fakeOvn.fakeClient.KubeClient, t.namespace, t.podName) }, 2).Should(MatchJSON(`{"default": {"ip_addresses":["` + t.podIP + `/24"], "mac_address":"` + t.podMAC + `", "gateway_ips": ["` + t.nodeGWIP + `"], "ip_address":"` + t.podIP + `/24", "gateway_ip": "` + t.nodeGWIP + `"}}`))
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// the BFD session is removed with the last route using it
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.3/32 9.0.0.1",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_static_route bfd=bfd-uuid-1",
+					"ovn-nbctl --timeout=15 --if-exists destroy bfd bfd-uuid-1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find bfd logical_port=rtoe-GR_node1 dst_ip=\"9.0.0.1\"",
+					Output: "bfd-uuid-1\n",
+				})
+				err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Delete(context.TODO(), t.podName, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports the state of the BFD sessions to the exgws as namespace events", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				namespaceT := *newNamespace("namespace1")
+				namespaceT.Annotations = map[string]string{
+					"k8s.ovn.org/routing-external-gws": "9.0.0.1",
+					"k8s.ovn.org/bfd-enabled":          "true",
+				}
+				t := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.3",
+					"0a:58:0a:80:01:03",
+					namespaceT.Name,
+				)
+
+				t.baseCmds(fExec)
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespaceT,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*newPod(t.namespace, t.podName, t.nodeName, t.podIP),
+						},
+					},
+				)
+				t.populateLogicalSwitchCache(fakeOvn)
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply --bfd lr-route-add GR_node1 10.128.1.3/32 9.0.0.1 rtoe-GR_node1",
+					Output: "\n",
+				})
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchPods()
+
+				Eventually(func() string { return getPodAnnotations(fakeOvn.fakeClient.KubeClient, t.namespace, t.podName) }, 2).Should(MatchJSON(`{"default": {"ip_addresses":["` + t.podIP + `/24"], "mac_address":"` + t.podMAC + `", "gateway_ips": ["` + t.nodeGWIP + `"], "ip_address":"` + t.podIP + `/24", "gateway_ip": "` + t.nodeGWIP + `"}}`))
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				for _, status := range []string{"up", "down", "down", "up"} {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=logical_port,dst_ip,status find bfd",
+						Output: "rtoe-GR_node1,9.0.0.1," + status + "\nrtoe-GR_node2,9.0.0.1,down\n",
+					})
+					fakeOvn.controller.checkExternalGatewaysBFD()
+				}
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+				Expect(fakeOvn.fakeRecorder.Events).To(Receive(Equal("Warning ExternalGatewayBFDDown BFD session from GR_node1 to external gateway 9.0.0.1 is down, the gateway is not used until it recovers")))
+				Expect(fakeOvn.fakeRecorder.Events).To(Receive(Equal("Normal ExternalGatewayBFDUp BFD session from GR_node1 to external gateway 9.0.0.1 is up")))
+				Expect(fakeOvn.fakeRecorder.Events).NotTo(Receive())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("on setting pod gateway annotations", func() {
 		It("reconciles a host networked pod acting as a exgw for another namespace for new pod", func() {
 			app.Action = func(ctx *cli.Context) error {
@@ -3,8 +3,10 @@ package ovn
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -36,6 +38,8 @@ const (
 	// In case we restart we need accept executing ovn-nbctl commands with this error.
 	// The ovn-nbctl API does not support `--may-exist` for `lr-policy-add`
 	policyAlreadyExistsMsg = "Same routing policy already existed"
+	// The number of reachability changes kept for each egress node
+	egressNodeReachabilityHistoryLength = 10
 )
 
 func (oc *Controller) addEgressIP(eIP *egressipv1.EgressIP) error {
@@ -153,7 +157,9 @@ func (oc *Controller) syncEgressIPs(eIPs []interface{}) {
 				klog.Errorf("Allocator error: EgressIP allocation: %s is the IP of node: %s ", ip.String(), node.name)
 				break
 			}
-			if utilnet.IsIPv6(ip) && eNode.v6Subnet != nil {
+			if eNode.getSecondaryNetwork(ip) != nil {
+				klog.V(5).Infof("EgressIP allocation: %s is on a secondary host network of node: %s", ip.String(), eIPStatus.Node)
+			} else if utilnet.IsIPv6(ip) && eNode.v6Subnet != nil {
 				if !eNode.v6Subnet.Contains(ip) {
 					klog.Errorf("Allocator error: EgressIP allocation: %s on subnet: %s which cannot host it", ip.String(), eNode.v6Subnet.String())
 					break
@@ -202,6 +208,11 @@ func (oc *Controller) isAnyClusterNodeIP(ip net.IP) *egressNode {
 		if ip.Equal(eNode.v6IP) || ip.Equal(eNode.v4IP) {
 			return eNode
 		}
+		for _, network := range eNode.secondaryNetworks {
+			if ip.Equal(network.IP) {
+				return eNode
+			}
+		}
 	}
 	return nil
 }
@@ -339,7 +350,8 @@ func (oc *Controller) assignEgressIPs(eIP *egressipv1.EgressIP) error {
 				continue
 			}
 			if (assignableNodes[i].v6Subnet != nil && assignableNodes[i].v6Subnet.Contains(eIPC)) ||
-				(assignableNodes[i].v4Subnet != nil && assignableNodes[i].v4Subnet.Contains(eIPC)) {
+				(assignableNodes[i].v4Subnet != nil && assignableNodes[i].v4Subnet.Contains(eIPC)) ||
+				assignableNodes[i].getSecondaryNetwork(eIPC) != nil {
 				assignableNodes[i].tainted, oc.eIPC.allocator[assignableNodes[i].name].allocations[eIPC.String()] = true, true
 				assignments = append(assignments, egressipv1.EgressIPStatusItem{
 					EgressIP: eIPC.String(),
@@ -419,7 +431,7 @@ func (oc *Controller) setNodeEgressReachable(nodeName string, isReachable bool)
 	oc.eIPC.allocatorMutex.Lock()
 	defer oc.eIPC.allocatorMutex.Unlock()
 	if eNode, exists := oc.eIPC.allocator[nodeName]; exists {
-		eNode.isReachable = isReachable
+		eNode.setReachable(isReachable, time.Now())
 	}
 }
 
@@ -476,6 +488,7 @@ func (oc *Controller) deleteEgressNode(egressNode *kapi.Node) error {
 
 func (oc *Controller) reassignEgressIP(eIP *egressipv1.EgressIP) (*egressipv1.EgressIP, error) {
 	klog.V(5).Infof("EgressIP: %s about to be re-assigned", eIP.Name)
+	oldStatusItems := eIP.Status.Items
 	if err := oc.deleteEgressIP(eIP); err != nil {
 		return nil, fmt.Errorf("old egress IP deletion failed, err: %v", err)
 	}
@@ -490,41 +503,114 @@ func (oc *Controller) reassignEgressIP(eIP *egressipv1.EgressIP) (*egressipv1.Eg
 	if err := oc.updateEgressIPWithRetry(eIP); err != nil {
 		return nil, fmt.Errorf("update of new egress IP failed, err: %v", err)
 	}
+	oc.recordEgressIPReassignment(eIP, oldStatusItems)
 	return eIP, reassignError
 }
 
+// recordEgressIPReassignment posts an event on the EgressIP for each of its egress IPs
+// which moved to another node, or which could not be assigned to any node anymore
+func (oc *Controller) recordEgressIPReassignment(eIP *egressipv1.EgressIP, oldStatusItems []egressipv1.EgressIPStatusItem) {
+	eIPRef := kapi.ObjectReference{
+		Kind: "EgressIP",
+		Name: eIP.Name,
+	}
+	newNodes := make(map[string]string, len(eIP.Status.Items))
+	for _, status := range eIP.Status.Items {
+		newNodes[status.EgressIP] = status.Node
+	}
+	for _, status := range oldStatusItems {
+		newNode, assigned := newNodes[status.EgressIP]
+		if !assigned {
+			oc.recorder.Eventf(&eIPRef, kapi.EventTypeWarning, "EgressIPUnassigned", "egress IP: %s for object EgressIP: %s was removed from node: %s and could not be assigned to another node", status.EgressIP, eIP.Name, status.Node)
+		} else if newNode != status.Node {
+			oc.recorder.Eventf(&eIPRef, kapi.EventTypeNormal, "EgressIPReassigned", "egress IP: %s for object EgressIP: %s moved from node: %s to node: %s", status.EgressIP, eIP.Name, status.Node, newNode)
+		}
+	}
+}
+
 func (oc *Controller) initEgressIPAllocator(node *kapi.Node) (err error) {
+	secondaryNetworks, err := getNodeSecondaryNetworks(node)
+	if err != nil {
+		return fmt.Errorf("unable to use the secondary host networks of node: %s for egress assignment, err: %v", node.Name, err)
+	}
+	oc.eIPC.secondaryNetworkCache.Store(node.Name, secondaryNetworks)
+
 	oc.eIPC.allocatorMutex.Lock()
 	defer oc.eIPC.allocatorMutex.Unlock()
-	if _, exists := oc.eIPC.allocator[node.Name]; !exists {
-		var v4IP, v6IP net.IP
-		var v4Subnet, v6Subnet *net.IPNet
-		v4IfAddr, v6IfAddr, err := util.ParseNodePrimaryIfAddr(node)
+	if eNode, exists := oc.eIPC.allocator[node.Name]; exists {
+		eNode.secondaryNetworks = secondaryNetworks.networks
+		return nil
+	}
+	var v4IP, v6IP net.IP
+	var v4Subnet, v6Subnet *net.IPNet
+	v4IfAddr, v6IfAddr, err := util.ParseNodePrimaryIfAddr(node)
+	if err != nil {
+		return fmt.Errorf("unable to use node for egress assignment, err: %v", err)
+	}
+	if v4IfAddr != "" {
+		v4IP, v4Subnet, err = net.ParseCIDR(v4IfAddr)
 		if err != nil {
-			return fmt.Errorf("unable to use node for egress assignment, err: %v", err)
+			return err
 		}
-		if v4IfAddr != "" {
-			v4IP, v4Subnet, err = net.ParseCIDR(v4IfAddr)
-			if err != nil {
-				return err
-			}
+	}
+	if v6IfAddr != "" {
+		v6IP, v6Subnet, err = net.ParseCIDR(v6IfAddr)
+		if err != nil {
+			return err
 		}
-		if v6IfAddr != "" {
-			v6IP, v6Subnet, err = net.ParseCIDR(v6IfAddr)
-			if err != nil {
-				return err
-			}
+	}
+	oc.eIPC.allocator[node.Name] = &egressNode{
+		name:              node.Name,
+		v4IP:              v4IP,
+		v6IP:              v6IP,
+		v4Subnet:          v4Subnet,
+		v6Subnet:          v6Subnet,
+		secondaryNetworks: secondaryNetworks.networks,
+		allocations:       make(map[string]bool),
+	}
+	return nil
+}
+
+// nodeSecondaryNetworks holds the secondary host networks on which a node can host egress IPs
+// and the management port IPs of the node, to which the traffic of those egress IPs is rerouted
+type nodeSecondaryNetworks struct {
+	// The interface addresses of the node on the networks
+	networks []*net.IPNet
+	v4MgmtIP net.IP
+	v6MgmtIP net.IP
+}
+
+func getNodeSecondaryNetworks(node *kapi.Node) (*nodeSecondaryNetworks, error) {
+	secondaryNetworks := &nodeSecondaryNetworks{}
+	egressNetworks, err := util.ParseNodeEgressNetworks(node)
+	if err != nil {
+		return nil, err
+	}
+	for _, egressNetwork := range egressNetworks {
+		ip, network, err := net.ParseCIDR(egressNetwork.Address)
+		if err != nil {
+			return nil, err
 		}
-		oc.eIPC.allocator[node.Name] = &egressNode{
-			name:        node.Name,
-			v4IP:        v4IP,
-			v6IP:        v6IP,
-			v4Subnet:    v4Subnet,
-			v6Subnet:    v6Subnet,
-			allocations: make(map[string]bool),
+		secondaryNetworks.networks = append(secondaryNetworks.networks, &net.IPNet{IP: ip, Mask: network.Mask})
+	}
+	if len(secondaryNetworks.networks) == 0 {
+		return secondaryNetworks, nil
+	}
+	// the node subnets may not be allocated yet, the node is updated once they are
+	hostSubnets, err := util.ParseNodeHostSubnetAnnotation(node)
+	if err != nil {
+		klog.V(5).Infof("Node: %s has secondary host networks but no subnet yet, err: %v", node.Name, err)
+		return secondaryNetworks, nil
+	}
+	for _, hostSubnet := range hostSubnets {
+		mgmtIP := util.GetNodeManagementIfAddr(hostSubnet).IP
+		if utilnet.IsIPv6(mgmtIP) {
+			secondaryNetworks.v6MgmtIP = mgmtIP
+		} else {
+			secondaryNetworks.v4MgmtIP = mgmtIP
 		}
 	}
-	return nil
+	return secondaryNetworks, nil
 }
 
 func (oc *Controller) addNodeForEgress(node *v1.Node) error {
@@ -569,6 +655,77 @@ type egressNode struct {
 	isEgressAssignable bool
 	tainted            bool
 	name               string
+	// The interface addresses of the node on the secondary host networks which can host egress IPs
+	secondaryNetworks []*net.IPNet
+	// The number of consecutive failed reachability probes of the node
+	reachabilityFailures int
+	// The last reachability changes of the node, oldest first
+	reachabilityHistory []egressNodeReachabilityChange
+}
+
+// egressNodeReachabilityChange records when an egress node became reachable or unreachable
+type egressNodeReachabilityChange struct {
+	time      time.Time
+	reachable bool
+}
+
+func (c egressNodeReachabilityChange) String() string {
+	if c.reachable {
+		return fmt.Sprintf("reachable since %s", c.time.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("unreachable since %s", c.time.Format(time.RFC3339))
+}
+
+// getSecondaryNetwork returns the interface address of the node on the secondary host network
+// containing ip, if any
+func (e *egressNode) getSecondaryNetwork(ip net.IP) *net.IPNet {
+	for _, network := range e.secondaryNetworks {
+		if network.Contains(ip) {
+			return network
+		}
+	}
+	return nil
+}
+
+// setReachable sets whether the node is reachable and records the change in its history
+func (e *egressNode) setReachable(isReachable bool, now time.Time) {
+	if isReachable {
+		e.reachabilityFailures = 0
+	}
+	if e.isReachable == isReachable {
+		return
+	}
+	e.isReachable = isReachable
+	e.reachabilityHistory = append(e.reachabilityHistory, egressNodeReachabilityChange{time: now, reachable: isReachable})
+	if len(e.reachabilityHistory) > egressNodeReachabilityHistoryLength {
+		e.reachabilityHistory = e.reachabilityHistory[len(e.reachabilityHistory)-egressNodeReachabilityHistoryLength:]
+	}
+}
+
+// recordProbe records the result of a reachability probe of the node and returns whether
+// the node reachability changed. A reachable node becomes unreachable once its probes
+// failed EgressIPReachabilityFailureThreshold times in a row, while an unreachable node
+// becomes reachable again with the first successful probe.
+func (e *egressNode) recordProbe(success bool, now time.Time) bool {
+	wasReachable := e.isReachable
+	if success {
+		e.setReachable(true, now)
+	} else {
+		e.reachabilityFailures++
+		if e.reachabilityFailures >= config.OVNKubernetesFeature.EgressIPReachabilityFailureThreshold {
+			e.setReachable(false, now)
+		}
+	}
+	return e.isReachable != wasReachable
+}
+
+// getReachabilityHistory returns a description of the last reachability changes of the node
+func (e *egressNode) getReachabilityHistory() string {
+	changes := make([]string, 0, len(e.reachabilityHistory))
+	for _, change := range e.reachabilityHistory {
+		changes = append(changes, change.String())
+	}
+	return strings.Join(changes, ", ")
 }
 
 type egressIPController struct {
@@ -578,6 +735,9 @@ type egressIPController struct {
 	// Cache of gateway join router IPs, usefull since these should not change often
 	gatewayIPCache sync.Map
 
+	// Cache of the secondary host networks of the nodes, see nodeSecondaryNetworks
+	secondaryNetworkCache sync.Map
+
 	// Cache used for retrying EgressIP objects which were created before any node existed.
 	assignmentRetry sync.Map
 
@@ -613,10 +773,14 @@ func (e *egressIPController) addPodEgressIP(eIP *egressipv1.EgressIP, pod *kapi.
 	if e.needsRetry(pod) {
 		e.podRetry.Delete(getPodKey(pod))
 	}
-	for _, status := range eIP.Status.Items {
+	for _, status := range util.SelectPodEgressIPStatuses(pod, eIP.Status.Items) {
 		if err := e.createEgressReroutePolicy(podIPs, status, eIP.Name); err != nil {
 			return fmt.Errorf("unable to create logical router policy for status: %v, err: %v", status, err)
 		}
+		// the egress node SNATs the traffic of egress IPs on its secondary host networks itself
+		if e.getSecondaryNetworkMgmtIP(status) != nil {
+			continue
+		}
 		if err := createNATRule(podIPs, status, eIP.Name); err != nil {
 			return fmt.Errorf("unable to create NAT rule for status: %v, err: %v", status, err)
 		}
@@ -636,6 +800,9 @@ func (e *egressIPController) deletePodEgressIP(eIP *egressipv1.EgressIP, pod *ka
 		if err := e.deleteEgressReroutePolicy(podIPs, status, eIP.Name); err != nil {
 			return fmt.Errorf("unable to delete logical router policy for status: %v, err: %v", status, err)
 		}
+		if e.getSecondaryNetworkMgmtIP(status) != nil {
+			continue
+		}
 		if err := deleteNATRule(podIPs, status, eIP.Name); err != nil {
 			return fmt.Errorf("unable to delete NAT rule for status: %v, err: %v", status, err)
 		}
@@ -688,14 +855,47 @@ func (e *egressIPController) needsRetry(pod *kapi.Pod) bool {
 	return retry
 }
 
+// getSecondaryNetworkMgmtIP returns the management port IP of the egress node when the egress IP
+// is on one of its secondary host networks: its traffic is then rerouted to the management port
+// and leaves the node through the interface attached to the network instead of the gateway router
+func (e *egressIPController) getSecondaryNetworkMgmtIP(status egressipv1.EgressIPStatusItem) net.IP {
+	item, exists := e.secondaryNetworkCache.Load(status.Node)
+	if !exists {
+		return nil
+	}
+	secondaryNetworks := item.(*nodeSecondaryNetworks)
+	ip := net.ParseIP(status.EgressIP)
+	for _, network := range secondaryNetworks.networks {
+		if network.Contains(ip) {
+			if utilnet.IsIPv6(ip) {
+				return secondaryNetworks.v6MgmtIP
+			}
+			return secondaryNetworks.v4MgmtIP
+		}
+	}
+	return nil
+}
+
+// getEgressNextHop returns the IP the egress traffic of an egress IP assignment is rerouted to
+func (e *egressIPController) getEgressNextHop(status egressipv1.EgressIPStatusItem) (net.IP, error) {
+	if mgmtIP := e.getSecondaryNetworkMgmtIP(status); mgmtIP != nil {
+		return mgmtIP, nil
+	}
+	gatewayRouterIP, err := e.getGatewayRouterJoinIP(status.Node, utilnet.IsIPv6String(status.EgressIP))
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve gateway IP for node: %s, err: %v", status.Node, err)
+	}
+	return gatewayRouterIP, nil
+}
+
 // createEgressReroutePolicy uses logical router policies to force egress traffic to the egress node, for that we need
-// to retrive the internal gateway router IP attached to the egress node. This method handles both the shared and
-// local gateway mode case
+// to retrive the internal gateway router IP attached to the egress node, or its management port IP for egress IPs on
+// its secondary host networks. This method handles both the shared and local gateway mode case
 func (e *egressIPController) createEgressReroutePolicy(podIps []net.IP, status egressipv1.EgressIPStatusItem, egressIPName string) error {
 	isEgressIPv6 := utilnet.IsIPv6String(status.EgressIP)
-	gatewayRouterIP, err := e.getGatewayRouterJoinIP(status.Node, isEgressIPv6)
+	gatewayRouterIP, err := e.getEgressNextHop(status)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve gateway IP for node: %s, err: %v", status.Node, err)
+		return err
 	}
 	for _, podIP := range podIps {
 		var err error
@@ -704,6 +904,8 @@ func (e *egressIPController) createEgressReroutePolicy(podIps []net.IP, status e
 			filterOption = fmt.Sprintf("ip6.src == %s", podIP.String())
 		} else if !isEgressIPv6 && !utilnet.IsIPv6(podIP) {
 			filterOption = fmt.Sprintf("ip4.src == %s", podIP.String())
+		} else {
+			continue
 		}
 		policyIDs, err := findReroutePolicyIDs(filterOption, egressIPName, gatewayRouterIP)
 		if err != nil {
@@ -735,10 +937,9 @@ func (e *egressIPController) createEgressReroutePolicy(podIps []net.IP, status e
 }
 
 func (e *egressIPController) deleteEgressReroutePolicy(podIps []net.IP, status egressipv1.EgressIPStatusItem, egressIPName string) error {
-	isEgressIPv6 := utilnet.IsIPv6String(status.EgressIP)
-	gatewayRouterIP, err := e.getGatewayRouterJoinIP(status.Node, isEgressIPv6)
+	gatewayRouterIP, err := e.getEgressNextHop(status)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve gateway IP for node: %s, err: %v", status.Node, err)
+		return err
 	}
 	for _, podIP := range podIps {
 		var filterOption string
@@ -746,6 +947,8 @@ func (e *egressIPController) deleteEgressReroutePolicy(podIps []net.IP, status e
 			filterOption = fmt.Sprintf("ip6.src == %s", podIP.String())
 		} else if !utilnet.IsIPv6(podIP) && !utilnet.IsIPv6String(status.EgressIP) {
 			filterOption = fmt.Sprintf("ip4.src == %s", podIP.String())
+		} else {
+			continue
 		}
 		policyIDs, err := findReroutePolicyIDs(filterOption, egressIPName, gatewayRouterIP)
 		if err != nil {
@@ -789,41 +992,72 @@ func findReroutePolicyIDs(filterOption, egressIPName string, gatewayRouterIP net
 	return strings.Split(policyIDs, "\n"), nil
 }
 
+// checkEgressNodesReachability periodically checks whether the egress nodes are reachable
+// until the controller stops
 func (oc *Controller) checkEgressNodesReachability() {
-	for {
-		reAddOrDelete := map[string]bool{}
-		oc.eIPC.allocatorMutex.Lock()
-		for _, eNode := range oc.eIPC.allocator {
-			if eNode.isEgressAssignable && eNode.isReady {
-				wasReachable := eNode.isReachable
-				isReachable := oc.isReachable(eNode)
-				if wasReachable && !isReachable {
-					reAddOrDelete[eNode.name] = true
-				} else if !wasReachable && isReachable {
-					reAddOrDelete[eNode.name] = false
-				}
-				eNode.isReachable = isReachable
-			}
+	interval := time.Duration(config.OVNKubernetesFeature.EgressIPReachabilityInterval) * time.Second
+	utilwait.Until(oc.updateEgressNodesReachability, interval, oc.stopChan)
+}
+
+// updateEgressNodesReachability probes the egress nodes, re-assigns the egress IPs of the
+// egress nodes which became unreachable to the other egress nodes, which also re-balances
+// the pods matched by those egress IPs across the egress nodes left, and retries the
+// assignment of the egress IPs which could not be fully assigned when a node becomes
+// reachable again
+func (oc *Controller) updateEgressNodesReachability() {
+	oc.eIPC.allocatorMutex.Lock()
+	eNodes := []*egressNode{}
+	for _, eNode := range oc.eIPC.allocator {
+		if eNode.isEgressAssignable && eNode.isReady {
+			eNodes = append(eNodes, eNode)
 		}
-		oc.eIPC.allocatorMutex.Unlock()
-		for nodeName, shouldDelete := range reAddOrDelete {
-			node, err := oc.kube.GetNode(nodeName)
-			if err != nil {
-				klog.Errorf("Node: %s reachability changed, but could not retrieve node from API server, err: %v", node.Name, err)
+	}
+	oc.eIPC.allocatorMutex.Unlock()
+
+	// probe the nodes concurrently so that a node timing out does not delay the others
+	probes := make([]bool, len(eNodes))
+	wg := &sync.WaitGroup{}
+	for i, eNode := range eNodes {
+		wg.Add(1)
+		go func(i int, eNode *egressNode) {
+			defer wg.Done()
+			probes[i] = oc.isReachable(eNode)
+		}(i, eNode)
+	}
+	wg.Wait()
+
+	reAddOrDelete := map[string]bool{}
+	histories := map[string]string{}
+	now := time.Now()
+	oc.eIPC.allocatorMutex.Lock()
+	for i, eNode := range eNodes {
+		// skip the nodes deleted while they were probed
+		if oc.eIPC.allocator[eNode.name] != eNode {
+			continue
+		}
+		if eNode.recordProbe(probes[i], now) {
+			reAddOrDelete[eNode.name] = !eNode.isReachable
+			histories[eNode.name] = eNode.getReachabilityHistory()
+		}
+	}
+	oc.eIPC.allocatorMutex.Unlock()
+	for nodeName, shouldDelete := range reAddOrDelete {
+		node, err := oc.kube.GetNode(nodeName)
+		if err != nil {
+			klog.Errorf("Node: %s reachability changed, but could not retrieve node from API server, err: %v", nodeName, err)
+			continue
+		}
+		if shouldDelete {
+			klog.Warningf("Node: %s is detected as unreachable, deleting it from egress assignment, reachability history: %s", node.Name, histories[nodeName])
+			if err := oc.deleteEgressNode(node); err != nil {
+				klog.Errorf("Node: %s is detected as unreachable, but could not re-assign egress IPs, err: %v", node.Name, err)
 			}
-			if shouldDelete {
-				klog.Warningf("Node: %s is detected as unreachable, deleting it from egress assignment", node.Name)
-				if err := oc.deleteEgressNode(node); err != nil {
-					klog.Errorf("Node: %s is detected as unreachable, but could not re-assign egress IPs, err: %v", node.Name, err)
-				}
-			} else {
-				klog.Infof("Node: %s is detected as reachable and ready again, adding it to egress assignment", node.Name)
-				if err := oc.addEgressNode(node); err != nil {
-					klog.Errorf("Node: %s is detected as reachable and ready again, but could not re-assign egress IPs, err: %v", node.Name, err)
-				}
+		} else {
+			klog.Infof("Node: %s is detected as reachable and ready again, adding it to egress assignment, reachability history: %s", node.Name, histories[nodeName])
+			if err := oc.addEgressNode(node); err != nil {
+				klog.Errorf("Node: %s is detected as reachable and ready again, but could not re-assign egress IPs, err: %v", node.Name, err)
 			}
 		}
-		time.Sleep(5 * time.Second)
 	}
 }
 
@@ -851,6 +1085,9 @@ type egressIPDial struct{}
 // refused" error; but the code below assumes that anything other than timeout or "no
 // route" indicates that the node is online.
 func (e *egressIPDial) dial(ip net.IP) bool {
+	if port := config.OVNKubernetesFeature.EgressIPNodeHealthCheckPort; port != 0 {
+		return probeEgressIPHealthCheck(ip, port)
+	}
 	timeout := time.Second
 	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip.String(), "9"), timeout)
 	if conn != nil {
@@ -867,6 +1104,21 @@ func (e *egressIPDial) dial(ip net.IP) bool {
 	return true
 }
 
+var egressIPHealthCheckClient = &http.Client{Timeout: time.Second}
+
+// probeEgressIPHealthCheck returns whether the health check endpoint served by ovnkube-node
+// on the node with the given IP reports it as healthy
+func probeEgressIPHealthCheck(ip net.IP, port int) bool {
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(ip.String(), strconv.Itoa(port)), types.EgressIPHealthCheckPath)
+	resp, err := egressIPHealthCheckClient.Get(url)
+	if err != nil {
+		klog.V(5).Infof("Egress IP health check of %s failed, err: %v", url, err)
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
 func getClusterSubnets() (*net.IPNet, *net.IPNet) {
 	var v4ClusterSubnet, v6ClusterSubnet *net.IPNet
 	for _, clusterSubnet := range config.Default.ClusterSubnets {
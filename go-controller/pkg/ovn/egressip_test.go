@@ -4,6 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,17 +16,33 @@ import (
 	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/urfave/cli/v2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	utilnet "k8s.io/utils/net"
 )
 
 type fakeEgressIPDialer struct{}
 
+var (
+	// fakeUnreachableEgressIPs holds the node IPs which fakeEgressIPDialer reports as unreachable
+	fakeUnreachableEgressIPs      = sets.NewString()
+	fakeUnreachableEgressIPsMutex sync.Mutex
+)
+
 func (f fakeEgressIPDialer) dial(ip net.IP) bool {
-	return true
+	fakeUnreachableEgressIPsMutex.Lock()
+	defer fakeUnreachableEgressIPsMutex.Unlock()
+	return !fakeUnreachableEgressIPs.Has(ip.String())
+}
+
+func setFakeUnreachableEgressIPs(ips ...string) {
+	fakeUnreachableEgressIPsMutex.Lock()
+	defer fakeUnreachableEgressIPsMutex.Unlock()
+	fakeUnreachableEgressIPs = sets.NewString(ips...)
 }
 
 var (
@@ -1916,6 +1937,269 @@ var _ = Describe("OVN master EgressIP Operations", func() {
 
 	})
 
+	Context("Egress node reachability", func() {
+
+		It("should re-balance the pods of an EgressIP across the egress nodes left when a node becomes unreachable", func() {
+			app.Action = func(ctx *cli.Context) error {
+				defer setFakeUnreachableEgressIPs()
+				fakeOvn = NewFakeOVN(ovntest.NewLooseCompareFakeExec())
+
+				egressIP1 := "192.168.126.101"
+				egressIP2 := "192.168.126.102"
+				nodeIPs := map[string]string{
+					node1Name: "192.168.126.202",
+					node2Name: "192.168.126.51",
+				}
+				nodeLogicalRouterIPs := map[string]string{
+					node1Name: nodeLogicalRouterIPv4,
+					node2Name: "100.64.0.3",
+				}
+
+				egressPod := *newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+				egressNamespace := newNamespace(namespace)
+				newEgressNode := func(name string) v1.Node {
+					return v1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: name,
+							Annotations: map[string]string{
+								"k8s.ovn.org/node-primary-ifaddr": fmt.Sprintf("{\"ipv4\": \"%s/24\", \"ipv6\": \"%s\"}", nodeIPs[name], ""),
+							},
+							Labels: map[string]string{
+								"k8s.ovn.org/egress-assignable": "",
+							},
+						},
+						Status: v1.NodeStatus{
+							Conditions: []v1.NodeCondition{
+								{
+									Type:   v1.NodeReady,
+									Status: v1.ConditionTrue,
+								},
+							},
+						},
+					}
+				}
+
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP1, egressIP2},
+						PodSelector: metav1.LabelSelector{
+							MatchLabels: egressPodLabel,
+						},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{},
+					},
+				}
+
+				fakeOvn.start(ctx,
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{newEgressNode(node1Name), newEgressNode(node2Name)},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					})
+
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError(
+					[]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 lr-policy-add ovn_cluster_router 101 ip4.src == 10.128.0.0/14 && ip4.dst == 10.128.0.0/14 allow"),
+					},
+				)
+
+				fakeOvn.controller.WatchEgressNodes()
+				Eventually(getEgressIPAllocatorSizeSafely).Should(Equal(2))
+				fakeOvn.controller.WatchEgressIP()
+				Eventually(getEgressIPStatusLen(egressIPName)).Should(Equal(2))
+
+				findPolicyCmd := func(nodeName string) string {
+					return fmt.Sprintf("ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid find logical_router_policy match=\"ip4.src == %s\" priority=%s external_ids:name=%s nexthop=%s", podV4IP, types.EgressIPReroutePriority, eIP.Name, nodeLogicalRouterIPs[nodeName])
+				}
+				findNATCmd := func(egressIP string) string {
+					return fmt.Sprintf("ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid find nat external_ids:name=%s logical_ip=%s external_ip=%s", eIP.Name, podV4IP, egressIP)
+				}
+				addPodCmds := func(status egressipv1.EgressIPStatusItem) []string {
+					return []string{
+						findPolicyCmd(status.Node),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --id=@lr-policy create logical_router_policy action=reroute match=\"ip4.src == %s\" priority=%s nexthop=%s external_ids:name=%s -- add logical_router %s policies @lr-policy", podV4IP, types.EgressIPReroutePriority, nodeLogicalRouterIPs[status.Node], eIP.Name, types.OVNClusterRouter),
+						findNATCmd(status.EgressIP),
+						fmt.Sprintf("ovn-nbctl --timeout=15 --id=@nat create nat type=snat logical_port=k8s-%s external_ip=%s logical_ip=%s external_ids:name=%s -- add logical_router GR_%s nat @nat", status.Node, status.EgressIP, podV4IP, eIP.Name, status.Node),
+					}
+				}
+				getRouterIPCmd := func(nodeName string) *ovntest.ExpectedCmd {
+					return &ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --if-exist get logical_router_port rtoj-GR_%s networks", nodeName),
+						Output: nodeLogicalRouterIPs[nodeName] + "/29",
+					}
+				}
+
+				// the pod only egresses through the node selected for it
+				statuses := getEgressIPStatus(egressIPName)
+				selected := util.SelectPodEgressIPStatuses(&egressPod, statuses)
+				Expect(selected).To(HaveLen(1))
+				var other egressipv1.EgressIPStatusItem
+				for _, status := range statuses {
+					if status.Node != selected[0].Node {
+						other = status
+					}
+				}
+				fakeOvn.fakeExec.AddFakeCmd(getRouterIPCmd(selected[0].Node))
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError(addPodCmds(selected[0]))
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(egressPod.Namespace).Create(context.TODO(), &egressPod, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fakeOvn.fakeExec.CalledMatchesExpected).Should(BeTrue(), fakeOvn.fakeExec.ErrorDesc)
+
+				// once its node is unreachable the pod is moved to the other node, which
+				// now hosts the first egress IP as it cannot host both
+				fakeOvn.fakeExec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: findPolicyCmd(selected[0].Node), Output: reroutePolicyID})
+				fakeOvn.fakeExec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: findNATCmd(selected[0].EgressIP), Output: natID})
+				fakeOvn.fakeExec.AddFakeCmd(getRouterIPCmd(other.Node))
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 remove logical_router %s policies %s", types.OVNClusterRouter, reroutePolicyID),
+					fmt.Sprintf("ovn-nbctl --timeout=15 remove logical_router GR_%s nat %s", selected[0].Node, natID),
+					findPolicyCmd(other.Node),
+					findNATCmd(other.EgressIP),
+				})
+				rebalanced := egressipv1.EgressIPStatusItem{Node: other.Node, EgressIP: egressIP1}
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError(addPodCmds(rebalanced))
+
+				setFakeUnreachableEgressIPs(nodeIPs[selected[0].Node])
+				for i := 0; i < config.OVNKubernetesFeature.EgressIPReachabilityFailureThreshold; i++ {
+					fakeOvn.controller.updateEgressNodesReachability()
+				}
+				Eventually(func() []egressipv1.EgressIPStatusItem { return getEgressIPStatus(egressIPName) }).Should(Equal([]egressipv1.EgressIPStatusItem{rebalanced}))
+				Eventually(fakeOvn.fakeExec.CalledMatchesExpected).Should(BeTrue(), fakeOvn.fakeExec.ErrorDesc)
+
+				// the moves of the egress IPs are reported on the EgressIP
+				expectedEvents := []string{}
+				for _, status := range statuses {
+					if status.EgressIP == egressIP2 {
+						expectedEvents = append(expectedEvents, fmt.Sprintf("Warning EgressIPUnassigned egress IP: %s for object EgressIP: %s was removed from node: %s and could not be assigned to another node", egressIP2, egressIPName, status.Node))
+					} else if status.Node != rebalanced.Node {
+						expectedEvents = append(expectedEvents, fmt.Sprintf("Normal EgressIPReassigned egress IP: %s for object EgressIP: %s moved from node: %s to node: %s", egressIP1, egressIPName, status.Node, rebalanced.Node))
+					}
+				}
+				recordedEvents := []string{}
+				getRecordedEvents := func() []string {
+					select {
+					case event := <-fakeOvn.fakeRecorder.Events:
+						recordedEvents = append(recordedEvents, event)
+					default:
+					}
+					return recordedEvents
+				}
+				for _, expectedEvent := range expectedEvents {
+					Eventually(getRecordedEvents).Should(ContainElement(expectedEvent))
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("Secondary host networks", func() {
+
+		It("should assign egress IPs on the secondary host networks of the nodes and reroute the pods to their management port", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				egressIP := "172.16.10.100"
+				nodeMgmtIP := "10.128.0.2"
+
+				egressPod := *newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+				egressNamespace := newNamespace(namespace)
+				node := v1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: node1Name,
+						Annotations: map[string]string{
+							"k8s.ovn.org/node-primary-ifaddr":  "{\"ipv4\": \"192.168.126.202/24\"}",
+							"k8s.ovn.org/node-egress-networks": "[{\"interface\": \"eth1\", \"address\": \"172.16.10.5/24\"}]",
+							"k8s.ovn.org/node-subnets":         "{\"default\": \"10.128.0.0/24\"}",
+						},
+						Labels: map[string]string{
+							"k8s.ovn.org/egress-assignable": "",
+						},
+					},
+					Status: v1.NodeStatus{
+						Conditions: []v1.NodeCondition{
+							{
+								Type:   v1.NodeReady,
+								Status: v1.ConditionTrue,
+							},
+						},
+					},
+				}
+
+				eIP := egressipv1.EgressIP{
+					ObjectMeta: newEgressIPMeta(egressIPName),
+					Spec: egressipv1.EgressIPSpec{
+						EgressIPs: []string{egressIP},
+						PodSelector: metav1.LabelSelector{
+							MatchLabels: egressPodLabel,
+						},
+						NamespaceSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"name": egressNamespace.Name,
+							},
+						},
+					},
+					Status: egressipv1.EgressIPStatus{
+						Items: []egressipv1.EgressIPStatusItem{},
+					},
+				}
+
+				fakeOvn.start(ctx,
+					&egressipv1.EgressIPList{
+						Items: []egressipv1.EgressIP{eIP},
+					},
+					&v1.NodeList{
+						Items: []v1.Node{node},
+					},
+					&v1.NamespaceList{
+						Items: []v1.Namespace{*egressNamespace},
+					})
+
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError(
+					[]string{
+						fmt.Sprintf("ovn-nbctl --timeout=15 lr-policy-add ovn_cluster_router 101 ip4.src == 10.128.0.0/14 && ip4.dst == 10.128.0.0/14 allow"),
+					},
+				)
+
+				fakeOvn.controller.WatchEgressNodes()
+				Eventually(getEgressIPAllocatorSizeSafely).Should(Equal(1))
+				fakeOvn.controller.WatchEgressIP()
+				Eventually(func() []egressipv1.EgressIPStatusItem { return getEgressIPStatus(egressIPName) }).Should(Equal([]egressipv1.EgressIPStatusItem{
+					{Node: node1Name, EgressIP: egressIP},
+				}))
+
+				// the node SNATs the traffic itself, no NAT is created on its gateway router
+				fakeOvn.fakeExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid find logical_router_policy match=\"ip4.src == %s\" priority=%s external_ids:name=%s nexthop=%s", podV4IP, types.EgressIPReroutePriority, eIP.Name, nodeMgmtIP),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@lr-policy create logical_router_policy action=reroute match=\"ip4.src == %s\" priority=%s nexthop=%s external_ids:name=%s -- add logical_router %s policies @lr-policy", podV4IP, types.EgressIPReroutePriority, nodeMgmtIP, eIP.Name, types.OVNClusterRouter),
+				})
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(egressPod.Namespace).Create(context.TODO(), &egressPod, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fakeOvn.fakeExec.CalledMatchesExpected).Should(BeTrue(), fakeOvn.fakeExec.ErrorDesc)
+
+				// the IP of the node on the secondary host network cannot be an egress IP
+				Expect(fakeOvn.controller.isAnyClusterNodeIP(net.ParseIP("172.16.10.5"))).NotTo(BeNil())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("Dual-stack assignment", func() {
 
 		It("should be able to allocate non-conflicting IPv4 on node which can host it, even if it happens to be the node with more assignments", func() {
@@ -2920,3 +3204,112 @@ var _ = Describe("OVN master EgressIP Operations", func() {
 		})
 	})
 })
+
+var _ = Describe("OVN EgressIP pod egress node selection", func() {
+
+	It("should select one egress node per IP family for each pod", func() {
+		statusItems := []egressipv1.EgressIPStatusItem{
+			{Node: node1Name, EgressIP: "192.168.126.101"},
+			{Node: node2Name, EgressIP: "192.168.126.102"},
+			{Node: node1Name, EgressIP: "0:0:0:0:0:feff:c0a8:8e0d"},
+			{Node: node2Name, EgressIP: "0:0:0:0:0:feff:c0a8:8e0f"},
+		}
+		pod := newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+		selected := util.SelectPodEgressIPStatuses(pod, statusItems)
+		Expect(selected).To(HaveLen(2))
+		Expect(utilnet.IsIPv6String(selected[0].EgressIP)).To(BeFalse())
+		Expect(utilnet.IsIPv6String(selected[1].EgressIP)).To(BeTrue())
+
+		// the selection does not depend on the order of the assignments
+		reversed := []egressipv1.EgressIPStatusItem{statusItems[3], statusItems[2], statusItems[1], statusItems[0]}
+		Expect(util.SelectPodEgressIPStatuses(pod, reversed)).To(Equal(selected))
+		Expect(util.SelectPodEgressIPStatuses(pod, nil)).To(BeEmpty())
+	})
+
+	It("should spread the pods across the egress nodes and only move the pods of a removed node", func() {
+		statusItems := []egressipv1.EgressIPStatusItem{
+			{Node: node1Name, EgressIP: "192.168.126.101"},
+			{Node: node2Name, EgressIP: "192.168.126.102"},
+			{Node: "node3", EgressIP: "192.168.126.103"},
+		}
+		selectedNodes := map[string]string{}
+		podsPerNode := map[string]int{}
+		for i := 0; i < 300; i++ {
+			pod := newPodWithLabels(namespace, fmt.Sprintf("%s-%d", podName, i), node1Name, podV4IP, egressPodLabel)
+			selected := util.SelectPodEgressIPStatuses(pod, statusItems)
+			Expect(selected).To(HaveLen(1))
+			selectedNodes[pod.Name] = selected[0].Node
+			podsPerNode[selected[0].Node]++
+		}
+		for _, item := range statusItems {
+			Expect(podsPerNode[item.Node]).To(BeNumerically(">", 50))
+		}
+
+		remaining := statusItems[:2]
+		for podName, nodeName := range selectedNodes {
+			pod := newPodWithLabels(namespace, podName, node1Name, podV4IP, egressPodLabel)
+			selected := util.SelectPodEgressIPStatuses(pod, remaining)
+			if nodeName != "node3" {
+				Expect(selected[0].Node).To(Equal(nodeName))
+			}
+		}
+	})
+})
+
+var _ = Describe("OVN EgressIP egress node reachability", func() {
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+	})
+
+	It("should consider a node unreachable only after consecutive failed probes", func() {
+		config.OVNKubernetesFeature.EgressIPReachabilityFailureThreshold = 2
+		eNode := &egressNode{name: node1Name, isReachable: true}
+		now := time.Now()
+
+		Expect(eNode.recordProbe(false, now)).To(BeFalse())
+		Expect(eNode.recordProbe(true, now)).To(BeFalse())
+		Expect(eNode.recordProbe(false, now)).To(BeFalse())
+		Expect(eNode.isReachable).To(BeTrue())
+		Expect(eNode.recordProbe(false, now)).To(BeTrue())
+		Expect(eNode.isReachable).To(BeFalse())
+		Expect(eNode.recordProbe(false, now)).To(BeFalse())
+
+		// a single successful probe makes the node reachable again
+		later := now.Add(time.Minute)
+		Expect(eNode.recordProbe(true, later)).To(BeTrue())
+		Expect(eNode.isReachable).To(BeTrue())
+		Expect(eNode.reachabilityHistory).To(Equal([]egressNodeReachabilityChange{
+			{time: now, reachable: false},
+			{time: later, reachable: true},
+		}))
+
+		for i := 0; i < egressNodeReachabilityHistoryLength; i++ {
+			eNode.setReachable(i%2 == 1, later.Add(time.Duration(i)*time.Second))
+		}
+		Expect(eNode.reachabilityHistory).To(HaveLen(egressNodeReachabilityHistoryLength))
+		// only the last changes are kept
+		Expect(eNode.reachabilityHistory[0]).To(Equal(egressNodeReachabilityChange{time: later, reachable: false}))
+	})
+
+	It("should probe the health check endpoint of the nodes when its port is configured", func() {
+		healthy := int32(1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != types.EgressIPHealthCheckPath || atomic.LoadInt32(&healthy) == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		serverAddr := server.Listener.Addr().(*net.TCPAddr)
+		config.OVNKubernetesFeature.EgressIPNodeHealthCheckPort = serverAddr.Port
+		healthCheckDialer := &egressIPDial{}
+
+		Expect(healthCheckDialer.dial(serverAddr.IP)).To(BeTrue())
+		atomic.StoreInt32(&healthy, 0)
+		Expect(healthCheckDialer.dial(serverAddr.IP)).To(BeFalse())
+		server.Close()
+		Expect(healthCheckDialer.dial(serverAddr.IP)).To(BeFalse())
+	})
+})
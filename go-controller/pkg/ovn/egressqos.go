@@ -0,0 +1,524 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressqosapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// egressQoSName is the name of the only EgressQoS of a namespace that is applied
+	egressQoSName = "default"
+	// egressQoSExternalID tags the QoS rows with the namespace of their EgressQoS
+	egressQoSExternalID = "EgressQoS"
+	// egressQoSMaxPriority is the priority of the QoS row of the first rule of an
+	// EgressQoS, the rows of the following rules have decreasing priorities
+	egressQoSMaxPriority = 1000
+	// egressQoSAddressSetInfix is in the names of the address sets of the rules of the EgressQoSes
+	egressQoSAddressSetInfix = "_egressQoS_"
+
+	egressQoSAppliedCorrectly = "EgressQoS Rules applied"
+	egressQoSAddError         = "EgressQoS Rules not correctly added"
+)
+
+// egressQoS is the state of the EgressQoS of a namespace programmed in OVN
+type egressQoS struct {
+	sync.Mutex
+	namespace string
+	rules     []*egressQoSRule
+}
+
+type egressQoSRule struct {
+	priority    int
+	dscp        *int32
+	bandwidth   *egressqosapi.EgressQoSBandwidth
+	dstCIDR     *net.IPNet
+	podSelector labels.Selector
+	// addressSet holds the IPs of the pods of the namespace selected by podSelector
+	addressSet AddressSet
+	// podHandler tracks the pods selected by podSelector
+	podHandler *factory.Handler
+	// uuid is the UUID of the QoS row of the rule, it is empty until the
+	// logical switch of a node exists to reference the row
+	uuid string
+}
+
+func newEgressQoSRule(rawRule egressqosapi.EgressQoSRule, priority int) (*egressQoSRule, error) {
+	if rawRule.DSCP == nil && rawRule.Bandwidth == nil {
+		return nil, fmt.Errorf("neither dscp nor bandwidth is set")
+	}
+	if rawRule.DSCP != nil && (*rawRule.DSCP < 0 || *rawRule.DSCP > 63) {
+		return nil, fmt.Errorf("invalid dscp %d, it must be between 0 and 63", *rawRule.DSCP)
+	}
+	if rawRule.Bandwidth != nil && rawRule.Bandwidth.Rate < 1 {
+		return nil, fmt.Errorf("invalid bandwidth rate %d", rawRule.Bandwidth.Rate)
+	}
+	rule := &egressQoSRule{
+		priority:  priority,
+		dscp:      rawRule.DSCP,
+		bandwidth: rawRule.Bandwidth,
+	}
+	if rawRule.DstCIDR != nil {
+		_, dstCIDR, err := net.ParseCIDR(*rawRule.DstCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dstCIDR %q: %v", *rawRule.DstCIDR, err)
+		}
+		if utilnet.IsIPv6CIDR(dstCIDR) && !config.IPv6Mode || !utilnet.IsIPv6CIDR(dstCIDR) && !config.IPv4Mode {
+			return nil, fmt.Errorf("dstCIDR %s does not match the IP family of the cluster", dstCIDR)
+		}
+		rule.dstCIDR = dstCIDR
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(&rawRule.PodSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid podSelector: %v", err)
+	}
+	rule.podSelector = podSelector
+	return rule, nil
+}
+
+// getEgressQoSAddressSetName returns the name of the address set holding the pods
+// selected by a rule of the EgressQoS of a namespace. Namespace names cannot contain
+// '_', so the name never matches a namespace.
+func getEgressQoSAddressSetName(namespace string, ruleIndex int) string {
+	return fmt.Sprintf("%s%s%d", namespace, egressQoSAddressSetInfix, ruleIndex)
+}
+
+// isEgressQoSAddressSetName returns true if the address set is the one of a rule of
+// an EgressQoS, such sets are synced with the EgressQoSes
+func isEgressQoSAddressSetName(name string) bool {
+	return strings.Contains(name, egressQoSAddressSetInfix)
+}
+
+// getMatch returns the match of the QoS row of the rule: the traffic sent by the
+// selected pods, to dstCIDR when it is set
+func (rule *egressQoSRule) getMatch() string {
+	hashedNameIPv4, hashedNameIPv6 := rule.addressSet.GetIPv4HashName(), rule.addressSet.GetIPv6HashName()
+	switch {
+	case rule.dstCIDR != nil && utilnet.IsIPv6CIDR(rule.dstCIDR):
+		return fmt.Sprintf("ip6.src == $%s && ip6.dst == %s", hashedNameIPv6, rule.dstCIDR)
+	case rule.dstCIDR != nil:
+		return fmt.Sprintf("ip4.src == $%s && ip4.dst == %s", hashedNameIPv4, rule.dstCIDR)
+	case config.IPv4Mode && config.IPv6Mode:
+		return fmt.Sprintf("(ip4.src == $%s || ip6.src == $%s)", hashedNameIPv4, hashedNameIPv6)
+	case config.IPv6Mode:
+		return fmt.Sprintf("ip6.src == $%s", hashedNameIPv6)
+	default:
+		return fmt.Sprintf("ip4.src == $%s", hashedNameIPv4)
+	}
+}
+
+// createQoS creates the QoS row of the rule and adds it to the given node
+// logical switches
+func (rule *egressQoSRule) createQoS(namespace string, switches []string) error {
+	args := []string{"--id=@qos", "create", "QoS", "direction=" + fromLport,
+		fmt.Sprintf("priority=%d", rule.priority), fmt.Sprintf("match=\"%s\"", rule.getMatch())}
+	if rule.dscp != nil {
+		args = append(args, fmt.Sprintf("action:dscp=%d", *rule.dscp))
+	}
+	if rule.bandwidth != nil {
+		args = append(args, fmt.Sprintf("bandwidth:rate=%d", rule.bandwidth.Rate))
+		if rule.bandwidth.Burst > 0 {
+			args = append(args, fmt.Sprintf("bandwidth:burst=%d", rule.bandwidth.Burst))
+		}
+	}
+	args = append(args, fmt.Sprintf("external-ids:%s=%s", egressQoSExternalID, namespace))
+	for _, logicalSwitch := range switches {
+		args = append(args, "--", "add", "logical_switch", logicalSwitch, "qos_rules", "@qos")
+	}
+	stdout, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to create the QoS row of the EgressQoS of namespace %s with priority %d, stderr: %q (%v)",
+			namespace, rule.priority, stderr, err)
+	}
+	rule.uuid = strings.TrimSpace(stdout)
+	return nil
+}
+
+// getEgressQoSSwitches returns the logical switches of the nodes hosting pods
+func (oc *Controller) getEgressQoSSwitches() ([]string, error) {
+	nodes, err := oc.watchFactory.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the nodes: %v", err)
+	}
+	switches := []string{}
+	for _, node := range nodes {
+		if oc.lsManager.GetSwitchSubnets(node.Name) != nil {
+			switches = append(switches, node.Name)
+		}
+	}
+	sort.Strings(switches)
+	return switches, nil
+}
+
+// removeEgressQoSRows removes the given QoS rows from the node logical switches,
+// OVN deletes the rows once they are not referenced anymore
+func (oc *Controller) removeEgressQoSRows(uuids []string) error {
+	switches, err := oc.getEgressQoSSwitches()
+	if err != nil {
+		return err
+	}
+	if len(switches) == 0 {
+		return nil
+	}
+	args := []string{}
+	for _, logicalSwitch := range switches {
+		args = append(args, "--", "--if-exists", "remove", "logical_switch", logicalSwitch, "qos_rules")
+		args = append(args, uuids...)
+	}
+	_, stderr, err := util.RunOVNNbctl(args[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to remove the QoS rows %v from the node logical switches, stderr: %q (%v)", uuids, stderr, err)
+	}
+	return nil
+}
+
+// WatchEgressQoS starts the watching of the EgressQoS resources and programs
+// their rules as QoS rows of the node logical switches
+func (oc *Controller) WatchEgressQoS() {
+	oc.watchFactory.AddEgressQoSHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			egressQoS := obj.(*egressqosapi.EgressQoS)
+			oc.syncEgressQoS(egressQoS.Namespace, egressQoS.Name)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldEgressQoS := old.(*egressqosapi.EgressQoS)
+			newEgressQoS := new.(*egressqosapi.EgressQoS)
+			if !reflect.DeepEqual(oldEgressQoS.Spec, newEgressQoS.Spec) {
+				oc.syncEgressQoS(newEgressQoS.Namespace, newEgressQoS.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			egressQoS := obj.(*egressqosapi.EgressQoS)
+			oc.syncEgressQoS(egressQoS.Namespace, egressQoS.Name)
+		},
+	}, oc.syncStaleEgressQoSes)
+}
+
+// syncStaleEgressQoSes removes the QoS rows and the address sets created by a
+// previous run for the namespaces without EgressQoS, and the address sets of the
+// rules removed from the existing EgressQoSes. The rows of the existing EgressQoSes
+// are kept until the rows of their current spec are created.
+func (oc *Controller) syncStaleEgressQoSes(egressQoSes []interface{}) {
+	expected := make(map[string]bool)
+	expectedAddressSets := make(map[string]bool)
+	for _, obj := range egressQoSes {
+		qos, ok := obj.(*egressqosapi.EgressQoS)
+		if !ok {
+			klog.Errorf("Spurious object in syncStaleEgressQoSes: %v", obj)
+			continue
+		}
+		if qos.Name != egressQoSName {
+			continue
+		}
+		expected[qos.Namespace] = true
+		for i := range qos.Spec.Egress {
+			expectedAddressSets[getEgressQoSAddressSetName(qos.Namespace, i)] = true
+		}
+	}
+
+	err := oc.addressSetFactory.ForEachAddressSet(func(addrSetName, namespaceName, nameSuffix string) {
+		if nameSuffix != "" || !isEgressQoSAddressSetName(addrSetName) || expectedAddressSets[addrSetName] {
+			return
+		}
+		if err := oc.addressSetFactory.DestroyAddressSetInBackingStore(addrSetName); err != nil {
+			klog.Errorf(err.Error())
+		}
+	})
+	if err != nil {
+		klog.Errorf("Error in syncing the address sets of the EgressQoSes: %v", err)
+	}
+
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=_uuid,external_ids", "find", "QoS", "direction="+fromLport)
+	if err != nil {
+		klog.Errorf("Failed to find the QoS rows of the EgressQoSes, stderr: %q (%v)", stderr, err)
+		return
+	}
+	oc.egressQoSLock.Lock()
+	defer oc.egressQoSLock.Unlock()
+	stale := []string{}
+	for _, line := range strings.Split(stdout, "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) != 2 {
+			continue
+		}
+		for _, externalID := range strings.Fields(parts[1]) {
+			namespace := strings.TrimPrefix(externalID, egressQoSExternalID+"=")
+			if namespace == externalID {
+				continue
+			}
+			if expected[namespace] {
+				oc.staleEgressQoSRows[namespace] = append(oc.staleEgressQoSRows[namespace], parts[0])
+			} else {
+				stale = append(stale, parts[0])
+			}
+			break
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	if err := oc.removeEgressQoSRows(stale); err != nil {
+		klog.Errorf("Failed to delete the stale QoS rows of the EgressQoSes: %v", err)
+	}
+}
+
+// removeStaleEgressQoSRows removes the QoS rows created by a previous run for the
+// EgressQoS of a namespace.
+// Caller must hold the egressQoSLock.
+func (oc *Controller) removeStaleEgressQoSRows(namespace string) {
+	uuids, ok := oc.staleEgressQoSRows[namespace]
+	if !ok {
+		return
+	}
+	if err := oc.removeEgressQoSRows(uuids); err != nil {
+		klog.Errorf("Failed to delete the stale QoS rows of the EgressQoS of namespace %s: %v", namespace, err)
+		return
+	}
+	delete(oc.staleEgressQoSRows, namespace)
+}
+
+// syncEgressQoS replaces the QoS rows and address sets of the EgressQoS of a
+// namespace with the ones of its current spec, or removes them when the
+// EgressQoS was deleted
+func (oc *Controller) syncEgressQoS(namespace, name string) {
+	if name != egressQoSName {
+		klog.Warningf("Ignoring EgressQoS %s/%s, only the EgressQoS named %s of a namespace is applied",
+			namespace, name, egressQoSName)
+		return
+	}
+	oc.egressQoSLock.Lock()
+	defer oc.egressQoSLock.Unlock()
+	// the rows left by a previous run are only removed once the rows of the
+	// current spec are created, so that the traffic keeps being marked
+	defer oc.removeStaleEgressQoSRows(namespace)
+
+	if eq, ok := oc.egressQoSes[namespace]; ok {
+		if err := oc.deleteEgressQoS(eq); err != nil {
+			klog.Error(err)
+		}
+		delete(oc.egressQoSes, namespace)
+	}
+
+	qos, err := oc.watchFactory.GetEgressQoS(namespace, name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to get EgressQoS %s/%s: %v", namespace, name, err)
+		}
+		return
+	}
+	eq, addErr := oc.addEgressQoS(qos)
+	status := egressQoSAppliedCorrectly
+	if addErr != nil {
+		klog.Error(addErr)
+		status = egressQoSAddError
+	} else {
+		oc.egressQoSes[namespace] = eq
+	}
+	if err := oc.updateEgressQoSStatus(namespace, name, status); err != nil {
+		klog.Errorf("Failed to update the status of EgressQoS %s/%s: %v", namespace, name, err)
+	}
+}
+
+// addEgressQoS creates the address sets and QoS rows of the rules of an EgressQoS
+func (oc *Controller) addEgressQoS(qos *egressqosapi.EgressQoS) (*egressQoS, error) {
+	eq := &egressQoS{namespace: qos.Namespace}
+	for i, rawRule := range qos.Spec.Egress {
+		rule, err := newEgressQoSRule(rawRule, egressQoSMaxPriority-i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d of EgressQoS %s/%s: %v", i, qos.Namespace, qos.Name, err)
+		}
+		eq.rules = append(eq.rules, rule)
+	}
+
+	var err error
+	for i, rule := range eq.rules {
+		// the address set left by a previous run is reused and set to the IPs of
+		// the pods selected now so that the QoS rows keep matching their traffic
+		rule.addressSet, err = oc.addressSetFactory.EnsureAddressSet(getEgressQoSAddressSetName(eq.namespace, i))
+		if err != nil {
+			_ = oc.deleteEgressQoS(eq)
+			return nil, fmt.Errorf("cannot create address set for EgressQoS in namespace %s: %v", eq.namespace, err)
+		}
+		if err := rule.addressSet.SetIPs(oc.getEgressQoSPodIPs(eq.namespace, rule.podSelector)); err != nil {
+			_ = oc.deleteEgressQoS(eq)
+			return nil, fmt.Errorf("cannot set the IPs of the address set for EgressQoS in namespace %s: %v", eq.namespace, err)
+		}
+		rule := rule
+		rule.podHandler = oc.watchFactory.AddFilteredPodHandler(eq.namespace, rule.podSelector,
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					oc.handleEgressQoSPodAddUpdate(eq, rule, obj)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					oc.handleEgressQoSPodAddUpdate(eq, rule, newObj)
+				},
+				DeleteFunc: func(obj interface{}) {
+					oc.handleEgressQoSPodDelete(eq, rule, obj)
+				},
+			}, nil)
+	}
+
+	// the QoS rows are only kept by OVN while a logical switch references them,
+	// they are created with the first node logical switch otherwise
+	switches, err := oc.getEgressQoSSwitches()
+	if err != nil {
+		_ = oc.deleteEgressQoS(eq)
+		return nil, err
+	}
+	if len(switches) > 0 {
+		for _, rule := range eq.rules {
+			if err := rule.createQoS(eq.namespace, switches); err != nil {
+				_ = oc.deleteEgressQoS(eq)
+				return nil, err
+			}
+		}
+	}
+	return eq, nil
+}
+
+// getEgressQoSPodIPs returns the IPs of the pods of a namespace selected by the
+// podSelector of a rule of an EgressQoS
+func (oc *Controller) getEgressQoSPodIPs(namespace string, podSelector labels.Selector) []net.IP {
+	pods, err := oc.watchFactory.GetPods(namespace)
+	if err != nil {
+		klog.Errorf("Failed to get the pods of namespace %s: %v", namespace, err)
+		return nil
+	}
+	var ips []net.IP
+	for _, pod := range pods {
+		if !util.PodWantsNetwork(pod) || !podSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		podIPs, err := util.GetAllPodIPs(pod)
+		if err != nil {
+			continue
+		}
+		ips = append(ips, podIPs...)
+	}
+	return ips
+}
+
+// deleteEgressQoS removes the QoS rows and address sets of an EgressQoS
+func (oc *Controller) deleteEgressQoS(eq *egressQoS) error {
+	uuids := []string{}
+	for _, rule := range eq.rules {
+		if rule.podHandler != nil {
+			oc.watchFactory.RemovePodHandler(rule.podHandler)
+			rule.podHandler = nil
+		}
+		if rule.uuid != "" {
+			uuids = append(uuids, rule.uuid)
+		}
+	}
+	var err error
+	if len(uuids) > 0 {
+		err = oc.removeEgressQoSRows(uuids)
+	}
+
+	eq.Lock()
+	defer eq.Unlock()
+	for _, rule := range eq.rules {
+		if rule.addressSet != nil {
+			if err := rule.addressSet.Destroy(); err != nil {
+				klog.Errorf("Failed to destroy the address set of EgressQoS in namespace %s: %v", eq.namespace, err)
+			}
+			rule.addressSet = nil
+		}
+	}
+	return err
+}
+
+// addEgressQoSRulesToSwitch adds the QoS rows of the EgressQoSes to the logical
+// switch of a new node, creating the rows that no switch referenced so far
+func (oc *Controller) addEgressQoSRulesToSwitch(nodeName string) error {
+	oc.egressQoSLock.Lock()
+	defer oc.egressQoSLock.Unlock()
+	for _, eq := range oc.egressQoSes {
+		for _, rule := range eq.rules {
+			if rule.uuid == "" {
+				if err := rule.createQoS(eq.namespace, []string{nodeName}); err != nil {
+					return err
+				}
+				continue
+			}
+			_, stderr, err := util.RunOVNNbctl("add", "logical_switch", nodeName, "qos_rules", rule.uuid)
+			if err != nil {
+				return fmt.Errorf("failed to add the QoS row %s of the EgressQoS of namespace %s to logical switch %s, stderr: %q (%v)",
+					rule.uuid, eq.namespace, nodeName, stderr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// handleEgressQoSPodAddUpdate adds a pod selected by a rule of an EgressQoS to the
+// address set of the rule once its IPs are known
+func (oc *Controller) handleEgressQoSPodAddUpdate(eq *egressQoS, rule *egressQoSRule, obj interface{}) {
+	pod := obj.(*kapi.Pod)
+	if !util.PodWantsNetwork(pod) {
+		return
+	}
+	ips, err := util.GetAllPodIPs(pod)
+	if err != nil {
+		// the pod will be added once it gets its IPs
+		klog.V(5).Infof("Pod %s/%s selected by EgressQoS has no IPs yet: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	eq.Lock()
+	defer eq.Unlock()
+	if rule.addressSet != nil {
+		if err := rule.addressSet.AddIPs(ips); err != nil {
+			klog.Errorf("Failed to add pod %s/%s to EgressQoS address set: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+// handleEgressQoSPodDelete removes a pod that was deleted or no longer matches the
+// podSelector of a rule of an EgressQoS from the address set of the rule
+func (oc *Controller) handleEgressQoSPodDelete(eq *egressQoS, rule *egressQoSRule, obj interface{}) {
+	pod := obj.(*kapi.Pod)
+	ips, err := util.GetAllPodIPs(pod)
+	if err != nil {
+		return
+	}
+	eq.Lock()
+	defer eq.Unlock()
+	if rule.addressSet != nil {
+		if err := rule.addressSet.DeleteIPs(ips); err != nil {
+			klog.Errorf("Failed to delete pod %s/%s from EgressQoS address set: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+func (oc *Controller) updateEgressQoSStatus(namespace, name, status string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		qos, err := oc.watchFactory.GetEgressQoS(namespace, name)
+		if err != nil {
+			return err
+		}
+		if qos.Status.Status == status {
+			return nil
+		}
+		updated := qos.DeepCopy()
+		updated.Status.Status = status
+		return oc.kube.UpdateEgressQoS(updated)
+	})
+}
@@ -0,0 +1,153 @@
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	egressqosv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	"github.com/urfave/cli/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN EgressQoS Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewLooseCompareFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("programs the rules as QoS rows of the node switches, reusing the ones of a previous run, and removes them on deletion", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			voicePod := *newPod(namespaceT.Name, "voice", "node1", "10.128.1.3")
+			voicePod.Labels = map[string]string{"app": "voice"}
+			otherPod := *newPod(namespaceT.Name, "other", "node1", "10.128.1.4")
+			dscp := int32(46)
+			dstCIDR := "1.2.3.0/24"
+			egressQoS := egressqosv1.EgressQoS{
+				ObjectMeta: metav1.ObjectMeta{Name: egressQoSName, Namespace: namespaceT.Name},
+				Spec: egressqosv1.EgressQoSSpec{
+					Egress: []egressqosv1.EgressQoSRule{
+						{
+							DSCP:        &dscp,
+							PodSelector: metav1.LabelSelector{MatchLabels: voicePod.Labels},
+						},
+						{
+							Bandwidth: &egressqosv1.EgressQoSBandwidth{Rate: 10000, Burst: 1000},
+							DstCIDR:   &dstCIDR,
+						},
+					},
+				},
+			}
+			ignored := egressQoS
+			ignored.Name = "other"
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&v1.NodeList{Items: []v1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+				}},
+				&v1.PodList{Items: []v1.Pod{voicePod, otherPod}},
+				&egressqosv1.EgressQoSList{Items: []egressqosv1.EgressQoS{egressQoS, ignored}},
+			)
+			err := fakeOvn.controller.lsManager.AddNode("node1", ovntest.MustParseIPNets("10.128.1.0/24"))
+			Expect(err).NotTo(HaveOccurred())
+			// the address sets of a rule, of a rule removed and of an EgressQoS deleted
+			// while ovnkube-master was not running
+			_, err = fakeOvn.asf.NewAddressSet(getEgressQoSAddressSetName(namespaceT.Name, 0),
+				[]net.IP{net.ParseIP("10.128.1.3"), net.ParseIP("10.128.1.9")})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fakeOvn.asf.NewAddressSet(getEgressQoSAddressSetName(namespaceT.Name, 2), []net.IP{net.ParseIP("10.128.1.3")})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fakeOvn.asf.NewAddressSet(getEgressQoSAddressSetName("namespace2", 0), []net.IP{net.ParseIP("10.128.2.3")})
+			Expect(err).NotTo(HaveOccurred())
+			// syncing the namespaces keeps the address sets of the EgressQoSes
+			fakeOvn.controller.syncNamespaces([]interface{}{&namespaceT})
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(getEgressQoSAddressSetName("namespace2", 0)), []string{"10.128.2.3"})
+
+			// the row of the deleted EgressQoS is removed right away, the one of the
+			// existing EgressQoS once its new rows are created
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,external_ids find QoS direction=from-lport",
+				Output: "stale-UUID,EgressQoS=namespace1\ngone-UUID,EgressQoS=namespace2\nother-UUID,owner=someone",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists remove logical_switch node1 qos_rules gone-UUID",
+				"ovn-nbctl --timeout=15 --if-exists remove logical_switch node1 qos_rules stale-UUID",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd: "ovn-nbctl --timeout=15 --id=@qos create QoS direction=from-lport priority=1000 " +
+					fmt.Sprintf("match=\"ip4.src == $%s\" ", getIPv4ASHashedName(getEgressQoSAddressSetName(namespaceT.Name, 0))) +
+					"action:dscp=46 external-ids:EgressQoS=namespace1 -- add logical_switch node1 qos_rules @qos",
+				Output: "qos0-UUID",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd: "ovn-nbctl --timeout=15 --id=@qos create QoS direction=from-lport priority=999 " +
+					fmt.Sprintf("match=\"ip4.src == $%s && ip4.dst == 1.2.3.0/24\" ", getIPv4ASHashedName(getEgressQoSAddressSetName(namespaceT.Name, 1))) +
+					"bandwidth:rate=10000 bandwidth:burst=1000 external-ids:EgressQoS=namespace1 -- add logical_switch node1 qos_rules @qos",
+				Output: "qos1-UUID",
+			})
+
+			fakeOvn.controller.WatchEgressQoS()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(getEgressQoSAddressSetName(namespaceT.Name, 0)), []string{"10.128.1.3"})
+			fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(getEgressQoSAddressSetName(namespaceT.Name, 1)), []string{"10.128.1.3", "10.128.1.4"})
+			fakeOvn.asf.ExpectNoAddressSet(getIPv4ASName(getEgressQoSAddressSetName(namespaceT.Name, 2)))
+			fakeOvn.asf.ExpectNoAddressSet(getIPv4ASName(getEgressQoSAddressSetName("namespace2", 0)))
+			updated, err := fakeOvn.fakeClient.EgressQoSClient.K8sV1().EgressQoSes(namespaceT.Name).Get(context.TODO(), egressQoSName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Status.Status).To(Equal(egressQoSAppliedCorrectly))
+
+			// the rows are added to the switch of a new node
+			err = fakeOvn.controller.lsManager.AddNode("node2", ovntest.MustParseIPNets("10.128.2.0/24"))
+			Expect(err).NotTo(HaveOccurred())
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 add logical_switch node2 qos_rules qos0-UUID",
+				"ovn-nbctl --timeout=15 add logical_switch node2 qos_rules qos1-UUID",
+			})
+			err = fakeOvn.controller.addEgressQoSRulesToSwitch("node2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists remove logical_switch node1 qos_rules qos0-UUID qos1-UUID " +
+					"-- --if-exists remove logical_switch node2 qos_rules qos0-UUID qos1-UUID",
+			})
+			err = fakeOvn.fakeClient.EgressQoSClient.K8sV1().EgressQoSes(namespaceT.Name).Delete(context.TODO(), egressQoSName, metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			fakeOvn.asf.EventuallyExpectNoAddressSet(getIPv4ASName(getEgressQoSAddressSetName(namespaceT.Name, 0)))
+			fakeOvn.asf.EventuallyExpectNoAddressSet(getIPv4ASName(getEgressQoSAddressSetName(namespaceT.Name, 1)))
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-egress-qos"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
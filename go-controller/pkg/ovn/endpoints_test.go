@@ -2,9 +2,6 @@ package ovn
 
 import (
 	"context"
-	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -20,10 +17,6 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-var FakeGRs = "GR_1 GR_2"
-
-type endpoints struct{}
-
 func newEndpointsMeta(name, namespace string) metav1.ObjectMeta {
 	return metav1.ObjectMeta{
 		UID:       types.UID(name),
@@ -47,96 +40,6 @@ func newEndpoints(name, namespace string, addresses []v1.EndpointAddress, ports
 	}
 }
 
-func (e endpoints) addNodePortPortCmds(fexec *ovntest.FakeExec, service v1.Service, endpoint v1.Endpoints) {
-	gatewayRouters := "GR_1 GR_2"
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-		Output: gatewayRouters,
-	})
-	for idx, gatewayR := range strings.Fields(gatewayRouters) {
-		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=" + gatewayR,
-			Output: "load_balancer_" + strconv.Itoa(idx),
-		})
-		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-			Cmd:    "ovn-nbctl --timeout=15 get logical_router " + gatewayR + " external_ids:physical_ips",
-			Output: "169.254.33.2",
-		})
-		fexec.AddFakeCmdsNoOutputNoError([]string{
-			fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%s vips:\"%s:%v\"=\"%s:%v\"", strconv.Itoa(idx), "169.254.33.2", service.Spec.Ports[0].NodePort, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
-		})
-	}
-}
-
-func (e endpoints) delNodePortPortCmds(fexec *ovntest.FakeExec, service v1.Service, gatewayR string, idx int) {
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 get logical_router " + gatewayR + " external_ids:physical_ips",
-		Output: "169.254.33.2",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}load_balancer_%d", idx),
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}load_balancer_%d", idx),
-		fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"\"", idx, "169.254.33.2", service.Spec.Ports[0].NodePort),
-	})
-}
-
-func (e endpoints) addCmds(fexec *ovntest.FakeExec, service v1.Service, endpoint v1.Endpoints) {
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
-		Output: k8sTCPLoadBalancerIP,
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"%s:%v\"=\"%s:%v\"", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
-	})
-}
-
-func (e endpoints) addExternalIPCmds(fexec *ovntest.FakeExec, loadBalancerIPs []string, service v1.Service, endpoint v1.Endpoints) {
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-		Output: FakeGRs,
-	})
-	for idx, gatewayR := range strings.Fields(FakeGRs) {
-		fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=" + gatewayR,
-			Output: fmt.Sprintf("load_balancer_%d", idx),
-		})
-		for _, loadBalancerIP := range loadBalancerIPs {
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer load_balancer_%d vips:\"%s:%v\"=\"%s:%v\"", idx, loadBalancerIP, service.Spec.Ports[0].Port, endpoint.Subsets[0].Addresses[0].IP, endpoint.Subsets[0].Ports[0].Port),
-			})
-		}
-	}
-}
-
-func (e endpoints) delCmds(fexec *ovntest.FakeExec, service v1.Service, isNodePort bool) {
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-		Output: FakeGRs,
-	})
-	for _, sPort := range service.Spec.Ports {
-		if sPort.Protocol == v1.ProtocolTCP {
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
-				fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
-				fmt.Sprintf("ovn-nbctl --timeout=15 set load_balancer %s vips:\"%s:%v\"=\"\"", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, sPort.Port),
-			})
-			for idx, gatewayR := range strings.Fields(FakeGRs) {
-				fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=" + gatewayR,
-					Output: fmt.Sprintf("load_balancer_%d", idx),
-				})
-				if isNodePort {
-					e.delNodePortPortCmds(fexec, service, gatewayR, idx)
-				}
-			}
-		} else if sPort.Protocol == v1.ProtocolUDP {
-			fexec.AddFakeCmdsNoOutputNoError([]string{
-				fmt.Sprintf("ovn-nbctl --timeout=15 remove load_balancer %s vips \"%s:%v\"", k8sUDPLoadBalancerIP, service.Spec.ClusterIP, sPort.Port),
-			})
-		}
-	}
-}
-
 var _ = Describe("OVN Namespace Operations", func() {
 	var (
 		app     *cli.App
@@ -165,8 +68,6 @@ var _ = Describe("OVN Namespace Operations", func() {
 		It("reconciles existing endpoints", func() {
 			app.Action = func(ctx *cli.Context) error {
 
-				testE := endpoints{}
-
 				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
 					[]v1.EndpointAddress{
 						{
@@ -193,7 +94,12 @@ var _ = Describe("OVN Namespace Operations", func() {
 					nil,
 				)
 
-				testE.addCmds(tExec, serviceT, endpointsT)
+				addServiceLBSyncCmds(tExec, "", "", "")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]",
+					Output: "lb_cluster\n",
+				})
 
 				fakeOvn.start(ctx,
 					&v1.EndpointsList{
@@ -223,8 +129,6 @@ var _ = Describe("OVN Namespace Operations", func() {
 		It("reconciles existing endpoints with ExternalIP", func() {
 			app.Action = func(ctx *cli.Context) error {
 
-				testE := endpoints{}
-
 				loadBalancerIPs := []string{"1.1.1.1", "192.168.126.11"}
 
 				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
@@ -254,8 +158,18 @@ var _ = Describe("OVN Namespace Operations", func() {
 					loadBalancerIPs,
 				)
 
-				testE.addCmds(tExec, serviceT, endpointsT)
-				testE.addExternalIPCmds(tExec, loadBalancerIPs, serviceT, endpointsT)
+				// the external IPs are load balanced by the gateway router of the node
+				addServiceLBSyncCmds(tExec, "", "node1,\n", "GR_node1,\n")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:9100\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_node_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"1.1.1.1:9100\"=\"10.125.0.2:8080\",\"192.168.126.11:9100\"=\"10.125.0.2:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb1 -- add logical_router GR_node1 load_balancer @lb1",
+					Output: "lb_cluster\nlb_node1\n",
+				})
 
 				fakeOvn.start(ctx,
 					&v1.EndpointsList{
@@ -269,6 +183,9 @@ var _ = Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
 				fakeOvn.controller.WatchEndpoints()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Get(context.TODO(), endpointsT.Name, metav1.GetOptions{})
@@ -284,8 +201,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 
 		It("reconciles existing endpoints with NodePort", func() {
 			app.Action = func(ctx *cli.Context) error {
-
-				testE := endpoints{}
+				config.Gateway.NodeportEnable = true
 
 				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
 					[]v1.EndpointAddress{
@@ -305,6 +221,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 					[]v1.ServicePort{
 						{
 							Name:       "portTcp1",
+							Port:       8032,
 							NodePort:   31111,
 							Protocol:   v1.ProtocolTCP,
 							TargetPort: intstr.FromInt(8080),
@@ -314,8 +231,16 @@ var _ = Describe("OVN Namespace Operations", func() {
 					nil,
 				)
 
-				testE.addNodePortPortCmds(tExec, serviceT, endpointsT)
-				testE.addCmds(tExec, serviceT, endpointsT)
+				addServiceLBSyncCmds(tExec, "", "node1,\n", "GR_node1,\n")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_node_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"169.254.33.2:31111\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb1 -- add logical_router GR_node1 load_balancer @lb1",
+					Output: "lb_cluster\nlb_node1\n",
+				})
 
 				fakeOvn.start(ctx,
 					&v1.EndpointsList{
@@ -329,6 +254,9 @@ var _ = Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
 				fakeOvn.controller.WatchEndpoints()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Get(context.TODO(), endpointsT.Name, metav1.GetOptions{})
@@ -342,10 +270,247 @@ var _ = Describe("OVN Namespace Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("reconciles deleted endpoints", func() {
+		It("reconciles existing endpoints with NodePort in local gateway mode", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.NodeportEnable = true
+				config.Gateway.Mode = config.GatewayModeLocal
+
+				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP: "10.125.0.2",
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:       "portTcp1",
+							Port:       8032,
+							NodePort:   31111,
+							Protocol:   v1.ProtocolTCP,
+							TargetPort: intstr.FromInt(8080),
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+
+				// the node ports are only load balanced by the node switch
+				addServiceLBSyncCmds(tExec, "", "node1,\n", "GR_node1,\n")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_node_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"169.254.33.2:31111\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb1",
+					Output: "lb_cluster\nlb_node1\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchEndpoints()
+
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reconciles existing endpoints with NodePort and the Local external traffic policy", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.NodeportEnable = true
+				node1, node2 := "1", "2"
+
+				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP:       "10.125.0.2",
+							NodeName: &node1,
+						},
+						{
+							IP:       "10.125.0.3",
+							NodeName: &node2,
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:       "portTcp1",
+							Port:       8032,
+							NodePort:   31111,
+							Protocol:   v1.ProtocolTCP,
+							TargetPort: intstr.FromInt(8080),
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				serviceT.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+
+				// the cluster load balancer uses all the endpoints, while the load
+				// balancer of each node only uses the endpoints on the node and skips SNAT
+				addServiceLBSyncCmds(tExec, "", "1,\n2,\n", "GR_1,\nGR_2,\n")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080,10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch 1 load_balancer @lb0 -- add logical_switch 2 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_node_1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"169.254.33.2:31111\"=\"10.125.0.2:8080\"}" +
+						" options={\"reject\"=\"true\",\"skip_snat\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch 1 load_balancer @lb1 -- add logical_router GR_1 load_balancer @lb1" +
+						" -- --id=@lb2 create load_balancer name=Service_namespace1/endpoint-service1_TCP_node_2 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"169.254.33.3:31111\"=\"10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\",\"skip_snat\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch 2 load_balancer @lb2 -- add logical_router GR_2 load_balancer @lb2",
+					Output: "lb_cluster\nlb_node1\nlb_node2\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				fakeOvn.controller.addServiceLBNode(node1)
+				fakeOvn.controller.setServiceLBNodeGateway(node1, "GR_1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode(node1)
+				fakeOvn.controller.addServiceLBNode(node2)
+				fakeOvn.controller.setServiceLBNodeGateway(node2, "GR_2", []string{"169.254.33.3"})
+				fakeOvn.controller.commitServiceLBNode(node2)
+				fakeOvn.controller.WatchEndpoints()
+
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reconciles existing endpoints with the Local internal traffic policy", func() {
 			app.Action = func(ctx *cli.Context) error {
+				node1, node2 := "node1", "node2"
 
-				testE := endpoints{}
+				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP:       "10.125.0.2",
+							NodeName: &node1,
+						},
+						{
+							IP:       "10.125.0.3",
+							NodeName: &node2,
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				local := v1.ServiceInternalTrafficPolicyLocal
+				serviceT.Spec.InternalTrafficPolicy = &local
+
+				// each node switch load balances the cluster IP to the endpoints on the
+				// node, else rejects the traffic
+				addServiceLBSyncCmds(tExec, "", "", "")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15" +
+						" -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node2 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node2 load_balancer @lb1" +
+						" -- --id=@lb2 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node3 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node3 load_balancer @lb2",
+					Output: "lb_node1\nlb_node2\nlb_node3\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpointsT,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				for _, nodeName := range []string{"node1", "node2", "node3"} {
+					fakeOvn.controller.addServiceLBNode(nodeName)
+					fakeOvn.controller.commitServiceLBNode(nodeName)
+				}
+				fakeOvn.controller.WatchEndpoints()
+
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reconciles deleted endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
 
 				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
 					[]v1.EndpointAddress{
@@ -372,7 +537,9 @@ var _ = Describe("OVN Namespace Operations", func() {
 					v1.ServiceTypeClusterIP,
 					nil,
 				)
-				testE.addCmds(tExec, serviceT, endpointsT)
+				addServiceLBSyncCmds(tExec,
+					"lb_cluster,Service_namespace1/endpoint-service1_TCP_cluster,tcp,k8s-service=namespace1/endpoint-service1,172.124.0.2:8032=10.125.0.2:8080,reject=true,,,\n",
+					"", "")
 
 				fakeOvn.start(ctx,
 					&v1.EndpointsList{
@@ -393,7 +560,9 @@ var _ = Describe("OVN Namespace Operations", func() {
 				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
 
 				// Delete the endpoint
-				testE.delCmds(tExec, serviceT, false)
+				tExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_cluster vips={\"172.124.0.2:8032\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]",
+				})
 
 				err = fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Delete(context.TODO(), endpointsT.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
@@ -408,8 +577,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 
 		It("reconciles deleted NodePort endpoints", func() {
 			app.Action = func(ctx *cli.Context) error {
-
-				testE := endpoints{}
+				config.Gateway.NodeportEnable = true
 
 				endpointsT := *newEndpoints("endpoint-service1", "namespace1",
 					[]v1.EndpointAddress{
@@ -428,6 +596,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
 					[]v1.ServicePort{
 						{
+							Port:     8032,
 							NodePort: 31100,
 							Protocol: v1.ProtocolTCP,
 							Name:     "portTcp1",
@@ -436,8 +605,11 @@ var _ = Describe("OVN Namespace Operations", func() {
 					v1.ServiceTypeNodePort,
 					nil,
 				)
-				testE.addNodePortPortCmds(tExec, serviceT, endpointsT)
-				testE.addCmds(tExec, serviceT, endpointsT)
+				addServiceLBSyncCmds(tExec,
+					"lb_cluster,Service_namespace1/endpoint-service1_TCP_cluster,tcp,k8s-service=namespace1/endpoint-service1,172.124.0.2:8032=10.125.0.2:8080,reject=true,,,\n"+
+						"lb_node1,Service_namespace1/endpoint-service1_TCP_node_node1,tcp,k8s-service=namespace1/endpoint-service1,169.254.33.2:31100=10.125.0.2:8080,reject=true,,,\n",
+					"node1,lb_cluster lb_node1\n",
+					"GR_node1,lb_node1\n")
 
 				fakeOvn.start(ctx,
 					&v1.EndpointsList{
@@ -451,6 +623,9 @@ var _ = Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
 				fakeOvn.controller.WatchEndpoints()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Get(context.TODO(), endpointsT.Name, metav1.GetOptions{})
@@ -458,7 +633,10 @@ var _ = Describe("OVN Namespace Operations", func() {
 				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
 
 				// Delete the endpoint
-				testE.delCmds(tExec, serviceT, true)
+				tExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_cluster vips={\"172.124.0.2:8032\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- set load_balancer lb_node1 vips={\"169.254.33.2:31100\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]",
+				})
 
 				err = fakeOvn.fakeClient.KubeClient.CoreV1().Endpoints(endpointsT.Namespace).Delete(context.TODO(), endpointsT.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,247 @@
+package ovn
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+
+	kapi "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// endpointSliceConditions returns whether an endpoint is ready, serving and
+// terminating, applying the defaults documented for unset conditions.
+func endpointSliceConditions(ep *discovery.Endpoint) (ready, serving, terminating bool) {
+	ready = ep.Conditions.Ready == nil || *ep.Conditions.Ready
+	serving = ready
+	if ep.Conditions.Serving != nil {
+		serving = *ep.Conditions.Serving
+	}
+	terminating = ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+	return ready, serving, terminating
+}
+
+// endpointSliceNodeName returns the name of the node hosting an endpoint, if known
+func endpointSliceNodeName(ep *discovery.Endpoint) *string {
+	if ep.NodeName != nil {
+		return ep.NodeName
+	}
+	if hostname, ok := ep.Topology[kapi.LabelHostname]; ok {
+		return &hostname
+	}
+	return nil
+}
+
+// endpointSlicePorts converts the ports of an EndpointSlice to Endpoints ports.
+// Ports without a number, which mean "all ports", cannot be load balanced and are skipped.
+func endpointSlicePorts(slice *discovery.EndpointSlice) []kapi.EndpointPort {
+	ports := make([]kapi.EndpointPort, 0, len(slice.Ports))
+	for _, port := range slice.Ports {
+		if port.Port == nil {
+			continue
+		}
+		epPort := kapi.EndpointPort{
+			Port:     *port.Port,
+			Protocol: kapi.ProtocolTCP,
+		}
+		if port.Name != nil {
+			epPort.Name = *port.Name
+		}
+		if port.Protocol != nil {
+			epPort.Protocol = *port.Protocol
+		}
+		ports = append(ports, epPort)
+	}
+	return ports
+}
+
+// endpointsFromSlices merges the EndpointSlices of a service into the Endpoints
+// used to program the service's load balancers. Slices sharing the same ports are
+// merged into a single subset and endpoints listed by several slices are only
+// added once.
+// Ready endpoints are used as the service backends. If a service has no ready
+// endpoints, the endpoints that are terminating but still serving are used instead,
+// so existing clients are not cut off while the backends shut down gracefully.
+func endpointsFromSlices(namespace, name string, slices []*discovery.EndpointSlice) *kapi.Endpoints {
+	ep := &kapi.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// sort the slices so that the merged endpoints do not depend on the order
+	// in which they are listed
+	sorted := make([]*discovery.EndpointSlice, len(slices))
+	copy(sorted, slices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	type subsetInfo struct {
+		ports       []kapi.EndpointPort
+		ready       []kapi.EndpointAddress
+		terminating []kapi.EndpointAddress
+		notReady    []kapi.EndpointAddress
+		seen        map[string]bool
+	}
+	subsets := make(map[string]*subsetInfo)
+	subsetKeys := []string{}
+	hasReady := false
+	for _, slice := range sorted {
+		if slice.AddressType == discovery.AddressTypeFQDN {
+			continue
+		}
+		ports := endpointSlicePorts(slice)
+		if len(ports) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%v", ports)
+		subset, ok := subsets[key]
+		if !ok {
+			subset = &subsetInfo{ports: ports, seen: make(map[string]bool)}
+			subsets[key] = subset
+			subsetKeys = append(subsetKeys, key)
+		}
+		for i := range slice.Endpoints {
+			sliceEp := &slice.Endpoints[i]
+			ready, serving, terminating := endpointSliceConditions(sliceEp)
+			for _, ip := range sliceEp.Addresses {
+				if subset.seen[ip] {
+					continue
+				}
+				subset.seen[ip] = true
+				addr := kapi.EndpointAddress{
+					IP:        ip,
+					NodeName:  endpointSliceNodeName(sliceEp),
+					TargetRef: sliceEp.TargetRef,
+				}
+				if sliceEp.Hostname != nil {
+					addr.Hostname = *sliceEp.Hostname
+				}
+				switch {
+				case ready:
+					subset.ready = append(subset.ready, addr)
+					hasReady = true
+				case serving && terminating:
+					subset.terminating = append(subset.terminating, addr)
+				default:
+					subset.notReady = append(subset.notReady, addr)
+				}
+			}
+		}
+	}
+
+	for _, key := range subsetKeys {
+		subset := subsets[key]
+		epSubset := kapi.EndpointSubset{
+			Ports:             subset.ports,
+			Addresses:         subset.ready,
+			NotReadyAddresses: subset.notReady,
+		}
+		if hasReady {
+			epSubset.NotReadyAddresses = append(epSubset.NotReadyAddresses, subset.terminating...)
+		} else {
+			epSubset.Addresses = subset.terminating
+		}
+		if len(epSubset.Addresses) == 0 && len(epSubset.NotReadyAddresses) == 0 {
+			continue
+		}
+		ep.Subsets = append(ep.Subsets, epSubset)
+	}
+	return ep
+}
+
+// getServiceEndpoints returns the endpoints of a service, merging its EndpointSlices
+// when those are used in place of Endpoints
+func (ovn *Controller) getServiceEndpoints(namespace, name string) (*kapi.Endpoints, error) {
+	if !config.OVNKubernetesFeature.EnableEndpointSlices {
+		return ovn.watchFactory.GetEndpoint(namespace, name)
+	}
+	slices, err := ovn.watchFactory.GetServiceEndpointSlices(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return endpointsFromSlices(namespace, name, slices), nil
+}
+
+// endpointSliceZoneHints returns the zones the topology aware hints of the endpoints
+// of the slices are for, keyed by endpoint IP. The endpoints without hints are not
+// part of the result.
+func endpointSliceZoneHints(slices []*discovery.EndpointSlice) map[string]sets.String {
+	zoneHints := map[string]sets.String{}
+	for _, slice := range slices {
+		for _, ep := range slice.Endpoints {
+			if ep.Hints == nil || len(ep.Hints.ForZones) == 0 {
+				continue
+			}
+			zones := sets.NewString()
+			for _, zone := range ep.Hints.ForZones {
+				zones.Insert(zone.Name)
+			}
+			for _, ip := range ep.Addresses {
+				zoneHints[ip] = zones
+			}
+		}
+	}
+	return zoneHints
+}
+
+// getServiceZoneHints returns the zone hints of the endpoints of a service, keyed
+// by endpoint IP, from its EndpointSlices
+func (ovn *Controller) getServiceZoneHints(namespace, name string) map[string]sets.String {
+	slices, err := ovn.watchFactory.GetServiceEndpointSlices(namespace, name)
+	if err != nil {
+		klog.V(5).Infof("No endpoint slices found for service %s/%s: %v", namespace, name, err)
+		return nil
+	}
+	return endpointSliceZoneHints(slices)
+}
+
+// syncEndpointSliceService reprograms the load balancers of the service owning an
+// EndpointSlice from all the service's current EndpointSlices
+func (ovn *Controller) syncEndpointSliceService(slice *discovery.EndpointSlice) error {
+	serviceName := slice.Labels[discovery.LabelServiceName]
+	if serviceName == "" {
+		klog.V(5).Infof("Skipping endpoint slice %s/%s not owned by a service", slice.Namespace, slice.Name)
+		return nil
+	}
+	return ovn.syncService(slice.Namespace, serviceName)
+}
+
+// WatchEndpointSlices starts the watching of EndpointSlice resource and calls back the
+// appropriate handler logic for the services owning them
+func (oc *Controller) WatchEndpointSlices() {
+	start := time.Now()
+	oc.watchFactory.AddEndpointSliceHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			slice := obj.(*discovery.EndpointSlice)
+			if err := oc.syncEndpointSliceService(slice); err != nil {
+				klog.Errorf("Error in adding endpoint slice %s/%s: %v", slice.Namespace, slice.Name, err)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			sliceNew := new.(*discovery.EndpointSlice)
+			sliceOld := old.(*discovery.EndpointSlice)
+			if reflect.DeepEqual(sliceNew.Endpoints, sliceOld.Endpoints) &&
+				reflect.DeepEqual(sliceNew.Ports, sliceOld.Ports) {
+				return
+			}
+			if err := oc.syncEndpointSliceService(sliceNew); err != nil {
+				klog.Errorf("Error in modifying endpoint slice %s/%s: %v", sliceNew.Namespace, sliceNew.Name, err)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			slice := obj.(*discovery.EndpointSlice)
+			if err := oc.syncEndpointSliceService(slice); err != nil {
+				klog.Errorf("Error in deleting endpoint slice %s/%s: %v", slice.Namespace, slice.Name, err)
+			}
+		},
+	}, nil)
+	klog.Infof("Bootstrapping existing endpoint slices and cleaning stale endpoint slices took %v", time.Since(start))
+}
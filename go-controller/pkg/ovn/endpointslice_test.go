@@ -0,0 +1,307 @@
+package ovn
+
+import (
+	"context"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newEndpointSlice(name, namespace, service string, endpoints []discovery.Endpoint, ports []discovery.EndpointPort) *discovery.EndpointSlice {
+	return &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       types.UID(name),
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				discovery.LabelServiceName: service,
+			},
+		},
+		AddressType: discovery.AddressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports:       ports,
+	}
+}
+
+func newEndpointSlicePort(name string, port int32, protocol v1.Protocol) discovery.EndpointPort {
+	return discovery.EndpointPort{
+		Name:     &name,
+		Port:     &port,
+		Protocol: &protocol,
+	}
+}
+
+func newEndpointSliceEndpoint(ip string, ready, serving, terminating bool) discovery.Endpoint {
+	return discovery.Endpoint{
+		Addresses: []string{ip},
+		Conditions: discovery.EndpointConditions{
+			Ready:       &ready,
+			Serving:     &serving,
+			Terminating: &terminating,
+		},
+	}
+}
+
+var _ = Describe("OVN EndpointSlice merging", func() {
+
+	It("uses the ready endpoints of all the slices once", func() {
+		port := newEndpointSlicePort("portTcp1", 8080, v1.ProtocolTCP)
+		slices := []*discovery.EndpointSlice{
+			newEndpointSlice("endpoint-service1-b", "namespace1", "endpoint-service1",
+				[]discovery.Endpoint{
+					newEndpointSliceEndpoint("10.125.0.3", true, true, false),
+					newEndpointSliceEndpoint("10.125.0.2", true, true, false),
+				},
+				[]discovery.EndpointPort{port}),
+			newEndpointSlice("endpoint-service1-a", "namespace1", "endpoint-service1",
+				[]discovery.Endpoint{
+					newEndpointSliceEndpoint("10.125.0.2", true, true, false),
+					newEndpointSliceEndpoint("10.125.0.4", false, false, false),
+				},
+				[]discovery.EndpointPort{port}),
+		}
+
+		ep := endpointsFromSlices("namespace1", "endpoint-service1", slices)
+		Expect(ep.Name).To(Equal("endpoint-service1"))
+		Expect(ep.Subsets).To(HaveLen(1))
+		Expect(ep.Subsets[0].Ports).To(Equal([]v1.EndpointPort{
+			{Name: "portTcp1", Port: 8080, Protocol: v1.ProtocolTCP},
+		}))
+		Expect(ep.Subsets[0].Addresses).To(Equal([]v1.EndpointAddress{
+			{IP: "10.125.0.2"},
+			{IP: "10.125.0.3"},
+		}))
+		Expect(ep.Subsets[0].NotReadyAddresses).To(Equal([]v1.EndpointAddress{
+			{IP: "10.125.0.4"},
+		}))
+	})
+
+	It("falls back to the serving terminating endpoints when none are ready", func() {
+		port := newEndpointSlicePort("portTcp1", 8080, v1.ProtocolTCP)
+		nodeName := "node1"
+		terminatingEp := newEndpointSliceEndpoint("10.125.0.2", false, true, true)
+		terminatingEp.NodeName = &nodeName
+		slices := []*discovery.EndpointSlice{
+			newEndpointSlice("endpoint-service1-a", "namespace1", "endpoint-service1",
+				[]discovery.Endpoint{
+					terminatingEp,
+					newEndpointSliceEndpoint("10.125.0.3", false, false, true),
+				},
+				[]discovery.EndpointPort{port}),
+		}
+
+		ep := endpointsFromSlices("namespace1", "endpoint-service1", slices)
+		Expect(ep.Subsets).To(HaveLen(1))
+		Expect(ep.Subsets[0].Addresses).To(Equal([]v1.EndpointAddress{
+			{IP: "10.125.0.2", NodeName: &nodeName},
+		}))
+		Expect(ep.Subsets[0].NotReadyAddresses).To(Equal([]v1.EndpointAddress{
+			{IP: "10.125.0.3"},
+		}))
+
+		// once a ready endpoint shows up the terminating one is no longer used
+		slices = append(slices, newEndpointSlice("endpoint-service1-b", "namespace1", "endpoint-service1",
+			[]discovery.Endpoint{
+				newEndpointSliceEndpoint("10.125.0.4", true, true, false),
+			},
+			[]discovery.EndpointPort{port}))
+		ep = endpointsFromSlices("namespace1", "endpoint-service1", slices)
+		Expect(ep.Subsets).To(HaveLen(1))
+		Expect(ep.Subsets[0].Addresses).To(Equal([]v1.EndpointAddress{
+			{IP: "10.125.0.4"},
+		}))
+	})
+})
+
+var _ = Describe("OVN EndpointSlice Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		tExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+		config.OVNKubernetesFeature.EnableEndpointSlices = true
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		tExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(tExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	Context("on endpoint slice changes", func() {
+
+		It("reconciles the load balancer of the service from all its slices", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				port := newEndpointSlicePort("portTcp1", 8080, v1.ProtocolTCP)
+				sliceA := *newEndpointSlice("endpoint-service1-a", "namespace1", "endpoint-service1",
+					[]discovery.Endpoint{
+						newEndpointSliceEndpoint("10.125.0.2", true, true, false),
+					},
+					[]discovery.EndpointPort{port})
+				sliceB := *newEndpointSlice("endpoint-service1-b", "namespace1", "endpoint-service1",
+					[]discovery.Endpoint{
+						newEndpointSliceEndpoint("10.125.0.3", true, true, false),
+					},
+					[]discovery.EndpointPort{port})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				// the load balancer is created from both slices, the second slice finding
+				// it up to date
+				addServiceLBSyncCmds(tExec, "", "", "")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080,10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[]",
+					Output: "lb_cluster\n",
+				})
+
+				fakeOvn.start(ctx,
+					&discovery.EndpointSliceList{
+						Items: []discovery.EndpointSlice{
+							sliceA,
+							sliceB,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				fakeOvn.controller.WatchEndpointSlices()
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				// Deleting a slice only removes its endpoints
+				tExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_cluster vips={\"172.124.0.2:8032\"=\"10.125.0.3:8080\"} options={\"reject\"=\"true\"} selection_fields=[]",
+				})
+				err := fakeOvn.fakeClient.KubeClient.DiscoveryV1beta1().EndpointSlices(sliceA.Namespace).Delete(context.TODO(), sliceA.Name, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				// Deleting the last slice makes the load balancer reject the traffic
+				tExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_cluster vips={\"172.124.0.2:8032\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]",
+				})
+				err = fakeOvn.fakeClient.KubeClient.DiscoveryV1beta1().EndpointSlices(sliceB.Namespace).Delete(context.TODO(), sliceB.Name, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reconciles the load balancers of a service from the topology hints of its slices", func() {
+			app.Action = func(ctx *cli.Context) error {
+				node1, node2 := "node1", "node2"
+				epA := newEndpointSliceEndpoint("10.125.0.2", true, true, false)
+				epA.NodeName = &node1
+				epA.Hints = &discovery.EndpointHints{ForZones: []discovery.ForZone{{Name: "a"}}}
+				epB := newEndpointSliceEndpoint("10.125.0.3", true, true, false)
+				epB.NodeName = &node2
+				epB.Hints = &discovery.EndpointHints{ForZones: []discovery.ForZone{{Name: "b"}}}
+				slice := *newEndpointSlice("endpoint-service1-a", "namespace1", "endpoint-service1",
+					[]discovery.Endpoint{epA, epB},
+					[]discovery.EndpointPort{newEndpointSlicePort("portTcp1", 8080, v1.ProtocolTCP)})
+
+				serviceT := *newService("endpoint-service1", "namespace1", "172.124.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				serviceT.Annotations = map[string]string{v1.AnnotationTopologyAwareHints: "Auto"}
+
+				// each node switch load balances the cluster IP to the endpoints hinted
+				// for its zone, else to all the endpoints
+				addServiceSyncCmds(tExec, "", "", "")
+				tExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15" +
+						" -- --id=@lb0 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node2 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node2 load_balancer @lb1" +
+						" -- --id=@lb2 create load_balancer name=Service_namespace1/endpoint-service1_TCP_cluster_node3 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/endpoint-service1\" vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080,10.125.0.3:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] -- add logical_switch node3 load_balancer @lb2",
+					Output: "lb_node1\nlb_node2\nlb_node3\n",
+				})
+
+				fakeOvn.start(ctx,
+					&discovery.EndpointSliceList{
+						Items: []discovery.EndpointSlice{
+							slice,
+						},
+					},
+					&v1.ServiceList{
+						Items: []v1.Service{
+							serviceT,
+						},
+					},
+				)
+				for nodeName, zone := range map[string]string{"node1": "a", "node2": "b", "node3": "c"} {
+					fakeOvn.controller.setServiceLBNodeZone(nodeName, zone)
+					fakeOvn.controller.addServiceLBNode(nodeName)
+					fakeOvn.controller.commitServiceLBNode(nodeName)
+				}
+				fakeOvn.controller.WatchServices()
+				fakeOvn.controller.WatchEndpointSlices()
+				Eventually(tExec.CalledMatchesExpected).Should(BeTrue(), tExec.ErrorDesc)
+
+				// a node moved to another zone gets the endpoints hinted for it
+				tExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_node3 vips={\"172.124.0.2:8032\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]",
+				})
+				fakeOvn.controller.setServiceLBNodeZone("node3", "a")
+				fakeOvn.controller.commitServiceLBNode("node3")
+				Expect(tExec.CalledMatchesExpected()).To(BeTrue(), tExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
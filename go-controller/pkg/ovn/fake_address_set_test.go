@@ -47,6 +47,26 @@ func (f *fakeAddressSetFactory) NewAddressSet(name string, ips []net.IP) (Addres
 	return set, nil
 }
 
+// EnsureAddressSet returns the existing address set object or a new empty one
+func (f *fakeAddressSetFactory) EnsureAddressSet(name string) (AddressSet, error) {
+	f.Lock()
+	defer f.Unlock()
+	set := &fakeAddressSets{name: name}
+	if config.IPv4Mode {
+		if set.ipv4 = f.sets[getIPv4ASName(name)]; set.ipv4 == nil {
+			set.ipv4 = newFakeAddressSet(getIPv4ASName(name), nil, f.removeAddressSet)
+			f.sets[getIPv4ASName(name)] = set.ipv4
+		}
+	}
+	if config.IPv6Mode {
+		if set.ipv6 = f.sets[getIPv6ASName(name)]; set.ipv6 == nil {
+			set.ipv6 = newFakeAddressSet(getIPv6ASName(name), nil, f.removeAddressSet)
+			f.sets[getIPv6ASName(name)] = set.ipv6
+		}
+	}
+	return set, nil
+}
+
 func (f *fakeAddressSetFactory) ForEachAddressSet(iteratorFn AddressSetIterFunc) error {
 	asNames := sets.String{}
 	for _, set := range f.sets {
@@ -230,7 +250,27 @@ func (as *fakeAddressSets) AddIPs(ips []net.IP) error {
 }
 
 func (as *fakeAddressSets) SetIPs(ips []net.IP) error {
-	// NOOP
+	as.Lock()
+	defer as.Unlock()
+
+	for _, set := range []*fakeAddressSet{as.ipv4, as.ipv6} {
+		if set != nil {
+			set.Lock()
+			set.ips = make(map[string]net.IP)
+			set.Unlock()
+		}
+	}
+	for _, ip := range ips {
+		var err error
+		if utilnet.IsIPv6(ip) {
+			err = as.ipv6.addIP(ip)
+		} else {
+			err = as.ipv4.addIP(ip)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -6,8 +6,6 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	"github.com/pkg/errors"
-
-	kapi "k8s.io/api/core/v1"
 )
 
 // GetOvnGateways return all created gateways.
@@ -49,47 +47,3 @@ func GetGatewayPhysicalIPs(gatewayRouter string) ([]string, error) {
 	}
 	return []string{physicalIP}, nil
 }
-
-// GetGatewayLoadBalancer return the gateway load balancer
-func GetGatewayLoadBalancer(gatewayRouter string, protocol kapi.Protocol) (string, error) {
-	externalIDKey := string(protocol) + "_lb_gateway_router"
-	loadBalancer, _, err := util.RunOVNNbctl("--data=bare", "--no-heading",
-		"--columns=_uuid", "find", "load_balancer",
-		"external_ids:"+externalIDKey+"="+
-			gatewayRouter)
-	if err != nil {
-		return "", err
-	}
-	if loadBalancer == "" {
-		return "", fmt.Errorf("load balancer item in OVN DB is an empty string")
-	}
-	return loadBalancer, nil
-}
-
-// GetGatewayLoadBalancers find TCP, SCTP, UDP load-balancers from gateway router.
-func GetGatewayLoadBalancers(gatewayRouter string) (string, string, string, error) {
-	lbTCP, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
-		"--columns=_uuid", "find", "load_balancer",
-		"external_ids:TCP_lb_gateway_router="+gatewayRouter)
-	if err != nil {
-		return "", "", "", errors.Wrapf(err, "failed to get gateway router %q TCP "+
-			"load balancer, stderr: %q", gatewayRouter, stderr)
-	}
-
-	lbUDP, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
-		"--columns=_uuid", "find", "load_balancer",
-		"external_ids:UDP_lb_gateway_router="+gatewayRouter)
-	if err != nil {
-		return "", "", "", errors.Wrapf(err, "failed to get gateway router %q UDP "+
-			"load balancer, stderr: %q", gatewayRouter, stderr)
-	}
-
-	lbSCTP, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
-		"--columns=_uuid", "find", "load_balancer",
-		"external_ids:SCTP_lb_gateway_router="+gatewayRouter)
-	if err != nil {
-		return "", "", "", errors.Wrapf(err, "failed to get gateway router %q SCTP "+
-			"load balancer, stderr: %q", gatewayRouter, stderr)
-	}
-	return lbTCP, lbUDP, lbSCTP, nil
-}
@@ -18,7 +18,7 @@ import (
 
 // gatewayInit creates a gateway router for the local chassis.
 func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*net.IPNet,
-	l3GatewayConfig *util.L3GatewayConfig, sctpSupport bool, gwLRPIfAddrs, drLRPIfAddrs []*net.IPNet) error {
+	l3GatewayConfig *util.L3GatewayConfig, gwLRPIfAddrs, drLRPIfAddrs []*net.IPNet) error {
 
 	gwLRPIPs := make([]net.IP, 0)
 	for _, gwLRPIfAddr := range gwLRPIfAddrs {
@@ -127,68 +127,6 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 		}
 	}
 
-	if l3GatewayConfig.NodePortEnable {
-		// Create 3 load-balancers for north-south traffic for each gateway
-		// router: UDP, TCP, SCTP
-		var k8sNSLbTCP, k8sNSLbUDP, k8sNSLbSCTP string
-		k8sNSLbTCP, k8sNSLbUDP, k8sNSLbSCTP, err = getGatewayLoadBalancers(gatewayRouter)
-		if err != nil {
-			return err
-		}
-		protoLBMap := map[kapi.Protocol]string{
-			kapi.ProtocolTCP:  k8sNSLbTCP,
-			kapi.ProtocolUDP:  k8sNSLbUDP,
-			kapi.ProtocolSCTP: k8sNSLbSCTP,
-		}
-		enabledProtos := []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP}
-		if sctpSupport {
-			enabledProtos = append(enabledProtos, kapi.ProtocolSCTP)
-		}
-		for _, proto := range enabledProtos {
-			if protoLBMap[proto] == "" {
-				protoLBMap[proto], stderr, err = util.RunOVNNbctl("--", "create",
-					"load_balancer",
-					fmt.Sprintf("external_ids:%s_lb_gateway_router=%s", proto, gatewayRouter),
-					fmt.Sprintf("protocol=%s", strings.ToLower(string(proto))))
-				if err != nil {
-					return fmt.Errorf("failed to create load balancer for gateway router %s for protocol %s: "+
-						"stderr: %q, error: %v", gatewayRouter, proto, stderr, err)
-				}
-			}
-		}
-
-		// Local gateway mode does not use GR for ingress node port traffic, it uses mp0 instead
-		if config.Gateway.Mode != config.GatewayModeLocal {
-			// Add north-south load-balancers to the gateway router.
-			lbString := fmt.Sprintf("%s,%s", protoLBMap[kapi.ProtocolTCP], protoLBMap[kapi.ProtocolUDP])
-			if sctpSupport {
-				lbString = lbString + "," + protoLBMap[kapi.ProtocolSCTP]
-			}
-			stdout, stderr, err = util.RunOVNNbctl("set", "logical_router", gatewayRouter, "load_balancer="+lbString)
-			if err != nil {
-				return fmt.Errorf("failed to set north-south load-balancers to the "+
-					"gateway router %s, stdout: %q, stderr: %q, error: %v",
-					gatewayRouter, stdout, stderr, err)
-			}
-		}
-		// Also add north-south load-balancers to local switches for pod -> nodePort traffic
-		stdout, stderr, err = util.RunOVNNbctl("get", "logical_switch", nodeName, "load_balancer")
-		if err != nil {
-			return fmt.Errorf("failed to get load-balancers on the node switch %s, stdout: %q, "+
-				"stderr: %q, error: %v", nodeName, stdout, stderr, err)
-		}
-		for _, proto := range enabledProtos {
-			if !strings.Contains(stdout, protoLBMap[proto]) {
-				stdout, stderr, err = util.RunOVNNbctl("ls-lb-add", nodeName, protoLBMap[proto])
-				if err != nil {
-					return fmt.Errorf("failed to add north-south load-balancer %s to the "+
-						"node switch %s, stdout: %q, stderr: %q, error: %v",
-						protoLBMap[proto], nodeName, stdout, stderr, err)
-				}
-			}
-		}
-	}
-
 	// Create the external switch for the physical interface to connect to.
 	externalSwitch := types.ExternalSwitchPrefix + nodeName
 	stdout, stderr, err = util.RunOVNNbctl("--may-exist", "ls-add",
@@ -2,6 +2,8 @@ package ovn
 
 import (
 	"fmt"
+	"net"
+	"sort"
 	"strings"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
@@ -9,6 +11,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
@@ -33,32 +36,69 @@ type gressPolicy struct {
 	// the rule in question.
 	portPolicies []*portPolicy
 
+	// namedPortPolicies represents the ports given by name to which traffic
+	// is allowed. They are resolved against the container ports of the pods
+	// receiving the traffic: the local pods of an ingress rule and the peer
+	// pods of an egress rule.
+	namedPortPolicies []*namedPortPolicy
+	// namedPortDsts holds, by logical port name, the pods receiving the
+	// traffic that expose some of the named ports
+	namedPortDsts map[string]*namedPortDst
+	// namedPortAddressSets holds, by port resolved from the named ports, the
+	// address set of the pods exposing it, which the ACLs allowing the traffic
+	// to the port match on
+	namedPortAddressSets map[portPolicy]AddressSet
+	// namedPortsFromAllPods is set for the egress rules with no peers or
+	// with ipBlock peers, whose named ports are resolved against all the
+	// pods of the cluster rather than against the peer pods
+	namedPortsFromAllPods bool
+
 	ipBlock []*knet.IPBlock
+
+	// aclLogging is the severity at which the policy's allow ACLs log; an
+	// empty string disables logging
+	aclLogging string
 }
 
 type portPolicy struct {
 	protocol string
 	port     int32
+	// endPort is the last port of the range starting at port, if any
+	endPort int32
 }
 
 func (pp *portPolicy) getL4Match() (string, error) {
-	if pp.protocol == TCP {
-		if pp.port != 0 {
-			return fmt.Sprintf("tcp && tcp.dst==%d", pp.port), nil
-		}
-		return "tcp", nil
-	} else if pp.protocol == UDP {
-		if pp.port != 0 {
-			return fmt.Sprintf("udp && udp.dst==%d", pp.port), nil
-		}
-		return "udp", nil
-	} else if pp.protocol == SCTP {
-		if pp.port != 0 {
-			return fmt.Sprintf("sctp && sctp.dst==%d", pp.port), nil
-		}
-		return "sctp", nil
+	var proto string
+	switch pp.protocol {
+	case TCP:
+		proto = "tcp"
+	case UDP:
+		proto = "udp"
+	case SCTP:
+		proto = "sctp"
+	default:
+		return "", fmt.Errorf("unknown port protocol %v", pp.protocol)
+	}
+	if pp.port == 0 {
+		return proto, nil
+	}
+	if pp.endPort > pp.port {
+		return fmt.Sprintf("%s && %s.dst>=%d && %s.dst<=%d", proto, proto, pp.port, proto, pp.endPort), nil
 	}
-	return "", fmt.Errorf("unknown port protocol %v", pp.protocol)
+	return fmt.Sprintf("%s && %s.dst==%d", proto, proto, pp.port), nil
+}
+
+// namedPortPolicy is a port given by the name of a container port
+type namedPortPolicy struct {
+	protocol string
+	name     string
+}
+
+// namedPortDst is a pod receiving the traffic of a rule with named ports, along
+// with the ports its containers expose under those names
+type namedPortDst struct {
+	ips   []net.IP
+	ports []portPolicy
 }
 
 func newGressPolicy(policyType knet.PolicyType, idx int, namespace, name string) *gressPolicy {
@@ -70,6 +110,9 @@ func newGressPolicy(policyType knet.PolicyType, idx int, namespace, name string)
 		peerV4AddressSets: sets.String{},
 		peerV6AddressSets: sets.String{},
 		portPolicies:      make([]*portPolicy, 0),
+		namedPortDsts:     make(map[string]*namedPortDst),
+
+		namedPortAddressSets: make(map[portPolicy]AddressSet),
 	}
 }
 
@@ -119,15 +162,218 @@ func (gp *gressPolicy) deletePeerPod(pod *v1.Pod) error {
 
 // If the port is not specified, it implies all ports for that protocol
 func (gp *gressPolicy) addPortPolicy(portJSON *knet.NetworkPolicyPort) {
+	if portJSON.Port != nil && portJSON.Port.Type == intstr.String {
+		gp.namedPortPolicies = append(gp.namedPortPolicies, &namedPortPolicy{
+			protocol: string(*portJSON.Protocol),
+			name:     portJSON.Port.StrVal,
+		})
+		return
+	}
 	pp := &portPolicy{protocol: string(*portJSON.Protocol),
 		port: 0,
 	}
 	if portJSON.Port != nil {
 		pp.port = portJSON.Port.IntVal
 	}
+	if portJSON.EndPort != nil {
+		pp.endPort = *portJSON.EndPort
+	}
 	gp.portPolicies = append(gp.portPolicies, pp)
 }
 
+// getNamedPorts returns the ports the containers of the pod expose under the
+// names of the rule's named ports
+func (gp *gressPolicy) getNamedPorts(pod *v1.Pod) []portPolicy {
+	var ports []portPolicy
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			protocol := containerPort.Protocol
+			if protocol == "" {
+				protocol = v1.ProtocolTCP
+			}
+			for _, npp := range gp.namedPortPolicies {
+				if npp.name == containerPort.Name && npp.protocol == string(protocol) {
+					ports = append(ports, portPolicy{protocol: npp.protocol, port: containerPort.ContainerPort})
+				}
+			}
+		}
+	}
+	return ports
+}
+
+// addNamedPortDst resolves the rule's named ports against the container ports
+// of a pod receiving its traffic, and allows the traffic to the ports found
+func (gp *gressPolicy) addNamedPortDst(factory AddressSetFactory, pod *v1.Pod, portGroupName, portGroupUUID string) error {
+	if len(gp.namedPortPolicies) == 0 {
+		return nil
+	}
+	ports := gp.getNamedPorts(pod)
+	if len(ports) == 0 {
+		return gp.deleteNamedPortDst(factory, pod, portGroupName, portGroupUUID)
+	}
+	ips, err := util.GetAllPodIPs(pod)
+	if err != nil {
+		return err
+	}
+	return gp.setNamedPortDst(factory, podLogicalPortName(pod), &namedPortDst{ips: ips, ports: ports},
+		portGroupName, portGroupUUID)
+}
+
+// deleteNamedPortDst stops allowing the traffic to the named ports of a pod
+func (gp *gressPolicy) deleteNamedPortDst(factory AddressSetFactory, pod *v1.Pod, portGroupName, portGroupUUID string) error {
+	if _, ok := gp.namedPortDsts[podLogicalPortName(pod)]; !ok {
+		return nil
+	}
+	return gp.setNamedPortDst(factory, podLogicalPortName(pod), nil, portGroupName, portGroupUUID)
+}
+
+// setNamedPortDst records the named ports of a pod, or forgets them when dst is
+// nil, and updates the address sets of the ports it exposed or exposes. The
+// ACLs allowing the traffic to a port are added along with its address set and
+// deleted once no pod exposes the port anymore.
+func (gp *gressPolicy) setNamedPortDst(factory AddressSetFactory, logicalPort string, dst *namedPortDst,
+	portGroupName, portGroupUUID string) error {
+	changedPorts := make(map[portPolicy]bool)
+	if oldDst, ok := gp.namedPortDsts[logicalPort]; ok {
+		for _, port := range oldDst.ports {
+			changedPorts[port] = true
+		}
+	}
+	if dst == nil {
+		delete(gp.namedPortDsts, logicalPort)
+	} else {
+		gp.namedPortDsts[logicalPort] = dst
+		for _, port := range dst.ports {
+			changedPorts[port] = true
+		}
+	}
+
+	dstIPs := gp.getNamedPortDstIPs()
+	l3Match := gp.getL3MatchFromAddressSet()
+	lportMatch := gp.getLPortMatch(portGroupName)
+	for _, port := range sortedPortPolicies(changedPorts) {
+		ips, exposed := dstIPs[port]
+		as, exists := gp.namedPortAddressSets[port]
+		switch {
+		case exposed && exists:
+			if err := as.SetIPs(ips); err != nil {
+				return err
+			}
+		case exposed:
+			as, err := factory.NewAddressSet(gp.getNamedPortAddressSetName(port), ips)
+			if err != nil {
+				return err
+			}
+			gp.namedPortAddressSets[port] = as
+			l4Match, err := gp.getNamedPortL4Match(port, as)
+			if err != nil {
+				return err
+			}
+			gp.addACLsForL4Match(l3Match, l4Match, lportMatch, portGroupUUID)
+		case exists:
+			l4Match, err := gp.getNamedPortL4Match(port, as)
+			if err != nil {
+				return err
+			}
+			if err := gp.deleteACLAllow(l4Match, portGroupName); err != nil {
+				return err
+			}
+			if err := as.Destroy(); err != nil {
+				return err
+			}
+			delete(gp.namedPortAddressSets, port)
+		}
+	}
+	return nil
+}
+
+// getNamedPortDstIPs returns, by port resolved from the named ports, the IPs of
+// the pods exposing it
+func (gp *gressPolicy) getNamedPortDstIPs() map[portPolicy][]net.IP {
+	dstIPs := make(map[portPolicy][]net.IP)
+	for _, dst := range gp.namedPortDsts {
+		for _, port := range dst.ports {
+			dstIPs[port] = append(dstIPs[port], dst.ips...)
+		}
+	}
+	return dstIPs
+}
+
+// sortedPortPolicies returns the ports of the set sorted by protocol and port
+func sortedPortPolicies(ports map[portPolicy]bool) []portPolicy {
+	sorted := make([]portPolicy, 0, len(ports))
+	for port := range ports {
+		sorted = append(sorted, port)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].protocol != sorted[j].protocol {
+			return sorted[i].protocol < sorted[j].protocol
+		}
+		return sorted[i].port < sorted[j].port
+	})
+	return sorted
+}
+
+// getNamedPortAddressSetName returns the name of the address set of the pods
+// exposing a port resolved from the named ports
+func (gp *gressPolicy) getNamedPortAddressSetName(port portPolicy) string {
+	direction := strings.ToLower(string(gp.policyType))
+	return fmt.Sprintf("%s.%s.%s.%d.%s.%d", gp.policyNamespace, gp.policyName, direction, gp.idx,
+		strings.ToLower(port.protocol), port.port)
+}
+
+// getNamedPortL4Match returns the L4 match allowing the traffic to a port
+// resolved from the named ports, destined to the pods in its address set
+func (gp *gressPolicy) getNamedPortL4Match(port portPolicy, as AddressSet) (string, error) {
+	l4Match, err := port.getL4Match()
+	if err != nil {
+		return "", err
+	}
+	v4MatchStr := "ip4.dst == $" + as.GetIPv4HashName()
+	v6MatchStr := "ip6.dst == $" + as.GetIPv6HashName()
+	switch {
+	case config.IPv4Mode && config.IPv6Mode:
+		return fmt.Sprintf("%s && (%s || %s)", l4Match, v4MatchStr, v6MatchStr), nil
+	case config.IPv6Mode:
+		return fmt.Sprintf("%s && %s", l4Match, v6MatchStr), nil
+	default:
+		return fmt.Sprintf("%s && %s", l4Match, v4MatchStr), nil
+	}
+}
+
+// getNamedPortL4Matches returns the L4 matches allowing the traffic to the
+// ports resolved from the named ports
+func (gp *gressPolicy) getNamedPortL4Matches() []string {
+	l4Matches := make([]string, 0, len(gp.namedPortAddressSets))
+	for port, as := range gp.namedPortAddressSets {
+		l4Match, err := gp.getNamedPortL4Match(port, as)
+		if err != nil {
+			continue
+		}
+		l4Matches = append(l4Matches, l4Match)
+	}
+	sort.Strings(l4Matches)
+	return l4Matches
+}
+
+// getL4Matches returns the L4 matches of the ACLs implementing the rule: one
+// per port and per port resolved from the named ports, or noneMatch when the
+// rule applies to all the ports
+func (gp *gressPolicy) getL4Matches() []string {
+	if len(gp.portPolicies) == 0 && len(gp.namedPortPolicies) == 0 {
+		return []string{noneMatch}
+	}
+	var l4Matches []string
+	for _, port := range gp.portPolicies {
+		l4Match, err := port.getL4Match()
+		if err != nil {
+			continue
+		}
+		l4Matches = append(l4Matches, l4Match)
+	}
+	return append(l4Matches, gp.getNamedPortL4Matches()...)
+}
+
 func (gp *gressPolicy) addIPBlock(ipblockJSON *knet.IPBlock) {
 	gp.ipBlock = append(gp.ipBlock, ipblockJSON)
 }
@@ -233,59 +479,51 @@ func (gp *gressPolicy) delNamespaceAddressSet(name, portGroupName string) {
 	gp.localPodUpdateACL(oldL3Match, gp.getL3MatchFromAddressSet(), portGroupName)
 }
 
+// getLPortMatch returns the match of the traffic of the pods of the given
+// Port Group that the gress policy applies to
+func (gp *gressPolicy) getLPortMatch(portGroupName string) string {
+	if gp.policyType == knet.PolicyTypeIngress {
+		return fmt.Sprintf("outport == @%s", portGroupName)
+	}
+	return fmt.Sprintf("inport == @%s", portGroupName)
+}
+
+// getGressACLMatch returns the match of an ACL implementing the gress policy
+func getGressACLMatch(l3Match, l4Match, lportMatch string) string {
+	if l4Match == noneMatch {
+		return fmt.Sprintf("match=\"%s && %s\"", l3Match, lportMatch)
+	}
+	return fmt.Sprintf("match=\"%s && %s && %s\"", l3Match, l4Match, lportMatch)
+}
+
 // localPodAddACL adds an ACL that implements the gress policy's rules to the
 // given Port Group (which should contain all pod logical switch ports selected
 // by the parent NetworkPolicy)
 func (gp *gressPolicy) localPodAddACL(portGroupName, portGroupUUID string) {
 	l3Match := gp.getL3MatchFromAddressSet()
-	var lportMatch string
-	var cidrMatches []string
-	if gp.policyType == knet.PolicyTypeIngress {
-		lportMatch = fmt.Sprintf("outport == @%s", portGroupName)
-	} else {
-		lportMatch = fmt.Sprintf("inport == @%s", portGroupName)
+	lportMatch := gp.getLPortMatch(portGroupName)
+	for _, l4Match := range gp.getL4Matches() {
+		gp.addACLsForL4Match(l3Match, l4Match, lportMatch, portGroupUUID)
 	}
+}
 
-	if len(gp.portPolicies) == 0 {
-		match := fmt.Sprintf("match=\"%s && %s\"", l3Match, lportMatch)
-		l4Match := noneMatch
-
-		if len(gp.ipBlock) > 0 {
-			// Add ACL allow rule for IPBlock CIDR
-			cidrMatches = gp.getMatchFromIPBlock(lportMatch, l4Match)
-			for _, cidrMatch := range cidrMatches {
-				if err := gp.addACLAllow(cidrMatch, l4Match, portGroupUUID, true); err != nil {
-					klog.Warningf(err.Error())
-				}
-			}
-		}
-		// if there are pod/namespace selector, then allow packets from/to that address_set or
-		// if the NetworkPolicyPeer is empty, then allow from all sources or to all destinations.
-		if gp.sizeOfAddressSet() > 0 || len(gp.ipBlock) == 0 {
-			if err := gp.addACLAllow(match, l4Match, portGroupUUID, false); err != nil {
+// addACLsForL4Match adds the ACLs allowing the traffic matching l4Match to the
+// given Port Group
+func (gp *gressPolicy) addACLsForL4Match(l3Match, l4Match, lportMatch, portGroupUUID string) {
+	if len(gp.ipBlock) > 0 {
+		// Add ACL allow rule for IPBlock CIDR
+		cidrMatches := gp.getMatchFromIPBlock(lportMatch, l4Match)
+		for _, cidrMatch := range cidrMatches {
+			if err := gp.addACLAllow(cidrMatch, l4Match, portGroupUUID, true); err != nil {
 				klog.Warningf(err.Error())
 			}
 		}
 	}
-	for _, port := range gp.portPolicies {
-		l4Match, err := port.getL4Match()
-		if err != nil {
-			continue
-		}
-		match := fmt.Sprintf("match=\"%s && %s && %s\"", l3Match, l4Match, lportMatch)
-		if len(gp.ipBlock) > 0 {
-			// Add ACL allow rule for IPBlock CIDR
-			cidrMatches = gp.getMatchFromIPBlock(lportMatch, l4Match)
-			for _, cidrMatch := range cidrMatches {
-				if err := gp.addACLAllow(cidrMatch, l4Match, portGroupUUID, true); err != nil {
-					klog.Warningf(err.Error())
-				}
-			}
-		}
-		if gp.sizeOfAddressSet() > 0 || len(gp.ipBlock) == 0 {
-			if err := gp.addACLAllow(match, l4Match, portGroupUUID, false); err != nil {
-				klog.Warningf(err.Error())
-			}
+	// if there are pod/namespace selector, then allow packets from/to that address_set or
+	// if the NetworkPolicyPeer is empty, then allow from all sources or to all destinations.
+	if gp.sizeOfAddressSet() > 0 || len(gp.ipBlock) == 0 {
+		if err := gp.addACLAllow(getGressACLMatch(l3Match, l4Match, lportMatch), l4Match, portGroupUUID, false); err != nil {
+			klog.Warningf(err.Error())
 		}
 	}
 }
@@ -311,13 +549,18 @@ func (gp *gressPolicy) addACLAllow(match, l4Match, portGroupUUID string, ipBlock
 	}
 
 	if uuid != "" {
-		return nil
+		// The ACL may be left over from before a restart: reconcile its
+		// logging with the namespace's current severity.
+		return setACLLogging([]string{uuid}, gp.aclLogging)
 	}
 
-	_, stderr, err = util.RunOVNNbctl("--id=@acl", "create",
+	args := []string{"--id=@acl", "create",
 		"acl", fmt.Sprintf("priority=%s", defaultAllowPriority),
 		fmt.Sprintf("direction=%s", direction), match,
 		fmt.Sprintf("action=%s", action),
+		getACLNameArg(gp.policyNamespace + "_" + gp.policyName)}
+	args = append(args, getACLLoggingArgs(gp.aclLogging)...)
+	args = append(args,
 		fmt.Sprintf("external-ids:l4Match=\"%s\"", l4Match),
 		fmt.Sprintf("external-ids:ipblock_cidr=%t", ipBlockCidr),
 		fmt.Sprintf("external-ids:namespace=%s", gp.policyNamespace),
@@ -325,6 +568,7 @@ func (gp *gressPolicy) addACLAllow(match, l4Match, portGroupUUID string, ipBlock
 		fmt.Sprintf("external-ids:%s_num=%d", gp.policyType, gp.idx),
 		fmt.Sprintf("external-ids:policy_type=%s", gp.policyType),
 		"--", "add", "port_group", portGroupUUID, "acls", "@acl")
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return fmt.Errorf("failed to create the acl allow rule for "+
 			"namespace=%s, policy=%s, stderr: %q (%v)", gp.policyNamespace,
@@ -361,6 +605,34 @@ func (gp *gressPolicy) modifyACLAllow(oldMatch, newMatch string) error {
 	return nil
 }
 
+// deleteACLAllow removes the ACLs allowing the traffic matching l4Match from
+// the given Port Group
+func (gp *gressPolicy) deleteACLAllow(l4Match, portGroupName string) error {
+	uuids, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+		"--columns=_uuid", "find", "ACL",
+		fmt.Sprintf("external-ids:l4Match=\"%s\"", l4Match),
+		fmt.Sprintf("external-ids:namespace=%s", gp.policyNamespace),
+		fmt.Sprintf("external-ids:policy=%s", gp.policyName),
+		fmt.Sprintf("external-ids:%s_num=%d", gp.policyType, gp.idx),
+		fmt.Sprintf("external-ids:policy_type=%s", gp.policyType))
+	if err != nil {
+		return fmt.Errorf("find failed to get the allow rule for "+
+			"namespace=%s, policy=%s, stderr: %q (%v)",
+			gp.policyNamespace, gp.policyName, stderr, err)
+	}
+
+	for _, uuid := range strings.Fields(uuids) {
+		_, stderr, err = util.RunOVNNbctl("--if-exists", "remove", "port_group",
+			portGroupName, "acls", uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete the allow rule for "+
+				"namespace=%s, policy=%s, stderr: %q (%v)", gp.policyNamespace,
+				gp.policyName, stderr, err)
+		}
+	}
+	return nil
+}
+
 func constructIPBlockStringsForACL(direction string, ipBlocks []*knet.IPBlock, lportMatch, l4Match string) []string {
 	var matchStrings []string
 	var matchStr, ipVersion string
@@ -389,26 +661,10 @@ func constructIPBlockStringsForACL(direction string, ipBlocks []*knet.IPBlock, l
 
 // localPodUpdateACL updates an existing ACL that implements the gress policy's rules
 func (gp *gressPolicy) localPodUpdateACL(oldl3Match, newl3Match, portGroupName string) {
-	var lportMatch string
-	if gp.policyType == knet.PolicyTypeIngress {
-		lportMatch = fmt.Sprintf("outport == @%s", portGroupName)
-	} else {
-		lportMatch = fmt.Sprintf("inport == @%s", portGroupName)
-	}
-	if len(gp.portPolicies) == 0 {
-		oldMatch := fmt.Sprintf("match=\"%s && %s\"", oldl3Match, lportMatch)
-		newMatch := fmt.Sprintf("match=\"%s && %s\"", newl3Match, lportMatch)
-		if err := gp.modifyACLAllow(oldMatch, newMatch); err != nil {
-			klog.Warningf(err.Error())
-		}
-	}
-	for _, port := range gp.portPolicies {
-		l4Match, err := port.getL4Match()
-		if err != nil {
-			continue
-		}
-		oldMatch := fmt.Sprintf("match=\"%s && %s && %s\"", oldl3Match, l4Match, lportMatch)
-		newMatch := fmt.Sprintf("match=\"%s && %s && %s\"", newl3Match, l4Match, lportMatch)
+	lportMatch := gp.getLPortMatch(portGroupName)
+	for _, l4Match := range gp.getL4Matches() {
+		oldMatch := getGressACLMatch(oldl3Match, l4Match, lportMatch)
+		newMatch := getGressACLMatch(newl3Match, l4Match, lportMatch)
 		if err := gp.modifyACLAllow(oldMatch, newMatch); err != nil {
 			klog.Warningf(err.Error())
 		}
@@ -422,5 +678,11 @@ func (gp *gressPolicy) destroy() error {
 		}
 		gp.peerAddressSet = nil
 	}
+	for port, as := range gp.namedPortAddressSets {
+		if err := as.Destroy(); err != nil {
+			return err
+		}
+		delete(gp.namedPortAddressSets, port)
+	}
 	return nil
 }
@@ -1,9 +1,14 @@
 package ovn
 
 import (
+	"net"
+	"testing"
+
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
-	"testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestGetMatchFromIPBlock(t *testing.T) {
@@ -117,3 +122,90 @@ func TestGetMatchFromIPBlock(t *testing.T) {
 		assert.Equal(t, tc.expected, output)
 	}
 }
+
+func TestGetL4Match(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		port     portPolicy
+		expected string
+	}{
+		{
+			desc:     "all ports",
+			port:     portPolicy{protocol: TCP},
+			expected: "tcp",
+		},
+		{
+			desc:     "single port",
+			port:     portPolicy{protocol: UDP, port: 53},
+			expected: "udp && udp.dst==53",
+		},
+		{
+			desc:     "port range",
+			port:     portPolicy{protocol: SCTP, port: 32000, endPort: 32768},
+			expected: "sctp && sctp.dst>=32000 && sctp.dst<=32768",
+		},
+	}
+
+	for _, tc := range testcases {
+		output, err := tc.port.getL4Match()
+		assert.NoError(t, err, tc.desc)
+		assert.Equal(t, tc.expected, output, tc.desc)
+	}
+}
+
+func TestAddPortPolicy(t *testing.T) {
+	tcp := v1.ProtocolTCP
+	endPort := int32(32768)
+	gressPolicy := newGressPolicy(knet.PolicyTypeEgress, 0, "testing", "test")
+	gressPolicy.addPortPolicy(&knet.NetworkPolicyPort{Protocol: &tcp})
+	gressPolicy.addPortPolicy(&knet.NetworkPolicyPort{Protocol: &tcp, Port: &intstr.IntOrString{IntVal: 80}})
+	gressPolicy.addPortPolicy(&knet.NetworkPolicyPort{Protocol: &tcp, Port: &intstr.IntOrString{IntVal: 32000}, EndPort: &endPort})
+
+	assert.Equal(t, []string{
+		"tcp",
+		"tcp && tcp.dst==80",
+		"tcp && tcp.dst>=32000 && tcp.dst<=32768",
+	}, gressPolicy.getL4Matches())
+}
+
+func TestGetNamedPortDstIPs(t *testing.T) {
+	tcp := v1.ProtocolTCP
+	udp := v1.ProtocolUDP
+	gressPolicy := newGressPolicy(knet.PolicyTypeIngress, 0, "testing", "test")
+	gressPolicy.addPortPolicy(&knet.NetworkPolicyPort{Protocol: &tcp, Port: &intstr.IntOrString{Type: intstr.String, StrVal: "http"}})
+	gressPolicy.addPortPolicy(&knet.NetworkPolicyPort{Protocol: &udp, Port: &intstr.IntOrString{Type: intstr.String, StrVal: "dns"}})
+	assert.Empty(t, gressPolicy.portPolicies)
+
+	pods := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}, Spec: v1.PodSpec{Containers: []v1.Container{{Ports: []v1.ContainerPort{
+			{Name: "http", ContainerPort: 8080},
+			{Name: "dns", ContainerPort: 53, Protocol: v1.ProtocolTCP},
+		}}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}, Spec: v1.PodSpec{Containers: []v1.Container{
+			{Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+			{Ports: []v1.ContainerPort{{Name: "dns", ContainerPort: 5353, Protocol: v1.ProtocolUDP}}},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod3"}, Spec: v1.PodSpec{Containers: []v1.Container{{Ports: []v1.ContainerPort{
+			{Name: "metrics", ContainerPort: 9090},
+		}}}}},
+	}
+	ips := [][]net.IP{
+		{net.ParseIP("10.128.1.3")},
+		{net.ParseIP("10.128.1.4"), net.ParseIP("fd00:10:244::4")},
+		{net.ParseIP("10.128.1.5")},
+	}
+	for i, pod := range pods {
+		if ports := gressPolicy.getNamedPorts(pod); len(ports) > 0 {
+			gressPolicy.namedPortDsts[pods[i].Name] = &namedPortDst{ips: ips[i], ports: ports}
+		}
+	}
+
+	http := portPolicy{protocol: TCP, port: 8080}
+	dns := portPolicy{protocol: UDP, port: 5353}
+	dstIPs := gressPolicy.getNamedPortDstIPs()
+	assert.Len(t, dstIPs, 2)
+	assert.ElementsMatch(t, []net.IP{net.ParseIP("10.128.1.3"), net.ParseIP("10.128.1.4"), net.ParseIP("fd00:10:244::4")}, dstIPs[http])
+	assert.ElementsMatch(t, []net.IP{net.ParseIP("10.128.1.4"), net.ParseIP("fd00:10:244::4")}, dstIPs[dns])
+	assert.Equal(t, "testing.test.ingress.0.tcp.8080", gressPolicy.getNamedPortAddressSetName(http))
+	assert.Equal(t, "testing.test.ingress.0.udp.5353", gressPolicy.getNamedPortAddressSetName(dns))
+}
@@ -0,0 +1,274 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	cnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ipamclaimapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// The IPs of an IPAMClaim are reserved in the logical switch manager of its
+// secondary network from the time they are allocated for the first pod
+// referencing the IPAMClaim, or the IPAMClaim is added on startup, until the
+// IPAMClaim is deleted. The reserved IPs are not released with the pods, the
+// next pods referencing the IPAMClaim get the same IPs, on any node.
+//
+// The IPs of the cluster network and of the layer3 secondary networks come from
+// the subnet of the node of a pod and are only routed to that node, so they
+// cannot follow the pods of an IPAMClaim: the IPAMClaims of these networks are
+// rejected, with a warning event on the IPAMClaim and on the pods referencing
+// it.
+
+// WatchIPAMClaims starts the watching of the IPAMClaims, which reserves the
+// IPs of the existing IPAMClaims and releases the IPs of the deleted ones
+func (oc *Controller) WatchIPAMClaims() {
+	oc.watchFactory.AddIPAMClaimHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ipamClaim := obj.(*ipamclaimapi.IPAMClaim)
+			if err := oc.getIPAMClaimNetworkError(ipamClaim.Spec.Network); err != nil {
+				klog.Errorf("IPAMClaim %s/%s: %v", ipamClaim.Namespace, ipamClaim.Name, err)
+				ipamClaimRef := kapi.ObjectReference{
+					Kind:      "IPAMClaim",
+					Namespace: ipamClaim.Namespace,
+					Name:      ipamClaim.Name,
+				}
+				oc.recorder.Eventf(&ipamClaimRef, kapi.EventTypeWarning, "UnsupportedNetwork", err.Error())
+				return
+			}
+			oc.reserveIPAMClaimIPs(ipamClaim)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			ipamClaim := new.(*ipamclaimapi.IPAMClaim)
+			oc.reserveIPAMClaimIPs(ipamClaim)
+		},
+		DeleteFunc: func(obj interface{}) {
+			ipamClaim := obj.(*ipamclaimapi.IPAMClaim)
+			oc.deleteIPAMClaim(ipamClaim)
+		},
+	}, nil)
+}
+
+// ipamClaimTopologyError returns the error of the IPAMClaims of a network of the
+// given topology, nil if the topology supports them
+func ipamClaimTopologyError(network, topology string) error {
+	if topology != types.Layer3Topology {
+		return nil
+	}
+	return fmt.Errorf("IPAMClaims are not supported on network %s: its IPs come from the subnets "+
+		"of the nodes and cannot follow the pods to other nodes, only the layer2 and localnet "+
+		"secondary networks support them", network)
+}
+
+// getIPAMClaimNetworkError returns the error of the IPAMClaims of a network, the
+// cluster network or a layer3 secondary network, nil if the network supports
+// them or does not exist yet
+func (oc *Controller) getIPAMClaimNetworkError(network string) error {
+	if network == util.OvnPodDefaultNetwork {
+		return ipamClaimTopologyError(network, types.Layer3Topology)
+	}
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	if sn, ok := oc.secondaryNetworks[network]; ok {
+		return ipamClaimTopologyError(network, sn.Topology)
+	}
+	return nil
+}
+
+// getIPAMClaimIPs returns the IPs allocated for an IPAMClaim
+func getIPAMClaimIPs(ipamClaim *ipamclaimapi.IPAMClaim) ([]*net.IPNet, error) {
+	ipNets := make([]*net.IPNet, 0, len(ipamClaim.Status.IPs))
+	for _, ipStr := range ipamClaim.Status.IPs {
+		ip, ipNet, err := net.ParseCIDR(ipStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse IP %q of IPAMClaim %s/%s: %v",
+				ipStr, ipamClaim.Namespace, ipamClaim.Name, err)
+		}
+		ipNet.IP = ip
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets, nil
+}
+
+func ipNetsIPs(ipNets []*net.IPNet) []net.IP {
+	ips := make([]net.IP, 0, len(ipNets))
+	for _, ipNet := range ipNets {
+		ips = append(ips, ipNet.IP)
+	}
+	return ips
+}
+
+// getNetworkIPAMClaimIPs returns the IPs of all the IPAMClaims of a network
+func (oc *Controller) getNetworkIPAMClaimIPs(network string) []net.IP {
+	if !config.OVNKubernetesFeature.EnablePersistentIPs {
+		return nil
+	}
+	ipamClaims, err := oc.watchFactory.GetIPAMClaims()
+	if err != nil {
+		klog.Errorf("Failed to get the IPAMClaims of network %s: %v", network, err)
+		return nil
+	}
+	ips := []net.IP{}
+	for _, ipamClaim := range ipamClaims {
+		if ipamClaim.Spec.Network != network {
+			continue
+		}
+		ipNets, err := getIPAMClaimIPs(ipamClaim)
+		if err != nil {
+			klog.Error(err)
+			continue
+		}
+		ips = append(ips, ipNetsIPs(ipNets)...)
+	}
+	return ips
+}
+
+// withIPAMClaimLSManager calls f with the logical switch manager of the secondary
+// network of an IPAMClaim, unless the network does not exist yet or does not
+// support IPAMClaims; the IPs of the IPAMClaims of a secondary network are
+// reserved when it is created
+func (oc *Controller) withIPAMClaimLSManager(ipamClaim *ipamclaimapi.IPAMClaim, f func(*logicalSwitchManager)) {
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	if sn, ok := oc.secondaryNetworks[ipamClaim.Spec.Network]; ok && ipamClaimTopologyError(sn.NetName, sn.Topology) == nil {
+		f(sn.lsManager)
+	}
+}
+
+// reserveIPAMClaimIPs reserves the IPs allocated for an IPAMClaim
+func (oc *Controller) reserveIPAMClaimIPs(ipamClaim *ipamclaimapi.IPAMClaim) {
+	ipNets, err := getIPAMClaimIPs(ipamClaim)
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	if len(ipNets) == 0 {
+		return
+	}
+	oc.withIPAMClaimLSManager(ipamClaim, func(lsManager *logicalSwitchManager) {
+		lsManager.ReserveIPs(ipNetsIPs(ipNets))
+	})
+}
+
+// deleteIPAMClaim stops reserving the IPs of a deleted IPAMClaim. The IPs are
+// released now, or with the pods still referencing the IPAMClaim.
+func (oc *Controller) deleteIPAMClaim(ipamClaim *ipamclaimapi.IPAMClaim) {
+	ipNets, err := getIPAMClaimIPs(ipamClaim)
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	if len(ipNets) == 0 {
+		return
+	}
+	release := true
+	pods, err := oc.watchFactory.GetPods(ipamClaim.Namespace)
+	if err != nil {
+		klog.Errorf("Failed to get the pods of IPAMClaim %s/%s: %v", ipamClaim.Namespace, ipamClaim.Name, err)
+		return
+	}
+	for _, pod := range pods {
+		if podScheduled(pod) && util.PodWantsNetwork(pod) && podReferencesIPAMClaim(pod, ipamClaim) {
+			release = false
+			break
+		}
+	}
+	klog.Infof("Deleting IPAMClaim %s/%s of IPs %s, released: %v", ipamClaim.Namespace, ipamClaim.Name,
+		util.JoinIPNetIPs(ipNets, " "), release)
+	oc.withIPAMClaimLSManager(ipamClaim, func(lsManager *logicalSwitchManager) {
+		lsManager.UnreserveIPs(ipNetsIPs(ipNets), release)
+	})
+}
+
+// podReferencesIPAMClaim returns whether one of the network selections of a pod
+// references an IPAMClaim
+func podReferencesIPAMClaim(pod *kapi.Pod, ipamClaim *ipamclaimapi.IPAMClaim) bool {
+	networks, err := util.GetPodNetSelAnnotation(pod, util.NetworkAttachmentAnnotation)
+	if err != nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.IPAMClaimReference == ipamClaim.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDefaultNetworkIPAMClaim returns an error if the default network selection
+// of a pod references an IPAMClaim, which the cluster network does not support
+func checkDefaultNetworkIPAMClaim(pod *kapi.Pod, network *cnitypes.NetworkSelectionElement) error {
+	if network.IPAMClaimReference == "" || !config.OVNKubernetesFeature.EnablePersistentIPs {
+		return nil
+	}
+	return fmt.Errorf("pod %s/%s references IPAMClaim %s: %v", pod.Namespace, pod.Name,
+		network.IPAMClaimReference, ipamClaimTopologyError(util.OvnPodDefaultNetwork, types.Layer3Topology))
+}
+
+// getPodIPAMClaim returns the IPAMClaim of the namespace of a pod referenced by
+// its selection of a secondary network of the given topology, nil if it
+// references none
+func (oc *Controller) getPodIPAMClaim(pod *kapi.Pod, name, network, topology string) (*ipamclaimapi.IPAMClaim, error) {
+	if name == "" || !config.OVNKubernetesFeature.EnablePersistentIPs {
+		return nil, nil
+	}
+	if err := ipamClaimTopologyError(network, topology); err != nil {
+		return nil, fmt.Errorf("pod %s/%s references IPAMClaim %s: %v", pod.Namespace, pod.Name, name, err)
+	}
+	ipamClaim, err := oc.watchFactory.GetIPAMClaim(pod.Namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IPAMClaim %s of pod %s/%s: %v", name, pod.Namespace, pod.Name, err)
+	}
+	if ipamClaim.Spec.Network != network {
+		return nil, fmt.Errorf("IPAMClaim %s/%s of pod %s is for network %s, not %s",
+			pod.Namespace, name, pod.Name, ipamClaim.Spec.Network, network)
+	}
+	return ipamClaim, nil
+}
+
+// getClaimedPodIPs returns the IPs of an IPAMClaim for a pod on a logical switch,
+// and ensures they are reserved
+func getClaimedPodIPs(lsManager *logicalSwitchManager, switchName string, ipamClaim *ipamclaimapi.IPAMClaim) ([]*net.IPNet, error) {
+	ipNets, err := getIPAMClaimIPs(ipamClaim)
+	if err != nil {
+		return nil, err
+	}
+	subnets := lsManager.GetSwitchSubnets(switchName)
+	for _, ipNet := range ipNets {
+		found := false
+		for _, subnet := range subnets {
+			if subnet.Contains(ipNet.IP) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("IP %s of IPAMClaim %s/%s is not in the subnets %s of logical switch %s",
+				ipNet.IP, ipamClaim.Namespace, ipamClaim.Name, util.JoinIPNets(subnets, ","), switchName)
+		}
+	}
+	lsManager.ReserveIPs(ipNetsIPs(ipNets))
+	return ipNets, nil
+}
+
+// setIPAMClaimIPs records the IPs allocated for the first pod referencing an
+// IPAMClaim in its status, and reserves them
+func (oc *Controller) setIPAMClaimIPs(lsManager *logicalSwitchManager, ipamClaim *ipamclaimapi.IPAMClaim, ipNets []*net.IPNet) error {
+	ipamClaim = ipamClaim.DeepCopy()
+	ipamClaim.Status.IPs = make([]string, 0, len(ipNets))
+	for _, ipNet := range ipNets {
+		ipamClaim.Status.IPs = append(ipamClaim.Status.IPs, ipNet.String())
+	}
+	if err := oc.kube.UpdateIPAMClaimStatus(ipamClaim); err != nil {
+		return err
+	}
+	lsManager.ReserveIPs(ipNetsIPs(ipNets))
+	return nil
+}
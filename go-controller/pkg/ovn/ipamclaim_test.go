@@ -0,0 +1,187 @@
+package ovn
+
+import (
+	"context"
+	"net"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ipamclaimv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/ipallocator"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/urfave/cli/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN IPAMClaim Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewLooseCompareFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("keeps the IPs of a claim on a layer2 network across the recreation of its pod on other nodes until the claim is deleted", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			ipamClaim := ipamclaimv1.IPAMClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: namespaceT.Name},
+				Spec:       ipamclaimv1.IPAMClaimSpec{Network: "green"},
+			}
+			nad := *newNetworkAttachmentDefinition(namespaceT.Name, "green",
+				`{"cniVersion": "0.4.0", "name": "green", "type": "ovn-k8s-cni-overlay", "topology": "layer2", `+
+					`"subnets": "10.100.0.0/24", "netAttachDefName": "namespace1/green"}`)
+			newClaimPod := func(node, podIP string) *v1.Pod {
+				return newSecondaryNetworkPod(namespaceT.Name, "db-0", node, podIP,
+					`[{"name": "green", "ipam-claim-reference": "db-0"}]`)
+			}
+			claimedIP := ovntest.MustParseIPNet("10.100.0.3/24")
+			const lspCmd string = "ovn-nbctl --timeout=15 --may-exist lsp-add green_ovn_layer2_switch green_namespace1.green_namespace1_db-0 " +
+				"-- lsp-set-addresses green_namespace1.green_namespace1_db-0 0a:58:0a:64:00:03 10.100.0.3 " +
+				"-- lsp-set-port-security green_namespace1.green_namespace1_db-0 0a:58:0a:64:00:03 10.100.0.3 " +
+				"-- set logical_switch_port green_namespace1.green_namespace1_db-0 external_ids:namespace=namespace1 " +
+				"external_ids:network=green external_ids:nad=\"namespace1/green\""
+			podIPs := func() []*net.IPNet {
+				pod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Get(context.TODO(), "db-0", metav1.GetOptions{})
+				if err != nil {
+					return nil
+				}
+				podInfo, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, "namespace1/green")
+				if err != nil {
+					return nil
+				}
+				return podInfo.IPs
+			}
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch_port",
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch",
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_router",
+				"ovn-nbctl --timeout=15 --may-exist ls-add green_ovn_layer2_switch " +
+					"-- set logical_switch green_ovn_layer2_switch external_ids:network=green",
+			})
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&ipamclaimv1.IPAMClaimList{Items: []ipamclaimv1.IPAMClaim{ipamClaim}},
+				&nadapi.NetworkAttachmentDefinitionList{Items: []nadapi.NetworkAttachmentDefinition{nad}},
+			)
+			fakeOvn.controller.WatchIPAMClaims()
+			fakeOvn.controller.WatchNetworkAttachmentDefinitions()
+			fakeOvn.controller.WatchSecondaryNetworkPods()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// the IPs allocated for the first pod are recorded in the claim
+			fExec.AddFakeCmdsNoOutputNoError([]string{lspCmd})
+			_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Create(context.TODO(), newClaimPod("node1", "10.128.1.3"), metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(podIPs, 2).Should(Equal([]*net.IPNet{claimedIP}))
+			Eventually(func() []string {
+				claim, err := fakeOvn.fakeClient.IPAMClaimClient.K8sV1().IPAMClaims(namespaceT.Name).Get(context.TODO(), "db-0", metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return claim.Status.IPs
+			}, 2).Should(Equal([]string{claimedIP.String()}))
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// the IPs are not released with the pod
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists lsp-del green_namespace1.green_namespace1_db-0",
+			})
+			err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Delete(context.TODO(), "db-0", *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			sn := fakeOvn.controller.secondaryNetworks["green"]
+			err = sn.lsManager.AllocateIPs(sn.switchName(""), []*net.IPNet{claimedIP})
+			Expect(err).To(Equal(ipallocator.ErrAllocated))
+
+			// and the pod recreated on another node gets them back
+			fExec.AddFakeCmdsNoOutputNoError([]string{lspCmd})
+			_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Create(context.TODO(), newClaimPod("node2", "10.128.2.3"), metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(podIPs, 2).Should(Equal([]*net.IPNet{claimedIP}))
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// the IPs are released with the pod once the claim is deleted
+			err = fakeOvn.fakeClient.IPAMClaimClient.K8sV1().IPAMClaims(namespaceT.Name).Delete(context.TODO(), "db-0", metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Consistently(func() error {
+				return sn.lsManager.AllocateIPs(sn.switchName(""), []*net.IPNet{claimedIP})
+			}, 1).Should(Equal(ipallocator.ErrAllocated))
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists lsp-del green_namespace1.green_namespace1_db-0",
+			})
+			err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Delete(context.TODO(), "db-0", *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() error {
+				return sn.lsManager.AllocateIPs(sn.switchName(""), []*net.IPNet{claimedIP})
+			}, 2).Should(Succeed())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-persistent-ips", "--enable-multi-network"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects the claims of the cluster network and fails the pods referencing them", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			ipamClaim := ipamclaimv1.IPAMClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: namespaceT.Name},
+				Spec:       ipamclaimv1.IPAMClaimSpec{Network: util.OvnPodDefaultNetwork},
+			}
+			pod := newPod(namespaceT.Name, "db-0", "node1", "")
+			pod.Annotations = map[string]string{
+				util.DefNetworkAnnotation: `[{"name":"default","namespace":"ovn-kubernetes","ipam-claim-reference":"db-0"}]`,
+			}
+
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch_port external_ids:pod=true",
+				Output: "\n",
+			})
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&ipamclaimv1.IPAMClaimList{Items: []ipamclaimv1.IPAMClaim{ipamClaim}},
+				&v1.PodList{Items: []v1.Pod{*pod}},
+			)
+			err := fakeOvn.controller.lsManager.AddNode("node1", ovntest.MustParseIPNets("10.128.1.0/24"))
+			Expect(err).NotTo(HaveOccurred())
+			fakeOvn.controller.WatchNamespaces()
+			fakeOvn.controller.WatchIPAMClaims()
+			Eventually(fakeOvn.fakeRecorder.Events).Should(Receive(HavePrefix("Warning UnsupportedNetwork")))
+
+			fakeOvn.controller.WatchPods()
+			Eventually(fakeOvn.fakeRecorder.Events).Should(Receive(And(
+				HavePrefix("Warning ErrorAddingLogicalPort"),
+				ContainSubstring("IPAMClaims are not supported on network default"))))
+			Consistently(func() string {
+				return getPodAnnotations(fakeOvn.fakeClient.KubeClient, namespaceT.Name, "db-0")
+			}, 1).Should(BeEmpty())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-persistent-ips"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,682 @@
+package loadbalancer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+// Addr is an IP and port, used as the VIP or as a backend of a load balancer rule
+type Addr struct {
+	IP   string
+	Port int32
+}
+
+// String returns the address in the format used by the OVN load balancer vips
+func (a Addr) String() string {
+	return util.JoinHostPortInt32(a.IP, a.Port)
+}
+
+// LBRule load balances the traffic sent to Source among the Targets
+type LBRule struct {
+	Source  Addr
+	Targets []Addr
+}
+
+// LBOpts are the options of a load balancer
+type LBOpts struct {
+	// Reject rejects the traffic sent to a VIP without backends
+	Reject bool
+	// Event emits an empty_lb_backends controller event for the traffic sent to a
+	// VIP without backends
+	Event bool
+	// SkipSNAT skips the SNAT of the load balanced traffic on gateway routers
+	SkipSNAT bool
+	// AffinityTimeout is the client IP session affinity timeout in seconds, 0 to
+	// disable session affinity
+	AffinityTimeout int32
+}
+
+// HealthCheck configures the OVN health checks of the backends of the VIPs of a
+// load balancer. Zero values use the OVN defaults.
+type HealthCheck struct {
+	// Interval is the time in seconds between two checks of a backend
+	Interval int32
+	// Timeout is the time in seconds after which a check fails
+	Timeout int32
+	// SuccessCount is the number of successful checks after which a backend is online
+	SuccessCount int32
+	// FailureCount is the number of failed checks after which a backend is offline
+	FailureCount int32
+}
+
+// options returns the options column of the Load_Balancer_Health_Check rows
+func (hc *HealthCheck) options() map[string]string {
+	options := map[string]string{}
+	for k, v := range map[string]int32{
+		"interval":      hc.Interval,
+		"timeout":       hc.Timeout,
+		"success_count": hc.SuccessCount,
+		"failure_count": hc.FailureCount,
+	} {
+		if v > 0 {
+			options[k] = strconv.Itoa(int(v))
+		}
+	}
+	return options
+}
+
+// PortMapping is the logical port of a backend, and the source IP used to check it
+type PortMapping struct {
+	LogicalPort string
+	SourceIP    string
+}
+
+// LB is the desired state of an OVN Load_Balancer row
+type LB struct {
+	// UUID is only set for the load balancers returned by Manager.Find
+	UUID        string
+	Name        string
+	Protocol    string
+	ExternalIDs map[string]string
+	Opts        LBOpts
+	Rules       []LBRule
+
+	// HealthCheck, if set, checks the backends of all the VIPs of the load
+	// balancer. Only the backends with a port mapping in PortMappings, keyed by
+	// backend IP, are checked.
+	HealthCheck  *HealthCheck
+	PortMappings map[string]PortMapping
+
+	// Switches and Routers are the names of the logical switches and routers
+	// the load balancer is applied to
+	Switches []string
+	Routers  []string
+}
+
+// vips returns the vips column of the load balancer
+func (lb *LB) vips() map[string]string {
+	vips := make(map[string]string, len(lb.Rules))
+	for _, rule := range lb.Rules {
+		targets := make([]string, 0, len(rule.Targets))
+		for _, target := range rule.Targets {
+			targets = append(targets, target.String())
+		}
+		vips[rule.Source.String()] = strings.Join(targets, ",")
+	}
+	return vips
+}
+
+// options returns the options column of the load balancer
+func (lb *LB) options() map[string]string {
+	options := map[string]string{}
+	if lb.Opts.Reject {
+		options["reject"] = "true"
+	}
+	if lb.Opts.Event {
+		options["event"] = "true"
+	}
+	if lb.Opts.SkipSNAT {
+		options["skip_snat"] = "true"
+	}
+	if lb.Opts.AffinityTimeout > 0 {
+		options["affinity_timeout"] = strconv.Itoa(int(lb.Opts.AffinityTimeout))
+	}
+	return options
+}
+
+// selectionFields returns the selection_fields column of the load balancer
+func (lb *LB) selectionFields() string {
+	if lb.Opts.AffinityTimeout > 0 {
+		return "ip_src"
+	}
+	return ""
+}
+
+// healthChecks returns the options of the health checks of the load balancer,
+// keyed by VIP
+func (lb *LB) healthChecks() map[string]map[string]string {
+	healthChecks := map[string]map[string]string{}
+	if lb.HealthCheck == nil {
+		return healthChecks
+	}
+	for _, rule := range lb.Rules {
+		healthChecks[rule.Source.String()] = lb.HealthCheck.options()
+	}
+	return healthChecks
+}
+
+// ipPortMappings returns the ip_port_mappings column of the load balancer
+func (lb *LB) ipPortMappings() map[string]string {
+	mappings := make(map[string]string, len(lb.PortMappings))
+	for ip, mapping := range lb.PortMappings {
+		mappings[bracketIPv6(ip)] = mapping.LogicalPort + ":" + bracketIPv6(mapping.SourceIP)
+	}
+	return mappings
+}
+
+// bracketIPv6 encloses IPv6 addresses in brackets, as expected by OVN in the
+// ip_port_mappings column
+func bracketIPv6(ip string) string {
+	if utilnet.IsIPv6String(ip) {
+		return "[" + ip + "]"
+	}
+	return ip
+}
+
+// cachedLB is the state of a Load_Balancer row in the northbound database
+type cachedLB struct {
+	uuid            string
+	name            string
+	protocol        string
+	externalIDs     map[string]string
+	vips            map[string]string
+	options         map[string]string
+	selectionFields string
+	ipPortMappings  map[string]string
+	// healthChecks are the options of the health checks, keyed by VIP
+	healthChecks map[string]map[string]string
+	switches     sets.String
+	routers      sets.String
+}
+
+// Manager programs OVN load balancers from their desired state. It caches the
+// load balancers of the northbound database, along with the logical switches and
+// routers they are applied to, so that only the differences between the desired
+// and the current state are written to the database.
+type Manager struct {
+	sync.Mutex
+	synced bool
+	lbs    map[string]*cachedLB
+}
+
+// NewManager returns a new load balancer manager. Its cache is populated from the
+// northbound database by Sync, or on first use.
+func NewManager() *Manager {
+	return &Manager{lbs: make(map[string]*cachedLB)}
+}
+
+// Sync (re)populates the cache of the manager from the northbound database
+func (m *Manager) Sync() error {
+	m.Lock()
+	defer m.Unlock()
+	return m.sync()
+}
+
+func (m *Manager) sync() error {
+	lbs := make(map[string]*cachedLB)
+	records, err := listRecords("_uuid,name,protocol,external_ids,vips,options,selection_fields,ip_port_mappings,health_check",
+		"load_balancer")
+	if err != nil {
+		return err
+	}
+	lbHealthChecks := make(map[string][]string)
+	for _, record := range records {
+		if len(record) != 9 {
+			return fmt.Errorf("unexpected load balancer record %v", record)
+		}
+		lbs[record[0]] = &cachedLB{
+			uuid:            record[0],
+			name:            record[1],
+			protocol:        record[2],
+			externalIDs:     parseMap(record[3]),
+			vips:            parseMap(record[4]),
+			options:         parseMap(record[5]),
+			selectionFields: record[6],
+			ipPortMappings:  parseMap(record[7]),
+			healthChecks:    map[string]map[string]string{},
+			switches:        sets.NewString(),
+			routers:         sets.NewString(),
+		}
+		if healthChecks := strings.Fields(record[8]); len(healthChecks) > 0 {
+			lbHealthChecks[record[0]] = healthChecks
+		}
+	}
+	// the health checks are only listed when in use
+	if len(lbHealthChecks) > 0 {
+		records, err := listRecords("_uuid,vip,options", "load_balancer_health_check")
+		if err != nil {
+			return err
+		}
+		healthChecks := make(map[string][]string)
+		for _, record := range records {
+			if len(record) != 3 {
+				return fmt.Errorf("unexpected load balancer health check record %v", record)
+			}
+			healthChecks[record[0]] = record[1:]
+		}
+		for uuid, hcUUIDs := range lbHealthChecks {
+			for _, hcUUID := range hcUUIDs {
+				if hc, ok := healthChecks[hcUUID]; ok {
+					lbs[uuid].healthChecks[hc[0]] = parseMap(hc[1])
+				}
+			}
+		}
+	}
+	for _, table := range []string{"logical_switch", "logical_router"} {
+		records, err := listRecords("name,load_balancer", table)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if len(record) != 2 {
+				return fmt.Errorf("unexpected %s record %v", table, record)
+			}
+			for _, uuid := range strings.Fields(record[1]) {
+				lb, ok := lbs[uuid]
+				if !ok {
+					continue
+				}
+				if table == "logical_switch" {
+					lb.switches.Insert(record[0])
+				} else {
+					lb.routers.Insert(record[0])
+				}
+			}
+		}
+	}
+	m.lbs = lbs
+	m.synced = true
+	return nil
+}
+
+// Find returns the load balancers having all the given external IDs, with their
+// UUID, name, protocol, external IDs, switches and routers set
+func (m *Manager) Find(externalIDs map[string]string) ([]*LB, error) {
+	m.Lock()
+	defer m.Unlock()
+	if !m.synced {
+		if err := m.sync(); err != nil {
+			return nil, err
+		}
+	}
+	lbs := []*LB{}
+	for _, cached := range m.find(externalIDs) {
+		lbs = append(lbs, &LB{
+			UUID:        cached.uuid,
+			Name:        cached.name,
+			Protocol:    cached.protocol,
+			ExternalIDs: copyMap(cached.externalIDs),
+			Switches:    cached.switches.List(),
+			Routers:     cached.routers.List(),
+		})
+	}
+	return lbs, nil
+}
+
+// find returns the cached load balancers having all the given external IDs,
+// sorted by name and UUID
+func (m *Manager) find(externalIDs map[string]string) []*cachedLB {
+	found := []*cachedLB{}
+	for _, lb := range m.lbs {
+		matches := true
+		for k, v := range externalIDs {
+			if lb.externalIDs[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, lb)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].name != found[j].name {
+			return found[i].name < found[j].name
+		}
+		return found[i].uuid < found[j].uuid
+	})
+	return found
+}
+
+// LBGroup is a set of load balancers owned by the same object, all of them having
+// the external IDs of the owner
+type LBGroup struct {
+	ExternalIDs map[string]string
+	LBs         []LB
+}
+
+// lbTransaction is a northbound transaction programming load balancers
+type lbTransaction struct {
+	args []string
+	// created are the rows created by the transaction, in the order their UUIDs are
+	// output: the load balancers, and nil for their health checks
+	created       []*cachedLB
+	nCreated      int
+	nHealthChecks int
+	// updated are the desired states of the updated load balancers, keyed by their
+	// cached state, and stale the deleted load balancers
+	updated map[*cachedLB]*cachedLB
+	stale   []*cachedLB
+}
+
+// EnsureLBs makes the given load balancers the only ones having the given external
+// IDs, which must be set on all of them. Load balancers are matched by name, those
+// that do not exist yet are created, those that differ are updated, and the
+// existing ones that are not part of lbs are deleted. All the changes are made in
+// a single northbound transaction.
+func (m *Manager) EnsureLBs(externalIDs map[string]string, lbs []LB) error {
+	return m.EnsureLBGroups([]LBGroup{{ExternalIDs: externalIDs, LBs: lbs}})
+}
+
+// EnsureLBGroups ensures the load balancers of each group like EnsureLBs, the
+// changes of all the groups being made in a single northbound transaction. The
+// external IDs of a group must not match the load balancers of another group.
+func (m *Manager) EnsureLBGroups(groups []LBGroup) error {
+	m.Lock()
+	defer m.Unlock()
+	if !m.synced {
+		if err := m.sync(); err != nil {
+			return err
+		}
+	}
+
+	txn := &lbTransaction{updated: make(map[*cachedLB]*cachedLB)}
+	owners := make([]map[string]string, 0, len(groups))
+	for _, group := range groups {
+		if err := m.addGroup(txn, group); err != nil {
+			return err
+		}
+		owners = append(owners, group.ExternalIDs)
+	}
+	if len(txn.args) == 0 {
+		return nil
+	}
+
+	stdout, stderr, err := util.RunOVNNbctl(txn.args...)
+	if err != nil {
+		return fmt.Errorf("failed to ensure load balancers with external IDs %v, stderr: %q, error: %v",
+			owners, stderr, err)
+	}
+	uuids := strings.Fields(stdout)
+	if len(uuids) != len(txn.created) {
+		// the database was changed, but we cannot tell how: start over from it
+		m.synced = false
+		return fmt.Errorf("unexpected output %q creating %d load balancers and %d health checks",
+			stdout, txn.nCreated, txn.nHealthChecks)
+	}
+	for i, lb := range txn.created {
+		if lb == nil {
+			continue
+		}
+		lb.uuid = uuids[i]
+		m.lbs[lb.uuid] = lb
+	}
+	for cur, desired := range txn.updated {
+		*cur = *desired
+	}
+	for _, lb := range txn.stale {
+		delete(m.lbs, lb.uuid)
+	}
+	klog.V(5).Infof("Ensured load balancers with external IDs %v: %d created, %d updated, %d deleted",
+		owners, txn.nCreated, len(txn.updated), len(txn.stale))
+	return nil
+}
+
+// addGroup adds the changes ensuring the load balancers of a group to a transaction
+func (m *Manager) addGroup(txn *lbTransaction, group LBGroup) error {
+	existing := make(map[string]*cachedLB)
+	stale := []*cachedLB{}
+	for _, lb := range m.find(group.ExternalIDs) {
+		if _, ok := existing[lb.name]; ok {
+			// duplicate load balancers are deleted
+			stale = append(stale, lb)
+			continue
+		}
+		existing[lb.name] = lb
+	}
+
+	sorted := make([]LB, len(group.LBs))
+	copy(sorted, group.LBs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	// createHealthChecks adds the creation of the health checks of a load balancer
+	// to the transaction, and returns its health_check column
+	createHealthChecks := func(lb *cachedLB) string {
+		ids := []string{}
+		for _, vip := range sortedVIPs(lb.healthChecks) {
+			id := fmt.Sprintf("@hc%d", txn.nHealthChecks)
+			txn.nHealthChecks++
+			txn.args = append(txn.args, "--", "--id="+id, "create", "load_balancer_health_check",
+				fmt.Sprintf("vip=%q", vip), "options="+formatMap(lb.healthChecks[vip]))
+			ids = append(ids, id)
+			txn.created = append(txn.created, nil)
+		}
+		return "[" + strings.Join(ids, ",") + "]"
+	}
+	for i := range sorted {
+		lb := &sorted[i]
+		for k, v := range group.ExternalIDs {
+			if lb.ExternalIDs[k] != v {
+				return fmt.Errorf("load balancer %s lacks external ID %s=%s", lb.Name, k, v)
+			}
+		}
+		desired := &cachedLB{
+			name:            lb.Name,
+			protocol:        lb.Protocol,
+			externalIDs:     copyMap(lb.ExternalIDs),
+			vips:            lb.vips(),
+			options:         lb.options(),
+			selectionFields: lb.selectionFields(),
+			ipPortMappings:  lb.ipPortMappings(),
+			healthChecks:    lb.healthChecks(),
+			switches:        sets.NewString(lb.Switches...),
+			routers:         sets.NewString(lb.Routers...),
+		}
+
+		cur, ok := existing[lb.Name]
+		if !ok || cur.protocol != desired.protocol || !reflect.DeepEqual(cur.externalIDs, desired.externalIDs) {
+			if ok {
+				stale = append(stale, cur)
+				delete(existing, lb.Name)
+			}
+			healthCheck := ""
+			if len(desired.healthChecks) > 0 {
+				healthCheck = createHealthChecks(desired)
+			}
+			id := fmt.Sprintf("@lb%d", txn.nCreated)
+			txn.nCreated++
+			args := []string{"--", "--id=" + id, "create", "load_balancer",
+				"name=" + desired.name, "protocol=" + desired.protocol}
+			for _, k := range sortedKeys(desired.externalIDs) {
+				args = append(args, fmt.Sprintf("external_ids:%s=%q", k, desired.externalIDs[k]))
+			}
+			args = append(args, "vips="+formatMap(desired.vips), "options="+formatMap(desired.options),
+				"selection_fields="+formatSet(desired.selectionFields))
+			if len(desired.ipPortMappings) > 0 {
+				args = append(args, "ip_port_mappings="+formatMap(desired.ipPortMappings))
+			}
+			if healthCheck != "" {
+				args = append(args, "health_check="+healthCheck)
+			}
+			for _, sw := range desired.switches.List() {
+				args = append(args, "--", "add", "logical_switch", sw, "load_balancer", id)
+			}
+			for _, router := range desired.routers.List() {
+				args = append(args, "--", "add", "logical_router", router, "load_balancer", id)
+			}
+			txn.args = append(txn.args, args...)
+			txn.created = append(txn.created, desired)
+			continue
+		}
+		delete(existing, lb.Name)
+
+		setArgs := []string{}
+		if !reflect.DeepEqual(cur.vips, desired.vips) || !reflect.DeepEqual(cur.options, desired.options) ||
+			cur.selectionFields != desired.selectionFields {
+			setArgs = append(setArgs, "vips="+formatMap(desired.vips), "options="+formatMap(desired.options),
+				"selection_fields="+formatSet(desired.selectionFields))
+		}
+		if !reflect.DeepEqual(cur.ipPortMappings, desired.ipPortMappings) ||
+			!reflect.DeepEqual(cur.healthChecks, desired.healthChecks) {
+			// the health checks are recreated, the replaced ones being garbage
+			// collected by the database
+			setArgs = append(setArgs, "ip_port_mappings="+formatMap(desired.ipPortMappings),
+				"health_check="+createHealthChecks(desired))
+		}
+		if len(setArgs) > 0 {
+			txn.args = append(txn.args, "--", "set", "load_balancer", cur.uuid)
+			txn.args = append(txn.args, setArgs...)
+		}
+		for _, sw := range desired.switches.Difference(cur.switches).List() {
+			txn.args = append(txn.args, "--", "add", "logical_switch", sw, "load_balancer", cur.uuid)
+		}
+		for _, sw := range cur.switches.Difference(desired.switches).List() {
+			txn.args = append(txn.args, "--", "--if-exists", "remove", "logical_switch", sw, "load_balancer", cur.uuid)
+		}
+		for _, router := range desired.routers.Difference(cur.routers).List() {
+			txn.args = append(txn.args, "--", "add", "logical_router", router, "load_balancer", cur.uuid)
+		}
+		for _, router := range cur.routers.Difference(desired.routers).List() {
+			txn.args = append(txn.args, "--", "--if-exists", "remove", "logical_router", router, "load_balancer", cur.uuid)
+		}
+		desired.uuid = cur.uuid
+		txn.updated[cur] = desired
+	}
+	for _, lb := range existing {
+		stale = append(stale, lb)
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].uuid < stale[j].uuid })
+	for _, lb := range stale {
+		txn.args = append(txn.args, "--", "--if-exists", "lb-del", lb.uuid)
+	}
+	txn.stale = append(txn.stale, stale...)
+	return nil
+}
+
+// DeleteLBs deletes the load balancers with the given UUIDs
+func (m *Manager) DeleteLBs(uuids []string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+	m.Lock()
+	defer m.Unlock()
+	args := []string{}
+	for _, uuid := range uuids {
+		args = append(args, "--", "--if-exists", "lb-del", uuid)
+	}
+	_, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete load balancers %v, stderr: %q, error: %v", uuids, stderr, err)
+	}
+	for _, uuid := range uuids {
+		delete(m.lbs, uuid)
+	}
+	return nil
+}
+
+// DeleteSwitch forgets a logical switch deleted from the northbound database
+func (m *Manager) DeleteSwitch(name string) {
+	m.Lock()
+	defer m.Unlock()
+	for _, lb := range m.lbs {
+		lb.switches.Delete(name)
+	}
+}
+
+// DeleteRouter forgets a logical router deleted from the northbound database
+func (m *Manager) DeleteRouter(name string) {
+	m.Lock()
+	defer m.Unlock()
+	for _, lb := range m.lbs {
+		lb.routers.Delete(name)
+	}
+}
+
+// listRecords lists the given columns of all the rows of a northbound table
+func listRecords(columns, table string) ([][]string, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns="+columns, "list", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s, stderr: %q, error: %v", table, stderr, err)
+	}
+	if stdout == "" {
+		return nil, nil
+	}
+	reader := csv.NewReader(strings.NewReader(stdout))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s records %q: %v", table, stdout, err)
+	}
+	return records, nil
+}
+
+// parseMap parses a map column printed with --data=bare
+func parseMap(s string) map[string]string {
+	m := map[string]string{}
+	for _, entry := range strings.Fields(s) {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+// formatMap formats a map column value for ovn-nbctl
+func formatMap(m map[string]string) string {
+	entries := make([]string, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		entries = append(entries, fmt.Sprintf("%q=%q", k, m[k]))
+	}
+	return "{" + strings.Join(entries, ",") + "}"
+}
+
+// formatSet formats a set column value of at most one element for ovn-nbctl
+func formatSet(s string) string {
+	if s == "" {
+		return "[]"
+	}
+	return s
+}
+
+func sortedVIPs(m map[string]map[string]string) []string {
+	vips := make([]string, 0, len(m))
+	for vip := range m {
+		vips = append(vips, vip)
+	}
+	sort.Strings(vips)
+	return vips
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// TargetsForFamily returns the targets listening on port whose IP is of the same
+// family as vip
+func TargetsForFamily(vip string, ips []string, port int32) []Addr {
+	isV6 := utilnet.IsIPv6String(vip)
+	targets := []Addr{}
+	for _, ip := range ips {
+		if utilnet.IsIPv6String(ip) == isV6 {
+			targets = append(targets, Addr{IP: ip, Port: port})
+		}
+	}
+	return targets
+}
@@ -0,0 +1,297 @@
+package loadbalancer
+
+import (
+	"reflect"
+	"testing"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+)
+
+const (
+	listLBsCmd      = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,name,protocol,external_ids,vips,options,selection_fields,ip_port_mappings,health_check list load_balancer"
+	listSwitchesCmd = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,load_balancer list logical_switch"
+	listRoutersCmd  = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,load_balancer list logical_router"
+	listHCsCmd      = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,vip,options list load_balancer_health_check"
+)
+
+func TestEnsureLBs(t *testing.T) {
+	ids := map[string]string{"k8s-service": "ns/svc"}
+	clusterLB := LB{
+		Name:        "Service_ns/svc_TCP_cluster",
+		Protocol:    "tcp",
+		ExternalIDs: ids,
+		Opts:        LBOpts{Reject: true},
+		Rules: []LBRule{
+			{
+				Source:  Addr{IP: "172.30.0.10", Port: 80},
+				Targets: []Addr{{IP: "10.128.0.5", Port: 8080}, {IP: "10.128.1.5", Port: 8080}},
+			},
+		},
+		Switches: []string{"node2", "node1"},
+	}
+	nodeLB := LB{
+		Name:        "Service_ns/svc_TCP_node_node1",
+		Protocol:    "tcp",
+		ExternalIDs: ids,
+		Opts:        LBOpts{Reject: true, SkipSNAT: true, AffinityTimeout: 10800},
+		Rules: []LBRule{
+			{Source: Addr{IP: "169.254.33.2", Port: 30080}},
+		},
+		Switches: []string{"node1"},
+		Routers:  []string{"GR_node1"},
+	}
+	healthCheckedLB := LB{
+		Name:        "Service_ns/svc_TCP_cluster",
+		Protocol:    "tcp",
+		ExternalIDs: ids,
+		Opts:        LBOpts{Reject: true},
+		Rules: []LBRule{
+			{
+				Source:  Addr{IP: "172.30.0.10", Port: 80},
+				Targets: []Addr{{IP: "10.128.0.5", Port: 8080}},
+			},
+		},
+		HealthCheck:  &HealthCheck{Interval: 5, FailureCount: 3},
+		PortMappings: map[string]PortMapping{"10.128.0.5": {LogicalPort: "ns_pod1", SourceIP: "10.128.0.2"}},
+		Switches:     []string{"node1"},
+	}
+
+	tests := []struct {
+		name     string
+		lbs      []LB
+		existing []ovntest.ExpectedCmd
+		want     string
+		output   string
+		wantErr  bool
+	}{
+		{
+			name: "creates the missing load balancers",
+			lbs:  []LB{nodeLB, clusterLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: ""},
+				{Cmd: listSwitchesCmd, Output: "node1,\nnode2,\n"},
+				{Cmd: listRoutersCmd, Output: "GR_node1,\n"},
+			},
+			want: "ovn-nbctl --timeout=15 " +
+				"-- --id=@lb0 create load_balancer name=Service_ns/svc_TCP_cluster protocol=tcp external_ids:k8s-service=\"ns/svc\" " +
+				"vips={\"172.30.0.10:80\"=\"10.128.0.5:8080,10.128.1.5:8080\"} options={\"reject\"=\"true\"} selection_fields=[] " +
+				"-- add logical_switch node1 load_balancer @lb0 -- add logical_switch node2 load_balancer @lb0 " +
+				"-- --id=@lb1 create load_balancer name=Service_ns/svc_TCP_node_node1 protocol=tcp external_ids:k8s-service=\"ns/svc\" " +
+				"vips={\"169.254.33.2:30080\"=\"\"} options={\"affinity_timeout\"=\"10800\",\"reject\"=\"true\",\"skip_snat\"=\"true\"} selection_fields=ip_src " +
+				"-- add logical_switch node1 load_balancer @lb1 -- add logical_router GR_node1 load_balancer @lb1",
+			output: "uuid0\nuuid1\n",
+		},
+		{
+			name: "does nothing when the load balancers are up to date",
+			lbs:  []LB{clusterLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,\"172.30.0.10:80=10.128.0.5:8080,10.128.1.5:8080\",reject=true,,,\n"},
+				{Cmd: listSwitchesCmd, Output: "node1,lb1\nnode2,lb1 lb-other\n"},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+		},
+		{
+			name: "updates the changed load balancers and deletes the stale ones",
+			lbs:  []LB{clusterLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,172.30.0.10:80=,reject=true,,,\n" +
+					"lb2,Service_ns/svc_UDP_cluster,udp,k8s-service=ns/svc,172.30.0.10:53=,reject=true,,,\n" +
+					"lb3,Service_ns/other_TCP_cluster,tcp,k8s-service=ns/other,172.30.0.11:80=,reject=true,,,\n"},
+				{Cmd: listSwitchesCmd, Output: "node1,lb1 lb2 lb3\nnode3,lb1\n"},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+			want: "ovn-nbctl --timeout=15 " +
+				"-- set load_balancer lb1 vips={\"172.30.0.10:80\"=\"10.128.0.5:8080,10.128.1.5:8080\"} options={\"reject\"=\"true\"} selection_fields=[] " +
+				"-- add logical_switch node2 load_balancer lb1 -- --if-exists remove logical_switch node3 load_balancer lb1 " +
+				"-- --if-exists lb-del lb2",
+		},
+		{
+			name: "deletes all the load balancers of the owner",
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,172.30.0.10:80=,reject=true,,,\n"},
+				{Cmd: listSwitchesCmd, Output: ""},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+			want: "ovn-nbctl --timeout=15 -- --if-exists lb-del lb1",
+		},
+		{
+			name: "creates the health checks of the load balancers",
+			lbs:  []LB{healthCheckedLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: ""},
+				{Cmd: listSwitchesCmd, Output: "node1,\n"},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+			want: "ovn-nbctl --timeout=15 " +
+				"-- --id=@hc0 create load_balancer_health_check vip=\"172.30.0.10:80\" options={\"failure_count\"=\"3\",\"interval\"=\"5\"} " +
+				"-- --id=@lb0 create load_balancer name=Service_ns/svc_TCP_cluster protocol=tcp external_ids:k8s-service=\"ns/svc\" " +
+				"vips={\"172.30.0.10:80\"=\"10.128.0.5:8080\"} options={\"reject\"=\"true\"} selection_fields=[] " +
+				"ip_port_mappings={\"10.128.0.5\"=\"ns_pod1:10.128.0.2\"} health_check=[@hc0] " +
+				"-- add logical_switch node1 load_balancer @lb0",
+			output: "hc0\nuuid0\n",
+		},
+		{
+			name: "does nothing when the health checks are up to date",
+			lbs:  []LB{healthCheckedLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,172.30.0.10:80=10.128.0.5:8080,reject=true,," +
+					"10.128.0.5=ns_pod1:10.128.0.2,hc1\n"},
+				{Cmd: listHCsCmd, Output: "hc1,172.30.0.10:80,failure_count=3 interval=5\n"},
+				{Cmd: listSwitchesCmd, Output: "node1,lb1\n"},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+		},
+		{
+			name: "removes the health checks of the load balancers",
+			lbs:  []LB{clusterLB},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,\"172.30.0.10:80=10.128.0.5:8080,10.128.1.5:8080\",reject=true,," +
+					"10.128.0.5=ns_pod1:10.128.0.2,hc1\n"},
+				{Cmd: listHCsCmd, Output: "hc1,172.30.0.10:80,failure_count=3 interval=5\n"},
+				{Cmd: listSwitchesCmd, Output: "node1,lb1\nnode2,lb1\n"},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+			want: "ovn-nbctl --timeout=15 -- set load_balancer lb1 ip_port_mappings={} health_check=[]",
+		},
+		{
+			name: "fails when a load balancer lacks the owner external IDs",
+			lbs:  []LB{{Name: "foo", Protocol: "tcp"}},
+			existing: []ovntest.ExpectedCmd{
+				{Cmd: listLBsCmd, Output: ""},
+				{Cmd: listSwitchesCmd, Output: ""},
+				{Cmd: listRoutersCmd, Output: ""},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fexec := ovntest.NewFakeExec()
+			for i := range tt.existing {
+				fexec.AddFakeCmd(&tt.existing[i])
+			}
+			if tt.want != "" {
+				fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: tt.want, Output: tt.output})
+			}
+			if err := util.SetExec(fexec); err != nil {
+				t.Fatalf("fexec error: %v", err)
+			}
+
+			m := NewManager()
+			err := m.EnsureLBs(ids, tt.lbs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnsureLBs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !fexec.CalledMatchesExpected() {
+				t.Fatalf("EnsureLBs() commands %v, want %v", fexec.ErrorDesc(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureLBsIsIdempotent(t *testing.T) {
+	ids := map[string]string{"k8s-service": "ns/svc"}
+	lbs := []LB{
+		{
+			Name:        "Service_ns/svc_UDP_cluster",
+			Protocol:    "udp",
+			ExternalIDs: ids,
+			Rules:       []LBRule{{Source: Addr{IP: "fd00::10", Port: 53}, Targets: []Addr{{IP: "fd01::5", Port: 5353}}}},
+			Switches:    []string{"node1"},
+		},
+	}
+
+	fexec := ovntest.NewFakeExec()
+	fexec.AddFakeCmdsNoOutputNoError([]string{listLBsCmd, listSwitchesCmd, listRoutersCmd})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd: "ovn-nbctl --timeout=15 " +
+			"-- --id=@lb0 create load_balancer name=Service_ns/svc_UDP_cluster protocol=udp external_ids:k8s-service=\"ns/svc\" " +
+			"vips={\"[fd00::10]:53\"=\"[fd01::5]:5353\"} options={} selection_fields=[] " +
+			"-- add logical_switch node1 load_balancer @lb0",
+		Output: "uuid0\n",
+	})
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatalf("fexec error: %v", err)
+	}
+
+	m := NewManager()
+	for i := 0; i < 2; i++ {
+		if err := m.EnsureLBs(ids, lbs); err != nil {
+			t.Fatalf("EnsureLBs() error = %v", err)
+		}
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Fatalf("EnsureLBs() commands %v", fexec.ErrorDesc())
+	}
+
+	found, err := m.Find(ids)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	want := []*LB{{UUID: "uuid0", Name: "Service_ns/svc_UDP_cluster", Protocol: "udp", ExternalIDs: ids,
+		Switches: []string{"node1"}, Routers: []string{}}}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Find() got = %+v, want %+v", found[0], want[0])
+	}
+}
+
+func TestEnsureLBGroups(t *testing.T) {
+	svcIDs := map[string]string{"k8s-service": "ns/svc"}
+	otherIDs := map[string]string{"k8s-service": "ns/other"}
+	groups := []LBGroup{
+		{
+			ExternalIDs: svcIDs,
+			LBs: []LB{{
+				Name:        "Service_ns/svc_TCP_cluster",
+				Protocol:    "tcp",
+				ExternalIDs: svcIDs,
+				Rules:       []LBRule{{Source: Addr{IP: "172.30.0.10", Port: 80}}},
+				Switches:    []string{"node1", "node2"},
+			}},
+		},
+		{
+			ExternalIDs: otherIDs,
+			LBs: []LB{{
+				Name:        "Service_ns/other_TCP_cluster",
+				Protocol:    "tcp",
+				ExternalIDs: otherIDs,
+				Rules:       []LBRule{{Source: Addr{IP: "172.30.0.11", Port: 80}}},
+				Switches:    []string{"node1", "node2"},
+			}},
+		},
+	}
+
+	fexec := ovntest.NewFakeExec()
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listLBsCmd, Output: "lb1,Service_ns/svc_TCP_cluster,tcp,k8s-service=ns/svc,172.30.0.10:80=,,,,\n" +
+		"lb2,Service_ns/other_TCP_cluster,tcp,k8s-service=ns/other,172.30.0.11:80=,,,,\n" +
+		"lb3,Service_ns/other_UDP_cluster,udp,k8s-service=ns/other,172.30.0.11:53=,,,,\n"})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listSwitchesCmd, Output: "node1,lb1 lb2 lb3\n"})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listRoutersCmd, Output: ""})
+	// the switch attachments of both groups are made in a single transaction
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 -- add logical_switch node2 load_balancer lb1 " +
+			"-- add logical_switch node2 load_balancer lb2 -- --if-exists lb-del lb3",
+	})
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatalf("fexec error: %v", err)
+	}
+
+	m := NewManager()
+	if err := m.EnsureLBGroups(groups); err != nil {
+		t.Fatalf("EnsureLBGroups() error = %v", err)
+	}
+	if !fexec.CalledMatchesExpected() {
+		t.Fatalf("EnsureLBGroups() commands %v", fexec.ErrorDesc())
+	}
+
+	found, err := m.Find(otherIDs)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	want := []*LB{{UUID: "lb2", Name: "Service_ns/other_TCP_cluster", Protocol: "tcp", ExternalIDs: otherIDs,
+		Switches: []string{"node1", "node2"}, Routers: []string{}}}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Find() got = %+v, want %+v", found, want)
+	}
+}
@@ -28,6 +28,9 @@ type ipamFactoryFunc func(*net.IPNet) (ipam.Interface, error)
 // logicalSwitchManager provides switch info management APIs including IPAM for the host subnets
 type logicalSwitchManager struct {
 	cache map[string]logicalSwitchInfo
+	// reservedIPs are the IPs kept allocated independently of the pods, by
+	// their string form, on the switches whose host subnets contain them
+	reservedIPs map[string]net.IP
 	// A RW mutex for logicalSwitchManager which holds logicalSwitch information
 	sync.RWMutex
 	ipamFunc ipamFactoryFunc
@@ -82,9 +85,10 @@ func reserveIPs(subnet *net.IPNet, ipam ipam.Interface) error {
 // Initializes a new logical switch manager
 func newLogicalSwitchManager() *logicalSwitchManager {
 	return &logicalSwitchManager{
-		cache:    make(map[string]logicalSwitchInfo),
-		RWMutex:  sync.RWMutex{},
-		ipamFunc: NewIPAMAllocator,
+		cache:       make(map[string]logicalSwitchInfo),
+		reservedIPs: make(map[string]net.IP),
+		RWMutex:     sync.RWMutex{},
+		ipamFunc:    NewIPAMAllocator,
 	}
 }
 
@@ -106,11 +110,15 @@ func (manager *logicalSwitchManager) AddNode(nodeName string, hostSubnets []*net
 		}
 		ipams = append(ipams, ipam)
 	}
-	manager.cache[nodeName] = logicalSwitchInfo{
+	lsi := logicalSwitchInfo{
 		hostSubnets:  hostSubnets,
 		ipams:        ipams,
 		noHostSubnet: len(hostSubnets) == 0,
 	}
+	for _, ip := range manager.reservedIPs {
+		allocateReservedIP(nodeName, lsi, ip)
+	}
+	manager.cache[nodeName] = lsi
 
 	return nil
 }
@@ -272,6 +280,10 @@ func (manager *logicalSwitchManager) ReleaseIPs(nodeName string, ipnets []*net.I
 		return fmt.Errorf("failed to release IPs for node %s because there is no IPAM instance", nodeName)
 	}
 	for _, ipnet := range ipnets {
+		if _, ok := manager.reservedIPs[ipnet.IP.String()]; ok {
+			klog.V(5).Infof("Not releasing reserved IP %s of node %s", ipnet.IP, nodeName)
+			continue
+		}
 		for _, ipam := range lsi.ipams {
 			cidr := ipam.CIDR()
 			if cidr.Contains(ipnet.IP) {
@@ -285,6 +297,57 @@ func (manager *logicalSwitchManager) ReleaseIPs(nodeName string, ipnets []*net.I
 	return nil
 }
 
+// ReserveIPs keeps the IPs allocated on the switches whose host subnets contain
+// them, including the switches added later, until they are unreserved. ReleaseIPs
+// does not release the reserved IPs.
+func (manager *logicalSwitchManager) ReserveIPs(ips []net.IP) {
+	manager.Lock()
+	defer manager.Unlock()
+	for _, ip := range ips {
+		manager.reservedIPs[ip.String()] = ip
+		for nodeName, lsi := range manager.cache {
+			allocateReservedIP(nodeName, lsi, ip)
+		}
+	}
+}
+
+// UnreserveIPs stops keeping the IPs allocated. If release is set, the IPs are
+// also released, otherwise they are released by the next ReleaseIPs.
+func (manager *logicalSwitchManager) UnreserveIPs(ips []net.IP, release bool) {
+	manager.Lock()
+	defer manager.Unlock()
+	for _, ip := range ips {
+		delete(manager.reservedIPs, ip.String())
+		if !release {
+			continue
+		}
+		for nodeName, lsi := range manager.cache {
+			for _, subnetIPAM := range lsi.ipams {
+				cidr := subnetIPAM.CIDR()
+				if cidr.Contains(ip) {
+					if err := subnetIPAM.Release(ip); err != nil {
+						klog.Errorf("Error while releasing reserved IP %s of node %s: %v", ip, nodeName, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// allocateReservedIP allocates a reserved IP on the switch if one of its host
+// subnets contains it; the IP may already be allocated to its pod
+func allocateReservedIP(nodeName string, lsi logicalSwitchInfo, ip net.IP) {
+	for _, subnetIPAM := range lsi.ipams {
+		cidr := subnetIPAM.CIDR()
+		if cidr.Contains(ip) {
+			if err := subnetIPAM.Allocate(ip); err != nil && err != ipam.ErrAllocated {
+				klog.Errorf("Error while allocating reserved IP %s of node %s: %v", ip, nodeName, err)
+			}
+			return
+		}
+	}
+}
+
 // IP allocator manager for join switch's IPv4 and IPv6 subnets.
 type joinSwitchIPManager struct {
 	lsm            *logicalSwitchManager
@@ -34,7 +34,11 @@ import (
 const (
 	// OvnServiceIdledAt is a constant string representing the Service annotation key
 	// whose value indicates the time stamp in RFC3339 format when a Service was idled
-	OvnServiceIdledAt              = "k8s.ovn.org/idled-at"
+	OvnServiceIdledAt = "k8s.ovn.org/idled-at"
+	// OvnServiceHealthCheck is a constant string representing the Service annotation key
+	// enabling the OVN health checks of the Service endpoints. Its value is a JSON object
+	// with the optional interval, timeout, successCount and failureCount of the checks.
+	OvnServiceHealthCheck          = "k8s.ovn.org/health-check"
 	OvnNodeAnnotationRetryInterval = 100 * time.Millisecond
 	OvnNodeAnnotationRetryTimeout  = 1 * time.Second
 	OvnSingleJoinSwitchTopoVersion = 1
@@ -371,47 +375,6 @@ func (oc *Controller) SetupMaster(masterNodeName string) error {
 		}
 	}
 
-	// Create 3 load-balancers for east-west traffic for UDP, TCP, SCTP
-	oc.TCPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "load_balancer", "external_ids:k8s-cluster-lb-tcp=yes")
-	if err != nil {
-		klog.Errorf("Failed to get tcp load balancer, stderr: %q, error: %v", stderr, err)
-		return err
-	}
-
-	if oc.TCPLoadBalancerUUID == "" {
-		oc.TCPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--", "create", "load_balancer", "external_ids:k8s-cluster-lb-tcp=yes", "protocol=tcp")
-		if err != nil {
-			klog.Errorf("Failed to create tcp load balancer, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
-			return err
-		}
-	}
-
-	oc.UDPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "load_balancer", "external_ids:k8s-cluster-lb-udp=yes")
-	if err != nil {
-		klog.Errorf("Failed to get udp load balancer, stderr: %q, error: %v", stderr, err)
-		return err
-	}
-	if oc.UDPLoadBalancerUUID == "" {
-		oc.UDPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--", "create", "load_balancer", "external_ids:k8s-cluster-lb-udp=yes", "protocol=udp")
-		if err != nil {
-			klog.Errorf("Failed to create udp load balancer, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
-			return err
-		}
-	}
-
-	oc.SCTPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find", "load_balancer", "external_ids:k8s-cluster-lb-sctp=yes")
-	if err != nil {
-		klog.Errorf("Failed to get sctp load balancer, stderr: %q, error: %v", stderr, err)
-		return err
-	}
-	if oc.SCTPLoadBalancerUUID == "" && oc.SCTPSupport {
-		oc.SCTPLoadBalancerUUID, stderr, err = util.RunOVNNbctl("--", "create", "load_balancer", "external_ids:k8s-cluster-lb-sctp=yes", "protocol=sctp")
-		if err != nil {
-			klog.Errorf("Failed to create sctp load balancer, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
-			return err
-		}
-	}
-
 	// Initialize the OVNJoinSwitch switch IP manager
 	// The OVNJoinSwitch will be allocated IP addresses in the range 100.64.0.0/16 or fd98::/64.
 	oc.joinSwIPManager, err = initJoinLogicalSwitchIPManager()
@@ -557,7 +520,7 @@ func (oc *Controller) syncGatewayLogicalNetwork(node *kapi.Node, l3GatewayConfig
 	}
 
 	drLRPIPs, _ := oc.joinSwIPManager.getJoinLRPCacheIPs(types.OVNClusterRouter)
-	err = gatewayInit(node.Name, clusterSubnets, hostSubnets, l3GatewayConfig, oc.SCTPSupport, gwLRPIPs, drLRPIPs)
+	err = gatewayInit(node.Name, clusterSubnets, hostSubnets, l3GatewayConfig, gwLRPIPs, drLRPIPs)
 	if err != nil {
 		return fmt.Errorf("failed to init shared interface gateway: %v", err)
 	}
@@ -591,22 +554,17 @@ func (oc *Controller) syncGatewayLogicalNetwork(node *kapi.Node, l3GatewayConfig
 	}
 
 	if l3GatewayConfig.NodePortEnable {
-		err = oc.handleNodePortLB(node)
-	} else {
-		// nodePort disabled, delete gateway load balancers for this node.
-		gatewayRouter := "GR_" + node.Name
-		for _, proto := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
-			lbUUID, _ := oc.getGatewayLoadBalancer(gatewayRouter, proto)
-			if lbUUID != "" {
-				_, _, err := util.RunOVNNbctl("--if-exists", "destroy", "load_balancer", lbUUID)
-				if err != nil {
-					klog.Errorf("Failed to destroy %s load balancer for gateway %s: %v", proto, gatewayRouter, err)
-				}
-			}
+		physicalIPs := make([]string, 0, len(l3GatewayConfig.IPAddresses))
+		for _, ip := range l3GatewayConfig.IPAddresses {
+			physicalIPs = append(physicalIPs, ip.IP.String())
 		}
+		oc.setServiceLBNodeGateway(node.Name, types.GWRouterPrefix+node.Name, physicalIPs)
+	} else {
+		// nodePort disabled, remove the load balancers of the services from the gateway router
+		oc.setServiceLBNodeGateway(node.Name, "", nil)
 	}
 
-	return err
+	return nil
 }
 
 func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*net.IPNet) error {
@@ -715,37 +673,27 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 		return err
 	}
 
-	// Add our cluster TCP and UDP load balancers to the node switch
-	if oc.TCPLoadBalancerUUID == "" {
-		return fmt.Errorf("TCP cluster load balancer not created")
-	}
-	stdout, stderr, err = util.RunOVNNbctl("set", "logical_switch", nodeName, "load_balancer="+oc.TCPLoadBalancerUUID)
-	if err != nil {
-		klog.Errorf("Failed to set logical switch %v's load balancer, stdout: %q, stderr: %q, error: %v", nodeName, stdout, stderr, err)
-		return err
-	}
-
-	if oc.UDPLoadBalancerUUID == "" {
-		return fmt.Errorf("UDP cluster load balancer not created")
-	}
-	stdout, stderr, err = util.RunOVNNbctl("add", "logical_switch", nodeName, "load_balancer", oc.UDPLoadBalancerUUID)
-	if err != nil {
-		klog.Errorf("Failed to add logical switch %v's load balancer, stdout: %q, stderr: %q, error: %v", nodeName, stdout, stderr, err)
+	// Add the node to the logical switch cache
+	if err = oc.lsManager.AddNode(nodeName, hostSubnets); err != nil {
 		return err
 	}
 
-	if oc.SCTPSupport {
-		if oc.SCTPLoadBalancerUUID == "" {
-			return fmt.Errorf("SCTP cluster load balancer not created")
-		}
-		stdout, stderr, err = util.RunOVNNbctl("add", "logical_switch", nodeName, "load_balancer", oc.SCTPLoadBalancerUUID)
-		if err != nil {
-			klog.Errorf("Failed to add logical switch %v's load balancer, stdout: %q, stderr: %q, error: %v", nodeName, stdout, stderr, err)
+	// Add the QoS rows of the EgressQoSes to the node switch, once the switch
+	// is in the cache so that EgressQoSes added concurrently include it
+	if config.OVNKubernetesFeature.EnableEgressQoS {
+		if err = oc.addEgressQoSRulesToSwitch(nodeName); err != nil {
 			return err
 		}
 	}
-	// Add the node to the logical switch cache
-	return oc.lsManager.AddNode(nodeName, hostSubnets)
+
+	// Apply the load balancers of the services to the node switch
+	oc.addServiceLBNode(nodeName)
+
+	// Create the node switches of the layer3 secondary networks
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		return oc.addNodeToSecondaryNetworks(nodeName)
+	}
+	return nil
 }
 
 func (oc *Controller) addNodeAnnotations(node *kapi.Node, hostSubnets []*net.IPNet) error {
@@ -864,6 +812,13 @@ func (oc *Controller) deleteNode(nodeName string, hostSubnets []*net.IPNet,
 		klog.Errorf("Error deleting node %s logical network: %v", nodeName, err)
 	}
 
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		oc.deleteNodeFromSecondaryNetworks(nodeName)
+	}
+
+	// Remove the node from the load balancers of the services
+	oc.deleteServiceLBNode(nodeName)
+
 	if err := gatewayCleanup(nodeName); err != nil {
 		return fmt.Errorf("failed to clean up node %s gateway: (%v)", nodeName, err)
 	}
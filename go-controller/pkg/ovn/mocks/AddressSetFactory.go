@@ -29,6 +29,29 @@ func (_m *AddressSetFactory) DestroyAddressSetInBackingStore(name string) error
 	return r0
 }
 
+// EnsureAddressSet provides a mock function with given fields: name
+func (_m *AddressSetFactory) EnsureAddressSet(name string) (ovn.AddressSet, error) {
+	ret := _m.Called(name)
+
+	var r0 ovn.AddressSet
+	if rf, ok := ret.Get(0).(func(string) ovn.AddressSet); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ovn.AddressSet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ForEachAddressSet provides a mock function with given fields: iteratorFn
 func (_m *AddressSetFactory) ForEachAddressSet(iteratorFn ovn.AddressSetIterFunc) error {
 	ret := _m.Called(iteratorFn)
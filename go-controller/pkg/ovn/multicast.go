@@ -0,0 +1,372 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	knet "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// multicastGroupsInterval is the interval at which the members of the IGMP groups are reported
+const multicastGroupsInterval = 30 * time.Second
+
+// multicastDomain is a set of multicast enabled namespaces whose pods receive the multicast
+// traffic sent by the pods of any of them
+type multicastDomain struct {
+	// the UUID of the port group containing the ports of all the pods of the namespaces
+	portGroupUUID string
+	// the namespaces in the domain
+	namespaces sets.String
+	// the match of the ACL currently allowing the multicast traffic between the namespaces
+	ingressMatch string
+	// the ports and the ACLs of the port group reused from a previous run, the ones no longer
+	// in use are removed by syncMulticastDomains
+	stalePorts sets.String
+	staleACLs  sets.String
+}
+
+// multicastDomainPortGroupName returns the name of the port group of a multicast domain
+func multicastDomainPortGroupName(domain string) string {
+	return "multicastDomain_" + domain
+}
+
+// getMulticastDomainACLMatch creates the match string used for the ACL allowing incoming
+// multicast from the pods of any of the namespaces of a domain
+func getMulticastDomainACLMatch(namespaces sets.String) string {
+	addressSets := make([]string, 0, namespaces.Len())
+	for _, ns := range namespaces.List() {
+		addressSets = append(addressSets, "$"+getIPv4ASHashedName(ns))
+	}
+	return "ip4.src == {" + strings.Join(addressSets, ", ") + "} && ip4.mcast"
+}
+
+// multicastJoinDomain adds the namespace to the multicast domain, allowing its pods to receive
+// the multicast traffic of the pods of the other namespaces of the domain and vice versa.
+// Caller must hold the namespace's namespaceInfo object lock.
+func (oc *Controller) multicastJoinDomain(ns string, nsInfo *namespaceInfo, domain string) error {
+	oc.multicastDomainsLock.Lock()
+	defer oc.multicastDomainsLock.Unlock()
+
+	d := oc.multicastDomains[domain]
+	if d == nil {
+		// reuse the port group left by a previous run so that the pods keep receiving the
+		// multicast traffic, its ports and ACL are reconciled by syncMulticastDomains
+		var err error
+		d, err = getMulticastDomainPortGroup(hashedPortGroup(multicastDomainPortGroupName(domain)))
+		if err != nil {
+			return fmt.Errorf("failed to get port_group for multicast domain %s (%v)", domain, err)
+		}
+		if d == nil {
+			portGroupUUID, err := createPortGroup(multicastDomainPortGroupName(domain),
+				hashedPortGroup(multicastDomainPortGroupName(domain)))
+			if err != nil {
+				return fmt.Errorf("failed to create port_group for multicast domain %s (%v)", domain, err)
+			}
+			d = &multicastDomain{
+				portGroupUUID: portGroupUUID,
+				namespaces:    sets.NewString(),
+			}
+		}
+		oc.multicastDomains[domain] = d
+	}
+	d.namespaces.Insert(ns)
+	nsInfo.multicastDomain = domain
+	if err := updateMulticastDomainACL(domain, d); err != nil {
+		return err
+	}
+
+	// Add all ports from this namespace to the multicast domain port group.
+	pods, err := oc.watchFactory.GetPods(ns)
+	if err != nil {
+		klog.Warningf("Failed to get pods for namespace %q: %v", ns, err)
+	}
+	for _, pod := range pods {
+		portName := podLogicalPortName(pod)
+		if portInfo, err := oc.logicalPortCache.get(portName); err != nil {
+			klog.Errorf(err.Error())
+		} else if err := addToPortGroup(hashedPortGroup(multicastDomainPortGroupName(domain)), portInfo); err != nil {
+			klog.Warningf("Failed to add port %s to multicast domain %s: %v", portName, domain, err)
+		}
+	}
+	return nil
+}
+
+// multicastLeaveDomain removes the namespace from its multicast domain, the domain is deleted
+// once it has no namespace anymore.
+// Caller must hold the namespace's namespaceInfo object lock.
+func (oc *Controller) multicastLeaveDomain(ns string, nsInfo *namespaceInfo) error {
+	if nsInfo.multicastDomain == "" {
+		return nil
+	}
+	domain := nsInfo.multicastDomain
+	nsInfo.multicastDomain = ""
+
+	oc.multicastDomainsLock.Lock()
+	defer oc.multicastDomainsLock.Unlock()
+
+	d := oc.multicastDomains[domain]
+	if d == nil {
+		return nil
+	}
+	d.namespaces.Delete(ns)
+	if d.namespaces.Len() == 0 {
+		// the ACL is garbage collected along with the port group
+		deletePortGroup(hashedPortGroup(multicastDomainPortGroupName(domain)))
+		delete(oc.multicastDomains, domain)
+		return nil
+	}
+
+	pods, err := oc.watchFactory.GetPods(ns)
+	if err != nil {
+		klog.Warningf("Failed to get pods for namespace %q: %v", ns, err)
+	}
+	for _, pod := range pods {
+		portName := podLogicalPortName(pod)
+		if portInfo, err := oc.logicalPortCache.get(portName); err != nil {
+			klog.Errorf(err.Error())
+		} else if err := deleteFromPortGroup(hashedPortGroup(multicastDomainPortGroupName(domain)), portInfo); err != nil {
+			klog.Warningf("Failed to delete port %s from multicast domain %s: %v", portName, domain, err)
+		}
+	}
+	return updateMulticastDomainACL(domain, d)
+}
+
+// getMulticastDomainPortGroup returns the multicast domain of the port group left by a
+// previous run, if any, holding its ports and ACLs as stale until they are found in use
+func getMulticastDomainPortGroup(portGroupName string) (*multicastDomain, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=_uuid,ports,acls", "find", "port_group", "name="+portGroupName)
+	if err != nil {
+		return nil, fmt.Errorf("find failed to get port_group %s, stderr: %q (%v)", portGroupName, stderr, err)
+	}
+	fields := strings.Split(stdout, ",")
+	if len(fields) != 3 || fields[0] == "" {
+		return nil, nil
+	}
+	return &multicastDomain{
+		portGroupUUID: fields[0],
+		namespaces:    sets.NewString(),
+		stalePorts:    sets.NewString(strings.Fields(fields[1])...),
+		staleACLs:     sets.NewString(strings.Fields(fields[2])...),
+	}, nil
+}
+
+// syncMulticastDomains removes from the port groups reused from a previous run the ports of
+// the pods that are not in the namespaces of their domain anymore and the ACLs no longer in
+// use. It must be called once the existing namespaces and pods have been added.
+func (oc *Controller) syncMulticastDomains() {
+	oc.multicastDomainsLock.Lock()
+	defer oc.multicastDomainsLock.Unlock()
+
+	for domain, d := range oc.multicastDomains {
+		if d.stalePorts.Len() == 0 && d.staleACLs.Len() == 0 {
+			continue
+		}
+		if err := oc.syncMulticastDomain(domain, d); err != nil {
+			klog.Errorf("Failed to sync the port group of multicast domain %s: %v", domain, err)
+			continue
+		}
+		d.stalePorts = nil
+		d.staleACLs = nil
+	}
+}
+
+// syncMulticastDomain removes the stale ports and ACLs of the port group of a multicast domain.
+// Caller must hold the multicastDomainsLock.
+func (oc *Controller) syncMulticastDomain(domain string, d *multicastDomain) error {
+	portGroupName := hashedPortGroup(multicastDomainPortGroupName(domain))
+	for _, ns := range d.namespaces.List() {
+		pods, err := oc.watchFactory.GetPods(ns)
+		if err != nil {
+			return fmt.Errorf("failed to get pods for namespace %q: %v", ns, err)
+		}
+		for _, pod := range pods {
+			if portInfo, err := oc.logicalPortCache.get(podLogicalPortName(pod)); err == nil {
+				d.stalePorts.Delete(portInfo.uuid)
+			}
+		}
+	}
+	if d.ingressMatch != "" {
+		match := getACLMatch(portGroupName, d.ingressMatch, knet.PolicyTypeIngress)
+		uuid, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+			"--columns=_uuid", "find", "ACL", match, "action=allow",
+			fmt.Sprintf("external-ids:default-deny-policy-type=%s", knet.PolicyTypeIngress))
+		if err != nil {
+			return fmt.Errorf("find failed to get the allow ingress multicast ACL, stderr: %q (%v)", stderr, err)
+		}
+		d.staleACLs.Delete(uuid)
+	}
+
+	var args []string
+	if d.stalePorts.Len() > 0 {
+		args = append(args, "--", "--if-exists", "remove", "port_group", portGroupName, "ports")
+		args = append(args, d.stalePorts.List()...)
+	}
+	if d.staleACLs.Len() > 0 {
+		args = append(args, "--", "--if-exists", "remove", "port_group", portGroupName, "acls")
+		args = append(args, d.staleACLs.List()...)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	_, stderr, err := util.RunOVNNbctl(args[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to remove the stale ports and ACLs, stderr: %q (%v)", stderr, err)
+	}
+	return nil
+}
+
+// updateMulticastDomainACL replaces the ACL of the domain port group allowing incoming multicast
+// when the namespaces of the domain changed.
+// Caller must hold the multicastDomainsLock.
+func updateMulticastDomainACL(domain string, d *multicastDomain) error {
+	portGroupName := hashedPortGroup(multicastDomainPortGroupName(domain))
+	ingressMatch := getMulticastDomainACLMatch(d.namespaces)
+	if ingressMatch == d.ingressMatch {
+		return nil
+	}
+	if d.ingressMatch != "" {
+		err := deleteACLPortGroup(portGroupName, toLport, defaultMcastAllowPriority,
+			d.ingressMatch, "allow", knet.PolicyTypeIngress)
+		if err != nil {
+			return fmt.Errorf("failed to delete allow ingress multicast ACL for multicast domain %s (%v)",
+				domain, err)
+		}
+		d.ingressMatch = ""
+	}
+	err := addACLPortGroup(d.portGroupUUID, toLport, defaultMcastAllowPriority,
+		getACLMatch(portGroupName, ingressMatch, knet.PolicyTypeIngress), "allow", knet.PolicyTypeIngress)
+	if err != nil {
+		return fmt.Errorf("failed to create allow ingress multicast ACL for multicast domain %s (%v)",
+			domain, err)
+	}
+	d.ingressMatch = ingressMatch
+	return nil
+}
+
+// podAddMulticastDomain adds the pod's logical switch port to the port group of the
+// namespace's multicast domain, if any. Caller must hold the namespace's namespaceInfo
+// object lock.
+func podAddMulticastDomain(nsInfo *namespaceInfo, portInfo *lpInfo) error {
+	if nsInfo.multicastDomain == "" {
+		return nil
+	}
+	return addToPortGroup(hashedPortGroup(multicastDomainPortGroupName(nsInfo.multicastDomain)), portInfo)
+}
+
+// podDeleteMulticastDomain removes the pod's logical switch port from the port group of
+// the namespace's multicast domain, if any. Caller must hold the namespace's namespaceInfo
+// object lock.
+func podDeleteMulticastDomain(nsInfo *namespaceInfo, portInfo *lpInfo) error {
+	if nsInfo.multicastDomain == "" {
+		return nil
+	}
+	return deleteFromPortGroup(hashedPortGroup(multicastDomainPortGroupName(nsInfo.multicastDomain)), portInfo)
+}
+
+// getSBColumns lists the given columns of the rows of a southbound database table
+func getSBColumns(table string, columns ...string) ([][]string, error) {
+	stdout, stderr, err := util.RunOVNSbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns="+strings.Join(columns, ","), "list", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s, stderr: %q (%v)", table, stderr, err)
+	}
+	var rows [][]string
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) == len(columns) {
+			rows = append(rows, fields)
+		}
+	}
+	return rows, nil
+}
+
+// multicastGroupMember is a pod that joined an IGMP group
+type multicastGroupMember struct {
+	group     string
+	namespace string
+	pod       string
+	node      string
+}
+
+// getMulticastGroupMembers returns the pods that joined the IGMP groups learnt by
+// ovn-controller, as found in the IGMP_Group table of the southbound database
+func getMulticastGroupMembers() ([]multicastGroupMember, error) {
+	chassisRows, err := getSBColumns("Chassis", "_uuid", "hostname")
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]string, len(chassisRows))
+	for _, row := range chassisRows {
+		nodes[row[0]] = row[1]
+	}
+	portRows, err := getSBColumns("Port_Binding", "_uuid", "logical_port", "type")
+	if err != nil {
+		return nil, err
+	}
+	pods := make(map[string]string)
+	for _, row := range portRows {
+		// the ports of the pods are the only VIF ports named <namespace>_<pod>
+		if row[2] == "" && strings.Contains(row[1], "_") {
+			pods[row[0]] = row[1]
+		}
+	}
+	groupRows, err := getSBColumns("IGMP_Group", "address", "chassis", "ports")
+	if err != nil {
+		return nil, err
+	}
+	var members []multicastGroupMember
+	for _, row := range groupRows {
+		group := net.ParseIP(row[0])
+		if group == nil {
+			continue
+		}
+		for _, port := range strings.Fields(row[2]) {
+			pod, ok := pods[port]
+			if !ok {
+				continue
+			}
+			parts := strings.SplitN(pod, "_", 2)
+			members = append(members, multicastGroupMember{
+				group:     group.String(),
+				namespace: parts[0],
+				pod:       parts[1],
+				node:      nodes[row[1]],
+			})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.pod < b.pod
+	})
+	return members, nil
+}
+
+// reportMulticastGroups records the pods that joined each IGMP group in the multicast
+// group member metric
+func reportMulticastGroups() {
+	members, err := getMulticastGroupMembers()
+	if err != nil {
+		klog.Errorf("Unable to get the members of the multicast groups: %v", err)
+		return
+	}
+	metrics.ResetMulticastGroupMembers()
+	for _, m := range members {
+		klog.V(5).Infof("Pod %s/%s on node %s joined multicast group %s", m.namespace, m.pod, m.node, m.group)
+		metrics.RecordMulticastGroupMember(m.group, m.namespace, m.pod, m.node)
+	}
+}
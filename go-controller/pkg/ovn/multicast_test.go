@@ -0,0 +1,44 @@
+package ovn
+
+import (
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN Multicast Group Operations", func() {
+	It("gets the pods that joined the IGMP groups", func() {
+		fExec := ovntest.NewLooseCompareFakeExec()
+		fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-sbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,hostname list Chassis",
+			Output: "chassis1-uuid,node1\nchassis2-uuid,node2\n",
+		})
+		fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd: "ovn-sbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,logical_port,type list Port_Binding",
+			Output: "pb1-uuid,namespace1_pod1,\n" +
+				"pb2-uuid,namespace2_pod2,\n" +
+				"pb3-uuid,namespace1_pod3,\n" +
+				"pb4-uuid,rtoj-GR_node1,patch\n" +
+				"pb5-uuid,k8s-node1,\n",
+		})
+		fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd: "ovn-sbctl --timeout=15 --format=csv --data=bare --no-heading --columns=address,chassis,ports list IGMP_Group",
+			Output: "239.1.1.1,chassis1-uuid,pb1-uuid pb4-uuid pb5-uuid\n" +
+				"239.1.1.1,chassis2-uuid,pb2-uuid\n" +
+				"239.2.2.2,chassis1-uuid,pb3-uuid\n" +
+				"mrouters,chassis1-uuid,pb4-uuid\n",
+		})
+		Expect(util.SetExec(fExec)).To(Succeed())
+
+		members, err := getMulticastGroupMembers()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal([]multicastGroupMember{
+			{group: "239.1.1.1", namespace: "namespace1", pod: "pod1", node: "node1"},
+			{group: "239.1.1.1", namespace: "namespace2", pod: "pod2", node: "node2"},
+			{group: "239.2.2.2", namespace: "namespace1", pod: "pod3", node: "node1"},
+		}))
+		Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+	})
+})
@@ -20,6 +20,14 @@ const (
 	routingExternalGWsAnnotation = "k8s.ovn.org/routing-external-gws"
 	routingNamespaceAnnotation   = "k8s.ovn.org/routing-namespaces"
 	routingNetworkAnnotation     = "k8s.ovn.org/routing-network"
+	// Annotation used to share the multicast traffic with the multicast enabled
+	// namespaces annotated with the same multicast domain
+	nsMulticastDomainAnnotation = "k8s.ovn.org/multicast-domain"
+	// Annotation used to monitor the external gateways of the namespace with BFD
+	bfdAnnotation = "k8s.ovn.org/bfd-enabled"
+	// Annotation used to set the severity at which the namespace's network
+	// policy ACLs log, e.g. {"deny": "alert", "allow": "notice"}
+	aclLoggingAnnotation = "k8s.ovn.org/acl-logging"
 )
 
 func (oc *Controller) syncNamespaces(namespaces []interface{}) {
@@ -34,6 +42,13 @@ func (oc *Controller) syncNamespaces(namespaces []interface{}) {
 	}
 
 	err := oc.addressSetFactory.ForEachAddressSet(func(addrSetName, namespaceName, nameSuffix string) {
+		// the address set of the pods selected by an egress firewall belongs to
+		// the namespace of the egress firewall
+		namespaceName = strings.TrimSuffix(namespaceName, egressFirewallAddressSetSuffix)
+		// the address sets of the rules of the egress QoSes are synced with them
+		if isEgressQoSAddressSetName(addrSetName) {
+			return
+		}
 		if nameSuffix == "" && !expectedNs[namespaceName] {
 			if err := oc.addressSetFactory.DestroyAddressSetInBackingStore(addrSetName); err != nil {
 				klog.Errorf(err.Error())
@@ -70,6 +85,9 @@ func (oc *Controller) addPodToNamespace(ns string, portInfo *lpInfo) error {
 		if err := podAddAllowMulticastPolicy(ns, portInfo); err != nil {
 			return err
 		}
+		if err := podAddMulticastDomain(nsInfo, portInfo); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -93,6 +111,9 @@ func (oc *Controller) deletePodFromNamespace(ns string, portInfo *lpInfo) error
 		if err := podDeleteAllowMulticastPolicy(ns, portInfo); err != nil {
 			return err
 		}
+		if err := podDeleteMulticastDomain(nsInfo, portInfo); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -109,6 +130,8 @@ func createIPAddressSlice(ips []*net.IPNet) []net.IP {
 // Creates an explicit "allow" policy for multicast traffic within the
 // namespace if multicast is enabled. Otherwise, removes the "allow" policy.
 // Traffic will be dropped by the default multicast deny ACL.
+// A multicast enabled namespace also joins the multicast domain it is
+// annotated with, if any.
 func (oc *Controller) multicastUpdateNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo) {
 	if !oc.multicastSupport {
 		return
@@ -116,27 +139,62 @@ func (oc *Controller) multicastUpdateNamespace(ns *kapi.Namespace, nsInfo *names
 
 	enabled := (ns.Annotations[nsMulticastAnnotation] == "true")
 	enabledOld := nsInfo.multicastEnabled
+	domain := ""
+	if enabled {
+		domain = ns.Annotations[nsMulticastDomainAnnotation]
+	}
 
-	if enabledOld == enabled {
-		return
+	if domain != nsInfo.multicastDomain {
+		if err := oc.multicastLeaveDomain(ns.Name, nsInfo); err != nil {
+			klog.Errorf(err.Error())
+		}
 	}
 
-	var err error
-	nsInfo.multicastEnabled = enabled
-	if enabled {
-		err = oc.createMulticastAllowPolicy(ns.Name, nsInfo)
-	} else {
-		err = deleteMulticastAllowPolicy(ns.Name, nsInfo)
+	if enabledOld != enabled {
+		var err error
+		nsInfo.multicastEnabled = enabled
+		if enabled {
+			err = oc.createMulticastAllowPolicy(ns.Name, nsInfo)
+		} else {
+			err = deleteMulticastAllowPolicy(ns.Name, nsInfo)
+		}
+		if err != nil {
+			klog.Errorf(err.Error())
+			return
+		}
 	}
+
+	if domain != "" && domain != nsInfo.multicastDomain {
+		if err := oc.multicastJoinDomain(ns.Name, nsInfo, domain); err != nil {
+			klog.Errorf(err.Error())
+		}
+	}
+}
+
+// aclLoggingUpdateNamespace parses the namespace's ACL logging annotation and,
+// when the severities changed, applies them to the ACLs already created for
+// the namespace's network policies.
+func (oc *Controller) aclLoggingUpdateNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo) {
+	aclLogging, err := parseACLLoggingAnnotation(ns.Annotations[aclLoggingAnnotation])
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("Namespace %s: %v", ns.Name, err)
+		return
+	}
+	if aclLogging == nsInfo.aclLogging {
 		return
 	}
+	nsInfo.aclLogging = aclLogging
+	if err := oc.updateACLLoggingForNamespace(ns.Name, nsInfo); err != nil {
+		klog.Errorf(err.Error())
+	}
 }
 
 // Cleans up the multicast policy for this namespace if multicast was
 // previously allowed.
 func (oc *Controller) multicastDeleteNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo) {
+	if err := oc.multicastLeaveDomain(ns.Name, nsInfo); err != nil {
+		klog.Errorf(err.Error())
+	}
 	if nsInfo.multicastEnabled {
 		nsInfo.multicastEnabled = false
 		if err := deleteMulticastAllowPolicy(ns.Name, nsInfo); err != nil {
@@ -188,6 +246,7 @@ func (oc *Controller) AddNamespace(ns *kapi.Namespace) {
 	defer nsInfo.Unlock()
 
 	var err error
+	nsInfo.bfdEnabled = ns.Annotations[bfdAnnotation] == "true"
 	annotation := ns.Annotations[routingExternalGWsAnnotation]
 	if annotation != "" {
 		nsInfo.routingExternalGWs, err = parseRoutingExternalGWAnnotation(annotation)
@@ -206,6 +265,36 @@ func (oc *Controller) AddNamespace(ns *kapi.Namespace) {
 	// created
 
 	oc.multicastUpdateNamespace(ns, nsInfo)
+	oc.aclLoggingUpdateNamespace(ns, nsInfo)
+}
+
+// bfdUpdateNamespace enables or disables the BFD sessions monitoring the external gateways of the namespace.
+// The routes to the gateways are re-created for their sessions to be added or removed; when the gateways of
+// the namespace annotation changed as well, the routes to them are left to be re-created by the caller.
+func (oc *Controller) bfdUpdateNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo, gwsChanged bool) {
+	enabled := ns.Annotations[bfdAnnotation] == "true"
+	if enabled == nsInfo.bfdEnabled {
+		return
+	}
+	routingExternalGWs := nsInfo.routingExternalGWs
+	oc.deleteGWRoutesForNamespace(nsInfo)
+	nsInfo.bfdEnabled = enabled
+	if !gwsChanged && routingExternalGWs != nil {
+		if err := oc.addExternalGWsForNamespace(routingExternalGWs, nsInfo, ns.Name); err != nil {
+			klog.Error(err.Error())
+		}
+	}
+	for pod, gws := range nsInfo.routingExternalPodGWs {
+		if err := oc.addGWRoutesForNamespace(ns.Name, gws, nsInfo); err != nil {
+			klog.Errorf("Unable to add routes to external gateway pod %s for namespace %s: %v", pod, ns.Name, err)
+		}
+	}
+	for policy, gws := range nsInfo.apbRouteGWs {
+		if err := oc.addGWRoutesForNamespace(ns.Name, gws, nsInfo); err != nil {
+			klog.Errorf("Unable to add routes to the external gateways of AdminPolicyBasedExternalRoute %s for namespace %s: %v",
+				policy, ns.Name, err)
+		}
+	}
 }
 
 func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
@@ -221,10 +310,12 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 	var annotation, oldAnnotation string
 	annotation = newer.Annotations[routingExternalGWsAnnotation]
 	oldAnnotation = old.Annotations[routingExternalGWsAnnotation]
+	oc.bfdUpdateNamespace(newer, nsInfo, annotation != oldAnnotation)
 	if annotation != oldAnnotation {
 		// if old gw annotation was empty, new one must not be empty, so we should remove any per pod SNAT
 		if oldAnnotation == "" {
-			if config.Gateway.DisableSNATMultipleGWs && (len(nsInfo.routingExternalGWs) != 0 || len(nsInfo.routingExternalPodGWs) != 0) {
+			if config.Gateway.DisableSNATMultipleGWs && (len(nsInfo.routingExternalGWs) != 0 || len(nsInfo.routingExternalPodGWs) != 0 ||
+				len(nsInfo.apbRouteGWs) != 0) {
 				existingPods, err := oc.watchFactory.GetPods(old.Name)
 				if err != nil {
 					klog.Errorf("Failed to get all the pods (%v)", err)
@@ -240,7 +331,10 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 				}
 			}
 		} else {
-			oc.deleteGWRoutesForNamespace(nsInfo)
+			// only remove the routes to the gateways no other pod or AdminPolicyBasedExternalRoute provides
+			routingExternalGWs := nsInfo.routingExternalGWs
+			nsInfo.routingExternalGWs = nil
+			oc.deleteGWRoutesForGWs(nsInfo, nsInfo.getUnusedExternalGWs(routingExternalGWs))
 		}
 		exGateways, err := parseRoutingExternalGWAnnotation(annotation)
 		if err != nil {
@@ -253,7 +347,8 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 		}
 		// if new annotation is empty, exgws were removed, may need to add SNAT per pod
 		// check if there are any pod gateways serving this namespace as well
-		if annotation == "" && len(nsInfo.routingExternalPodGWs) == 0 && config.Gateway.DisableSNATMultipleGWs {
+		if annotation == "" && len(nsInfo.routingExternalPodGWs) == 0 && len(nsInfo.apbRouteGWs) == 0 &&
+			config.Gateway.DisableSNATMultipleGWs {
 			existingPods, err := oc.watchFactory.GetPods(old.Name)
 			if err != nil {
 				klog.Errorf("Failed to get all the pods (%v)", err)
@@ -271,6 +366,7 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 		}
 	}
 	oc.multicastUpdateNamespace(newer, nsInfo)
+	oc.aclLoggingUpdateNamespace(newer, nsInfo)
 }
 
 func (oc *Controller) deleteNamespace(ns *kapi.Namespace) {
@@ -287,6 +383,7 @@ func (oc *Controller) deleteNamespace(ns *kapi.Namespace) {
 		delete(nsInfo.networkPolicies, np.name)
 		oc.destroyNamespacePolicy(np)
 	}
+	deleteDefaultDenyPortGroups(ns.Name, nsInfo)
 	oc.deleteGWRoutesForNamespace(nsInfo)
 	oc.multicastDeleteNamespace(ns, nsInfo)
 }
@@ -336,6 +433,17 @@ func (oc *Controller) getNamespaceLocked(ns string) *namespaceInfo {
 	return nsInfo
 }
 
+// getNamespaceNames locks namespacesMutex and returns the names of the known namespaces
+func (oc *Controller) getNamespaceNames() []string {
+	oc.namespacesMutex.Lock()
+	defer oc.namespacesMutex.Unlock()
+	names := make([]string, 0, len(oc.namespaces))
+	for ns := range oc.namespaces {
+		names = append(names, ns)
+	}
+	return names
+}
+
 // createNamespaceLocked locks namespacesMutex, creates an entry for ns, and returns it
 // with its mutex locked.
 func (oc *Controller) createNamespaceLocked(ns string) *namespaceInfo {
@@ -347,6 +455,7 @@ func (oc *Controller) createNamespaceLocked(ns string) *namespaceInfo {
 		podExternalRoutes:     make(map[string]map[string]string),
 		multicastEnabled:      false,
 		routingExternalPodGWs: make(map[string][]net.IP),
+		apbRouteGWs:           make(map[string][]net.IP),
 	}
 	nsInfo.Lock()
 	oc.namespaces[ns] = nsInfo
@@ -17,6 +17,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/ipallocator"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/subnetallocator"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
@@ -30,6 +31,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/cache"
@@ -53,14 +56,6 @@ type ServiceVIPKey struct {
 	protocol kapi.Protocol
 }
 
-// loadBalancerConf contains the OVN based config for a LB
-type loadBalancerConf struct {
-	// List of endpoints as configured in OVN, ip:port
-	endpoints []string
-	// ACL configured for Rejecting access to the LB
-	rejectACL string
-}
-
 // namespaceInfo contains information related to a Namespace. Use oc.getNamespaceLocked()
 // or oc.waitForNamespaceLocked() to get a locked namespaceInfo for a Namespace, and call
 // nsInfo.Unlock() on it when you are done with it. (No code outside of the code that
@@ -93,10 +88,31 @@ type namespaceInfo struct {
 	// exgw IPs
 	routingExternalPodGWs map[string][]net.IP
 
+	// apbRouteGWs contains a map of all the AdminPolicyBasedExternalRoutes targeting the
+	// namespace as well as the exgw IPs they provide
+	apbRouteGWs map[string][]net.IP
+
+	// bfdEnabled is set when the k8s.ovn.org/bfd-enabled annotation requests the
+	// external gateways of the namespace to be monitored with BFD
+	bfdEnabled bool
+
 	// The UUID of the namespace-wide port group that contains all the pods in the namespace.
 	portGroupUUID string
 
+	// The UUIDs of the port groups that contain the pods in the namespace
+	// selected by a NetworkPolicy applying ingress/egress default deny.
+	portGroupIngressDenyUUID string
+	portGroupEgressDenyUUID  string
+
 	multicastEnabled bool
+
+	// multicastDomain is the multicast domain the namespace joined through the
+	// k8s.ovn.org/multicast-domain annotation, if any
+	multicastDomain string
+
+	// aclLogging holds the severities parsed from the k8s.ovn.org/acl-logging
+	// annotation at which the namespace's policy ACLs log
+	aclLogging ACLLoggingLevels
 }
 
 // Controller structure is the object which holds the controls for starting
@@ -106,7 +122,9 @@ type Controller struct {
 	kube                  kube.Interface
 	watchFactory          *factory.WatchFactory
 	egressFirewallHandler *factory.Handler
-	stopChan              <-chan struct{}
+	// handles the IPs the nodes learn for the wildcard DNS names of EgressFirewalls
+	egressFirewallNodeHandler *factory.Handler
+	stopChan                  <-chan struct{}
 
 	// FIXME DUAL-STACK -  Make IP Allocators more dual-stack friendly
 	masterSubnetAllocator     *subnetallocator.SubnetAllocator
@@ -115,14 +133,10 @@ type Controller struct {
 
 	hoMaster *hocontroller.MasterController
 
-	TCPLoadBalancerUUID  string
-	UDPLoadBalancerUUID  string
-	SCTPLoadBalancerUUID string
-	SCTPSupport          bool
+	SCTPSupport bool
 
-	// For TCP, UDP, and SCTP type traffic, cache OVN load-balancers used for the
-	// cluster's east-west traffic.
-	loadbalancerClusterCache map[kapi.Protocol]string
+	// Programs the OVN load balancers of the services
+	lbManager *loadbalancer.Manager
 
 	// A cache of all logical switches seen by the watcher and their subnets
 	lsManager *logicalSwitchManager
@@ -130,10 +144,10 @@ type Controller struct {
 	// A cache of all logical ports known to the controller
 	logicalPortCache *portCache
 
-	// Info about known namespaces. You must use oc.getNamespaceLocked() or
-	// oc.waitForNamespaceLocked() to read this map, and oc.createNamespaceLocked()
-	// or oc.deleteNamespaceLocked() to modify it. namespacesMutex is only held
-	// from inside those functions.
+	// Info about known namespaces. You must use oc.getNamespaceLocked(),
+	// oc.waitForNamespaceLocked() or oc.getNamespaceNames() to read this map,
+	// and oc.createNamespaceLocked() or oc.deleteNamespaceLocked() to modify it.
+	// namespacesMutex is only held from inside those functions.
 	namespaces      map[string]*namespaceInfo
 	namespacesMutex sync.Mutex
 
@@ -143,12 +157,6 @@ type Controller struct {
 	// Port group for all cluster logical switch ports
 	clusterPortGroupUUID string
 
-	// Port group for ingress deny rule
-	portGroupIngressDeny string
-
-	// Port group for egress deny rule
-	portGroupEgressDeny string
-
 	// For each logical port, the number of network policies that want
 	// to add a ingress deny rule.
 	lspIngressDenyCache map[string]int
@@ -163,6 +171,10 @@ type Controller struct {
 	// Supports multicast?
 	multicastSupport bool
 
+	// The multicast domains shared by namespaces, protected by multicastDomainsLock
+	multicastDomains     map[string]*multicastDomain
+	multicastDomainsLock sync.Mutex
+
 	// Controller used for programming OVN for egress IP
 	eIPC egressIPController
 
@@ -173,13 +185,44 @@ type Controller struct {
 
 	serviceVIPToNameLock sync.Mutex
 
-	// Map of load balancers, each containing a map of VIP to OVN LB Config
-	serviceLBMap map[string]map[string]*loadBalancerConf
-
-	serviceLBLock sync.Mutex
+	// The nodes the load balancers of the services are applied to, keyed by
+	// node name, the changes of the nodes staged while handling their events,
+	// and whether the services watch has started. Protected by serviceLBLock,
+	// which also serializes the programming of the services.
+	serviceLBNodes       map[string]*serviceLBNode
+	stagedServiceLBNodes map[string]*serviceLBNode
+	servicesStarted      bool
+	serviceLBLock        sync.Mutex
 
 	joinSwIPManager *joinSwitchIPManager
 
+	// The last state seen of the BFD sessions monitoring the external gateways,
+	// only accessed by checkExternalGatewaysBFD
+	exGWBFDStatus map[exGWBFDSession]string
+
+	// The namespaces each AdminPolicyBasedExternalRoute was last applied to,
+	// protected by apbRouteLock
+	apbRouteNamespaces map[string]sets.String
+	apbRouteLock       sync.Mutex
+
+	// The AdminNetworkPolicies programmed in OVN, by name, protected by anpLock
+	adminNetworkPolicies map[string]*adminNetworkPolicy
+	anpLock              sync.Mutex
+
+	// The EgressQoSes programmed in OVN, by namespace, protected by egressQoSLock
+	egressQoSes   map[string]*egressQoS
+	egressQoSLock sync.Mutex
+	// The QoS rows of the EgressQoSes created by a previous run, by namespace,
+	// protected by egressQoSLock
+	staleEgressQoSRows map[string][]string
+
+	// The OVN-managed secondary networks programmed in OVN, by network name, and
+	// the networks of their NetworkAttachmentDefinitions, by <namespace>/<name>,
+	// protected by secondaryNetworksLock
+	secondaryNetworks     map[string]*secondaryNetwork
+	nadNetworks           map[string]string
+	secondaryNetworksLock sync.Mutex
+
 	// event recorder used to post events to k8s
 	recorder record.EventRecorder
 
@@ -228,6 +271,9 @@ func NewOvnController(ovnClient *util.OVNClientset, wf *factory.WatchFactory,
 			KClient:              ovnClient.KubeClient,
 			EIPClient:            ovnClient.EgressIPClient,
 			EgressFirewallClient: ovnClient.EgressFirewallClient,
+			APBRouteClient:       ovnClient.APBRouteClient,
+			EgressQoSClient:      ovnClient.EgressQoSClient,
+			IPAMClaimClient:      ovnClient.IPAMClaimClient,
 		},
 		watchFactory:              wf,
 		stopChan:                  stopChan,
@@ -250,16 +296,25 @@ func NewOvnController(ovnClient *util.OVNClientset, wf *factory.WatchFactory,
 			allocatorMutex:        &sync.Mutex{},
 			allocator:             make(map[string]*egressNode),
 		},
-		loadbalancerClusterCache: make(map[kapi.Protocol]string),
-		multicastSupport:         config.EnableMulticast,
-		serviceVIPToName:         make(map[ServiceVIPKey]types.NamespacedName),
-		serviceVIPToNameLock:     sync.Mutex{},
-		serviceLBMap:             make(map[string]map[string]*loadBalancerConf),
-		serviceLBLock:            sync.Mutex{},
-		joinSwIPManager:          nil,
-		recorder:                 recorder,
-		ovnNBClient:              ovnNBClient,
-		ovnSBClient:              ovnSBClient,
+		lbManager:            loadbalancer.NewManager(),
+		multicastSupport:     config.EnableMulticast,
+		serviceVIPToName:     make(map[ServiceVIPKey]types.NamespacedName),
+		serviceVIPToNameLock: sync.Mutex{},
+		serviceLBNodes:       make(map[string]*serviceLBNode),
+		stagedServiceLBNodes: make(map[string]*serviceLBNode),
+		serviceLBLock:        sync.Mutex{},
+		joinSwIPManager:      nil,
+		exGWBFDStatus:        make(map[exGWBFDSession]string),
+		apbRouteNamespaces:   make(map[string]sets.String),
+		adminNetworkPolicies: make(map[string]*adminNetworkPolicy),
+		egressQoSes:          make(map[string]*egressQoS),
+		staleEgressQoSRows:   make(map[string][]string),
+		secondaryNetworks:    make(map[string]*secondaryNetwork),
+		nadNetworks:          make(map[string]string),
+		multicastDomains:     make(map[string]*multicastDomain),
+		recorder:             recorder,
+		ovnNBClient:          ovnNBClient,
+		ovnSBClient:          ovnSBClient,
 	}
 }
 
@@ -278,9 +333,32 @@ func (oc *Controller) Run(wg *sync.WaitGroup) error {
 	// https://github.com/ovn-org/ovn-kubernetes/pull/859
 	oc.WatchNodes()
 
+	// WatchIPAMClaims must be started before WatchPods, so that the IPs of the
+	// IPAMClaims are not allocated to other pods
+	if config.OVNKubernetesFeature.EnablePersistentIPs {
+		oc.WatchIPAMClaims()
+	}
+
+	// WatchAdminNetworkPolicies must be started before WatchPods and
+	// WatchNetworkPolicy so that the admin network policies are enforced before
+	// the pods are added and the network policies allow their traffic
+	if config.OVNKubernetesFeature.EnableAdminNetworkPolicy {
+		oc.WatchAdminNetworkPolicies()
+	}
+
 	oc.WatchPods()
+	// the port groups of the multicast domains reused from a previous run are reconciled
+	// once the existing namespaces and pods have been added
+	oc.syncMulticastDomains()
+	if config.OVNKubernetesFeature.EnableAdminNetworkPolicy {
+		oc.syncAdminNetworkPolicyPortGroups()
+	}
 	oc.WatchServices()
-	oc.WatchEndpoints()
+	if config.OVNKubernetesFeature.EnableEndpointSlices {
+		oc.WatchEndpointSlices()
+	} else {
+		oc.WatchEndpoints()
+	}
 	oc.WatchNetworkPolicy()
 	oc.WatchCRD()
 
@@ -289,8 +367,33 @@ func (oc *Controller) Run(wg *sync.WaitGroup) error {
 		oc.WatchEgressIP()
 	}
 
+	if config.OVNKubernetesFeature.EnableAdminPolicyBasedExternalRoutes {
+		oc.WatchAdminPolicyBasedExternalRoutes()
+	}
+
+	// WatchEgressQoS must be started after WatchNodes, which creates the node
+	// logical switches the QoS rows are added to
+	if config.OVNKubernetesFeature.EnableEgressQoS {
+		oc.WatchEgressQoS()
+	}
+
+	// WatchNetworkAttachmentDefinitions must be started after WatchNodes, which
+	// allocates the node subnets of the default network the node switches of the
+	// secondary networks are created for, and before WatchSecondaryNetworkPods,
+	// which attaches the pods to the known secondary networks
+	if config.OVNKubernetesFeature.EnableMultiNetwork {
+		oc.WatchNetworkAttachmentDefinitions()
+		oc.WatchSecondaryNetworkPods()
+	}
+
 	klog.Infof("Completing all the Watchers took %v", time.Since(start))
 
+	go utilwait.Until(oc.checkExternalGatewaysBFD, exGWBFDStatusInterval, oc.stopChan)
+
+	if oc.multicastSupport {
+		go utilwait.Until(reportMulticastGroups, multicastGroupsInterval, oc.stopChan)
+	}
+
 	if config.Kubernetes.OVNEmptyLbEvents {
 		go oc.ovnControllerEventChecker()
 	}
@@ -562,24 +665,29 @@ func (oc *Controller) WatchServices() {
 	oc.watchFactory.AddServiceHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			err := oc.createService(service)
+			err := oc.syncService(service.Namespace, service.Name)
 			if err != nil {
 				klog.Errorf("Error in adding service: %v", err)
 			}
 		},
 		UpdateFunc: func(old, new interface{}) {
-			svcOld := old.(*kapi.Service)
 			svcNew := new.(*kapi.Service)
-			err := oc.updateService(svcOld, svcNew)
+			err := oc.syncService(svcNew.Namespace, svcNew.Name)
 			if err != nil {
 				klog.Errorf("Error while updating service: %v", err)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			oc.deleteService(service)
+			err := oc.syncService(service.Namespace, service.Name)
+			if err != nil {
+				klog.Errorf("Error in deleting service: %v", err)
+			}
 		},
 	}, oc.syncServices)
+	oc.serviceLBLock.Lock()
+	oc.servicesStarted = true
+	oc.serviceLBLock.Unlock()
 	klog.Infof("Bootstrapping existing services and cleaning stale services took %v", time.Since(start))
 }
 
@@ -589,9 +697,9 @@ func (oc *Controller) WatchEndpoints() {
 	oc.watchFactory.AddEndpointsHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			ep := obj.(*kapi.Endpoints)
-			err := oc.AddEndpoints(ep)
+			err := oc.syncService(ep.Namespace, ep.Name)
 			if err != nil {
-				klog.Errorf("Error in adding load balancer: %v", err)
+				klog.Errorf("Error in adding endpoints: %v", err)
 			}
 		},
 		UpdateFunc: func(old, new interface{}) {
@@ -600,21 +708,14 @@ func (oc *Controller) WatchEndpoints() {
 			if reflect.DeepEqual(epNew.Subsets, epOld.Subsets) {
 				return
 			}
-			if len(epNew.Subsets) == 0 {
-				err := oc.deleteEndpoints(epNew)
-				if err != nil {
-					klog.Errorf("Error in deleting endpoints - %v", err)
-				}
-			} else {
-				err := oc.AddEndpoints(epNew)
-				if err != nil {
-					klog.Errorf("Error in modifying endpoints: %v", err)
-				}
+			err := oc.syncService(epNew.Namespace, epNew.Name)
+			if err != nil {
+				klog.Errorf("Error in modifying endpoints: %v", err)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			ep := obj.(*kapi.Endpoints)
-			err := oc.deleteEndpoints(ep)
+			err := oc.syncService(ep.Namespace, ep.Name)
 			if err != nil {
 				klog.Errorf("Error in deleting endpoints - %v", err)
 			}
@@ -661,13 +762,27 @@ func (oc *Controller) WatchCRD() {
 					klog.Errorf("Error Creating EgressFirewallWatchFactory: %v", err)
 					return
 				}
-				oc.egressFirewallHandler = oc.WatchEgressFirewall()
-
 				oc.egressFirewallDNS, err = NewEgressDNS(oc.addressSetFactory, oc.stopChan)
-				oc.egressFirewallDNS.Run(egressFirewallDNSDefaultDuration)
 				if err != nil {
 					klog.Errorf("Error Creating EgressFirewallDNS: %v", err)
+				} else {
+					// the status is refreshed asynchronously as the namespaces may be locked
+					// by egress firewalls waiting to add DNS names
+					oc.egressFirewallDNS.onResolve = func(dnsName string, namespaces []string) {
+						go func() {
+							for _, namespace := range namespaces {
+								if ef := oc.getEgressFirewall(namespace); ef != nil {
+									oc.refreshEgressFirewallStatus(ef)
+								}
+							}
+						}()
+					}
+					oc.egressFirewallDNS.Run(egressFirewallDNSDefaultDuration)
+					if config.OVNKubernetesFeature.EnableEgressFirewallDNSSnooping {
+						oc.egressFirewallNodeHandler = oc.WatchEgressFirewallNodes()
+					}
 				}
+				oc.egressFirewallHandler = oc.WatchEgressFirewall()
 			}
 		},
 		UpdateFunc: func(old, newer interface{}) {
@@ -679,12 +794,45 @@ func (oc *Controller) WatchCRD() {
 				oc.egressFirewallDNS.Shutdown()
 				oc.watchFactory.RemoveEgressFirewallHandler(oc.egressFirewallHandler)
 				oc.egressFirewallHandler = nil
+				if oc.egressFirewallNodeHandler != nil {
+					oc.watchFactory.RemoveNodeHandler(oc.egressFirewallNodeHandler)
+					oc.egressFirewallNodeHandler = nil
+				}
 				oc.watchFactory.ShutdownEgressFirewallWatchFactory()
 			}
 		},
 	}, nil)
 }
 
+// WatchEgressFirewallNodes starts the watching of the nodes, which get an address set
+// for each wildcard DNS name used in egressfirewall rules to set the IPs they learn in
+func (oc *Controller) WatchEgressFirewallNodes() *factory.Handler {
+	addNode := func(node *kapi.Node) {
+		namespaces, err := oc.egressFirewallDNS.AddNode(node.Name)
+		if err != nil {
+			klog.Errorf("Failed to create the egress firewall DNS address sets of node %s: %v", node.Name, err)
+		}
+		oc.refreshEgressFirewallWildcardRules(namespaces)
+	}
+	return oc.watchFactory.AddNodeHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			addNode(obj.(*kapi.Node))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			// retries the address sets that failed to be created
+			addNode(new.(*kapi.Node))
+		},
+		DeleteFunc: func(obj interface{}) {
+			node := obj.(*kapi.Node)
+			namespaces, err := oc.egressFirewallDNS.DeleteNode(node.Name)
+			if err != nil {
+				klog.Errorf("Failed to destroy the egress firewall DNS address sets of node %s: %v", node.Name, err)
+			}
+			oc.refreshEgressFirewallWildcardRules(namespaces)
+		},
+	}, nil)
+}
+
 // WatchEgressFirewall starts the watching of egressfirewall resource and calls
 // back the appropriate handler logic
 func (oc *Controller) WatchEgressFirewall() *factory.Handler {
@@ -692,15 +840,17 @@ func (oc *Controller) WatchEgressFirewall() *factory.Handler {
 		AddFunc: func(obj interface{}) {
 			egressFirewall := obj.(*egressfirewall.EgressFirewall).DeepCopy()
 			addErrors := oc.addEgressFirewall(egressFirewall)
+			status := egressFirewallAppliedCorrectly
 			if addErrors != nil {
 				klog.Error(addErrors)
-				egressFirewall.Status.Status = egressFirewallAddError
-			} else {
-
-				egressFirewall.Status.Status = egressFirewallAppliedCorrectly
+				status = egressFirewallAddError
 			}
 
-			err := oc.updateEgressFirewallWithRetry(egressFirewall)
+			err := oc.updateEgressFirewallStatus(egressFirewall.Namespace, egressFirewall.Name,
+				oc.getEgressFirewall(egressFirewall.Namespace), func(egressFirewall *egressfirewall.EgressFirewall) {
+					egressFirewall.Status.Status = status
+					setEgressFirewallReadyCondition(egressFirewall, addErrors)
+				})
 			if err != nil {
 				klog.Error(err)
 			}
@@ -710,13 +860,16 @@ func (oc *Controller) WatchEgressFirewall() *factory.Handler {
 			oldEgressFirewall := old.(*egressfirewall.EgressFirewall)
 			if !reflect.DeepEqual(oldEgressFirewall.Spec, newEgressFirewall.Spec) {
 				errList := oc.updateEgressFirewall(oldEgressFirewall, newEgressFirewall)
+				status := egressFirewallAppliedCorrectly
 				if errList != nil {
-					newEgressFirewall.Status.Status = egressFirewallUpdateError
+					status = egressFirewallUpdateError
 					klog.Error(errList)
-				} else {
-					newEgressFirewall.Status.Status = egressFirewallAppliedCorrectly
 				}
-				err := oc.updateEgressFirewallWithRetry(newEgressFirewall)
+				err := oc.updateEgressFirewallStatus(newEgressFirewall.Namespace, newEgressFirewall.Name,
+					oc.getEgressFirewall(newEgressFirewall.Namespace), func(egressFirewall *egressfirewall.EgressFirewall) {
+						egressFirewall.Status.Status = status
+						setEgressFirewallReadyCondition(egressFirewall, errList)
+					})
 				if err != nil {
 					klog.Error(err)
 				}
@@ -729,7 +882,7 @@ func (oc *Controller) WatchEgressFirewall() *factory.Handler {
 				klog.Error(deleteErrors)
 			}
 		},
-	}, nil)
+	}, oc.syncEgressFirewalls)
 }
 
 // WatchEgressNodes starts the watching of egress assignable nodes and calls
@@ -894,6 +1047,7 @@ func (oc *Controller) syncNodeGateway(node *kapi.Node, hostSubnets []*net.IPNet)
 		if err := gatewayCleanup(node.Name); err != nil {
 			return fmt.Errorf("error cleaning up gateway for node %s: %v", node.Name, err)
 		}
+		oc.deleteServiceLBNodeGateway(node.Name)
 		if err := oc.joinSwIPManager.releaseJoinLRPIPs(node.Name); err != nil {
 			return err
 		}
@@ -925,6 +1079,10 @@ func (oc *Controller) WatchNodes() {
 			}
 
 			klog.V(5).Infof("Added event for Node %q", node.Name)
+			// the changes of the node made while handling the event are applied to
+			// the load balancers of the services at once
+			defer oc.commitServiceLBNode(node.Name)
+			oc.setServiceLBNodeZone(node.Name, node.Labels[kapi.LabelTopologyZone])
 			hostSubnets, err := oc.addNode(node)
 			if err != nil {
 				klog.Errorf("NodeAdd: error creating subnet for node %s: %v", node.Name, err)
@@ -961,6 +1119,8 @@ func (oc *Controller) WatchNodes() {
 				// the hostsubnet is not assigned by ovn-kubernetes
 				return
 			}
+			defer oc.commitServiceLBNode(node.Name)
+			oc.setServiceLBNodeZone(node.Name, node.Labels[kapi.LabelTopologyZone])
 
 			var hostSubnets []*net.IPNet
 			_, failed := addNodeFailed.Load(node.Name)
@@ -1036,70 +1196,6 @@ func (oc *Controller) GetServiceVIPToName(vip string, protocol kapi.Protocol) (t
 	return namespace, ok
 }
 
-// setServiceLBToACL associates an empty load balancer with its associated ACL reject rule
-func (oc *Controller) setServiceACLToLB(lb, vip, acl string) {
-	if _, ok := oc.serviceLBMap[lb]; !ok {
-		oc.serviceLBMap[lb] = make(map[string]*loadBalancerConf)
-		oc.serviceLBMap[lb][vip] = &loadBalancerConf{rejectACL: acl}
-		return
-	}
-	if _, ok := oc.serviceLBMap[lb][vip]; !ok {
-		oc.serviceLBMap[lb][vip] = &loadBalancerConf{rejectACL: acl}
-		return
-	}
-	oc.serviceLBMap[lb][vip].rejectACL = acl
-}
-
-// setServiceEndpointsToLB associates a load balancer with endpoints
-func (oc *Controller) setServiceEndpointsToLB(lb, vip string, eps []string) {
-	if _, ok := oc.serviceLBMap[lb]; !ok {
-		oc.serviceLBMap[lb] = make(map[string]*loadBalancerConf)
-		oc.serviceLBMap[lb][vip] = &loadBalancerConf{endpoints: eps}
-		return
-	}
-	if _, ok := oc.serviceLBMap[lb][vip]; !ok {
-		oc.serviceLBMap[lb][vip] = &loadBalancerConf{endpoints: eps}
-		return
-	}
-	oc.serviceLBMap[lb][vip].endpoints = eps
-}
-
-// getServiceLBInfo returns the reject ACL and whether the number of endpoints for the service is greater than zero
-func (oc *Controller) getServiceLBInfo(lb, vip string) (string, bool) {
-	oc.serviceLBLock.Lock()
-	defer oc.serviceLBLock.Unlock()
-	conf, ok := oc.serviceLBMap[lb][vip]
-	if !ok {
-		conf = &loadBalancerConf{}
-	}
-	return conf.rejectACL, len(conf.endpoints) > 0
-}
-
-// removeServiceLB removes the entire LB entry for a VIP
-func (oc *Controller) removeServiceLB(lb, vip string) {
-	oc.serviceLBLock.Lock()
-	defer oc.serviceLBLock.Unlock()
-	delete(oc.serviceLBMap[lb], vip)
-}
-
-// removeServiceACL removes a specific ACL associated with a load balancer and ip:port
-func (oc *Controller) removeServiceACL(lb, vip string) {
-	oc.serviceLBLock.Lock()
-	defer oc.serviceLBLock.Unlock()
-	if _, ok := oc.serviceLBMap[lb][vip]; ok {
-		oc.serviceLBMap[lb][vip].rejectACL = ""
-	}
-}
-
-// removeServiceEndpoints removes endpoints associated with a load balancer and ip:port
-func (oc *Controller) removeServiceEndpoints(lb, vip string) {
-	oc.serviceLBLock.Lock()
-	defer oc.serviceLBLock.Unlock()
-	if _, ok := oc.serviceLBMap[lb][vip]; ok {
-		oc.serviceLBMap[lb][vip].endpoints = []string{}
-	}
-}
-
 // gatewayChanged() compares old annotations to new and returns true if something has changed.
 func gatewayChanged(oldNode, newNode *kapi.Node) bool {
 	oldL3GatewayConfig, _ := util.ParseNodeL3GatewayAnnotation(oldNode)
@@ -10,20 +10,11 @@ import (
 
 	"github.com/urfave/cli/v2"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/record"
-
-	egressfirewall "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
-	egressfirewallfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned/fake"
-	egressip "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
-	egressipfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/fake"
-	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 )
 
 const (
 	k8sTCPLoadBalancerIP    = "k8s_tcp_load_balancer"
-	k8sUDPLoadBalancerIP    = "k8s_udp_load_balancer"
-	k8sSCTPLoadBalancerIP   = "k8s_sctp_load_balancer"
 	fakeUUID                = "8a86f6d8-7972-4253-b0bd-ddbef66e9303"
 	fakeUUIDv6              = "8a86f6d8-7972-4253-b0bd-ddbef66e9304"
 	ovnClusterPortGroupUUID = "740515f3-7ece-4cd1-9be5-6fdb9066d198"
@@ -52,26 +43,9 @@ func NewFakeOVN(fexec *ovntest.FakeExec) *FakeOVN {
 }
 
 func (o *FakeOVN) start(ctx *cli.Context, objects ...runtime.Object) {
-	egressIPObjects := []runtime.Object{}
-	egressFirewallObjects := []runtime.Object{}
-	v1Objects := []runtime.Object{}
-	for _, object := range objects {
-		if _, isEgressIPObject := object.(*egressip.EgressIPList); isEgressIPObject {
-			egressIPObjects = append(egressIPObjects, object)
-		} else if _, isEgressFirewallObject := object.(*egressfirewall.EgressFirewallList); isEgressFirewallObject {
-			egressFirewallObjects = append(egressFirewallObjects, object)
-		} else {
-			v1Objects = append(v1Objects, object)
-		}
-	}
 	_, err := config.InitConfig(ctx, o.fakeExec, nil)
 	Expect(err).NotTo(HaveOccurred())
-	o.fakeClient = &util.OVNClientset{
-		KubeClient:           fake.NewSimpleClientset(v1Objects...),
-		EgressIPClient:       egressipfake.NewSimpleClientset(egressIPObjects...),
-		EgressFirewallClient: egressfirewallfake.NewSimpleClientset(egressFirewallObjects...),
-		APIExtensionsClient:  apiextensionsfake.NewSimpleClientset(),
-	}
+	o.fakeClient = util.GetOVNClientset(objects...)
 	o.init()
 }
 
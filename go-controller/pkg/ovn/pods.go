@@ -232,6 +232,21 @@ func (oc *Controller) getRoutingPodGWs(ns string) map[string][]net.IP {
 	return nsInfo.routingExternalPodGWs
 }
 
+// getRoutingAPBRouteGWs returns a copy of the gateways of the AdminPolicyBasedExternalRoutes
+// targeting the namespace, as the map is updated under the namespace lock
+func (oc *Controller) getRoutingAPBRouteGWs(ns string) map[string][]net.IP {
+	nsInfo := oc.getNamespaceLocked(ns)
+	if nsInfo == nil {
+		return nil
+	}
+	defer nsInfo.Unlock()
+	gws := make(map[string][]net.IP, len(nsInfo.apbRouteGWs))
+	for name, ips := range nsInfo.apbRouteGWs {
+		gws[name] = append([]net.IP{}, ips...)
+	}
+	return gws
+}
+
 func (oc *Controller) addLogicalPort(pod *kapi.Pod) (err error) {
 	// If a node does node have an assigned hostsubnet don't wait for the logical switch to appear
 	if oc.lsManager.IsNonHostSubnetSwitch(pod.Spec.NodeName) {
@@ -323,46 +338,61 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) (err error) {
 	}
 
 	if needsIP {
-		// try to get the IP from existing port in OVN first
-		podMac, podIfAddrs, err = oc.getPortAddresses(logicalSwitch, portName)
-		if err != nil {
-			return fmt.Errorf("failed to get pod addresses for pod %s on node: %s, err: %v",
-				portName, logicalSwitch, err)
-		}
-		needsNewAllocation := false
-		// ensure we have reserved the IPs found in OVN
-		if len(podIfAddrs) == 0 {
-			needsNewAllocation = true
-		} else if err = oc.lsManager.AllocateIPs(logicalSwitch, podIfAddrs); err != nil && err != ipallocator.ErrAllocated {
-			klog.Warningf("Unable to allocate IPs found on existing OVN port: %s, for pod %s on node: %s"+
-				" error: %v", util.JoinIPNetIPs(podIfAddrs, " "), portName, logicalSwitch, err)
-
-			needsNewAllocation = true
-		}
-		if needsNewAllocation {
-			// Previous attempts to use already configured IPs failed, need to assign new
-			podMac, podIfAddrs, err = oc.assignPodAddresses(logicalSwitch)
-			if err != nil {
-				return fmt.Errorf("failed to assign pod addresses for pod %s on node: %s, err: %v",
-					portName, logicalSwitch, err)
-			}
-		}
-
-		releaseIPs = true
 		var networks []*types.NetworkSelectionElement
 
 		networks, err = util.GetPodNetSelAnnotation(pod, util.DefNetworkAnnotation)
 		// handle error cases separately first to ensure binding to err, otherwise the
 		// defer will fail
 		if err != nil {
-			return fmt.Errorf("error while getting custom MAC config for port %q from "+
+			return fmt.Errorf("error while getting custom IP/MAC config for port %q from "+
 				"default-network's network-attachment: %v", portName, err)
 		} else if networks != nil && len(networks) != 1 {
-			err = fmt.Errorf("invalid network annotation size while getting custom MAC config"+
+			err = fmt.Errorf("invalid network annotation size while getting custom IP/MAC config"+
 				" for port %q", portName)
 			return err
 		}
 
+		if networks != nil {
+			if err = checkDefaultNetworkIPAMClaim(pod, networks[0]); err != nil {
+				return err
+			}
+		}
+
+		if networks != nil && len(networks[0].IPRequest) > 0 {
+			klog.V(5).Infof("Pod %s/%s requested custom IPs: %v", pod.Namespace, pod.Name, networks[0].IPRequest)
+			podMac, podIfAddrs, err = oc.assignRequestedPodAddresses(logicalSwitch, networks[0].IPRequest)
+			if err != nil {
+				return fmt.Errorf("failed to assign requested addresses to pod %s/%s on node %s: %v",
+					pod.Namespace, pod.Name, logicalSwitch, err)
+			}
+		} else {
+			// try to get the IP from existing port in OVN first
+			podMac, podIfAddrs, err = oc.getPortAddresses(logicalSwitch, portName)
+			if err != nil {
+				return fmt.Errorf("failed to get pod addresses for pod %s on node: %s, err: %v",
+					portName, logicalSwitch, err)
+			}
+			needsNewAllocation := false
+			// ensure we have reserved the IPs found in OVN
+			if len(podIfAddrs) == 0 {
+				needsNewAllocation = true
+			} else if err = oc.lsManager.AllocateIPs(logicalSwitch, podIfAddrs); err != nil && err != ipallocator.ErrAllocated {
+				klog.Warningf("Unable to allocate IPs found on existing OVN port: %s, for pod %s on node: %s"+
+					" error: %v", util.JoinIPNetIPs(podIfAddrs, " "), portName, logicalSwitch, err)
+
+				needsNewAllocation = true
+			}
+			if needsNewAllocation {
+				// Previous attempts to use already configured IPs failed, need to assign new
+				podMac, podIfAddrs, err = oc.assignPodAddresses(logicalSwitch)
+				if err != nil {
+					return fmt.Errorf("failed to assign pod addresses for pod %s on node: %s, err: %v",
+						portName, logicalSwitch, err)
+				}
+			}
+		}
+
+		releaseIPs = true
 		if networks != nil && networks[0].MacRequest != "" {
 			klog.V(5).Infof("Pod %s/%s requested custom MAC: %s", pod.Namespace, pod.Name, networks[0].MacRequest)
 			podMac, err = net.ParseMAC(networks[0].MacRequest)
@@ -442,13 +472,17 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) (err error) {
 	// add src-ip routes to GR if external gw annotation is set
 	routingExternalGWs := oc.getRoutingExternalGWs(pod.Namespace)
 	routingPodGWs := oc.getRoutingPodGWs(pod.Namespace)
+	routingAPBRouteGWs := oc.getRoutingAPBRouteGWs(pod.Namespace)
 
-	// if we have any external or pod Gateways, add routes
-	if len(routingExternalGWs) > 0 || len(routingPodGWs) > 0 {
+	// if we have any external, pod or policy Gateways, add routes
+	if len(routingExternalGWs) > 0 || len(routingPodGWs) > 0 || len(routingAPBRouteGWs) > 0 {
 		routingGWs := routingExternalGWs
 		for _, ipNets := range routingPodGWs {
 			routingGWs = append(routingGWs, ipNets...)
 		}
+		for _, ipNets := range routingAPBRouteGWs {
+			routingGWs = append(routingGWs, ipNets...)
+		}
 		err = oc.addGWRoutesForPod(routingGWs, podIfAddrs, pod.Namespace, pod.Spec.NodeName)
 		if err != nil {
 			return err
@@ -502,6 +536,51 @@ func (oc *Controller) assignPodAddresses(nodeName string) (net.HardwareAddr, []*
 	return podMAC, podCIDRs, nil
 }
 
+// Given a node and the IPs requested by a pod, reserves one IP of each of the node's
+// subnets (from the IPAM) for the pod
+func (oc *Controller) assignRequestedPodAddresses(nodeName string, ipRequest []string) (net.HardwareAddr, []*net.IPNet, error) {
+	nodeSubnets := oc.lsManager.GetSwitchSubnets(nodeName)
+	if nodeSubnets == nil {
+		return nil, nil, fmt.Errorf("cannot retrieve the subnets of node %s", nodeName)
+	}
+	if len(ipRequest) != len(nodeSubnets) {
+		return nil, nil, fmt.Errorf("%d IPs requested for the %d subnets of node %s",
+			len(ipRequest), len(nodeSubnets), nodeName)
+	}
+
+	podCIDRs := make([]*net.IPNet, 0, len(ipRequest))
+	for _, ipStr := range ipRequest {
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			var err error
+			if ip, _, err = net.ParseCIDR(ipStr); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse requested IP %q", ipStr)
+			}
+		}
+		var podCIDR *net.IPNet
+		for _, subnet := range nodeSubnets {
+			if subnet.Contains(ip) {
+				podCIDR = &net.IPNet{IP: ip, Mask: subnet.Mask}
+				break
+			}
+		}
+		if podCIDR == nil {
+			return nil, nil, fmt.Errorf("requested IP %s is not in the subnets %s of node %s",
+				ip, util.JoinIPNets(nodeSubnets, ","), nodeName)
+		}
+		podCIDRs = append(podCIDRs, podCIDR)
+	}
+
+	if err := oc.lsManager.AllocateIPs(nodeName, podCIDRs); err != nil {
+		if err == ipallocator.ErrAllocated {
+			return nil, nil, fmt.Errorf("requested IPs %s conflict with IPs already allocated on node %s",
+				util.JoinIPNetIPs(podCIDRs, " "), nodeName)
+		}
+		return nil, nil, err
+	}
+	return util.IPAddrToHWAddr(podCIDRs[0].IP), podCIDRs, nil
+}
+
 // Given a pod and the node on which it is scheduled, get all addresses currently assigned
 // to it from the nbdb.
 func (oc *Controller) getPortAddresses(nodeName, portName string) (net.HardwareAddr, []*net.IPNet, error) {
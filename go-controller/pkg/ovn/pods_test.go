@@ -475,6 +475,69 @@ var _ = Describe("OVN Pod Operations", func() {
 			err := app.Run([]string{app.Name})
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("assigns the IPs and MAC requested in the default network selection", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespaceT := *newNamespace("namespace1")
+				t := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.10",
+					"0a:58:0a:80:01:aa",
+					namespaceT.Name,
+				)
+
+				t.baseCmds(fExec)
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespaceT,
+						},
+					},
+				)
+				t.populateLogicalSwitchCache(fakeOvn)
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchPods()
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				selection := `[{"name":"default","namespace":"ovn-kubernetes","ips":["` + t.podIP + `/24"],"mac":"` + t.podMAC + `"}]`
+				pod := newPod(t.namespace, t.podName, t.nodeName, "")
+				pod.Annotations = map[string]string{util.DefNetworkAnnotation: selection}
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() string { return getPodAnnotations(fakeOvn.fakeClient.KubeClient, t.namespace, t.podName) }, 2).Should(MatchJSON(`{"default": {"ip_addresses":["` + t.podIP + `/24"], "mac_address":"` + t.podMAC + `", "gateway_ips": ["` + t.nodeGWIP + `"], "ip_address":"` + t.podIP + `/24", "gateway_ip": "` + t.nodeGWIP + `"}}`))
+
+				lsp, err := fakeOvn.ovnNBClient.LSPGet(t.portName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lsp.Addresses).To(Equal([]string{t.podMAC + " " + t.podIP}))
+
+				// a second pod requesting the same IP is rejected
+				conflicting := newPod(t.namespace, "myPod2", t.nodeName, "")
+				conflicting.Annotations = map[string]string{util.DefNetworkAnnotation: `[{"name":"default","namespace":"ovn-kubernetes","ips":["` + t.podIP + `"]}]`}
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), conflicting, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				var recordedEvent string
+				Eventually(fakeOvn.fakeRecorder.Events).Should(Receive(&recordedEvent))
+				Expect(recordedEvent).To(ContainSubstring("ErrorAddingLogicalPort"))
+				Expect(recordedEvent).To(ContainSubstring("requested IPs " + t.podIP + " conflict with IPs already allocated on node " + t.nodeName))
+				Expect(getPodAnnotations(fakeOvn.fakeClient.KubeClient, t.namespace, "myPod2")).To(BeEmpty())
+
+				// the IP is released when the first pod is deleted
+				err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(t.namespace).Delete(context.TODO(), t.podName, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() error {
+					return fakeOvn.controller.lsManager.AllocateIPs(t.nodeName, []*net.IPNet{ovntest.MustParseIPNet(t.podIP + "/24")})
+				}, 2).Should(Succeed())
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 
 	Context("on startup", func() {
@@ -1,8 +1,10 @@
 package ovn
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
@@ -10,6 +12,7 @@ import (
 	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	utilnet "k8s.io/utils/net"
@@ -56,8 +59,123 @@ const (
 	defaultMcastDenyPriority = "1011"
 	// Default multicast allow acl rule priority
 	defaultMcastAllowPriority = "1012"
+	// Suffixes of the per-namespace port groups holding the pods that are
+	// subject to a default deny rule
+	ingressDefaultDenySuffix = "ingressDefaultDeny"
+	egressDefaultDenySuffix  = "egressDefaultDeny"
 )
 
+// ACLLoggingLevels holds the severities, parsed from a namespace's
+// k8s.ovn.org/acl-logging annotation, at which the ACLs implementing its
+// network policies log allowed and denied connections. An empty severity
+// disables logging.
+type ACLLoggingLevels struct {
+	Allow string `json:"allow,omitempty"`
+	Deny  string `json:"deny,omitempty"`
+}
+
+// aclLoggingSeverities are the severities accepted by the OVN ACL log column
+var aclLoggingSeverities = sets.NewString("alert", "warning", "notice", "info", "debug")
+
+func parseACLLoggingAnnotation(annotation string) (ACLLoggingLevels, error) {
+	var levels ACLLoggingLevels
+	if annotation == "" {
+		return levels, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), &levels); err != nil {
+		return ACLLoggingLevels{}, fmt.Errorf("could not parse ACL logging annotation %q: %v", annotation, err)
+	}
+	for _, severity := range []string{levels.Allow, levels.Deny} {
+		if severity != "" && !aclLoggingSeverities.Has(severity) {
+			return ACLLoggingLevels{}, fmt.Errorf("invalid ACL logging severity %q in annotation %q", severity, annotation)
+		}
+	}
+	return levels, nil
+}
+
+// getACLNameArg returns the nbctl argument naming an ACL, truncated to the
+// 63 characters OVN allows
+func getACLNameArg(name string) string {
+	return fmt.Sprintf("name=%.63s", name)
+}
+
+// getACLLoggingArgs returns the nbctl arguments enabling logging on a new ACL
+// at the given severity, if any
+func getACLLoggingArgs(severity string) []string {
+	if severity == "" {
+		return nil
+	}
+	return []string{"log=true", "severity=" + severity}
+}
+
+// setACLLogging updates the log and severity columns of the given ACLs
+func setACLLogging(uuids []string, severity string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+	var args []string
+	for _, uuid := range uuids {
+		if len(args) > 0 {
+			args = append(args, "--")
+		}
+		if severity == "" {
+			args = append(args, "set", "acl", uuid, "log=false")
+		} else {
+			args = append(args, "set", "acl", uuid, "log=true", "severity="+severity)
+		}
+	}
+	_, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to update logging of ACLs %v, stderr: %q (%v)", uuids, stderr, err)
+	}
+	return nil
+}
+
+// updateACLLoggingForNamespace applies the namespace's ACL logging levels to
+// the existing ACLs of its default deny port groups and network policies
+// without recreating them. Caller must hold the namespace's namespaceInfo
+// object lock.
+func (oc *Controller) updateACLLoggingForNamespace(ns string, nsInfo *namespaceInfo) error {
+	var denyUUIDs []string
+	for _, policyType := range []knet.PolicyType{knet.PolicyTypeIngress, knet.PolicyTypeEgress} {
+		gressSuffix := ingressDefaultDenySuffix
+		if policyType == knet.PolicyTypeEgress {
+			gressSuffix = egressDefaultDenySuffix
+		}
+		match := getACLMatch(defaultDenyPortGroup(ns, gressSuffix), "", policyType)
+		uuids, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+			"--columns=_uuid", "find", "ACL", match, "action=drop",
+			fmt.Sprintf("external-ids:default-deny-policy-type=%s", policyType))
+		if err != nil {
+			return fmt.Errorf("find failed to get the default deny rules for namespace %s, "+
+				"stderr: %q (%v)", ns, stderr, err)
+		}
+		denyUUIDs = append(denyUUIDs, strings.Fields(uuids)...)
+	}
+	if err := setACLLogging(denyUUIDs, nsInfo.aclLogging.Deny); err != nil {
+		return err
+	}
+
+	for _, np := range nsInfo.networkPolicies {
+		np.Lock()
+		for _, gp := range np.ingressPolicies {
+			gp.aclLogging = nsInfo.aclLogging.Allow
+		}
+		for _, gp := range np.egressPolicies {
+			gp.aclLogging = nsInfo.aclLogging.Allow
+		}
+		np.Unlock()
+	}
+	uuids, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+		"--columns=_uuid", "find", "ACL", "action=allow-related",
+		fmt.Sprintf("external-ids:namespace=%s", ns))
+	if err != nil {
+		return fmt.Errorf("find failed to get the allow rules for namespace %s, "+
+			"stderr: %q (%v)", ns, stderr, err)
+	}
+	return setACLLogging(strings.Fields(uuids), nsInfo.aclLogging.Allow)
+}
+
 func (oc *Controller) syncNetworkPolicies(networkPolicies []interface{}) {
 	expectedPolicies := make(map[string]map[string]bool)
 	for _, npInterface := range networkPolicies {
@@ -78,6 +196,15 @@ func (oc *Controller) syncNetworkPolicies(networkPolicies []interface{}) {
 	}
 
 	err := oc.addressSetFactory.ForEachAddressSet(func(addrSetName, namespaceName, policyName string) {
+		// the address sets of the wildcard DNS names of egress firewalls are
+		// synced with the egress firewalls
+		if util.IsWildcardDNSName(addrSetName) {
+			return
+		}
+		// the address sets of the admin network policies are synced with them
+		if namespaceName == anpPrefix {
+			return
+		}
 		if policyName != "" && !expectedPolicies[namespaceName][policyName] {
 			// policy doesn't exist on k8s. Delete the port group
 			portGroupName := fmt.Sprintf("%s_%s", namespaceName, policyName)
@@ -93,6 +220,11 @@ func (oc *Controller) syncNetworkPolicies(networkPolicies []interface{}) {
 	if err != nil {
 		klog.Errorf("Error in syncing network policies: %v", err)
 	}
+
+	// Default deny port groups used to be shared by all namespaces; they are
+	// now created per namespace so remove any left over cluster-wide ones.
+	deletePortGroup(ingressDefaultDenySuffix)
+	deletePortGroup(egressDefaultDenySuffix)
 }
 
 func addAllowACLFromNode(logicalSwitch string, mgmtPortIP net.IP) error {
@@ -127,6 +259,13 @@ func getACLMatch(portGroupName, match string, policyType knet.PolicyType) string
 }
 
 func addACLPortGroup(portGroupUUID, direction, priority, match, action string, policyType knet.PolicyType) error {
+	return addACLPortGroupWithLogging(portGroupUUID, direction, priority, match, action, policyType, "", "")
+}
+
+// addACLPortGroupWithLogging creates an ACL on the given port group, naming
+// it aclName and logging its hits at aclSeverity when aclSeverity is not empty
+func addACLPortGroupWithLogging(portGroupUUID, direction, priority, match, action string,
+	policyType knet.PolicyType, aclName, aclSeverity string) error {
 	uuid, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
 		"--columns=_uuid", "find", "ACL", match, "action="+action,
 		fmt.Sprintf("external-ids:default-deny-policy-type=%s", policyType))
@@ -136,15 +275,26 @@ func addACLPortGroup(portGroupUUID, direction, priority, match, action string, p
 	}
 
 	if uuid != "" {
+		// The ACL may be left over from before a restart: reconcile its
+		// logging with the namespace's current severity.
+		if aclName != "" {
+			return setACLLogging([]string{uuid}, aclSeverity)
+		}
 		return nil
 	}
 
-	_, stderr, err = util.RunOVNNbctl("--id=@acl", "create", "acl",
+	args := []string{"--id=@acl", "create", "acl",
 		fmt.Sprintf("priority=%s", priority),
-		fmt.Sprintf("direction=%s", direction), match, "action="+action,
+		fmt.Sprintf("direction=%s", direction), match, "action=" + action}
+	if aclName != "" {
+		args = append(args, getACLNameArg(aclName))
+	}
+	args = append(args, getACLLoggingArgs(aclSeverity)...)
+	args = append(args,
 		fmt.Sprintf("external-ids:default-deny-policy-type=%s", policyType),
 		"--", "add", "port_group", portGroupUUID,
 		"acls", "@acl")
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return fmt.Errorf("error executing create ACL command for "+
 			"policy type %s stderr: %q (%v)", policyType, stderr, err)
@@ -197,27 +347,38 @@ func deleteFromPortGroup(portGroup string, portInfo *lpInfo) error {
 	return nil
 }
 
-func (oc *Controller) createDefaultDenyPortGroup(policyType knet.PolicyType) error {
-	var portGroupName string
+// defaultDenyPortGroup returns the name of the namespace's port group holding
+// the pods that a NetworkPolicy subjects to ingress or egress default deny
+func defaultDenyPortGroup(namespace, gressSuffix string) string {
+	return hashedPortGroup(namespace) + "_" + gressSuffix
+}
+
+// createDefaultDenyPortGroup creates the namespace's default deny port group
+// for policyType along with its drop and allow ARP ACLs. Caller must hold the
+// namespace's namespaceInfo object lock.
+func (oc *Controller) createDefaultDenyPortGroup(ns string, nsInfo *namespaceInfo, policyType knet.PolicyType) error {
+	var portGroupName, gressSuffix string
 	if policyType == knet.PolicyTypeIngress {
-		if oc.portGroupIngressDeny != "" {
+		if nsInfo.portGroupIngressDenyUUID != "" {
 			return nil
 		}
-		portGroupName = "ingressDefaultDeny"
+		gressSuffix = ingressDefaultDenySuffix
 	} else if policyType == knet.PolicyTypeEgress {
-		if oc.portGroupEgressDeny != "" {
+		if nsInfo.portGroupEgressDenyUUID != "" {
 			return nil
 		}
-		portGroupName = "egressDefaultDeny"
+		gressSuffix = egressDefaultDenySuffix
 	}
-	portGroupUUID, err := createPortGroup(portGroupName, portGroupName)
+	portGroupName = defaultDenyPortGroup(ns, gressSuffix)
+	portGroupUUID, err := createPortGroup(ns+"_"+gressSuffix, portGroupName)
 	if err != nil {
 		return fmt.Errorf("failed to create port_group for %s (%v)",
 			portGroupName, err)
 	}
 	match := getACLMatch(portGroupName, "", policyType)
-	err = addACLPortGroup(portGroupUUID, toLport,
-		defaultDenyPriority, match, "drop", policyType)
+	err = addACLPortGroupWithLogging(portGroupUUID, toLport,
+		defaultDenyPriority, match, "drop", policyType,
+		ns+"_"+gressSuffix, nsInfo.aclLogging.Deny)
 	if err != nil {
 		return fmt.Errorf("failed to create default deny ACL for port group %v", err)
 	}
@@ -230,13 +391,26 @@ func (oc *Controller) createDefaultDenyPortGroup(policyType knet.PolicyType) err
 	}
 
 	if policyType == knet.PolicyTypeIngress {
-		oc.portGroupIngressDeny = portGroupUUID
+		nsInfo.portGroupIngressDenyUUID = portGroupUUID
 	} else if policyType == knet.PolicyTypeEgress {
-		oc.portGroupEgressDeny = portGroupUUID
+		nsInfo.portGroupEgressDenyUUID = portGroupUUID
 	}
 	return nil
 }
 
+// deleteDefaultDenyPortGroups removes the namespace's default deny port groups
+// and their ACLs. Caller must hold the namespace's namespaceInfo object lock.
+func deleteDefaultDenyPortGroups(ns string, nsInfo *namespaceInfo) {
+	if nsInfo.portGroupIngressDenyUUID != "" {
+		deletePortGroup(defaultDenyPortGroup(ns, ingressDefaultDenySuffix))
+		nsInfo.portGroupIngressDenyUUID = ""
+	}
+	if nsInfo.portGroupEgressDenyUUID != "" {
+		deletePortGroup(defaultDenyPortGroup(ns, egressDefaultDenySuffix))
+		nsInfo.portGroupEgressDenyUUID = ""
+	}
+}
+
 // Creates the match string used for ACLs allowing incoming multicast into a
 // namespace, that is, from IPs that are in the namespace's address set.
 func getMulticastACLMatch(nsInfo *namespaceInfo) string {
@@ -372,17 +546,6 @@ func (oc *Controller) localPodAddDefaultDeny(
 	oc.lspMutex.Lock()
 	defer oc.lspMutex.Unlock()
 
-	err := oc.createDefaultDenyPortGroup(knet.PolicyTypeIngress)
-	if err != nil {
-		klog.Errorf(err.Error())
-		return
-	}
-	err = oc.createDefaultDenyPortGroup(knet.PolicyTypeEgress)
-	if err != nil {
-		klog.Errorf(err.Error())
-		return
-	}
-
 	// Default deny rule.
 	// 1. Any pod that matches a network policy should get a default
 	// ingress deny rule.  This is irrespective of whether there
@@ -397,7 +560,7 @@ func (oc *Controller) localPodAddDefaultDeny(
 	// Handle condition 1 above.
 	if !(len(policy.Spec.PolicyTypes) == 1 && policy.Spec.PolicyTypes[0] == knet.PolicyTypeEgress) {
 		if oc.lspIngressDenyCache[portInfo.name] == 0 {
-			if err := addToPortGroup(oc.portGroupIngressDeny, portInfo); err != nil {
+			if err := addToPortGroup(defaultDenyPortGroup(policy.Namespace, ingressDefaultDenySuffix), portInfo); err != nil {
 				klog.Warningf("Failed to add port %s to ingress deny ACL: %v", portInfo.name, err)
 			}
 		}
@@ -408,7 +571,7 @@ func (oc *Controller) localPodAddDefaultDeny(
 	if (len(policy.Spec.PolicyTypes) == 1 && policy.Spec.PolicyTypes[0] == knet.PolicyTypeEgress) ||
 		len(policy.Spec.Egress) > 0 || len(policy.Spec.PolicyTypes) == 2 {
 		if oc.lspEgressDenyCache[portInfo.name] == 0 {
-			if err := addToPortGroup(oc.portGroupEgressDeny, portInfo); err != nil {
+			if err := addToPortGroup(defaultDenyPortGroup(policy.Namespace, egressDefaultDenySuffix), portInfo); err != nil {
 				klog.Warningf("Failed to add port %s to egress deny ACL: %v", portInfo.name, err)
 			}
 		}
@@ -427,7 +590,7 @@ func (oc *Controller) localPodDelDefaultDeny(
 		if oc.lspIngressDenyCache[portInfo.name] > 0 {
 			oc.lspIngressDenyCache[portInfo.name]--
 			if oc.lspIngressDenyCache[portInfo.name] == 0 {
-				if err := deleteFromPortGroup(oc.portGroupIngressDeny, portInfo); err != nil {
+				if err := deleteFromPortGroup(defaultDenyPortGroup(np.namespace, ingressDefaultDenySuffix), portInfo); err != nil {
 					klog.Warningf("Failed to remove port %s from ingress deny ACL: %v", portInfo.name, err)
 				}
 			}
@@ -440,7 +603,7 @@ func (oc *Controller) localPodDelDefaultDeny(
 		if oc.lspEgressDenyCache[portInfo.name] > 0 {
 			oc.lspEgressDenyCache[portInfo.name]--
 			if oc.lspEgressDenyCache[portInfo.name] == 0 {
-				if err := deleteFromPortGroup(oc.portGroupEgressDeny, portInfo); err != nil {
+				if err := deleteFromPortGroup(defaultDenyPortGroup(np.namespace, egressDefaultDenySuffix), portInfo); err != nil {
 					klog.Warningf("Failed to remove port %s from egress deny ACL: %v", portInfo.name, err)
 				}
 			}
@@ -491,6 +654,14 @@ func (oc *Controller) handleLocalPodSelectorAddFunc(
 	}
 
 	np.localPods[logicalPort] = portInfo
+
+	// the named ports of the ingress rules are the ports of the local pods
+	for _, gp := range np.ingressPolicies {
+		if err := gp.addNamedPortDst(oc.addressSetFactory, pod, np.portGroupName, np.portGroupUUID); err != nil {
+			klog.Errorf("Failed to resolve the named ports of pod %s for network policy %s/%s: %v",
+				logicalPort, np.namespace, np.name, err)
+		}
+	}
 }
 
 func (oc *Controller) handleLocalPodSelectorDelFunc(
@@ -532,6 +703,13 @@ func (oc *Controller) handleLocalPodSelectorDelFunc(
 		return
 	}
 
+	for _, gp := range np.ingressPolicies {
+		if err := gp.deleteNamedPortDst(oc.addressSetFactory, pod, np.portGroupName, np.portGroupUUID); err != nil {
+			klog.Errorf("Failed to delete the named ports of pod %s for network policy %s/%s: %v",
+				logicalPort, np.namespace, np.name, err)
+		}
+	}
+
 	_, stderr, err := util.RunOVNNbctl("--if-exists", "remove",
 		"port_group", np.portGroupUUID, "ports", portInfo.uuid)
 	if err != nil {
@@ -590,8 +768,17 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 		return
 	}
 
+	for _, policyType := range []knet.PolicyType{knet.PolicyTypeIngress, knet.PolicyTypeEgress} {
+		if err := oc.createDefaultDenyPortGroup(policy.Namespace, nsInfo, policyType); err != nil {
+			nsInfo.Unlock()
+			klog.Errorf(err.Error())
+			return
+		}
+	}
+
 	np := NewNamespacePolicy(policy)
 	nsInfo.networkPolicies[policy.Name] = np
+	aclLogging := nsInfo.aclLogging.Allow
 	np.Lock()
 	nsInfo.Unlock()
 
@@ -619,6 +806,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 		klog.V(5).Infof("Network policy ingress is %+v", ingressJSON)
 
 		ingress := newGressPolicy(knet.PolicyTypeIngress, i, policy.Namespace, policy.Name)
+		ingress.aclLogging = aclLogging
 
 		// Each ingress rule can have multiple ports to which we allow traffic.
 		for _, portJSON := range ingressJSON.Ports {
@@ -654,6 +842,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 		klog.V(5).Infof("Network policy egress is %+v", egressJSON)
 
 		egress := newGressPolicy(knet.PolicyTypeEgress, i, policy.Namespace, policy.Name)
+		egress.aclLogging = aclLogging
 
 		// Each egress rule can have multiple ports to which we allow traffic.
 		for _, portJSON := range egressJSON.Ports {
@@ -667,10 +856,15 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 			}
 		}
 
+		// The named ports of a rule allowing the traffic to all destinations
+		// or to ipBlocks may be exposed by any pod of the cluster
+		egress.namedPortsFromAllPods = len(egress.namedPortPolicies) > 0 && len(egressJSON.To) == 0
+
 		for _, toJSON := range egressJSON.To {
 			// Add IPBlock to egress network policy
 			if toJSON.IPBlock != nil {
 				egress.addIPBlock(toJSON.IPBlock)
+				egress.namedPortsFromAllPods = len(egress.namedPortPolicies) > 0
 			}
 
 			policyHandlers = append(policyHandlers, policyHandler{
@@ -701,6 +895,12 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 			// populates ingress.peerAddressSets
 			oc.handlePeerNamespaceSelector(policy,
 				handler.namespaceSelector, handler.gress, np)
+			if handler.gress.policyType == knet.PolicyTypeEgress && len(handler.gress.namedPortPolicies) > 0 &&
+				!handler.gress.namedPortsFromAllPods {
+				// The named ports of an egress rule are resolved against
+				// the peer pods, so watch the pods of the namespaces too
+				oc.handleNamedPortDstSelector(handler.namespaceSelector, handler.gress, np)
+			}
 		} else if handler.podSelector != nil {
 			// For each peer pod selector, we create a watcher that
 			// populates the addressSet
@@ -708,6 +908,12 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 				handler.gress, np)
 		}
 	}
+
+	for _, egress := range np.egressPolicies {
+		if egress.namedPortsFromAllPods {
+			oc.handleNamedPortDstSelector(nil, egress, np)
+		}
+	}
 }
 
 // deletes the namespacePolicy for policy and returns it, locked
@@ -768,22 +974,93 @@ func (oc *Controller) destroyNamespacePolicy(np *namespacePolicy) {
 
 // handlePeerPodSelectorAddUpdate adds the IP address of a pod that has been
 // selected as a peer by a NetworkPolicy's ingress/egress section to that
-// ingress/egress address set
-func (oc *Controller) handlePeerPodSelectorAddUpdate(gp *gressPolicy, obj interface{}) {
+// ingress/egress address set, and resolves the named ports of an egress
+// section against its container ports
+func (oc *Controller) handlePeerPodSelectorAddUpdate(np *namespacePolicy, gp *gressPolicy, obj interface{}) {
 	pod := obj.(*kapi.Pod)
 	if err := gp.addPeerPod(pod); err != nil {
 		klog.Errorf(err.Error())
 	}
+
+	if gp.policyType == knet.PolicyTypeEgress && !gp.namedPortsFromAllPods {
+		oc.handleNamedPortDstAddUpdate(np, gp, pod)
+	}
 }
 
 // handlePeerPodSelectorDelete removes the IP address of a pod that no longer
 // matches a NetworkPolicy ingress/egress section's selectors from that
 // ingress/egress address set
-func (oc *Controller) handlePeerPodSelectorDelete(gp *gressPolicy, obj interface{}) {
+func (oc *Controller) handlePeerPodSelectorDelete(np *namespacePolicy, gp *gressPolicy, obj interface{}) {
 	pod := obj.(*kapi.Pod)
 	if err := gp.deletePeerPod(pod); err != nil {
 		klog.Errorf(err.Error())
 	}
+
+	if gp.policyType == knet.PolicyTypeEgress && !gp.namedPortsFromAllPods {
+		oc.handleNamedPortDstDelete(np, gp, pod)
+	}
+}
+
+// handleNamedPortDstAddUpdate resolves the named ports of an egress section
+// against the container ports of a pod the section allows the traffic to
+func (oc *Controller) handleNamedPortDstAddUpdate(np *namespacePolicy, gp *gressPolicy, pod *kapi.Pod) {
+	if len(gp.namedPortPolicies) == 0 {
+		return
+	}
+	np.Lock()
+	defer np.Unlock()
+	if np.deleted {
+		return
+	}
+	if err := gp.addNamedPortDst(oc.addressSetFactory, pod, np.portGroupName, np.portGroupUUID); err != nil {
+		klog.Errorf("Failed to resolve the named ports of pod %s/%s for network policy %s/%s: %v",
+			pod.Namespace, pod.Name, np.namespace, np.name, err)
+	}
+}
+
+// handleNamedPortDstDelete stops allowing the traffic of an egress section to
+// the named ports of a pod
+func (oc *Controller) handleNamedPortDstDelete(np *namespacePolicy, gp *gressPolicy, pod *kapi.Pod) {
+	if len(gp.namedPortPolicies) == 0 {
+		return
+	}
+	np.Lock()
+	defer np.Unlock()
+	if np.deleted {
+		return
+	}
+	if err := gp.deleteNamedPortDst(oc.addressSetFactory, pod, np.portGroupName, np.portGroupUUID); err != nil {
+		klog.Errorf("Failed to delete the named ports of pod %s/%s for network policy %s/%s: %v",
+			pod.Namespace, pod.Name, np.namespace, np.name, err)
+	}
+}
+
+// handleNamedPortDstSelector resolves the named ports of an egress section
+// against the pods of the namespaces matching namespaceSelector, or against
+// all the pods of the cluster when namespaceSelector is nil. Unlike the peer
+// pod handlers, it does not add the pods to the section's address set.
+func (oc *Controller) handleNamedPortDstSelector(namespaceSelector *metav1.LabelSelector,
+	gp *gressPolicy, np *namespacePolicy) {
+	if namespaceSelector != nil {
+		oc.handleNamespaceAndPodSelector(namespaceSelector, &metav1.LabelSelector{}, np,
+			func(pod *kapi.Pod) { oc.handleNamedPortDstAddUpdate(np, gp, pod) },
+			func(pod *kapi.Pod) { oc.handleNamedPortDstDelete(np, gp, pod) })
+		return
+	}
+
+	h := oc.watchFactory.AddFilteredPodHandler("", nil,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				oc.handleNamedPortDstAddUpdate(np, gp, obj.(*kapi.Pod))
+			},
+			DeleteFunc: func(obj interface{}) {
+				oc.handleNamedPortDstDelete(np, gp, obj.(*kapi.Pod))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oc.handleNamedPortDstAddUpdate(np, gp, newObj.(*kapi.Pod))
+			},
+		}, nil)
+	np.podHandlerList = append(np.podHandlerList, h)
 }
 
 func (oc *Controller) handlePeerPodSelector(
@@ -796,13 +1073,13 @@ func (oc *Controller) handlePeerPodSelector(
 	h := oc.watchFactory.AddFilteredPodHandler(policy.Namespace, sel,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				oc.handlePeerPodSelectorAddUpdate(gp, obj)
+				oc.handlePeerPodSelectorAddUpdate(np, gp, obj)
 			},
 			DeleteFunc: func(obj interface{}) {
-				oc.handlePeerPodSelectorDelete(gp, obj)
+				oc.handlePeerPodSelectorDelete(np, gp, obj)
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				oc.handlePeerPodSelectorAddUpdate(gp, newObj)
+				oc.handlePeerPodSelectorAddUpdate(np, gp, newObj)
 			},
 		}, nil)
 	np.podHandlerList = append(np.podHandlerList, h)
@@ -815,6 +1092,19 @@ func (oc *Controller) handlePeerNamespaceAndPodSelector(
 	gp *gressPolicy,
 	np *namespacePolicy) {
 
+	oc.handleNamespaceAndPodSelector(namespaceSelector, podSelector, np,
+		func(pod *kapi.Pod) { oc.handlePeerPodSelectorAddUpdate(np, gp, pod) },
+		func(pod *kapi.Pod) { oc.handlePeerPodSelectorDelete(np, gp, pod) })
+}
+
+// handleNamespaceAndPodSelector calls addPod and deletePod on the events of
+// the pods matching podSelector in the namespaces matching namespaceSelector
+func (oc *Controller) handleNamespaceAndPodSelector(
+	namespaceSelector *metav1.LabelSelector,
+	podSelector *metav1.LabelSelector,
+	np *namespacePolicy,
+	addPod, deletePod func(pod *kapi.Pod)) {
+
 	// NetworkPolicy is validated by the apiserver; this can't fail.
 	nsSel, _ := metav1.LabelSelectorAsSelector(namespaceSelector)
 	podSel, _ := metav1.LabelSelectorAsSelector(podSelector)
@@ -830,18 +1120,18 @@ func (oc *Controller) handlePeerNamespaceAndPodSelector(
 					return
 				}
 
-				// The AddFilteredPodHandler call might call handlePeerPodSelectorAddUpdate
-				// on existing pods so we can't be holding the lock at this point
+				// The AddFilteredPodHandler call might call addPod on
+				// existing pods so we can't be holding the lock at this point
 				podHandler := oc.watchFactory.AddFilteredPodHandler(namespace.Name, podSel,
 					cache.ResourceEventHandlerFuncs{
 						AddFunc: func(obj interface{}) {
-							oc.handlePeerPodSelectorAddUpdate(gp, obj)
+							addPod(obj.(*kapi.Pod))
 						},
 						DeleteFunc: func(obj interface{}) {
-							oc.handlePeerPodSelectorDelete(gp, obj)
+							deletePod(obj.(*kapi.Pod))
 						},
 						UpdateFunc: func(oldObj, newObj interface{}) {
-							oc.handlePeerPodSelectorAddUpdate(gp, newObj)
+							addPod(newObj.(*kapi.Pod))
 						},
 					}, nil)
 				np.Lock()
@@ -859,7 +1149,7 @@ func (oc *Controller) handlePeerNamespaceAndPodSelector(
 				pods, _ := oc.watchFactory.GetPods(namespace.Name)
 
 				for _, pod := range pods {
-					oc.handlePeerPodSelectorDelete(gp, pod)
+					deletePod(pod)
 				}
 
 			},
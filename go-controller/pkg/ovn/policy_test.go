@@ -19,6 +19,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type networkPolicy struct{}
@@ -46,60 +47,69 @@ func newNetworkPolicy(name, namespace string, podSelector metav1.LabelSelector,
 }
 
 func (n networkPolicy) baseCmds(fexec *ovntest.FakeExec, networkPolicy *knet.NetworkPolicy) string {
-	readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
-	hashedGroupName := hashedPortGroup(readableGroupName)
+	// legacy cluster-wide default deny port groups are removed on sync
 	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=%s", hashedGroupName),
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 create port_group name=%s external-ids:name=%s", hashedGroupName, readableGroupName),
-		Output: readableGroupName,
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=ingressDefaultDeny",
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=egressDefaultDeny",
 	})
-	return readableGroupName
-}
-
-const (
-	ingressDenyPG string = "ingressDefaultDeny"
-	egressDenyPG  string = "egressDefaultDeny"
-)
-
-func (n networkPolicy) addLocalPodCmds(fexec *ovntest.FakeExec, networkPolicy *knet.NetworkPolicy) {
+	ingressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, ingressDefaultDenySuffix)
+	egressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, egressDefaultDenySuffix)
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=ingressDefaultDeny",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + ingressDenyPG,
 		Output: ingressDenyPG,
 	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"outport == @ingressDefaultDeny\" action=drop external-ids:default-deny-policy-type=Ingress",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"outport == @" + ingressDenyPG + "\" action=drop external-ids:default-deny-policy-type=Ingress",
 		Output: fakeUUID,
 	})
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 set acl " + fakeUUID + " log=false",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"outport == @ingressDefaultDeny && arp\" action=allow external-ids:default-deny-policy-type=Ingress",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"outport == @" + ingressDenyPG + " && arp\" action=allow external-ids:default-deny-policy-type=Ingress",
 		Output: fakeUUID,
 	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=egressDefaultDeny",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + egressDenyPG,
 		Output: egressDenyPG,
 	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"inport == @egressDefaultDeny\" action=drop external-ids:default-deny-policy-type=Egress",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"inport == @" + egressDenyPG + "\" action=drop external-ids:default-deny-policy-type=Egress",
 		Output: fakeUUID,
 	})
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 set acl " + fakeUUID + " log=false",
+	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"inport == @egressDefaultDeny && arp\" action=allow external-ids:default-deny-policy-type=Egress",
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"inport == @" + egressDenyPG + " && arp\" action=allow external-ids:default-deny-policy-type=Egress",
 		Output: fakeUUID,
 	})
+
+	readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
+	hashedGroupName := hashedPortGroup(readableGroupName)
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=%s", hashedGroupName),
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 create port_group name=%s external-ids:name=%s", hashedGroupName, readableGroupName),
+		Output: readableGroupName,
+	})
+	return readableGroupName
+}
+
+func (n networkPolicy) addLocalPodCmds(fexec *ovntest.FakeExec, networkPolicy *knet.NetworkPolicy) {
+	ingressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, ingressDefaultDenySuffix)
+	egressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, egressDefaultDenySuffix)
 	fexec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --if-exists remove port_group " + ingressDenyPG + " ports " + fakeUUID + " -- add port_group " + ingressDenyPG + " ports " + fakeUUID,
 	})
 	fexec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --if-exists remove port_group " + egressDenyPG + " ports " + fakeUUID + " -- add port_group " + egressDenyPG + " ports " + fakeUUID,
 	})
-	if networkPolicy != nil {
-		readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
-		fexec.AddFakeCmdsNoOutputNoError([]string{
-			"ovn-nbctl --timeout=15 --if-exists remove port_group " + readableGroupName + " ports " + fakeUUID + " -- add port_group " + readableGroupName + " ports " + fakeUUID,
-		})
-	}
+	readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --if-exists remove port_group " + readableGroupName + " ports " + fakeUUID + " -- add port_group " + readableGroupName + " ports " + fakeUUID,
+	})
 }
 
 func (n networkPolicy) addNamespaceSelectorCmds(fexec *ovntest.FakeExec, networkPolicy *knet.NetworkPolicy, findAgain bool) {
@@ -107,7 +117,7 @@ func (n networkPolicy) addNamespaceSelectorCmds(fexec *ovntest.FakeExec, network
 	for i := range networkPolicy.Spec.Ingress {
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=%v external-ids:policy_type=Ingress", networkPolicy.Namespace, networkPolicy.Name, i),
-			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.src == {$a3128014386057836746} && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group " + readableGroupName + " acls @acl",
+			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.src == {$a3128014386057836746} && outport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group " + readableGroupName + " acls @acl",
 		})
 		if findAgain {
 			fexec.AddFakeCmdsNoOutputNoError([]string{
@@ -118,7 +128,7 @@ func (n networkPolicy) addNamespaceSelectorCmds(fexec *ovntest.FakeExec, network
 	for i := range networkPolicy.Spec.Egress {
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=%v external-ids:policy_type=Egress", networkPolicy.Namespace, networkPolicy.Name, i),
-			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {$a17928043879887565554} && inport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group " + readableGroupName + " acls @acl",
+			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {$a17928043879887565554} && inport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group " + readableGroupName + " acls @acl",
 		})
 		if findAgain {
 			fexec.AddFakeCmdsNoOutputNoError([]string{
@@ -168,6 +178,8 @@ func eventuallyExpectEmptyAddressSets(fakeOvn *FakeOVN, networkPolicy *knet.Netw
 
 func (n networkPolicy) delCmds(fexec *ovntest.FakeExec, pod pod, networkPolicy *knet.NetworkPolicy, withLocal bool) {
 	if withLocal {
+		ingressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, ingressDefaultDenySuffix)
+		egressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, egressDefaultDenySuffix)
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			"ovn-nbctl --timeout=15 --if-exists remove port_group " + ingressDenyPG + " ports " + fakeUUID,
 		})
@@ -186,6 +198,8 @@ func (n networkPolicy) delCmds(fexec *ovntest.FakeExec, pod pod, networkPolicy *
 }
 
 func (n networkPolicy) delPodCmds(fexec *ovntest.FakeExec, networkPolicy *knet.NetworkPolicy) {
+	ingressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, ingressDefaultDenySuffix)
+	egressDenyPG := defaultDenyPortGroup(networkPolicy.Namespace, egressDefaultDenySuffix)
 	fexec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --if-exists remove port_group " + ingressDenyPG + " ports " + fakeUUID,
 	})
@@ -286,6 +300,57 @@ func (p multicastPolicy) delPodCmds(fExec *ovntest.FakeExec, ns string) {
 	})
 }
 
+// updateDomainACLCmds adds the commands replacing the ACL of a multicast domain allowing the
+// multicast traffic from the namespaces in oldNamespaces to the namespaces in newNamespaces
+func (p multicastPolicy) updateDomainACLCmds(fExec *ovntest.FakeExec, domain string, oldNamespaces, newNamespaces []string) {
+	pg_hash := hashedPortGroup(multicastDomainPortGroupName(domain))
+
+	if len(oldNamespaces) > 0 {
+		match := getACLMatch(pg_hash, getMulticastDomainACLMatch(sets.NewString(oldNamespaces...)), knet.PolicyTypeIngress)
+		fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
+				match + " action=allow external-ids:default-deny-policy-type=Ingress",
+			Output: "domain_acl_uuid",
+		})
+		fExec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 remove port_group " + pg_hash + " acls domain_acl_uuid",
+		})
+	}
+	match := getACLMatch(pg_hash, getMulticastDomainACLMatch(sets.NewString(newNamespaces...)), knet.PolicyTypeIngress)
+	fExec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
+			match + " action=allow external-ids:default-deny-policy-type=Ingress",
+		"ovn-nbctl --timeout=15 --id=@acl create acl priority=1012 direction=to-lport " +
+			match + " action=allow external-ids:default-deny-policy-type=Ingress " +
+			"-- add port_group domain_uuid acls @acl",
+	})
+}
+
+// createDomainCmds adds the commands creating the port group of a multicast domain
+func (p multicastPolicy) createDomainCmds(fExec *ovntest.FakeExec, domain string) {
+	pg_hash := hashedPortGroup(multicastDomainPortGroupName(domain))
+	fExec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,ports,acls find port_group name=" + pg_hash,
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
+	})
+	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 create port_group name=" + pg_hash + " external-ids:name=" + multicastDomainPortGroupName(domain),
+		Output: "domain_uuid",
+	})
+}
+
+// deleteDomainCmds adds the commands deleting the port group of a multicast domain
+func (p multicastPolicy) deleteDomainCmds(fExec *ovntest.FakeExec, domain string) {
+	pg_hash := hashedPortGroup(multicastDomainPortGroupName(domain))
+	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
+		Output: "domain_uuid",
+	})
+	fExec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --if-exists destroy port_group domain_uuid",
+	})
+}
+
 var _ = Describe("OVN NetworkPolicy Operations", func() {
 	const (
 		namespaceName1    = "namespace1"
@@ -636,9 +701,9 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
 				fExec.AddFakeCmdsNoOutputNoError([]string{
 					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress", portNum, networkPolicy.Namespace, networkPolicy.Name),
-					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && outport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
 					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress", portNum, networkPolicy.Namespace, networkPolicy.Name),
-					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && inport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && inport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
 				})
 
 				fakeOvn.start(ctx,
@@ -670,6 +735,222 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("resolves the named ports of a networkpolicy against the container ports of the pods", func() {
+			app.Action = func(ctx *cli.Context) error {
+				npTest := networkPolicy{}
+
+				namespace1 := *newNamespace(namespaceName1)
+				nPodTest := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.3",
+					"0a:58:0a:80:01:03",
+					namespace1.Name,
+				)
+				nPod := newPod(nPodTest.namespace, nPodTest.podName, nPodTest.nodeName, nPodTest.podIP)
+				nPod.Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+
+				tcpProtocol := v1.Protocol(v1.ProtocolTCP)
+				namedPort := []knet.NetworkPolicyPort{{
+					Port:     &intstr.IntOrString{Type: intstr.String, StrVal: "http"},
+					Protocol: &tcpProtocol,
+				}}
+				networkPolicy := newNetworkPolicy("networkpolicy1", namespace1.Name,
+					metav1.LabelSelector{},
+					[]knet.NetworkPolicyIngressRule{{
+						Ports: namedPort,
+					}},
+					[]knet.NetworkPolicyEgressRule{{
+						Ports: namedPort,
+						To: []knet.NetworkPolicyPeer{{
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"name": nPodTest.podName,
+								},
+							},
+						}},
+					}},
+				)
+
+				nPodTest.baseCmds(fExec)
+				readableGroupName := npTest.baseCmds(fExec, networkPolicy)
+				npTest.addLocalPodCmds(fExec, networkPolicy)
+
+				// the ACLs of the port match on the address sets of the pods exposing it
+				portASNames := map[string]string{
+					"Ingress": networkPolicy.Namespace + "." + networkPolicy.Name + ".ingress.0.tcp.8080",
+					"Egress":  networkPolicy.Namespace + "." + networkPolicy.Name + ".egress.0.tcp.8080",
+				}
+				l4Matches := map[string]string{
+					"Ingress": "tcp && tcp.dst==8080 && ip4.dst == $" + getIPv4ASHashedName(portASNames["Ingress"]),
+					"Egress":  "tcp && tcp.dst==8080 && ip4.dst == $" + getIPv4ASHashedName(portASNames["Egress"]),
+				}
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress", l4Matches["Ingress"], networkPolicy.Namespace, networkPolicy.Name),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && %s && outport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group %s acls @acl", l4Matches["Ingress"], l4Matches["Ingress"], networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress", l4Matches["Egress"], networkPolicy.Namespace, networkPolicy.Name),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {$a17928043879887565554} && %s && inport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group %s acls @acl", l4Matches["Egress"], l4Matches["Egress"], networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+				})
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{namespace1},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{*nPod},
+					},
+					&knet.NetworkPolicyList{
+						Items: []knet.NetworkPolicy{*networkPolicy},
+					},
+				)
+				nPodTest.populateLogicalSwitchCache(fakeOvn)
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchPods()
+				fakeOvn.controller.WatchNetworkPolicy()
+
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				egressASName := getAddressSetName(networkPolicy.Namespace, networkPolicy.Name, knet.PolicyTypeEgress, 0)
+				fakeOvn.asf.ExpectAddressSetWithIPs(egressASName, []string{nPodTest.podIP})
+				for _, portASName := range portASNames {
+					fakeOvn.asf.ExpectAddressSetWithIPs(getIPv4ASName(portASName), []string{nPodTest.podIP})
+				}
+
+				// the ACLs and address sets of the port are removed along with the last pod exposing it
+				npTest.delPodCmds(fExec, networkPolicy)
+				for _, policyType := range []string{"Ingress", "Egress"} {
+					aclUUID := strings.ToLower(policyType) + "-acl-uuid"
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:namespace=%s external-ids:policy=%s external-ids:%s_num=0 external-ids:policy_type=%s", l4Matches[policyType], networkPolicy.Namespace, networkPolicy.Name, policyType, policyType),
+						Output: aclUUID,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --if-exists remove port_group a14195333570786048679 acls " + aclUUID,
+					})
+				}
+
+				err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(nPodTest.namespace).Delete(context.TODO(), nPodTest.podName, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				fakeOvn.asf.EventuallyExpectEmptyAddressSet(egressASName)
+				for _, portASName := range portASNames {
+					fakeOvn.asf.EventuallyExpectNoAddressSet(getIPv4ASName(portASName))
+				}
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("resolves the named ports of egress rules without pod selectors against the pods of the cluster", func() {
+			app.Action = func(ctx *cli.Context) error {
+				npTest := networkPolicy{}
+
+				namespace1 := *newNamespace(namespaceName1)
+				namespace2 := *newNamespace(namespaceName2)
+				nPodTest := newTPod(
+					"node1",
+					"10.128.1.0/24",
+					"10.128.1.2",
+					"10.128.1.1",
+					"myPod",
+					"10.128.1.3",
+					"0a:58:0a:80:01:03",
+					namespace2.Name,
+				)
+				nPod := newPod(nPodTest.namespace, nPodTest.podName, nPodTest.nodeName, nPodTest.podIP)
+				nPod.Spec.Containers[0].Ports = []v1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+
+				tcpProtocol := v1.Protocol(v1.ProtocolTCP)
+				namedPort := []knet.NetworkPolicyPort{{
+					Port:     &intstr.IntOrString{Type: intstr.String, StrVal: "http"},
+					Protocol: &tcpProtocol,
+				}}
+				networkPolicy := newNetworkPolicy("networkpolicy1", namespace1.Name,
+					metav1.LabelSelector{},
+					nil,
+					[]knet.NetworkPolicyEgressRule{
+						{
+							Ports: namedPort,
+							To: []knet.NetworkPolicyPeer{{
+								IPBlock: &knet.IPBlock{CIDR: "10.128.0.0/14"},
+							}},
+						},
+						{
+							Ports: namedPort,
+							To: []knet.NetworkPolicyPeer{{
+								NamespaceSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{
+										"name": namespace2.Name,
+									},
+								},
+							}},
+						},
+					},
+				)
+
+				nPodTest.baseCmds(fExec)
+				readableGroupName := npTest.baseCmds(fExec, networkPolicy)
+
+				l4Matches := make([]string, len(networkPolicy.Spec.Egress))
+				for i := range networkPolicy.Spec.Egress {
+					portASName := fmt.Sprintf("%s.%s.egress.%d.tcp.8080", networkPolicy.Namespace, networkPolicy.Name, i)
+					l4Matches[i] = "tcp && tcp.dst==8080 && ip4.dst == $" + getIPv4ASHashedName(portASName)
+				}
+				egressAS := getAddressSetName(networkPolicy.Namespace, networkPolicy.Name, knet.PolicyTypeEgress, 1)
+				l3Match := strings.Join(sets.NewString("$"+hashedAddressSet(egressAS), "$"+getIPv4ASHashedName(namespace2.Name)).List(), ", ")
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=true external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress", l4Matches[0], networkPolicy.Namespace, networkPolicy.Name),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == 10.128.0.0/14 && %s && inport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=true external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group %s acls @acl", l4Matches[0], l4Matches[0], networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=1 external-ids:policy_type=Egress", l4Matches[1], networkPolicy.Namespace, networkPolicy.Name),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {%s} && %s && inport == @a14195333570786048679\" action=allow-related name=namespace1_networkpolicy1 external-ids:l4Match=\"%s\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=1 external-ids:policy_type=Egress -- add port_group %s acls @acl", l3Match, l4Matches[1], l4Matches[1], networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+				})
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{namespace1, namespace2},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{*nPod},
+					},
+					&knet.NetworkPolicyList{
+						Items: []knet.NetworkPolicy{*networkPolicy},
+					},
+				)
+				nPodTest.populateLogicalSwitchCache(fakeOvn)
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchPods()
+				fakeOvn.controller.WatchNetworkPolicy()
+
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				// the pods of the selected namespaces are not peers of their own
+				fakeOvn.asf.ExpectEmptyAddressSet(egressAS)
+				fakeOvn.asf.ExpectAddressSetWithIPs(v4AddressSetName2, []string{nPodTest.podIP})
+
+				for i := range networkPolicy.Spec.Egress {
+					aclUUID := fmt.Sprintf("egress-%d-acl-uuid", i)
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"%s\" external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=%d external-ids:policy_type=Egress", l4Matches[i], networkPolicy.Namespace, networkPolicy.Name, i),
+						Output: aclUUID,
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 --if-exists remove port_group a14195333570786048679 acls " + aclUUID,
+					})
+				}
+
+				err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(nPodTest.namespace).Delete(context.TODO(), nPodTest.podName, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("reconciles a deleted namespace referenced by a networkpolicy with a local running pod", func() {
 			app.Action = func(ctx *cli.Context) error {
 
@@ -1261,6 +1542,155 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("updates the logging of existing ACLs when the acl-logging annotation changes", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				npTest := networkPolicy{}
+
+				namespace1 := *newNamespace(namespaceName1)
+				namespace2 := *newNamespace(namespaceName2)
+				networkPolicy := newNetworkPolicy("networkpolicy1", namespace1.Name,
+					metav1.LabelSelector{},
+					[]knet.NetworkPolicyIngressRule{
+						{
+							From: []knet.NetworkPolicyPeer{
+								{
+									NamespaceSelector: &metav1.LabelSelector{
+										MatchLabels: map[string]string{
+											"name": namespace2.Name,
+										},
+									},
+								},
+							},
+						},
+					},
+					[]knet.NetworkPolicyEgressRule{
+						{
+							To: []knet.NetworkPolicyPeer{
+								{
+									NamespaceSelector: &metav1.LabelSelector{
+										MatchLabels: map[string]string{
+											"name": namespace2.Name,
+										},
+									},
+								},
+							},
+						},
+					})
+
+				npTest.addNamespaceSelectorCmds(fExec, networkPolicy, true)
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+							namespace2,
+						},
+					},
+					&knet.NetworkPolicyList{
+						Items: []knet.NetworkPolicy{
+							*networkPolicy,
+						},
+					},
+				)
+
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchNetworkPolicy()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				ingressDenyPG := defaultDenyPortGroup(namespace1.Name, ingressDefaultDenySuffix)
+				egressDenyPG := defaultDenyPortGroup(namespace1.Name, egressDefaultDenySuffix)
+				aclLoggingCmds := func(denyArgs, allowArgs string) {
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"outport == @" + ingressDenyPG + "\" action=drop external-ids:default-deny-policy-type=Ingress",
+						Output: "ingress-deny-uuid",
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"inport == @" + egressDenyPG + "\" action=drop external-ids:default-deny-policy-type=Egress",
+						Output: "egress-deny-uuid",
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 set acl ingress-deny-uuid " + denyArgs + " -- set acl egress-deny-uuid " + denyArgs,
+					})
+					fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+						Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL action=allow-related external-ids:namespace=" + namespace1.Name,
+						Output: "ingress-allow-uuid\negress-allow-uuid",
+					})
+					fExec.AddFakeCmdsNoOutputNoError([]string{
+						"ovn-nbctl --timeout=15 set acl ingress-allow-uuid " + allowArgs + " -- set acl egress-allow-uuid " + allowArgs,
+					})
+				}
+
+				// Enable logging of denied and allowed connections
+				aclLoggingCmds("log=true severity=alert", "log=true severity=notice")
+				ns, err := fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Get(
+					context.TODO(), namespace1.Name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				ns.Annotations[aclLoggingAnnotation] = `{"deny": "alert", "allow": "notice"}`
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Disable logging of allowed connections only
+				aclLoggingCmds("log=true severity=alert", "log=false")
+				ns.Annotations[aclLoggingAnnotation] = `{"deny": "alert"}`
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("disables the logging of existing ACLs when the acl-logging annotation was removed before a restart", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				npTest := networkPolicy{}
+
+				namespace1 := *newNamespace(namespaceName1)
+				networkPolicy := newNetworkPolicy("networkpolicy1", namespace1.Name,
+					metav1.LabelSelector{},
+					[]knet.NetworkPolicyIngressRule{{}},
+					nil)
+
+				// The ACLs of the policy already exist, with the logging
+				// they had before the annotation was removed
+				npTest.baseCmds(fExec, networkPolicy)
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress", networkPolicy.Namespace, networkPolicy.Name),
+					Output: "ingress-allow-uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 set acl ingress-allow-uuid log=false",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&knet.NetworkPolicyList{
+						Items: []knet.NetworkPolicy{
+							*networkPolicy,
+						},
+					},
+				)
+
+				fakeOvn.controller.WatchNamespaces()
+				fakeOvn.controller.WatchNetworkPolicy()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("tests enabling/disabling multicast in a namespace", func() {
 			app.Action = func(ctx *cli.Context) error {
 				namespace1 := *newNamespace(namespaceName1)
@@ -1358,6 +1788,126 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("tests sharing multicast between the namespaces of a multicast domain", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace(namespaceName1)
+				namespace2 := *newNamespace(namespaceName2)
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1, namespace2,
+						},
+					},
+				)
+
+				fakeOvn.controller.WatchNamespaces()
+				mcastPolicy := multicastPolicy{}
+
+				// The first namespace creates the domain.
+				mcastPolicy.enableCmds(fExec, namespace1.Name, v4AddressSetName1)
+				mcastPolicy.createDomainCmds(fExec, "domain1")
+				mcastPolicy.updateDomainACLCmds(fExec, "domain1", nil, []string{namespace1.Name})
+				namespace1.Annotations[nsMulticastAnnotation] = "true"
+				namespace1.Annotations[nsMulticastDomainAnnotation] = "domain1"
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), &namespace1, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// The second namespace receives the multicast traffic of the first one and vice versa.
+				mcastPolicy.enableCmds(fExec, namespace2.Name, v4AddressSetName2)
+				mcastPolicy.updateDomainACLCmds(fExec, "domain1", []string{namespace1.Name},
+					[]string{namespace1.Name, namespace2.Name})
+				namespace2.Annotations[nsMulticastAnnotation] = "true"
+				namespace2.Annotations[nsMulticastDomainAnnotation] = "domain1"
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), &namespace2, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Disabling multicast in the first namespace removes it from the domain.
+				mcastPolicy.updateDomainACLCmds(fExec, "domain1", []string{namespace1.Name, namespace2.Name},
+					[]string{namespace2.Name})
+				mcastPolicy.disableCmds(fExec, namespace1.Name, v4AddressSetName1)
+				namespace1.Annotations[nsMulticastAnnotation] = "false"
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), &namespace1, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// The domain is deleted along with its last namespace.
+				mcastPolicy.deleteDomainCmds(fExec, "domain1")
+				delete(namespace2.Annotations, nsMulticastDomainAnnotation)
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Namespaces().Update(context.TODO(), &namespace2, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reuses the port group of a multicast domain left by a previous run", func() {
+			app.Action = func(ctx *cli.Context) error {
+				namespace1 := *newNamespace(namespaceName1)
+				namespace1.Annotations[nsMulticastAnnotation] = "true"
+				namespace1.Annotations[nsMulticastDomainAnnotation] = "domain1"
+				pod := newPod(namespace1.Name, "myPod", "node1", "10.128.1.3")
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+					&v1.PodList{
+						Items: []v1.Pod{
+							*pod,
+						},
+					},
+				)
+				fakeOvn.controller.logicalPortCache.add("node1", podLogicalPortName(pod), fakeUUID, nil, nil)
+
+				pg_hash := hashedPortGroup(multicastDomainPortGroupName("domain1"))
+				match := getACLMatch(pg_hash, getMulticastDomainACLMatch(sets.NewString(namespace1.Name)), knet.PolicyTypeIngress)
+				mcastPolicy := multicastPolicy{}
+				mcastPolicy.enableCmds(fExec, namespace1.Name, v4AddressSetName1)
+				mcastPolicy.addPodCmds(fExec, namespace1.Name)
+				// the port group still holds the port of the pod, the port of a pod of a namespace
+				// that left the domain, the ACL of the domain and an ACL of its former namespaces
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,ports,acls find port_group name=" + pg_hash,
+					Output: "domain_uuid," + fakeUUID + " stale_port_uuid,domain_acl_uuid stale_acl_uuid",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
+						match + " action=allow external-ids:default-deny-policy-type=Ingress",
+					Output: "domain_acl_uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove port_group " + pg_hash + " ports " + fakeUUID + " " +
+						"-- add port_group " + pg_hash + " ports " + fakeUUID,
+				})
+				fakeOvn.controller.WatchNamespaces()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
+						match + " action=allow external-ids:default-deny-policy-type=Ingress",
+					Output: "domain_acl_uuid",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove port_group " + pg_hash + " ports stale_port_uuid " +
+						"-- --if-exists remove port_group " + pg_hash + " acls stale_acl_uuid",
+				})
+				fakeOvn.controller.syncMulticastDomains()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("tests adding a pod to a multicast enabled namespace", func() {
 			app.Action = func(ctx *cli.Context) error {
 				namespace1 := *newNamespace(namespaceName1)
@@ -1480,6 +2030,22 @@ var _ = Describe("OVN NetworkPolicy Low-Level Operations", func() {
 		config.IPv6Mode = false
 	})
 
+	It("parses ACL logging annotations", func() {
+		levels, err := parseACLLoggingAnnotation("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(levels).To(Equal(ACLLoggingLevels{}))
+
+		levels, err = parseACLLoggingAnnotation(`{"deny": "alert", "allow": "notice"}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(levels).To(Equal(ACLLoggingLevels{Deny: "alert", Allow: "notice"}))
+
+		_, err = parseACLLoggingAnnotation(`{"deny": "loud"}`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = parseACLLoggingAnnotation("alert")
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("computes match strings from address sets correctly", func() {
 		const (
 			pgUUID string = "pg-uuid"
@@ -0,0 +1,904 @@
+package ovn
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	cnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	ipamclaimapi "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/ipallocator"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/subnetallocator"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// layer2SwitchName is the name of the logical switch of a layer2 secondary
+	// network, after the prefix of the network
+	layer2SwitchName = "ovn_layer2_switch"
+	// localnetSwitchName and localnetPortName are the names of the logical switch
+	// of a localnet secondary network and of its localnet port, after the prefix
+	// of the network
+	localnetSwitchName = "ovn_localnet_switch"
+	localnetPortName   = "ovn_localnet_port"
+
+	// secondaryNetworkExternalID tags the logical routers, switches and switch
+	// ports of a secondary network with the name of the network
+	secondaryNetworkExternalID = "network"
+	// secondaryNetworkNADExternalID tags the logical switch port of a pod with the
+	// NetworkAttachmentDefinition the pod is attached through
+	secondaryNetworkNADExternalID = "nad"
+	// secondaryNetworkNodeExternalID and secondaryNetworkSubnetsExternalID tag the
+	// node logical switches of a layer3 network with their node and subnets
+	secondaryNetworkNodeExternalID    = "node"
+	secondaryNetworkSubnetsExternalID = "subnets"
+)
+
+// secondaryNetwork is the state of an OVN-managed secondary network programmed
+// in OVN, protected by the secondaryNetworksLock of the controller
+type secondaryNetwork struct {
+	*util.NetInfo
+	// nadNames are the <namespace>/<name> of the NetworkAttachmentDefinitions
+	// of the network
+	nadNames sets.String
+	// subnetAllocator allocates the node subnets of a layer3 network
+	subnetAllocator *subnetallocator.SubnetAllocator
+	// lsManager allocates the pod IPs of the logical switches of the network:
+	// the node switches of a layer3 network, the single switch of the others
+	lsManager *logicalSwitchManager
+	// ports are the logical switch ports of the pods attached to the network,
+	// by name
+	ports map[string]*secondaryNetworkPort
+}
+
+type secondaryNetworkPort struct {
+	nadName    string
+	switchName string
+	ips        []*net.IPNet
+}
+
+func (sn *secondaryNetwork) prefix() string {
+	return util.GetSecondaryNetworkPrefix(sn.NetName)
+}
+
+func (sn *secondaryNetwork) clusterRouterName() string {
+	return sn.prefix() + types.OVNClusterRouter
+}
+
+// switchName returns the logical switch of the network the pods of a node are
+// attached to
+func (sn *secondaryNetwork) switchName(nodeName string) string {
+	switch sn.Topology {
+	case types.Layer2Topology:
+		return sn.prefix() + layer2SwitchName
+	case types.LocalnetTopology:
+		return sn.prefix() + localnetSwitchName
+	}
+	return sn.prefix() + nodeName
+}
+
+// WatchNetworkAttachmentDefinitions starts the watching of the NetworkAttachmentDefinitions
+// and creates the OVN topologies of the secondary networks they define
+func (oc *Controller) WatchNetworkAttachmentDefinitions() {
+	oc.watchFactory.AddNetworkAttachmentDefinitionHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			nad := obj.(*nadapi.NetworkAttachmentDefinition)
+			if err := oc.addNetworkAttachmentDefinition(nad); err != nil {
+				klog.Error(err)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldNAD := old.(*nadapi.NetworkAttachmentDefinition)
+			newNAD := new.(*nadapi.NetworkAttachmentDefinition)
+			if oldNAD.Spec.Config == newNAD.Spec.Config {
+				return
+			}
+			oc.deleteNetworkAttachmentDefinition(oldNAD)
+			if err := oc.addNetworkAttachmentDefinition(newNAD); err != nil {
+				klog.Error(err)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			nad := obj.(*nadapi.NetworkAttachmentDefinition)
+			oc.deleteNetworkAttachmentDefinition(nad)
+		},
+	}, oc.syncSecondaryNetworks)
+}
+
+// WatchSecondaryNetworkPods starts the watching of the pods and attaches them to
+// the secondary networks they select
+func (oc *Controller) WatchSecondaryNetworkPods() {
+	oc.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			oc.addPodToSecondaryNetworks(obj.(*kapi.Pod))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oc.addPodToSecondaryNetworks(new.(*kapi.Pod))
+		},
+		DeleteFunc: func(obj interface{}) {
+			oc.deletePodFromSecondaryNetworks(obj.(*kapi.Pod))
+		},
+	}, nil)
+}
+
+// findSecondaryNetworkRows returns the names of the rows of an OVN table tagged
+// with a secondary network, by network name
+func findSecondaryNetworkRows(table string, conditions ...string) (map[string][]string, error) {
+	args := append([]string{"--format=csv", "--data=bare", "--no-heading",
+		"--columns=name,external_ids", "find", table}, conditions...)
+	stdout, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the %s rows of the secondary networks, stderr: %q (%v)",
+			table, stderr, err)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s rows of the secondary networks %q: %v", table, stdout, err)
+	}
+	rows := make(map[string][]string)
+	for _, record := range records {
+		if len(record) != 2 || record[0] == "" {
+			continue
+		}
+		if netName, ok := getExternalID(record[1], secondaryNetworkExternalID); ok {
+			rows[netName] = append(rows[netName], record[0])
+		}
+	}
+	return rows, nil
+}
+
+// getExternalID returns the value of a key of the external_ids of a row, as
+// printed by ovn-nbctl --data=bare
+func getExternalID(externalIDs, key string) (string, bool) {
+	for _, externalID := range strings.Fields(externalIDs) {
+		if strings.HasPrefix(externalID, key+"=") {
+			return strings.Trim(strings.TrimPrefix(externalID, key+"="), "\""), true
+		}
+	}
+	return "", false
+}
+
+// syncSecondaryNetworks removes the OVN objects of the secondary networks that
+// no longer exist, and the logical switch ports of the pods that are no longer
+// attached to a secondary network
+func (oc *Controller) syncSecondaryNetworks(nads []interface{}) {
+	nadNetworks := make(map[string]string)
+	expectedNetworks := sets.NewString()
+	for _, obj := range nads {
+		nad, ok := obj.(*nadapi.NetworkAttachmentDefinition)
+		if !ok {
+			klog.Errorf("Spurious object in syncSecondaryNetworks: %v", obj)
+			continue
+		}
+		netconf, err := util.ParseNADNetConf(nad)
+		if err != nil || netconf == nil {
+			continue
+		}
+		nadNetworks[util.GetNADName(nad.Namespace, nad.Name)] = netconf.Name
+		expectedNetworks.Insert(netconf.Name)
+	}
+
+	pods, err := oc.watchFactory.GetPods("")
+	if err != nil {
+		klog.Errorf("Failed to get the pods to sync the secondary networks: %v", err)
+		return
+	}
+	expectedPorts := sets.NewString()
+	for _, pod := range pods {
+		networks, err := util.GetPodNetSelAnnotation(pod, util.NetworkAttachmentAnnotation)
+		if err != nil {
+			continue
+		}
+		for _, network := range networks {
+			nadName := util.GetNADName(network.Namespace, network.Name)
+			netName, ok := nadNetworks[nadName]
+			if !ok {
+				continue
+			}
+			if _, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, nadName); err == nil {
+				expectedPorts.Insert(util.GetSecondaryNetworkLogicalPortName(netName, nadName, pod.Namespace, pod.Name))
+			}
+		}
+	}
+
+	args := []string{}
+	ports, err := findSecondaryNetworkRows("logical_switch_port")
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	for _, netPorts := range ports {
+		for _, port := range netPorts {
+			if !expectedPorts.Has(port) {
+				args = append(args, "--", "--if-exists", "lsp-del", port)
+			}
+		}
+	}
+	for _, table := range []string{"logical_switch", "logical_router"} {
+		rows, err := findSecondaryNetworkRows(table)
+		if err != nil {
+			klog.Error(err)
+			return
+		}
+		for netName, names := range rows {
+			if expectedNetworks.Has(netName) {
+				continue
+			}
+			for _, name := range names {
+				if table == "logical_switch" {
+					args = append(args, "--", "--if-exists", "ls-del", name)
+				} else {
+					args = append(args, "--", "--if-exists", "lr-del", name)
+				}
+			}
+		}
+	}
+	if len(args) == 0 {
+		return
+	}
+	_, stderr, err := util.RunOVNNbctl(args[1:]...)
+	if err != nil {
+		klog.Errorf("Failed to delete the stale OVN objects of the secondary networks, stderr: %q (%v)", stderr, err)
+	}
+}
+
+// addNetworkAttachmentDefinition creates the OVN topology of the secondary network
+// of a NetworkAttachmentDefinition, if it does not exist yet, and attaches the pods
+// that selected the NetworkAttachmentDefinition to it
+func (oc *Controller) addNetworkAttachmentDefinition(nad *nadapi.NetworkAttachmentDefinition) error {
+	nadName := util.GetNADName(nad.Namespace, nad.Name)
+	netconf, err := util.ParseNADNetConf(nad)
+	if err != nil {
+		return err
+	}
+	if netconf == nil {
+		// not an OVN-managed secondary network
+		return nil
+	}
+	netInfo, err := util.NewNetInfo(netconf)
+	if err != nil {
+		return fmt.Errorf("invalid NetworkAttachmentDefinition %s: %v", nadName, err)
+	}
+
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	sn, ok := oc.secondaryNetworks[netInfo.NetName]
+	if ok && !sn.Equals(netInfo) {
+		return fmt.Errorf("ignoring NetworkAttachmentDefinition %s, its configuration of network %s differs from the "+
+			"one of NetworkAttachmentDefinitions %v", nadName, netInfo.NetName, sn.nadNames.List())
+	}
+	if !ok {
+		klog.Infof("Creating the OVN topology of secondary network %s", netInfo.NetName)
+		sn, err = oc.newSecondaryNetwork(netInfo)
+		if err != nil {
+			return fmt.Errorf("failed to create the OVN topology of secondary network %s: %v", netInfo.NetName, err)
+		}
+		oc.secondaryNetworks[netInfo.NetName] = sn
+	}
+	sn.nadNames.Insert(nadName)
+	oc.nadNetworks[nadName] = netInfo.NetName
+
+	pods, err := oc.watchFactory.GetPods("")
+	if err != nil {
+		return fmt.Errorf("failed to get the pods to attach to secondary network %s: %v", netInfo.NetName, err)
+	}
+	type podNetwork struct {
+		pod     *kapi.Pod
+		network *cnitypes.NetworkSelectionElement
+	}
+	podNetworks := []podNetwork{}
+	for _, pod := range pods {
+		if network := getPodNADSelection(pod, nadName); network != nil {
+			podNetworks = append(podNetworks, podNetwork{pod, network})
+		}
+	}
+	// reserve the IPs of the pods attached by a previous run before allocating
+	// the IPs of the new pods
+	for _, pn := range podNetworks {
+		if podInfo, err := util.UnmarshalPodAnnotationForNetwork(pn.pod.Annotations, nadName); err == nil {
+			_ = sn.lsManager.AllocateIPs(sn.switchName(pn.pod.Spec.NodeName), podInfo.IPs)
+		}
+	}
+	var errs []error
+	for _, pn := range podNetworks {
+		if err := oc.addPodToSecondaryNetwork(sn, nadName, pn.pod, pn.network); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// deleteNetworkAttachmentDefinition detaches the pods that selected a
+// NetworkAttachmentDefinition from its secondary network, and deletes the OVN
+// topology of the network with its last NetworkAttachmentDefinition
+func (oc *Controller) deleteNetworkAttachmentDefinition(nad *nadapi.NetworkAttachmentDefinition) {
+	nadName := util.GetNADName(nad.Namespace, nad.Name)
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	netName, ok := oc.nadNetworks[nadName]
+	if !ok {
+		return
+	}
+	delete(oc.nadNetworks, nadName)
+	sn := oc.secondaryNetworks[netName]
+	sn.nadNames.Delete(nadName)
+
+	if sn.nadNames.Len() == 0 {
+		klog.Infof("Deleting the OVN topology of secondary network %s", netName)
+		if err := oc.deleteSecondaryNetwork(sn); err != nil {
+			klog.Error(err)
+		}
+		delete(oc.secondaryNetworks, netName)
+		return
+	}
+
+	portNames := []string{}
+	for portName, port := range sn.ports {
+		if port.nadName == nadName {
+			portNames = append(portNames, portName)
+		}
+	}
+	if err := oc.deleteSecondaryNetworkPorts(sn, portNames); err != nil {
+		klog.Error(err)
+	}
+}
+
+// newSecondaryNetwork creates the OVN topology of a secondary network: a cluster
+// router and a logical switch per node for the layer3 topology, a single logical
+// switch for the layer2 topology, and a single logical switch with a localnet
+// port for the localnet topology
+func (oc *Controller) newSecondaryNetwork(netInfo *util.NetInfo) (*secondaryNetwork, error) {
+	sn := &secondaryNetwork{
+		NetInfo:   netInfo,
+		nadNames:  sets.NewString(),
+		lsManager: newLogicalSwitchManager(),
+		ports:     make(map[string]*secondaryNetworkPort),
+	}
+	// the IPs of the IPAMClaims are allocated when the switches are added
+	if ipamClaimTopologyError(sn.NetName, sn.Topology) == nil {
+		sn.lsManager.ReserveIPs(oc.getNetworkIPAMClaimIPs(sn.NetName))
+	}
+
+	if sn.Topology != types.Layer3Topology {
+		switchName := sn.switchName("")
+		args := []string{
+			"--may-exist", "ls-add", switchName,
+			"--", "set", "logical_switch", switchName,
+			"external_ids:" + secondaryNetworkExternalID + "=" + sn.NetName,
+		}
+		if sn.Topology == types.LocalnetTopology {
+			// the localnet port is not tagged with the network, it is not a pod
+			// port, and is deleted with the switch
+			portName := sn.prefix() + localnetPortName
+			args = append(args,
+				"--", "--may-exist", "lsp-add", switchName, portName,
+				"--", "lsp-set-type", portName, "localnet",
+				"--", "lsp-set-addresses", portName, "unknown",
+				"--", "lsp-set-options", portName, "network_name="+sn.PhysicalNetworkName)
+			if sn.VLANID != 0 {
+				args = append(args, "--", "set", "logical_switch_port", portName, fmt.Sprintf("tag=%d", sn.VLANID))
+			} else {
+				args = append(args, "--", "clear", "logical_switch_port", portName, "tag")
+			}
+		}
+		_, stderr, err := util.RunOVNNbctl(args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create logical switch %s, stderr: %q (%v)", switchName, stderr, err)
+		}
+		if len(sn.Subnets) == 0 {
+			// a localnet network without IPAM
+			if err := sn.lsManager.AddNoHostSubnetNode(switchName); err != nil {
+				return nil, err
+			}
+			return sn, nil
+		}
+		subnets := []*net.IPNet{}
+		for _, subnet := range sn.Subnets {
+			subnets = append(subnets, subnet.CIDR)
+		}
+		if err := sn.lsManager.AddNode(switchName, subnets); err != nil {
+			return nil, err
+		}
+		return sn, nil
+	}
+
+	sn.subnetAllocator = subnetallocator.NewSubnetAllocator()
+	for _, subnet := range sn.Subnets {
+		if err := sn.subnetAllocator.AddNetworkRange(subnet.CIDR, subnet.HostSubnetLength); err != nil {
+			return nil, err
+		}
+	}
+	routerName := sn.clusterRouterName()
+	_, stderr, err := util.RunOVNNbctl("--may-exist", "lr-add", routerName,
+		"--", "set", "logical_router", routerName,
+		"external_ids:"+secondaryNetworkExternalID+"="+sn.NetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logical router %s, stderr: %q (%v)", routerName, stderr, err)
+	}
+
+	// recover the node subnets allocated by a previous run
+	nodeSubnets, err := getSecondaryNetworkNodeSubnets(sn.NetName)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make([]string, 0, len(nodeSubnets))
+	for nodeName := range nodeSubnets {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	for _, nodeName := range nodeNames {
+		if oc.lsManager.GetSwitchSubnets(nodeName) == nil {
+			// the node was deleted
+			if err := sn.deleteNodeSwitch(nodeName); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, subnet := range nodeSubnets[nodeName] {
+			if err := sn.subnetAllocator.MarkAllocatedNetwork(subnet); err != nil {
+				return nil, fmt.Errorf("failed to mark subnet %s of node %s allocated: %v", subnet, nodeName, err)
+			}
+		}
+		if err := sn.lsManager.AddNode(sn.switchName(nodeName), nodeSubnets[nodeName]); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes, err := oc.watchFactory.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the nodes: %v", err)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	for _, node := range nodes {
+		if oc.lsManager.GetSwitchSubnets(node.Name) == nil {
+			continue
+		}
+		if err := sn.addNode(node.Name); err != nil {
+			return nil, err
+		}
+	}
+	return sn, nil
+}
+
+// getSecondaryNetworkNodeSubnets returns the subnets of the existing node logical
+// switches of a layer3 network, by node
+func getSecondaryNetworkNodeSubnets(netName string) (map[string][]*net.IPNet, error) {
+	stdout, stderr, err := util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=external_ids", "find", "logical_switch",
+		"external_ids:"+secondaryNetworkExternalID+"="+netName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the logical switches of secondary network %s, stderr: %q (%v)",
+			netName, stderr, err)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the logical switches of secondary network %s %q: %v", netName, stdout, err)
+	}
+	nodeSubnets := make(map[string][]*net.IPNet)
+	for _, record := range records {
+		externalIDs := strings.Join(record, " ")
+		nodeName, ok := getExternalID(externalIDs, secondaryNetworkNodeExternalID)
+		if !ok {
+			continue
+		}
+		subnets, ok := getExternalID(externalIDs, secondaryNetworkSubnetsExternalID)
+		if !ok {
+			continue
+		}
+		nodeSubnets[nodeName], err = util.ParseIPNets(subnets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnets of the logical switch of node %s on secondary network %s: %v",
+				nodeName, netName, err)
+		}
+	}
+	return nodeSubnets, nil
+}
+
+// addNode allocates the subnets of a node on a layer3 network and creates its
+// logical switch, connected to the cluster router of the network
+func (sn *secondaryNetwork) addNode(nodeName string) error {
+	switchName := sn.switchName(nodeName)
+	hostSubnets := sn.lsManager.GetSwitchSubnets(switchName)
+	allocated := false
+	if hostSubnets == nil {
+		var err error
+		hostSubnets, err = sn.subnetAllocator.AllocateNetworks()
+		if err != nil {
+			return fmt.Errorf("failed to allocate the subnets of node %s on secondary network %s: %v",
+				nodeName, sn.NetName, err)
+		}
+		allocated = true
+	}
+
+	// the router port MAC is based on the IPv4 subnet if there is one, else IPv6
+	var lrpMAC net.HardwareAddr
+	for _, hostSubnet := range hostSubnets {
+		lrpMAC = util.IPAddrToHWAddr(util.GetNodeGatewayIfAddr(hostSubnet).IP)
+		if !utilnet.IsIPv6CIDR(hostSubnet) {
+			break
+		}
+	}
+	routerPort := sn.prefix() + types.RouterToSwitchPrefix + nodeName
+	switchPort := sn.prefix() + types.SwitchToRouterPrefix + nodeName
+	args := []string{
+		"--may-exist", "ls-add", switchName,
+		"--", "set", "logical_switch", switchName,
+		"external_ids:" + secondaryNetworkExternalID + "=" + sn.NetName,
+		"external_ids:" + secondaryNetworkNodeExternalID + "=" + nodeName,
+		fmt.Sprintf("external_ids:%s=\"%s\"", secondaryNetworkSubnetsExternalID, util.JoinIPNets(hostSubnets, ",")),
+		"--", "--if-exists", "lrp-del", routerPort,
+		"--", "lrp-add", sn.clusterRouterName(), routerPort, lrpMAC.String(),
+	}
+	for _, hostSubnet := range hostSubnets {
+		args = append(args, util.GetNodeGatewayIfAddr(hostSubnet).String())
+	}
+	args = append(args,
+		"--", "--may-exist", "lsp-add", switchName, switchPort,
+		"--", "set", "logical_switch_port", switchPort, "type=router",
+		"options:router-port="+routerPort, "addresses=\""+lrpMAC.String()+"\"")
+	_, stderr, err := util.RunOVNNbctl(args...)
+	if err == nil {
+		err = sn.lsManager.AddNode(switchName, hostSubnets)
+	} else {
+		err = fmt.Errorf("failed to create logical switch %s, stderr: %q (%v)", switchName, stderr, err)
+	}
+	if err != nil && allocated {
+		for _, hostSubnet := range hostSubnets {
+			_ = sn.subnetAllocator.ReleaseNetwork(hostSubnet)
+		}
+	}
+	return err
+}
+
+// deleteNodeSwitch deletes the logical switch of a node on a layer3 network and
+// releases its subnets
+func (sn *secondaryNetwork) deleteNodeSwitch(nodeName string) error {
+	switchName := sn.switchName(nodeName)
+	_, stderr, err := util.RunOVNNbctl("--if-exists", "ls-del", switchName,
+		"--", "--if-exists", "lrp-del", sn.prefix()+types.RouterToSwitchPrefix+nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to delete logical switch %s, stderr: %q (%v)", switchName, stderr, err)
+	}
+	for _, hostSubnet := range sn.lsManager.GetSwitchSubnets(switchName) {
+		if err := sn.subnetAllocator.ReleaseNetwork(hostSubnet); err != nil {
+			klog.Warningf("Failed to release subnet %s of node %s on secondary network %s: %v",
+				hostSubnet, nodeName, sn.NetName, err)
+		}
+	}
+	sn.lsManager.DeleteNode(switchName)
+	for portName, port := range sn.ports {
+		if port.switchName == switchName {
+			delete(sn.ports, portName)
+		}
+	}
+	return nil
+}
+
+// deleteSecondaryNetwork deletes the OVN topology of a secondary network, with
+// the logical switch ports of its pods
+func (oc *Controller) deleteSecondaryNetwork(sn *secondaryNetwork) error {
+	switches, err := findSecondaryNetworkRows("logical_switch",
+		"external_ids:"+secondaryNetworkExternalID+"="+sn.NetName)
+	if err != nil {
+		return err
+	}
+	args := []string{}
+	for _, switchName := range switches[sn.NetName] {
+		args = append(args, "--", "--if-exists", "ls-del", switchName)
+	}
+	if sn.Topology == types.Layer3Topology {
+		args = append(args, "--", "--if-exists", "lr-del", sn.clusterRouterName())
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	_, stderr, err := util.RunOVNNbctl(args[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to delete the OVN topology of secondary network %s, stderr: %q (%v)",
+			sn.NetName, stderr, err)
+	}
+	return nil
+}
+
+// addNodeToSecondaryNetworks creates the logical switches of a new node on the
+// layer3 secondary networks
+func (oc *Controller) addNodeToSecondaryNetworks(nodeName string) error {
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	var errs []error
+	for _, sn := range oc.secondaryNetworks {
+		if sn.Topology != types.Layer3Topology {
+			continue
+		}
+		if err := sn.addNode(nodeName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// deleteNodeFromSecondaryNetworks deletes the logical switches of a deleted node
+// on the layer3 secondary networks
+func (oc *Controller) deleteNodeFromSecondaryNetworks(nodeName string) {
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	for _, sn := range oc.secondaryNetworks {
+		if sn.Topology != types.Layer3Topology || sn.lsManager.GetSwitchSubnets(sn.switchName(nodeName)) == nil {
+			continue
+		}
+		if err := sn.deleteNodeSwitch(nodeName); err != nil {
+			klog.Error(err)
+		}
+	}
+}
+
+// getPodNADSelection returns the network selection of a pod for a
+// NetworkAttachmentDefinition, or nil if the pod does not select it
+func getPodNADSelection(pod *kapi.Pod, nadName string) *cnitypes.NetworkSelectionElement {
+	if !podScheduled(pod) || !util.PodWantsNetwork(pod) {
+		return nil
+	}
+	networks, err := util.GetPodNetSelAnnotation(pod, util.NetworkAttachmentAnnotation)
+	if err != nil {
+		return nil
+	}
+	for _, network := range networks {
+		if util.GetNADName(network.Namespace, network.Name) == nadName {
+			return network
+		}
+	}
+	return nil
+}
+
+// addPodToSecondaryNetworks attaches a pod to the secondary networks it selects,
+// once it is attached to the default network
+func (oc *Controller) addPodToSecondaryNetworks(pod *kapi.Pod) {
+	if !podScheduled(pod) || !util.PodWantsNetwork(pod) {
+		return
+	}
+	if _, err := util.UnmarshalPodAnnotation(pod.Annotations); err != nil {
+		return
+	}
+	networks, err := util.GetPodNetSelAnnotation(pod, util.NetworkAttachmentAnnotation)
+	if err != nil {
+		klog.Errorf("Failed to get the networks of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	if len(networks) == 0 {
+		return
+	}
+
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	for _, network := range networks {
+		nadName := util.GetNADName(network.Namespace, network.Name)
+		netName, ok := oc.nadNetworks[nadName]
+		if !ok {
+			// not an OVN-managed secondary network
+			continue
+		}
+		if err := oc.addPodToSecondaryNetwork(oc.secondaryNetworks[netName], nadName, pod, network); err != nil {
+			klog.Error(err)
+			oc.recordPodEvent(err, pod)
+		}
+	}
+}
+
+// addPodToSecondaryNetwork creates the logical switch port of a pod on a secondary
+// network and, unless it was attached by a previous run, allocates its IPs and
+// adds the entry of the network to its annotation
+func (oc *Controller) addPodToSecondaryNetwork(sn *secondaryNetwork, nadName string, pod *kapi.Pod,
+	network *cnitypes.NetworkSelectionElement) error {
+	portName := util.GetSecondaryNetworkLogicalPortName(sn.NetName, nadName, pod.Namespace, pod.Name)
+	if _, ok := sn.ports[portName]; ok {
+		return nil
+	}
+	switchName := sn.switchName(pod.Spec.NodeName)
+	noIPAM := sn.lsManager.IsNonHostSubnetSwitch(switchName)
+	if !noIPAM && sn.lsManager.GetSwitchSubnets(switchName) == nil {
+		return fmt.Errorf("failed to attach pod %s/%s to secondary network %s, logical switch %s not found",
+			pod.Namespace, pod.Name, sn.NetName, switchName)
+	}
+
+	podInfo, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, nadName)
+	allocated := false
+	if err == nil {
+		if !noIPAM {
+			err := sn.lsManager.AllocateIPs(switchName, podInfo.IPs)
+			if err != nil && err != ipallocator.ErrAllocated {
+				return fmt.Errorf("failed to reserve IPs %s of pod %s/%s on secondary network %s: %v",
+					util.JoinIPNetIPs(podInfo.IPs, " "), pod.Namespace, pod.Name, sn.NetName, err)
+			}
+		}
+	} else {
+		var ipamClaim *ipamclaimapi.IPAMClaim
+		var claimedIPs []*net.IPNet
+		if !noIPAM {
+			ipamClaim, err = oc.getPodIPAMClaim(pod, network.IPAMClaimReference, sn.NetName, sn.Topology)
+			if err != nil {
+				return err
+			}
+			if ipamClaim != nil && len(ipamClaim.Status.IPs) > 0 {
+				// the IPs of the previous pods of the claim
+				claimedIPs, err = getClaimedPodIPs(sn.lsManager, switchName, ipamClaim)
+				if err != nil {
+					return fmt.Errorf("failed to assign claimed IPs to pod %s/%s on secondary network %s: %v",
+						pod.Namespace, pod.Name, sn.NetName, err)
+				}
+			}
+		}
+		podInfo, err = sn.allocatePodAnnotation(switchName, portName, noIPAM, network, claimedIPs)
+		if err != nil {
+			return fmt.Errorf("failed to allocate IPs of pod %s/%s on secondary network %s: %v",
+				pod.Namespace, pod.Name, sn.NetName, err)
+		}
+		if ipamClaim != nil && len(ipamClaim.Status.IPs) == 0 {
+			if err = oc.setIPAMClaimIPs(sn.lsManager, ipamClaim, podInfo.IPs); err != nil {
+				_ = sn.lsManager.ReleaseIPs(switchName, podInfo.IPs)
+				return fmt.Errorf("failed to claim the IPs of pod %s/%s on secondary network %s: %v",
+					pod.Namespace, pod.Name, sn.NetName, err)
+			}
+		}
+		allocated = true
+	}
+	releaseIPs := func() {
+		if allocated {
+			if err := sn.lsManager.ReleaseIPs(switchName, podInfo.IPs); err != nil {
+				klog.Errorf("Failed to release IPs %s of pod %s/%s on secondary network %s: %v",
+					util.JoinIPNetIPs(podInfo.IPs, " "), pod.Namespace, pod.Name, sn.NetName, err)
+			}
+		}
+	}
+
+	addresses := podInfo.MAC.String()
+	for _, ip := range podInfo.IPs {
+		addresses += " " + ip.IP.String()
+	}
+	_, stderr, err := util.RunOVNNbctl("--may-exist", "lsp-add", switchName, portName,
+		"--", "lsp-set-addresses", portName, addresses,
+		"--", "lsp-set-port-security", portName, addresses,
+		"--", "set", "logical_switch_port", portName,
+		"external_ids:namespace="+pod.Namespace,
+		"external_ids:"+secondaryNetworkExternalID+"="+sn.NetName,
+		fmt.Sprintf("external_ids:%s=\"%s\"", secondaryNetworkNADExternalID, nadName))
+	if err != nil {
+		releaseIPs()
+		return fmt.Errorf("failed to create logical switch port %s, stderr: %q (%v)", portName, stderr, err)
+	}
+
+	if allocated {
+		// merge the entry with the current annotation, the entries of the other
+		// networks may not be in the cache yet
+		err = func() error {
+			annotations, err := oc.kube.GetAnnotationsOnPod(pod.Namespace, pod.Name)
+			if err != nil {
+				return err
+			}
+			marshalledAnnotation, err := util.MarshalPodAnnotationForNetwork(annotations, podInfo, nadName)
+			if err != nil {
+				return err
+			}
+			return oc.kube.SetAnnotationsOnPod(pod, marshalledAnnotation)
+		}()
+		if err != nil {
+			_, _, _ = util.RunOVNNbctl("--if-exists", "lsp-del", portName)
+			releaseIPs()
+			return fmt.Errorf("failed to set the annotation of pod %s/%s for secondary network %s: %v",
+				pod.Namespace, pod.Name, sn.NetName, err)
+		}
+	}
+
+	sn.ports[portName] = &secondaryNetworkPort{
+		nadName:    nadName,
+		switchName: switchName,
+		ips:        podInfo.IPs,
+	}
+	return nil
+}
+
+// allocatePodAnnotation allocates the IPs of a pod on a logical switch of the
+// network, unless the network has no IPAM or the IPs are claimed, and returns the
+// annotation of the pod for the network. The pods have no default route through a secondary network,
+// they reach the other nodes of a layer3 network through routes to the subnets
+// of the network.
+func (sn *secondaryNetwork) allocatePodAnnotation(switchName, portName string, noIPAM bool,
+	network *cnitypes.NetworkSelectionElement, claimedIPs []*net.IPNet) (*util.PodAnnotation, error) {
+	ips := claimedIPs
+	var err error
+	if !noIPAM && ips == nil {
+		ips, err = sn.lsManager.AllocateNextIPs(switchName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	podInfo := &util.PodAnnotation{IPs: ips}
+	if network.MacRequest != "" {
+		podInfo.MAC, err = net.ParseMAC(network.MacRequest)
+		if err != nil {
+			_ = sn.lsManager.ReleaseIPs(switchName, ips)
+			return nil, fmt.Errorf("failed to parse requested MAC %s: %v", network.MacRequest, err)
+		}
+	} else if len(ips) > 0 {
+		podInfo.MAC = util.IPAddrToHWAddr(ips[0].IP)
+	} else {
+		// without IPs, the MAC is derived from the logical switch port
+		hash := sha256.Sum256([]byte(portName))
+		podInfo.MAC = net.HardwareAddr{0x0A, 0x58, hash[0], hash[1], hash[2], hash[3]}
+	}
+
+	if sn.Topology == types.Layer3Topology {
+		nodeSubnets := sn.lsManager.GetSwitchSubnets(switchName)
+		for _, ip := range ips {
+			isIPv6 := utilnet.IsIPv6CIDR(ip)
+			nodeSubnet, err := util.MatchIPNetFamily(isIPv6, nodeSubnets)
+			if err != nil {
+				_ = sn.lsManager.ReleaseIPs(switchName, ips)
+				return nil, err
+			}
+			gatewayIP := util.GetNodeGatewayIfAddr(nodeSubnet).IP
+			for _, subnet := range sn.Subnets {
+				if utilnet.IsIPv6CIDR(subnet.CIDR) == isIPv6 {
+					podInfo.Routes = append(podInfo.Routes, util.PodRoute{Dest: subnet.CIDR, NextHop: gatewayIP})
+				}
+			}
+		}
+	}
+	return podInfo, nil
+}
+
+// deletePodFromSecondaryNetworks deletes the logical switch ports of a deleted
+// pod on the secondary networks and releases its IPs
+func (oc *Controller) deletePodFromSecondaryNetworks(pod *kapi.Pod) {
+	oc.secondaryNetworksLock.Lock()
+	defer oc.secondaryNetworksLock.Unlock()
+	for nadName, netName := range oc.nadNetworks {
+		sn := oc.secondaryNetworks[netName]
+		portName := util.GetSecondaryNetworkLogicalPortName(netName, nadName, pod.Namespace, pod.Name)
+		if _, ok := sn.ports[portName]; !ok {
+			continue
+		}
+		if err := oc.deleteSecondaryNetworkPorts(sn, []string{portName}); err != nil {
+			klog.Error(err)
+		}
+	}
+}
+
+// deleteSecondaryNetworkPorts deletes logical switch ports of pods on a secondary
+// network and releases their IPs
+func (oc *Controller) deleteSecondaryNetworkPorts(sn *secondaryNetwork, portNames []string) error {
+	if len(portNames) == 0 {
+		return nil
+	}
+	sort.Strings(portNames)
+	args := []string{}
+	for _, portName := range portNames {
+		args = append(args, "--", "--if-exists", "lsp-del", portName)
+	}
+	_, stderr, err := util.RunOVNNbctl(args[1:]...)
+	if err != nil {
+		return fmt.Errorf("failed to delete logical switch ports %v of secondary network %s, stderr: %q (%v)",
+			portNames, sn.NetName, stderr, err)
+	}
+	for _, portName := range portNames {
+		port := sn.ports[portName]
+		if err := sn.lsManager.ReleaseIPs(port.switchName, port.ips); err != nil {
+			klog.Warningf("Failed to release IPs %s of logical switch port %s: %v",
+				util.JoinIPNetIPs(port.ips, " "), portName, err)
+		}
+		delete(sn.ports, portName)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package ovn
+
+import (
+	"context"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/urfave/cli/v2"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newNetworkAttachmentDefinition(namespace, name, netConf string) *nadapi.NetworkAttachmentDefinition {
+	return &nadapi.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       nadapi.NetworkAttachmentDefinitionSpec{Config: netConf},
+	}
+}
+
+// newSecondaryNetworkPod returns a pod attached to the default network which
+// selects the given networks
+func newSecondaryNetworkPod(namespace, name, node, podIP, networks string) *v1.Pod {
+	pod := newPod(namespace, name, node, podIP)
+	annotations, err := util.MarshalPodAnnotation(&util.PodAnnotation{
+		IPs: ovntest.MustParseIPNets(podIP + "/24"),
+		MAC: util.IPAddrToHWAddr(ovntest.MustParseIP(podIP)),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	annotations[util.NetworkAttachmentAnnotation] = networks
+	pod.Annotations = annotations
+	return pod
+}
+
+var _ = Describe("OVN Secondary Network Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewLooseCompareFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("creates a layer3 network with a switch per node and attaches the pods to it", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			podT := *newSecondaryNetworkPod(namespaceT.Name, "pod1", "node1", "10.128.1.3", "blue")
+			nad := *newNetworkAttachmentDefinition(namespaceT.Name, "blue",
+				`{"cniVersion": "0.4.0", "name": "blue", "type": "ovn-k8s-cni-overlay", "topology": "layer3", `+
+					`"subnets": "10.200.0.0/16/24", "netAttachDefName": "namespace1/blue"}`)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&v1.NodeList{Items: []v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}}},
+				&v1.PodList{Items: []v1.Pod{podT}},
+				&nadapi.NetworkAttachmentDefinitionList{Items: []nadapi.NetworkAttachmentDefinition{nad}},
+			)
+			err := fakeOvn.controller.lsManager.AddNode("node1", ovntest.MustParseIPNets("10.128.1.0/24"))
+			Expect(err).NotTo(HaveOccurred())
+
+			// the objects of the deleted network red and the stale port of network
+			// blue are removed
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch_port",
+				Output: "blue_namespace1.blue_namespace1_gone,namespace=namespace1 network=blue",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch",
+				Output: "red_node1,network=red node=node1 subnets=10.100.0.0/24",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_router",
+				Output: "red_ovn_cluster_router,network=red",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists lsp-del blue_namespace1.blue_namespace1_gone " +
+					"-- --if-exists ls-del red_node1 -- --if-exists lr-del red_ovn_cluster_router",
+				"ovn-nbctl --timeout=15 --may-exist lr-add blue_ovn_cluster_router " +
+					"-- set logical_router blue_ovn_cluster_router external_ids:network=blue",
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=external_ids find logical_switch external_ids:network=blue",
+				"ovn-nbctl --timeout=15 --may-exist ls-add blue_node1 -- set logical_switch blue_node1 external_ids:network=blue " +
+					"external_ids:node=node1 external_ids:subnets=\"10.200.0.0/24\" -- --if-exists lrp-del blue_rtos-node1 " +
+					"-- lrp-add blue_ovn_cluster_router blue_rtos-node1 0a:58:0a:c8:00:01 10.200.0.1/24 " +
+					"-- --may-exist lsp-add blue_node1 blue_stor-node1 -- set logical_switch_port blue_stor-node1 type=router " +
+					"options:router-port=blue_rtos-node1 addresses=\"0a:58:0a:c8:00:01\"",
+				"ovn-nbctl --timeout=15 --may-exist lsp-add blue_node1 blue_namespace1.blue_namespace1_pod1 " +
+					"-- lsp-set-addresses blue_namespace1.blue_namespace1_pod1 0a:58:0a:c8:00:03 10.200.0.3 " +
+					"-- lsp-set-port-security blue_namespace1.blue_namespace1_pod1 0a:58:0a:c8:00:03 10.200.0.3 " +
+					"-- set logical_switch_port blue_namespace1.blue_namespace1_pod1 external_ids:namespace=namespace1 " +
+					"external_ids:network=blue external_ids:nad=\"namespace1/blue\"",
+			})
+
+			fakeOvn.controller.WatchNetworkAttachmentDefinitions()
+			fakeOvn.controller.WatchSecondaryNetworkPods()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			pod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Get(context.TODO(), podT.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			podInfo, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, "namespace1/blue")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podInfo).To(Equal(&util.PodAnnotation{
+				IPs: ovntest.MustParseIPNets("10.200.0.3/24"),
+				MAC: ovntest.MustParseMAC("0a:58:0a:c8:00:03"),
+				Routes: []util.PodRoute{{
+					Dest:    ovntest.MustParseIPNet("10.200.0.0/16"),
+					NextHop: ovntest.MustParseIP("10.200.0.1"),
+				}},
+			}))
+			// the default network annotation is kept
+			_, err = util.UnmarshalPodAnnotation(pod.Annotations)
+			Expect(err).NotTo(HaveOccurred())
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists lsp-del blue_namespace1.blue_namespace1_pod1",
+			})
+			err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Delete(context.TODO(), podT.Name, *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch external_ids:network=blue",
+				Output: "blue_node1,network=blue node=node1 subnets=10.200.0.0/24",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists ls-del blue_node1 -- --if-exists lr-del blue_ovn_cluster_router",
+			})
+			err = fakeOvn.fakeClient.NetworkAttchDefClient.K8sCniCncfIoV1().NetworkAttachmentDefinitions(namespaceT.Name).Delete(context.TODO(), nad.Name, metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-multi-network"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates a layer2 network with a single switch and reuses the IPs of the attached pods", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			attached := *newSecondaryNetworkPod(namespaceT.Name, "attached", "node1", "10.128.1.3", "namespace1/green")
+			annotations, err := util.MarshalPodAnnotationForNetwork(attached.Annotations, &util.PodAnnotation{
+				IPs: ovntest.MustParseIPNets("10.100.0.3/24"),
+				MAC: ovntest.MustParseMAC("0a:58:0a:64:00:03"),
+			}, "namespace1/green")
+			Expect(err).NotTo(HaveOccurred())
+			attached.Annotations[util.OvnPodAnnotationName] = annotations[util.OvnPodAnnotationName]
+			podT := *newSecondaryNetworkPod(namespaceT.Name, "pod1", "node2", "10.128.2.3",
+				`[{"name": "green", "mac": "0a:58:00:00:00:01"}]`)
+			nad := *newNetworkAttachmentDefinition(namespaceT.Name, "green",
+				`{"cniVersion": "0.4.0", "name": "green", "type": "ovn-k8s-cni-overlay", "topology": "layer2", `+
+					`"subnets": "10.100.0.0/24", "netAttachDefName": "namespace1/green"}`)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&v1.PodList{Items: []v1.Pod{attached, podT}},
+				&nadapi.NetworkAttachmentDefinitionList{Items: []nadapi.NetworkAttachmentDefinition{nad}},
+			)
+
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch_port",
+				Output: "green_namespace1.green_namespace1_attached,namespace=namespace1 network=green",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch",
+				Output: "green_ovn_layer2_switch,network=green",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_router",
+				"ovn-nbctl --timeout=15 --may-exist ls-add green_ovn_layer2_switch " +
+					"-- set logical_switch green_ovn_layer2_switch external_ids:network=green",
+				"ovn-nbctl --timeout=15 --may-exist lsp-add green_ovn_layer2_switch green_namespace1.green_namespace1_attached " +
+					"-- lsp-set-addresses green_namespace1.green_namespace1_attached 0a:58:0a:64:00:03 10.100.0.3 " +
+					"-- lsp-set-port-security green_namespace1.green_namespace1_attached 0a:58:0a:64:00:03 10.100.0.3 " +
+					"-- set logical_switch_port green_namespace1.green_namespace1_attached external_ids:namespace=namespace1 " +
+					"external_ids:network=green external_ids:nad=\"namespace1/green\"",
+				"ovn-nbctl --timeout=15 --may-exist lsp-add green_ovn_layer2_switch green_namespace1.green_namespace1_pod1 " +
+					"-- lsp-set-addresses green_namespace1.green_namespace1_pod1 0a:58:00:00:00:01 10.100.0.4 " +
+					"-- lsp-set-port-security green_namespace1.green_namespace1_pod1 0a:58:00:00:00:01 10.100.0.4 " +
+					"-- set logical_switch_port green_namespace1.green_namespace1_pod1 external_ids:namespace=namespace1 " +
+					"external_ids:network=green external_ids:nad=\"namespace1/green\"",
+			})
+
+			fakeOvn.controller.WatchNetworkAttachmentDefinitions()
+			fakeOvn.controller.WatchSecondaryNetworkPods()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			pod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Get(context.TODO(), podT.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			podInfo, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, "namespace1/green")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podInfo).To(Equal(&util.PodAnnotation{
+				IPs: ovntest.MustParseIPNets("10.100.0.4/24"),
+				MAC: ovntest.MustParseMAC("0a:58:00:00:00:01"),
+			}))
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-multi-network"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates a localnet network with a VLAN and attaches the pods to it without IPAM", func() {
+		app.Action = func(ctx *cli.Context) error {
+			namespaceT := *newNamespace("namespace1")
+			podT := *newSecondaryNetworkPod(namespaceT.Name, "pod1", "node1", "10.128.1.3",
+				`[{"name": "dc", "mac": "0a:58:00:00:00:01"}]`)
+			nad := *newNetworkAttachmentDefinition(namespaceT.Name, "dc",
+				`{"cniVersion": "0.4.0", "name": "dc", "type": "ovn-k8s-cni-overlay", "topology": "localnet", `+
+					`"physicalNetworkName": "physnet-dc", "vlanID": 100, "netAttachDefName": "namespace1/dc"}`)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{namespaceT}},
+				&v1.PodList{Items: []v1.Pod{podT}},
+				&nadapi.NetworkAttachmentDefinitionList{Items: []nadapi.NetworkAttachmentDefinition{nad}},
+			)
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch_port",
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_switch",
+				"ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,external_ids find logical_router",
+				"ovn-nbctl --timeout=15 --may-exist ls-add dc_ovn_localnet_switch " +
+					"-- set logical_switch dc_ovn_localnet_switch external_ids:network=dc " +
+					"-- --may-exist lsp-add dc_ovn_localnet_switch dc_ovn_localnet_port " +
+					"-- lsp-set-type dc_ovn_localnet_port localnet -- lsp-set-addresses dc_ovn_localnet_port unknown " +
+					"-- lsp-set-options dc_ovn_localnet_port network_name=physnet-dc " +
+					"-- set logical_switch_port dc_ovn_localnet_port tag=100",
+				"ovn-nbctl --timeout=15 --may-exist lsp-add dc_ovn_localnet_switch dc_namespace1.dc_namespace1_pod1 " +
+					"-- lsp-set-addresses dc_namespace1.dc_namespace1_pod1 0a:58:00:00:00:01 " +
+					"-- lsp-set-port-security dc_namespace1.dc_namespace1_pod1 0a:58:00:00:00:01 " +
+					"-- set logical_switch_port dc_namespace1.dc_namespace1_pod1 external_ids:namespace=namespace1 " +
+					"external_ids:network=dc external_ids:nad=\"namespace1/dc\"",
+			})
+
+			fakeOvn.controller.WatchNetworkAttachmentDefinitions()
+			fakeOvn.controller.WatchSecondaryNetworkPods()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			pod, err := fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Get(context.TODO(), podT.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			podInfo, err := util.UnmarshalPodAnnotationForNetwork(pod.Annotations, "namespace1/dc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podInfo).To(Equal(&util.PodAnnotation{MAC: ovntest.MustParseMAC("0a:58:00:00:00:01")}))
+
+			// the port of a deleted pod has no IPs to release
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists lsp-del dc_namespace1.dc_namespace1_pod1",
+			})
+			err = fakeOvn.fakeClient.KubeClient.CoreV1().Pods(namespaceT.Name).Delete(context.TODO(), podT.Name, *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--enable-multi-network"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
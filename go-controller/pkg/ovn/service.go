@@ -1,513 +1,616 @@
 package ovn
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
-	"net"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/loadbalancer"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/reference"
 	"k8s.io/klog/v2"
+	utilnet "k8s.io/utils/net"
 )
 
-func addRejectACLs(rejectACLs map[string]map[string]bool, lb, ip string, port int32, hasEndpoints bool) {
-	if ip != "" {
-		name := generateACLName(lb, ip, port)
-		if _, ok := rejectACLs[name]; !ok {
-			rejectACLs[name] = make(map[string]bool)
-		}
-		rejectACLs[name][lb] = hasEndpoints
-	}
+// serviceExternalID is the external ID set to namespace/name of the service owning
+// a load balancer
+const serviceExternalID = "k8s-service"
+
+// legacyServiceLBExternalIDs are the external IDs of the load balancers shared by
+// the services in previous versions, which are deleted on startup
+var legacyServiceLBExternalIDs = []string{
+	"k8s-cluster-lb-tcp",
+	"k8s-cluster-lb-udp",
+	"k8s-cluster-lb-sctp",
+	"k8s-cluster-lb-affinity",
+	"TCP_lb_gateway_router",
+	"UDP_lb_gateway_router",
+	"SCTP_lb_gateway_router",
+	"lb_gateway_router_dedicated",
 }
 
+// serviceLBNode is a node the load balancers of the services are applied to
+type serviceLBNode struct {
+	// hasSwitch is true once the node logical switch is created
+	hasSwitch bool
+	// gatewayRouter serves the node ports, external IPs and ingress IPs of the
+	// services on the node, empty if the node has none
+	gatewayRouter string
+	// physicalIPs are the node IPs the node ports are exposed on
+	physicalIPs []string
+	// zone is the topology.kubernetes.io/zone label of the node, matched against
+	// the topology hints of the endpoints
+	zone string
+}
+
+// serviceHealthCheck is the value of the OvnServiceHealthCheck annotation
+type serviceHealthCheck struct {
+	Interval     int32 `json:"interval,omitempty"`
+	Timeout      int32 `json:"timeout,omitempty"`
+	SuccessCount int32 `json:"successCount,omitempty"`
+	FailureCount int32 `json:"failureCount,omitempty"`
+}
+
+func serviceExternalIDs(namespace, name string) map[string]string {
+	return map[string]string{serviceExternalID: namespace + "/" + name}
+}
+
+// syncServices deletes the load balancers of the services that no longer exist, as
+// well as the load balancers and reject ACLs used by previous versions
 func (ovn *Controller) syncServices(services []interface{}) {
-	// For all clusterIP in k8s, we will populate the below slice with
-	// IP:port. In OVN's database those are the keys. We need to
-	// have separate slice for TCP, SCTP, and UDP load-balancers (hence the dict).
-	clusterServices := make(map[kapi.Protocol][]string)
-
-	// For all nodePorts in k8s, we will populate the below slice with
-	// nodePort. In OVN's database, nodeIP:nodePort is the key.
-	// We have separate slice for TCP, SCTP, and UDP nodePort load-balancers.
-	// We will get nodeIP separately later.
-	nodeportServices := make(map[kapi.Protocol][]string)
-
-	// For all externalIPs in k8s, we will populate the below map of slices
-	// with load balancer type services based on each protocol.
-	lbServices := make(map[kapi.Protocol][]string)
-
-	// Track which services found should have reject ACLs. Format is name, load balancer, and value is if service has endpoints
-	svcRejectACLs := make(map[string]map[string]bool)
-
-	// Go through the k8s services and populate 'clusterServices',
-	// 'nodeportServices' and 'lbServices'
+	if err := ovn.lbManager.Sync(); err != nil {
+		klog.Errorf("Service Sync: failed to get the load balancers: %v", err)
+		return
+	}
+
+	foundServices := sets.NewString()
 	for _, serviceInterface := range services {
 		service, ok := serviceInterface.(*kapi.Service)
 		if !ok {
 			klog.Errorf("Spurious object in syncServices: %v", serviceInterface)
 			continue
 		}
+		foundServices.Insert(service.Namespace + "/" + service.Name)
+	}
 
-		if !util.ServiceTypeHasClusterIP(service) {
+	lbs, err := ovn.lbManager.Find(nil)
+	if err != nil {
+		klog.Errorf("Service Sync: failed to get the load balancers: %v", err)
+		return
+	}
+	staleLBs := []string{}
+	for _, lb := range lbs {
+		if owner, ok := lb.ExternalIDs[serviceExternalID]; ok {
+			if !foundServices.Has(owner) {
+				klog.Infof("Service Sync: deleting load balancer %s of stale service %s", lb.Name, owner)
+				staleLBs = append(staleLBs, lb.UUID)
+			}
 			continue
 		}
+		for _, key := range legacyServiceLBExternalIDs {
+			if _, ok := lb.ExternalIDs[key]; ok {
+				klog.Infof("Service Sync: deleting legacy load balancer %s", lb.UUID)
+				staleLBs = append(staleLBs, lb.UUID)
+				break
+			}
+		}
+	}
+	if err := ovn.lbManager.DeleteLBs(staleLBs); err != nil {
+		klog.Errorf("Service Sync: %v", err)
+	}
 
-		if !util.IsClusterIPSet(service) {
-			klog.V(5).Infof("Skipping service %s due to clusterIP = %q", service.Name, service.Spec.ClusterIP)
+	if err := ovn.deleteLegacyRejectACLs(); err != nil {
+		klog.Errorf("Service Sync: %v", err)
+	}
+}
+
+// deleteLegacyRejectACLs deletes the reject ACLs previously used for services without
+// endpoints, which now use the reject option of their load balancers. Only services
+// created reject ACLs.
+func (ovn *Controller) deleteLegacyRejectACLs() error {
+	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid",
+		"find", "acl", "action=reject")
+	if err != nil {
+		return fmt.Errorf("failed to find reject ACLs, stderr: %q, error: %v", stderr, err)
+	}
+	rejectACLs := sets.NewString(strings.Fields(stdout)...)
+	if rejectACLs.Len() == 0 {
+		return nil
+	}
+
+	stdout, stderr, err = util.RunOVNNbctl("--format=csv", "--data=bare", "--no-heading",
+		"--columns=name,acls", "list", "logical_switch")
+	if err != nil {
+		return fmt.Errorf("failed to list logical switches, stderr: %q, error: %v", stderr, err)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to parse logical switches %q: %v", stdout, err)
+	}
+	args := []string{}
+	for _, record := range records {
+		if len(record) != 2 {
 			continue
 		}
-
-		// detect if service has endpoints for stale reject ACL check. If there are endpoints, we need to wipe any
-		// old stale ACLs
-		ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
-		hasEndpoints := false
-		if err == nil {
-			if len(ep.Subsets) > 0 {
-				hasEndpoints = true
+		for _, acl := range strings.Fields(record[1]) {
+			if rejectACLs.Has(acl) {
+				args = append(args, "--", "--if-exists", "remove", "logical_switch", record[0], "acls", acl)
 			}
 		}
+	}
+	if ovn.clusterPortGroupUUID != "" {
+		for _, acl := range rejectACLs.List() {
+			args = append(args, "--", "--if-exists", "remove", "port_group", ovn.clusterPortGroupUUID, "acls", acl)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	klog.Infof("Service Sync: deleting %d legacy reject ACLs", rejectACLs.Len())
+	_, stderr, err = util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete reject ACLs, stderr: %q, error: %v", stderr, err)
+	}
+	return nil
+}
 
-		for _, svcPort := range service.Spec.Ports {
-			if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
-				klog.Errorf("Error validating port %s: %v", svcPort.Name, err)
-				continue
-			}
+// syncService programs the load balancers of a service from the service, its endpoints
+// and the nodes of the cluster. The load balancers of a service that no longer exists,
+// or has no cluster IP, are deleted.
+func (ovn *Controller) syncService(namespace, name string) error {
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	return ovn.syncServiceLocked(namespace, name)
+}
 
-			if util.ServiceTypeHasNodePort(service) {
-				port := fmt.Sprintf("%d", svcPort.NodePort)
-				nodeportServices[svcPort.Protocol] = append(nodeportServices[svcPort.Protocol], port)
-				gatewayRouters, _, err := ovn.getOvnGateways()
-				if err == nil {
-					for _, gatewayRouter := range gatewayRouters {
-						lb, err := ovn.getGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
-						if err != nil {
-							klog.Warningf("Service Sync: Gateway router %s does not have load balancer (%v)",
-								gatewayRouter, err)
-							continue
-						}
-						physicalIPs, err := ovn.getGatewayPhysicalIPs(gatewayRouter)
-						if err != nil {
-							klog.Warningf("Service Sync: Gateway router %s does not have physical ips: %v",
-								gatewayRouter, err)
-							continue
-						}
-						for _, physicalIP := range physicalIPs {
-							addRejectACLs(svcRejectACLs, lb, physicalIP, svcPort.NodePort, hasEndpoints)
-						}
-					}
-				}
-			}
+func (ovn *Controller) syncServiceLocked(namespace, name string) error {
+	var lbs []loadbalancer.LB
+	service, err := ovn.watchFactory.GetService(namespace, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get service %s/%s: %v", namespace, name, err)
+	}
+	if err == nil && util.ServiceTypeHasClusterIP(service) && util.IsClusterIPSet(service) {
+		lbs = ovn.buildServiceLBs(service)
+	}
+	if err := ovn.lbManager.EnsureLBs(serviceExternalIDs(namespace, name), lbs); err != nil {
+		return fmt.Errorf("failed to program the load balancers of service %s/%s: %v", namespace, name, err)
+	}
+	return nil
+}
 
-			key := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			clusterServices[svcPort.Protocol] = append(clusterServices[svcPort.Protocol], key)
-			lb, err := ovn.getLoadBalancer(svcPort.Protocol)
-			if err != nil {
-				klog.Warningf("Unable to get existing load balancer from ovn. Reject ACLs may not be synced!")
-			} else {
-				addRejectACLs(svcRejectACLs, lb, service.Spec.ClusterIP, svcPort.Port, hasEndpoints)
+// buildServiceLBs returns the load balancers of a service, one per protocol for the
+// cluster IP, applied to all the node switches, and one per protocol and node for the
+// node ports, external IPs and ingress IPs, applied to the node gateway router and
+// switch. Each VIP without endpoints is rejected, unless the service is idled and
+// empty load balancer events are enabled.
+func (ovn *Controller) buildServiceLBs(service *kapi.Service) []loadbalancer.LB {
+	protoPortMap := map[kapi.Protocol]map[string]lbEndpoints{}
+	ep, err := ovn.getServiceEndpoints(service.Namespace, service.Name)
+	if err == nil {
+		protoPortMap = ovn.getLbEndpoints(ep)
+	} else {
+		klog.V(5).Infof("No endpoints found for service %s/%s: %v", service.Namespace, service.Name, err)
+	}
 
-				// Cloud load balancers: directly load balance that traffic from pods
-				for _, ing := range service.Status.LoadBalancer.Ingress {
-					addRejectACLs(svcRejectACLs, lb, ing.IP, svcPort.Port, hasEndpoints)
-				}
-			}
-			for _, extIP := range service.Spec.ExternalIPs {
-				key := util.JoinHostPortInt32(extIP, svcPort.Port)
-				lbServices[svcPort.Protocol] = append(lbServices[svcPort.Protocol], key)
-				gateways, _, err := ovn.getOvnGateways()
-				if err != nil {
-					continue
-				}
-				for _, gateway := range gateways {
-					lb, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
-					if err != nil {
-						klog.Errorf("Service Sync: Gateway router %s does not have load balancer (%v)",
-							gateway, err)
-						continue
-					}
-					addRejectACLs(svcRejectACLs, lb, extIP, svcPort.Port, hasEndpoints)
-				}
-			}
-		}
+	healthCheck, err := parseServiceHealthCheck(service)
+	if err != nil {
+		klog.Errorf("Invalid health check of service %s/%s: %v", service.Namespace, service.Name, err)
+		ovn.recordServiceEvent(service, "InvalidHealthCheck", err.Error())
+	}
+	var portMappings map[string]loadbalancer.PortMapping
+	if healthCheck != nil && ep != nil {
+		portMappings = ovn.getBackendPortMappings(ep)
+	}
+	var zoneHints map[string]sets.String
+	if serviceUsesTopologyHints(service) {
+		zoneHints = ovn.getServiceZoneHints(service.Namespace, service.Name)
 	}
 
-	// Get OVN's current reject ACLs. Note, currently only services use reject ACLs.
-	type ovnACLData struct {
-		Data [][]interface{}
+	nodeNames := []string{}
+	switches := []string{}
+	for nodeName, node := range ovn.serviceLBNodes {
+		if !node.hasSwitch {
+			continue
+		}
+		switches = append(switches, nodeName)
+		if node.gatewayRouter != "" {
+			nodeNames = append(nodeNames, nodeName)
+		}
 	}
-	data, stderr, err := util.RunOVNNbctl("--columns=name,_uuid", "--format=json", "find", "acl", "action=reject")
-	if err != nil {
-		klog.Errorf("Error while querying ACLs with reject action: %s, %v", stderr, err)
-	} else {
-		x := ovnACLData{}
-		if err := json.Unmarshal([]byte(data), &x); err != nil {
-			klog.Errorf("Unable to get current OVN reject ACLs. Unable to sync reject ACLs!: %v", err)
-		} else if len(x.Data) == 0 {
-			klog.Infof("Service Sync: No reject ACLs currently configured in OVN")
-		} else {
-			for _, entry := range x.Data {
-				// ACL entry format is a slice: [<aclName>, ["_uuid", <uuid>]]
-				if len(entry) != 2 {
-					continue
-				}
-				name, ok := entry[0].(string)
-				if !ok {
-					continue
+	sort.Strings(switches)
+	sort.Strings(nodeNames)
+
+	reject := svcQualifiesForReject(service)
+	opts := loadbalancer.LBOpts{
+		Reject:          reject,
+		Event:           !reject,
+		AffinityTimeout: util.ServiceSessionAffinityTimeout(service),
+	}
+	nodeOpts := opts
+	nodeOpts.SkipSNAT = util.ServiceExternalTrafficPolicyLocal(service)
+
+	nodeLocalClusterIP := util.ServiceInternalTrafficPolicyLocal(service) || serviceUsesTopologyHints(service)
+	clusterRules := map[kapi.Protocol][]loadbalancer.LBRule{}
+	clusterNodeRules := map[kapi.Protocol]map[string][]loadbalancer.LBRule{}
+	nodeRules := map[kapi.Protocol]map[string][]loadbalancer.LBRule{}
+	sctpRejected := false
+	for _, svcPort := range service.Spec.Ports {
+		if err := util.ValidatePort(svcPort.Protocol, svcPort.Port); err != nil {
+			klog.Errorf("Error validating port %s: %v", svcPort.Name, err)
+			continue
+		}
+		if !ovn.SCTPSupport && svcPort.Protocol == kapi.ProtocolSCTP {
+			sctpRejected = true
+			continue
+		}
+		lbEps := protoPortMap[svcPort.Protocol][svcPort.Name]
+
+		vip := service.Spec.ClusterIP
+		if nodeLocalClusterIP {
+			// the cluster IP is load balanced by a load balancer per node switch, to
+			// the endpoints selected by the internal traffic policy or the topology
+			// hints for the node
+			for _, nodeName := range switches {
+				if clusterNodeRules[svcPort.Protocol] == nil {
+					clusterNodeRules[svcPort.Protocol] = map[string][]loadbalancer.LBRule{}
 				}
-				uuidData, ok := entry[1].([]interface{})
-				if !ok || len(uuidData) != 2 {
-					continue
+				targetIPs := lbEps.clusterTargetIPs(service, nodeName, ovn.serviceLBNodes[nodeName].zone, zoneHints)
+				clusterNodeRules[svcPort.Protocol][nodeName] = append(clusterNodeRules[svcPort.Protocol][nodeName], loadbalancer.LBRule{
+					Source:  loadbalancer.Addr{IP: vip, Port: svcPort.Port},
+					Targets: loadbalancer.TargetsForFamily(vip, targetIPs, lbEps.Port),
+				})
+			}
+		} else {
+			clusterRules[svcPort.Protocol] = append(clusterRules[svcPort.Protocol], loadbalancer.LBRule{
+				Source:  loadbalancer.Addr{IP: vip, Port: svcPort.Port},
+				Targets: loadbalancer.TargetsForFamily(vip, lbEps.IPs, lbEps.Port),
+			})
+		}
+		ovn.AddServiceVIPToName(util.JoinHostPortInt32(vip, svcPort.Port), svcPort.Protocol,
+			service.Namespace, service.Name)
+
+		for _, nodeName := range nodeNames {
+			targetIPs := lbEps.nodeTargetIPs(service, nodeName, ovn.serviceLBNodes[nodeName].zone, zoneHints)
+			sources := []loadbalancer.Addr{}
+			if util.ServiceTypeHasNodePort(service) && svcPort.NodePort != 0 {
+				for _, physicalIP := range ovn.serviceLBNodes[nodeName].physicalIPs {
+					sources = append(sources, loadbalancer.Addr{IP: physicalIP, Port: svcPort.NodePort})
 				}
-				uuid, ok := uuidData[1].(string)
-				if !ok {
-					continue
+			}
+			// Local gateway mode DNATs the node ports, external IPs and ingress IPs of
+			// services with the Local external traffic policy to the node port on the
+			// node switch gateway IP, so that the client source IP is preserved
+			if config.Gateway.Mode == config.GatewayModeLocal && nodeOpts.SkipSNAT && svcPort.NodePort != 0 {
+				for _, subnet := range ovn.lsManager.GetSwitchSubnets(nodeName) {
+					gwIfAddr := util.GetNodeGatewayIfAddr(subnet)
+					sources = append(sources, loadbalancer.Addr{IP: gwIfAddr.IP.String(), Port: svcPort.NodePort})
 				}
-				if svcCacheEntry, ok := svcRejectACLs[name]; ok {
-					for lb, hasEps := range svcCacheEntry {
-						if hasEps {
-							klog.Infof("Service Sync: Removing OVN stale reject ACL: %s", name)
-							ovn.removeACLFromPortGroup(lb, uuid)
-							var foundSwitches []string
-							// For upgrade from a non-port group Reject ACL implementation
-							// Deprecated: remove in the future
-							switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
-							if err != nil {
-								klog.Errorf("Error finding node logical switches for load balancer "+
-									"%s: %v", lb, err)
-							} else {
-								foundSwitches = append(foundSwitches, switches...)
-							}
-							// Look for load balancer on join/external switches
-							grExtSwitch, err := ovn.getGRLogicalSwitchForLoadBalancer(lb)
-							if err != nil {
-								klog.Errorf("Error finding GR logical switches for load balancer "+
-									"%s: %v", lb, err)
-							} else {
-								// For upgrade from a previous implementation the ACL may also be on join switch
-								if grExtSwitch != "" {
-									routerName := strings.TrimPrefix(grExtSwitch, types.ExternalSwitchPrefix)
-									grJoinSwitch := types.JoinSwitchPrefix + routerName
-									foundSwitches = append(foundSwitches, grExtSwitch, grJoinSwitch)
-								}
-							}
-							if len(foundSwitches) > 0 {
-								klog.V(5).Infof("Service Sync: Removing OVN stale reject ACL (%s) "+
-									"from logical switches that contains load balancer %s, switches: %s", name, lb,
-									foundSwitches)
-								ovn.removeACLFromNodeSwitches(foundSwitches, uuid)
-							}
-						}
-					}
+			}
+			for _, extIP := range service.Spec.ExternalIPs {
+				sources = append(sources, loadbalancer.Addr{IP: extIP, Port: svcPort.Port})
+			}
+			// Cloud load balancers: load balance the ingress traffic to the gateway
+			// router, as well as the traffic from the pods of the node
+			for _, ing := range service.Status.LoadBalancer.Ingress {
+				if ing.IP != "" {
+					sources = append(sources, loadbalancer.Addr{IP: ing.IP, Port: svcPort.Port})
 				}
 			}
+			if len(sources) == 0 {
+				continue
+			}
+			if nodeRules[svcPort.Protocol] == nil {
+				nodeRules[svcPort.Protocol] = map[string][]loadbalancer.LBRule{}
+			}
+			for _, source := range sources {
+				nodeRules[svcPort.Protocol][nodeName] = append(nodeRules[svcPort.Protocol][nodeName], loadbalancer.LBRule{
+					Source:  source,
+					Targets: loadbalancer.TargetsForFamily(source.IP, targetIPs, lbEps.Port),
+				})
+			}
 		}
 	}
+	if sctpRejected {
+		ovn.recordServiceEvent(service, "Unsupported protocol error", "SCTP protocol is unsupported by this version of OVN")
+	}
 
-	// Get OVN's current cluster load balancer VIPs and delete them if they
-	// are stale.
-	for _, protocol := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
-		loadBalancer, err := ovn.getLoadBalancer(protocol)
-		if err != nil {
-			klog.Errorf("Failed to get load balancer for %s (%v)", kapi.Protocol(protocol), err)
-			continue
+	ids := serviceExternalIDs(service.Namespace, service.Name)
+	lbs := []loadbalancer.LB{}
+	for _, proto := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
+		if rules, ok := clusterRules[proto]; ok {
+			lbs = append(lbs, loadbalancer.LB{
+				Name:         fmt.Sprintf("Service_%s/%s_%s_cluster", service.Namespace, service.Name, proto),
+				Protocol:     strings.ToLower(string(proto)),
+				ExternalIDs:  ids,
+				Opts:         opts,
+				Rules:        rules,
+				HealthCheck:  healthCheck,
+				PortMappings: portMappings,
+				Switches:     switches,
+			})
 		}
-
-		loadBalancerVIPs, err := ovn.getLoadBalancerVIPs(loadBalancer)
-		if err != nil {
-			klog.Errorf("Failed to get load balancer vips for %s (%v)", loadBalancer, err)
-			continue
+		for _, nodeName := range switches {
+			rules, ok := clusterNodeRules[proto][nodeName]
+			if !ok {
+				continue
+			}
+			lbs = append(lbs, loadbalancer.LB{
+				Name:         fmt.Sprintf("Service_%s/%s_%s_cluster_%s", service.Namespace, service.Name, proto, nodeName),
+				Protocol:     strings.ToLower(string(proto)),
+				ExternalIDs:  ids,
+				Opts:         opts,
+				Rules:        rules,
+				HealthCheck:  healthCheck,
+				PortMappings: portMappings,
+				Switches:     []string{nodeName},
+			})
 		}
-		for vip := range loadBalancerVIPs {
-			if !stringSliceMembership(clusterServices[protocol], vip) {
-				klog.V(5).Infof("Deleting stale cluster vip %s in load balancer %s", vip, loadBalancer)
-				if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-					klog.Error(err)
-				}
+		for _, nodeName := range nodeNames {
+			rules, ok := nodeRules[proto][nodeName]
+			if !ok {
+				continue
+			}
+			lb := loadbalancer.LB{
+				Name:         fmt.Sprintf("Service_%s/%s_%s_node_%s", service.Namespace, service.Name, proto, nodeName),
+				Protocol:     strings.ToLower(string(proto)),
+				ExternalIDs:  ids,
+				Opts:         nodeOpts,
+				Rules:        rules,
+				HealthCheck:  healthCheck,
+				PortMappings: portMappings,
+				// the node switch load balances the traffic from the pods to the node ports
+				Switches: []string{nodeName},
 			}
+			// Local gateway mode does not use GR for ingress node port traffic, it uses mp0 instead
+			if config.Gateway.Mode != config.GatewayModeLocal {
+				lb.Routers = []string{ovn.serviceLBNodes[nodeName].gatewayRouter}
+			}
+			lbs = append(lbs, lb)
 		}
 	}
+	return lbs
+}
 
-	// For each gateway, remove any VIP that does not exist in
-	// 'nodeportServices'.
-	gateways, stderr, err := ovn.getOvnGateways()
+// recordServiceEvent records a warning event for a service
+func (ovn *Controller) recordServiceEvent(service *kapi.Service, reason, message string) {
+	ref, err := reference.GetReference(scheme.Scheme, service)
 	if err != nil {
-		klog.Errorf("Failed to get ovn gateways. Not syncing nodeport stdout: %q, stderr: %q (%v)", gateways, stderr, err)
+		klog.Errorf("Could not get reference for service %v: %v\n", service.Name, err)
 		return
 	}
+	ovn.recorder.Event(ref, kapi.EventTypeWarning, reason, message)
+}
 
-	for _, gateway := range gateways {
-		for _, protocol := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
-			loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, protocol)
-			if err != nil {
-				klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
+// parseServiceHealthCheck returns the health check of the endpoints of a service set
+// by its OvnServiceHealthCheck annotation, nil if it has none
+func parseServiceHealthCheck(service *kapi.Service) (*loadbalancer.HealthCheck, error) {
+	value, ok := service.Annotations[OvnServiceHealthCheck]
+	if !ok {
+		return nil, nil
+	}
+	hc := serviceHealthCheck{}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &hc); err != nil {
+			return nil, fmt.Errorf("failed to parse annotation %s=%q: %v", OvnServiceHealthCheck, value, err)
+		}
+	}
+	if hc.Interval < 0 || hc.Timeout < 0 || hc.SuccessCount < 0 || hc.FailureCount < 0 {
+		return nil, fmt.Errorf("negative value in annotation %s=%q", OvnServiceHealthCheck, value)
+	}
+	return &loadbalancer.HealthCheck{
+		Interval:     hc.Interval,
+		Timeout:      hc.Timeout,
+		SuccessCount: hc.SuccessCount,
+		FailureCount: hc.FailureCount,
+	}, nil
+}
+
+// getBackendPortMappings returns the logical ports of the pod endpoints keyed by IP,
+// along with the management port IP of their node, from which OVN checks them.
+// Endpoints whose logical port is not known yet are not checked.
+func (ovn *Controller) getBackendPortMappings(ep *kapi.Endpoints) map[string]loadbalancer.PortMapping {
+	mappings := map[string]loadbalancer.PortMapping{}
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
 				continue
 			}
-			loadBalancerVIPs, err := ovn.getLoadBalancerVIPs(loadBalancer)
+			logicalPort := addr.TargetRef.Namespace + "_" + addr.TargetRef.Name
+			portInfo, err := ovn.logicalPortCache.get(logicalPort)
 			if err != nil {
-				klog.Errorf("Failed to get load balancer vips for %s (%v)", loadBalancer, err)
+				klog.V(5).Infof("Not checking the health of endpoint %s: %v", addr.IP, err)
 				continue
 			}
-			for vip := range loadBalancerVIPs {
-				_, port, err := net.SplitHostPort(vip)
-				if err != nil {
-					// In a OVN load-balancer, we should always have vip:port.
-					// In the unlikely event that it is not the case, skip it.
-					klog.Errorf("Failed to split %s to vip and port (%v)", vip, err)
-					continue
-				}
-
-				if !stringSliceMembership(nodeportServices[protocol], port) && !stringSliceMembership(lbServices[protocol], vip) {
-					klog.V(5).Infof("Deleting stale nodeport vip %s in load balancer %s", vip, loadBalancer)
-					if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-						klog.Error(err)
+			isIPv6 := utilnet.IsIPv6String(addr.IP)
+			for _, subnet := range ovn.lsManager.GetSwitchSubnets(portInfo.logicalSwitch) {
+				if utilnet.IsIPv6CIDR(subnet) == isIPv6 {
+					mappings[addr.IP] = loadbalancer.PortMapping{
+						LogicalPort: logicalPort,
+						SourceIP:    util.GetNodeManagementIfAddr(subnet).IP.String(),
 					}
+					break
 				}
 			}
 		}
 	}
+	return mappings
 }
 
-func (ovn *Controller) createService(service *kapi.Service) error {
-	klog.Infof("Creating service %s", service.Name)
-	if !util.IsClusterIPSet(service) {
-		klog.V(5).Infof("Skipping service create: No cluster IP for service %s found", service.Name)
-		return nil
-	} else if len(service.Spec.Ports) == 0 {
-		klog.V(5).Info("Skipping service create: No Ports specified for service")
-		return nil
+// syncServicesLocked reprograms the load balancers of the services for which
+// selected returns true, once the services watch has started, in a single
+// northbound transaction. It must be called with serviceLBLock held.
+func (ovn *Controller) syncServicesLocked(selected func(service *kapi.Service) bool) {
+	if !ovn.servicesStarted {
+		return
 	}
-
-	// We can end up in a situation where the endpoint creation is triggered before the service creation,
-	// we should not be creating the reject ACLs if this endpoint exists, because that would result in an unreachable service
-	// eventough the endpoint exists.
-	// NOTE: we can also end up in a situation where a service matching no pods is created. Such a service still has an endpoint, but with no subsets.
-	// make sure to treat that service as an ACL reject.
-	ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
-	if err == nil {
-		if len(ep.Subsets) > 0 {
-			klog.V(5).Infof("service: %s has endpoint, will create load balancer VIPs", service.Name)
-		} else {
-			klog.V(5).Infof("service: %s has empty endpoint", service.Name)
-			ep = nil
-		}
+	services, err := ovn.watchFactory.GetServices()
+	if err != nil {
+		klog.Errorf("Failed to get services: %v", err)
+		return
 	}
-
-	for _, svcPort := range service.Spec.Ports {
-		var port int32
-		if util.ServiceTypeHasNodePort(service) {
-			port = svcPort.NodePort
-		} else {
-			port = svcPort.Port
+	// sort the services, for the transaction to be the same across runs
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Namespace != services[j].Namespace {
+			return services[i].Namespace < services[j].Namespace
 		}
-
-		if err := util.ValidatePort(svcPort.Protocol, port); err != nil {
-			klog.Errorf("Error validating port %s: %v", svcPort.Name, err)
+		return services[i].Name < services[j].Name
+	})
+	groups := []loadbalancer.LBGroup{}
+	for _, service := range services {
+		if !selected(service) {
 			continue
 		}
-
-		if !ovn.SCTPSupport && svcPort.Protocol == kapi.ProtocolSCTP {
-			ref, err := reference.GetReference(scheme.Scheme, service)
-			if err != nil {
-				klog.Errorf("Could not get reference for pod %v: %v\n", service.Name, err)
-			} else {
-				ovn.recorder.Event(ref, kapi.EventTypeWarning, "Unsupported protocol error", "SCTP protocol is unsupported by this version of OVN")
-			}
-			return fmt.Errorf("invalid service port %s: SCTP is unsupported by this version of OVN", svcPort.Name)
+		var lbs []loadbalancer.LB
+		if util.ServiceTypeHasClusterIP(service) && util.IsClusterIPSet(service) {
+			lbs = ovn.buildServiceLBs(service)
 		}
+		groups = append(groups, loadbalancer.LBGroup{
+			ExternalIDs: serviceExternalIDs(service.Namespace, service.Name),
+			LBs:         lbs,
+		})
+	}
+	if err := ovn.lbManager.EnsureLBGroups(groups); err != nil {
+		klog.Errorf("Failed to program the load balancers of %d services: %v", len(groups), err)
+	}
+}
 
-		if util.ServiceTypeHasNodePort(service) {
-			// Each gateway has a separate load-balancer for N/S traffic
-
-			gatewayRouters, _, err := ovn.getOvnGateways()
-			if err != nil {
-				return err
-			}
-
-			for _, gatewayRouter := range gatewayRouters {
-				loadBalancer, err := ovn.getGatewayLoadBalancer(gatewayRouter, svcPort.Protocol)
-				if err != nil {
-					klog.Errorf("Gateway router %s does not have load balancer (%v)", gatewayRouter, err)
-					continue
-				}
-				physicalIPs, err := ovn.getGatewayPhysicalIPs(gatewayRouter)
-				if err != nil {
-					klog.Errorf("Gateway router %s does not have physical ip (%v)", gatewayRouter, err)
-					continue
-				}
-				for _, physicalIP := range physicalIPs {
-					// With the physical_ip:port as the VIP, add an entry in
-					// 'load balancer'.
-					vip := util.JoinHostPortInt32(physicalIP, port)
-					// Skip creating LB if endpoints watcher already did it
-					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-						klog.V(5).Infof("Load balancer already configured for %s, %s", loadBalancer, vip)
-					} else if ep != nil {
-						if err := ovn.AddEndpoints(ep); err != nil {
-							return err
-						}
-					} else if svcQualifiesForReject(service) {
-						aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, physicalIP, port, svcPort.Protocol)
-						if err != nil {
-							return fmt.Errorf("failed to create service ACL: %v", err)
-						}
-						klog.Infof("Service Reject ACL created for NodePort service: %s, namespace: %s, via "+
-							"gateway router: %s:%s:%d, ACL UUID:%s", service.Name, service.Namespace,
-							svcPort.Protocol, physicalIP, port, aclUUID)
-					}
-				}
-			}
+// serviceHasNodeLBs returns true if the service has node ports, external IPs or
+// ingress IPs, served by the load balancers of the node gateway routers
+func serviceHasNodeLBs(service *kapi.Service) bool {
+	if len(service.Spec.ExternalIPs) > 0 {
+		return true
+	}
+	for _, ing := range service.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			return true
 		}
-		if util.ServiceTypeHasClusterIP(service) {
-			loadBalancer, err := ovn.getLoadBalancer(svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
-				break
-			}
-			if svcQualifiesForReject(service) {
-				gateways, _, err := ovn.getOvnGateways()
-				if err != nil {
-					return err
-				}
-				vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-				// Skip creating LB if endpoints watcher already did it
-				if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-					klog.V(5).Infof("Load balancer already configured for %s, %s", loadBalancer, vip)
-				} else if ep != nil {
-					if err := ovn.AddEndpoints(ep); err != nil {
-						return err
-					}
-				} else {
-					aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, service.Spec.ClusterIP,
-						svcPort.Port, svcPort.Protocol)
-					if err != nil {
-						return fmt.Errorf("failed to create service ACL: %v", err)
-					}
-					klog.Infof("Service Reject ACL created for ClusterIP service: %s, namespace: %s, via: "+
-						"%s:%s:%d, ACL UUID: %s", service.Name, service.Namespace, svcPort.Protocol,
-						service.Spec.ClusterIP, svcPort.Port, aclUUID)
-					// Cloud load balancers reject ACLs
-					for _, ing := range service.Status.LoadBalancer.Ingress {
-						if ing.IP == "" {
-							continue
-						}
-						for _, gateway := range gateways {
-							loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
-							if err != nil {
-								klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
-								continue
-							}
-							aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, ing.IP, svcPort.Port, svcPort.Protocol)
-							if err != nil {
-								klog.Errorf("Failed to create reject ACL for Ingress IP: %s, load balancer: %s, error: %v",
-									ing.IP, loadBalancer, err)
-							} else {
-								klog.Infof("Reject ACL created for Ingress IP: %s, load balancer: %s, %s", ing.IP,
-									loadBalancer, aclUUID)
-							}
-						}
-					}
-				}
-				if len(service.Spec.ExternalIPs) > 0 {
-					for _, extIP := range service.Spec.ExternalIPs {
-						for _, gateway := range gateways {
-							loadBalancer, err := ovn.getGatewayLoadBalancer(gateway, svcPort.Protocol)
-							if err != nil {
-								klog.Errorf("Gateway router %s does not have load balancer (%v)", gateway, err)
-								continue
-							}
-							vip := util.JoinHostPortInt32(extIP, svcPort.Port)
-							// Skip creating LB if endpoints watcher already did it
-							if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-								klog.V(5).Infof("Load Balancer already configured for %s, %s", loadBalancer, vip)
-							} else {
-								aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, extIP, svcPort.Port, svcPort.Protocol)
-								if err != nil {
-									return fmt.Errorf("failed to create service ACL for external IP")
-								}
-								klog.Infof("Service Reject ACL created for ExternalIP service: %s, namespace: %s,"+
-									"via: %s:%s:%d, ACL UUID: %s", service.Name, service.Namespace, svcPort.Protocol,
-									extIP, svcPort.Port, aclUUID)
-							}
-						}
-					}
-				}
+	}
+	if util.ServiceTypeHasNodePort(service) {
+		for _, svcPort := range service.Spec.Ports {
+			if svcPort.NodePort != 0 {
+				return true
 			}
 		}
 	}
-	return nil
+	return false
 }
 
-func (ovn *Controller) updateService(oldSvc, newSvc *kapi.Service) error {
-	if reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports) &&
-		reflect.DeepEqual(newSvc.Spec.ExternalIPs, oldSvc.Spec.ExternalIPs) &&
-		reflect.DeepEqual(newSvc.Spec.ClusterIP, oldSvc.Spec.ClusterIP) &&
-		reflect.DeepEqual(newSvc.Spec.Type, oldSvc.Spec.Type) &&
-		reflect.DeepEqual(newSvc.Status.LoadBalancer.Ingress, oldSvc.Status.LoadBalancer.Ingress) {
-		klog.V(5).Infof("Skipping service update for: %s as change does not apply to any of .Spec.Ports, "+
-			".Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type, .Status.LoadBalancer.Ingress", newSvc.Name)
-		return nil
+// serviceLBsDependOnNodeChange returns true if the load balancers of a service
+// change when a node changes from old to new
+func serviceLBsDependOnNodeChange(service *kapi.Service, old, new *serviceLBNode) bool {
+	if old.hasSwitch != new.hasSwitch {
+		return true
 	}
+	if (old.gatewayRouter != new.gatewayRouter || !reflect.DeepEqual(old.physicalIPs, new.physicalIPs)) &&
+		serviceHasNodeLBs(service) {
+		return true
+	}
+	return old.zone != new.zone && serviceUsesTopologyHints(service)
+}
 
-	klog.V(5).Infof("Updating service from: %v to: %v", oldSvc, newSvc)
-
-	ovn.deleteService(oldSvc)
-	return ovn.createService(newSvc)
+// stageServiceLBNode stages a change of a node the load balancers of the services
+// are applied to, until commitServiceLBNode applies the changes staged while
+// handling a node event
+func (ovn *Controller) stageServiceLBNode(nodeName string, update func(node *serviceLBNode)) {
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	node, ok := ovn.stagedServiceLBNodes[nodeName]
+	if !ok {
+		node = &serviceLBNode{}
+		if cur, ok := ovn.serviceLBNodes[nodeName]; ok {
+			*node = *cur
+		}
+		ovn.stagedServiceLBNodes[nodeName] = node
+	}
+	update(node)
 }
 
-func (ovn *Controller) deleteService(service *kapi.Service) {
-	klog.Infof("Deleting service %s", service.Name)
-	if !util.IsClusterIPSet(service) {
+// commitServiceLBNode applies the staged changes of a node, and reprograms the load
+// balancers of the services that depend on the changed fields of the node
+func (ovn *Controller) commitServiceLBNode(nodeName string) {
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	node, ok := ovn.stagedServiceLBNodes[nodeName]
+	if !ok {
 		return
 	}
-	for _, svcPort := range service.Spec.Ports {
-		var port int32
-		if util.ServiceTypeHasNodePort(service) {
-			port = svcPort.NodePort
-		} else {
-			port = svcPort.Port
-		}
+	delete(ovn.stagedServiceLBNodes, nodeName)
+	old, ok := ovn.serviceLBNodes[nodeName]
+	if !ok {
+		old = &serviceLBNode{}
+	}
+	if reflect.DeepEqual(node, old) {
+		return
+	}
+	ovn.serviceLBNodes[nodeName] = node
+	ovn.syncServicesLocked(func(service *kapi.Service) bool {
+		return serviceLBsDependOnNodeChange(service, old, node)
+	})
+}
 
-		if err := util.ValidatePort(svcPort.Protocol, port); err != nil {
-			klog.Errorf("Skipping delete for service port %s: %v", svcPort.Name, err)
-			continue
-		}
+// addServiceLBNode applies the load balancers of the services to a node logical switch
+func (ovn *Controller) addServiceLBNode(nodeName string) {
+	ovn.stageServiceLBNode(nodeName, func(node *serviceLBNode) {
+		node.hasSwitch = true
+	})
+}
 
-		if util.ServiceTypeHasNodePort(service) {
-			// Delete the 'NodePort' service from a load balancer instantiated in gateways.
-			ovn.deleteGatewayVIPs(svcPort.Protocol, port)
-		}
-		if util.ServiceTypeHasClusterIP(service) {
-			loadBalancer, err := ovn.getLoadBalancer(svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("Failed to get load balancer for %s (%v)", svcPort.Protocol, err)
-				continue
-			}
-			vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			if err := ovn.deleteLoadBalancerVIP(loadBalancer, vip); err != nil {
-				klog.Error(err)
-			}
-			// Cloud load balancers
-			if err := ovn.deleteIngressVIPs(service, svcPort); err != nil {
-				klog.Error(err)
-			}
-			if err := ovn.deleteExternalVIPs(service, svcPort); err != nil {
-				klog.Error(err)
-			}
-		}
+// setServiceLBNodeZone sets the zone of a node, matched against the topology hints of
+// the endpoints of the services
+func (ovn *Controller) setServiceLBNodeZone(nodeName, zone string) {
+	ovn.stageServiceLBNode(nodeName, func(node *serviceLBNode) {
+		node.zone = zone
+	})
+}
+
+// setServiceLBNodeGateway sets the gateway router serving the node ports, external IPs
+// and ingress IPs of the services on a node, an empty gatewayRouter removing them
+func (ovn *Controller) setServiceLBNodeGateway(nodeName, gatewayRouter string, physicalIPs []string) {
+	ovn.stageServiceLBNode(nodeName, func(node *serviceLBNode) {
+		node.gatewayRouter = gatewayRouter
+		node.physicalIPs = physicalIPs
+	})
+}
+
+// deleteServiceLBNodeGateway removes the load balancers of the services from the
+// deleted gateway router of a node
+func (ovn *Controller) deleteServiceLBNodeGateway(nodeName string) {
+	ovn.lbManager.DeleteRouter(types.GWRouterPrefix + nodeName)
+	ovn.setServiceLBNodeGateway(nodeName, "", nil)
+}
+
+// deleteServiceLBNode removes the load balancers of the services from a deleted node
+func (ovn *Controller) deleteServiceLBNode(nodeName string) {
+	ovn.lbManager.DeleteSwitch(nodeName)
+	ovn.lbManager.DeleteRouter(types.GWRouterPrefix + nodeName)
+	ovn.serviceLBLock.Lock()
+	defer ovn.serviceLBLock.Unlock()
+	delete(ovn.stagedServiceLBNodes, nodeName)
+	old, ok := ovn.serviceLBNodes[nodeName]
+	if !ok {
+		return
 	}
+	delete(ovn.serviceLBNodes, nodeName)
+	ovn.syncServicesLocked(func(service *kapi.Service) bool {
+		return serviceLBsDependOnNodeChange(service, old, &serviceLBNode{})
+	})
 }
 
-// svcQualifiesForReject determines if a service should have a reject ACL on it when it has no endpoints
-// The reject ACL is only applied to terminate incoming connections immediately when idling is not used
-// or OVNEmptyLbEvents are not enabled. When idilng or empty LB events are enabled, we want to ensure we
-// receive these packets and not reject them.
+// svcQualifiesForReject determines if a service should reject the traffic to its VIPs
+// when it has no endpoints. The traffic is only rejected to terminate incoming
+// connections immediately when idling is not used or OVNEmptyLbEvents are not enabled.
+// When idling or empty LB events are enabled, we want to ensure we receive these
+// packets and not reject them.
 func svcQualifiesForReject(service *kapi.Service) bool {
 	_, ok := service.Annotations[OvnServiceIdledAt]
 	return !(config.Kubernetes.OVNEmptyLbEvents && ok)
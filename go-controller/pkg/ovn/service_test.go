@@ -2,7 +2,7 @@ package ovn
 
 import (
 	"context"
-	"fmt"
+	"net"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,8 +15,6 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-type service struct{}
-
 func newServiceMeta(name, namespace string) metav1.ObjectMeta {
 	return metav1.ObjectMeta{
 		UID:       types.UID(namespace),
@@ -40,117 +38,27 @@ func newService(name, namespace, ip string, ports []v1.ServicePort, serviceType
 	}
 }
 
-func (s service) baseCmds(fexec *ovntest.FakeExec, service v1.Service) {
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
-		Output: k8sTCPLoadBalancerIP,
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --columns=name,_uuid --format=json find acl action=reject",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sTCPLoadBalancerIP),
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:53\"", k8sTCPLoadBalancerIP),
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", k8sTCPLoadBalancerIP),
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
-		Output: k8sUDPLoadBalancerIP,
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sUDPLoadBalancerIP),
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:53\"", k8sUDPLoadBalancerIP),
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", k8sUDPLoadBalancerIP),
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
-		Output: k8sSCTPLoadBalancerIP,
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer %s vips", k8sSCTPLoadBalancerIP),
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"172.30.0.10:53\"", k8sSCTPLoadBalancerIP),
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-172.30.0.10\\:53", k8sSCTPLoadBalancerIP),
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-		Output: "gateway1",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=gateway1",
-		Output: "tcp_load_balancer_id_1",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer tcp_load_balancer_id_1 vips",
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"172.30.0.10:53\"",
-		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=tcp_load_balancer_id_1-172.30.0.10\\:53",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:UDP_lb_gateway_router=gateway1",
-		Output: "udp_load_balancer_id_1",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer udp_load_balancer_id_1 vips",
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --if-exists remove load_balancer udp_load_balancer_id_1 vips \"172.30.0.10:53\"",
-		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=udp_load_balancer_id_1-172.30.0.10\\:53",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:SCTP_lb_gateway_router=gateway1",
-		Output: "sctp_load_balancer_id_1",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer sctp_load_balancer_id_1 vips",
-		Output: "{\"172.30.0.10:53\"=\"10.128.0.18:5353,10.129.0.3:5353\"}",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --if-exists remove load_balancer sctp_load_balancer_id_1 vips \"172.30.0.10:53\"",
-		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=sctp_load_balancer_id_1-172.30.0.10\\:53",
-		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-	})
-	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}%s", k8sTCPLoadBalancerIP),
-		Output: "62c672a4-1132-44ab-9202-e47d18784138",
-	})
-	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}%s", k8sTCPLoadBalancerIP),
-	})
-}
+const (
+	listServiceLBsCmd        = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=_uuid,name,protocol,external_ids,vips,options,selection_fields,ip_port_mappings,health_check list load_balancer"
+	listServiceLBSwitchesCmd = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,load_balancer list logical_switch"
+	listServiceLBRoutersCmd  = "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,load_balancer list logical_router"
+)
 
-func (s service) addCmds(fexec *ovntest.FakeExec, service v1.Service) {
-	s.baseCmds(fexec, service)
-	for _, port := range service.Spec.Ports {
-		fexec.AddFakeCmdsNoOutputNoError([]string{
-			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-%s\\:%v",
-				k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
-			fmt.Sprintf("ovn-nbctl --timeout=15 --id=@reject-acl create acl direction=from-lport priority=1000 match=\"ip4.dst==%s && tcp "+
-				"&& tcp.dst==%v\" action=reject name=%s-%s\\:%v -- add port_group %s acls @reject-acl", service.Spec.ClusterIP, port.Port,
-				k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port, ovnClusterPortGroupUUID),
-		})
-	}
+// addServiceLBSyncCmds adds the commands reading the load balancers of the northbound
+// database, with the given CSV outputs, run when the load balancer manager first syncs
+func addServiceLBSyncCmds(fexec *ovntest.FakeExec, lbs, switches, routers string) {
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listServiceLBsCmd, Output: lbs})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listServiceLBSwitchesCmd, Output: switches})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{Cmd: listServiceLBRoutersCmd, Output: routers})
 }
 
-func (s service) delCmds(fexec *ovntest.FakeExec, service v1.Service) {
-	for _, port := range service.Spec.Ports {
-		fexec.AddFakeCmdsNoOutputNoError([]string{
-			fmt.Sprintf("ovn-nbctl --timeout=15 --if-exists remove load_balancer %s vips \"%s:%v\"", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
-			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=%s-%s\\:%v", k8sTCPLoadBalancerIP, service.Spec.ClusterIP, port.Port),
-			"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router options:chassis!=null",
-		})
-	}
+// addServiceSyncCmds adds the commands of the services sync on startup, when the
+// northbound database has the given load balancers and no reject ACL
+func addServiceSyncCmds(fexec *ovntest.FakeExec, lbs, switches, routers string) {
+	addServiceLBSyncCmds(fexec, lbs, switches, routers)
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl action=reject",
+	})
 }
 
 var _ = Describe("OVN Namespace Operations", func() {
@@ -181,8 +89,6 @@ var _ = Describe("OVN Namespace Operations", func() {
 		It("reconciles an existing service", func() {
 			app.Action = func(ctx *cli.Context) error {
 
-				test := service{}
-
 				service := *newService("service1", "namespace1", "10.129.0.2",
 					[]v1.ServicePort{
 						{
@@ -194,7 +100,35 @@ var _ = Describe("OVN Namespace Operations", func() {
 					nil,
 				)
 
-				test.addCmds(fExec, service)
+				// the load balancer shared by the services in previous versions and the
+				// load balancer of a deleted service are removed
+				addServiceLBSyncCmds(fExec,
+					k8sTCPLoadBalancerIP+",,tcp,k8s-cluster-lb-tcp=yes,\"172.30.0.10:53=10.128.0.18:5353,10.129.0.3:5353\",,,,\n"+
+						"stale_lb,Service_namespace1/service2_TCP_cluster,tcp,k8s-service=namespace1/service2,172.30.0.11:80=,reject=true,,,\n",
+					"node1,"+k8sTCPLoadBalancerIP+" stale_lb\n",
+					"")
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists lb-del " + k8sTCPLoadBalancerIP + " -- --if-exists lb-del stale_lb",
+				})
+				// so are the reject ACLs of the services without endpoints
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl action=reject",
+					Output: "reject_acl1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --format=csv --data=bare --no-heading --columns=name,acls list logical_switch",
+					Output: "node1,reject_acl1 other_acl\n",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists remove logical_switch node1 acls reject_acl1" +
+						" -- --if-exists remove port_group " + ovnClusterPortGroupUUID + " acls reject_acl1",
+				})
+				// the service has no endpoints, so its load balancer rejects the traffic
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"10.129.0.2:8032\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]",
+					Output: "lb_service1\n",
+				})
 
 				fakeOvn.start(ctx,
 					&v1.ServiceList{
@@ -220,8 +154,6 @@ var _ = Describe("OVN Namespace Operations", func() {
 		It("reconciles a deleted service", func() {
 			app.Action = func(ctx *cli.Context) error {
 
-				test := service{}
-
 				service := *newService("service1", "namespace1", "10.129.0.2",
 					[]v1.ServicePort{
 						{
@@ -233,7 +165,10 @@ var _ = Describe("OVN Namespace Operations", func() {
 					nil,
 				)
 
-				test.addCmds(fExec, service)
+				// the load balancer of the service is up to date
+				addServiceSyncCmds(fExec,
+					"lb_service1,Service_namespace1/service1_TCP_cluster,tcp,k8s-service=namespace1/service1,10.129.0.2:8032=,reject=true,,,\n",
+					"", "")
 
 				fakeOvn.start(ctx,
 					&v1.ServiceList{
@@ -242,14 +177,15 @@ var _ = Describe("OVN Namespace Operations", func() {
 						},
 					},
 				)
-				fakeOvn.controller.clusterPortGroupUUID = ovnClusterPortGroupUUID
 				fakeOvn.controller.WatchServices()
 
 				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
-				test.delCmds(fExec, service)
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists lb-del lb_service1",
+				})
 				err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Delete(context.TODO(), service.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
@@ -264,5 +200,387 @@ var _ = Describe("OVN Namespace Operations", func() {
 			err := app.Run([]string{app.Name})
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("configures the health checks of the endpoints of a service", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Annotations = map[string]string{OvnServiceHealthCheck: `{"interval": 10, "failureCount": 2}`}
+				endpoints := *newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP:        "10.128.1.3",
+							TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: "namespace1", Name: "pod1"},
+						},
+						{
+							// the logical port of this endpoint is not known, so it is not checked
+							IP:        "10.128.1.4",
+							TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: "namespace1", Name: "pod2"},
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				// the endpoint is checked from the management port of its node
+				addServiceSyncCmds(fExec, "", "node1,\n", "")
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@hc0 create load_balancer_health_check vip=\"10.129.0.2:8032\"" +
+						" options={\"failure_count\"=\"2\",\"interval\"=\"10\"}" +
+						" -- --id=@lb0 create load_balancer name=Service_namespace1/service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"10.129.0.2:8032\"=\"10.128.1.3:8080,10.128.1.4:8080\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[] ip_port_mappings={\"10.128.1.3\"=\"namespace1_pod1:10.128.1.2\"}" +
+						" health_check=[@hc0] -- add logical_switch node1 load_balancer @lb0",
+					Output: "hc_service1\nlb_service1\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpoints,
+						},
+					},
+				)
+				err := fakeOvn.controller.lsManager.AddNode("node1", []*net.IPNet{ovntest.MustParseIPNet("10.128.1.0/24")})
+				Expect(err).NotTo(HaveOccurred())
+				fakeOvn.controller.logicalPortCache.add("node1", "namespace1_pod1", "pod1-uuid",
+					ovntest.MustParseMAC("0a:58:0a:80:01:03"), []*net.IPNet{ovntest.MustParseIPNet("10.128.1.3/24")})
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchServices()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// removing the annotation removes the health checks
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_service1 ip_port_mappings={} health_check=[]",
+				})
+
+				service.Annotations = nil
+				_, err = fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Update(context.TODO(), &service, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("updates the load balancer options of a service with session affinity", func() {
+			app.Action = func(ctx *cli.Context) error {
+				timeout := int32(60)
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+				service.Spec.SessionAffinity = v1.ServiceAffinityClientIP
+				service.Spec.SessionAffinityConfig = &v1.SessionAffinityConfig{
+					ClientIP: &v1.ClientIPConfig{TimeoutSeconds: &timeout},
+				}
+
+				addServiceSyncCmds(fExec,
+					"lb_service1,Service_namespace1/service1_TCP_cluster,tcp,k8s-service=namespace1/service1,10.129.0.2:8032=,reject=true,,,\n",
+					"node1,lb_service1\n", "")
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_service1 vips={\"10.129.0.2:8032\"=\"\"}" +
+						" options={\"affinity_timeout\"=\"60\",\"reject\"=\"true\"} selection_fields=ip_src",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchServices()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// turning session affinity off resets the load balancer options
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- set load_balancer lb_service1 vips={\"10.129.0.2:8032\"=\"\"}" +
+						" options={\"reject\"=\"true\"} selection_fields=[]",
+				})
+
+				service.Spec.SessionAffinity = v1.ServiceAffinityNone
+				service.Spec.SessionAffinityConfig = nil
+				_, err := fakeOvn.fakeClient.KubeClient.CoreV1().Services(service.Namespace).Update(context.TODO(), &service, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("on node changes", func() {
+
+		It("applies the load balancers of the services to the nodes", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.NodeportEnable = true
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							NodePort: 31111,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				endpoints := *newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP: "10.125.0.2",
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				addServiceSyncCmds(fExec, "", "node1,\n", "GR_node1,\n")
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"10.129.0.2:8032\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/service1_TCP_node_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"169.254.33.2:31111\"=\"10.125.0.2:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb1 -- add logical_router GR_node1 load_balancer @lb1",
+					Output: "lb_cluster\nlb_node1\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpoints,
+						},
+					},
+				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchServices()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// a new node switch gets the cluster load balancer
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- add logical_switch node2 load_balancer lb_cluster",
+				})
+				fakeOvn.controller.addServiceLBNode("node2")
+				fakeOvn.controller.commitServiceLBNode("node2")
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				// the load balancer of a deleted node is deleted
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 -- --if-exists lb-del lb_node1",
+				})
+				fakeOvn.controller.deleteServiceLBNode("node1")
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("applies the changes of a node event to the load balancers of all the services in one transaction", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.NodeportEnable = true
+
+				nodePortService := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							NodePort: 31111,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				clusterIPService := *newService("service2", "namespace1", "10.129.0.3",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+					nil,
+				)
+
+				// the cluster load balancers of the services are up to date
+				addServiceSyncCmds(fExec,
+					"lb_c1,Service_namespace1/service1_TCP_cluster,tcp,k8s-service=namespace1/service1,10.129.0.2:8032=,reject=true,,,\n"+
+						"lb_c2,Service_namespace1/service2_TCP_cluster,tcp,k8s-service=namespace1/service2,10.129.0.3:80=,reject=true,,,\n",
+					"node1,lb_c1 lb_c2\n", "")
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							nodePortService,
+							clusterIPService,
+						},
+					},
+				)
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchServices()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// the switch and the gateway router of a new node get the load
+				// balancers of both services at once
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- add logical_switch node2 load_balancer lb_c1" +
+						" -- --id=@lb0 create load_balancer name=Service_namespace1/service1_TCP_node_node2 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"169.254.33.3:31111\"=\"\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node2 load_balancer @lb0 -- add logical_router GR_node2 load_balancer @lb0" +
+						" -- add logical_switch node2 load_balancer lb_c2",
+					Output: "lb_node2\n",
+				})
+				fakeOvn.controller.setServiceLBNodeZone("node2", "zone1")
+				fakeOvn.controller.addServiceLBNode("node2")
+				fakeOvn.controller.setServiceLBNodeGateway("node2", "GR_node2", []string{"169.254.33.3"})
+				fakeOvn.controller.commitServiceLBNode("node2")
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+				// only the node port service depends on the gateway of the node, and
+				// no service depends on its zone
+				Expect(serviceLBsDependOnNodeChange(&nodePortService, &serviceLBNode{hasSwitch: true},
+					&serviceLBNode{hasSwitch: true, gatewayRouter: "GR_node2"})).To(BeTrue())
+				Expect(serviceLBsDependOnNodeChange(&clusterIPService, &serviceLBNode{hasSwitch: true},
+					&serviceLBNode{hasSwitch: true, gatewayRouter: "GR_node2"})).To(BeFalse())
+				Expect(serviceLBsDependOnNodeChange(&nodePortService, &serviceLBNode{hasSwitch: true},
+					&serviceLBNode{hasSwitch: true, zone: "zone1"})).To(BeFalse())
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("load balances the node ports of Local external traffic policy services on the node switch gateway IP in local gateway mode", func() {
+			app.Action = func(ctx *cli.Context) error {
+				config.Gateway.NodeportEnable = true
+				config.Gateway.Mode = config.GatewayModeLocal
+				node1 := "node1"
+				node2 := "node2"
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Name:     "portTcp1",
+							Port:     8032,
+							NodePort: 31111,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+					nil,
+				)
+				service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+				endpoints := *newEndpoints("service1", "namespace1",
+					[]v1.EndpointAddress{
+						{
+							IP:       "10.128.1.5",
+							NodeName: &node1,
+						},
+						{
+							IP:       "10.128.2.5",
+							NodeName: &node2,
+						},
+					},
+					[]v1.EndpointPort{
+						{
+							Name:     "portTcp1",
+							Port:     8080,
+							Protocol: v1.ProtocolTCP,
+						},
+					})
+
+				addServiceSyncCmds(fExec, "", "node1,\n", "GR_node1,\n")
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd: "ovn-nbctl --timeout=15 -- --id=@lb0 create load_balancer name=Service_namespace1/service1_TCP_cluster protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"10.129.0.2:8032\"=\"10.128.1.5:8080,10.128.2.5:8080\"} options={\"reject\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb0" +
+						" -- --id=@lb1 create load_balancer name=Service_namespace1/service1_TCP_node_node1 protocol=tcp" +
+						" external_ids:k8s-service=\"namespace1/service1\" vips={\"10.128.1.1:31111\"=\"10.128.1.5:8080\",\"169.254.33.2:31111\"=\"10.128.1.5:8080\"}" +
+						" options={\"reject\"=\"true\",\"skip_snat\"=\"true\"} selection_fields=[]" +
+						" -- add logical_switch node1 load_balancer @lb1",
+					Output: "lb_cluster\nlb_node1\n",
+				})
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+					&v1.EndpointsList{
+						Items: []v1.Endpoints{
+							endpoints,
+						},
+					},
+				)
+				err := fakeOvn.controller.lsManager.AddNode("node1", []*net.IPNet{ovntest.MustParseIPNet("10.128.1.0/24")})
+				Expect(err).NotTo(HaveOccurred())
+				fakeOvn.controller.addServiceLBNode("node1")
+				fakeOvn.controller.setServiceLBNodeGateway("node1", "GR_node1", []string{"169.254.33.2"})
+				fakeOvn.controller.commitServiceLBNode("node1")
+				fakeOvn.controller.WatchServices()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 })
@@ -32,15 +32,28 @@ const (
 
 	NodeLocalSwitch = "node_local_switch"
 
+	// Layer3Topology is the topology of the secondary networks with a logical switch
+	// per node, connected by a cluster router
+	Layer3Topology = "layer3"
+	// Layer2Topology is the topology of the secondary networks with a single logical
+	// switch spanning all the nodes
+	Layer2Topology = "layer2"
+	// LocalnetTopology is the topology of the secondary networks with a single
+	// logical switch connected to a physical network through a localnet port
+	LocalnetTopology = "localnet"
+
 	// priority of logical router policies on the OVNClusterRouter
 	EgressFirewallStartPriority           = "10000"
 	MinimumReservedEgressFirewallPriority = "2000"
-	MGMTPortPolicyPriority                = "1005"
-	NodeSubnetPolicyPriority              = "1004"
-	InterNodePolicyPriority               = "1003"
-	HybridOverlayReroutePriority          = "501"
-	DefaultNoRereoutePriority             = "101"
-	EgressIPReroutePriority               = "100"
+	// EgressFirewallACLStartPriority is the priority of the ACL mirroring the first egress
+	// firewall rule, below the NetworkPolicy ACLs
+	EgressFirewallACLStartPriority = "999"
+	MGMTPortPolicyPriority         = "1005"
+	NodeSubnetPolicyPriority       = "1004"
+	InterNodePolicyPriority        = "1003"
+	HybridOverlayReroutePriority   = "501"
+	DefaultNoRereoutePriority      = "101"
+	EgressIPReroutePriority        = "100"
 
 	V6NodeLocalNATSubnet           = "fd99::/64"
 	V6NodeLocalNATSubnetPrefix     = 64
@@ -55,6 +68,10 @@ const (
 	V4JoinSubnetCIDR = "100.64.0.0/16"
 	V6JoinSubnetCIDR = "fd98::/64"
 
+	// EgressIPHealthCheckPath is the path of the endpoint which ovnkube-node serves on the
+	// egress IP node health check port
+	EgressIPHealthCheckPath = "/healthz"
+
 	// OpenFlow and Networking constants
 	RouteAdvertisementICMPType    = 134
 	NeighborAdvertisementICMPType = 136
@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -218,6 +219,33 @@ func (d *DNS) GetNextQueryTime() (time.Time, string, bool) {
 	return minTime, dns, timeSet
 }
 
+// IsWildcardDNSName returns true if the DNS name is a wildcard name such as *.example.com
+func IsWildcardDNSName(dnsName string) bool {
+	return strings.HasPrefix(dnsName, "*.")
+}
+
+// NormalizeDNSName returns the DNS name in lower case and without its trailing dot
+func NormalizeDNSName(dnsName string) string {
+	return strings.ToLower(strings.TrimSuffix(dnsName, "."))
+}
+
+// DNSNameMatchesWildcard returns true if the DNS name is a subdomain, at any depth, of the
+// domain of the wildcard DNS name. *.example.com matches www.example.com and
+// a.b.example.com but not example.com.
+func DNSNameMatchesWildcard(dnsName, wildcard string) bool {
+	if !IsWildcardDNSName(wildcard) {
+		return false
+	}
+	return strings.HasSuffix(NormalizeDNSName(dnsName), NormalizeDNSName(wildcard)[1:])
+}
+
+// GetEgressFirewallDNSAddressSetName returns the name of the address sets holding the IPs a
+// node learned for a wildcard DNS name. The master creates one address set per IP family,
+// named after it with a _v4 or _v6 suffix, and the node sets their addresses.
+func GetEgressFirewallDNSAddressSetName(wildcard, nodeName string) string {
+	return wildcard + "_" + nodeName
+}
+
 func ipsEqual(oldips, newips []net.IP) bool {
 	if len(oldips) != len(newips) {
 		return false
@@ -283,3 +283,48 @@ func TestAdd(t *testing.T) {
 	}
 
 }
+
+func TestDNSNameMatchesWildcard(t *testing.T) {
+	tests := []struct {
+		desc     string
+		dnsName  string
+		wildcard string
+		match    bool
+	}{
+		{
+			desc:     "matches a direct subdomain",
+			dnsName:  "www.example.com",
+			wildcard: "*.example.com",
+			match:    true,
+		},
+		{
+			desc:     "matches a nested subdomain and ignores the case and trailing dot",
+			dnsName:  "A.b.Example.com.",
+			wildcard: "*.example.com",
+			match:    true,
+		},
+		{
+			desc:     "does not match the domain itself",
+			dnsName:  "example.com",
+			wildcard: "*.example.com",
+			match:    false,
+		},
+		{
+			desc:     "does not match a domain sharing the suffix",
+			dnsName:  "www.badexample.com",
+			wildcard: "*.example.com",
+			match:    false,
+		},
+		{
+			desc:     "does not match with a concrete name",
+			dnsName:  "www.example.com",
+			wildcard: "www.example.com",
+			match:    false,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			assert.Equal(t, tc.match, DNSNameMatchesWildcard(tc.dnsName, tc.wildcard))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"hash/fnv"
+
+	egressipv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	kapi "k8s.io/api/core/v1"
+	utilnet "k8s.io/utils/net"
+)
+
+// SelectPodEgressIPStatuses spreads the pods matched by an EgressIP across all the egress
+// nodes it is assigned to. For each IP family it returns the assignment the pod egresses
+// through, picked by rendezvous hashing of the pod and the egress IPs: a pod keeps its
+// egress node while that node remains assigned, and only the pods of a node that loses
+// its assignment move to the remaining ones. The master reroutes the traffic of the pod
+// to the selected egress nodes, and the nodes hosting egress IPs on their secondary
+// egress interfaces SNAT it.
+func SelectPodEgressIPStatuses(pod *kapi.Pod, statusItems []egressipv1.EgressIPStatusItem) []egressipv1.EgressIPStatusItem {
+	var selectedV4, selectedV6 *egressipv1.EgressIPStatusItem
+	var scoreV4, scoreV6 uint32
+	for i := range statusItems {
+		item := &statusItems[i]
+		hash := fnv.New32a()
+		hash.Write([]byte(pod.Namespace + "_" + pod.Name + "/" + item.EgressIP))
+		score := hash.Sum32()
+		selected, selectedScore := &selectedV4, &scoreV4
+		if utilnet.IsIPv6String(item.EgressIP) {
+			selected, selectedScore = &selectedV6, &scoreV6
+		}
+		if *selected == nil || score > *selectedScore ||
+			(score == *selectedScore && item.EgressIP < (*selected).EgressIP) {
+			*selected, *selectedScore = item, score
+		}
+	}
+	statuses := []egressipv1.EgressIPStatusItem{}
+	for _, selected := range []*egressipv1.EgressIPStatusItem{selectedV4, selectedV6} {
+		if selected != nil {
+			statuses = append(statuses, *selected)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	networkattachmentdefinitionfake "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	adminnetworkpolicy "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1"
+	adminnetworkpolicyfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/clientset/versioned/fake"
+	adminpolicybasedroute "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1"
+	adminpolicybasedroutefake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/clientset/versioned/fake"
+	egressfirewall "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+	egressfirewallfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned/fake"
+	egressip "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1"
+	egressipfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned/fake"
+	egressqos "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1"
+	egressqosfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/clientset/versioned/fake"
+	ipamclaim "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1"
+	ipamclaimfake "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/clientset/versioned/fake"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// GetOVNClientset returns an OVNClientset made of fake clientsets for the tests. The lists of
+// the ovn-kubernetes CRDs are added to the fake clientset of their CRD, and all the other
+// objects to the fake kubernetes clientset.
+func GetOVNClientset(objects ...runtime.Object) *OVNClientset {
+	egressIPObjects := []runtime.Object{}
+	egressFirewallObjects := []runtime.Object{}
+	apbRouteObjects := []runtime.Object{}
+	anpObjects := []runtime.Object{}
+	egressQoSObjects := []runtime.Object{}
+	ipamClaimObjects := []runtime.Object{}
+	nads := []nadapi.NetworkAttachmentDefinition{}
+	v1Objects := []runtime.Object{}
+	for _, object := range objects {
+		switch o := object.(type) {
+		case *egressip.EgressIPList:
+			egressIPObjects = append(egressIPObjects, object)
+		case *egressfirewall.EgressFirewallList:
+			egressFirewallObjects = append(egressFirewallObjects, object)
+		case *adminpolicybasedroute.AdminPolicyBasedExternalRouteList:
+			apbRouteObjects = append(apbRouteObjects, object)
+		case *adminnetworkpolicy.AdminNetworkPolicyList:
+			anpObjects = append(anpObjects, object)
+		case *egressqos.EgressQoSList:
+			egressQoSObjects = append(egressQoSObjects, object)
+		case *ipamclaim.IPAMClaimList:
+			ipamClaimObjects = append(ipamClaimObjects, object)
+		case *nadapi.NetworkAttachmentDefinitionList:
+			nads = append(nads, o.Items...)
+		default:
+			v1Objects = append(v1Objects, object)
+		}
+	}
+	cs := &OVNClientset{
+		KubeClient:            fake.NewSimpleClientset(v1Objects...),
+		EgressIPClient:        egressipfake.NewSimpleClientset(egressIPObjects...),
+		EgressFirewallClient:  egressfirewallfake.NewSimpleClientset(egressFirewallObjects...),
+		APIExtensionsClient:   apiextensionsfake.NewSimpleClientset(),
+		APBRouteClient:        adminpolicybasedroutefake.NewSimpleClientset(apbRouteObjects...),
+		ANPClient:             adminnetworkpolicyfake.NewSimpleClientset(anpObjects...),
+		EgressQoSClient:       egressqosfake.NewSimpleClientset(egressQoSObjects...),
+		NetworkAttchDefClient: networkattachmentdefinitionfake.NewSimpleClientset(),
+		IPAMClaimClient:       ipamclaimfake.NewSimpleClientset(ipamClaimObjects...),
+	}
+	// the NetworkAttachmentDefinitions are created through the typed client,
+	// the object tracker does not guess their resource name
+	for i := range nads {
+		_, err := cs.NetworkAttchDefClient.K8sCniCncfIoV1().NetworkAttachmentDefinitions(nads[i].Namespace).Create(
+			context.TODO(), &nads[i], metav1.CreateOptions{})
+		if err != nil {
+			panic(fmt.Sprintf("failed to create NetworkAttachmentDefinition %s/%s: %v", nads[i].Namespace, nads[i].Name, err))
+		}
+	}
+	return cs
+}
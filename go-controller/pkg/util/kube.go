@@ -15,8 +15,13 @@ import (
 	"k8s.io/client-go/util/cert"
 	"k8s.io/klog/v2"
 
+	networkattchmentdefclientset "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
+	adminnetworkpolicyclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminnetworkpolicy/v1/apis/clientset/versioned"
+	adminpolicybasedrouteclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/adminpolicybasedroute/v1/apis/clientset/versioned"
 	egressfirewallclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1/apis/clientset/versioned"
 	egressipclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressip/v1/apis/clientset/versioned"
+	egressqosclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressqos/v1/apis/clientset/versioned"
+	ipamclaimclientset "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/ipamclaim/v1/apis/clientset/versioned"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
@@ -25,10 +30,15 @@ import (
 
 // OVNClientset is a wrapper around all clientsets used by OVN-Kubernetes
 type OVNClientset struct {
-	KubeClient           kubernetes.Interface
-	EgressIPClient       egressipclientset.Interface
-	EgressFirewallClient egressfirewallclientset.Interface
-	APIExtensionsClient  apiextensionsclientset.Interface
+	KubeClient            kubernetes.Interface
+	EgressIPClient        egressipclientset.Interface
+	EgressFirewallClient  egressfirewallclientset.Interface
+	APIExtensionsClient   apiextensionsclientset.Interface
+	APBRouteClient        adminpolicybasedrouteclientset.Interface
+	ANPClient             adminnetworkpolicyclientset.Interface
+	EgressQoSClient       egressqosclientset.Interface
+	NetworkAttchDefClient networkattchmentdefclientset.Interface
+	IPAMClaimClient       ipamclaimclientset.Interface
 }
 
 // newKubernetesRestConfig create a Kubernetes rest config from either a kubeconfig,
@@ -108,11 +118,36 @@ func NewOVNClientset(conf *config.KubernetesConfig) (*OVNClientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	apbRouteClientset, err := adminpolicybasedrouteclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+	anpClientset, err := adminnetworkpolicyclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+	egressQoSClientset, err := egressqosclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+	networkAttchmntDefClientset, err := networkattchmentdefclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
+	ipamClaimClientset, err := ipamclaimclientset.NewForConfig(kconfig)
+	if err != nil {
+		return nil, err
+	}
 	return &OVNClientset{
-		KubeClient:           kclientset,
-		EgressIPClient:       egressIPClientset,
-		EgressFirewallClient: egressFirewallClientset,
-		APIExtensionsClient:  crdClientset,
+		KubeClient:            kclientset,
+		EgressIPClient:        egressIPClientset,
+		EgressFirewallClient:  egressFirewallClientset,
+		APIExtensionsClient:   crdClientset,
+		APBRouteClient:        apbRouteClientset,
+		ANPClient:             anpClientset,
+		EgressQoSClient:       egressQoSClientset,
+		NetworkAttchDefClient: networkAttchmntDefClientset,
+		IPAMClaimClient:       ipamClaimClientset,
 	}, nil
 }
 
@@ -147,6 +182,33 @@ func ServiceTypeHasNodePort(service *kapi.Service) bool {
 	return service.Spec.Type == kapi.ServiceTypeNodePort || service.Spec.Type == kapi.ServiceTypeLoadBalancer
 }
 
+// ServiceSessionAffinityTimeout returns the ClientIP session affinity timeout of the
+// service in seconds, or 0 if the service does not use session affinity
+func ServiceSessionAffinityTimeout(service *kapi.Service) int32 {
+	if service.Spec.SessionAffinity != kapi.ServiceAffinityClientIP {
+		return 0
+	}
+	if cfg := service.Spec.SessionAffinityConfig; cfg != nil && cfg.ClientIP != nil &&
+		cfg.ClientIP.TimeoutSeconds != nil && *cfg.ClientIP.TimeoutSeconds > 0 {
+		return *cfg.ClientIP.TimeoutSeconds
+	}
+	return kapi.DefaultClientIPServiceAffinitySeconds
+}
+
+// ServiceExternalTrafficPolicyLocal checks if the service only routes external traffic
+// to node-local endpoints
+func ServiceExternalTrafficPolicyLocal(service *kapi.Service) bool {
+	return ServiceTypeHasNodePort(service) &&
+		service.Spec.ExternalTrafficPolicy == kapi.ServiceExternalTrafficPolicyTypeLocal
+}
+
+// ServiceInternalTrafficPolicyLocal checks if the service only routes the traffic
+// sent to its cluster IPs to node-local endpoints
+func ServiceInternalTrafficPolicyLocal(service *kapi.Service) bool {
+	return service.Spec.InternalTrafficPolicy != nil &&
+		*service.Spec.InternalTrafficPolicy == kapi.ServiceInternalTrafficPolicyLocal
+}
+
 // GetNodePrimaryIP extracts the primary IP address from the node status in the  API
 func GetNodePrimaryIP(node *kapi.Node) (string, error) {
 	for _, addr := range node.Status.Addresses {
@@ -185,6 +247,7 @@ const (
 //
 // Note that the changes below is based on following assumptions, which is true today.
 // - a pod's default network is OVN managed
+// - a network without namespace belongs to the namespace of the pod
 func GetPodNetSelAnnotation(pod *kapi.Pod, netAttachAnnot string) ([]*types.NetworkSelectionElement, error) {
 	var networkAnnotation string
 	var networks []*types.NetworkSelectionElement
@@ -195,16 +258,45 @@ func GetPodNetSelAnnotation(pod *kapi.Pod, netAttachAnnot string) ([]*types.Netw
 		return nil, nil
 	}
 
-	// it is possible the default network is defined in the form of comma-delimited list of
-	// network attachment resource names (i.e. list of <namespace>/<network name>@<ifname>), but
-	// we are only interested in the NetworkSelectionElement json form that has custom MAC/IP
+	// the networks are either a JSON list of NetworkSelectionElements, or a comma-delimited
+	// list of network attachment resource names (i.e. list of <namespace>/<network name>@<ifname>)
 	if json.Valid([]byte(networkAnnotation)) {
 		if err := json.Unmarshal([]byte(networkAnnotation), &networks); err != nil {
 			return nil, fmt.Errorf("failed to parse pod's net-attach-definition JSON %q: %v", networkAnnotation, err)
 		}
-	} else {
-		// nothing special to do
-		return nil, nil
+		for _, network := range networks {
+			if network.Namespace == "" {
+				network.Namespace = pod.Namespace
+			}
+		}
+		return networks, nil
+	}
+
+	for _, item := range strings.Split(networkAnnotation, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		network := &types.NetworkSelectionElement{Namespace: pod.Namespace}
+		if i := strings.LastIndex(item, "@"); i >= 0 {
+			network.InterfaceRequest = item[i+1:]
+			item = item[:i]
+		}
+		parts := strings.Split(item, "/")
+		switch len(parts) {
+		case 1:
+			network.Name = parts[0]
+		case 2:
+			network.Namespace = parts[0]
+			network.Name = parts[1]
+		default:
+			return nil, fmt.Errorf("invalid network attachment %q in pod's net-attach-definition %q",
+				item, networkAnnotation)
+		}
+		if network.Name == "" {
+			return nil, fmt.Errorf("missing network name in pod's net-attach-definition %q", networkAnnotation)
+		}
+		networks = append(networks, network)
 	}
 
 	return networks, nil
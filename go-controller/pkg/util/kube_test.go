@@ -240,6 +240,165 @@ func TestServiceTypeHasNodePort(t *testing.T) {
 	}
 }
 
+func TestServiceSessionAffinityTimeout(t *testing.T) {
+	timeout := int32(60)
+	tests := []struct {
+		desc   string
+		inp    v1.Service
+		expOut int32
+	}{
+		{
+			desc: "0: test when SessionAffinity is not set",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{},
+			},
+			expOut: 0,
+		},
+		{
+			desc: "0: test when SessionAffinity set to `None`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					SessionAffinity: v1.ServiceAffinityNone,
+				},
+			},
+			expOut: 0,
+		},
+		{
+			desc: "default: test when SessionAffinity set to `ClientIP` without a config",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					SessionAffinity: v1.ServiceAffinityClientIP,
+				},
+			},
+			expOut: v1.DefaultClientIPServiceAffinitySeconds,
+		},
+		{
+			desc: "60: test when SessionAffinity set to `ClientIP` with a timeout",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					SessionAffinity: v1.ServiceAffinityClientIP,
+					SessionAffinityConfig: &v1.SessionAffinityConfig{
+						ClientIP: &v1.ClientIPConfig{
+							TimeoutSeconds: &timeout,
+						},
+					},
+				},
+			},
+			expOut: timeout,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := ServiceSessionAffinityTimeout(&tc.inp)
+			assert.Equal(t, res, tc.expOut)
+		})
+	}
+}
+
+func TestServiceExternalTrafficPolicyLocal(t *testing.T) {
+	tests := []struct {
+		desc   string
+		inp    v1.Service
+		expOut bool
+	}{
+		{
+			desc: "false: test when the service is of type ClusterIP",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeClusterIP,
+					ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeLocal,
+				},
+			},
+			expOut: false,
+		},
+		{
+			desc: "false: test when ExternalTrafficPolicy set to `Cluster`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeNodePort,
+					ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeCluster,
+				},
+			},
+			expOut: false,
+		},
+		{
+			desc: "true: test when a NodePort service sets ExternalTrafficPolicy to `Local`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeNodePort,
+					ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeLocal,
+				},
+			},
+			expOut: true,
+		},
+		{
+			desc: "true: test when a LoadBalancer service sets ExternalTrafficPolicy to `Local`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeLocal,
+				},
+			},
+			expOut: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := ServiceExternalTrafficPolicyLocal(&tc.inp)
+			assert.Equal(t, res, tc.expOut)
+		})
+	}
+}
+
+func TestServiceInternalTrafficPolicyLocal(t *testing.T) {
+	local := v1.ServiceInternalTrafficPolicyLocal
+	cluster := v1.ServiceInternalTrafficPolicyCluster
+	tests := []struct {
+		desc   string
+		inp    v1.Service
+		expOut bool
+	}{
+		{
+			desc: "false: test when InternalTrafficPolicy is not set",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type: v1.ServiceTypeClusterIP,
+				},
+			},
+			expOut: false,
+		},
+		{
+			desc: "false: test when InternalTrafficPolicy set to `Cluster`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeClusterIP,
+					InternalTrafficPolicy: &cluster,
+				},
+			},
+			expOut: false,
+		},
+		{
+			desc: "true: test when InternalTrafficPolicy set to `Local`",
+			inp: v1.Service{
+				Spec: v1.ServiceSpec{
+					Type:                  v1.ServiceTypeClusterIP,
+					InternalTrafficPolicy: &local,
+				},
+			},
+			expOut: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			res := ServiceInternalTrafficPolicyLocal(&tc.inp)
+			assert.Equal(t, res, tc.expOut)
+		})
+	}
+}
+
 func TestGetNodePrimaryIP(t *testing.T) {
 	tests := []struct {
 		desc   string
@@ -321,6 +480,43 @@ func TestGetPodNetSelAnnotation(t *testing.T) {
 			inpNetAnnotation: "k8s.ovn.org/pod-networks",
 			expErr:           true,
 		},
+		{
+			desc: "JSON network selection defaults to the pod namespace",
+			inpPod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "ns1",
+					Annotations: map[string]string{NetworkAttachmentAnnotation: `[{"name":"blue","mac":"0a:58:fd:98:00:01"}]`},
+				},
+			},
+			inpNetAnnotation: NetworkAttachmentAnnotation,
+			expOutput: []*types.NetworkSelectionElement{
+				{Name: "blue", Namespace: "ns1", MacRequest: "0a:58:fd:98:00:01"},
+			},
+		},
+		{
+			desc: "comma-delimited network selection",
+			inpPod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "ns1",
+					Annotations: map[string]string{NetworkAttachmentAnnotation: "blue, ns2/red@net2"},
+				},
+			},
+			inpNetAnnotation: NetworkAttachmentAnnotation,
+			expOutput: []*types.NetworkSelectionElement{
+				{Name: "blue", Namespace: "ns1"},
+				{Name: "red", Namespace: "ns2", InterfaceRequest: "net2"},
+			},
+		},
+		{
+			desc: "invalid comma-delimited network selection",
+			inpPod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{NetworkAttachmentAnnotation: "ns1/blue/red"},
+				},
+			},
+			inpNetAnnotation: NetworkAttachmentAnnotation,
+			expErr:           true,
+		},
 	}
 	for i, tc := range tests {
 		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
@@ -330,7 +526,7 @@ func TestGetPodNetSelAnnotation(t *testing.T) {
 				assert.Error(t, e)
 			}
 			if tc.expOutput != nil {
-				assert.Greater(t, len(res), 0)
+				assert.Equal(t, tc.expOutput, res)
 			}
 		})
 	}
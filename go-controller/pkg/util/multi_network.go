@@ -0,0 +1,152 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	nadapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+
+	ovncnitypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/types"
+)
+
+// NetInfo is the configuration of an OVN-managed secondary network, shared by
+// all the NetworkAttachmentDefinitions of the network
+type NetInfo struct {
+	// NetName is the name of the network, the name of its CNI configuration
+	NetName string
+	// Topology is types.Layer3Topology, types.Layer2Topology or types.LocalnetTopology
+	Topology string
+	// Subnets of the network; their HostSubnetLength is the length of the
+	// node subnets with the layer3 topology, and is not used with the others.
+	// A localnet network without subnets has no IPAM.
+	Subnets []config.CIDRNetworkEntry
+	// MTU of the pod interfaces, 0 for the MTU of the default network
+	MTU int
+	// PhysicalNetworkName and VLANID are the physical network and the VLAN tag
+	// of the localnet topology
+	PhysicalNetworkName string
+	VLANID              int
+}
+
+// GetNADName returns the <namespace>/<name> key of a NetworkAttachmentDefinition,
+// which is also the key of the pod annotation entry of the network
+func GetNADName(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// GetSecondaryNetworkPrefix returns the prefix of the names of the OVN objects of
+// a secondary network
+func GetSecondaryNetworkPrefix(netName string) string {
+	return netName + "_"
+}
+
+// GetSecondaryNetworkLogicalPortName returns the name of the logical switch port
+// of a pod on a secondary network, attached through the NetworkAttachmentDefinition
+// nadName; it is also the iface-id of the OVS interface of the pod
+func GetSecondaryNetworkLogicalPortName(netName, nadName, podNamespace, podName string) string {
+	return GetSecondaryNetworkPrefix(netName) + strings.Replace(nadName, "/", ".", 1) +
+		"_" + podNamespace + "_" + podName
+}
+
+// ParseNADNetConf returns the CNI configuration of a NetworkAttachmentDefinition,
+// or nil if the NetworkAttachmentDefinition is not an OVN-managed secondary network
+func ParseNADNetConf(nad *nadapi.NetworkAttachmentDefinition) (*ovncnitypes.NetConf, error) {
+	netconf := &ovncnitypes.NetConf{}
+	if err := json.Unmarshal([]byte(nad.Spec.Config), netconf); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the CNI config of %s/%s: %v",
+			nad.Namespace, nad.Name, err)
+	}
+	if netconf.Type != config.CNI.Plugin || netconf.Topology == "" {
+		return nil, nil
+	}
+	if netconf.NADName != GetNADName(nad.Namespace, nad.Name) {
+		return nil, fmt.Errorf("netAttachDefName %q of the CNI config of %s/%s does not match it",
+			netconf.NADName, nad.Namespace, nad.Name)
+	}
+	return netconf, nil
+}
+
+// NewNetInfo returns the NetInfo of the CNI configuration of an OVN-managed
+// secondary network
+func NewNetInfo(netconf *ovncnitypes.NetConf) (*NetInfo, error) {
+	// the names of the OVN objects of the network must not clash with the ones
+	// of the default network
+	prefix := GetSecondaryNetworkPrefix(netconf.Name)
+	if netconf.Name == "" || netconf.Name == OvnPodDefaultNetwork ||
+		prefix == types.JoinSwitchPrefix || prefix == types.ExternalSwitchPrefix {
+		return nil, fmt.Errorf("invalid network name %q", netconf.Name)
+	}
+	if netconf.Subnets == "" && netconf.Topology != types.LocalnetTopology {
+		return nil, fmt.Errorf("missing subnets of network %s", netconf.Name)
+	}
+
+	netInfo := &NetInfo{
+		NetName:  netconf.Name,
+		Topology: netconf.Topology,
+		MTU:      netconf.MTU,
+	}
+	var err error
+	switch netconf.Topology {
+	case types.Layer3Topology:
+		netInfo.Subnets, err = config.ParseClusterSubnetEntries(netconf.Subnets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnets %q of network %s: %v", netconf.Subnets, netconf.Name, err)
+		}
+	case types.Layer2Topology, types.LocalnetTopology:
+		if netconf.Subnets != "" {
+			subnets, err := ParseIPNets(netconf.Subnets)
+			if err != nil {
+				return nil, fmt.Errorf("invalid subnets %q of network %s: %v", netconf.Subnets, netconf.Name, err)
+			}
+			for _, subnet := range subnets {
+				netInfo.Subnets = append(netInfo.Subnets, config.CIDRNetworkEntry{CIDR: subnet})
+			}
+		}
+		if netconf.Topology == types.LocalnetTopology {
+			netInfo.PhysicalNetworkName = netconf.PhysicalNetworkName
+			if netInfo.PhysicalNetworkName == "" {
+				netInfo.PhysicalNetworkName = netconf.Name
+			}
+			if netconf.VLANID < 0 || netconf.VLANID > 4094 {
+				return nil, fmt.Errorf("invalid VLAN ID %d of network %s", netconf.VLANID, netconf.Name)
+			}
+			netInfo.VLANID = netconf.VLANID
+		}
+	default:
+		return nil, fmt.Errorf("unsupported topology %q of network %s", netconf.Topology, netconf.Name)
+	}
+	return netInfo, nil
+}
+
+// Equals returns true if the two NetInfos describe the same network
+func (netInfo *NetInfo) Equals(other *NetInfo) bool {
+	if netInfo.NetName != other.NetName || netInfo.Topology != other.Topology ||
+		netInfo.MTU != other.MTU || len(netInfo.Subnets) != len(other.Subnets) ||
+		netInfo.PhysicalNetworkName != other.PhysicalNetworkName || netInfo.VLANID != other.VLANID {
+		return false
+	}
+	for i, subnet := range netInfo.Subnets {
+		if subnet.CIDR.String() != other.Subnets[i].CIDR.String() ||
+			subnet.HostSubnetLength != other.Subnets[i].HostSubnetLength {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseIPNets parses a comma separated list of CIDRs
+func ParseIPNets(cidrs string) ([]*net.IPNet, error) {
+	var ipnets []*net.IPNet
+	for _, cidr := range strings.Split(cidrs, ",") {
+		_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		ipnets = append(ipnets, ipnet)
+	}
+	return ipnets, nil
+}
@@ -282,44 +282,134 @@ func LinkNeighExists(link netlink.Link, neighIP net.IP, neighMAC net.HardwareAdd
 	return false, nil
 }
 
-func DeleteConntrack(ip string, port int32, protocol kapi.Protocol) error {
+// DeleteConntrack deletes the conntrack entries of the connections whose reply has the
+// given IP address as source or destination, and returns the number of deleted entries
+func DeleteConntrack(ip string, port int32, protocol kapi.Protocol) (uint, error) {
 	ipAddress := net.ParseIP(ip)
 	if ipAddress == nil {
-		return fmt.Errorf("value %q passed to DeleteConntrack is not an IP address", ipAddress)
+		return 0, fmt.Errorf("value %q passed to DeleteConntrack is not an IP address", ipAddress)
 	}
 
 	filter := &netlink.ConntrackFilter{}
 	if protocol == kapi.ProtocolUDP {
 		// 17 = UDP protocol
 		if err := filter.AddProtocol(17); err != nil {
-			return fmt.Errorf("could not add Protocol UDP to conntrack filter %v", err)
+			return 0, fmt.Errorf("could not add Protocol UDP to conntrack filter %v", err)
 		}
 	} else if protocol == kapi.ProtocolSCTP {
 		// 132 = SCTP protocol
 		if err := filter.AddProtocol(132); err != nil {
-			return fmt.Errorf("could not add Protocol SCTP to conntrack filter %v", err)
+			return 0, fmt.Errorf("could not add Protocol SCTP to conntrack filter %v", err)
 		}
 	}
 	if port > 0 {
 		if err := filter.AddPort(netlink.ConntrackOrigDstPort, uint16(port)); err != nil {
-			return fmt.Errorf("could not add port %d to conntrack filter: %v", port, err)
+			return 0, fmt.Errorf("could not add port %d to conntrack filter: %v", port, err)
 		}
 	}
 	if err := filter.AddIP(netlink.ConntrackReplyAnyIP, ipAddress); err != nil {
-		return fmt.Errorf("could not add IP: %s to conntrack filter: %v", ipAddress, err)
+		return 0, fmt.Errorf("could not add IP: %s to conntrack filter: %v", ipAddress, err)
 	}
 	if ipAddress.To4() != nil {
-		if _, err := netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.FAMILY_V4, filter); err != nil {
-			return err
+		return netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.FAMILY_V4, filter)
+	}
+	return netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.FAMILY_V6, filter)
+}
+
+// addConntrackProtocol adds the layer 4 protocol of a service port to a conntrack filter
+func addConntrackProtocol(filter *netlink.ConntrackFilter, protocol kapi.Protocol) error {
+	switch protocol {
+	case kapi.ProtocolTCP:
+		// 6 = TCP protocol
+		if err := filter.AddProtocol(6); err != nil {
+			return fmt.Errorf("could not add Protocol TCP to conntrack filter %v", err)
 		}
-	} else {
-		if _, err := netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.FAMILY_V6, filter); err != nil {
-			return err
+	case kapi.ProtocolUDP:
+		if err := filter.AddProtocol(17); err != nil {
+			return fmt.Errorf("could not add Protocol UDP to conntrack filter %v", err)
+		}
+	case kapi.ProtocolSCTP:
+		if err := filter.AddProtocol(132); err != nil {
+			return fmt.Errorf("could not add Protocol SCTP to conntrack filter %v", err)
 		}
+	default:
+		return fmt.Errorf("unsupported protocol %q for conntrack filter", protocol)
 	}
 	return nil
 }
 
+// DeleteConntrackDst deletes the conntrack entries of the connections whose original
+// destination is the given IP address and port, e.g. an external IP that a service no
+// longer uses
+func DeleteConntrackDst(ip string, port int32, protocol kapi.Protocol) error {
+	ipAddress := net.ParseIP(ip)
+	if ipAddress == nil {
+		return fmt.Errorf("value %q passed to DeleteConntrackDst is not an IP address", ip)
+	}
+
+	filter := &netlink.ConntrackFilter{}
+	if err := addConntrackProtocol(filter, protocol); err != nil {
+		return err
+	}
+	if err := filter.AddPort(netlink.ConntrackOrigDstPort, uint16(port)); err != nil {
+		return fmt.Errorf("could not add port %d to conntrack filter: %v", port, err)
+	}
+	if err := filter.AddIP(netlink.ConntrackOrigDstIP, ipAddress); err != nil {
+		return fmt.Errorf("could not add IP: %s to conntrack filter: %v", ipAddress, err)
+	}
+	if _, err := netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.InetFamily(getFamily(ipAddress)), filter); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteConntrackServiceVIP deletes the conntrack entries, in any conntrack zone, of the
+// connections sent to a service VIP and port that were DNAT'ed to the given backend IP,
+// and returns the number of deleted entries. An empty vip matches any destination IP,
+// e.g. for the node port of a service that is DNAT'ed on all the node IPs, and an empty
+// backendIP matches any backend, e.g. when the service is deleted.
+func DeleteConntrackServiceVIP(vip string, port int32, protocol kapi.Protocol, backendIP string) (uint, error) {
+	filter := &netlink.ConntrackFilter{}
+	if err := addConntrackProtocol(filter, protocol); err != nil {
+		return 0, err
+	}
+	if err := filter.AddPort(netlink.ConntrackOrigDstPort, uint16(port)); err != nil {
+		return 0, fmt.Errorf("could not add port %d to conntrack filter: %v", port, err)
+	}
+	// the entries are deleted from the family of the given addresses, or from both
+	// families when none is given
+	families := []int{netlink.FAMILY_V4, netlink.FAMILY_V6}
+	if vip != "" {
+		vipAddress := net.ParseIP(vip)
+		if vipAddress == nil {
+			return 0, fmt.Errorf("value %q passed to DeleteConntrackServiceVIP is not an IP address", vip)
+		}
+		if err := filter.AddIP(netlink.ConntrackOrigDstIP, vipAddress); err != nil {
+			return 0, fmt.Errorf("could not add IP: %s to conntrack filter: %v", vipAddress, err)
+		}
+		families = []int{getFamily(vipAddress)}
+	}
+	if backendIP != "" {
+		backendAddress := net.ParseIP(backendIP)
+		if backendAddress == nil {
+			return 0, fmt.Errorf("value %q passed to DeleteConntrackServiceVIP is not an IP address", backendIP)
+		}
+		if err := filter.AddIP(netlink.ConntrackReplySrcIP, backendAddress); err != nil {
+			return 0, fmt.Errorf("could not add IP: %s to conntrack filter: %v", backendAddress, err)
+		}
+		families = []int{getFamily(backendAddress)}
+	}
+	var deleted uint
+	for _, family := range families {
+		count, err := netLinkOps.ConntrackDeleteFilter(netlink.ConntrackTable, netlink.InetFamily(family), filter)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
 // GetNetworkInterfaceIPs returns the IP addresses for the network interface 'iface'.
 func GetNetworkInterfaceIPs(iface string) ([]*net.IPNet, error) {
 	link, err := netLinkOps.LinkByName(iface)
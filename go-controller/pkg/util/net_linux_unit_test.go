@@ -828,7 +828,7 @@ func TestDeleteConntrack(t *testing.T) {
 		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
 			ovntest.ProcessMockFnList(&mockNetLinkOps.Mock, tc.onRetArgsNetLinkLibOpers)
 
-			err := DeleteConntrack(tc.inputIPStr, tc.inputPort, tc.inputProtocol)
+			_, err := DeleteConntrack(tc.inputIPStr, tc.inputPort, tc.inputProtocol)
 			if tc.errExp {
 				assert.Error(t, err)
 			} else {
@@ -838,3 +838,167 @@ func TestDeleteConntrack(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteConntrackDst(t *testing.T) {
+	mockNetLinkOps := new(mocks.NetLinkOps)
+	// below is defined in net_linux.go
+	netLinkOps = mockNetLinkOps
+	tests := []struct {
+		desc                     string
+		errExp                   bool
+		inputIPStr               string
+		inputPort                int32
+		inputProtocol            kapi.Protocol
+		onRetArgsNetLinkLibOpers []ovntest.TestifyMockHelper
+	}{
+		{
+			desc:          "Invalid IP address code input",
+			inputIPStr:    "blah",
+			inputPort:     8080,
+			inputProtocol: kapi.ProtocolTCP,
+			errExp:        true,
+		},
+		{
+			desc:       "Valid IPv4 address input with NO layer 4 protocol input",
+			inputIPStr: "192.168.1.14",
+			inputPort:  8080,
+			errExp:     true,
+		},
+		{
+			desc:          "Valid IPv4 address input with TCP protocol",
+			inputIPStr:    "192.168.1.14",
+			inputPort:     8080,
+			inputProtocol: kapi.ProtocolTCP,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(1), nil}},
+			},
+		},
+		{
+			desc:          "Valid IPv6 address input with UDP protocol",
+			inputIPStr:    "fffb::1",
+			inputPort:     53,
+			inputProtocol: kapi.ProtocolUDP,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(1), nil}},
+			},
+		},
+		{
+			desc:          "Conntrack delete failure",
+			inputIPStr:    "192.168.1.14",
+			inputPort:     9999,
+			inputProtocol: kapi.ProtocolSCTP,
+			errExp:        true,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(0), fmt.Errorf("mock error")}},
+			},
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			ovntest.ProcessMockFnList(&mockNetLinkOps.Mock, tc.onRetArgsNetLinkLibOpers)
+
+			err := DeleteConntrackDst(tc.inputIPStr, tc.inputPort, tc.inputProtocol)
+			if tc.errExp {
+				assert.Error(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockNetLinkOps.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteConntrackServiceVIP(t *testing.T) {
+	mockNetLinkOps := new(mocks.NetLinkOps)
+	// below is defined in net_linux.go
+	netLinkOps = mockNetLinkOps
+	tests := []struct {
+		desc                     string
+		errExp                   bool
+		inputVIP                 string
+		inputPort                int32
+		inputProtocol            kapi.Protocol
+		inputBackendIP           string
+		outExp                   uint
+		onRetArgsNetLinkLibOpers []ovntest.TestifyMockHelper
+	}{
+		{
+			desc:           "Invalid backend IP address input",
+			inputVIP:       "10.96.0.10",
+			inputPort:      53,
+			inputProtocol:  kapi.ProtocolUDP,
+			inputBackendIP: "blah",
+			errExp:         true,
+		},
+		{
+			desc:           "Invalid VIP address input",
+			inputVIP:       "blah",
+			inputPort:      53,
+			inputProtocol:  kapi.ProtocolUDP,
+			inputBackendIP: "10.244.0.5",
+			errExp:         true,
+		},
+		{
+			desc:           "Valid IPv4 VIP input with NO layer 4 protocol input",
+			inputVIP:       "10.96.0.10",
+			inputPort:      53,
+			inputBackendIP: "10.244.0.5",
+			errExp:         true,
+		},
+		{
+			desc:           "Valid IPv4 VIP input with UDP protocol",
+			inputVIP:       "10.96.0.10",
+			inputPort:      53,
+			inputProtocol:  kapi.ProtocolUDP,
+			inputBackendIP: "10.244.0.5",
+			outExp:         2,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(2), nil}},
+			},
+		},
+		{
+			desc:          "Service VIP input without backend IP with UDP protocol",
+			inputVIP:      "10.96.0.10",
+			inputPort:     53,
+			inputProtocol: kapi.ProtocolUDP,
+			outExp:        3,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(3), nil}},
+			},
+		},
+		{
+			desc:          "Node port input without VIP and backend IP deletes the entries of both families",
+			inputPort:     30053,
+			inputProtocol: kapi.ProtocolUDP,
+			outExp:        3,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(1), nil}},
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(2), nil}},
+			},
+		},
+		{
+			desc:           "Node port input without VIP with SCTP protocol",
+			inputPort:      30053,
+			inputProtocol:  kapi.ProtocolSCTP,
+			inputBackendIP: "fd00:10:244::5",
+			outExp:         1,
+			onRetArgsNetLinkLibOpers: []ovntest.TestifyMockHelper{
+				{OnCallMethodName: "ConntrackDeleteFilter", OnCallMethodArgType: []string{"netlink.ConntrackTableType", "netlink.InetFamily", "*netlink.ConntrackFilter"}, RetArgList: []interface{}{uint(1), nil}},
+			},
+		},
+	}
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.desc), func(t *testing.T) {
+			ovntest.ProcessMockFnList(&mockNetLinkOps.Mock, tc.onRetArgsNetLinkLibOpers)
+
+			res, err := DeleteConntrackServiceVIP(tc.inputVIP, tc.inputPort, tc.inputProtocol, tc.inputBackendIP)
+			if tc.errExp {
+				assert.Error(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.outExp, res)
+			}
+			mockNetLinkOps.AssertExpectations(t)
+		})
+	}
+}
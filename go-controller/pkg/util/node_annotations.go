@@ -55,6 +55,10 @@ const (
 	// ovnNodeCIDR is the CIDR form representation of primary network interface's attached IP address (i.e: 192.168.126.31/24 or 0:0:0:0:0:feff:c0a8:8e0c/64)
 	ovnNodeIfAddr = "k8s.ovn.org/node-primary-ifaddr"
 
+	// ovnNodeEgressNetworks lists the host networks, besides the one of the primary network
+	// interface, on which the node can host egress IPs
+	ovnNodeEgressNetworks = "k8s.ovn.org/node-egress-networks"
+
 	// OvnNodeEgressLabel is a user assigned node label indicating to ovn-kubernetes that the node is to be used for egress IP assignment
 	ovnNodeEgressLabel = "k8s.ovn.org/egress-assignable"
 )
@@ -275,3 +279,43 @@ func ParseNodePrimaryIfAddr(node *kapi.Node) (string, string, error) {
 func GetNodeEgressLabel() string {
 	return ovnNodeEgressLabel
 }
+
+// NodeEgressNetwork is a host network, besides the one of the primary network interface,
+// on which a node can host egress IPs
+type NodeEgressNetwork struct {
+	// Interface is the name of the host interface attached to the network
+	Interface string `json:"interface"`
+	// Address is the CIDR form representation of the interface address on the network
+	Address string `json:"address"`
+}
+
+// SetNodeEgressNetworks sets the host networks, besides the one of the primary network
+// interface, on which the node can host egress IPs, or removes the annotation when there
+// are none
+func SetNodeEgressNetworks(nodeAnnotator kube.Annotator, networks []NodeEgressNetwork) error {
+	if len(networks) == 0 {
+		nodeAnnotator.Delete(ovnNodeEgressNetworks)
+		return nil
+	}
+	return nodeAnnotator.Set(ovnNodeEgressNetworks, networks)
+}
+
+// ParseNodeEgressNetworks returns the host networks, besides the one of the primary network
+// interface, on which the node can host egress IPs
+func ParseNodeEgressNetworks(node *kapi.Node) ([]NodeEgressNetwork, error) {
+	annotation, ok := node.Annotations[ovnNodeEgressNetworks]
+	if !ok {
+		return nil, nil
+	}
+	networks := []NodeEgressNetwork{}
+	if err := json.Unmarshal([]byte(annotation), &networks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal annotation: %s for node %q, err: %v", ovnNodeEgressNetworks, node.Name, err)
+	}
+	for _, network := range networks {
+		if _, _, err := net.ParseCIDR(network.Address); err != nil {
+			return nil, fmt.Errorf("invalid address %q of interface %s in annotation: %s for node %q: %v",
+				network.Address, network.Interface, ovnNodeEgressNetworks, node.Name, err)
+		}
+	}
+	return networks, nil
+}
@@ -35,6 +35,10 @@ import (
 // additional network attachment that claims the default route, then the "default" network
 // will have explicit routes to the cluster and service subnets.)
 //
+// A pod attached to OVN-managed secondary networks has an additional entry per
+// network, keyed by the <namespace>/<name> of the NetworkAttachmentDefinition the
+// pod selected, e.g. "ns1/blue".
+//
 // The "ip_address" and "gateway_ip" fields are deprecated and will eventually go away.
 // (And they are not output when "ip_addresses" or "gateway_ips" contains multiple
 // values.)
@@ -88,6 +92,22 @@ type podRoute struct {
 // MarshalPodAnnotation returns a JSON-formatted annotation describing the pod's
 // network details
 func MarshalPodAnnotation(podInfo *PodAnnotation) (map[string]string, error) {
+	return MarshalPodAnnotationForNetwork(nil, podInfo, OvnPodDefaultNetwork)
+}
+
+// MarshalPodAnnotationForNetwork returns a JSON-formatted annotation describing the
+// pod's network details on the network nadName, OvnPodDefaultNetwork or the
+// <namespace>/<name> of a NetworkAttachmentDefinition, along with the details of
+// the other networks found in the given pod annotations
+func MarshalPodAnnotationForNetwork(annotations map[string]string, podInfo *PodAnnotation, nadName string) (map[string]string, error) {
+	podNetworks := make(map[string]podAnnotation)
+	if ovnAnnotation, ok := annotations[OvnPodAnnotationName]; ok {
+		if err := json.Unmarshal([]byte(ovnAnnotation), &podNetworks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ovn pod annotation %q: %v",
+				ovnAnnotation, err)
+		}
+	}
+
 	pa := podAnnotation{
 		MAC: podInfo.MAC.String(),
 	}
@@ -121,9 +141,7 @@ func MarshalPodAnnotation(podInfo *PodAnnotation) (map[string]string, error) {
 		})
 	}
 
-	podNetworks := map[string]podAnnotation{
-		OvnPodDefaultNetwork: pa,
-	}
+	podNetworks[nadName] = pa
 	bytes, err := json.Marshal(podNetworks)
 	if err != nil {
 		klog.Errorf("Failed marshaling podNetworks map %v", podNetworks)
@@ -136,6 +154,13 @@ func MarshalPodAnnotation(podInfo *PodAnnotation) (map[string]string, error) {
 
 // UnmarshalPodAnnotation returns the default network info from pod.Annotations
 func UnmarshalPodAnnotation(annotations map[string]string) (*PodAnnotation, error) {
+	return UnmarshalPodAnnotationForNetwork(annotations, OvnPodDefaultNetwork)
+}
+
+// UnmarshalPodAnnotationForNetwork returns the info of the network nadName,
+// OvnPodDefaultNetwork or the <namespace>/<name> of a NetworkAttachmentDefinition,
+// from pod.Annotations
+func UnmarshalPodAnnotationForNetwork(annotations map[string]string, nadName string) (*PodAnnotation, error) {
 	ovnAnnotation, ok := annotations[OvnPodAnnotationName]
 	if !ok {
 		return nil, newAnnotationNotSetError("could not find OVN pod annotation in %v", annotations)
@@ -146,7 +171,11 @@ func UnmarshalPodAnnotation(annotations map[string]string) (*PodAnnotation, erro
 		return nil, fmt.Errorf("failed to unmarshal ovn pod annotation %q: %v",
 			ovnAnnotation, err)
 	}
-	tempA := podNetworks[OvnPodDefaultNetwork]
+	tempA, ok := podNetworks[nadName]
+	if !ok {
+		return nil, newAnnotationNotSetError("could not find OVN pod annotation for network %s in %v",
+			nadName, annotations)
+	}
 	a := &tempA
 
 	podAnnotation := &PodAnnotation{}
@@ -158,10 +187,13 @@ func UnmarshalPodAnnotation(annotations map[string]string) (*PodAnnotation, erro
 	}
 
 	if len(a.IPs) == 0 {
-		if a.IP == "" {
+		// the secondary networks without IPAM have no IPs
+		if a.IP == "" && nadName == OvnPodDefaultNetwork {
 			return nil, fmt.Errorf("bad annotation data (neither ip_address nor ip_addresses is set)")
 		}
-		a.IPs = append(a.IPs, a.IP)
+		if a.IP != "" {
+			a.IPs = append(a.IPs, a.IP)
+		}
 	} else if a.IP != "" && a.IP != a.IPs[0] {
 		return nil, fmt.Errorf("bad annotation data (ip_address and ip_addresses conflict)")
 	}
@@ -212,6 +212,41 @@ func TestUnmarshalPodAnnotation(t *testing.T) {
 	}
 }
 
+func TestPodAnnotationForNetwork(t *testing.T) {
+	annotations := map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:c0:a8:00:05","gateway_ips":["192.168.0.1"],"ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1"}}`}
+	podInfo := &PodAnnotation{
+		IPs: []*net.IPNet{ovntest.MustParseIPNet("10.1.0.5/24")},
+		MAC: IPAddrToHWAddr(net.ParseIP("10.1.0.5")),
+	}
+
+	_, err := UnmarshalPodAnnotationForNetwork(annotations, "ns1/blue")
+	assert.True(t, IsAnnotationNotSetError(err))
+
+	res, err := MarshalPodAnnotationForNetwork(annotations, podInfo, "ns1/blue")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"ip_addresses":["192.168.0.5/24"],"mac_address":"0a:58:c0:a8:00:05","gateway_ips":["192.168.0.1"],"ip_address":"192.168.0.5/24","gateway_ip":"192.168.0.1"},` +
+		`"ns1/blue":{"ip_addresses":["10.1.0.5/24"],"mac_address":"0a:58:0a:01:00:05","ip_address":"10.1.0.5/24"}}`}, res)
+
+	defaultInfo, err := UnmarshalPodAnnotation(res)
+	assert.Nil(t, err)
+	assert.Equal(t, []*net.IPNet{ovntest.MustParseIPNet("192.168.0.5/24")}, defaultInfo.IPs)
+	blueInfo, err := UnmarshalPodAnnotationForNetwork(res, "ns1/blue")
+	assert.Nil(t, err)
+	assert.Equal(t, podInfo.IPs, blueInfo.IPs)
+	assert.Equal(t, podInfo.MAC, blueInfo.MAC)
+	assert.Empty(t, blueInfo.Gateways)
+
+	// a secondary network without IPAM has no IPs, the default network must have some
+	noIPAMInfo := &PodAnnotation{MAC: IPAddrToHWAddr(net.ParseIP("10.1.0.6"))}
+	res, err = MarshalPodAnnotationForNetwork(res, noIPAMInfo, "ns1/green")
+	assert.Nil(t, err)
+	greenInfo, err := UnmarshalPodAnnotationForNetwork(res, "ns1/green")
+	assert.Nil(t, err)
+	assert.Equal(t, noIPAMInfo, greenInfo)
+	_, err = UnmarshalPodAnnotation(map[string]string{"k8s.ovn.org/pod-networks": `{"default":{"mac_address":"0a:58:0a:01:00:06"}}`})
+	assert.Error(t, err)
+}
+
 func TestGetAllPodIPs(t *testing.T) {
 	tests := []struct {
 		desc      string